@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,13 +12,14 @@ import (
 
 	"github.com/biwakonbu/agent-runner/internal/agenttools"
 	"github.com/biwakonbu/agent-runner/internal/chat"
+	"github.com/biwakonbu/agent-runner/internal/daemon"
 	"github.com/biwakonbu/agent-runner/internal/ide"
+	"github.com/biwakonbu/agent-runner/internal/logging"
 	"github.com/biwakonbu/agent-runner/internal/meta"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator"
-	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/eventbus"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
 	"github.com/biwakonbu/agent-runner/pkg/config"
-	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -35,7 +37,9 @@ type App struct {
 	executionOrchestrator *orchestrator.ExecutionOrchestrator
 	taskExecutor          *orchestrator.Executor
 	backlogStore          *orchestrator.BacklogStore
+	scheduleStore         *orchestrator.ScheduleStore
 	eventEmitter          orchestrator.EventEmitter
+	eventBus              *eventbus.Bus
 }
 
 func safeRuntimeLogErrorf(ctx context.Context, format string, args ...any) {
@@ -68,55 +72,38 @@ func (a *App) startup(ctx context.Context) {
 }
 
 // newMetaClientFromConfig は LLMConfigStore の設定に基づいて Meta クライアントを生成する
-// 優先度:
-// 1. LLMConfigStore の設定（codex-cli, mock 等）
-// 2. 環境変数でのオーバーライド（後方互換性のため）
 func (a *App) newMetaClientFromConfig() chat.MetaClient {
-	config, err := a.llmConfigStore.GetEffectiveConfig()
-	if err != nil {
-		runtime.LogErrorf(a.ctx, "Failed to load LLM config, falling back to default: %v", err)
-		config = ide.DefaultLLMConfig()
-	}
-
-	kind := config.Kind
-	if kind == "" {
-		kind = "openai-chat"
-	}
+	return ide.NewMetaClient(a.llmConfigStore, a.toolingConfigStore, logging.WithComponent(slog.Default(), "app"))
+}
 
-	// agenttools.IsValidToolKind で有効性を検証
-	if !agenttools.IsValidToolKind(kind) {
-		runtime.LogErrorf(a.ctx, "Unknown LLM kind '%s', falling back to openai-chat", kind)
-		kind = "openai-chat"
-	}
+// shutdown closes the event sinks so buffered events reach the log and webhooks.
+func (a *App) shutdown(ctx context.Context) {
+	a.closeEventBus(ctx)
+}
 
-	apiKey, apiKeyErr := a.llmConfigStore.GetAPIKey()
-	if apiKeyErr != nil {
-		runtime.LogWarningf(a.ctx, "Failed to read API key: %v", apiKeyErr)
-		apiKey = os.Getenv("OPENAI_API_KEY")
+// openEventBus replaces the workspace event bus: events go to the frontend
+// (Wails) and to the sinks configured in the workspace (JSONL log, webhooks).
+func (a *App) openEventBus(wsDir string) *eventbus.Bus {
+	a.closeEventBus(a.ctx)
+	sinksConfig, err := eventbus.LoadSinksConfig(wsDir)
+	if err != nil {
+		runtime.LogWarningf(a.ctx, "Failed to load event sinks config, using defaults: %v", err)
 	}
+	a.eventBus = eventbus.NewWorkspaceBus(wsDir, sinksConfig, slog.Default(),
+		eventbus.NewEmitterSink("wails", orchestrator.NewWailsEventEmitter(a.ctx)))
+	return a.eventBus
+}
 
-	// openai-chat は API キー必須。未設定時は codex-cli に自動フォールバックする。
-	if kind == "openai-chat" && apiKey == "" {
-		if _, err := exec.LookPath("codex"); err == nil {
-			runtime.LogInfof(a.ctx, "OPENAI_API_KEY is empty; switching Meta provider from openai-chat to codex-cli")
-			kind = "codex-cli"
-		} else {
-			runtime.LogWarningf(a.ctx, "OPENAI_API_KEY is empty and codex CLI not found; Meta requests will fail")
-		}
+func (a *App) closeEventBus(ctx context.Context) {
+	if a.eventBus == nil {
+		return
 	}
-
-	baseClient := meta.NewClient(kind, apiKey, config.Model, config.SystemPrompt)
-
-	toolingCfg, err := a.toolingConfigStore.Load()
-	if err != nil {
-		runtime.LogErrorf(a.ctx, "Failed to load tooling config: %v", err)
-		toolingCfg = ide.DefaultToolingConfig()
-	}
-	if toolingCfg != nil && len(toolingCfg.Profiles) > 0 {
-		return meta.NewToolingClient(toolingCfg, apiKey, baseClient, config.SystemPrompt)
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := a.eventBus.Close(closeCtx); err != nil {
+		slog.Warn("failed to close event bus", slog.Any("error", err))
 	}
-
-	return baseClient
+	a.eventBus = nil
 }
 
 // SelectWorkspace opens a directory selection dialog and loads the workspace.
@@ -156,56 +143,53 @@ func (a *App) SelectWorkspace() string {
 	a.currentWS = ws
 	a.currentWSID = id
 
-	// Initialize TaskStore (Repo), Scheduler, and ChatHandler for this workspace
+	// Initialize Repo, Scheduler, and ChatHandler for this workspace
 	wsDir := a.workspaceStore.GetWorkspaceDir(id)
 	a.repo = persistence.NewWorkspaceRepository(wsDir)
 	if err := a.repo.Init(); err != nil {
 		runtime.LogErrorf(a.ctx, "Failed to initialize repository: %v", err)
 		return ""
 	}
-	// a.taskStore = orchestrator.NewTaskStore(wsDir) // Removed
-	queue := ipc.NewFilesystemQueue(wsDir)
-
-	// Initialize Execution Environment
-	agentRunnerPath := "agent-runner"
-	if _, err := os.Stat("agent-runner"); err == nil {
-		agentRunnerPath, _ = filepath.Abs("agent-runner")
+	if _, err := orchestrator.MigrateWorkspace(a.repo); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to migrate workspace: %v", err)
+		return ""
 	}
-	executor := orchestrator.NewExecutor(agentRunnerPath, ws.ProjectRoot)
-	a.eventEmitter = orchestrator.NewWailsEventEmitter(a.ctx)
-	executor.SetEventEmitter(a.eventEmitter)
-	a.taskExecutor = executor
+	a.initExecution(wsDir, ws)
+
+	// Initialize ChatHandler with Meta client from LLMConfigStore
+	sessionStore := chat.NewChatSessionStore(wsDir)
+	metaClient := a.newMetaClientFromConfig()
+	a.chatHandler = chat.NewHandler(metaClient, sessionStore, id, ws.ProjectRoot, a.repo, a.eventEmitter)
+	a.executionOrchestrator.SetTaskReplanner(a.chatHandler)
+
+	return id
+}
+
+// initExecution はワークスペースのイベントバス・Executor・Scheduler・ExecutionOrchestrator を組み立てる
+// IDE はプロセスを分離するため、タスクは agent-runner のサブプロセスで実行する。
+func (a *App) initExecution(wsDir string, ws *ide.Workspace) {
+	a.eventEmitter = a.openEventBus(wsDir)
 
 	toolingCfg, err := a.toolingConfigStore.Load()
 	if err != nil {
 		runtime.LogErrorf(a.ctx, "Failed to load tooling config: %v", err)
 		toolingCfg = ide.DefaultToolingConfig()
 	}
-	executor.SetToolingConfig(toolingCfg)
-
-	a.scheduler = orchestrator.NewScheduler(a.repo, queue, a.eventEmitter)
 
-	// Initialize BacklogStore (before ExecutionOrchestrator)
-	a.backlogStore = orchestrator.NewBacklogStore(wsDir)
-
-	a.executionOrchestrator = orchestrator.NewExecutionOrchestrator(
-		a.scheduler,
-		executor,
-		a.repo,
-		queue,
-		a.eventEmitter,
-		a.backlogStore,
-		[]string{"default", "codegen", "test"},
-	)
-
-	// Initialize ChatHandler with Meta client from LLMConfigStore
-	sessionStore := chat.NewChatSessionStore(wsDir)
-	metaClient := a.newMetaClientFromConfig()
-	// ChatHandler の互換のため TaskStore を引き続き生成（design/state との同期は Handler 内で行う）
-	taskStore := orchestrator.NewTaskStore(wsDir)
-	a.chatHandler = chat.NewHandler(metaClient, taskStore, sessionStore, id, ws.ProjectRoot, a.repo, a.eventEmitter)
-
-	return id
+	execution := orchestrator.NewFromWorkspace(a.repo, orchestrator.WorkspaceOptions{
+		ProjectRoot:   ws.ProjectRoot,
+		ExecutorMode:  orchestrator.ExecutorModeSubprocess,
+		Events:        a.eventEmitter,
+		ToolingConfig: toolingCfg,
+		LeaderRole:    persistence.LeaderRoleIDE,
+		StartupFsck:   &orchestrator.FsckOptions{Repair: true},
+		Warn:          func(err error) { runtime.LogErrorf(a.ctx, "%v", err) },
+	})
+	a.taskExecutor = execution.Executor
+	a.scheduler = execution.Scheduler
+	a.backlogStore = execution.BacklogStore
+	a.executionOrchestrator = execution.Orchestrator
+	a.scheduleStore = orchestrator.NewScheduleStore(wsDir)
 }
 
 // GetWorkspace returns the workspace details.
@@ -245,54 +229,24 @@ func (a *App) OpenWorkspaceByID(id string) string {
 	a.currentWS = ws
 	a.currentWSID = id
 
-	// Initialize TaskStore, Scheduler, and ChatHandler for this workspace
+	// Initialize Repo, Scheduler, and ChatHandler for this workspace
 	wsDir := a.workspaceStore.GetWorkspaceDir(id)
 	a.repo = persistence.NewWorkspaceRepository(wsDir) // Initialize repo here
 	if err := a.repo.Init(); err != nil {
 		runtime.LogErrorf(a.ctx, "Failed to initialize repository: %v", err)
 		return ""
 	}
-	// a.taskStore = orchestrator.NewTaskStore(wsDir) // Removed
-	queue := ipc.NewFilesystemQueue(wsDir)
-
-	// Initialize Execution Environment
-	agentRunnerPath := "agent-runner"
-	if _, err := os.Stat("agent-runner"); err == nil {
-		agentRunnerPath, _ = filepath.Abs("agent-runner")
-	}
-	executor := orchestrator.NewExecutor(agentRunnerPath, ws.ProjectRoot) // Removed a.taskStore from here
-	a.eventEmitter = orchestrator.NewWailsEventEmitter(a.ctx)
-	executor.SetEventEmitter(a.eventEmitter)
-	a.taskExecutor = executor
-
-	toolingCfg, err := a.toolingConfigStore.Load()
-	if err != nil {
-		runtime.LogErrorf(a.ctx, "Failed to load tooling config: %v", err)
-		toolingCfg = ide.DefaultToolingConfig()
+	if _, err := orchestrator.MigrateWorkspace(a.repo); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to migrate workspace: %v", err)
+		return ""
 	}
-	executor.SetToolingConfig(toolingCfg)
-
-	a.scheduler = orchestrator.NewScheduler(a.repo, queue, a.eventEmitter) // Use a.repo here
-
-	// Initialize BacklogStore (ExecutionOrchestrator depends on it)
-	a.backlogStore = orchestrator.NewBacklogStore(wsDir)
-
-	a.executionOrchestrator = orchestrator.NewExecutionOrchestrator(
-		a.scheduler,
-		executor,
-		a.repo,
-		queue,
-		a.eventEmitter,
-		a.backlogStore,
-		[]string{"default", "codegen", "test"},
-	)
+	a.initExecution(wsDir, ws)
 
 	// Initialize ChatHandler with Meta client from LLMConfigStore
 	sessionStore := chat.NewChatSessionStore(wsDir)
 	metaClient := a.newMetaClientFromConfig()
-	// Temporary taskStore instance for ChatHandler
-	taskStore := orchestrator.NewTaskStore(wsDir)
-	a.chatHandler = chat.NewHandler(metaClient, taskStore, sessionStore, id, ws.ProjectRoot, a.repo, a.eventEmitter)
+	a.chatHandler = chat.NewHandler(metaClient, sessionStore, id, ws.ProjectRoot, a.repo, a.eventEmitter)
+	a.executionOrchestrator.SetTaskReplanner(a.chatHandler)
 
 	return id
 }
@@ -308,148 +262,11 @@ func (a *App) ListTasks() []orchestrator.Task {
 		return []orchestrator.Task{}
 	}
 
-	tasksState, err := a.repo.State().LoadTasks()
+	tasks, err := orchestrator.ListTaskViews(a.repo)
 	if err != nil {
 		runtime.LogErrorf(a.ctx, "Failed to load tasks: %v", err)
 		return []orchestrator.Task{}
 	}
-
-	// Load WBS for ordering / parent info (best-effort).
-	var wbs *persistence.WBS
-	parentByID := map[string]*string{}
-	childrenByID := map[string][]string{}
-	if loaded, err := a.repo.Design().LoadWBS(); err == nil && loaded != nil {
-		wbs = loaded
-		for i := range wbs.NodeIndex {
-			n := wbs.NodeIndex[i]
-			parentByID[n.NodeID] = n.ParentID
-			childrenByID[n.NodeID] = n.Children
-		}
-	}
-
-	// task_id -> TaskState (preserve original order for fallback).
-	taskStateByID := make(map[string]persistence.TaskState, len(tasksState.Tasks))
-	originalOrder := make([]string, 0, len(tasksState.Tasks))
-	for _, t := range tasksState.Tasks {
-		taskStateByID[t.TaskID] = t
-		originalOrder = append(originalOrder, t.TaskID)
-	}
-
-	// Determine ordered task IDs (WBS DFS order -> fallback).
-	orderedTaskIDs := make([]string, 0, len(tasksState.Tasks))
-	seen := make(map[string]struct{}, len(tasksState.Tasks))
-	if wbs != nil && wbs.RootNodeID != "" {
-		var stack []string
-		stack = append(stack, wbs.RootNodeID)
-		for len(stack) > 0 {
-			// pop
-			n := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-
-			if _, ok := taskStateByID[n]; ok {
-				if _, dup := seen[n]; !dup {
-					seen[n] = struct{}{}
-					orderedTaskIDs = append(orderedTaskIDs, n)
-				}
-			}
-
-			children := childrenByID[n]
-			// push reversed for stable order
-			for i := len(children) - 1; i >= 0; i-- {
-				stack = append(stack, children[i])
-			}
-		}
-	}
-	for _, id := range originalOrder {
-		if _, ok := seen[id]; ok {
-			continue
-		}
-		seen[id] = struct{}{}
-		orderedTaskIDs = append(orderedTaskIDs, id)
-	}
-
-	// NodeDesign cache to minimize disk access.
-	nodeCache := make(map[string]*persistence.NodeDesign)
-	loadNode := func(nodeID string) *persistence.NodeDesign {
-		if nodeID == "" {
-			return nil
-		}
-		if cached, ok := nodeCache[nodeID]; ok {
-			return cached
-		}
-		node, err := a.repo.Design().GetNode(nodeID)
-		if err != nil {
-			nodeCache[nodeID] = nil
-			return nil
-		}
-		nodeCache[nodeID] = node
-		return node
-	}
-
-	tasks := make([]orchestrator.Task, 0, len(orderedTaskIDs))
-	for _, id := range orderedTaskIDs {
-		ts, ok := taskStateByID[id]
-		if !ok {
-			continue
-		}
-
-		title := ""
-		poolID := "default"
-		if ts.Inputs != nil {
-			if raw, ok := ts.Inputs["title"]; ok {
-				if s, ok := raw.(string); ok && s != "" {
-					title = s
-				}
-			}
-			if raw, ok := ts.Inputs["pool_id"]; ok {
-				if s, ok := raw.(string); ok && s != "" {
-					poolID = s
-				}
-			}
-		}
-
-		node := loadNode(ts.NodeID)
-		if title == "" {
-			if node != nil && node.Name != "" {
-				title = node.Name
-			} else if ts.NodeID != "" {
-				title = ts.Kind + ": " + ts.NodeID
-			} else {
-				title = ts.Kind + ": " + ts.TaskID
-			}
-		}
-
-		task := orchestrator.Task{
-			ID:        ts.TaskID,
-			Title:     title,
-			Status:    orchestrator.TaskStatus(ts.Status),
-			PoolID:    poolID,
-			CreatedAt: ts.CreatedAt,
-			UpdatedAt: ts.UpdatedAt,
-		}
-
-		// Best-effort enrich from NodeDesign.
-		if node != nil {
-			task.Description = node.Summary
-			task.Dependencies = append([]string{}, node.Dependencies...)
-			task.WBSLevel = node.WBSLevel
-			task.PhaseName = node.PhaseName
-			task.Milestone = node.Milestone
-			task.AcceptanceCriteria = append([]string{}, node.AcceptanceCriteria...)
-			task.SuggestedImpl = &orchestrator.SuggestedImpl{
-				Language:    node.SuggestedImpl.Language,
-				FilePaths:   append([]string{}, node.SuggestedImpl.FilePaths...),
-				Constraints: append([]string{}, node.SuggestedImpl.Constraints...),
-			}
-		}
-
-		if p, ok := parentByID[task.ID]; ok {
-			task.ParentID = p
-		}
-
-		tasks = append(tasks, task)
-	}
-
 	return tasks
 }
 
@@ -459,46 +276,12 @@ func (a *App) CreateTask(title string, poolID string) *orchestrator.Task {
 		return nil
 	}
 
-	// NOTE: CreateTask in V2 should go through WBS/Node creation properly via Planner.
-	// Direct task creation is technical debt or for simple tasks.
-	// We'll map it to a generic task node or just create a task in tasks.json without node?
-	// The schema expects NodeID.
-	// For "manual" task, maybe create a "manual-node" or similar.
-	// This is tricky. I'll just skip implementation or create a dummy node?
-	// Or create a task with NodeID="manual".
-
-	tasksState, err := a.repo.State().LoadTasks()
+	task, err := orchestrator.CreateManualTask(a.repo, title, poolID)
 	if err != nil {
-		runtime.LogErrorf(a.ctx, "Failed to load tasks: %v", err)
-		return nil
-	}
-
-	taskID := uuid.New().String()
-	now := time.Now()
-
-	newState := persistence.TaskState{
-		TaskID:    taskID,
-		NodeID:    "manual-" + taskID, // Dummy
-		Kind:      "manual",
-		Status:    string(orchestrator.TaskStatusPending),
-		CreatedAt: now,
-		UpdatedAt: now,
-		Inputs:    map[string]interface{}{"title": title, "pool_id": poolID},
-	}
-	tasksState.Tasks = append(tasksState.Tasks, newState)
-
-	if err := a.repo.State().SaveTasks(tasksState); err != nil {
 		runtime.LogErrorf(a.ctx, "Failed to save tasks: %v", err)
 		return nil
 	}
-
-	return &orchestrator.Task{
-		ID:        taskID,
-		Title:     title,
-		Status:    orchestrator.TaskStatusPending,
-		PoolID:    poolID,
-		CreatedAt: now,
-	}
+	return task
 }
 
 // RunTask schedules a task for execution.
@@ -511,20 +294,88 @@ func (a *App) RunTask(taskID string) error {
 
 // ListAttempts returns all attempts for a given task.
 func (a *App) ListAttempts(taskID string) []orchestrator.Attempt {
-	// Not supported in new persistence yet. Return empty.
-	return []orchestrator.Attempt{}
+	if a.repo == nil {
+		return []orchestrator.Attempt{}
+	}
+	attempts, err := orchestrator.ListTaskAttempts(a.repo, taskID)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to list attempts: %v", err)
+		return []orchestrator.Attempt{}
+	}
+	return attempts
+}
+
+// GetAttemptLog returns up to limit persisted stdout/stderr lines of an attempt, starting at line from.
+// 実行中の試行は Next を次の from に渡して追従する（Complete になるまで）。
+func (a *App) GetAttemptLog(attemptID string, from int, limit int) (*persistence.AttemptLogPage, error) {
+	if a.repo == nil {
+		return nil, fmt.Errorf("workspace not selected")
+	}
+	return orchestrator.ReadAttemptLog(a.repo, attemptID, from, limit)
+}
+
+// GetDependencyAnalysis returns the execution order, cycles, critical path and failure impact of the dependency graph.
+func (a *App) GetDependencyAnalysis() (*orchestrator.DependencyAnalysis, error) {
+	if a.repo == nil {
+		return nil, fmt.Errorf("workspace not selected")
+	}
+	return orchestrator.AnalyzeDependencies(a.repo)
 }
 
-// GetPoolSummaries returns task count summaries by pool.
-func (a *App) GetPoolSummaries() []orchestrator.PoolSummary {
-	// Not supported fully yet (Repo logic missing for stats)
-	return []orchestrator.PoolSummary{}
-	// Implementation would read tasks.json and aggregate.
+// ListStaleNodes returns nodes flagged for re-verification after an upstream change, with the reason.
+func (a *App) ListStaleNodes() ([]orchestrator.NodeImpact, error) {
+	if a.repo == nil {
+		return nil, fmt.Errorf("workspace not selected")
+	}
+	return orchestrator.ListStaleNodes(a.repo)
+}
+
+// ReverifyNode schedules a verification task for a node flagged for re-verification and returns its task ID.
+// An empty ID means a verification task for the node is already pending.
+func (a *App) ReverifyNode(nodeID string) (string, error) {
+	if a.repo == nil {
+		return "", fmt.Errorf("workspace not selected")
+	}
+	return orchestrator.ScheduleReverification(a.repo, nodeID)
+}
+
+// GetProgressReport returns milestone / phase progress, pool summaries and burndown data.
+// windowDays is the throughput window (0 uses the default).
+func (a *App) GetProgressReport(windowDays int) *orchestrator.ProgressReport {
+	if a.repo == nil {
+		return nil
+	}
+	report, err := orchestrator.BuildProgressReport(a.repo, time.Now(), windowDays)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to build progress report: %v", err)
+		return nil
+	}
+	return report
+}
+
+// GetCalibrationReport returns estimate-versus-actual figures of completed nodes by kind and difficulty.
+func (a *App) GetCalibrationReport() *orchestrator.CalibrationReport {
+	if a.repo == nil {
+		return nil
+	}
+	report, err := orchestrator.BuildCalibrationReport(a.repo, time.Now())
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to build calibration report: %v", err)
+		return nil
+	}
+	return report
 }
 
 // GetAvailablePools returns the list of available worker pools.
 func (a *App) GetAvailablePools() []orchestrator.Pool {
-	return orchestrator.DefaultPools
+	if a.repo == nil {
+		return orchestrator.DefaultPools
+	}
+	cfg, err := orchestrator.LoadWorkerPoolsConfig(a.repo.BaseDir())
+	if err != nil {
+		safeRuntimeLogErrorf(a.ctx, "Failed to load worker pools config: %v", err)
+	}
+	return cfg.Pools
 }
 
 // ============================================================================
@@ -624,11 +475,63 @@ func (a *App) GetChatHistory(sessionID string) []chat.ChatMessage {
 // Execution Control API
 // ============================================================================
 
+// ExecutionOwnerDTO は実行ループを所有しているオーケストレータの情報
+type ExecutionOwnerDTO struct {
+	Role        string `json:"role"`
+	PID         int    `json:"pid"`
+	Hostname    string `json:"hostname"`
+	State       string `json:"state"`
+	HeartbeatAt string `json:"heartbeatAt"`
+	External    bool   `json:"external"`
+}
+
+// externalLeader は別プロセス（multiverse-orchestrator デーモン等）が
+// このワークスペースの実行ループを所有していればその情報を返す
+func (a *App) externalLeader() (*persistence.LeaderInfo, bool) {
+	if a.repo == nil {
+		return nil, false
+	}
+	info, ok := persistence.ActiveLeader(a.repo.BaseDir(), persistence.DefaultLeaderStaleAfter)
+	if !ok {
+		return nil, false
+	}
+	if a.executionOrchestrator != nil && a.executionOrchestrator.Leader != nil &&
+		info.InstanceID == a.executionOrchestrator.Leader.Info().InstanceID {
+		return nil, false
+	}
+	return info, true
+}
+
+// errExternalLeader は外部オーケストレータ稼働中に IDE から操作できないことを示すエラーを返す
+func errExternalLeader(info *persistence.LeaderInfo) error {
+	return fmt.Errorf("execution is managed by an external orchestrator (%s)", info.String())
+}
+
+// forwardExecution は外部デーモンの API に実行制御（pause / resume / stop）を転送する
+// API が公開されていない（-no-api で起動された等）場合は errExternalLeader を返す。
+func (a *App) forwardExecution(info *persistence.LeaderInfo, action string) error {
+	client, err := daemon.DiscoverClient(a.repo.BaseDir())
+	if err != nil {
+		return errExternalLeader(info)
+	}
+	ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+	defer cancel()
+	if _, err := client.Execution(ctx, action); err != nil {
+		return fmt.Errorf("failed to %s external orchestrator: %w", action, err)
+	}
+	return nil
+}
+
 // StartExecution starts the autonomous execution loop.
+// 外部デーモンが稼働中の場合は自前のループを起動せず、デーモンにキュー消費を任せる。
 func (a *App) StartExecution() error {
 	if a.executionOrchestrator == nil {
 		return fmt.Errorf("execution orchestrator not initialized")
 	}
+	if info, ok := a.externalLeader(); ok {
+		runtime.LogInfof(a.ctx, "External orchestrator is running (%s); not starting embedded loop", info.String())
+		return nil
+	}
 	return a.executionOrchestrator.Start(a.ctx)
 }
 
@@ -637,6 +540,9 @@ func (a *App) PauseExecution() error {
 	if a.executionOrchestrator == nil {
 		return fmt.Errorf("execution orchestrator not initialized")
 	}
+	if info, ok := a.externalLeader(); ok {
+		return a.forwardExecution(info, "pause")
+	}
 	return a.executionOrchestrator.Pause()
 }
 
@@ -645,6 +551,9 @@ func (a *App) ResumeExecution() error {
 	if a.executionOrchestrator == nil {
 		return fmt.Errorf("execution orchestrator not initialized")
 	}
+	if info, ok := a.externalLeader(); ok {
+		return a.forwardExecution(info, "resume")
+	}
 	return a.executionOrchestrator.Resume()
 }
 
@@ -653,17 +562,103 @@ func (a *App) StopExecution() error {
 	if a.executionOrchestrator == nil {
 		return fmt.Errorf("execution orchestrator not initialized")
 	}
+	if info, ok := a.externalLeader(); ok {
+		return a.forwardExecution(info, "stop")
+	}
 	return a.executionOrchestrator.Stop()
 }
 
 // GetExecutionState returns the current execution state.
+// 外部デーモンが稼働中の場合はデーモンが記録した状態を返す。
 func (a *App) GetExecutionState() string {
+	if info, ok := a.externalLeader(); ok {
+		if info.State == "" {
+			return string(orchestrator.ExecutionStateRunning)
+		}
+		return info.State
+	}
 	if a.executionOrchestrator == nil {
 		return "IDLE"
 	}
 	return string(a.executionOrchestrator.State())
 }
 
+// GetExecutionOwner returns the orchestrator that currently owns the workspace queue (nil if none).
+func (a *App) GetExecutionOwner() *ExecutionOwnerDTO {
+	if a.repo == nil {
+		return nil
+	}
+	info, ok := persistence.ActiveLeader(a.repo.BaseDir(), persistence.DefaultLeaderStaleAfter)
+	if !ok {
+		return nil
+	}
+	_, external := a.externalLeader()
+	return &ExecutionOwnerDTO{
+		Role:        info.Role,
+		PID:         info.PID,
+		Hostname:    info.Hostname,
+		State:       info.State,
+		HeartbeatAt: info.HeartbeatAt.Format(time.RFC3339),
+		External:    external,
+	}
+}
+
+// ============================================================================
+// Snapshot API
+// ============================================================================
+
+// ListSnapshots returns the workspace snapshots (newest first, safety backups excluded).
+func (a *App) ListSnapshots() []persistence.Snapshot {
+	if a.repo == nil {
+		return []persistence.Snapshot{}
+	}
+	snaps, err := a.repo.Snapshot().ListSnapshots()
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to list snapshots: %v", err)
+		return []persistence.Snapshot{}
+	}
+	if snaps == nil {
+		return []persistence.Snapshot{}
+	}
+	return snaps
+}
+
+// CreateSnapshot takes a manual snapshot of design, state, tasks and backlog.
+func (a *App) CreateSnapshot(description string) (*persistence.Snapshot, error) {
+	if a.repo == nil {
+		return nil, fmt.Errorf("workspace not selected")
+	}
+	return a.repo.Snapshot().CreateSnapshot(description)
+}
+
+// DiffSnapshots returns the tasks / nodes / backlog added, removed or changed between two snapshots.
+// toID に "current" を指定すると現在のワークスペースと比較する。
+func (a *App) DiffSnapshots(fromID, toID string) (*persistence.SnapshotDiff, error) {
+	if a.repo == nil {
+		return nil, fmt.Errorf("workspace not selected")
+	}
+	return a.repo.Snapshot().DiffSnapshots(fromID, toID)
+}
+
+// RestoreSnapshot replaces the workspace design / state / tasks / backlog with a snapshot.
+// 実行ループがファイルを書き換えている最中に置き換えないよう、実行中はリストアを拒否する。
+func (a *App) RestoreSnapshot(snapshotID string) error {
+	if a.repo == nil {
+		return fmt.Errorf("workspace not selected")
+	}
+	if info, ok := a.externalLeader(); ok {
+		return errExternalLeader(info)
+	}
+	if a.executionOrchestrator != nil && a.executionOrchestrator.State() != orchestrator.ExecutionStateIdle {
+		return fmt.Errorf("stop execution before restoring a snapshot (state: %s)", a.executionOrchestrator.State())
+	}
+	if err := a.repo.Snapshot().RestoreSnapshot(snapshotID); err != nil {
+		return err
+	}
+	runtime.LogInfof(a.ctx, "Restored snapshot %s", snapshotID)
+	return nil
+}
+
 // ============================================================================
 // Backlog API
 // ============================================================================
@@ -696,12 +691,31 @@ func (a *App) GetAllBacklogItems() []orchestrator.BacklogItem {
 	return items
 }
 
-// ResolveBacklogItem marks a backlog item as resolved.
-func (a *App) ResolveBacklogItem(id string, resolution string) error {
+// ListPendingApprovals returns unresolved approval gates (APPROVAL backlog items).
+// Approve or reject them with ResolveBacklogItem.
+func (a *App) ListPendingApprovals() []orchestrator.BacklogItem {
 	if a.backlogStore == nil {
-		return fmt.Errorf("backlog store not initialized")
+		return []orchestrator.BacklogItem{}
+	}
+
+	items, err := a.backlogStore.ListPendingApprovals()
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to list pending approvals: %v", err)
+		return []orchestrator.BacklogItem{}
 	}
-	return a.backlogStore.Resolve(id, resolution)
+	return items
+}
+
+// ResolveBacklogItem resolves a backlog item and applies the resolution action to its task.
+func (a *App) ResolveBacklogItem(id string, resolution orchestrator.BacklogResolution) (*orchestrator.BacklogResolutionResult, error) {
+	if a.backlogStore == nil || a.repo == nil {
+		return nil, fmt.Errorf("backlog store not initialized")
+	}
+	resolver := orchestrator.NewBacklogResolver(a.repo, a.backlogStore, a.eventEmitter)
+	if a.chatHandler != nil {
+		resolver.SetTaskSplitter(a.chatHandler)
+	}
+	return resolver.Resolve(a.ctx, id, resolution)
 }
 
 // DeleteBacklogItem deletes a backlog item.
@@ -712,6 +726,97 @@ func (a *App) DeleteBacklogItem(id string) error {
 	return a.backlogStore.Delete(id)
 }
 
+// ============================================================================
+// Schedule API
+// ============================================================================
+
+// ListTaskSchedules returns all scheduled/recurring task definitions.
+func (a *App) ListTaskSchedules() []orchestrator.TaskSchedule {
+	if a.scheduleStore == nil {
+		return []orchestrator.TaskSchedule{}
+	}
+
+	schedules, err := a.scheduleStore.List()
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to list schedules: %v", err)
+		return []orchestrator.TaskSchedule{}
+	}
+	return schedules
+}
+
+// SaveTaskSchedule creates (empty ID) or updates a scheduled task definition and returns it with its next run time.
+func (a *App) SaveTaskSchedule(schedule orchestrator.TaskSchedule) (*orchestrator.TaskSchedule, error) {
+	if a.scheduleStore == nil {
+		return nil, fmt.Errorf("schedule store not initialized")
+	}
+	if err := a.scheduleStore.Save(&schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// SetTaskScheduleEnabled enables or disables a scheduled task definition.
+func (a *App) SetTaskScheduleEnabled(id string, enabled bool) (*orchestrator.TaskSchedule, error) {
+	if a.scheduleStore == nil {
+		return nil, fmt.Errorf("schedule store not initialized")
+	}
+	return a.scheduleStore.SetEnabled(id, enabled)
+}
+
+// DeleteTaskSchedule deletes a scheduled task definition. Tasks already created from it are kept.
+func (a *App) DeleteTaskSchedule(id string) error {
+	if a.scheduleStore == nil {
+		return fmt.Errorf("schedule store not initialized")
+	}
+	return a.scheduleStore.Delete(id)
+}
+
+// ============================================================================
+// Agent API
+// ============================================================================
+
+// ListAgents returns the agents registered for capability-based scheduling.
+func (a *App) ListAgents() []persistence.AgentState {
+	if a.repo == nil {
+		return []persistence.AgentState{}
+	}
+
+	agents, err := orchestrator.ListAgents(a.repo)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to list agents: %v", err)
+		return []persistence.AgentState{}
+	}
+	return agents
+}
+
+// RegisterAgent registers an agent, or updates its kind, parallelism and capabilities if it already exists.
+func (a *App) RegisterAgent(agent persistence.AgentState) error {
+	if a.repo == nil {
+		return fmt.Errorf("workspace not selected")
+	}
+	return orchestrator.RegisterAgent(a.repo, agent)
+}
+
+// UnregisterAgent removes an agent. Agents with running tasks cannot be removed.
+func (a *App) UnregisterAgent(agentID string) error {
+	if a.repo == nil {
+		return fmt.Errorf("workspace not selected")
+	}
+	return orchestrator.UnregisterAgent(a.repo, agentID)
+}
+
+// CheckAgentsHealth runs the health check of every agent and returns the agents with the results.
+func (a *App) CheckAgentsHealth() ([]persistence.AgentState, error) {
+	if a.repo == nil || a.taskExecutor == nil {
+		return nil, fmt.Errorf("workspace not selected")
+	}
+	check := orchestrator.AgentRunnerHealthCheck(a.taskExecutor.AgentRunnerPath)
+	if err := orchestrator.CheckAgentsHealth(a.ctx, a.repo, check); err != nil {
+		return nil, err
+	}
+	return orchestrator.ListAgents(a.repo)
+}
+
 // ============================================================================
 // LLM Config API
 // ============================================================================
@@ -759,8 +864,7 @@ func (a *App) SetLLMConfig(dto LLMConfigDTO) error {
 		wsDir := a.workspaceStore.GetWorkspaceDir(a.currentWSID)
 		sessionStore := chat.NewChatSessionStore(wsDir)
 		metaClient := a.newMetaClientFromConfig()
-		taskStore := orchestrator.NewTaskStore(wsDir) // Temp
-		a.chatHandler = chat.NewHandler(metaClient, taskStore, sessionStore, a.currentWSID, a.currentWS.ProjectRoot, a.repo, a.eventEmitter)
+		a.chatHandler = chat.NewHandler(metaClient, sessionStore, a.currentWSID, a.currentWS.ProjectRoot, a.repo, a.eventEmitter)
 	}
 
 	return nil
@@ -805,8 +909,7 @@ func (a *App) SetToolingConfigJSON(raw string) error {
 		wsDir := a.workspaceStore.GetWorkspaceDir(a.currentWSID)
 		sessionStore := chat.NewChatSessionStore(wsDir)
 		metaClient := a.newMetaClientFromConfig()
-		taskStore := orchestrator.NewTaskStore(wsDir)
-		a.chatHandler = chat.NewHandler(metaClient, taskStore, sessionStore, a.currentWSID, a.currentWS.ProjectRoot, a.repo, a.eventEmitter)
+		a.chatHandler = chat.NewHandler(metaClient, sessionStore, a.currentWSID, a.currentWS.ProjectRoot, a.repo, a.eventEmitter)
 	}
 
 	return nil
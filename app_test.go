@@ -53,11 +53,53 @@ func TestGetAvailablePools_WithRepo(t *testing.T) {
 	}
 }
 
-func TestGetPoolSummaries_Stubbed(t *testing.T) {
+func TestGetProgressReport_WithoutRepo(t *testing.T) {
 	app := NewApp()
-	summaries := app.GetPoolSummaries()
-	if len(summaries) != 0 {
-		t.Errorf("expected 0 summaries (stubbed), got %d", len(summaries))
+	if report := app.GetProgressReport(0); report != nil {
+		t.Errorf("expected nil report without workspace, got %+v", report)
+	}
+}
+
+func TestGetCalibrationReport_WithoutRepo(t *testing.T) {
+	app := NewApp()
+	if report := app.GetCalibrationReport(); report != nil {
+		t.Errorf("expected nil report without workspace, got %+v", report)
+	}
+}
+
+func TestAgents_WithRepo(t *testing.T) {
+	tmpDir := t.TempDir()
+	app := NewApp()
+	if got := app.ListAgents(); len(got) != 0 {
+		t.Errorf("expected no agents without workspace, got %+v", got)
+	}
+	app.repo = persistence.NewWorkspaceRepository(tmpDir)
+	if err := app.repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+	app.taskExecutor = orchestrator.NewExecutor("/nonexistent/agent-runner", tmpDir)
+
+	if err := app.RegisterAgent(persistence.AgentState{AgentID: "codex", Kind: "implementation", Capabilities: []string{"lang:go"}}); err != nil {
+		t.Fatalf("RegisterAgent failed: %v", err)
+	}
+	agents := app.ListAgents()
+	if len(agents) != 1 || agents[0].AgentID != "codex" || agents[0].MaxParallel != 1 {
+		t.Fatalf("unexpected agents: %+v", agents)
+	}
+
+	agents, err := app.CheckAgentsHealth()
+	if err != nil {
+		t.Fatalf("CheckAgentsHealth failed: %v", err)
+	}
+	if len(agents) != 1 || agents[0].Health != string(persistence.AgentHealthUnhealthy) {
+		t.Errorf("agent must be unhealthy without agent-runner, got %+v", agents)
+	}
+
+	if err := app.UnregisterAgent("codex"); err != nil {
+		t.Fatalf("UnregisterAgent failed: %v", err)
+	}
+	if got := app.ListAgents(); len(got) != 0 {
+		t.Errorf("expected no agents after unregister, got %+v", got)
 	}
 }
 
@@ -191,24 +233,18 @@ func TestGetWorkspace_Found(t *testing.T) {
 }
 
 func TestSendChatMessage(t *testing.T) {
-	// Need legacy TaskStore for ChatHandler until ChatHandler is refactored
 	tmpDir := t.TempDir()
 
-	taskStore := orchestrator.NewTaskStore(tmpDir)
+	repo := persistence.NewWorkspaceRepository(tmpDir)
 	sessionStore := chat.NewChatSessionStore(tmpDir)
 	metaClient := meta.NewMockClient()
 
-	// Init Repo for App context (because SendChatMessage might trigger app logic requiring repo?
-	// Actually SendChatMessage calls handler.HandleMessage.
-	// app.SendChatMessage calls a.chatHandler.HandleMessage.
-
-	handler := chat.NewHandler(metaClient, taskStore, sessionStore, "test-ws-id", tmpDir, nil, nil)
+	handler := chat.NewHandler(metaClient, sessionStore, "test-ws-id", tmpDir, repo, nil)
 
 	app := NewApp()
 	app.chatHandler = handler
-	app.repo = persistence.NewWorkspaceRepository(tmpDir)
+	app.repo = repo
 	_ = app.repo.Init()
-	// app.taskStore is gone.
 	app.ctx = context.Background()
 
 	session := app.CreateChatSession()
@@ -227,21 +263,17 @@ func TestSendChatMessage(t *testing.T) {
 }
 
 func TestSendChatMessage_PersistsTasksAndDependencies(t *testing.T) {
-	t.Skip("Skipping legacy persistence check until ChatHandler is fully migrated to Repo")
-	// This test relies on verifying TaskStore files which ChatHandler still writes,
-	// but App doesn't expose TaskStore anymore to verify easily.
-	// We can manually verify using taskStore created locally.
-
 	tmpDir := t.TempDir()
 
-	taskStore := orchestrator.NewTaskStore(tmpDir)
+	repo := persistence.NewWorkspaceRepository(tmpDir)
 	sessionStore := chat.NewChatSessionStore(tmpDir)
 	metaClient := meta.NewMockClient()
 
-	handler := chat.NewHandler(metaClient, taskStore, sessionStore, "ws-id", tmpDir, nil, nil)
+	handler := chat.NewHandler(metaClient, sessionStore, "ws-id", tmpDir, repo, nil)
 
 	app := NewApp()
 	app.chatHandler = handler
+	app.repo = repo
 	app.ctx = context.Background()
 
 	session := app.CreateChatSession()
@@ -251,12 +283,15 @@ func TestSendChatMessage_PersistsTasksAndDependencies(t *testing.T) {
 		t.Fatalf("Error: %s", resp.Error)
 	}
 
-	// Verify using legacy taskStore
-	allTasks, err := taskStore.ListAllTasks()
-	if err != nil {
-		t.Fatalf("failed to list tasks: %v", err)
-	}
+	allTasks := app.ListTasks()
 	if len(allTasks) != len(resp.GeneratedTasks) {
 		t.Errorf("expected %d tasks, got %d", len(resp.GeneratedTasks), len(allTasks))
 	}
+	report := app.GetProgressReport(0)
+	if report == nil || len(report.Pools) == 0 || report.Pools[0].Total != len(allTasks) {
+		t.Fatalf("expected pool summary for %d tasks, got %+v", len(allTasks), report)
+	}
+	if report.Total.TotalTasks != len(allTasks) || report.Total.CompletedTasks != 0 {
+		t.Errorf("expected %d open tasks in progress total, got %+v", len(allTasks), report.Total)
+	}
 }
@@ -24,7 +24,12 @@ func main() {
 	slog.SetDefault(logger)
 
 	if err := Run(context.Background(), os.Stdin, os.Stdout, os.Stderr, logger); err != nil {
-		slog.Error("application failed", "err", err)
+		// The orchestrator classifies the failure by error_kind to choose a retry policy.
+		slog.Error("application failed",
+			"event_type", "runner:failed",
+			"error_kind", string(core.ErrorKindOf(err)),
+			"err", err,
+		)
 		os.Exit(1)
 	}
 }
@@ -82,5 +87,5 @@ func Run(ctx context.Context, stdin io.Reader, _, _ io.Writer, logger *slog.Logg
 	}
 
 	logger.Info("task completed", "state", result.State)
-	return nil
+	return core.OutcomeError(result)
 }
@@ -2,15 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/biwakonbu/agent-runner/internal/chat"
+	"github.com/biwakonbu/agent-runner/internal/daemon"
+	"github.com/biwakonbu/agent-runner/internal/ide"
+	"github.com/biwakonbu/agent-runner/internal/logging"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator"
-	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/eventbus"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
 )
 
@@ -18,9 +27,25 @@ func main() {
 	// Parse flags
 	workspaceDir := flag.String("workspace", filepath.Join(os.Getenv("HOME"), ".multiverse"), "Path to multiverse workspace directory")
 	agentRunnerPath := flag.String("agent-runner", "agent-runner", "Path to agent-runner binary")
-	poolID := flag.String("pool", "default", "Queue Pool ID to consume from")
+	executorFlag := flag.String("executor", string(orchestrator.ExecutorModeSubprocess), "Task executor: subprocess (spawn agent-runner per task) or in-process")
+	poolFlag := flag.String("pool", "", "Comma-separated Queue Pool IDs to consume from (default: all pools in worker-pools.json)")
+	listenAddr := flag.String("listen", "", "API listen address: unix:///path/to.sock or 127.0.0.1:port (default: <workspace>/orchestrator.sock)")
+	apiToken := flag.String("token", os.Getenv(daemon.TokenEnv), "API bearer token (default: $"+daemon.TokenEnv+" or a generated token)")
+	noAPI := flag.Bool("no-api", false, "Disable the local HTTP API")
+	webhooks := flag.String("webhook", "", "Comma-separated webhook URLs to POST events to (in addition to event-sinks.json)")
+	webhookSecret := flag.String("webhook-secret", os.Getenv(eventbus.WebhookSecretEnv), "HMAC secret for -webhook (default: $"+eventbus.WebhookSecretEnv+")")
+	fsckMode := flag.String("fsck", "repair", "Workspace integrity check at startup: repair, check or off")
 	flag.Parse()
 
+	startupFsck, err := parseFsckMode(*fsckMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	executorMode, err := orchestrator.ParseExecutorMode(*executorFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Validate workspace
 	if _, err := os.Stat(*workspaceDir); os.IsNotExist(err) {
 		log.Fatalf("Workspace directory does not exist: %s", *workspaceDir)
@@ -31,29 +56,34 @@ func main() {
 	if err := repo.Init(); err != nil {
 		log.Fatalf("Failed to initialize repository: %v", err)
 	}
+	if _, err := orchestrator.MigrateWorkspace(repo); err != nil {
+		log.Fatalf("Failed to migrate workspace: %v", err)
+	}
 
-	queue := ipc.NewFilesystemQueue(*workspaceDir)
-
-	// Scheduler (Optional for pure worker, but Orchestrator usually bundles both roles in this binary?)
-	// If this binary acts as the Orchestrator Daemon, it should process schedule + execution.
-	scheduler := orchestrator.NewScheduler(repo, queue, nil)
-
-	// Executor (Stateless)
-	executor := orchestrator.NewExecutor(*agentRunnerPath, *workspaceDir)
-
-	// RetryPolicy and Backlog configurable? Using defaults for now.
-	backlogStore := orchestrator.NewBacklogStore(*workspaceDir)
+	// Events are fanned out to the JSONL event log, webhooks and API subscribers (SSE)
+	sinksConfig, err := eventbus.LoadSinksConfig(*workspaceDir)
+	if err != nil {
+		log.Printf("Failed to load event sinks config, using defaults: %v", err)
+	}
+	for _, url := range splitList(*webhooks) {
+		sinksConfig.Webhooks = append(sinksConfig.Webhooks, eventbus.WebhookFileConfig{URL: url, Secret: *webhookSecret})
+	}
+	events := eventbus.NewWorkspaceBus(*workspaceDir, sinksConfig, slog.Default())
 
-	// Create ExecutionOrchestrator
-	orch := orchestrator.NewExecutionOrchestrator(
-		scheduler,
-		executor,
-		repo,
-		queue,
-		nil, // EventEmitter (can be added if needed for logging/UI)
-		backlogStore,
-		[]string{*poolID},
-	)
+	// Executor, scheduler and execution loop (worker pools, retry policies and verification from the workspace)
+	execution := orchestrator.NewFromWorkspace(repo, orchestrator.WorkspaceOptions{
+		ProjectRoot:     *workspaceDir,
+		AgentRunnerPath: *agentRunnerPath,
+		ExecutorMode:    executorMode,
+		PoolIDs:         splitList(*poolFlag),
+		Events:          events,
+		StartupFsck:     startupFsck,
+		Warn:            func(err error) { log.Printf("%v", err) },
+	})
+	scheduler := execution.Scheduler
+	backlogStore := execution.BacklogStore
+	orch := execution.Orchestrator
+	poolIDs := execution.PoolIDs
 
 	// Setup context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -64,11 +94,24 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Start Orchestrator
-	log.Printf("Orchestrator started. Workspace: %s, Pool: %s", *workspaceDir, *poolID)
+	log.Printf("Orchestrator started. Workspace: %s, Pools: %s", *workspaceDir, strings.Join(poolIDs, ","))
 	if err := orch.Start(ctx); err != nil {
+		if errors.Is(err, persistence.ErrLeaderLockHeld) {
+			log.Fatalf("Another orchestrator is already running for this workspace: %v", err)
+		}
 		log.Fatalf("Failed to start orchestrator: %v", err)
 	}
 
+	// Start local API
+	var apiDone <-chan struct{}
+	if !*noAPI {
+		apiDone, err = startAPI(ctx, *workspaceDir, *listenAddr, *apiToken, repo, scheduler, orch, backlogStore, events)
+		if err != nil {
+			_ = orch.Stop()
+			log.Fatalf("Failed to start API: %v", err)
+		}
+	}
+
 	// Wait for signal
 	<-sigChan
 	log.Println("Shutting down...")
@@ -78,5 +121,98 @@ func main() {
 		log.Printf("Error stopping orchestrator: %v", err)
 	}
 	orch.Wait()
+	cancel()
+	if apiDone != nil {
+		<-apiDone
+	}
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer closeCancel()
+	if err := events.Close(closeCtx); err != nil {
+		log.Printf("Error closing event sinks: %v", err)
+	}
 	log.Println("Orchestrator stopped.")
 }
+
+// startAPI serves the local HTTP API until ctx is cancelled and publishes the
+// endpoint/token to <workspace>/api.json.
+func startAPI(
+	ctx context.Context,
+	workspaceDir, listenAddr, token string,
+	repo persistence.WorkspaceRepository,
+	scheduler *orchestrator.Scheduler,
+	orch *orchestrator.ExecutionOrchestrator,
+	backlogStore *orchestrator.BacklogStore,
+	events *eventbus.Bus,
+) (<-chan struct{}, error) {
+	sessionStore := chat.NewChatSessionStore(workspaceDir)
+	workspaceID := filepath.Base(workspaceDir)
+	chatHandler := newChatHandler(workspaceDir, workspaceID, repo, sessionStore, events)
+	if chatHandler != nil {
+		orch.SetTaskReplanner(chatHandler)
+	}
+
+	endpoint, done, err := daemon.ServeWorkspace(ctx, workspaceDir, listenAddr, daemon.Config{
+		WorkspaceID:  workspaceID,
+		Repo:         repo,
+		Scheduler:    scheduler,
+		Orchestrator: orch,
+		BacklogStore: backlogStore,
+		Chat:         chatHandler,
+		Sessions:     sessionStore,
+		Events:       events,
+		Token:        token,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("API listening on %s", endpoint)
+	return done, nil
+}
+
+// newChatHandler builds a chat handler when the workspace has IDE metadata
+// (workspace.json under ~/.multiverse/workspaces/<id>). Returns nil otherwise.
+func newChatHandler(
+	workspaceDir, workspaceID string,
+	repo persistence.WorkspaceRepository,
+	sessionStore *chat.ChatSessionStore,
+	events orchestrator.EventEmitter,
+) *chat.Handler {
+	ws, err := ide.NewWorkspaceStore(filepath.Dir(workspaceDir)).LoadWorkspace(workspaceID)
+	if err != nil {
+		log.Printf("Chat API disabled (workspace metadata not found): %v", err)
+		return nil
+	}
+	multiverseDir := filepath.Dir(filepath.Dir(workspaceDir))
+	metaClient := ide.NewMetaClient(
+		ide.NewLLMConfigStore(multiverseDir),
+		ide.NewToolingConfigStore(multiverseDir),
+		logging.WithComponent(slog.Default(), "orchestrator-daemon"),
+	)
+	return chat.NewHandler(metaClient, sessionStore, workspaceID, ws.ProjectRoot, repo, events)
+}
+
+// splitList splits a comma-separated flag value (-pool, -webhook), dropping empty entries.
+func splitList(raw string) []string {
+	var ids []string
+	for _, id := range strings.Split(raw, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseFsckMode converts the -fsck flag into startup check options (nil disables the check).
+func parseFsckMode(mode string) (*orchestrator.FsckOptions, error) {
+	switch mode {
+	case "repair":
+		return &orchestrator.FsckOptions{Repair: true}, nil
+	case "check":
+		return &orchestrator.FsckOptions{}, nil
+	case "off", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid -fsck mode %q (want repair, check or off)", mode)
+	}
+}
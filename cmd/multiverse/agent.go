@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func (c *cli) agentCmd(ctx context.Context, args []string) error {
+	name, rest, err := subcommand(args, "agent", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "list", "ls":
+		return c.agentList(rest)
+	case "register", "add":
+		return c.agentRegister(rest)
+	case "unregister", "rm":
+		return c.agentUnregister(rest)
+	case "check":
+		return c.agentCheck(ctx, rest)
+	default:
+		return unknownSubcommand("agent", name, c.stderr)
+	}
+}
+
+func (c *cli) agentList(args []string) error {
+	fs := newFlagSet("agent list", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	agents, err := orchestrator.ListAgents(env.Repo)
+	if err != nil {
+		return err
+	}
+	return c.renderAgents(agents)
+}
+
+func (c *cli) agentRegister(args []string) error {
+	fs := newFlagSet("agent register", c.stderr)
+	kind := fs.String("kind", "", "Task kind the agent runs (empty: any kind)")
+	parallel := fs.Int("parallel", 1, "Maximum number of tasks the agent runs at once")
+	capabilities := fs.String("capabilities", "", "Comma-separated capabilities (e.g. lang:go,docker)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "agent register [-kind K] [-parallel N] [-capabilities C,...] <agent-id>", c.stderr); err != nil {
+		return err
+	}
+	agent := persistence.AgentState{
+		AgentID:     fs.Arg(0),
+		Kind:        *kind,
+		MaxParallel: *parallel,
+	}
+	for _, capability := range strings.Split(*capabilities, ",") {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			agent.Capabilities = append(agent.Capabilities, capability)
+		}
+	}
+
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	if err := orchestrator.RegisterAgent(env.Repo, agent); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"id": agent.AgentID}, "Registered agent %s", agent.AgentID)
+}
+
+func (c *cli) agentUnregister(args []string) error {
+	fs := newFlagSet("agent unregister", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "agent unregister <agent-id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	id := fs.Arg(0)
+	if err := orchestrator.UnregisterAgent(env.Repo, id); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"id": id}, "Unregistered agent %s", id)
+}
+
+func (c *cli) agentCheck(ctx context.Context, args []string) error {
+	fs := newFlagSet("agent check", c.stderr)
+	agentRunner := fs.String("agent-runner", "agent-runner", "Path to agent-runner binary the agents run tasks with")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	if err := orchestrator.CheckAgentsHealth(ctx, env.Repo, orchestrator.AgentRunnerHealthCheck(*agentRunner)); err != nil {
+		return err
+	}
+	agents, err := orchestrator.ListAgents(env.Repo)
+	if err != nil {
+		return err
+	}
+	return c.renderAgents(agents)
+}
+
+func (c *cli) renderAgents(agents []persistence.AgentState) error {
+	return c.out.render(agents, func(w io.Writer) {
+		row(w, "ID", "KIND", "RUNNING", "CAPABILITIES", "HEALTH", "CHECKED", "ERROR")
+		for _, a := range agents {
+			health := a.Health
+			if health == "" {
+				health = "-"
+			}
+			row(w, a.AgentID, a.Kind, fmt.Sprintf("%d/%d", len(a.RunningTasks), a.MaxParallel),
+				strings.Join(a.Capabilities, ","), health, formatTimePtr(a.HealthCheckedAt), truncate(a.HealthError, 50))
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+)
+
+func (c *cli) backlogCmd(ctx context.Context, args []string) error {
+	name, rest, err := subcommand(args, "backlog", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "list", "ls":
+		return c.backlogList(rest)
+	case "resolve":
+		return c.backlogResolve(ctx, rest)
+	default:
+		return unknownSubcommand("backlog", name, c.stderr)
+	}
+}
+
+func (c *cli) backlogList(args []string) error {
+	fs := newFlagSet("backlog list", c.stderr)
+	all := fs.Bool("all", false, "Include resolved items")
+	itemType := fs.String("type", "", "Only items of this type (FAILURE, QUESTION, BLOCKER, APPROVAL)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	store := orchestrator.NewBacklogStore(env.Dir)
+	var items []orchestrator.BacklogItem
+	if *all {
+		items, err = store.List()
+	} else {
+		items, err = store.ListUnresolved()
+	}
+	if err != nil {
+		return err
+	}
+	filtered := []orchestrator.BacklogItem{}
+	for _, item := range items {
+		if *itemType == "" || strings.EqualFold(string(item.Type), *itemType) {
+			filtered = append(filtered, item)
+		}
+	}
+	items = filtered
+	return c.out.render(items, func(w io.Writer) {
+		row(w, "ID", "TYPE", "PRIORITY", "TASK", "CREATED", "RESOLVED", "TITLE")
+		for _, item := range items {
+			row(w, item.ID, string(item.Type), fmt.Sprintf("%d", item.Priority), item.TaskID,
+				formatTime(item.CreatedAt), formatTimePtr(item.ResolvedAt), truncate(item.Title, 50))
+		}
+	})
+}
+
+func (c *cli) backlogResolve(ctx context.Context, args []string) error {
+	fs := newFlagSet("backlog resolve", c.stderr)
+	action := fs.String("action", "", "Resolution action: "+backlogActionNames()+" (default: answer for questions, note otherwise; approvals need approve or reject)")
+	description := fs.String("description", "", "New task description (retry_edited)")
+	var criteria stringList
+	fs.Var(&criteria, "criteria", "Acceptance criterion (retry_edited, repeatable)")
+	profile := fs.String("profile", "", "Tooling profile to retry with (retry_tooling)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "backlog resolve [-action A] [flags] <id> [note...]", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	id := fs.Arg(0)
+	resolution := orchestrator.BacklogResolution{
+		Action:             orchestrator.BacklogAction(*action),
+		Note:               strings.Join(fs.Args()[1:], " "),
+		AcceptanceCriteria: criteria,
+		ToolingProfile:     *profile,
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "description" {
+			resolution.Description = description
+		}
+	})
+
+	resolver := orchestrator.NewBacklogResolver(env.Repo, orchestrator.NewBacklogStore(env.Dir), nil)
+	if resolution.Action == orchestrator.BacklogActionSplit {
+		resolver.SetTaskSplitter(c.chatHandler(env))
+	}
+	result, err := resolver.Resolve(ctx, id, resolution)
+	if err != nil {
+		return err
+	}
+	return c.out.render(result, func(w io.Writer) {
+		_, _ = fmt.Fprintf(w, "Resolved backlog item %s (%s)\n", id, result.Item.ResolutionAction)
+		if result.TaskStatus != "" {
+			_, _ = fmt.Fprintf(w, "Task %s is now %s\n", result.Item.TaskID, result.TaskStatus)
+		}
+		if len(result.CreatedTaskIDs) > 0 {
+			_, _ = fmt.Fprintf(w, "Created tasks: %s\n", strings.Join(result.CreatedTaskIDs, ", "))
+		}
+	})
+}
+
+// backlogActionNames returns the resolution actions as a comma-separated list.
+func backlogActionNames() string {
+	names := make([]string, 0, len(orchestrator.BacklogActions))
+	for _, a := range orchestrator.BacklogActions {
+		names = append(names, string(a))
+	}
+	return strings.Join(names, ", ")
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+)
+
+// calibrationCmd compares estimates with the recorded actuals of completed nodes.
+func (c *cli) calibrationCmd(_ context.Context, args []string) error {
+	fs := newFlagSet("calibration", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	report, err := orchestrator.BuildCalibrationReport(env.Repo, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return c.out.render(report, func(w io.Writer) {
+		if report.Nodes == 0 {
+			_, _ = fmt.Fprintln(w, "No completed nodes with recorded actuals")
+			return
+		}
+		row(w, "BY", "KEY", "NODES", "AVG PT", "AVG TIME", "AVG ATTEMPTS", "AVG RUNS", "AVG TOKENS", "AVG COST", "TIME/PT", "COST/PT")
+		for _, g := range report.ByKind {
+			calibrationRow(w, g)
+		}
+		for _, g := range report.ByDifficulty {
+			calibrationRow(w, g)
+		}
+	})
+}
+
+// calibrationRow writes one kind / difficulty group.
+func calibrationRow(w io.Writer, g orchestrator.CalibrationGroup) {
+	key := g.Key
+	if key == "" {
+		key = "-"
+	}
+	row(w, g.Dimension, truncate(key, 20), fmt.Sprint(g.Nodes),
+		fmt.Sprintf("%.1f", g.AvgStoryPoints),
+		formatSeconds(g.AvgWallClockSec),
+		fmt.Sprintf("%.1f", g.AvgAttempts),
+		fmt.Sprintf("%.1f", g.AvgWorkerRuns),
+		fmt.Sprintf("%.0f", g.AvgTokens),
+		fmt.Sprintf("$%.2f", g.AvgCostUSD),
+		formatSeconds(g.WallClockSecPerPoint),
+		fmt.Sprintf("$%.2f", g.CostUSDPerPoint))
+}
+
+// formatSeconds formats a duration in seconds rounded to the second.
+func formatSeconds(sec float64) string {
+	return time.Duration(sec * float64(time.Second)).Round(time.Second).String()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/chat"
+	"github.com/biwakonbu/agent-runner/internal/ide"
+	"github.com/biwakonbu/agent-runner/internal/logging"
+)
+
+// chatHandler builds a chat.Handler the same way the IDE does, using the
+// LLM / tooling configuration under the multiverse home directory.
+func (c *cli) chatHandler(env *wsEnv) *chat.Handler {
+	metaClient := ide.NewMetaClient(
+		ide.NewLLMConfigStore(c.home),
+		ide.NewToolingConfigStore(c.home),
+		logging.WithComponent(slog.Default(), "cli"),
+	)
+	return chat.NewHandler(
+		metaClient,
+		chat.NewChatSessionStore(env.Dir),
+		env.ID,
+		env.WS.ProjectRoot,
+		env.Repo,
+		nil,
+	)
+}
+
+func (c *cli) chatCmd(ctx context.Context, args []string) error {
+	name, rest, err := subcommand(args, "chat", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "send":
+		return c.chatSend(ctx, rest)
+	case "history":
+		return c.chatHistory(ctx, rest)
+	case "sessions":
+		return c.chatSessions(rest)
+	default:
+		return unknownSubcommand("chat", name, c.stderr)
+	}
+}
+
+func (c *cli) chatSend(ctx context.Context, args []string) error {
+	fs := newFlagSet("chat send", c.stderr)
+	sessionID := fs.String("session", "", "Chat session ID (default: create a new session)")
+	noSchedule := fs.Bool("no-schedule", false, "Do not queue generated tasks that are ready")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "chat send [-session id] <message>", c.stderr); err != nil {
+		return err
+	}
+	message := strings.Join(fs.Args(), " ")
+
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	handler := c.chatHandler(env)
+
+	if *sessionID == "" {
+		session, err := handler.CreateSession(ctx)
+		if err != nil {
+			return err
+		}
+		*sessionID = session.ID
+		_, _ = fmt.Fprintf(c.stderr, "Created chat session %s\n", session.ID)
+	}
+
+	resp, err := handler.HandleMessage(ctx, *sessionID, message)
+	if err != nil {
+		return err
+	}
+
+	// IDE の Chat Autopilot と同様、依存が満たされたタスクを即時キューに投入する
+	if len(resp.GeneratedTasks) > 0 && !*noSchedule {
+		if _, err := env.scheduler().ScheduleReadyTasks(); err != nil {
+			_, _ = fmt.Fprintf(c.stderr, "warning: failed to schedule ready tasks: %v\n", err)
+		}
+	}
+
+	return c.out.render(resp, func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, resp.Message.Content)
+		if len(resp.GeneratedTasks) > 0 {
+			_, _ = fmt.Fprintln(w)
+			row(w, "TASK ID", "STATUS", "TITLE")
+			for _, t := range resp.GeneratedTasks {
+				row(w, t.ID, string(t.Status), truncate(t.Title, 60))
+			}
+		}
+	})
+}
+
+func (c *cli) chatHistory(ctx context.Context, args []string) error {
+	fs := newFlagSet("chat history", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "chat history <session-id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	messages, err := c.chatHandler(env).GetHistory(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.out.render(messages, func(w io.Writer) {
+		for _, m := range messages {
+			_, _ = fmt.Fprintf(w, "[%s] %s:\n%s\n\n", formatTime(m.Timestamp), m.Role, m.Content)
+		}
+	})
+}
+
+func (c *cli) chatSessions(args []string) error {
+	fs := newFlagSet("chat sessions", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	sessions, err := chat.NewChatSessionStore(env.Dir).ListSessions()
+	if err != nil {
+		return err
+	}
+	return c.out.render(sessions, func(w io.Writer) {
+		row(w, "SESSION ID", "CREATED", "UPDATED")
+		for _, s := range sessions {
+			row(w, s.ID, formatTime(s.CreatedAt), formatTime(s.UpdatedAt))
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/chat"
+	"github.com/biwakonbu/agent-runner/internal/daemon"
+	"github.com/biwakonbu/agent-runner/internal/ide"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/eventbus"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func (c *cli) executionCmd(ctx context.Context, args []string) error {
+	name, rest, err := subcommand(args, "execution", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "status":
+		return c.executionStatus(ctx, rest)
+	case "start":
+		return c.executionStart(ctx, rest)
+	case "pause", "resume", "stop":
+		return c.executionControl(ctx, name, rest)
+	default:
+		return unknownSubcommand("execution", name, c.stderr)
+	}
+}
+
+func (c *cli) renderExecution(status *daemon.ExecutionStatus) error {
+	return c.out.render(status, func(w io.Writer) {
+		field(w, "State", status.State)
+		if l := status.Leader; l != nil {
+			field(w, "Owner", fmt.Sprintf("%s (pid %d on %s)", l.Role, l.PID, l.Hostname))
+			field(w, "Heartbeat", formatTime(l.HeartbeatAt))
+			field(w, "Endpoint", l.Endpoint)
+		}
+	})
+}
+
+func (c *cli) executionStatus(ctx context.Context, args []string) error {
+	fs := newFlagSet("execution status", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	if client := env.daemonClient(ctx); client != nil {
+		status, err := client.GetExecution(ctx)
+		if err != nil {
+			return err
+		}
+		return c.renderExecution(status)
+	}
+
+	// API が無い場合（IDE の組み込みループ等）はリーダー情報から状態を読む
+	status := &daemon.ExecutionStatus{State: string(orchestrator.ExecutionStateIdle)}
+	if info, ok := persistence.ActiveLeader(env.Dir, persistence.DefaultLeaderStaleAfter); ok {
+		status.Leader = info
+		status.State = info.State
+		if status.State == "" {
+			status.State = string(orchestrator.ExecutionStateRunning)
+		}
+	}
+	return c.renderExecution(status)
+}
+
+func (c *cli) executionControl(ctx context.Context, action string, args []string) error {
+	fs := newFlagSet("execution "+action, c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	client := env.daemonClient(ctx)
+	if client == nil {
+		if info, ok := persistence.ActiveLeader(env.Dir, persistence.DefaultLeaderStaleAfter); ok {
+			return fmt.Errorf("execution is owned by %s without an API; control it there", info.String())
+		}
+		return fmt.Errorf("no orchestrator is running for workspace %s", env.ID)
+	}
+	status, err := client.Execution(ctx, action)
+	if err != nil {
+		return err
+	}
+	return c.renderExecution(status)
+}
+
+// executionStart starts execution in the workspace daemon if one is running.
+// Otherwise it runs the orchestrator in the foreground (with the local API, so
+// other shells can pause/stop it) until interrupted or stopped.
+func (c *cli) executionStart(ctx context.Context, args []string) error {
+	fs := newFlagSet("execution start", c.stderr)
+	agentRunner := fs.String("agent-runner", "agent-runner", "Path to agent-runner binary (foreground mode)")
+	poolFlag := fs.String("pool", "", "Comma-separated pool IDs to consume (foreground mode, default: all pools)")
+	listen := fs.String("listen", "", "API listen address (foreground mode, default: <workspace>/orchestrator.sock)")
+	executorFlag := fs.String("executor", string(orchestrator.ExecutorModeSubprocess), "Task executor: subprocess or in-process (foreground mode)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	mode, err := orchestrator.ParseExecutorMode(*executorFlag)
+	if err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+
+	if client := env.daemonClient(ctx); client != nil {
+		status, err := client.Execution(ctx, "start")
+		if err != nil {
+			return err
+		}
+		return c.renderExecution(status)
+	}
+
+	return c.runForeground(ctx, env, *agentRunner, mode, splitList(*poolFlag), *listen)
+}
+
+func (c *cli) runForeground(ctx context.Context, env *wsEnv, agentRunnerPath string, mode orchestrator.ExecutorMode, poolIDs []string, listenAddr string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sinksConfig, err := eventbus.LoadSinksConfig(env.Dir)
+	if err != nil {
+		_, _ = fmt.Fprintf(c.stderr, "warning: failed to load event sinks config, using defaults: %v\n", err)
+	}
+	events := eventbus.NewWorkspaceBus(env.Dir, sinksConfig, slog.Default())
+	defer func() {
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer closeCancel()
+		_ = events.Close(closeCtx)
+	}()
+	toolingCfg, err := ide.NewToolingConfigStore(c.home).Load()
+	if err != nil {
+		toolingCfg = ide.DefaultToolingConfig()
+	}
+	execution := orchestrator.NewFromWorkspace(env.Repo, orchestrator.WorkspaceOptions{
+		ProjectRoot:     env.WS.ProjectRoot,
+		AgentRunnerPath: agentRunnerPath,
+		ExecutorMode:    mode,
+		PoolIDs:         poolIDs,
+		Events:          events,
+		ToolingConfig:   toolingCfg,
+		StartupFsck:     &orchestrator.FsckOptions{Repair: true},
+		Warn: func(err error) {
+			_, _ = fmt.Fprintf(c.stderr, "warning: %v\n", err)
+		},
+	})
+	scheduler := execution.Scheduler
+	backlogStore := execution.BacklogStore
+	orch := execution.Orchestrator
+	poolIDs = execution.PoolIDs
+
+	// 状態変化を表示し、API 経由で停止されたら終了する
+	sub, unsubscribe := events.Subscribe()
+	defer unsubscribe()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for ev := range sub {
+			if c.printEvent(ev) {
+				return
+			}
+		}
+	}()
+
+	chatHandler := c.chatHandler(env)
+	orch.SetTaskReplanner(chatHandler)
+
+	if err := orch.Start(ctx); err != nil {
+		return err
+	}
+	sessionStore := chat.NewChatSessionStore(env.Dir)
+	endpoint, apiDone, err := daemon.ServeWorkspace(ctx, env.Dir, listenAddr, daemon.Config{
+		WorkspaceID:  env.ID,
+		Repo:         env.Repo,
+		Scheduler:    scheduler,
+		Orchestrator: orch,
+		BacklogStore: backlogStore,
+		Chat:         chatHandler,
+		Sessions:     sessionStore,
+		Events:       events,
+	})
+	if err != nil {
+		_ = orch.Stop()
+		orch.Wait()
+		return err
+	}
+	_, _ = fmt.Fprintf(c.stderr, "Execution started for workspace %s (pools: %s, api: %s). Press Ctrl+C to stop.\n",
+		env.ID, strings.Join(poolIDs, ","), endpoint)
+
+	select {
+	case <-ctx.Done():
+	case <-stopped:
+	}
+	_ = orch.Stop()
+	orch.Wait()
+	cancel()
+	<-apiDone
+	_, _ = fmt.Fprintln(c.stderr, "Execution stopped.")
+	return nil
+}
+
+// printEvent prints orchestrator events in foreground mode. Returns true when
+// the execution loop has been stopped.
+func (c *cli) printEvent(ev eventbus.Envelope) bool {
+	if c.out.format == formatJSON {
+		_ = json.NewEncoder(c.out.w).Encode(ev)
+	}
+	switch data := ev.Data.(type) {
+	case orchestrator.TaskStateChangeEvent:
+		if c.out.format == formatTable {
+			_, _ = fmt.Fprintf(c.out.w, "%s  task %s: %s -> %s\n", formatTime(ev.Timestamp), data.TaskID, data.OldStatus, data.NewStatus)
+		}
+	case orchestrator.ExecutionStateChangeEvent:
+		if c.out.format == formatTable {
+			_, _ = fmt.Fprintf(c.out.w, "%s  execution: %s -> %s\n", formatTime(ev.Timestamp), data.OldState, data.NewState)
+		}
+		return data.NewState == orchestrator.ExecutionStateIdle
+	}
+	return false
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// fsckCmd checks workspace integrity and optionally applies the safe repairs.
+func (c *cli) fsckCmd(_ context.Context, args []string) error {
+	fs := newFlagSet("fsck", c.stderr)
+	repair := fs.Bool("repair", false, "Apply safe automatic repairs (a snapshot is taken first)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	// Repairs touch the queue and task files, which only the leader may do while it runs.
+	if *repair {
+		if info, ok := persistence.ActiveLeader(env.Dir, persistence.DefaultLeaderStaleAfter); ok {
+			return fmt.Errorf("cannot repair while an orchestrator is running (%s); stop it first", info.String())
+		}
+	}
+
+	fsck := orchestrator.NewFsck(
+		env.Repo,
+		ipc.NewFilesystemQueue(env.Dir),
+		orchestrator.NewBacklogStore(env.Dir),
+	)
+	report, err := fsck.Run(orchestrator.FsckOptions{Repair: *repair})
+	if err != nil {
+		return err
+	}
+
+	if err := c.out.render(report, func(w io.Writer) {
+		if len(report.Issues) == 0 {
+			_, _ = fmt.Fprintln(w, "No problems found")
+			return
+		}
+		row(w, "SEVERITY", "CODE", "SUBJECT", "REPAIR", "MESSAGE")
+		for _, issue := range report.Issues {
+			row(w, string(issue.Severity), issue.Code, truncate(issue.Subject, 40), repairState(issue), issue.Message)
+		}
+		_, _ = fmt.Fprintf(w, "\n%d errors, %d warnings remaining", report.Count(orchestrator.FsckSeverityError), report.Count(orchestrator.FsckSeverityWarning))
+		if report.SnapshotID != "" {
+			_, _ = fmt.Fprintf(w, "; %d repaired (snapshot %s)", report.Repaired(), report.SnapshotID)
+		}
+		_, _ = fmt.Fprintln(w)
+	}); err != nil {
+		return err
+	}
+	if report.Count(orchestrator.FsckSeverityError) > 0 {
+		if !*repair {
+			return errors.New("workspace has integrity errors; run 'multiverse fsck -repair' to fix the repairable ones")
+		}
+		return errors.New("workspace still has integrity errors that need manual attention")
+	}
+	return nil
+}
+
+// repairState summarizes whether an issue can be or has been repaired.
+func repairState(issue orchestrator.FsckIssue) string {
+	switch {
+	case issue.Repaired:
+		return "repaired"
+	case issue.RepairError != "":
+		return "failed: " + truncate(issue.RepairError, 40)
+	case issue.Repairable:
+		return "available"
+	default:
+		return "manual"
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func (c *cli) historyCmd(_ context.Context, args []string) error {
+	name, rest, err := subcommand(args, "history", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "list", "ls":
+		return c.historyList(rest)
+	case "state":
+		return c.historyState(rest)
+	case "verify":
+		return c.historyVerify(rest)
+	case "rebuild":
+		return c.historyRebuild(rest)
+	default:
+		return unknownSubcommand("history", name, c.stderr)
+	}
+}
+
+func (c *cli) historyList(args []string) error {
+	fs := newFlagSet("history list", c.stderr)
+	since := fs.String("since", "", "Only actions at or after this time (RFC3339 or a duration ago, e.g. 2h)")
+	until := fs.String("until", "", "Only actions at or before this time (RFC3339 or a duration ago)")
+	kind := fs.String("kind", "", "Only actions whose kind starts with this prefix (e.g. state.)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	from, err := parseWhen(*since, time.Time{})
+	if err != nil {
+		return err
+	}
+	to, err := parseWhen(*until, time.Now())
+	if err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	all, err := env.Repo.History().ListActions(from, to)
+	if err != nil {
+		return err
+	}
+	actions := make([]persistence.Action, 0, len(all))
+	for _, a := range all {
+		if strings.HasPrefix(a.Kind, *kind) {
+			actions = append(actions, a)
+		}
+	}
+	return c.out.render(actions, func(w io.Writer) {
+		row(w, "AT", "KIND", "SUBJECT", "DETAIL")
+		for _, a := range actions {
+			row(w, a.At.Local().Format("2006-01-02 15:04:05.000"), a.Kind, actionSubject(a), actionDetail(a))
+		}
+	})
+}
+
+func (c *cli) historyState(args []string) error {
+	fs := newFlagSet("history state", c.stderr)
+	at := fs.String("at", "", "Point in time to rebuild (RFC3339 or a duration ago; default: now)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	when, err := parseWhen(*at, time.Now())
+	if err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	state, err := persistence.StateAt(env.Repo.History(), when)
+	if err != nil {
+		return err
+	}
+	return c.out.render(state, func(w io.Writer) {
+		_, _ = fmt.Fprintf(w, "State as of %s (%d actions applied, %d skipped)\n\n",
+			state.At.Local().Format(time.RFC3339), state.Applied, state.Skipped)
+		row(w, "TASK", "NODE", "KIND", "STATUS", "UPDATED")
+		for _, t := range state.Tasks.Tasks {
+			row(w, t.TaskID, t.NodeID, t.Kind, t.Status, formatTime(t.UpdatedAt))
+		}
+		if len(state.NodesRuntime.Nodes) > 0 {
+			_, _ = fmt.Fprintln(w)
+			row(w, "NODE", "STATUS", "VERIFICATION")
+			for _, n := range state.NodesRuntime.Nodes {
+				row(w, n.NodeID, n.Status, n.Verification.Status)
+			}
+		}
+	})
+}
+
+// historyVerify compares the state rebuilt from history with the state files.
+func (c *cli) historyVerify(args []string) error {
+	fs := newFlagSet("history verify", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	replayed, err := persistence.StateAt(env.Repo.History(), time.Now())
+	if err != nil {
+		return err
+	}
+	tasks, err := env.Repo.State().LoadTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks state (try 'history rebuild'): %w", err)
+	}
+	nodes, err := env.Repo.State().LoadNodesRuntime()
+	if err != nil {
+		return fmt.Errorf("failed to load nodes runtime (try 'history rebuild'): %w", err)
+	}
+	drift, err := replayed.Drift(tasks, nodes)
+	if err != nil {
+		return err
+	}
+	if drift == nil {
+		drift = []string{}
+	}
+	if err := c.out.render(map[string]any{"consistent": len(drift) == 0, "drift": drift}, func(w io.Writer) {
+		if len(drift) == 0 {
+			_, _ = fmt.Fprintf(w, "State matches history (%d actions applied)\n", replayed.Applied)
+			return
+		}
+		_, _ = fmt.Fprintln(w, "State differs from history:")
+		for _, d := range drift {
+			_, _ = fmt.Fprintf(w, "  %s\n", d)
+		}
+	}); err != nil {
+		return err
+	}
+	if len(drift) > 0 {
+		return errors.New("state has drifted from history; run 'multiverse history rebuild' to restore it")
+	}
+	return nil
+}
+
+func (c *cli) historyRebuild(args []string) error {
+	fs := newFlagSet("history rebuild", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	state, err := persistence.RebuildState(env.Repo)
+	if err != nil {
+		return err
+	}
+	return c.out.message(state, "Rebuilt state from %d actions (%d tasks, %d nodes)",
+		state.Applied, len(state.Tasks.Tasks), len(state.NodesRuntime.Nodes))
+}
+
+// parseWhen parses an RFC3339 timestamp or a duration meaning "that long ago".
+func parseWhen(raw string, def time.Time) (time.Time, error) {
+	if raw == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want RFC3339 or a duration such as 2h)", raw)
+}
+
+// actionSubject returns the task or node an action is about.
+func actionSubject(a persistence.Action) string {
+	for _, key := range []string{"task_id", "node_id", "session_id"} {
+		if v, ok := a.Payload[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return "-"
+}
+
+// actionDetail summarizes an action for table output.
+func actionDetail(a persistence.Action) string {
+	from, _ := a.Payload["from_status"].(string)
+	to, _ := a.Payload["to_status"].(string)
+	switch {
+	case from != "" || to != "":
+		if from == "" {
+			return to
+		}
+		return from + " -> " + to
+	case a.Payload["error"] != nil:
+		return truncate(fmt.Sprint(a.Payload["error"]), 60)
+	}
+	return ""
+}
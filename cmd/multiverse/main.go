@@ -0,0 +1,216 @@
+// Command multiverse is the command-line front end for multiverse workspaces.
+//
+// It operates on the same workspace files as the desktop IDE (via the ide,
+// chat and orchestrator packages), and forwards execution control to a
+// running multiverse-orchestrator daemon through its local API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/biwakonbu/agent-runner/internal/logging"
+)
+
+const (
+	// homeEnv overrides the multiverse home directory (default: ~/.multiverse)
+	homeEnv = "MULTIVERSE_HOME"
+	// workspaceEnv selects the workspace (ID or project root) when -workspace is not given
+	workspaceEnv = "MULTIVERSE_WORKSPACE"
+)
+
+// errUsage indicates invalid arguments; usage has already been printed.
+var errUsage = errors.New("usage error")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run parses global flags and dispatches to a subcommand. Returns the process exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("multiverse", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	home := fs.String("home", defaultHome(), "Multiverse home directory (env: "+homeEnv+")")
+	workspace := fs.String("workspace", os.Getenv(workspaceEnv), "Workspace ID or project root (default: current directory, env: "+workspaceEnv+")")
+	output := fs.String("o", formatTable, "Output format: table or json")
+	verbose := fs.Bool("v", false, "Enable debug logging")
+	fs.Usage = func() { printUsage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != formatTable && *output != formatJSON {
+		_, _ = fmt.Fprintf(stderr, "error: unknown output format %q (want table or json)\n", *output)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	logCfg := logging.DefaultConfig()
+	logCfg.Level = slog.LevelWarn
+	if *verbose {
+		logCfg = logging.DebugConfig()
+	}
+	slog.SetDefault(logging.NewLogger(logCfg))
+
+	c := &cli{
+		home:      *home,
+		workspace: *workspace,
+		out:       newPrinter(stdout, *output),
+		stderr:    stderr,
+	}
+
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	var err error
+	switch cmd {
+	case "workspace", "ws":
+		err = c.workspaceCmd(ctx, rest)
+	case "chat":
+		err = c.chatCmd(ctx, rest)
+	case "task":
+		err = c.taskCmd(ctx, rest)
+	case "backlog":
+		err = c.backlogCmd(ctx, rest)
+	case "schedule":
+		err = c.scheduleCmd(ctx, rest)
+	case "agent":
+		err = c.agentCmd(ctx, rest)
+	case "execution", "exec":
+		err = c.executionCmd(ctx, rest)
+	case "history":
+		err = c.historyCmd(ctx, rest)
+	case "progress":
+		err = c.progressCmd(ctx, rest)
+	case "calibration":
+		err = c.calibrationCmd(ctx, rest)
+	case "fsck":
+		err = c.fsckCmd(ctx, rest)
+	case "help":
+		fs.Usage()
+		return 0
+	default:
+		_, _ = fmt.Fprintf(stderr, "error: unknown command %q\n", cmd)
+		fs.Usage()
+		return 2
+	}
+
+	if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func defaultHome() string {
+	if home := os.Getenv(homeEnv); home != "" {
+		return home
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return ".multiverse"
+	}
+	return filepath.Join(userHome, ".multiverse")
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	_, _ = fmt.Fprint(w, `Usage: multiverse [global flags] <command> <subcommand> [flags] [args]
+
+Commands:
+  workspace open [dir]                 Register/open a project root as a workspace (default: current directory)
+  workspace list                       List workspaces
+  workspace remove <id>                Remove a workspace
+
+  chat send [-session id] <message>    Send a message to the planner (creates a session if omitted)
+  chat history <session-id>            Show messages of a chat session
+  chat sessions                        List chat sessions
+
+  task list [-status S]                List tasks
+  task show <task-id>                  Show a task and its attempts
+  task create [-pool P] <title>        Create a manual task
+  task run <task-id>                   Queue a task for execution
+  task cancel <task-id>                Cancel a task (stops it if running in the daemon)
+  task logs [-f] [-attempt id] <task-id>
+                                       Show the stdout/stderr of the latest (or given) attempt; -f follows it
+
+  backlog list [-all] [-type T]        List backlog items (unresolved by default; -type APPROVAL for pending approvals)
+  backlog resolve [-action A] <id> [note...]
+                                       Resolve a backlog item: note, retry, retry_edited (-description, -criteria),
+                                       retry_tooling (-profile), skip, split, abandon, answer, approve, reject
+
+  schedule list                        List scheduled/recurring task definitions
+  schedule add (-cron E | -every D | -after D | -at T) [-pool P] [-kind K] [-tz Z] [-missed skip] <title>
+                                       Create tasks on a cron schedule, repeatedly, or once later
+  schedule enable|disable <id>         Enable or disable a schedule
+  schedule remove <id>                 Remove a schedule (tasks already created are kept)
+
+  agent list                           List agents for capability-based scheduling with their load and health
+  agent register [-kind K] [-parallel N] [-capabilities C,...] <agent-id>
+                                       Register an agent (or update an existing one)
+  agent unregister <agent-id>          Remove an agent (fails while it has running tasks)
+  agent check [-agent-runner path]     Run the agent health check and show the results
+
+  execution status                     Show execution state and the orchestrator owning the workspace
+  execution start [-pool P]            Start execution (in the daemon if running, otherwise in the foreground)
+  execution pause|resume|stop          Control the running daemon
+
+  history list [-since T] [-kind K]    List recorded actions (T: RFC3339 or a duration ago, e.g. 2h)
+  history state [-at T]                Show tasks and nodes rebuilt from history as of T
+  history verify                       Check that the state files match history
+  history rebuild                      Rebuild the state files from history
+
+  progress [-days N]                   Show milestone/phase progress, daily throughput and the projected completion date
+  calibration                          Compare estimates with the actuals of completed nodes by kind and difficulty
+
+  fsck [-repair]                       Check workspace integrity; -repair applies the safe fixes
+
+Global flags:
+`)
+	fs.PrintDefaults()
+}
+
+// subcommand splits args into the subcommand name and its arguments.
+func subcommand(args []string, group string, w io.Writer) (string, []string, error) {
+	if len(args) == 0 {
+		_, _ = fmt.Fprintf(w, "error: missing %s subcommand (see 'multiverse help')\n", group)
+		return "", nil, errUsage
+	}
+	return args[0], args[1:], nil
+}
+
+// unknownSubcommand reports an unknown subcommand.
+func unknownSubcommand(group, name string, w io.Writer) error {
+	_, _ = fmt.Fprintf(w, "error: unknown %s subcommand %q (see 'multiverse help')\n", group, name)
+	return errUsage
+}
+
+// newFlagSet returns a FlagSet for a subcommand that reports errors to w.
+func newFlagSet(name string, w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	return fs
+}
+
+// requireArgs checks the number of positional arguments.
+func requireArgs(fs *flag.FlagSet, n int, usage string, w io.Writer) error {
+	if fs.NArg() < n {
+		_, _ = fmt.Fprintf(w, "usage: multiverse %s\n", usage)
+		return errUsage
+	}
+	return nil
+}
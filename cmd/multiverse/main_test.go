@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/biwakonbu/agent-runner/internal/ide"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// runCLI runs the CLI with the given home/workspace and returns stdout, stderr and the exit code.
+func runCLI(t *testing.T, home, project string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	full := append([]string{"-home", home, "-workspace", project}, args...)
+	code := run(context.Background(), full, &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestCLI_WorkspaceLifecycle(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+	var opened ide.WorkspaceSummary
+	require.NoError(t, json.Unmarshal([]byte(out), &opened))
+	assert.Equal(t, project, opened.ProjectRoot)
+	assert.Equal(t, filepath.Base(project), opened.DisplayName)
+
+	out, _, code = runCLI(t, home, project, "workspace", "list")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, opened.ID)
+	assert.Contains(t, out, "PROJECT ROOT")
+
+	_, _, code = runCLI(t, home, project, "workspace", "remove", opened.ID)
+	require.Equal(t, 0, code)
+
+	// 削除後はワークスペースを解決できない
+	_, errOut, code = runCLI(t, home, project, "task", "list")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "workspace open")
+}
+
+func TestCLI_TaskCommands(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	_, errOut, code := runCLI(t, home, project, "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "task", "create", "-pool", "codegen", "Write", "README")
+	require.Equal(t, 0, code, errOut)
+	var created orchestrator.Task
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+	assert.Equal(t, "Write README", created.Title)
+	assert.Equal(t, "codegen", created.PoolID)
+
+	out, _, code = runCLI(t, home, project, "task", "list")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, created.ID)
+	assert.Contains(t, out, "Write README")
+
+	out, _, code = runCLI(t, home, project, "-o", "json", "task", "list", "-status", "succeeded")
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", out)
+
+	out, _, code = runCLI(t, home, project, "-o", "json", "task", "show", created.ID)
+	require.Equal(t, 0, code)
+	var detail taskDetail
+	require.NoError(t, json.Unmarshal([]byte(out), &detail))
+	assert.Equal(t, created.ID, detail.Task.ID)
+	assert.Empty(t, detail.Attempts)
+
+	_, errOut, code = runCLI(t, home, project, "task", "cancel", created.ID)
+	require.Equal(t, 0, code, errOut)
+	out, _, _ = runCLI(t, home, project, "-o", "json", "task", "show", created.ID)
+	require.NoError(t, json.Unmarshal([]byte(out), &detail))
+	assert.Equal(t, orchestrator.TaskStatusCanceled, detail.Task.Status)
+
+	// 完了済み（取り消し済み）タスクは再度取り消せない
+	_, _, code = runCLI(t, home, project, "task", "cancel", created.ID)
+	assert.Equal(t, 1, code)
+
+	// 試行が無ければログは表示できない
+	_, errOut, code = runCLI(t, home, project, "task", "logs", created.ID)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "no attempts")
+}
+
+func TestCLI_TaskLogs(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+	var opened ide.WorkspaceSummary
+	require.NoError(t, json.Unmarshal([]byte(out), &opened))
+
+	out, errOut, code = runCLI(t, home, project, "-o", "json", "task", "create", "Build")
+	require.Equal(t, 0, code, errOut)
+	var created orchestrator.Task
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+
+	wsDir := ide.NewWorkspaceStore(filepath.Join(home, "workspaces")).GetWorkspaceDir(opened.ID)
+	attempts := persistence.NewWorkspaceRepository(wsDir).Attempts()
+	require.NoError(t, attempts.SaveAttempt(&persistence.AttemptRecord{
+		AttemptID: "att-1", TaskID: created.ID, Status: "SUCCEEDED", StartedAt: time.Now(),
+	}))
+	logw, err := attempts.OpenLog("att-1")
+	require.NoError(t, err)
+	for _, line := range []string{"compiling", "linking", "done"} {
+		require.NoError(t, logw.WriteLine("stdout", line))
+	}
+	require.NoError(t, logw.Close())
+
+	out, errOut, code = runCLI(t, home, project, "task", "logs", created.ID)
+	require.Equal(t, 0, code, errOut)
+	assert.Equal(t, "compiling\nlinking\ndone\n", out)
+
+	out, errOut, code = runCLI(t, home, project, "-o", "json", "task", "logs", "-attempt", "att-1", "-from", "1", "-limit", "1", created.ID)
+	require.Equal(t, 0, code, errOut)
+	var lines []persistence.AttemptLogLine
+	require.NoError(t, json.Unmarshal([]byte(out), &lines))
+	require.Len(t, lines, 1)
+	assert.Equal(t, "linking", lines[0].Line)
+
+	// 終了した試行の追従は全行を出力して戻る
+	out, errOut, code = runCLI(t, home, project, "task", "logs", "-f", created.ID)
+	require.Equal(t, 0, code, errOut)
+	assert.Equal(t, "compiling\nlinking\ndone\n", out)
+
+	_, errOut, code = runCLI(t, home, project, "task", "logs", "-attempt", "att-1", "other-task")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "belongs to task")
+}
+
+func TestCLI_BacklogAndExecution(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+	var opened ide.WorkspaceSummary
+	require.NoError(t, json.Unmarshal([]byte(out), &opened))
+
+	wsDir := ide.NewWorkspaceStore(filepath.Join(home, "workspaces")).GetWorkspaceDir(opened.ID)
+	require.NoError(t, orchestrator.NewBacklogStore(wsDir).Add(&orchestrator.BacklogItem{
+		ID:     "bl-1",
+		TaskID: "task-1",
+		Type:   orchestrator.BacklogTypeFailure,
+		Title:  "build failed",
+	}))
+
+	out, _, code = runCLI(t, home, project, "backlog", "list")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "bl-1")
+
+	out, _, code = runCLI(t, home, project, "-o", "json", "backlog", "list", "-type", "approval")
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", out)
+
+	// 対象タスクが無ければ retry はできない
+	_, errOut, code = runCLI(t, home, project, "backlog", "resolve", "-action", "retry", "bl-1")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "task not found")
+
+	_, errOut, code = runCLI(t, home, project, "backlog", "resolve", "-action", "bogus", "bl-1")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "unknown action")
+
+	_, errOut, code = runCLI(t, home, project, "backlog", "resolve", "bl-1", "fixed", "manually")
+	require.Equal(t, 0, code, errOut)
+
+	out, _, code = runCLI(t, home, project, "-o", "json", "backlog", "list")
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", out)
+
+	out, _, code = runCLI(t, home, project, "-o", "json", "execution", "status")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, `"state": "IDLE"`)
+
+	// デーモンが無ければ pause / stop はできない
+	_, errOut, code = runCLI(t, home, project, "execution", "pause")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "no orchestrator is running")
+}
+
+func TestCLI_History(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	_, errOut, code := runCLI(t, home, project, "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "task", "create", "Write", "docs")
+	require.Equal(t, 0, code, errOut)
+	var created orchestrator.Task
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+	_, errOut, code = runCLI(t, home, project, "task", "cancel", created.ID)
+	require.Equal(t, 0, code, errOut)
+
+	out, _, code = runCLI(t, home, project, "history", "list", "-kind", "state.")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "state.task_created")
+	assert.Contains(t, out, "PENDING -> CANCELED")
+
+	out, _, code = runCLI(t, home, project, "-o", "json", "history", "state", "-at", "1h")
+	require.Equal(t, 0, code)
+	var past persistence.ReplayedState
+	require.NoError(t, json.Unmarshal([]byte(out), &past))
+	assert.Empty(t, past.Tasks.Tasks)
+
+	out, _, code = runCLI(t, home, project, "history", "verify")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "State matches history")
+
+	_, errOut, code = runCLI(t, home, project, "history", "state", "-at", "yesterday")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "invalid time")
+}
+
+func TestCLI_Fsck(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+	var opened ide.WorkspaceSummary
+	require.NoError(t, json.Unmarshal([]byte(out), &opened))
+	wsDir := ide.NewWorkspaceStore(filepath.Join(home, "workspaces")).GetWorkspaceDir(opened.ID)
+
+	out, errOut, code = runCLI(t, home, project, "fsck")
+	require.Equal(t, 0, code, errOut)
+	assert.Contains(t, out, "No problems found")
+
+	// 壊れたジョブファイルはエラーとして報告され、-repair で退避される
+	queueDir := ipc.NewFilesystemQueue(wsDir).GetQueueDir("default")
+	require.NoError(t, os.MkdirAll(queueDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(queueDir, "job-bad.json"), []byte("{"), 0644))
+
+	out, errOut, code = runCLI(t, home, project, "fsck")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, orchestrator.FsckCorruptJob)
+	assert.Contains(t, errOut, "fsck -repair")
+
+	out, errOut, code = runCLI(t, home, project, "fsck", "-repair")
+	require.Equal(t, 0, code, errOut)
+	assert.Contains(t, out, "repaired")
+
+	out, _, code = runCLI(t, home, project, "fsck")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "No problems found")
+}
+
+func TestCLI_Progress(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	_, errOut, code := runCLI(t, home, project, "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+	_, errOut, code = runCLI(t, home, project, "task", "create", "-pool", "codegen", "Write", "README")
+	require.Equal(t, 0, code, errOut)
+
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "progress", "-days", "3")
+	require.Equal(t, 0, code, errOut)
+	var report orchestrator.ProgressReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, 1, report.Total.TotalTasks)
+	assert.Equal(t, 1, report.Total.TotalPoints)
+	assert.Len(t, report.Throughput, 3)
+	assert.Nil(t, report.ProjectedCompletion)
+	require.Len(t, report.Pools, 1)
+	assert.Equal(t, "codegen", report.Pools[0].PoolID)
+
+	out, _, code = runCLI(t, home, project, "progress")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "0/1 pt")
+	assert.Contains(t, out, "PENDING=1")
+}
+
+func TestCLI_Calibration(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	_, errOut, code := runCLI(t, home, project, "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "calibration")
+	require.Equal(t, 0, code, errOut)
+	var report orchestrator.CalibrationReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, 0, report.Nodes)
+	assert.Empty(t, report.ByKind)
+
+	out, _, code = runCLI(t, home, project, "calibration")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "No completed nodes with recorded actuals")
+}
+
+func TestCLI_ScheduleCommands(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	_, errOut, code := runCLI(t, home, project, "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "schedule", "add", "-cron", "0 3 * * *", "-pool", "codegen", "Nightly", "dependency", "update")
+	require.Equal(t, 0, code, errOut)
+	var created orchestrator.TaskSchedule
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+	assert.Equal(t, "Nightly dependency update", created.Title)
+	assert.True(t, created.Enabled)
+	require.NotNil(t, created.NextRunAt)
+	assert.Equal(t, 3, created.NextRunAt.Local().Hour())
+
+	out, _, code = runCLI(t, home, project, "schedule", "list")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, created.ID)
+	assert.Contains(t, out, "0 3 * * *")
+
+	out, errOut, code = runCLI(t, home, project, "-o", "json", "schedule", "disable", created.ID)
+	require.Equal(t, 0, code, errOut)
+	var disabled orchestrator.TaskSchedule
+	require.NoError(t, json.Unmarshal([]byte(out), &disabled))
+	assert.False(t, disabled.Enabled)
+	assert.Nil(t, disabled.NextRunAt)
+
+	_, errOut, code = runCLI(t, home, project, "schedule", "add", "-cron", "0 3 * *", "Broken")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "expected 5 fields")
+
+	_, errOut, code = runCLI(t, home, project, "schedule", "remove", created.ID)
+	require.Equal(t, 0, code, errOut)
+	out, _, code = runCLI(t, home, project, "-o", "json", "schedule", "list")
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", out)
+}
+
+func TestCLI_AgentCommands(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	_, errOut, code := runCLI(t, home, project, "workspace", "open", project)
+	require.Equal(t, 0, code, errOut)
+
+	_, errOut, code = runCLI(t, home, project, "agent", "register", "-kind", "implementation", "-parallel", "2", "-capabilities", "lang:go, docker", "codex")
+	require.Equal(t, 0, code, errOut)
+
+	out, errOut, code := runCLI(t, home, project, "-o", "json", "agent", "list")
+	require.Equal(t, 0, code, errOut)
+	var agents []persistence.AgentState
+	require.NoError(t, json.Unmarshal([]byte(out), &agents))
+	require.Len(t, agents, 1)
+	assert.Equal(t, "implementation", agents[0].Kind)
+	assert.Equal(t, 2, agents[0].MaxParallel)
+	assert.Equal(t, []string{"lang:go", "docker"}, agents[0].Capabilities)
+
+	out, errOut, code = runCLI(t, home, project, "agent", "check", "-agent-runner", filepath.Join(project, "missing-agent-runner"))
+	require.Equal(t, 0, code, errOut)
+	assert.Contains(t, out, "unhealthy")
+	assert.Contains(t, out, "agent-runner not available")
+
+	_, errOut, code = runCLI(t, home, project, "agent", "unregister", "codex")
+	require.Equal(t, 0, code, errOut)
+	out, _, code = runCLI(t, home, project, "-o", "json", "agent", "list")
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", out)
+
+	_, errOut, code = runCLI(t, home, project, "agent", "unregister", "codex")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "agent not found")
+}
+
+func TestCLI_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(context.Background(), nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Usage: multiverse")
+
+	stderr.Reset()
+	assert.Equal(t, 2, run(context.Background(), []string{"bogus"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "unknown command")
+
+	stderr.Reset()
+	assert.Equal(t, 2, run(context.Background(), []string{"-o", "yaml", "task", "list"}, &stdout, &stderr))
+}
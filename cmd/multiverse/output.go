@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// printer renders command results either as aligned tables or as JSON.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, format: format}
+}
+
+// render writes v as JSON, or calls table to write a human-readable form.
+func (p *printer) render(v any, table func(w io.Writer)) error {
+	if p.format == formatJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// message prints a one-line confirmation in table mode, or v as JSON.
+func (p *printer) message(v any, format string, args ...any) error {
+	return p.render(v, func(w io.Writer) {
+		_, _ = fmt.Fprintf(w, format+"\n", args...)
+	})
+}
+
+// row writes tab-separated cells terminated by a newline.
+func row(w io.Writer, cells ...string) {
+	_, _ = fmt.Fprintln(w, strings.Join(cells, "\t"))
+}
+
+// field writes a "key: value" line (used for detail views).
+func field(w io.Writer, key, value string) {
+	if value == "" {
+		value = "-"
+	}
+	_, _ = fmt.Fprintf(w, "%s:\t%s\n", key, value)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return formatTime(*t)
+}
+
+// truncate shortens s to max runes for table cells.
+func truncate(s string, max int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+)
+
+// progressCmd shows milestone / phase progress, throughput and the projected completion date.
+func (c *cli) progressCmd(_ context.Context, args []string) error {
+	fs := newFlagSet("progress", c.stderr)
+	days := fs.Int("days", orchestrator.DefaultProgressWindowDays, "Number of days of history used for throughput")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	report, err := orchestrator.BuildProgressReport(env.Repo, time.Now(), *days)
+	if err != nil {
+		return err
+	}
+
+	return c.out.render(report, func(w io.Writer) {
+		field(w, "Completed", fmt.Sprintf("%d/%d pt (%d/%d tasks)", report.Total.CompletedPoints, report.Total.TotalPoints, report.Total.CompletedTasks, report.Total.TotalTasks))
+		field(w, "Throughput", fmt.Sprintf("%.2f pt/day over %d days", report.PointsPerDay, report.WindowDays))
+		field(w, "Projected", formatTimePtr(report.ProjectedCompletion))
+		_, _ = fmt.Fprintln(w)
+
+		row(w, "GROUP", "NAME", "POINTS", "TASKS", "STATUS")
+		for _, g := range report.Milestones {
+			progressRow(w, "milestone", g)
+		}
+		for _, g := range report.Phases {
+			progressRow(w, "phase", g)
+		}
+		_, _ = fmt.Fprintln(w)
+
+		row(w, "DATE", "TASKS", "POINTS", "REMAINING")
+		remaining := report.Total.RemainingPoints()
+		// Walk back from today to get the points remaining at the end of each day (burndown).
+		remainingAt := make([]int, len(report.Throughput))
+		for i := len(report.Throughput) - 1; i >= 0; i-- {
+			remainingAt[i] = remaining
+			remaining += report.Throughput[i].Points
+		}
+		for i, b := range report.Throughput {
+			row(w, b.Date.Format("2006-01-02"), fmt.Sprint(b.Tasks), fmt.Sprint(b.Points), fmt.Sprint(remainingAt[i]))
+		}
+	})
+}
+
+// progressRow writes one milestone / phase rollup.
+func progressRow(w io.Writer, group string, g orchestrator.ProgressGroup) {
+	name := g.Name
+	if name == "" {
+		name = "-"
+	}
+	counts := make([]string, 0, len(g.Counts))
+	for _, status := range slices.Sorted(maps.Keys(g.Counts)) {
+		counts = append(counts, fmt.Sprintf("%s=%d", status, g.Counts[status]))
+	}
+	row(w, group, truncate(name, 30),
+		fmt.Sprintf("%d/%d", g.CompletedPoints, g.TotalPoints),
+		fmt.Sprintf("%d/%d", g.CompletedTasks, g.TotalTasks),
+		strings.Join(counts, " "))
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+)
+
+func (c *cli) scheduleCmd(_ context.Context, args []string) error {
+	name, rest, err := subcommand(args, "schedule", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "list", "ls":
+		return c.scheduleList(rest)
+	case "add":
+		return c.scheduleAdd(rest)
+	case "enable":
+		return c.scheduleSetEnabled(rest, true)
+	case "disable":
+		return c.scheduleSetEnabled(rest, false)
+	case "remove", "rm":
+		return c.scheduleRemove(rest)
+	default:
+		return unknownSubcommand("schedule", name, c.stderr)
+	}
+}
+
+func (c *cli) scheduleList(args []string) error {
+	fs := newFlagSet("schedule list", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	schedules, err := orchestrator.NewScheduleStore(env.Dir).List()
+	if err != nil {
+		return err
+	}
+	return c.out.render(schedules, func(w io.Writer) {
+		row(w, "ID", "ENABLED", "SCHEDULE", "NEXT RUN", "LAST RUN", "LAST TASK", "TITLE")
+		for _, s := range schedules {
+			row(w, s.ID, fmt.Sprintf("%t", s.Enabled), scheduleSpec(s), formatTimePtr(s.NextRunAt),
+				formatTimePtr(s.LastRunAt), s.LastTaskID, truncate(s.Title, 50))
+		}
+	})
+}
+
+func (c *cli) scheduleAdd(args []string) error {
+	fs := newFlagSet("schedule add", c.stderr)
+	cron := fs.String("cron", "", "Cron expression (minute hour day month weekday, or @daily, @weekly, ...)")
+	every := fs.String("every", "", "Run repeatedly this long after the previous run (e.g. 24h)")
+	after := fs.String("after", "", "Run once after this duration (e.g. 3h)")
+	at := fs.String("at", "", "Run once at this RFC3339 time")
+	pool := fs.String("pool", "", "Worker pool of the created tasks")
+	kind := fs.String("kind", "", "Kind of the created tasks (default: manual)")
+	tz := fs.String("tz", "", "Time zone for -cron (IANA name, default: local)")
+	missed := fs.String("missed", "", "Missed schedules after downtime: run_once (default) or skip")
+	disabled := fs.Bool("disabled", false, "Create the schedule disabled")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "schedule add (-cron E | -every D | -after D | -at T) [flags] <title>", c.stderr); err != nil {
+		return err
+	}
+	schedule := orchestrator.TaskSchedule{
+		Title:        strings.Join(fs.Args(), " "),
+		Kind:         *kind,
+		PoolID:       *pool,
+		Cron:         *cron,
+		Interval:     *every,
+		Timezone:     *tz,
+		MissedPolicy: orchestrator.MissedSchedulePolicy(*missed),
+		Enabled:      !*disabled,
+	}
+	switch {
+	case *after != "" && *at != "":
+		return fmt.Errorf("-after and -at cannot be combined")
+	case *after != "":
+		d, err := time.ParseDuration(*after)
+		if err != nil {
+			return fmt.Errorf("invalid -after %q: %w", *after, err)
+		}
+		runAt := time.Now().Add(d)
+		schedule.RunAt = &runAt
+	case *at != "":
+		runAt, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return fmt.Errorf("invalid -at %q (want RFC3339): %w", *at, err)
+		}
+		schedule.RunAt = &runAt
+	}
+
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	if err := orchestrator.NewScheduleStore(env.Dir).Save(&schedule); err != nil {
+		return err
+	}
+	return c.out.message(schedule, "Created schedule %s (next run: %s)", schedule.ID, formatTimePtr(schedule.NextRunAt))
+}
+
+func (c *cli) scheduleSetEnabled(args []string, enabled bool) error {
+	verb := "disable"
+	if enabled {
+		verb = "enable"
+	}
+	fs := newFlagSet("schedule "+verb, c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "schedule "+verb+" <id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	schedule, err := orchestrator.NewScheduleStore(env.Dir).SetEnabled(fs.Arg(0), enabled)
+	if err != nil {
+		return err
+	}
+	return c.out.message(schedule, "Schedule %s is now %sd (next run: %s)", schedule.ID, verb, formatTimePtr(schedule.NextRunAt))
+}
+
+func (c *cli) scheduleRemove(args []string) error {
+	fs := newFlagSet("schedule remove", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "schedule remove <id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	id := fs.Arg(0)
+	if err := orchestrator.NewScheduleStore(env.Dir).Delete(id); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"id": id}, "Removed schedule %s", id)
+}
+
+// scheduleSpec describes when a schedule runs for table output.
+func scheduleSpec(s orchestrator.TaskSchedule) string {
+	switch {
+	case s.Cron != "":
+		if s.Timezone != "" {
+			return s.Cron + " (" + s.Timezone + ")"
+		}
+		return s.Cron
+	case s.Interval != "":
+		return "every " + s.Interval
+	case s.RunAt != nil:
+		return "once at " + formatTime(*s.RunAt)
+	}
+	return "-"
+}
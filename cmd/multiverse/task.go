@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func (c *cli) taskCmd(ctx context.Context, args []string) error {
+	name, rest, err := subcommand(args, "task", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "list", "ls":
+		return c.taskList(rest)
+	case "show":
+		return c.taskShow(rest)
+	case "create":
+		return c.taskCreate(rest)
+	case "run":
+		return c.taskRun(rest)
+	case "cancel":
+		return c.taskCancel(ctx, rest)
+	case "logs":
+		return c.taskLogs(ctx, rest)
+	default:
+		return unknownSubcommand("task", name, c.stderr)
+	}
+}
+
+func (c *cli) taskList(args []string) error {
+	fs := newFlagSet("task list", c.stderr)
+	status := fs.String("status", "", "Only show tasks with this status (e.g. PENDING, RUNNING, FAILED)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	tasks, err := orchestrator.ListTaskViews(env.Repo)
+	if err != nil {
+		return err
+	}
+	if *status != "" {
+		filtered := make([]orchestrator.Task, 0, len(tasks))
+		for _, t := range tasks {
+			if strings.EqualFold(string(t.Status), *status) {
+				filtered = append(filtered, t)
+			}
+		}
+		tasks = filtered
+	}
+	return c.out.render(tasks, func(w io.Writer) {
+		row(w, "ID", "STATUS", "POOL", "PHASE", "TITLE")
+		for _, t := range tasks {
+			row(w, t.ID, string(t.Status), t.PoolID, t.PhaseName, truncate(t.Title, 60))
+		}
+	})
+}
+
+// taskDetail is the JSON shape of "task show".
+type taskDetail struct {
+	Task     *orchestrator.Task     `json:"task"`
+	Attempts []orchestrator.Attempt `json:"attempts"`
+}
+
+func (c *cli) taskShow(args []string) error {
+	fs := newFlagSet("task show", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "task show <task-id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	task, err := orchestrator.FindTaskView(env.Repo, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	attempts, err := orchestrator.ListTaskAttempts(env.Repo, task.ID)
+	if err != nil {
+		return err
+	}
+	if attempts == nil {
+		attempts = []orchestrator.Attempt{}
+	}
+
+	return c.out.render(taskDetail{Task: task, Attempts: attempts}, func(w io.Writer) {
+		field(w, "ID", task.ID)
+		field(w, "Title", task.Title)
+		field(w, "Status", string(task.Status))
+		field(w, "Pool", task.PoolID)
+		field(w, "Phase", task.PhaseName)
+		field(w, "Milestone", task.Milestone)
+		field(w, "Dependencies", strings.Join(task.Dependencies, ", "))
+		field(w, "Created", formatTime(task.CreatedAt))
+		field(w, "Updated", formatTime(task.UpdatedAt))
+		if task.Description != "" {
+			field(w, "Description", truncate(task.Description, 200))
+		}
+		for i, ac := range task.AcceptanceCriteria {
+			field(w, fmt.Sprintf("Acceptance[%d]", i+1), ac)
+		}
+		field(w, "Attempts", fmt.Sprintf("%d", len(attempts)))
+		for _, a := range attempts {
+			field(w, "  "+a.ID, fmt.Sprintf("%s  %s -> %s", a.Status, formatTime(a.StartedAt), formatTimePtr(a.FinishedAt)))
+		}
+	})
+}
+
+func (c *cli) taskCreate(args []string) error {
+	fs := newFlagSet("task create", c.stderr)
+	pool := fs.String("pool", orchestrator.DefaultPoolID, "Worker pool ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "task create [-pool P] <title>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	task, err := orchestrator.CreateManualTask(env.Repo, strings.Join(fs.Args(), " "), *pool)
+	if err != nil {
+		return err
+	}
+	return c.out.message(task, "Created task %s", task.ID)
+}
+
+func (c *cli) taskRun(args []string) error {
+	fs := newFlagSet("task run", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "task run <task-id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	taskID := fs.Arg(0)
+	if err := env.scheduler().ScheduleTask(taskID); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"queued": taskID}, "Queued task %s", taskID)
+}
+
+func (c *cli) taskCancel(ctx context.Context, args []string) error {
+	fs := newFlagSet("task cancel", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "task cancel <task-id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	taskID := fs.Arg(0)
+
+	// 実行中タスクの停止は実行ループを所有するデーモンにしかできないため、稼働中なら API 経由で取り消す
+	if client := env.daemonClient(ctx); client != nil {
+		if err := client.CancelTask(ctx, taskID); err != nil {
+			return err
+		}
+	} else if _, err := orchestrator.CancelTaskState(env.Repo, taskID); err != nil {
+		if errors.Is(err, orchestrator.ErrTaskRunning) {
+			return fmt.Errorf("task %s is running in an orchestrator without an API (e.g. the IDE); cancel it there", taskID)
+		}
+		return err
+	}
+	return c.out.message(map[string]string{"canceled": taskID}, "Canceled task %s", taskID)
+}
+
+// taskLogs prints the persisted stdout/stderr of a task attempt (the latest
+// one by default). With -f it keeps following the log until the attempt
+// finishes; the log is read from the workspace, so no daemon is needed.
+func (c *cli) taskLogs(ctx context.Context, args []string) error {
+	fs := newFlagSet("task logs", c.stderr)
+	follow := fs.Bool("f", false, "Follow the log until the attempt finishes")
+	attemptID := fs.String("attempt", "", "Attempt ID (default: the latest attempt of the task)")
+	from := fs.Int("from", 0, "First line to print (0-based line number)")
+	limit := fs.Int("limit", 0, "Maximum number of lines to print (default: all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "task logs [-f] [-attempt <id>] [-from <n>] [-limit <n>] <task-id>", c.stderr); err != nil {
+		return err
+	}
+	env, err := c.openWorkspace()
+	if err != nil {
+		return err
+	}
+	attempt, err := c.logAttempt(ctx, env, fs.Arg(0), *attemptID, *follow)
+	if err != nil {
+		return err
+	}
+
+	if *follow {
+		_, _ = fmt.Fprintf(c.stderr, "following attempt %s (%s)\n", attempt.ID, attempt.Status)
+		err := orchestrator.FollowAttemptLog(ctx, env.Repo, attempt.ID, *from, func(line persistence.AttemptLogLine) error {
+			return c.printLogLine(line)
+		})
+		if err != nil {
+			return err
+		}
+		if finished, err := orchestrator.GetAttempt(env.Repo, attempt.ID); err == nil {
+			_, _ = fmt.Fprintf(c.stderr, "attempt %s finished: %s\n", finished.ID, finished.Status)
+		}
+		return nil
+	}
+
+	lines := []persistence.AttemptLogLine{}
+	next := *from
+	for *limit <= 0 || len(lines) < *limit {
+		pageSize := 0
+		if *limit > 0 {
+			pageSize = *limit - len(lines)
+		}
+		page, err := orchestrator.ReadAttemptLog(env.Repo, attempt.ID, next, pageSize)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, page.Lines...)
+		next = page.Next
+		if len(page.Lines) == 0 || page.Complete {
+			break
+		}
+	}
+	return c.out.render(lines, func(w io.Writer) {
+		for _, line := range lines {
+			_, _ = fmt.Fprintln(w, line.Line)
+		}
+	})
+}
+
+// logAttempt resolves the attempt whose log task logs prints. Without an
+// explicit ID it picks the task's latest attempt; when following, it waits
+// for the first attempt of a task that has not started yet.
+func (c *cli) logAttempt(ctx context.Context, env *wsEnv, taskID, attemptID string, follow bool) (*orchestrator.Attempt, error) {
+	if attemptID != "" {
+		attempt, err := orchestrator.GetAttempt(env.Repo, attemptID)
+		if err != nil {
+			return nil, err
+		}
+		if attempt.TaskID != taskID {
+			return nil, fmt.Errorf("attempt %s belongs to task %s, not %s", attemptID, attempt.TaskID, taskID)
+		}
+		return attempt, nil
+	}
+	if _, err := orchestrator.FindTaskView(env.Repo, taskID); err != nil {
+		return nil, err
+	}
+	waiting := false
+	for {
+		attempt, err := orchestrator.LatestAttempt(env.Repo, taskID)
+		if err != nil || attempt != nil {
+			return attempt, err
+		}
+		if !follow {
+			return nil, fmt.Errorf("task %s has no attempts yet", taskID)
+		}
+		if !waiting {
+			_, _ = fmt.Fprintf(c.stderr, "waiting for task %s to start...\n", taskID)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// printLogLine writes one followed log line (a JSON object per line with -o json).
+func (c *cli) printLogLine(line persistence.AttemptLogLine) error {
+	if c.out.format == formatJSON {
+		return json.NewEncoder(c.out.w).Encode(line)
+	}
+	_, err := fmt.Fprintln(c.out.w, line.Line)
+	return err
+}
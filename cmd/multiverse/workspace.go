@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/daemon"
+	"github.com/biwakonbu/agent-runner/internal/ide"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// cli holds global options shared by all subcommands.
+type cli struct {
+	home      string
+	workspace string
+	out       *printer
+	stderr    io.Writer
+}
+
+// wsEnv is an opened workspace.
+type wsEnv struct {
+	ID   string
+	Dir  string
+	WS   *ide.Workspace
+	Repo persistence.WorkspaceRepository
+}
+
+func (c *cli) workspaceStore() *ide.WorkspaceStore {
+	return ide.NewWorkspaceStore(filepath.Join(c.home, "workspaces"))
+}
+
+// openWorkspace resolves the target workspace from -workspace (ID or project
+// root) or the current directory, and initializes its repository.
+func (c *cli) openWorkspace() (*wsEnv, error) {
+	store := c.workspaceStore()
+
+	ref := c.workspace
+	if ref == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		ref = cwd
+	}
+
+	id := ref
+	ws, err := store.LoadWorkspace(id)
+	if err != nil {
+		// Not an ID: treat as a project root
+		root, absErr := filepath.Abs(ref)
+		if absErr != nil {
+			return nil, fmt.Errorf("failed to resolve project root: %w", absErr)
+		}
+		id = store.GetWorkspaceID(root)
+		ws, err = store.LoadWorkspace(id)
+		if err != nil {
+			return nil, fmt.Errorf("no workspace for %s (run 'multiverse workspace open %s' first)", ref, ref)
+		}
+	}
+
+	dir := store.GetWorkspaceDir(id)
+	repo := persistence.NewWorkspaceRepository(dir)
+	if err := repo.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize repository: %w", err)
+	}
+	if _, err := orchestrator.MigrateWorkspace(repo); err != nil {
+		return nil, fmt.Errorf("failed to migrate workspace: %w", err)
+	}
+	return &wsEnv{ID: id, Dir: dir, WS: ws, Repo: repo}, nil
+}
+
+// scheduler returns a scheduler that enqueues into the workspace's filesystem queue.
+func (env *wsEnv) scheduler() *orchestrator.Scheduler {
+	return orchestrator.NewScheduler(env.Repo, ipc.NewFilesystemQueue(env.Dir), nil)
+}
+
+// daemonClient returns a client for the workspace's running daemon, or nil if
+// no daemon API is reachable.
+func (env *wsEnv) daemonClient(ctx context.Context) *daemon.Client {
+	client, err := daemon.DiscoverClient(env.Dir)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if _, err := client.Health(ctx); err != nil {
+		return nil
+	}
+	return client
+}
+
+func (c *cli) workspaceCmd(_ context.Context, args []string) error {
+	name, rest, err := subcommand(args, "workspace", c.stderr)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "open":
+		return c.workspaceOpen(rest)
+	case "list", "ls":
+		return c.workspaceList(rest)
+	case "remove", "rm":
+		return c.workspaceRemove(rest)
+	default:
+		return unknownSubcommand("workspace", name, c.stderr)
+	}
+}
+
+// workspaceOpen registers a project root as a workspace (same as the IDE's
+// "Select Project Root"), or updates lastOpenedAt if it already exists.
+func (c *cli) workspaceOpen(args []string) error {
+	fs := newFlagSet("workspace open", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	root := fs.Arg(0)
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("project root is not a directory: %s", root)
+	}
+
+	store := c.workspaceStore()
+	id := store.GetWorkspaceID(root)
+	ws, err := store.LoadWorkspace(id)
+	if err != nil {
+		now := time.Now()
+		ws = &ide.Workspace{
+			Version:      "1.0",
+			ProjectRoot:  root,
+			DisplayName:  filepath.Base(root),
+			CreatedAt:    now,
+			LastOpenedAt: now,
+		}
+	} else {
+		ws.LastOpenedAt = time.Now()
+	}
+	if err := store.SaveWorkspace(ws); err != nil {
+		return err
+	}
+	repo := persistence.NewWorkspaceRepository(store.GetWorkspaceDir(id))
+	if err := repo.Init(); err != nil {
+		return fmt.Errorf("failed to initialize repository: %w", err)
+	}
+	if _, err := orchestrator.MigrateWorkspace(repo); err != nil {
+		return fmt.Errorf("failed to migrate workspace: %w", err)
+	}
+
+	summary := ide.WorkspaceSummary{ID: id, DisplayName: ws.DisplayName, ProjectRoot: ws.ProjectRoot, LastOpenedAt: ws.LastOpenedAt}
+	return c.out.message(summary, "Opened workspace %s (%s)", id, root)
+}
+
+func (c *cli) workspaceList(args []string) error {
+	fs := newFlagSet("workspace list", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	summaries, err := c.workspaceStore().ListWorkspaces()
+	if err != nil {
+		return err
+	}
+	return c.out.render(summaries, func(w io.Writer) {
+		row(w, "ID", "NAME", "PROJECT ROOT", "LAST OPENED")
+		for _, s := range summaries {
+			row(w, s.ID, s.DisplayName, s.ProjectRoot, formatTime(s.LastOpenedAt))
+		}
+	})
+}
+
+func (c *cli) workspaceRemove(args []string) error {
+	fs := newFlagSet("workspace remove", c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "workspace remove <id>", c.stderr); err != nil {
+		return err
+	}
+	id := fs.Arg(0)
+	dir := c.workspaceStore().GetWorkspaceDir(id)
+	if info, ok := persistence.ActiveLeader(dir, persistence.DefaultLeaderStaleAfter); ok {
+		return fmt.Errorf("workspace is in use by an orchestrator (%s); stop it first", info.String())
+	}
+	if err := c.workspaceStore().RemoveWorkspace(id); err != nil {
+		return err
+	}
+	return c.out.message(map[string]string{"removed": id}, "Removed workspace %s", id)
+}
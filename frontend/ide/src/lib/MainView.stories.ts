@@ -68,6 +68,7 @@ function countByStatus(tasks: Task[]): Record<TaskStatus, number> {
     CANCELED: 0,
     BLOCKED: 0,
     RETRY_WAIT: 0,
+    SKIPPED: 0,
   };
   for (const task of tasks) {
     counts[task.status]++;
@@ -381,6 +382,7 @@ export const EmptyProject: Story = {
       CANCELED: 0,
       BLOCKED: 0,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
     selectedTask: null,
     showChat: true,
@@ -56,6 +56,7 @@ export const Default: Story = {
       CANCELED: 0,
       BLOCKED: 0,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
   },
 };
@@ -79,6 +80,7 @@ export const WithPoolSummaries: Story = {
       CANCELED: 0,
       BLOCKED: 0,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
   },
   parameters: {
@@ -106,6 +108,7 @@ export const WithStatusSummary: Story = {
       CANCELED: 0,
       BLOCKED: 0,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
   },
   parameters: {
@@ -135,6 +138,7 @@ export const HighProgress: Story = {
       CANCELED: 0,
       BLOCKED: 0,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
   },
   parameters: {
@@ -165,6 +169,7 @@ export const AllCompleted: Story = {
       CANCELED: 0,
       BLOCKED: 0,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
   },
   parameters: {
@@ -194,6 +199,7 @@ export const WBSMode: Story = {
       CANCELED: 0,
       BLOCKED: 4,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
   },
   parameters: {
@@ -224,6 +230,7 @@ export const ManyFailures: Story = {
       CANCELED: 2,
       BLOCKED: 0,
       RETRY_WAIT: 0,
+      SKIPPED: 0,
     },
   },
   parameters: {
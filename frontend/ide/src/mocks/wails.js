@@ -93,11 +93,53 @@ export function ListAttempts(taskId) {
     return Promise.resolve([]);
 }
 
-export function GetPoolSummaries() {
-    console.log("[Mock] GetPoolSummaries called");
+export function GetAttemptLog(attemptId, from, limit) {
+    console.log("[Mock] GetAttemptLog called", attemptId, from, limit);
+    return Promise.resolve({ attempt_id: attemptId, lines: [], next: from || 0, complete: true });
+}
+
+export function GetDependencyAnalysis() {
+    console.log("[Mock] GetDependencyAnalysis called");
+    return Promise.resolve({ order: [], cycles: [], unknownRefs: [], criticalPath: [], criticalPathWeight: 0, blockedByFailure: {} });
+}
+
+export function ListStaleNodes() {
+    console.log("[Mock] ListStaleNodes called");
     return Promise.resolve([]);
 }
 
+export function ReverifyNode(nodeId) {
+    console.log("[Mock] ReverifyNode called", nodeId);
+    return Promise.resolve("");
+}
+
+export function GetProgressReport(windowDays) {
+    console.log("[Mock] GetProgressReport called", windowDays);
+    const days = windowDays > 0 ? windowDays : 14;
+    const today = new Date();
+    today.setHours(0, 0, 0, 0);
+    const emptyGroup = (name) => ({ name, totalPoints: 0, completedPoints: 0, totalTasks: 0, completedTasks: 0, counts: {} });
+    return Promise.resolve({
+        generatedAt: new Date().toISOString(),
+        total: emptyGroup(""),
+        milestones: [],
+        phases: [],
+        pools: [],
+        throughput: Array.from({ length: days }, (_, i) => ({
+            date: new Date(today.getTime() - (days - 1 - i) * 86400000).toISOString(),
+            tasks: 0,
+            points: 0,
+        })),
+        windowDays: days,
+        pointsPerDay: 0,
+    });
+}
+
+export function GetCalibrationReport() {
+    console.log("[Mock] GetCalibrationReport called");
+    return Promise.resolve({ generatedAt: new Date().toISOString(), nodes: 0, byKind: [], byDifficulty: [] });
+}
+
 export function GetAvailablePools() {
     console.log("[Mock] GetAvailablePools called");
     return Promise.resolve([
@@ -215,6 +257,11 @@ export function StopExecution() {
     return Promise.resolve();
 }
 
+export function GetExecutionOwner() {
+    console.log("[Mock] GetExecutionOwner called");
+    return Promise.resolve(null);
+}
+
 export function GetExecutionState() {
     console.log("[Mock] GetExecutionState called");
     return Promise.resolve(executionState);
@@ -227,16 +274,64 @@ export function GetBacklogItems() {
     return Promise.resolve(items);
 }
 
+export function ListSnapshots() {
+    console.log("[Mock] ListSnapshots called");
+    return Promise.resolve(JSON.parse(window.localStorage.getItem('mock_snapshots') || '[]'));
+}
+
+export function CreateSnapshot(description) {
+    console.log("[Mock] CreateSnapshot called", description);
+    const snapshots = JSON.parse(window.localStorage.getItem('mock_snapshots') || '[]');
+    const snapshot = {
+        id: `mock-snapshot-${Date.now()}`,
+        description,
+        created_at: new Date().toISOString(),
+        sources: ['design', 'state', 'tasks', 'backlog'],
+    };
+    window.localStorage.setItem('mock_snapshots', JSON.stringify([snapshot, ...snapshots]));
+    return Promise.resolve(snapshot);
+}
+
+export function DiffSnapshots(fromId, toId) {
+    console.log("[Mock] DiffSnapshots called", fromId, toId);
+    const empty = () => ({ added: [], removed: [], changed: [] });
+    return Promise.resolve({
+        from: fromId,
+        to: toId,
+        tasks: empty(),
+        nodes: empty(),
+        nodes_runtime: empty(),
+        backlog: empty(),
+    });
+}
+
+export function RestoreSnapshot(id) {
+    console.log("[Mock] RestoreSnapshot called", id);
+    return Promise.resolve();
+}
+
 export function ResolveBacklogItem(id, resolution) {
     console.log("[Mock] ResolveBacklogItem called", id, resolution);
     const items = JSON.parse(window.localStorage.getItem('mock_backlog') || '[]');
     const index = items.findIndex(i => i.id === id);
-    if (index >= 0) {
-        items[index].resolvedAt = new Date().toISOString();
-        items[index].resolution = resolution;
-        window.localStorage.setItem('mock_backlog', JSON.stringify(items));
+    if (index < 0) {
+        return Promise.reject(new Error(`backlog item not found: ${id}`));
     }
-    return Promise.resolve();
+    const action = resolution.action || (items[index].type === 'QUESTION' ? 'answer' : 'note');
+    items[index].resolvedAt = new Date().toISOString();
+    items[index].resolution = resolution.note || '';
+    items[index].resolutionAction = action;
+    window.localStorage.setItem('mock_backlog', JSON.stringify(items));
+    const taskStatus = {
+        retry: 'PENDING',
+        retry_edited: 'PENDING',
+        retry_tooling: 'PENDING',
+        skip: 'SKIPPED',
+        split: 'CANCELED',
+        abandon: 'CANCELED',
+        approve: 'PENDING',
+    }[action];
+    return Promise.resolve({ item: items[index], taskStatus });
 }
 
 export function DeleteBacklogItem(id) {
@@ -246,3 +341,44 @@ export function DeleteBacklogItem(id) {
     window.localStorage.setItem('mock_backlog', JSON.stringify(filtered));
     return Promise.resolve();
 }
+
+export function ListTaskSchedules() {
+    console.log("[Mock] ListTaskSchedules called");
+    return Promise.resolve(JSON.parse(window.localStorage.getItem('mock_schedules') || '[]'));
+}
+
+export function SaveTaskSchedule(schedule) {
+    console.log("[Mock] SaveTaskSchedule called", schedule);
+    const schedules = JSON.parse(window.localStorage.getItem('mock_schedules') || '[]');
+    const now = new Date().toISOString();
+    const saved = { ...schedule, id: schedule.id || `schedule-${Date.now()}`, updatedAt: now };
+    saved.createdAt = saved.createdAt || now;
+    const rest = schedules.filter(s => s.id !== saved.id);
+    window.localStorage.setItem('mock_schedules', JSON.stringify([...rest, saved]));
+    return Promise.resolve(saved);
+}
+
+export function SetTaskScheduleEnabled(id, enabled) {
+    console.log("[Mock] SetTaskScheduleEnabled called", id, enabled);
+    const schedules = JSON.parse(window.localStorage.getItem('mock_schedules') || '[]');
+    const schedule = schedules.find(s => s.id === id);
+    if (!schedule) {
+        return Promise.reject(new Error(`schedule not found: ${id}`));
+    }
+    schedule.enabled = enabled;
+    window.localStorage.setItem('mock_schedules', JSON.stringify(schedules));
+    return Promise.resolve(schedule);
+}
+
+export function DeleteTaskSchedule(id) {
+    console.log("[Mock] DeleteTaskSchedule called", id);
+    const schedules = JSON.parse(window.localStorage.getItem('mock_schedules') || '[]');
+    window.localStorage.setItem('mock_schedules', JSON.stringify(schedules.filter(s => s.id !== id)));
+    return Promise.resolve();
+}
+
+export function ListPendingApprovals() {
+    console.log("[Mock] ListPendingApprovals called");
+    const items = JSON.parse(window.localStorage.getItem('mock_backlog') || '[]');
+    return Promise.resolve(items.filter(i => i.type === 'APPROVAL' && !i.resolvedAt));
+}
@@ -18,6 +18,7 @@ export const TaskStatusSchema = z.enum([
   'CANCELED',
   'BLOCKED',
   'RETRY_WAIT',
+  'SKIPPED',
 ]);
 
 export type TaskStatus = z.infer<typeof TaskStatusSchema>;
@@ -135,6 +136,7 @@ export const statusLabels: Record<TaskStatus, string> = {
   CANCELED: 'キャンセル',
   BLOCKED: 'ブロック',
   RETRY_WAIT: 'リトライ待機',
+  SKIPPED: 'スキップ',
 };
 
 // AttemptStatus スキーマ
@@ -182,3 +184,36 @@ export const PoolSummarySchema = z.object({
 });
 
 export type PoolSummary = z.infer<typeof PoolSummarySchema>;
+
+// ProgressGroup スキーマ（マイルストーン・フェーズ単位の進捗、name が空は未設定）
+export const ProgressGroupSchema = z.object({
+  name: z.string(),
+  totalPoints: z.number(),
+  completedPoints: z.number(),
+  totalTasks: z.number(),
+  completedTasks: z.number(),
+  counts: z.record(z.string(), z.number()),
+});
+
+export type ProgressGroup = z.infer<typeof ProgressGroupSchema>;
+
+// ProgressReport スキーマ（進捗とバーンダウン用のスループット）
+export const ProgressReportSchema = z.object({
+  generatedAt: z.string(),
+  total: ProgressGroupSchema,
+  milestones: z.array(ProgressGroupSchema),
+  phases: z.array(ProgressGroupSchema),
+  pools: z.array(PoolSummarySchema),
+  throughput: z.array(
+    z.object({
+      date: z.string(),
+      tasks: z.number(),
+      points: z.number(),
+    })
+  ),
+  windowDays: z.number(),
+  pointsPerDay: z.number(),
+  projectedCompletion: z.string().optional(),
+});
+
+export type ProgressReport = z.infer<typeof ProgressReportSchema>;
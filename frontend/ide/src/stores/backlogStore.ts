@@ -17,8 +17,23 @@ import { Logger } from '../services/logger';
 
 const log = Logger.withComponent('BacklogStore');
 
-export type BacklogType = 'FAILURE' | 'QUESTION' | 'BLOCKER';
+export type BacklogType = 'FAILURE' | 'QUESTION' | 'BLOCKER' | 'APPROVAL';
 export type BacklogItem = orchestrator.BacklogItem;
+export type BacklogResolution = orchestrator.BacklogResolution;
+export type BacklogResolutionResult = orchestrator.BacklogResolutionResult;
+
+// 解決時の操作（Go の orchestrator.BacklogAction と対応）
+export type BacklogAction =
+    | 'note'
+    | 'retry'
+    | 'retry_edited'
+    | 'retry_tooling'
+    | 'skip'
+    | 'split'
+    | 'abandon'
+    | 'answer'
+    | 'approve'
+    | 'reject';
 
 // バックログアイテム一覧ストア
 function createBacklogStore() {
@@ -88,12 +103,16 @@ export async function loadBacklogItems(): Promise<void> {
 }
 
 // バックログアイテムを解決
-export async function resolveItem(id: string, resolution: string = 'Resolved'): Promise<void> {
+export async function resolveItem(
+    id: string,
+    resolution: BacklogResolution = { note: 'Resolved' }
+): Promise<BacklogResolutionResult> {
     try {
-        log.info('resolving backlog item', { id, resolution });
-        await WailsResolveBacklogItem(id, resolution);
+        log.info('resolving backlog item', { id, action: resolution.action });
+        const result = await WailsResolveBacklogItem(id, resolution);
         // 成功したらリストから削除（または再読み込み）
         backlogItems.removeItem(id);
+        return result;
     } catch (error) {
         log.error('failed to resolve backlog item', { id, error });
         throw error;
@@ -120,6 +139,12 @@ export function initBacklogEvents(): void {
         backlogItems.addItem(item);
     });
 
+    // backlog:resolved イベントをリッスン（CLI やデーモンからの解決も反映する）
+    EventsOn('backlog:resolved', (item: BacklogItem) => {
+        log.info('backlog item resolved via event', { id: item.id, action: item.resolutionAction });
+        backlogItems.removeItem(item.id);
+    });
+
     // 初期データを読み込み
     loadBacklogItems();
 
@@ -44,6 +44,7 @@ export const getTaskLogs = (taskId: string) => derived(logs, $logs => $logs.filt
 
 interface TaskLogEvent {
     taskId: string;
+    attemptId?: string;
     stream: 'stdout' | 'stderr';
     line: string;
     timestamp: string;
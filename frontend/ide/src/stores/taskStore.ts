@@ -6,7 +6,7 @@
  */
 
 import { writable, derived } from 'svelte/store';
-import type { Task, TaskNode, TaskStatus, PoolSummary } from '../types';
+import type { Task, TaskNode, TaskStatus, PoolSummary, ProgressReport } from '../types';
 import { grid, gridToCanvas } from '../design-system';
 import { Logger } from '../services/logger';
 import { EventsOn } from '../../wailsjs/runtime/runtime';
@@ -107,6 +107,7 @@ export const taskCountsByStatus = derived(tasks, ($tasks) => {
     CANCELED: 0,
     BLOCKED: 0,
     RETRY_WAIT: 0,
+    SKIPPED: 0,
   };
 
   for (const task of $tasks) {
@@ -144,7 +145,7 @@ export interface TaskEdge {
 export const taskEdges = derived(tasks, ($tasks): TaskEdge[] => {
   const edges: TaskEdge[] = [];
   const taskMap = new Map($tasks.map((t) => [t.id, t]));
-  const completedStatuses = new Set(['SUCCEEDED', 'COMPLETED', 'CANCELED']);
+  const completedStatuses = new Set(['SUCCEEDED', 'COMPLETED', 'CANCELED', 'SKIPPED']);
   const missingDeps: string[] = [];
 
   for (const task of $tasks) {
@@ -209,6 +210,9 @@ function createPoolSummariesStore() {
 
 export const poolSummaries = createPoolSummariesStore();
 
+// 進捗レポートストア（マイルストーン・フェーズの集計と完了見込み）
+export const progressReport = writable<ProgressReport | null>(null);
+
 // タスク状態変更イベントの型
 interface TaskStateChangeEvent {
   taskId: string;
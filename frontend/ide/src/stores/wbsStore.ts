@@ -143,7 +143,7 @@ export const viewMode = createViewModeStore();
 
 // タスクの完了判定
 function isTaskCompleted(status: TaskStatus): boolean {
-  return status === 'SUCCEEDED' || status === 'COMPLETED' || status === 'CANCELED';
+  return status === 'SUCCEEDED' || status === 'COMPLETED' || status === 'CANCELED' || status === 'SKIPPED';
 }
 
 // 進捗を計算
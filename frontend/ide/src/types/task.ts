@@ -16,4 +16,6 @@ export {
   type Attempt,
   attemptStatusLabels,
   type PoolSummary,
+  type ProgressGroup,
+  type ProgressReport,
 } from '../schemas';
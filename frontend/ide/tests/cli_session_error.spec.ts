@@ -23,7 +23,7 @@ test.describe('E2E: CLI Session Validation', () => {
         main: {
           App: {
             ListTasks: async () => [],
-            GetPoolSummaries: async () => [],
+            GetProgressReport: async () => null,
             SelectWorkspace: async () => "mock-workspace-id",
             GetExecutionState: async () => "IDLE",
             StartExecution: async () => {}, // Default success
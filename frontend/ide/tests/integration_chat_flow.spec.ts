@@ -33,14 +33,14 @@ test.describe('Integration: Chat Flow & Real-time Updates', () => {
         }
       };
       
-      // Mock Backend API calls (ListTasks, GetPoolSummaries)
+      // Mock Backend API calls (ListTasks, GetProgressReport)
       // Assuming the App calls these on mount. We return empty first.
       // We need to match the structure the app expects (window.go.main.App...)
       (window as any).go = {
         main: {
           App: {
             ListTasks: async () => [],
-            GetPoolSummaries: async () => [],
+            GetProgressReport: async () => null,
             GetExecutionState: async () => "IDLE",
             StartExecution: async () => {},
             StopExecution: async () => {},
@@ -26,7 +26,7 @@ test.describe('Integration: Chat Scroll Behavior', () => {
         main: {
           App: {
             ListTasks: async () => [],
-            GetPoolSummaries: async () => [],
+            GetProgressReport: async () => null,
             GetExecutionState: async () => "IDLE",
             StartExecution: async () => {},
             StopExecution: async () => {},
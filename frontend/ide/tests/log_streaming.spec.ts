@@ -23,7 +23,7 @@ test.describe('E2E: Log Streaming', () => {
         main: {
           App: {
             ListTasks: async () => [],
-            GetPoolSummaries: async () => [],
+            GetProgressReport: async () => null,
             GetExecutionState: async () => "RUNNING", // Simulate running state
             StartExecution: async () => {}, 
             StopExecution: async () => {},
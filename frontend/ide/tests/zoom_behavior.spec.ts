@@ -23,7 +23,7 @@ test.describe('Zoom Behavior', () => {
         main: {
           App: {
             ListTasks: async () => [],
-            GetPoolSummaries: async () => [],
+            GetProgressReport: async () => null,
             GetExecutionState: async () => "IDLE",
             StartExecution: async () => {},
             StopExecution: async () => {},
@@ -4,15 +4,26 @@ import {chat} from '../models';
 import {orchestrator} from '../models';
 import {main} from '../models';
 import {ide} from '../models';
+import {persistence} from '../models';
+
+export function CheckAgentsHealth():Promise<Array<persistence.AgentState>>;
 
 export function CreateChatSession():Promise<chat.ChatSession>;
 
+export function CreateSnapshot(arg1:string):Promise<persistence.Snapshot>;
+
 export function CreateTask(arg1:string,arg2:string):Promise<orchestrator.Task>;
 
 export function DeleteBacklogItem(arg1:string):Promise<void>;
 
+export function DeleteTaskSchedule(arg1:string):Promise<void>;
+
+export function DiffSnapshots(arg1:string,arg2:string):Promise<persistence.SnapshotDiff>;
+
 export function GetAllBacklogItems():Promise<Array<orchestrator.BacklogItem>>;
 
+export function GetAttemptLog(arg1:string,arg2:number,arg3:number):Promise<persistence.AttemptLogPage>;
+
 export function GetAvailableModels():Promise<Array<main.ModelOptionDTO>>;
 
 export function GetAvailablePools():Promise<Array<orchestrator.Pool>>;
@@ -21,44 +32,70 @@ export function GetAvailableTools():Promise<Array<main.ToolOptionDTO>>;
 
 export function GetBacklogItems():Promise<Array<orchestrator.BacklogItem>>;
 
+export function GetCalibrationReport():Promise<orchestrator.CalibrationReport>;
+
 export function GetChatHistory(arg1:string):Promise<Array<chat.ChatMessage>>;
 
+export function GetDependencyAnalysis():Promise<orchestrator.DependencyAnalysis>;
+
+export function GetExecutionOwner():Promise<main.ExecutionOwnerDTO>;
+
 export function GetExecutionState():Promise<string>;
 
 export function GetLLMConfig():Promise<main.LLMConfigDTO>;
 
 export function GetModelsForTool(arg1:string):Promise<Array<main.ModelOptionDTO>>;
 
-export function GetPoolSummaries():Promise<Array<orchestrator.PoolSummary>>;
+export function GetProgressReport(arg1:number):Promise<orchestrator.ProgressReport>;
 
 export function GetToolingConfigJSON():Promise<string>;
 
 export function GetWorkspace(arg1:string):Promise<ide.Workspace>;
 
+export function ListAgents():Promise<Array<persistence.AgentState>>;
+
 export function ListAttempts(arg1:string):Promise<Array<orchestrator.Attempt>>;
 
+export function ListPendingApprovals():Promise<Array<orchestrator.BacklogItem>>;
+
 export function ListRecentWorkspaces():Promise<Array<ide.WorkspaceSummary>>;
 
+export function ListSnapshots():Promise<Array<persistence.Snapshot>>;
+
+export function ListStaleNodes():Promise<Array<orchestrator.NodeImpact>>;
+
+export function ListTaskSchedules():Promise<Array<orchestrator.TaskSchedule>>;
+
 export function ListTasks():Promise<Array<orchestrator.Task>>;
 
 export function OpenWorkspaceByID(arg1:string):Promise<string>;
 
 export function PauseExecution():Promise<void>;
 
+export function RegisterAgent(arg1:persistence.AgentState):Promise<void>;
+
 export function RemoveWorkspace(arg1:string):Promise<void>;
 
-export function ResolveBacklogItem(arg1:string,arg2:string):Promise<void>;
+export function ResolveBacklogItem(arg1:string,arg2:orchestrator.BacklogResolution):Promise<orchestrator.BacklogResolutionResult>;
+
+export function RestoreSnapshot(arg1:string):Promise<void>;
 
 export function ResumeExecution():Promise<void>;
 
+export function ReverifyNode(arg1:string):Promise<string>;
+
 export function RunTask(arg1:string):Promise<void>;
 
+export function SaveTaskSchedule(arg1:orchestrator.TaskSchedule):Promise<orchestrator.TaskSchedule>;
+
 export function SelectWorkspace():Promise<string>;
 
 export function SendChatMessage(arg1:string,arg2:string):Promise<main.ChatResponseDTO>;
 
 export function SetLLMConfig(arg1:main.LLMConfigDTO):Promise<void>;
 
+export function SetTaskScheduleEnabled(arg1:string,arg2:boolean):Promise<orchestrator.TaskSchedule>;
+
 export function SetToolingConfigJSON(arg1:string):Promise<void>;
 
 export function StartExecution():Promise<void>;
@@ -67,4 +104,6 @@ export function StopExecution():Promise<void>;
 
 export function TestLLMConnection():Promise<string>;
 
+export function UnregisterAgent(arg1:string):Promise<void>;
+
 export function ValidateToolModelCombination(arg1:string,arg2:string):Promise<boolean>;
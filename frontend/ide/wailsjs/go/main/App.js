@@ -2,10 +2,18 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CheckAgentsHealth() {
+  return window['go']['main']['App']['CheckAgentsHealth']();
+}
+
 export function CreateChatSession() {
   return window['go']['main']['App']['CreateChatSession']();
 }
 
+export function CreateSnapshot(arg1) {
+  return window['go']['main']['App']['CreateSnapshot'](arg1);
+}
+
 export function CreateTask(arg1, arg2) {
   return window['go']['main']['App']['CreateTask'](arg1, arg2);
 }
@@ -14,10 +22,22 @@ export function DeleteBacklogItem(arg1) {
   return window['go']['main']['App']['DeleteBacklogItem'](arg1);
 }
 
+export function DeleteTaskSchedule(arg1) {
+  return window['go']['main']['App']['DeleteTaskSchedule'](arg1);
+}
+
+export function DiffSnapshots(arg1, arg2) {
+  return window['go']['main']['App']['DiffSnapshots'](arg1, arg2);
+}
+
 export function GetAllBacklogItems() {
   return window['go']['main']['App']['GetAllBacklogItems']();
 }
 
+export function GetAttemptLog(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetAttemptLog'](arg1, arg2, arg3);
+}
+
 export function GetAvailableModels() {
   return window['go']['main']['App']['GetAvailableModels']();
 }
@@ -34,10 +54,22 @@ export function GetBacklogItems() {
   return window['go']['main']['App']['GetBacklogItems']();
 }
 
+export function GetCalibrationReport() {
+  return window['go']['main']['App']['GetCalibrationReport']();
+}
+
 export function GetChatHistory(arg1) {
   return window['go']['main']['App']['GetChatHistory'](arg1);
 }
 
+export function GetDependencyAnalysis() {
+  return window['go']['main']['App']['GetDependencyAnalysis']();
+}
+
+export function GetExecutionOwner() {
+  return window['go']['main']['App']['GetExecutionOwner']();
+}
+
 export function GetExecutionState() {
   return window['go']['main']['App']['GetExecutionState']();
 }
@@ -50,8 +82,8 @@ export function GetModelsForTool(arg1) {
   return window['go']['main']['App']['GetModelsForTool'](arg1);
 }
 
-export function GetPoolSummaries() {
-  return window['go']['main']['App']['GetPoolSummaries']();
+export function GetProgressReport(arg1) {
+  return window['go']['main']['App']['GetProgressReport'](arg1);
 }
 
 export function GetToolingConfigJSON() {
@@ -62,14 +94,34 @@ export function GetWorkspace(arg1) {
   return window['go']['main']['App']['GetWorkspace'](arg1);
 }
 
+export function ListAgents() {
+  return window['go']['main']['App']['ListAgents']();
+}
+
 export function ListAttempts(arg1) {
   return window['go']['main']['App']['ListAttempts'](arg1);
 }
 
+export function ListPendingApprovals() {
+  return window['go']['main']['App']['ListPendingApprovals']();
+}
+
 export function ListRecentWorkspaces() {
   return window['go']['main']['App']['ListRecentWorkspaces']();
 }
 
+export function ListSnapshots() {
+  return window['go']['main']['App']['ListSnapshots']();
+}
+
+export function ListStaleNodes() {
+  return window['go']['main']['App']['ListStaleNodes']();
+}
+
+export function ListTaskSchedules() {
+  return window['go']['main']['App']['ListTaskSchedules']();
+}
+
 export function ListTasks() {
   return window['go']['main']['App']['ListTasks']();
 }
@@ -82,6 +134,10 @@ export function PauseExecution() {
   return window['go']['main']['App']['PauseExecution']();
 }
 
+export function RegisterAgent(arg1) {
+  return window['go']['main']['App']['RegisterAgent'](arg1);
+}
+
 export function RemoveWorkspace(arg1) {
   return window['go']['main']['App']['RemoveWorkspace'](arg1);
 }
@@ -90,14 +146,26 @@ export function ResolveBacklogItem(arg1, arg2) {
   return window['go']['main']['App']['ResolveBacklogItem'](arg1, arg2);
 }
 
+export function RestoreSnapshot(arg1) {
+  return window['go']['main']['App']['RestoreSnapshot'](arg1);
+}
+
 export function ResumeExecution() {
   return window['go']['main']['App']['ResumeExecution']();
 }
 
+export function ReverifyNode(arg1) {
+  return window['go']['main']['App']['ReverifyNode'](arg1);
+}
+
 export function RunTask(arg1) {
   return window['go']['main']['App']['RunTask'](arg1);
 }
 
+export function SaveTaskSchedule(arg1) {
+  return window['go']['main']['App']['SaveTaskSchedule'](arg1);
+}
+
 export function SelectWorkspace() {
   return window['go']['main']['App']['SelectWorkspace']();
 }
@@ -110,6 +178,10 @@ export function SetLLMConfig(arg1) {
   return window['go']['main']['App']['SetLLMConfig'](arg1);
 }
 
+export function SetTaskScheduleEnabled(arg1, arg2) {
+  return window['go']['main']['App']['SetTaskScheduleEnabled'](arg1, arg2);
+}
+
 export function SetToolingConfigJSON(arg1) {
   return window['go']['main']['App']['SetToolingConfigJSON'](arg1);
 }
@@ -126,6 +198,10 @@ export function TestLLMConnection() {
   return window['go']['main']['App']['TestLLMConnection']();
 }
 
+export function UnregisterAgent(arg1) {
+  return window['go']['main']['App']['UnregisterAgent'](arg1);
+}
+
 export function ValidateToolModelCombination(arg1, arg2) {
   return window['go']['main']['App']['ValidateToolModelCombination'](arg1, arg2);
 }
@@ -204,6 +204,28 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class ExecutionOwnerDTO {
+	    role: string;
+	    pid: number;
+	    hostname: string;
+	    state: string;
+	    heartbeatAt: string;
+	    external: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExecutionOwnerDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.role = source["role"];
+	        this.pid = source["pid"];
+	        this.hostname = source["hostname"];
+	        this.state = source["state"];
+	        this.heartbeatAt = source["heartbeatAt"];
+	        this.external = source["external"];
+	    }
+	}
 	export class LLMConfigDTO {
 	    kind: string;
 	    model: string;
@@ -282,9 +304,26 @@ export namespace meta {
 
 export namespace orchestrator {
 	
+	export class FileDiff {
+	    path: string;
+	    patch?: string;
+	    truncated?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.patch = source["patch"];
+	        this.truncated = source["truncated"];
+	    }
+	}
 	export class Artifacts {
 	    files?: string[];
 	    logs?: string[];
+	    diffs?: FileDiff[];
 	
 	    static createFrom(source: any = {}) {
 	        return new Artifacts(source);
@@ -294,17 +333,45 @@ export namespace orchestrator {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.files = source["files"];
 	        this.logs = source["logs"];
+	        this.diffs = this.convertValues(source["diffs"], FileDiff);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class Attempt {
 	    id: string;
 	    taskId: string;
+	    poolId?: string;
 	    status: string;
 	    // Go type: time
 	    startedAt: any;
 	    // Go type: time
 	    finishedAt?: any;
+	    exitCode?: number;
 	    errorSummary?: string;
+	    tooling?: AttemptTooling;
+	    artifacts?: Artifacts;
+	    logLines: number;
+	    workerRuns?: number;
+	    inputTokens?: number;
+	    outputTokens?: number;
+	    costUsd?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new Attempt(source);
@@ -314,10 +381,19 @@ export namespace orchestrator {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
 	        this.taskId = source["taskId"];
+	        this.poolId = source["poolId"];
 	        this.status = source["status"];
 	        this.startedAt = this.convertValues(source["startedAt"], null);
 	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	        this.exitCode = source["exitCode"];
 	        this.errorSummary = source["errorSummary"];
+	        this.tooling = this.convertValues(source["tooling"], AttemptTooling);
+	        this.artifacts = this.convertValues(source["artifacts"], Artifacts);
+	        this.logLines = source["logLines"];
+	        this.workerRuns = source["workerRuns"];
+	        this.inputTokens = source["inputTokens"];
+	        this.outputTokens = source["outputTokens"];
+	        this.costUsd = source["costUsd"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -338,6 +414,20 @@ export namespace orchestrator {
 		    return a;
 		}
 	}
+	export class AttemptTooling {
+	    tool: string;
+	    model: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttemptTooling(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tool = source["tool"];
+	        this.model = source["model"];
+	    }
+	}
 	export class BacklogItem {
 	    id: string;
 	    taskId: string;
@@ -350,6 +440,7 @@ export namespace orchestrator {
 	    // Go type: time
 	    resolvedAt?: any;
 	    resolution?: string;
+	    resolutionAction?: string;
 	    metadata?: Record<string, any>;
 	
 	    static createFrom(source: any = {}) {
@@ -367,6 +458,7 @@ export namespace orchestrator {
 	        this.createdAt = this.convertValues(source["createdAt"], null);
 	        this.resolvedAt = this.convertValues(source["resolvedAt"], null);
 	        this.resolution = source["resolution"];
+	        this.resolutionAction = source["resolutionAction"];
 	        this.metadata = source["metadata"];
 	    }
 	
@@ -388,10 +480,218 @@ export namespace orchestrator {
 		    return a;
 		}
 	}
+	export class BacklogResolution {
+	    action?: string;
+	    note?: string;
+	    description?: string;
+	    acceptanceCriteria?: string[];
+	    toolingProfile?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BacklogResolution(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.action = source["action"];
+	        this.note = source["note"];
+	        this.description = source["description"];
+	        this.acceptanceCriteria = source["acceptanceCriteria"];
+	        this.toolingProfile = source["toolingProfile"];
+	    }
+	}
+	export class BacklogResolutionResult {
+	    item?: BacklogItem;
+	    taskStatus?: string;
+	    createdTaskIds?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BacklogResolutionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item = this.convertValues(source["item"], BacklogItem);
+	        this.taskStatus = source["taskStatus"];
+	        this.createdTaskIds = source["createdTaskIds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CalibrationGroup {
+	    dimension: string;
+	    key: string;
+	    nodes: number;
+	    storyPoints: number;
+	    attempts: number;
+	    workerRuns: number;
+	    wallClockSec: number;
+	    inputTokens: number;
+	    outputTokens: number;
+	    costUsd: number;
+	    avgStoryPoints: number;
+	    avgWallClockSec: number;
+	    avgAttempts: number;
+	    avgWorkerRuns: number;
+	    avgTokens: number;
+	    avgCostUsd: number;
+	    wallClockSecPerPoint: number;
+	    costUsdPerPoint: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalibrationGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dimension = source["dimension"];
+	        this.key = source["key"];
+	        this.nodes = source["nodes"];
+	        this.storyPoints = source["storyPoints"];
+	        this.attempts = source["attempts"];
+	        this.workerRuns = source["workerRuns"];
+	        this.wallClockSec = source["wallClockSec"];
+	        this.inputTokens = source["inputTokens"];
+	        this.outputTokens = source["outputTokens"];
+	        this.costUsd = source["costUsd"];
+	        this.avgStoryPoints = source["avgStoryPoints"];
+	        this.avgWallClockSec = source["avgWallClockSec"];
+	        this.avgAttempts = source["avgAttempts"];
+	        this.avgWorkerRuns = source["avgWorkerRuns"];
+	        this.avgTokens = source["avgTokens"];
+	        this.avgCostUsd = source["avgCostUsd"];
+	        this.wallClockSecPerPoint = source["wallClockSecPerPoint"];
+	        this.costUsdPerPoint = source["costUsdPerPoint"];
+	    }
+	}
+	export class CalibrationReport {
+	    // Go type: time
+	    generatedAt: any;
+	    nodes: number;
+	    byKind: CalibrationGroup[];
+	    byDifficulty: CalibrationGroup[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CalibrationReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.generatedAt = this.convertValues(source["generatedAt"], null);
+	        this.nodes = source["nodes"];
+	        this.byKind = this.convertValues(source["byKind"], CalibrationGroup);
+	        this.byDifficulty = this.convertValues(source["byDifficulty"], CalibrationGroup);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DependencyAnalysis {
+	    order: string[];
+	    cycles: string[][];
+	    unknownRefs: DependencyRef[];
+	    criticalPath: string[];
+	    criticalPathWeight: number;
+	    blockedByFailure: Record<string, Array<string>>;
+	
+	    static createFrom(source: any = {}) {
+	        return new DependencyAnalysis(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.order = source["order"];
+	        this.cycles = source["cycles"];
+	        this.unknownRefs = this.convertValues(source["unknownRefs"], DependencyRef);
+	        this.criticalPath = source["criticalPath"];
+	        this.criticalPathWeight = source["criticalPathWeight"];
+	        this.blockedByFailure = source["blockedByFailure"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DependencyRef {
+	    nodeId: string;
+	    dependsOn: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DependencyRef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodeId = source["nodeId"];
+	        this.dependsOn = source["dependsOn"];
+	    }
+	}
+	export class NodeImpact {
+	    nodeId: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NodeImpact(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodeId = source["nodeId"];
+	        this.reason = source["reason"];
+	    }
+	}
 	export class Pool {
 	    id: string;
 	    name: string;
 	    description?: string;
+	    toolingProfile?: string;
+	    workerImage?: string;
+	    concurrency?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new Pool(source);
@@ -402,6 +702,9 @@ export namespace orchestrator {
 	        this.id = source["id"];
 	        this.name = source["name"];
 	        this.description = source["description"];
+	        this.toolingProfile = source["toolingProfile"];
+	        this.workerImage = source["workerImage"];
+	        this.concurrency = source["concurrency"];
 	    }
 	}
 	export class PoolSummary {
@@ -426,6 +729,76 @@ export namespace orchestrator {
 	        this.counts = source["counts"];
 	    }
 	}
+	export class ProgressGroup {
+	    name: string;
+	    totalPoints: number;
+	    completedPoints: number;
+	    totalTasks: number;
+	    completedTasks: number;
+	    counts: Record<string, number>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProgressGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.totalPoints = source["totalPoints"];
+	        this.completedPoints = source["completedPoints"];
+	        this.totalTasks = source["totalTasks"];
+	        this.completedTasks = source["completedTasks"];
+	        this.counts = source["counts"];
+	    }
+	}
+	export class ProgressReport {
+	    // Go type: time
+	    generatedAt: any;
+	    total: ProgressGroup;
+	    milestones: ProgressGroup[];
+	    phases: ProgressGroup[];
+	    pools: PoolSummary[];
+	    throughput: ThroughputBucket[];
+	    windowDays: number;
+	    pointsPerDay: number;
+	    // Go type: time
+	    projectedCompletion?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProgressReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.generatedAt = this.convertValues(source["generatedAt"], null);
+	        this.total = this.convertValues(source["total"], ProgressGroup);
+	        this.milestones = this.convertValues(source["milestones"], ProgressGroup);
+	        this.phases = this.convertValues(source["phases"], ProgressGroup);
+	        this.pools = this.convertValues(source["pools"], PoolSummary);
+	        this.throughput = this.convertValues(source["throughput"], ThroughputBucket);
+	        this.windowDays = source["windowDays"];
+	        this.pointsPerDay = source["pointsPerDay"];
+	        this.projectedCompletion = this.convertValues(source["projectedCompletion"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class RunnerSpec {
 	    maxLoops?: number;
 	    workerKind?: string;
@@ -456,6 +829,41 @@ export namespace orchestrator {
 	        this.constraints = source["constraints"];
 	    }
 	}
+	export class TaskAnswer {
+	    question: string;
+	    answer: string;
+	    // Go type: time
+	    answeredAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new TaskAnswer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.question = source["question"];
+	        this.answer = source["answer"];
+	        this.answeredAt = this.convertValues(source["answeredAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Task {
 	    id: string;
 	    title: string;
@@ -477,12 +885,17 @@ export namespace orchestrator {
 	    milestone?: string;
 	    sourceChatId?: string;
 	    acceptanceCriteria?: string[];
+	    estimatedEffort?: string;
+	    storyPoints?: number;
 	    attemptCount?: number;
 	    // Go type: time
 	    nextRetryAt?: any;
 	    suggestedImpl?: SuggestedImpl;
 	    artifacts?: Artifacts;
 	    runner?: RunnerSpec;
+	    toolingProfile?: string;
+	    answers?: TaskAnswer[];
+	    waitReason?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Task(source);
@@ -506,11 +919,79 @@ export namespace orchestrator {
 	        this.milestone = source["milestone"];
 	        this.sourceChatId = source["sourceChatId"];
 	        this.acceptanceCriteria = source["acceptanceCriteria"];
+	        this.estimatedEffort = source["estimatedEffort"];
+	        this.storyPoints = source["storyPoints"];
 	        this.attemptCount = source["attemptCount"];
 	        this.nextRetryAt = this.convertValues(source["nextRetryAt"], null);
 	        this.suggestedImpl = this.convertValues(source["suggestedImpl"], SuggestedImpl);
 	        this.artifacts = this.convertValues(source["artifacts"], Artifacts);
 	        this.runner = this.convertValues(source["runner"], RunnerSpec);
+	        this.toolingProfile = source["toolingProfile"];
+	        this.answers = this.convertValues(source["answers"], TaskAnswer);
+	        this.waitReason = source["waitReason"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TaskSchedule {
+	    id: string;
+	    title: string;
+	    kind?: string;
+	    poolId?: string;
+	    cron?: string;
+	    interval?: string;
+	    // Go type: time
+	    runAt?: any;
+	    timezone?: string;
+	    missedPolicy?: string;
+	    enabled: boolean;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	    // Go type: time
+	    nextRunAt?: any;
+	    // Go type: time
+	    lastRunAt?: any;
+	    lastTaskId?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TaskSchedule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.kind = source["kind"];
+	        this.poolId = source["poolId"];
+	        this.cron = source["cron"];
+	        this.interval = source["interval"];
+	        this.runAt = this.convertValues(source["runAt"], null);
+	        this.timezone = source["timezone"];
+	        this.missedPolicy = source["missedPolicy"];
+	        this.enabled = source["enabled"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	        this.nextRunAt = this.convertValues(source["nextRunAt"], null);
+	        this.lastRunAt = this.convertValues(source["lastRunAt"], null);
+	        this.lastTaskId = source["lastTaskId"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -532,5 +1013,290 @@ export namespace orchestrator {
 		}
 	}
 
+	export class ThroughputBucket {
+	    // Go type: time
+	    date: any;
+	    tasks: number;
+	    points: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ThroughputBucket(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = this.convertValues(source["date"], null);
+	        this.tasks = source["tasks"];
+	        this.points = source["points"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 }
 
+
+export namespace persistence {
+	
+	export class AgentState {
+	    agent_id: string;
+	    kind: string;
+	    max_parallel: number;
+	    running_tasks: string[];
+	    capabilities: string[];
+	    health?: string;
+	    health_error?: string;
+	    // Go type: time
+	    health_checked_at?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new AgentState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.agent_id = source["agent_id"];
+	        this.kind = source["kind"];
+	        this.max_parallel = source["max_parallel"];
+	        this.running_tasks = source["running_tasks"];
+	        this.capabilities = source["capabilities"];
+	        this.health = source["health"];
+	        this.health_error = source["health_error"];
+	        this.health_checked_at = this.convertValues(source["health_checked_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AttemptLogLine {
+	    seq: number;
+	    // Go type: time
+	    at: any;
+	    stream: string;
+	    line: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttemptLogLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.at = this.convertValues(source["at"], null);
+	        this.stream = source["stream"];
+	        this.line = source["line"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AttemptLogPage {
+	    attempt_id: string;
+	    lines: AttemptLogLine[];
+	    next: number;
+	    complete: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttemptLogPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.attempt_id = source["attempt_id"];
+	        this.lines = this.convertValues(source["lines"], AttemptLogLine);
+	        this.next = source["next"];
+	        this.complete = source["complete"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class EntityChange {
+	    id: string;
+	    fields: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EntityChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.fields = source["fields"];
+	    }
+	}
+	export class EntityDiff {
+	    added: string[];
+	    removed: string[];
+	    changed: EntityChange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EntityDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = source["added"];
+	        this.removed = source["removed"];
+	        this.changed = this.convertValues(source["changed"], EntityChange);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Snapshot {
+	    id: string;
+	    description: string;
+	    // Go type: time
+	    created_at: any;
+	    auto?: boolean;
+	    sources?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Snapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.description = source["description"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.auto = source["auto"];
+	        this.sources = source["sources"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SnapshotDiff {
+	    from: string;
+	    to: string;
+	    tasks: EntityDiff;
+	    nodes: EntityDiff;
+	    nodes_runtime: EntityDiff;
+	    backlog: EntityDiff;
+	
+	    static createFrom(source: any = {}) {
+	        return new SnapshotDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.tasks = this.convertValues(source["tasks"], EntityDiff);
+	        this.nodes = this.convertValues(source["nodes"], EntityDiff);
+	        this.nodes_runtime = this.convertValues(source["nodes_runtime"], EntityDiff);
+	        this.backlog = this.convertValues(source["backlog"], EntityDiff);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
@@ -79,3 +79,17 @@ func ResolveOpenAIModelID(model string) string {
 	}
 	return model
 }
+
+// EstimateCostUSD は既知モデルの価格からトークン使用量のコストを見積もる。
+// 価格が未確認のモデル（PricingUSDPerMTok が nil）や未知のモデルは false を返す。
+func EstimateCostUSD(model string, inputTokens, outputTokens int) (float64, bool) {
+	id := ResolveOpenAIModelID(model)
+	for _, m := range KnownOpenAIModels {
+		if m.ID != id || m.PricingUSDPerMTok == nil {
+			continue
+		}
+		cost := float64(inputTokens)*m.PricingUSDPerMTok.Input + float64(outputTokens)*m.PricingUSDPerMTok.Output
+		return cost / 1_000_000, true
+	}
+	return 0, false
+}
@@ -26,3 +26,22 @@ func TestResolveOpenAIModelID_ResolvesAlias(t *testing.T) {
 		t.Fatalf("ResolveOpenAIModelID() = %q, want %q", got, "gpt-5.1-codex-mini")
 	}
 }
+
+func TestEstimateCostUSD(t *testing.T) {
+	saved := KnownOpenAIModels
+	t.Cleanup(func() { KnownOpenAIModels = saved })
+	KnownOpenAIModels = []OpenAIModelInfo{
+		{ID: "priced", Aliases: []string{"p"}, PricingUSDPerMTok: &ModelPricingUSDPerMTok{Input: 2, Output: 10}},
+		{ID: "unpriced"},
+	}
+
+	if got, ok := EstimateCostUSD("p", 500_000, 100_000); !ok || got != 2.0 {
+		t.Fatalf("EstimateCostUSD(alias) = %v, %v, want 2, true", got, ok)
+	}
+	if _, ok := EstimateCostUSD("unpriced", 1000, 1000); ok {
+		t.Fatal("EstimateCostUSD() should not estimate a model without pricing")
+	}
+	if _, ok := EstimateCostUSD("unknown", 1000, 1000); ok {
+		t.Fatal("EstimateCostUSD() should not estimate an unknown model")
+	}
+}
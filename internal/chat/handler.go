@@ -38,7 +38,6 @@ type ChatResponse struct {
 type Handler struct {
 	Meta         MetaClient
 	Repo         persistence.WorkspaceRepository
-	TaskStore    *orchestrator.TaskStore
 	SessionStore *ChatSessionStore
 	WorkspaceID  string
 	ProjectRoot  string
@@ -50,7 +49,6 @@ type Handler struct {
 // NewHandler は新しい ChatHandler を作成する
 func NewHandler(
 	metaClient MetaClient,
-	taskStore *orchestrator.TaskStore,
 	sessionStore *ChatSessionStore,
 	workspaceID string,
 	projectRoot string,
@@ -60,7 +58,6 @@ func NewHandler(
 	return &Handler{
 		Meta:         metaClient,
 		Repo:         repo,
-		TaskStore:    taskStore,
 		SessionStore: sessionStore,
 		WorkspaceID:  workspaceID,
 		ProjectRoot:  projectRoot,
@@ -120,31 +117,19 @@ func (h *Handler) HandleMessage(ctx context.Context, sessionID, message string)
 		return nil, fmt.Errorf("failed to save user message: %w", err)
 	}
 
-	existingTasks, err := h.TaskStore.ListAllTasks()
-	if err != nil {
-		emitFailed(fmt.Sprintf("既存タスクの取得に失敗しました: %v", err))
-		return nil, fmt.Errorf("failed to list existing tasks: %w", err)
-	}
+	// design/state を真実源として扱い、state/tasks.json に存在するタスクを既存タスクとして Meta へ渡す
+	existingTasks := []orchestrator.Task{}
 	if h.Repo != nil {
-		// design/state を真実源として扱い、state/tasks.json に存在するタスクのみを
-		// 既存タスクとして Meta へ渡す（削除済みタスクの再登場を防ぐ）。
-		if err := h.Repo.Init(); err == nil {
-			if tasksState, err := h.Repo.State().LoadTasks(); err == nil && tasksState != nil {
-				activeIDs := make(map[string]struct{}, len(tasksState.Tasks))
-				for _, ts := range tasksState.Tasks {
-					if ts.TaskID != "" {
-						activeIDs[ts.TaskID] = struct{}{}
-					}
-				}
-				filtered := make([]orchestrator.Task, 0, len(existingTasks))
-				for _, t := range existingTasks {
-					if _, ok := activeIDs[t.ID]; ok {
-						filtered = append(filtered, t)
-					}
-				}
-				existingTasks = filtered
-			}
+		if err := h.Repo.Init(); err != nil {
+			emitFailed(fmt.Sprintf("ワークスペースの初期化に失敗しました: %v", err))
+			return nil, fmt.Errorf("failed to init workspace repo: %w", err)
+		}
+		tasks, err := orchestrator.ListTaskViews(h.Repo)
+		if err != nil {
+			emitFailed(fmt.Sprintf("既存タスクの取得に失敗しました: %v", err))
+			return nil, fmt.Errorf("failed to list existing tasks: %w", err)
 		}
+		existingTasks = tasks
 	}
 	existingTaskIDs := make(map[string]struct{}, len(existingTasks))
 	existingTasksByID := make(map[string]orchestrator.Task, len(existingTasks))
@@ -267,6 +252,7 @@ func (h *Handler) BuildDecomposeRequest(sessionID, message string, existingTasks
 			WorkspacePath:       h.ProjectRoot,
 			ExistingTasks:       taskSummaries,
 			ConversationHistory: conversationHistory,
+			Calibration:         h.estimateCalibration(),
 		},
 	}
 }
@@ -329,6 +315,8 @@ func (h *Handler) PersistTasks(
 				Milestone:          phase.Milestone,
 				SourceChatID:       &sessionID,
 				AcceptanceCriteria: decomposedTask.AcceptanceCriteria,
+				EstimatedEffort:    strings.TrimSpace(decomposedTask.EstimatedEffort),
+				StoryPoints:        decomposedTask.StoryPoints,
 				Runner: &orchestrator.RunnerSpec{
 					MaxLoops:   orchestrator.DefaultRunnerMaxLoops,
 					WorkerKind: orchestrator.DefaultWorkerKind,
@@ -363,20 +351,14 @@ func (h *Handler) PersistTasks(
 		return nil, fmt.Errorf("unresolved dependencies: %s", strings.Join(unique, ", "))
 	}
 
+	h.snapshotBeforeBulkChange(ctx, fmt.Sprintf("before decompose (%d tasks)", len(tasksToSave)))
+
 	if err := h.persistDesignAndState(ctx, sessionID, tasksToSave, existingTasksByID); err != nil {
 		logger.Error("failed to persist design/state", slog.Any("error", err))
 		return nil, fmt.Errorf("failed to persist design/state: %w", err)
 	}
 
 	for _, task := range tasksToSave {
-		if err := h.TaskStore.SaveTask(&task); err != nil {
-			logger.Error("failed to save task",
-				slog.String("task_id", task.ID),
-				slog.Any("error", err),
-			)
-			return nil, fmt.Errorf("failed to save task %s: %w", task.ID, err)
-		}
-
 		allTasks = append(allTasks, task)
 		logger.Debug("task created",
 			slog.String("task_id", task.ID),
@@ -395,6 +377,21 @@ func (h *Handler) PersistTasks(
 	return allTasks, nil
 }
 
+// snapshotBeforeBulkChange は一括変更の前に自動スナップショットを取得する（Repo が nil の場合はスキップ）。
+// 取得に失敗しても変更自体は続行する。
+func (h *Handler) snapshotBeforeBulkChange(ctx context.Context, reason string) {
+	if h.Repo == nil || h.Repo.Snapshot() == nil {
+		return
+	}
+	logger := logging.WithTraceID(h.logger, ctx)
+	snap, err := h.Repo.Snapshot().CreateAutoSnapshot(reason)
+	if err != nil {
+		logger.Warn("failed to create auto snapshot", slog.String("reason", reason), slog.Any("error", err))
+		return
+	}
+	logger.Debug("auto snapshot created", slog.String("snapshot_id", snap.ID), slog.String("reason", reason))
+}
+
 // persistDesignAndState は decompose されたタスクを design/state に反映する（Repo が nil の場合はスキップ）。
 func (h *Handler) persistDesignAndState(
 	ctx context.Context,
@@ -413,65 +410,74 @@ func (h *Handler) persistDesignAndState(
 
 	now := time.Now()
 
-	// Load or create WBS root
-	wbs, err := h.Repo.Design().LoadWBS()
-	if err != nil {
-		if os.IsNotExist(err) {
-			wbs = &persistence.WBS{
-				WBSID:       uuid.New().String(),
-				ProjectRoot: h.ProjectRoot,
-				CreatedAt:   now,
-				UpdatedAt:   now,
-				RootNodeID:  "node-root",
-				NodeIndex:   []persistence.NodeIndex{},
+	contains := func(xs []string, x string) bool {
+		for _, v := range xs {
+			if v == x {
+				return true
 			}
-		} else {
-			return fmt.Errorf("failed to load wbs: %w", err)
 		}
+		return false
 	}
 
-	if wbs.WBSID == "" {
-		wbs.WBSID = uuid.New().String()
-		wbs.CreatedAt = now
-	}
-	if wbs.ProjectRoot == "" {
-		wbs.ProjectRoot = h.ProjectRoot
-	}
-	if wbs.RootNodeID == "" {
-		wbs.RootNodeID = "node-root"
-	}
-	wbs.UpdatedAt = now
+	// Load or create WBS root and update WBS index
+	var wbsID string
+	err := h.Repo.Design().UpdateWBS(func(wbs *persistence.WBS) error {
+		if wbs.WBSID == "" {
+			wbs.WBSID = uuid.New().String()
+			wbs.CreatedAt = now
+		}
+		if wbs.ProjectRoot == "" {
+			wbs.ProjectRoot = h.ProjectRoot
+		}
+		if wbs.RootNodeID == "" {
+			wbs.RootNodeID = "node-root"
+		}
+		wbs.UpdatedAt = now
 
-	// Index lookup (store positions to avoid slice pointer invalidation).
-	indexPosByID := make(map[string]int)
-	for i := range wbs.NodeIndex {
-		indexPosByID[wbs.NodeIndex[i].NodeID] = i
-	}
-	rootPos, ok := indexPosByID[wbs.RootNodeID]
-	if !ok {
-		rootID := wbs.RootNodeID
-		wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{
-			NodeID:   rootID,
-			ParentID: nil,
-			Children: []string{},
-		})
-		rootPos = len(wbs.NodeIndex) - 1
-		indexPosByID[rootID] = rootPos
-	}
+		// Index lookup (store positions to avoid slice pointer invalidation).
+		indexPosByID := make(map[string]int)
+		for i := range wbs.NodeIndex {
+			indexPosByID[wbs.NodeIndex[i].NodeID] = i
+		}
+		rootPos, ok := indexPosByID[wbs.RootNodeID]
+		if !ok {
+			rootID := wbs.RootNodeID
+			wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{
+				NodeID:   rootID,
+				ParentID: nil,
+				Children: []string{},
+			})
+			rootPos = len(wbs.NodeIndex) - 1
+			indexPosByID[rootID] = rootPos
+		}
 
-	contains := func(xs []string, x string) bool {
-		for _, v := range xs {
-			if v == x {
-				return true
+		for _, t := range tasks {
+			if _, exists := indexPosByID[t.ID]; exists {
+				continue
+			}
+			parentID := wbs.RootNodeID
+			wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{
+				NodeID:   t.ID,
+				ParentID: &parentID,
+				Children: []string{},
+			})
+			indexPosByID[t.ID] = len(wbs.NodeIndex) - 1
+			if !contains(wbs.NodeIndex[rootPos].Children, t.ID) {
+				wbs.NodeIndex[rootPos].Children = append(wbs.NodeIndex[rootPos].Children, t.ID)
 			}
 		}
-		return false
+
+		wbsID = wbs.WBSID
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save wbs: %w", err)
 	}
 
 	// Track new nodes for dependency handling
 	newNodeIDs := make(map[string]struct{}, len(tasks))
 
-	// Save NodeDesign for each task and update WBS index
+	// Save NodeDesign for each task
 	for _, t := range tasks {
 		newNodeIDs[t.ID] = struct{}{}
 
@@ -488,7 +494,7 @@ func (h *Handler) persistDesignAndState(
 
 		node := &persistence.NodeDesign{
 			NodeID:             t.ID,
-			WBSID:              wbs.WBSID,
+			WBSID:              wbsID,
 			Name:               t.Title,
 			Summary:            t.Description,
 			PhaseName:          t.PhaseName,
@@ -496,7 +502,7 @@ func (h *Handler) persistDesignAndState(
 			WBSLevel:           t.WBSLevel,
 			Kind:               "feature",
 			Priority:           "medium",
-			Estimate:           persistence.Estimate{},
+			Estimate:           persistence.Estimate{StoryPoints: t.StoryPoints, Difficulty: t.EstimatedEffort},
 			Dependencies:       t.Dependencies,
 			AcceptanceCriteria: t.AcceptanceCriteria,
 			DesignNotes:        []string{},
@@ -506,88 +512,58 @@ func (h *Handler) persistDesignAndState(
 			CreatedBy:          "agent:planner",
 		}
 
-		if err := h.Repo.Design().SaveNode(node); err != nil {
+		if err := saveNodeDesign(h.Repo.Design(), node); err != nil {
 			return fmt.Errorf("failed to save node %s: %w", node.NodeID, err)
 		}
-
-		if _, exists := indexPosByID[t.ID]; !exists {
-			parentID := wbs.RootNodeID
-			wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{
-				NodeID:   t.ID,
-				ParentID: &parentID,
-				Children: []string{},
-			})
-			pos := len(wbs.NodeIndex) - 1
-			indexPosByID[t.ID] = pos
-			if !contains(wbs.NodeIndex[rootPos].Children, t.ID) {
-				wbs.NodeIndex[rootPos].Children = append(wbs.NodeIndex[rootPos].Children, t.ID)
-			}
-		}
-	}
-
-	if err := h.Repo.Design().SaveWBS(wbs); err != nil {
-		return fmt.Errorf("failed to save wbs: %w", err)
-	}
-
-	// Load state
-	nodesRuntime, err := h.Repo.State().LoadNodesRuntime()
-	if err != nil {
-		return fmt.Errorf("failed to load nodes runtime: %w", err)
-	}
-	tasksState, err := h.Repo.State().LoadTasks()
-	if err != nil {
-		return fmt.Errorf("failed to load tasks state: %w", err)
-	}
-
-	runtimeByID := make(map[string]*persistence.NodeRuntime)
-	for i := range nodesRuntime.Nodes {
-		runtimeByID[nodesRuntime.Nodes[i].NodeID] = &nodesRuntime.Nodes[i]
 	}
 
-	taskByID := make(map[string]struct{})
-	for _, ts := range tasksState.Tasks {
-		taskByID[ts.TaskID] = struct{}{}
-	}
+	// Ensure runtime entries for existing dependency nodes to avoid permanent blocking,
+	// then upsert runtime entries for new nodes.
+	err = h.Repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		runtimeByID := make(map[string]struct{}, len(nodesRuntime.Nodes))
+		for i := range nodesRuntime.Nodes {
+			runtimeByID[nodesRuntime.Nodes[i].NodeID] = struct{}{}
+		}
 
-	// Ensure runtime entries for existing dependency nodes to avoid permanent blocking.
-	for _, t := range tasks {
-		for _, depID := range t.Dependencies {
-			if _, isNew := newNodeIDs[depID]; isNew {
-				continue
-			}
-			if _, exists := runtimeByID[depID]; exists {
-				continue
-			}
-			existing, ok := existingTasksByID[depID]
-			if !ok {
-				continue
-			}
-			status := "planned"
-			if existing.Status == orchestrator.TaskStatusSucceeded || existing.Status == orchestrator.TaskStatusCompleted {
-				status = "implemented"
+		for _, t := range tasks {
+			for _, depID := range t.Dependencies {
+				if _, isNew := newNodeIDs[depID]; isNew {
+					continue
+				}
+				if _, exists := runtimeByID[depID]; exists {
+					continue
+				}
+				existing, ok := existingTasksByID[depID]
+				if !ok {
+					continue
+				}
+				status := "planned"
+				if existing.Status == orchestrator.TaskStatusSucceeded || existing.Status == orchestrator.TaskStatusCompleted {
+					status = "implemented"
+				}
+				nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
+					NodeID: depID,
+					Status: status,
+					Implementation: persistence.NodeImplementation{
+						Files:          []string{},
+						LastModifiedAt: now,
+						LastModifiedBy: "chat-handler",
+					},
+					Verification: persistence.NodeVerification{
+						Status: "not_tested",
+					},
+					Notes: []persistence.NodeNote{
+						{At: now, By: "chat-handler", Text: "imported from existing task"},
+					},
+				})
+				runtimeByID[depID] = struct{}{}
 			}
-			nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
-				NodeID: depID,
-				Status: status,
-				Implementation: persistence.NodeImplementation{
-					Files:          []string{},
-					LastModifiedAt: now,
-					LastModifiedBy: "chat-handler",
-				},
-				Verification: persistence.NodeVerification{
-					Status: "not_tested",
-				},
-				Notes: []persistence.NodeNote{
-					{At: now, By: "chat-handler", Text: "imported from existing task"},
-				},
-			})
-			runtimeByID[depID] = &nodesRuntime.Nodes[len(nodesRuntime.Nodes)-1]
 		}
-	}
 
-	// Upsert runtime/tasks for new nodes.
-	for _, t := range tasks {
-		if _, exists := runtimeByID[t.ID]; !exists {
+		for _, t := range tasks {
+			if _, exists := runtimeByID[t.ID]; exists {
+				continue
+			}
 			nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
 				NodeID: t.ID,
 				Status: "planned",
@@ -603,10 +579,25 @@ func (h *Handler) persistDesignAndState(
 					{At: now, By: "chat-handler", Text: fmt.Sprintf("created from chat session %s", sessionID)},
 				},
 			})
-			runtimeByID[t.ID] = &nodesRuntime.Nodes[len(nodesRuntime.Nodes)-1]
+			runtimeByID[t.ID] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save nodes runtime: %w", err)
+	}
+
+	// Upsert tasks for new nodes.
+	err = h.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		taskByID := make(map[string]struct{}, len(tasksState.Tasks))
+		for _, ts := range tasksState.Tasks {
+			taskByID[ts.TaskID] = struct{}{}
 		}
 
-		if _, exists := taskByID[t.ID]; !exists {
+		for _, t := range tasks {
+			if _, exists := taskByID[t.ID]; exists {
+				continue
+			}
 			tasksState.Tasks = append(tasksState.Tasks, persistence.TaskState{
 				TaskID:        t.ID,
 				NodeID:        t.ID,
@@ -621,17 +612,16 @@ func (h *Handler) persistDesignAndState(
 					orchestrator.InputKeyAttemptCount:     0,
 					orchestrator.InputKeyRunnerMaxLoops:   orchestrator.DefaultRunnerMaxLoops,
 					orchestrator.InputKeyRunnerWorkerKind: orchestrator.DefaultWorkerKind,
+					orchestrator.InputKeyPoolID:           poolIDOrDefault(t.PoolID),
+					orchestrator.InputKeySourceChatID:     sessionID,
 				},
 				Outputs: persistence.TaskOutputs{},
 			})
 			taskByID[t.ID] = struct{}{}
 		}
-	}
-
-	if err := h.Repo.State().SaveNodesRuntime(nodesRuntime); err != nil {
-		return fmt.Errorf("failed to save nodes runtime: %w", err)
-	}
-	if err := h.Repo.State().SaveTasks(tasksState); err != nil {
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to save tasks state: %w", err)
 	}
 
@@ -642,6 +632,28 @@ func (h *Handler) persistDesignAndState(
 	return nil
 }
 
+func poolIDOrDefault(poolID string) string {
+	if poolID == "" {
+		return orchestrator.DefaultPoolID
+	}
+	return poolID
+}
+
+// saveNodeDesign はノード設計を上書き保存する
+// 既存ノードは版を引き継いで UpdateNode で置き換え、存在しない場合は新規作成する。
+func saveNodeDesign(repo persistence.DesignRepository, node *persistence.NodeDesign) error {
+	err := repo.UpdateNode(node.NodeID, func(current *persistence.NodeDesign) error {
+		version := current.Version
+		*current = *node
+		current.Version = version
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return repo.SaveNode(node)
+	}
+	return err
+}
+
 // buildResponseContent はアシスタント応答メッセージを構築する
 func (h *Handler) buildResponseContent(resp *meta.DecomposeResponse, tasks []orchestrator.Task) string {
 	var content string
@@ -8,17 +8,17 @@ import (
 	"github.com/biwakonbu/agent-runner/internal/chat"
 	"github.com/biwakonbu/agent-runner/internal/meta"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
 )
 
 func TestHandleMessage_Mock(t *testing.T) {
 	// Setup
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := chat.NewChatSessionStore(tmpDir)
 	mockMeta := meta.NewMockClient()
 
 	// Create Handler
-	handler := chat.NewHandler(mockMeta, taskStore, sessionStore, "test-ws", tmpDir, nil, nil)
+	handler := chat.NewHandler(mockMeta, sessionStore, "test-ws", tmpDir, nil, nil)
 
 	// Create Session
 	ctx := context.Background()
@@ -65,10 +65,9 @@ func TestHandleMessage_Mock(t *testing.T) {
 
 func TestHandleMessage_EmitsFailedEventOnMetaError(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := chat.NewChatSessionStore(tmpDir)
 	recorder := &recordingEmitter{}
-	handler := chat.NewHandler(failingMetaClient{}, taskStore, sessionStore, "ws", tmpDir, nil, recorder)
+	handler := chat.NewHandler(failingMetaClient{}, sessionStore, "ws", tmpDir, nil, recorder)
 
 	ctx := context.Background()
 	session, err := handler.CreateSession(ctx)
@@ -91,7 +90,7 @@ func TestHandleMessage_EmitsFailedEventOnMetaError(t *testing.T) {
 
 func TestHandleMessage_FailsOnUnknownDependency(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
+	repo := persistence.NewWorkspaceRepository(tmpDir)
 	sessionStore := chat.NewChatSessionStore(tmpDir)
 	recorder := &recordingEmitter{}
 
@@ -121,7 +120,7 @@ func TestHandleMessage_FailsOnUnknownDependency(t *testing.T) {
 		},
 	}
 
-	handler := chat.NewHandler(staticMetaClient{resp: patch}, taskStore, sessionStore, "ws", tmpDir, nil, recorder)
+	handler := chat.NewHandler(staticMetaClient{resp: patch}, sessionStore, "ws", tmpDir, repo, recorder)
 
 	ctx := context.Background()
 	session, err := handler.CreateSession(ctx)
@@ -133,9 +132,9 @@ func TestHandleMessage_FailsOnUnknownDependency(t *testing.T) {
 		t.Fatalf("expected error but got nil")
 	}
 
-	tasks, err := taskStore.ListAllTasks()
+	tasks, err := orchestrator.ListTaskViews(repo)
 	if err != nil {
-		t.Fatalf("ListAllTasks failed: %v", err)
+		t.Fatalf("ListTaskViews failed: %v", err)
 	}
 	if len(tasks) != 0 {
 		t.Fatalf("expected no tasks to be persisted on failure, got %d", len(tasks))
@@ -35,18 +35,14 @@ func (m *MockMetaClient) PlanPatch(ctx context.Context, req *meta.PlanPatchReque
 
 func TestHandler_NewHandler(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 	mockMeta := &MockMetaClient{}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", nil, nil)
 
 	if handler.Meta != mockMeta {
 		t.Error("Meta client not set correctly")
 	}
-	if handler.TaskStore != taskStore {
-		t.Error("TaskStore not set correctly")
-	}
 	if handler.SessionStore != sessionStore {
 		t.Error("SessionStore not set correctly")
 	}
@@ -60,11 +56,10 @@ func TestHandler_NewHandler(t *testing.T) {
 
 func TestHandler_CreateSession(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 	mockMeta := &MockMetaClient{}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", nil, nil)
 
 	ctx := context.Background()
 	session, err := handler.CreateSession(ctx)
@@ -96,7 +91,6 @@ func TestHandler_CreateSession(t *testing.T) {
 
 func TestHandler_HandleMessage_Success(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 
 	mockMeta := &MockMetaClient{
@@ -144,7 +138,7 @@ func TestHandler_HandleMessage_Success(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", nil, nil)
 
 	ctx := context.Background()
 
@@ -217,7 +211,6 @@ func TestHandler_HandleMessage_PersistsDesignAndState(t *testing.T) {
 		t.Fatalf("failed to create project root: %v", err)
 	}
 
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 	repo := persistence.NewWorkspaceRepository(tmpDir)
 	if err := repo.Init(); err != nil {
@@ -266,7 +259,7 @@ func TestHandler_HandleMessage_PersistsDesignAndState(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", projectRoot, repo, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", projectRoot, repo, nil)
 	ctx := context.Background()
 	session, err := handler.CreateSession(ctx)
 	if err != nil {
@@ -350,7 +343,6 @@ func TestHandler_HandleMessage_PersistsDesignAndState(t *testing.T) {
 
 func TestHandler_HandleMessage_MetaError(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 
 	mockMeta := &MockMetaClient{
@@ -359,7 +351,7 @@ func TestHandler_HandleMessage_MetaError(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", nil, nil)
 
 	ctx := context.Background()
 
@@ -388,20 +380,24 @@ func TestHandler_HandleMessage_MetaError(t *testing.T) {
 
 func TestHandler_HandleMessage_WithExistingTasks(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 
 	// 既存タスクを作成
-	existingTask := &orchestrator.Task{
-		ID:        "existing-task-1",
-		Title:     "既存タスク",
-		Status:    orchestrator.TaskStatusPending,
-		PoolID:    "default",
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	err := repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{{
+		TaskID:    "existing-task-1",
+		NodeID:    "existing-task-1",
+		Kind:      "implementation",
+		Status:    string(orchestrator.TaskStatusPending),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-	}
-	if err := taskStore.SaveTask(existingTask); err != nil {
-		t.Fatalf("SaveTask failed: %v", err)
+		Inputs:    map[string]interface{}{orchestrator.InputKeyTitle: "既存タスク"},
+	}}})
+	if err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
 	}
 
 	var capturedReq *meta.PlanPatchRequest
@@ -415,7 +411,7 @@ func TestHandler_HandleMessage_WithExistingTasks(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", repo, nil)
 
 	ctx := context.Background()
 
@@ -445,7 +441,6 @@ func TestHandler_HandleMessage_WithExistingTasks(t *testing.T) {
 
 func TestHandler_HandleMessage_WithConversationHistory(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 
 	var capturedReq *meta.PlanPatchRequest
@@ -461,7 +456,7 @@ func TestHandler_HandleMessage_WithConversationHistory(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", nil, nil)
 
 	ctx := context.Background()
 
@@ -498,7 +493,6 @@ func TestHandler_HandleMessage_WithConversationHistory(t *testing.T) {
 
 func TestHandler_HandleMessage_PotentialConflicts(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 	projectRoot := filepath.Join(tmpDir, "project")
 	if err := os.MkdirAll(filepath.Join(projectRoot, "src", "auth"), 0o755); err != nil {
@@ -524,7 +518,7 @@ func TestHandler_HandleMessage_PotentialConflicts(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", projectRoot, nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", projectRoot, nil, nil)
 
 	ctx := context.Background()
 
@@ -551,7 +545,6 @@ func TestHandler_HandleMessage_PotentialConflicts(t *testing.T) {
 
 func TestHandler_GetHistory(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 	mockMeta := &MockMetaClient{
 		PlanPatchFunc: func(ctx context.Context, req *meta.PlanPatchRequest) (*meta.PlanPatchResponse, error) {
@@ -562,7 +555,7 @@ func TestHandler_GetHistory(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", nil, nil)
 
 	ctx := context.Background()
 
@@ -647,7 +640,6 @@ func containsStringHelper(s, substr string) bool {
 
 func TestHandler_HandleMessage_WithSuggestedImpl(t *testing.T) {
 	tmpDir := t.TempDir()
-	taskStore := orchestrator.NewTaskStore(tmpDir)
 	sessionStore := NewChatSessionStore(tmpDir)
 
 	mockMeta := &MockMetaClient{
@@ -679,7 +671,7 @@ func TestHandler_HandleMessage_WithSuggestedImpl(t *testing.T) {
 		},
 	}
 
-	handler := NewHandler(mockMeta, taskStore, sessionStore, "workspace-1", "/project", nil, nil)
+	handler := NewHandler(mockMeta, sessionStore, "workspace-1", "/project", nil, nil)
 	ctx := context.Background()
 	session, _ := handler.CreateSession(ctx)
 
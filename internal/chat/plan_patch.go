@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -37,10 +38,12 @@ func (h *Handler) buildPlanPatchRequest(sessionID, message string, existingTasks
 		}
 	}
 
-	// 会話履歴を取得（最新10件）
-	recentMessages, err := h.SessionStore.GetRecentMessages(sessionID, 10)
-	if err != nil {
-		recentMessages = []ChatMessage{}
+	// 会話履歴を取得（最新10件、セッション外の呼び出しでは空）
+	recentMessages := []ChatMessage{}
+	if h.SessionStore != nil && sessionID != "" {
+		if msgs, err := h.SessionStore.GetRecentMessages(sessionID, 10); err == nil {
+			recentMessages = msgs
+		}
 	}
 
 	conversationHistory := make([]meta.ConversationMessage, len(recentMessages))
@@ -76,10 +79,43 @@ func (h *Handler) buildPlanPatchRequest(sessionID, message string, existingTasks
 			ExistingTasks:       taskSummaries,
 			ExistingWBS:         wbsOverview,
 			ConversationHistory: conversationHistory,
+			Calibration:         h.estimateCalibration(),
 		},
 	}
 }
 
+// estimateCalibration は完了したノードの見積もりと実績の比較を planner のコンテキスト用に変換する
+// 見積もりの精度を上げるための補助情報なので、読み込めない場合は渡さない。
+func (h *Handler) estimateCalibration() []meta.EstimateCalibration {
+	if h.Repo == nil {
+		return nil
+	}
+	report, err := orchestrator.BuildCalibrationReport(h.Repo, time.Now())
+	if err != nil {
+		h.logger.Warn("failed to build estimate calibration", slog.Any("error", err))
+		return nil
+	}
+	groups := append(append([]orchestrator.CalibrationGroup{}, report.ByKind...), report.ByDifficulty...)
+	if len(groups) == 0 {
+		return nil
+	}
+	rows := make([]meta.EstimateCalibration, len(groups))
+	for i, g := range groups {
+		rows[i] = meta.EstimateCalibration{
+			Dimension:       g.Dimension,
+			Key:             g.Key,
+			Nodes:           g.Nodes,
+			AvgStoryPoints:  g.AvgStoryPoints,
+			AvgWallClockMin: g.AvgWallClockSec / 60,
+			AvgAttempts:     g.AvgAttempts,
+			AvgWorkerRuns:   g.AvgWorkerRuns,
+			AvgTokens:       g.AvgTokens,
+			AvgCostUSD:      g.AvgCostUSD,
+		}
+	}
+	return rows
+}
+
 func (h *Handler) applyPlanPatch(
 	ctx context.Context,
 	sessionID string,
@@ -184,6 +220,12 @@ func (h *Handler) applyPlanPatch(
 		if op.Milestone != nil {
 			task.Milestone = strings.TrimSpace(*op.Milestone)
 		}
+		if op.EstimatedEffort != nil {
+			task.EstimatedEffort = strings.TrimSpace(*op.EstimatedEffort)
+		}
+		if op.StoryPoints != nil {
+			task.StoryPoints = *op.StoryPoints
+		}
 
 		if op.SuggestedImpl != nil {
 			validatedPaths := h.validateFilePaths(op.SuggestedImpl.FilePaths)
@@ -211,19 +253,22 @@ func (h *Handler) applyPlanPatch(
 		}
 	}
 
-	// 3) Persist created tasks into design/state and TaskStore.
+	// 循環する依存を持ち込むパッチは永続化する前に拒否する（循環内のタスクは BLOCKED のまま実行されない）
+	if err := checkPatchDependencyCycles(resp, tempToReal, existingTasksByID, createdByID); err != nil {
+		return nil, err
+	}
+
+	h.snapshotBeforeBulkChange(ctx, fmt.Sprintf("before plan_patch (%d operations)", len(resp.Operations)))
+
+	// 3) Persist created tasks into design/state.
 	if len(tasksToCreate) > 0 {
 		if err := h.persistDesignAndState(ctx, sessionID, tasksToCreate, existingTasksByID); err != nil {
 			logger.Error("failed to persist design/state for created tasks", slog.Any("error", err))
 			return nil, fmt.Errorf("failed to persist design/state: %w", err)
 		}
 
-		for i := range tasksToCreate {
-			task := tasksToCreate[i]
-			if err := h.TaskStore.SaveTask(&task); err != nil {
-				return nil, fmt.Errorf("failed to save task %s: %w", task.ID, err)
-			}
-			if h.events != nil {
+		if h.events != nil {
+			for _, task := range tasksToCreate {
 				h.events.Emit(orchestrator.EventTaskCreated, orchestrator.TaskCreatedEvent{Task: task})
 			}
 		}
@@ -234,7 +279,7 @@ func (h *Handler) applyPlanPatch(
 	}
 
 	if h.Repo == nil {
-		// Repo が無い場合は TaskStore のみ更新して終了。
+		// Repo が無い場合は永続化せずに終了。
 		return result, nil
 	}
 
@@ -260,17 +305,25 @@ func (h *Handler) applyPlanPatch(
 		}
 	}
 
-	nodesRuntime, err := h.Repo.State().LoadNodesRuntime()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load nodes runtime: %w", err)
-	}
 	tasksState, err := h.Repo.State().LoadTasks()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load tasks state: %w", err)
 	}
 
 	// Track deletions to clean up dependencies later.
+	// state への反映は保存時に UpdateNodesRuntime/UpdateTasks で最新の状態に対して行う。
 	deleted := make(map[string]struct{})
+	var obsoleteIDs []string
+
+	// WBS への変更は読み込んだコピーで検証しながら記録し、保存時に UpdateWBS で最新の WBS に適用し直す
+	var wbsEdits []func(*persistence.WBS) error
+	editWBS := func(edit func(*persistence.WBS) error) error {
+		if err := edit(wbs); err != nil {
+			return err
+		}
+		wbsEdits = append(wbsEdits, edit)
+		return nil
+	}
 
 	// Apply update/move/delete in order (create already handled).
 	for _, op := range resp.Operations {
@@ -300,7 +353,10 @@ func (h *Handler) applyPlanPatch(
 			if _, ok := existingTaskIDs[taskID]; !ok {
 				return nil, fmt.Errorf("unknown task_id in plan_patch move: %s", taskID)
 			}
-			if err := moveNodeInWBS(wbs, taskID, op, tempToReal); err != nil {
+			moveOp := op
+			if err := editWBS(func(w *persistence.WBS) error {
+				return moveNodeInWBS(w, taskID, moveOp, tempToReal)
+			}); err != nil {
 				return nil, err
 			}
 			result.MovedTaskIDs = append(result.MovedTaskIDs, taskID)
@@ -350,10 +406,14 @@ func (h *Handler) applyPlanPatch(
 				result.DeletedTaskIDs = append(result.DeletedTaskIDs, id)
 				removeTaskState(tasksState, id)
 				if wasActive {
-					markNodeObsolete(nodesRuntime, id, now)
+					obsoleteIDs = append(obsoleteIDs, id)
 				}
 				// QH-003: Pass cascade flag for proper child reparenting
-				removeNodeFromWBS(wbs, id, op.Cascade)
+				deleteID, cascade := id, op.Cascade
+				_ = editWBS(func(w *persistence.WBS) error {
+					removeNodeFromWBS(w, deleteID, cascade)
+					return nil
+				})
 			}
 		default:
 			return nil, fmt.Errorf("unknown plan_patch op: %s", op.Op)
@@ -365,7 +425,10 @@ func (h *Handler) applyPlanPatch(
 		if _, ok := deleted[id]; ok {
 			continue
 		}
-		if err := moveNodeInWBS(wbs, id, op, tempToReal); err != nil {
+		placeID, placeOp := id, op
+		if err := editWBS(func(w *persistence.WBS) error {
+			return moveNodeInWBS(w, placeID, placeOp, tempToReal)
+		}); err != nil {
 			return nil, err
 		}
 	}
@@ -382,7 +445,7 @@ func (h *Handler) applyPlanPatch(
 	historyAction := &persistence.Action{
 		ID:          uuid.New().String(),
 		At:          now,
-		Kind:        "plan_patch",
+		Kind:        persistence.ActionPlanPatch,
 		WorkspaceID: h.WorkspaceID,
 		Payload: map[string]interface{}{
 			"session_id":         sessionID,
@@ -401,7 +464,7 @@ func (h *Handler) applyPlanPatch(
 			failAction := &persistence.Action{
 				ID:          uuid.New().String(),
 				At:          now,
-				Kind:        "history_failed",
+				Kind:        persistence.ActionHistoryFailed,
 				WorkspaceID: h.WorkspaceID,
 				Payload: map[string]interface{}{
 					"original_action_id": historyAction.ID,
@@ -414,13 +477,31 @@ func (h *Handler) applyPlanPatch(
 
 	// Then save design/state
 	// If save fails, record failure action for recovery tracking (PRD 12.3 requirement)
-	wbs.UpdatedAt = now
-	if err := h.Repo.Design().SaveWBS(wbs); err != nil {
+	// 読み込み後に他の書き込み手が WBS を更新していても、記録した変更を最新の WBS に適用し直して保存する
+	if err := h.Repo.Design().UpdateWBS(func(fresh *persistence.WBS) error {
+		if fresh.WBSID == "" {
+			fresh.WBSID = wbs.WBSID
+			fresh.CreatedAt = wbs.CreatedAt
+		}
+		if fresh.ProjectRoot == "" {
+			fresh.ProjectRoot = wbs.ProjectRoot
+		}
+		if fresh.RootNodeID == "" {
+			fresh.RootNodeID = wbs.RootNodeID
+		}
+		for _, edit := range wbsEdits {
+			if err := edit(fresh); err != nil {
+				return err
+			}
+		}
+		fresh.UpdatedAt = now
+		return nil
+	}); err != nil {
 		if h.Repo.History() != nil {
 			failAction := &persistence.Action{
 				ID:          uuid.New().String(),
 				At:          now,
-				Kind:        "state_save_failed",
+				Kind:        persistence.ActionStateSaveFailed,
 				WorkspaceID: h.WorkspaceID,
 				Payload: map[string]interface{}{
 					"original_action_id": historyAction.ID,
@@ -432,12 +513,20 @@ func (h *Handler) applyPlanPatch(
 		}
 		return nil, fmt.Errorf("failed to save wbs: %w", err)
 	}
-	if err := h.Repo.State().SaveNodesRuntime(nodesRuntime); err != nil {
+	if err := h.Repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		if len(obsoleteIDs) == 0 {
+			return persistence.ErrNoChange
+		}
+		for _, id := range obsoleteIDs {
+			markNodeObsolete(nodesRuntime, id, now)
+		}
+		return nil
+	}); err != nil {
 		if h.Repo.History() != nil {
 			failAction := &persistence.Action{
 				ID:          uuid.New().String(),
 				At:          now,
-				Kind:        "state_save_failed",
+				Kind:        persistence.ActionStateSaveFailed,
 				WorkspaceID: h.WorkspaceID,
 				Payload: map[string]interface{}{
 					"original_action_id": historyAction.ID,
@@ -449,12 +538,24 @@ func (h *Handler) applyPlanPatch(
 		}
 		return nil, fmt.Errorf("failed to save nodes runtime: %w", err)
 	}
-	if err := h.Repo.State().SaveTasks(tasksState); err != nil {
+	if err := h.Repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+		if len(deleted) == 0 {
+			return persistence.ErrNoChange
+		}
+		for _, id := range result.DeletedTaskIDs {
+			// 読み込み後に実行が始まったタスクは削除しない
+			if isTaskRunning(state, id) {
+				return fmt.Errorf("cannot delete running task: %s", id)
+			}
+			removeTaskState(state, id)
+		}
+		return nil
+	}); err != nil {
 		if h.Repo.History() != nil {
 			failAction := &persistence.Action{
 				ID:          uuid.New().String(),
 				At:          now,
-				Kind:        "state_save_failed",
+				Kind:        persistence.ActionStateSaveFailed,
 				WorkspaceID: h.WorkspaceID,
 				Payload: map[string]interface{}{
 					"original_action_id": historyAction.ID,
@@ -467,9 +568,152 @@ func (h *Handler) applyPlanPatch(
 		return nil, fmt.Errorf("failed to save tasks state: %w", err)
 	}
 
+	// Meta-agent が指摘した潜在的なコンフリクトは、関連タスクの書き込み先として宣言する（ファイルロックの対象になる）
+	declarePotentialConflicts(h.Repo, resp.PotentialConflicts, tempToReal, deleted, now, logger)
+
+	// 完了したノードの受け入れ条件・説明が変わった場合は、影響するノードを再検証の対象にする
+	h.propagateDesignChanges(result.UpdatedTasks, existingTasksByID, logger)
+
 	return result, nil
 }
 
+// propagateDesignChanges は受け入れ条件・説明を変更したタスクのノードについて変更の影響を伝播する
+// 影響の伝播は計画の変更の補助なので、失敗しても plan_patch は失敗させない。
+func (h *Handler) propagateDesignChanges(updated []orchestrator.Task, before map[string]orchestrator.Task, logger *slog.Logger) {
+	if len(updated) == 0 {
+		return
+	}
+	cfg, err := orchestrator.LoadVerificationConfig(h.Repo.BaseDir())
+	if err != nil {
+		logger.Warn("failed to load verification config, re-verification is not scheduled", slog.Any("error", err))
+		cfg = nil
+	}
+	for _, task := range updated {
+		old, ok := before[task.ID]
+		if !ok {
+			continue
+		}
+		var changed []string
+		if !slices.Equal(old.AcceptanceCriteria, task.AcceptanceCriteria) {
+			changed = append(changed, "acceptance criteria")
+		}
+		if old.Description != task.Description {
+			changed = append(changed, "description")
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		detail := strings.Join(changed, " and ") + " updated"
+		if _, err := orchestrator.PropagateImpact(h.Repo, task.ID, orchestrator.ImpactCauseDesignChanged, detail, cfg, logger); err != nil {
+			logger.Warn("failed to propagate design change", slog.String("task_id", task.ID), slog.Any("error", err))
+		}
+	}
+}
+
+// declarePotentialConflicts は potential_conflicts のファイルを関連タスクのノード設計の file_paths に追加する
+// ファイルロックの精度を上げるための補助なので、保存に失敗しても plan_patch は失敗させない。
+func declarePotentialConflicts(
+	repo persistence.WorkspaceRepository,
+	conflicts []meta.PotentialConflict,
+	tempToReal map[string]string,
+	deleted map[string]struct{},
+	now time.Time,
+	logger *slog.Logger,
+) {
+	filesByTask := make(map[string][]string)
+	var order []string
+	for _, c := range conflicts {
+		file := strings.TrimSpace(c.File)
+		if file == "" {
+			continue
+		}
+		for _, ref := range c.Tasks {
+			taskID := strings.TrimSpace(ref)
+			if real, ok := tempToReal[taskID]; ok {
+				taskID = real
+			}
+			if taskID == "" {
+				continue
+			}
+			if _, ok := deleted[taskID]; ok {
+				continue
+			}
+			if _, ok := filesByTask[taskID]; !ok {
+				order = append(order, taskID)
+			}
+			filesByTask[taskID] = append(filesByTask[taskID], file)
+		}
+	}
+
+	for _, taskID := range order {
+		err := repo.Design().UpdateNode(taskID, func(node *persistence.NodeDesign) error {
+			changed := false
+			for _, file := range filesByTask[taskID] {
+				if !slices.Contains(node.SuggestedImpl.FilePaths, file) {
+					node.SuggestedImpl.FilePaths = append(node.SuggestedImpl.FilePaths, file)
+					changed = true
+				}
+			}
+			if !changed {
+				return persistence.ErrNoChange
+			}
+			node.UpdatedAt = now
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			logger.Warn("failed to declare potential conflict files", slog.String("task_id", taskID), slog.Any("error", err))
+		}
+	}
+}
+
+// checkPatchDependencyCycles は plan_patch を適用した後の依存グラフに、適用前には無かった循環ができないかを検査する
+// tasksByID は作成するタスクを含む適用後のタスク一覧、createdByID は作成するタスク。
+func checkPatchDependencyCycles(
+	resp *meta.PlanPatchResponse,
+	tempToReal map[string]string,
+	tasksByID map[string]orchestrator.Task,
+	createdByID map[string]orchestrator.Task,
+) error {
+	before := orchestrator.NewDependencyGraph()
+	after := orchestrator.NewDependencyGraph()
+	for id, t := range tasksByID {
+		after.SetNode(id, t.Dependencies, 0)
+		if _, created := createdByID[id]; !created {
+			before.SetNode(id, t.Dependencies, 0)
+		}
+	}
+	for _, op := range resp.Operations {
+		// move も facet の更新として依存を置き換える（applyUpdateOp）
+		if (op.Op != meta.PlanOpUpdate && op.Op != meta.PlanOpMove) || op.Dependencies == nil {
+			continue
+		}
+		taskID := strings.TrimSpace(op.TaskID)
+		if real, ok := tempToReal[taskID]; ok {
+			taskID = real
+		}
+		deps := make([]string, 0, len(op.Dependencies))
+		for _, depRef := range op.Dependencies {
+			depID := strings.TrimSpace(depRef)
+			if real, ok := tempToReal[depID]; ok {
+				depID = real
+			}
+			deps = append(deps, depID)
+		}
+		after.SetNode(taskID, deps, 0)
+	}
+
+	existing := make(map[string]struct{})
+	for _, cycle := range before.Cycles() {
+		existing[strings.Join(cycle, ",")] = struct{}{}
+	}
+	for _, cycle := range after.Cycles() {
+		if _, ok := existing[strings.Join(cycle, ",")]; !ok {
+			return fmt.Errorf("%w: plan_patch introduces a cycle among %s", orchestrator.ErrDependencyCycle, strings.Join(cycle, ", "))
+		}
+	}
+	return nil
+}
+
 func taskIDs(tasks []orchestrator.Task) []string {
 	out := make([]string, 0, len(tasks))
 	for _, t := range tasks {
@@ -808,26 +1052,28 @@ func cleanupDeletedDependencies(repo persistence.WorkspaceRepository, tasksState
 		if ts.NodeID == "" {
 			continue
 		}
-		node, err := repo.Design().GetNode(ts.NodeID)
-		if err != nil {
-			continue
-		}
-		changed := false
-		next := node.Dependencies[:0]
-		for _, dep := range node.Dependencies {
-			if _, ok := deleted[dep]; ok {
-				changed = true
-				continue
+		err := repo.Design().UpdateNode(ts.NodeID, func(node *persistence.NodeDesign) error {
+			changed := false
+			next := make([]string, 0, len(node.Dependencies))
+			for _, dep := range node.Dependencies {
+				if _, ok := deleted[dep]; ok {
+					changed = true
+					continue
+				}
+				next = append(next, dep)
 			}
-			next = append(next, dep)
-		}
-		if !changed {
+			if !changed {
+				return persistence.ErrNoChange
+			}
+			node.Dependencies = next
+			node.UpdatedAt = now
+			return nil
+		})
+		if os.IsNotExist(err) {
 			continue
 		}
-		node.Dependencies = next
-		node.UpdatedAt = now
-		if err := repo.Design().SaveNode(node); err != nil {
-			return fmt.Errorf("failed to save node %s during dependency cleanup: %w", node.NodeID, err)
+		if err != nil {
+			return fmt.Errorf("failed to save node %s during dependency cleanup: %w", ts.NodeID, err)
 		}
 	}
 	return nil
@@ -843,127 +1089,84 @@ func (h *Handler) applyUpdateOp(
 	now := time.Now()
 
 	if h.Repo != nil {
-		node, err := h.Repo.Design().GetNode(taskID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load node %s: %w", taskID, err)
-		}
-
-		if op.Title != nil {
-			node.Name = strings.TrimSpace(*op.Title)
-		}
-		if op.Description != nil {
-			node.Summary = strings.TrimSpace(*op.Description)
-		}
-		if op.PhaseName != nil {
-			node.PhaseName = strings.TrimSpace(*op.PhaseName)
-		}
-		if op.Milestone != nil {
-			node.Milestone = strings.TrimSpace(*op.Milestone)
-		}
-		if op.WBSLevel != nil {
-			node.WBSLevel = *op.WBSLevel
-		}
-
-		if op.AcceptanceCriteria != nil {
-			node.AcceptanceCriteria = op.AcceptanceCriteria
-		}
-
-		if op.Dependencies != nil {
-			deps := make([]string, 0, len(op.Dependencies))
-			for _, depRef := range op.Dependencies {
-				depID := strings.TrimSpace(depRef)
-				if depID == "" {
-					continue
-				}
-				if real, ok := tempToReal[depID]; ok {
-					depID = real
-				}
-				if _, ok := knownTaskIDs[depID]; !ok {
-					return nil, fmt.Errorf("unknown dependency id in update: %s (task_id=%s)", depRef, taskID)
-				}
-				deps = append(deps, depID)
+		err := h.Repo.Design().UpdateNode(taskID, func(node *persistence.NodeDesign) error {
+			if op.Title != nil {
+				node.Name = strings.TrimSpace(*op.Title)
 			}
-			node.Dependencies = deps
-		}
-
-		if op.SuggestedImpl != nil {
-			paths := make([]string, 0, len(op.SuggestedImpl.FilePaths))
-			for _, p := range op.SuggestedImpl.FilePaths {
-				paths = append(paths, strings.TrimSuffix(p, " (New File)"))
+			if op.Description != nil {
+				node.Summary = strings.TrimSpace(*op.Description)
 			}
-			node.SuggestedImpl = persistence.SuggestedImpl{
-				Language:    op.SuggestedImpl.Language,
-				FilePaths:   paths,
-				Constraints: op.SuggestedImpl.Constraints,
+			if op.PhaseName != nil {
+				node.PhaseName = strings.TrimSpace(*op.PhaseName)
+			}
+			if op.Milestone != nil {
+				node.Milestone = strings.TrimSpace(*op.Milestone)
+			}
+			if op.WBSLevel != nil {
+				node.WBSLevel = *op.WBSLevel
+			}
+			if op.EstimatedEffort != nil {
+				node.Estimate.Difficulty = strings.TrimSpace(*op.EstimatedEffort)
+			}
+			if op.StoryPoints != nil {
+				node.Estimate.StoryPoints = *op.StoryPoints
 			}
-		}
 
-		node.UpdatedAt = now
-		if err := h.Repo.Design().SaveNode(node); err != nil {
-			return nil, fmt.Errorf("failed to save node %s: %w", node.NodeID, err)
-		}
-	}
+			if op.AcceptanceCriteria != nil {
+				node.AcceptanceCriteria = op.AcceptanceCriteria
+			}
 
-	// Best-effort TaskStore sync.
-	storeTask, err := h.TaskStore.LoadTask(taskID)
-	if err == nil && storeTask != nil {
-		if op.Title != nil && strings.TrimSpace(*op.Title) != "" {
-			storeTask.Title = strings.TrimSpace(*op.Title)
-		}
-		if op.Description != nil {
-			storeTask.Description = strings.TrimSpace(*op.Description)
-		}
-		if op.Dependencies != nil {
-			deps := make([]string, 0, len(op.Dependencies))
-			for _, depRef := range op.Dependencies {
-				depID := strings.TrimSpace(depRef)
-				if real, ok := tempToReal[depID]; ok {
-					depID = real
-				}
-				if depID != "" {
+			if op.Dependencies != nil {
+				deps := make([]string, 0, len(op.Dependencies))
+				for _, depRef := range op.Dependencies {
+					depID := strings.TrimSpace(depRef)
+					if depID == "" {
+						continue
+					}
+					if real, ok := tempToReal[depID]; ok {
+						depID = real
+					}
+					if _, ok := knownTaskIDs[depID]; !ok {
+						return fmt.Errorf("unknown dependency id in update: %s (task_id=%s)", depRef, taskID)
+					}
 					deps = append(deps, depID)
 				}
+				node.Dependencies = deps
 			}
-			storeTask.Dependencies = deps
-		}
-		if op.PhaseName != nil {
-			storeTask.PhaseName = strings.TrimSpace(*op.PhaseName)
-		}
-		if op.Milestone != nil {
-			storeTask.Milestone = strings.TrimSpace(*op.Milestone)
-		}
-		if op.WBSLevel != nil {
-			storeTask.WBSLevel = *op.WBSLevel
-		}
-		if op.ParentID != nil && strings.TrimSpace(*op.ParentID) != "" {
-			parent := strings.TrimSpace(*op.ParentID)
-			if real, ok := tempToReal[parent]; ok {
-				parent = real
-			}
-			storeTask.ParentID = &parent
-		}
-		if op.AcceptanceCriteria != nil {
-			storeTask.AcceptanceCriteria = op.AcceptanceCriteria
-		}
-		if op.SuggestedImpl != nil {
-			// PRD 13.3 #3: 正規化（NodeDesignと同一ルール）
-			normalizedPaths := make([]string, 0, len(op.SuggestedImpl.FilePaths))
-			for _, p := range op.SuggestedImpl.FilePaths {
-				normalizedPaths = append(normalizedPaths, strings.TrimSuffix(p, " (New File)"))
-			}
-			storeTask.SuggestedImpl = &orchestrator.SuggestedImpl{
-				Language:    op.SuggestedImpl.Language,
-				FilePaths:   normalizedPaths,
-				Constraints: op.SuggestedImpl.Constraints,
+
+			if op.SuggestedImpl != nil {
+				paths := make([]string, 0, len(op.SuggestedImpl.FilePaths))
+				for _, p := range op.SuggestedImpl.FilePaths {
+					paths = append(paths, strings.TrimSuffix(p, " (New File)"))
+				}
+				node.SuggestedImpl = persistence.SuggestedImpl{
+					Language:    op.SuggestedImpl.Language,
+					FilePaths:   paths,
+					Constraints: op.SuggestedImpl.Constraints,
+				}
 			}
+
+			node.UpdatedAt = now
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update node %s: %w", taskID, err)
 		}
-		if err := h.TaskStore.SaveTask(storeTask); err != nil {
-			return nil, fmt.Errorf("failed to save task store for %s: %w", taskID, err)
-		}
-		return storeTask, nil
 	}
 
-	return nil, nil
+	// 更新後のタスクは state / design から組み立てる（WBS の移動は後で保存されるため親は op から反映する）
+	task, err := orchestrator.FindTaskView(h.Repo, taskID)
+	if err != nil {
+		return nil, nil
+	}
+	if op.ParentID != nil && strings.TrimSpace(*op.ParentID) != "" {
+		parent := strings.TrimSpace(*op.ParentID)
+		if real, ok := tempToReal[parent]; ok {
+			parent = real
+		}
+		task.ParentID = &parent
+	}
+	return task, nil
 }
 
 func (h *Handler) buildPlanPatchResponseContent(resp *meta.PlanPatchResponse, res *PlanPatchApplyResult) string {
@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func TestApplyPlanPatch_DeclaresPotentialConflictFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	if err := repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "task-1", Name: "既存タスク"}); err != nil {
+		t.Fatalf("SaveNode failed: %v", err)
+	}
+	err := repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{{
+		TaskID:    "task-1",
+		NodeID:    "task-1",
+		Kind:      "implementation",
+		Status:    string(orchestrator.TaskStatusPending),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}}})
+	if err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
+	}
+	handler := NewHandler(&MockMetaClient{}, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	title := "新しいタスク"
+	resp := &meta.PlanPatchResponse{
+		Operations: []meta.PlanOperation{{Op: meta.PlanOpCreate, TempID: "t1", Title: &title}},
+		PotentialConflicts: []meta.PotentialConflict{
+			{File: "internal/shared.go", Tasks: []string{"task-1", "t1"}, Warning: "両方のタスクが変更する"},
+		},
+	}
+	res, err := handler.applyPlanPatch(context.Background(), "", resp,
+		map[string]struct{}{"task-1": {}}, map[string]orchestrator.Task{"task-1": {ID: "task-1"}})
+	if err != nil {
+		t.Fatalf("applyPlanPatch failed: %v", err)
+	}
+	if len(res.CreatedTasks) != 1 {
+		t.Fatalf("expected 1 created task, got %d", len(res.CreatedTasks))
+	}
+
+	for _, id := range []string{"task-1", res.CreatedTasks[0].ID} {
+		node, err := repo.Design().GetNode(id)
+		if err != nil {
+			t.Fatalf("GetNode(%s) failed: %v", id, err)
+		}
+		if !slices.Contains(node.SuggestedImpl.FilePaths, "internal/shared.go") {
+			t.Errorf("node %s must declare the conflicting file, got %v", id, node.SuggestedImpl.FilePaths)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func TestApplyPlanPatch_RejectsDependencyCycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	if err := repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "task-1", Name: "既存タスク"}); err != nil {
+		t.Fatalf("SaveNode failed: %v", err)
+	}
+	err := repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{{
+		TaskID:    "task-1",
+		NodeID:    "task-1",
+		Kind:      "implementation",
+		Status:    string(orchestrator.TaskStatusPending),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}}})
+	if err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
+	}
+	handler := NewHandler(&MockMetaClient{}, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	apply := func(ops []meta.PlanOperation) error {
+		existing, err := orchestrator.ListTaskViews(repo)
+		if err != nil {
+			t.Fatalf("ListTaskViews failed: %v", err)
+		}
+		ids := make(map[string]struct{}, len(existing))
+		byID := make(map[string]orchestrator.Task, len(existing))
+		for _, task := range existing {
+			ids[task.ID] = struct{}{}
+			byID[task.ID] = task
+		}
+		_, err = handler.applyPlanPatch(context.Background(), "", &meta.PlanPatchResponse{Operations: ops}, ids, byID)
+		return err
+	}
+
+	// 作成するタスクが既存タスクに依存し、既存タスクを作成するタスクに依存させると循環する
+	title := "前提"
+	err = apply([]meta.PlanOperation{
+		{Op: meta.PlanOpCreate, TempID: "t1", Title: &title, Dependencies: []string{"task-1"}},
+		{Op: meta.PlanOpUpdate, TaskID: "task-1", Dependencies: []string{"t1"}},
+	})
+	if !errors.Is(err, orchestrator.ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", err)
+	}
+	tasks, err := orchestrator.ListTaskViews(repo)
+	if err != nil {
+		t.Fatalf("ListTaskViews failed: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Errorf("rejected patch must not create tasks, got %d tasks", len(tasks))
+	}
+
+	// 自己依存も拒否する
+	err = apply([]meta.PlanOperation{{Op: meta.PlanOpUpdate, TaskID: "task-1", Dependencies: []string{"task-1"}}})
+	if !errors.Is(err, orchestrator.ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle for self dependency, got %v", err)
+	}
+
+	// 循環しない依存は適用できる
+	if err := apply([]meta.PlanOperation{{Op: meta.PlanOpCreate, TempID: "t2", Title: &title, Dependencies: []string{"task-1"}}}); err != nil {
+		t.Fatalf("applyPlanPatch failed: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package chat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func TestApplyPlanPatch_PersistsEstimates(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	handler := NewHandler(&MockMetaClient{}, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	title := "API"
+	effort := "medium"
+	points := 3
+	resp := &meta.PlanPatchResponse{Operations: []meta.PlanOperation{
+		{Op: meta.PlanOpCreate, TempID: "temp-1", Title: &title, EstimatedEffort: &effort, StoryPoints: &points},
+	}}
+	res, err := handler.applyPlanPatch(context.Background(), "", resp, map[string]struct{}{}, map[string]orchestrator.Task{})
+	if err != nil {
+		t.Fatalf("applyPlanPatch failed: %v", err)
+	}
+	if len(res.CreatedTasks) != 1 {
+		t.Fatalf("expected 1 created task, got %d", len(res.CreatedTasks))
+	}
+	taskID := res.CreatedTasks[0].ID
+	node, err := repo.Design().GetNode(taskID)
+	if err != nil {
+		t.Fatalf("GetNode failed: %v", err)
+	}
+	if node.Estimate.Difficulty != "medium" || node.Estimate.StoryPoints != 3 {
+		t.Fatalf("unexpected estimate on create: %+v", node.Estimate)
+	}
+
+	// update で見積もりを補正できる
+	effort = "large"
+	points = 8
+	update := &meta.PlanPatchResponse{Operations: []meta.PlanOperation{
+		{Op: meta.PlanOpUpdate, TaskID: taskID, EstimatedEffort: &effort, StoryPoints: &points},
+	}}
+	ids := map[string]struct{}{taskID: {}}
+	byID := map[string]orchestrator.Task{taskID: res.CreatedTasks[0]}
+	if _, err := handler.applyPlanPatch(context.Background(), "", update, ids, byID); err != nil {
+		t.Fatalf("applyPlanPatch update failed: %v", err)
+	}
+	views, err := orchestrator.ListTaskViews(repo)
+	if err != nil {
+		t.Fatalf("ListTaskViews failed: %v", err)
+	}
+	if len(views) != 1 || views[0].EstimatedEffort != "large" || views[0].StoryPoints != 8 {
+		t.Fatalf("unexpected task view estimate: %+v", views)
+	}
+}
+
+func TestBuildPlanPatchRequest_IncludesCalibration(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	node := persistence.NodeDesign{NodeID: "n1", Kind: "feature", Estimate: persistence.Estimate{StoryPoints: 2, Difficulty: "small"}}
+	if err := repo.Design().SaveNode(&node); err != nil {
+		t.Fatalf("SaveNode failed: %v", err)
+	}
+	err := repo.State().SaveNodesRuntime(&persistence.NodesRuntime{Nodes: []persistence.NodeRuntime{
+		{NodeID: "n1", Status: string(persistence.NodeRuntimeStatusImplemented), Actuals: &persistence.NodeActuals{Attempts: 2, WorkerRuns: 3, WallClockSec: 900, CostUSD: 0.3}},
+	}})
+	if err != nil {
+		t.Fatalf("SaveNodesRuntime failed: %v", err)
+	}
+	handler := NewHandler(&MockMetaClient{}, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	req := handler.buildPlanPatchRequest("", "add a feature", nil)
+	rows := req.Context.Calibration
+	if len(rows) != 2 {
+		t.Fatalf("expected kind and difficulty rows, got %+v", rows)
+	}
+	if rows[0].Dimension != orchestrator.CalibrationDimensionKind || rows[0].Key != "feature" {
+		t.Errorf("unexpected kind row: %+v", rows[0])
+	}
+	if rows[1].Dimension != orchestrator.CalibrationDimensionDifficulty || rows[1].Key != "small" {
+		t.Errorf("unexpected difficulty row: %+v", rows[1])
+	}
+	if rows[0].AvgWallClockMin != 15 || rows[0].AvgAttempts != 2 {
+		t.Errorf("unexpected actuals: %+v", rows[0])
+	}
+
+	decompose := handler.BuildDecomposeRequest("", "add a feature", nil)
+	if len(decompose.Context.Calibration) != 2 {
+		t.Errorf("expected calibration in decompose context, got %+v", decompose.Context.Calibration)
+	}
+}
@@ -0,0 +1,71 @@
+package chat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func TestApplyPlanPatch_FlagsImpactedNodesOnDesignChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	for _, node := range []persistence.NodeDesign{
+		{NodeID: "task-1", Name: "API", AcceptanceCriteria: []string{"200 を返す"}},
+		{NodeID: "task-2", Name: "UI", Dependencies: []string{"task-1"}},
+	} {
+		if err := repo.Design().SaveNode(&node); err != nil {
+			t.Fatalf("SaveNode failed: %v", err)
+		}
+	}
+	now := time.Now()
+	err := repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "task-1", Kind: "implementation", Status: string(orchestrator.TaskStatusSucceeded), CreatedAt: now, UpdatedAt: now},
+		{TaskID: "task-2", NodeID: "task-2", Kind: "implementation", Status: string(orchestrator.TaskStatusSucceeded), CreatedAt: now, UpdatedAt: now},
+	}})
+	if err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
+	}
+	err = repo.State().SaveNodesRuntime(&persistence.NodesRuntime{Nodes: []persistence.NodeRuntime{
+		{NodeID: "task-1", Status: string(persistence.NodeRuntimeStatusVerified)},
+		{NodeID: "task-2", Status: string(persistence.NodeRuntimeStatusVerified)},
+	}})
+	if err != nil {
+		t.Fatalf("SaveNodesRuntime failed: %v", err)
+	}
+	handler := NewHandler(&MockMetaClient{}, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	existing, err := orchestrator.ListTaskViews(repo)
+	if err != nil {
+		t.Fatalf("ListTaskViews failed: %v", err)
+	}
+	ids := make(map[string]struct{}, len(existing))
+	byID := make(map[string]orchestrator.Task, len(existing))
+	for _, task := range existing {
+		ids[task.ID] = struct{}{}
+		byID[task.ID] = task
+	}
+	resp := &meta.PlanPatchResponse{Operations: []meta.PlanOperation{
+		{Op: meta.PlanOpUpdate, TaskID: "task-1", AcceptanceCriteria: []string{"201 を返す"}},
+	}}
+	if _, err := handler.applyPlanPatch(context.Background(), "", resp, ids, byID); err != nil {
+		t.Fatalf("applyPlanPatch failed: %v", err)
+	}
+
+	stale, err := orchestrator.ListStaleNodes(repo)
+	if err != nil {
+		t.Fatalf("ListStaleNodes failed: %v", err)
+	}
+	if len(stale) != 2 || stale[0].NodeID != "task-1" || stale[1].NodeID != "task-2" {
+		t.Fatalf("expected both nodes to need re-verification, got %+v", stale)
+	}
+	if want := "depends on task-1 (design changed): acceptance criteria updated"; stale[1].Reason != want {
+		t.Errorf("reason = %q, want %q", stale[1].Reason, want)
+	}
+}
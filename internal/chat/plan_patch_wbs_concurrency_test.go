@@ -0,0 +1,98 @@
+package chat
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// racingWBSRepo は applyPlanPatch が WBS を読んだ直後に、別の書き込み手が WBS を更新した状況を作る
+type racingWBSRepo struct {
+	persistence.WorkspaceRepository
+	design *racingDesignRepo
+}
+
+func (r *racingWBSRepo) Design() persistence.DesignRepository { return r.design }
+
+type racingDesignRepo struct {
+	persistence.DesignRepository
+	raced bool
+}
+
+func (d *racingDesignRepo) LoadWBS() (*persistence.WBS, error) {
+	wbs, err := d.DesignRepository.LoadWBS()
+	if err != nil || d.raced {
+		return wbs, err
+	}
+	d.raced = true
+	err = d.DesignRepository.UpdateWBS(func(w *persistence.WBS) error {
+		parent := w.RootNodeID
+		w.NodeIndex = append(w.NodeIndex, persistence.NodeIndex{NodeID: "ide-node", ParentID: &parent, Children: []string{}})
+		for i := range w.NodeIndex {
+			if w.NodeIndex[i].NodeID == w.RootNodeID {
+				w.NodeIndex[i].Children = append(w.NodeIndex[i].Children, "ide-node")
+			}
+		}
+		return nil
+	})
+	return wbs, err
+}
+
+func TestApplyPlanPatch_ReappliesWBSChangesOnConcurrentWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := persistence.NewWorkspaceRepository(tmpDir)
+	if err := base.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	root := "node-root"
+	if err := base.Design().SaveWBS(&persistence.WBS{
+		WBSID:      "wbs-1",
+		RootNodeID: root,
+		NodeIndex: []persistence.NodeIndex{
+			{NodeID: root, Children: []string{"task-1", "task-2"}},
+			{NodeID: "task-1", ParentID: &root, Children: []string{}},
+			{NodeID: "task-2", ParentID: &root, Children: []string{}},
+		},
+	}); err != nil {
+		t.Fatalf("SaveWBS failed: %v", err)
+	}
+	now := time.Now()
+	if err := base.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "task-1", Status: string(orchestrator.TaskStatusPending), CreatedAt: now, UpdatedAt: now},
+		{TaskID: "task-2", NodeID: "task-2", Status: string(orchestrator.TaskStatusPending), CreatedAt: now, UpdatedAt: now},
+	}}); err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
+	}
+	repo := &racingWBSRepo{WorkspaceRepository: base, design: &racingDesignRepo{DesignRepository: base.Design()}}
+	handler := NewHandler(&MockMetaClient{}, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	resp := &meta.PlanPatchResponse{Operations: []meta.PlanOperation{{Op: meta.PlanOpDelete, TaskID: "task-2"}}}
+	existing := map[string]orchestrator.Task{"task-1": {ID: "task-1"}, "task-2": {ID: "task-2"}}
+	if _, err := handler.applyPlanPatch(context.Background(), "", resp,
+		map[string]struct{}{"task-1": {}, "task-2": {}}, existing); err != nil {
+		t.Fatalf("applyPlanPatch failed: %v", err)
+	}
+
+	wbs, err := base.Design().LoadWBS()
+	if err != nil {
+		t.Fatalf("LoadWBS failed: %v", err)
+	}
+	var ids []string
+	for _, n := range wbs.NodeIndex {
+		ids = append(ids, n.NodeID)
+		if n.NodeID == root && !slices.Equal(n.Children, []string{"task-1", "ide-node"}) {
+			t.Errorf("unexpected root children: %v", n.Children)
+		}
+	}
+	if !slices.Contains(ids, "ide-node") {
+		t.Errorf("concurrent WBS write was lost: %v", ids)
+	}
+	if slices.Contains(ids, "task-2") {
+		t.Errorf("deleted node must be removed from the WBS: %v", ids)
+	}
+}
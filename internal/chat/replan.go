@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/logging"
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+)
+
+// ReplanTask はリトライを使い切ったタスクを Meta-agent（plan_patch）で計画し直す
+// 自動再計画（orchestrator.TaskReplanner）から呼ばれる。適用するのは create 操作と元のタスクへの update 操作のみで、
+// 元のタスクのステータスと後続タスクの付け替えは呼び出し側が行う。
+func (h *Handler) ReplanTask(ctx context.Context, req orchestrator.ReplanRequest) (*orchestrator.ReplanResult, error) {
+	logger := logging.WithTraceID(h.logger, ctx)
+	if h.Repo == nil {
+		return nil, fmt.Errorf("workspace repository not configured")
+	}
+
+	existingTasks, err := orchestrator.ListTaskViews(h.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing tasks: %w", err)
+	}
+	existingTaskIDs := make(map[string]struct{}, len(existingTasks))
+	existingTasksByID := make(map[string]orchestrator.Task, len(existingTasks))
+	for _, t := range existingTasks {
+		existingTaskIDs[t.ID] = struct{}{}
+		existingTasksByID[t.ID] = t
+	}
+	task, ok := existingTasksByID[req.TaskID]
+	if !ok {
+		return nil, fmt.Errorf("task not found: %s", req.TaskID)
+	}
+
+	patchReq := h.buildPlanPatchRequest("", replanTaskMessage(task, req), existingTasks)
+	metaCtx, cancel := context.WithTimeout(ctx, h.metaTimeout)
+	defer cancel()
+	resp, err := h.Meta.PlanPatch(metaCtx, patchReq)
+	if err != nil {
+		return nil, fmt.Errorf("meta-agent plan_patch failed: %w", err)
+	}
+
+	// 再計画で変更してよいのは元のタスクだけ（他のタスクの削除・移動・更新は無視する）
+	ops := make([]meta.PlanOperation, 0, len(resp.Operations))
+	for _, op := range resp.Operations {
+		if op.Op == meta.PlanOpCreate || (op.Op == meta.PlanOpUpdate && strings.TrimSpace(op.TaskID) == req.TaskID) {
+			ops = append(ops, op)
+		} else {
+			logger.Warn("ignoring operation in task replan",
+				slog.String("task_id", req.TaskID),
+				slog.String("op", string(op.Op)),
+				slog.String("target", op.TaskID),
+			)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("meta-agent returned no changes for replan of %s", req.TaskID)
+	}
+	resp.Operations = ops
+
+	res, err := h.applyPlanPatch(ctx, "", resp, existingTaskIDs, existingTasksByID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply task replan: %w", err)
+	}
+	updatedOriginal := slices.Contains(taskIDs(res.UpdatedTasks), req.TaskID)
+	logger.Info("task replanned by meta-agent",
+		slog.String("task_id", req.TaskID),
+		slog.Int("created_tasks", len(res.CreatedTasks)),
+		slog.Bool("updated_original", updatedOriginal),
+	)
+	return &orchestrator.ReplanResult{
+		Understanding:   resp.Understanding,
+		CreatedTaskIDs:  taskIDs(res.CreatedTasks),
+		UpdatedOriginal: updatedOriginal,
+	}, nil
+}
+
+// replanTaskMessage は再計画を依頼する Meta-agent への入力を作る
+func replanTaskMessage(task orchestrator.Task, req orchestrator.ReplanRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "タスク %s「%s」はリトライを使い切っても完了しませんでした（失敗の分類: %s、%d 回目の再計画）。\n", task.ID, task.Title, req.FailureKind, req.Replan)
+	b.WriteString("失敗の内容を踏まえて、次のいずれかで計画を直してください。\n")
+	fmt.Fprintf(&b, "- 元のタスク %s を update して受け入れ条件や説明を実行可能なものに調整する\n", task.ID)
+	fmt.Fprintf(&b, "- 前提となるタスクを create し、元のタスク %s の dependencies に追加する\n", task.ID)
+	b.WriteString("- 元のタスクを置き換える小さなタスクを create する（元のタスクは update しない）\n")
+	b.WriteString("元のタスク以外の既存タスクの更新・削除・移動は行わないでください。")
+	if task.ParentID != nil {
+		fmt.Fprintf(&b, "新しいタスクは親 %s の下に置いてください。", *task.ParentID)
+	}
+	b.WriteString("\n")
+	if task.Description != "" {
+		fmt.Fprintf(&b, "\n説明:\n%s\n", task.Description)
+	}
+	if len(task.AcceptanceCriteria) > 0 {
+		b.WriteString("\n受け入れ条件:\n")
+		for _, ac := range task.AcceptanceCriteria {
+			fmt.Fprintf(&b, "- %s\n", ac)
+		}
+	}
+	if req.Error != "" {
+		fmt.Fprintf(&b, "\n最後のエラー:\n%s\n", req.Error)
+	}
+	if len(req.Attempts) > 0 {
+		b.WriteString("\n失敗した試行:\n")
+		diffBudget := replanDiffBudget / len(req.Attempts)
+		for _, attempt := range req.Attempts {
+			fmt.Fprintf(&b, "- %s [%s]", attempt.StartedAt.Format("2006-01-02 15:04:05"), attempt.Status)
+			if attempt.FailureKind != "" {
+				fmt.Fprintf(&b, " (%s)", attempt.FailureKind)
+			}
+			if attempt.ErrorSummary != "" {
+				fmt.Fprintf(&b, ": %s", attempt.ErrorSummary)
+			}
+			b.WriteString("\n")
+			if attempt.Artifacts != nil && len(attempt.Artifacts.Files) > 0 {
+				fmt.Fprintf(&b, "  変更したファイル: %s\n", strings.Join(attempt.Artifacts.Files, ", "))
+				writeAttemptDiffs(&b, attempt.Artifacts.Diffs, diffBudget)
+			}
+		}
+	}
+	return b.String()
+}
+
+// replanDiffBudget は再計画の依頼に含める試行の差分の合計（バイト）
+// 試行ごとに均等に割り当て、超えた分は切り詰める。
+const replanDiffBudget = 24 * 1024
+
+// writeAttemptDiffs は試行の差分をファイルごとに budget バイトまで書く
+func writeAttemptDiffs(b *strings.Builder, diffs []orchestrator.FileDiff, budget int) {
+	for _, diff := range diffs {
+		patch := diff.Patch
+		truncated := diff.Truncated
+		if len(patch) > budget {
+			patch = patch[:budget]
+			if idx := strings.LastIndexByte(patch, '\n'); idx >= 0 {
+				patch = patch[:idx+1]
+			} else {
+				patch = ""
+			}
+			truncated = true
+		}
+		budget -= len(patch)
+		if patch == "" {
+			if truncated {
+				fmt.Fprintf(b, "  %s の差分: （上限を超えたため省略）\n", diff.Path)
+			}
+			continue
+		}
+		fmt.Fprintf(b, "  %s の差分:\n```diff\n%s", diff.Path, patch)
+		if !strings.HasSuffix(patch, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("```\n")
+		if truncated {
+			b.WriteString("  （上限を超えたため以降を省略）\n")
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func TestHandler_ReplanTask(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	for _, node := range []persistence.NodeDesign{{NodeID: "task-1", Name: "大きなタスク"}, {NodeID: "task-2", Name: "別のタスク"}} {
+		if err := repo.Design().SaveNode(&node); err != nil {
+			t.Fatalf("SaveNode failed: %v", err)
+		}
+	}
+	now := time.Now()
+	err := repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{
+			TaskID:    "task-1",
+			NodeID:    "task-1",
+			Kind:      "implementation",
+			Status:    string(orchestrator.TaskStatusFailed),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Inputs:    map[string]interface{}{orchestrator.InputKeyTitle: "大きなタスク"},
+		},
+		{
+			TaskID:    "task-2",
+			NodeID:    "task-2",
+			Kind:      "implementation",
+			Status:    string(orchestrator.TaskStatusPending),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Inputs:    map[string]interface{}{orchestrator.InputKeyTitle: "別のタスク"},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
+	}
+
+	var capturedReq *meta.PlanPatchRequest
+	prereq, renamed := "前提の準備", "renamed"
+	mockMeta := &MockMetaClient{
+		PlanPatchFunc: func(ctx context.Context, req *meta.PlanPatchRequest) (*meta.PlanPatchResponse, error) {
+			capturedReq = req
+			return &meta.PlanPatchResponse{
+				Understanding: "前提が足りない",
+				Operations: []meta.PlanOperation{
+					{Op: meta.PlanOpCreate, TempID: "t1", Title: &prereq},
+					{Op: meta.PlanOpUpdate, TaskID: "task-1", Dependencies: []string{"t1"}, AcceptanceCriteria: []string{"ビルドが通る"}},
+					// 元のタスク以外は変更しない
+					{Op: meta.PlanOpUpdate, TaskID: "task-2", Title: &renamed},
+					{Op: meta.PlanOpDelete, TaskID: "task-2"},
+				},
+			}, nil
+		},
+	}
+	handler := NewHandler(mockMeta, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	res, err := handler.ReplanTask(context.Background(), orchestrator.ReplanRequest{
+		TaskID:      "task-1",
+		FailureKind: orchestrator.FailureAgentGaveUp,
+		Error:       "agent gave up",
+		Replan:      1,
+		Attempts: []orchestrator.Attempt{{
+			TaskID:       "task-1",
+			Status:       orchestrator.AttemptStatusFailed,
+			StartedAt:    now,
+			ErrorSummary: "missing fixture",
+			Artifacts: &orchestrator.Artifacts{
+				Files: []string{"internal/foo.go"},
+				Diffs: []orchestrator.FileDiff{{Path: "internal/foo.go", Patch: "@@ -1 +1 @@\n-old\n+loadFixture()\n"}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("ReplanTask failed: %v", err)
+	}
+	if !res.UpdatedOriginal || len(res.CreatedTaskIDs) != 1 || res.Understanding != "前提が足りない" {
+		t.Fatalf("unexpected replan result: %+v", res)
+	}
+	for _, want := range []string{"agent gave up", "missing fixture", "internal/foo.go", "+loadFixture()"} {
+		if capturedReq == nil || !strings.Contains(capturedReq.UserInput, want) {
+			t.Errorf("replan request does not contain %q", want)
+		}
+	}
+
+	tasks, err := orchestrator.ListTaskViews(repo)
+	if err != nil {
+		t.Fatalf("ListTaskViews failed: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	for _, task := range tasks {
+		switch task.ID {
+		case "task-1":
+			if len(task.Dependencies) != 1 || task.Dependencies[0] != res.CreatedTaskIDs[0] {
+				t.Errorf("original task must depend on the prerequisite, got %v", task.Dependencies)
+			}
+		case "task-2":
+			if task.Title != "別のタスク" {
+				t.Errorf("other tasks must not be updated, got title %q", task.Title)
+			}
+		}
+	}
+
+	if _, err := handler.ReplanTask(context.Background(), orchestrator.ReplanRequest{TaskID: "missing"}); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestReplanTaskMessage_TruncatesDiffs(t *testing.T) {
+	big := strings.Repeat("+line\n", replanDiffBudget/len("+line\n")+10)
+	msg := replanTaskMessage(orchestrator.Task{ID: "task-1", Title: "大きなタスク"}, orchestrator.ReplanRequest{
+		TaskID: "task-1",
+		Attempts: []orchestrator.Attempt{{
+			Status: orchestrator.AttemptStatusFailed,
+			Artifacts: &orchestrator.Artifacts{
+				Files: []string{"a.go", "b.go"},
+				Diffs: []orchestrator.FileDiff{{Path: "a.go", Patch: big}, {Path: "b.go", Patch: "+b\n"}},
+			},
+		}},
+	})
+	if len(msg) > replanDiffBudget+2048 {
+		t.Errorf("diffs must be truncated to the budget, message is %d bytes", len(msg))
+	}
+	if !strings.Contains(msg, "a.go の差分:") || !strings.Contains(msg, "b.go の差分: （上限を超えたため省略）") {
+		t.Errorf("truncated diffs must be marked:\n%s", msg[len(msg)-200:])
+	}
+	if strings.Count(msg, "```") != 2 {
+		t.Errorf("expected one fenced diff, got %d fences", strings.Count(msg, "```"))
+	}
+}
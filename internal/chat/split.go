@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/logging"
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+)
+
+// SplitTask はタスクを Meta-agent（plan_patch）で小さなタスクに分割し、作成したタスク ID を返す
+// バックログの split 解決（orchestrator.TaskSplitter）から呼ばれる。適用するのは create 操作のみで、
+// 元のタスクと後続タスクの付け替えは呼び出し側が行う。
+func (h *Handler) SplitTask(ctx context.Context, taskID, instructions string) ([]string, error) {
+	logger := logging.WithTraceID(h.logger, ctx)
+	if h.Repo == nil {
+		return nil, fmt.Errorf("workspace repository not configured")
+	}
+
+	existingTasks, err := orchestrator.ListTaskViews(h.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing tasks: %w", err)
+	}
+	existingTaskIDs := make(map[string]struct{}, len(existingTasks))
+	existingTasksByID := make(map[string]orchestrator.Task, len(existingTasks))
+	for _, t := range existingTasks {
+		existingTaskIDs[t.ID] = struct{}{}
+		existingTasksByID[t.ID] = t
+	}
+	task, ok := existingTasksByID[taskID]
+	if !ok {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+
+	req := h.buildPlanPatchRequest("", splitTaskMessage(task, instructions), existingTasks)
+	metaCtx, cancel := context.WithTimeout(ctx, h.metaTimeout)
+	defer cancel()
+	resp, err := h.Meta.PlanPatch(metaCtx, req)
+	if err != nil {
+		return nil, fmt.Errorf("meta-agent plan_patch failed: %w", err)
+	}
+
+	// 分割では既存タスクを変更しない（元のタスクの扱いは呼び出し側が決める）
+	creates := make([]meta.PlanOperation, 0, len(resp.Operations))
+	for _, op := range resp.Operations {
+		if op.Op == meta.PlanOpCreate {
+			creates = append(creates, op)
+		} else {
+			logger.Warn("ignoring non-create operation in task split",
+				slog.String("task_id", taskID),
+				slog.String("op", string(op.Op)),
+				slog.String("target", op.TaskID),
+			)
+		}
+	}
+	if len(creates) == 0 {
+		return nil, fmt.Errorf("meta-agent returned no tasks for split of %s", taskID)
+	}
+	resp.Operations = creates
+
+	res, err := h.applyPlanPatch(ctx, "", resp, existingTaskIDs, existingTasksByID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply task split: %w", err)
+	}
+	logger.Info("task split",
+		slog.String("task_id", taskID),
+		slog.Int("created_tasks", len(res.CreatedTasks)),
+	)
+	return taskIDs(res.CreatedTasks), nil
+}
+
+// splitTaskMessage は分割を依頼する Meta-agent への入力を作る
+func splitTaskMessage(task orchestrator.Task, instructions string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "タスク %s「%s」は実行できなかったため、独立して実行できる小さなタスクに分割してください。\n", task.ID, task.Title)
+	b.WriteString("create 操作のみを返し、既存タスクの更新・削除・移動は行わないでください。")
+	if task.ParentID != nil {
+		fmt.Fprintf(&b, "新しいタスクは親 %s の下に置いてください。", *task.ParentID)
+	}
+	b.WriteString("\n")
+	if task.Description != "" {
+		fmt.Fprintf(&b, "\n説明:\n%s\n", task.Description)
+	}
+	if len(task.AcceptanceCriteria) > 0 {
+		b.WriteString("\n受け入れ条件:\n")
+		for _, ac := range task.AcceptanceCriteria {
+			fmt.Fprintf(&b, "- %s\n", ac)
+		}
+	}
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		fmt.Fprintf(&b, "\n補足:\n%s\n", instructions)
+	}
+	return b.String()
+}
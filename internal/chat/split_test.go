@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func TestHandler_SplitTask(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(tmpDir)
+	if err := repo.Init(); err != nil {
+		t.Fatalf("repo init failed: %v", err)
+	}
+	err := repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{{
+		TaskID:    "task-1",
+		NodeID:    "task-1",
+		Kind:      "implementation",
+		Status:    string(orchestrator.TaskStatusFailed),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Inputs:    map[string]interface{}{orchestrator.InputKeyTitle: "大きなタスク"},
+	}}})
+	if err != nil {
+		t.Fatalf("SaveTasks failed: %v", err)
+	}
+
+	var capturedReq *meta.PlanPatchRequest
+	title1, title2, renamed := "API", "UI", "renamed"
+	mockMeta := &MockMetaClient{
+		PlanPatchFunc: func(ctx context.Context, req *meta.PlanPatchRequest) (*meta.PlanPatchResponse, error) {
+			capturedReq = req
+			return &meta.PlanPatchResponse{
+				Operations: []meta.PlanOperation{
+					{Op: meta.PlanOpCreate, TempID: "t1", Title: &title1},
+					{Op: meta.PlanOpCreate, TempID: "t2", Title: &title2, Dependencies: []string{"t1"}},
+					// 分割では既存タスクを変更しない
+					{Op: meta.PlanOpUpdate, TaskID: "task-1", Title: &renamed},
+				},
+			}, nil
+		},
+	}
+	handler := NewHandler(mockMeta, NewChatSessionStore(tmpDir), "workspace-1", "/project", repo, nil)
+
+	created, err := handler.SplitTask(context.Background(), "task-1", "API と UI に分ける")
+	if err != nil {
+		t.Fatalf("SplitTask failed: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("expected 2 created tasks, got %d", len(created))
+	}
+	if capturedReq == nil || !strings.Contains(capturedReq.UserInput, "API と UI に分ける") {
+		t.Errorf("split instructions not passed to meta-agent: %+v", capturedReq)
+	}
+
+	tasks, err := orchestrator.ListTaskViews(repo)
+	if err != nil {
+		t.Fatalf("ListTaskViews failed: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	for _, task := range tasks {
+		if task.ID == "task-1" && task.Title != "大きなタスク" {
+			t.Errorf("original task must not be updated, got title %q", task.Title)
+		}
+	}
+
+	if _, err := handler.SplitTask(context.Background(), "missing", ""); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
@@ -63,6 +63,11 @@ type WorkerRunResult struct {
 	Summary    string
 	Error      error
 	Artifacts  []string
+
+	// CLI が報告したトークン使用量とコスト（報告が無ければ 0）
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
 }
 
 // TestResult records the result of the test command
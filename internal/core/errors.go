@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/tooling"
+)
+
+// ErrorKind は Run の失敗の分類
+// agent-runner は失敗時に runner:failed イベントの error_kind として出力し、
+// orchestrator はこれを元にリトライポリシーを選ぶ。
+type ErrorKind string
+
+const (
+	ErrorKindTransient        ErrorKind = "transient"         // 一時的な失敗（再実行で解消し得る）
+	ErrorKindRateLimit        ErrorKind = "rate_limit"        // プロバイダのレート制限
+	ErrorKindAuth             ErrorKind = "auth"              // 認証・CLI セッションの不備
+	ErrorKindSandboxInfra     ErrorKind = "sandbox_infra"     // コンテナ・サンドボックス基盤の失敗
+	ErrorKindAgentGaveUp      ErrorKind = "agent_gave_up"     // エージェントが中断した、またはループ上限で完了しなかった
+	ErrorKindValidationFailed ErrorKind = "validation_failed" // 完了評価で受け入れ条件を満たさなかった
+)
+
+// RunError は分類付きの Run の失敗
+type RunError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *RunError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// newRunError は err を kind で分類した RunError にする
+func newRunError(kind ErrorKind, err error) error {
+	return &RunError{Kind: kind, Err: err}
+}
+
+// OutcomeError は Run が返した TaskContext の最終状態を分類付きのエラーにする（COMPLETE なら nil）
+// 受け入れ条件を満たさなかった場合は validation_failed、ループ上限で完了しなかった場合は agent_gave_up。
+func OutcomeError(taskCtx *TaskContext) error {
+	switch taskCtx.State {
+	case StateComplete:
+		return nil
+	case StateFailed:
+		return &RunError{Kind: ErrorKindValidationFailed, Err: errors.New("task did not satisfy its acceptance criteria")}
+	default:
+		return &RunError{Kind: ErrorKindAgentGaveUp, Err: fmt.Errorf("task did not complete within the loop limit (state: %s)", taskCtx.State)}
+	}
+}
+
+// ErrorKindOf は err の分類を返す
+// RunError であればその Kind、そうでなければメッセージから推定する。
+func ErrorKindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var runErr *RunError
+	if errors.As(err, &runErr) {
+		return runErr.Kind
+	}
+	return ClassifyErrorMessage(err.Error())
+}
+
+// ClassifyErrorMessage はエラーメッセージからレート制限・認証の失敗を推定する（それ以外は transient）
+// メッセージには行番号・所要時間・テストの出力なども含まれるため、認証の失敗は API・CLI が返す文言と
+// ステータスコードの文脈（"status 401" など）でのみ判定する。
+func ClassifyErrorMessage(msg string) ErrorKind {
+	if tooling.IsRateLimitError(errors.New(msg)) {
+		return ErrorKindRateLimit
+	}
+	lower := strings.ToLower(msg)
+	for _, marker := range authErrorMarkers {
+		if strings.Contains(lower, marker) {
+			return ErrorKindAuth
+		}
+	}
+	if authStatusPattern.MatchString(lower) {
+		return ErrorKindAuth
+	}
+	return ErrorKindTransient
+}
+
+// authErrorMarkers は認証の失敗を示すメッセージの断片（小文字）
+var authErrorMarkers = []string{
+	"invalid api key",
+	"invalid_api_key",
+	"incorrect api key",
+	"authentication_error",
+	"codex authentication failed",
+	"session not found",
+	"not logged in",
+	"login required",
+}
+
+// authStatusPattern は HTTP ステータス 401 を示す文脈（"401 Unauthorized"・"status 401"・"status code: 401"・"HTTP/1.1 401"）
+var authStatusPattern = regexp.MustCompile(`\b401\s+unauthorized\b|\b(?:status(?:[ _]code)?|http(?:/[0-9.]+)?)\s*[:=]?\s*401\b`)
@@ -35,6 +35,54 @@ type NoteWriter interface {
 	Write(taskCtx *TaskContext) error
 }
 
+// RunnerHooks は Run の進行を受け取る型付きのコールバック（nil のフィールドは呼ばない）
+// agent-runner は同じ出来事を event_type 付きの構造化ログとして出力する。
+// プロセス内で Run を呼ぶ場合はログを解釈せずにこちらで受け取る。
+type RunnerHooks struct {
+	OnMetaThinking      func(detail string)                  // meta:thinking
+	OnContainerStarting func()                               // container:starting
+	OnContainerStarted  func()                               // container:started
+	OnWorkerRunning     func(prompt string)                  // worker:running
+	OnToolingSelected   func(candidate config.ToolCandidate) // worker:tooling_selected
+	OnWorkerCompleted   func(res WorkerRunResult)            // worker:completed
+}
+
+func (h RunnerHooks) metaThinking(detail string) {
+	if h.OnMetaThinking != nil {
+		h.OnMetaThinking(detail)
+	}
+}
+
+func (h RunnerHooks) containerStarting() {
+	if h.OnContainerStarting != nil {
+		h.OnContainerStarting()
+	}
+}
+
+func (h RunnerHooks) containerStarted() {
+	if h.OnContainerStarted != nil {
+		h.OnContainerStarted()
+	}
+}
+
+func (h RunnerHooks) workerRunning(prompt string) {
+	if h.OnWorkerRunning != nil {
+		h.OnWorkerRunning(prompt)
+	}
+}
+
+func (h RunnerHooks) toolingSelected(candidate config.ToolCandidate) {
+	if h.OnToolingSelected != nil {
+		h.OnToolingSelected(candidate)
+	}
+}
+
+func (h RunnerHooks) workerCompleted(res WorkerRunResult) {
+	if h.OnWorkerCompleted != nil {
+		h.OnWorkerCompleted(res)
+	}
+}
+
 // Runner orchestrates the task execution
 type Runner struct {
 	Config *config.TaskConfig
@@ -42,6 +90,7 @@ type Runner struct {
 	Worker WorkerExecutor
 	Note   NoteWriter
 	Logger *slog.Logger
+	Hooks  RunnerHooks
 }
 
 // NewRunner creates a new Runner instance
@@ -114,13 +163,14 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 	planRequestYAML := fmt.Sprintf("type: plan_task\nversion: 1\npayload:\n  prd: %q", taskCtx.PRDText)
 
 	logger.Info("calling Meta.PlanTask", slog.String("event_type", "meta:thinking"), slog.String("detail", "Planning task..."))
+	r.Hooks.metaThinking("Planning task...")
 	logger.Debug("PlanTask request", slog.Int("prd_length", len(taskCtx.PRDText)))
 	planStart := time.Now()
 	plan, err := r.Meta.PlanTask(ctx, taskCtx.PRDText)
 	if err != nil {
 		logger.Error("PlanTask failed", slog.Any("error", err), logging.LogDuration(planStart))
 		taskCtx.State = StateFailed
-		return taskCtx, fmt.Errorf("planning failed: %w", err)
+		return taskCtx, newRunError(ErrorKindOf(err), fmt.Errorf("planning failed: %w", err))
 	}
 	logger.Info("PlanTask completed",
 		slog.Int("criteria_count", len(plan.AcceptanceCriteria)),
@@ -155,13 +205,15 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 
 	// Start persistent container
 	logger.Info("starting worker container", slog.String("event_type", "container:starting"))
+	r.Hooks.containerStarting()
 	containerStart := time.Now()
 	if err := r.Worker.Start(ctx); err != nil {
 		logger.Error("failed to start container", slog.Any("error", err), logging.LogDuration(containerStart))
 		taskCtx.State = StateFailed
-		return taskCtx, fmt.Errorf("failed to start container: %w", err)
+		return taskCtx, newRunError(ErrorKindSandboxInfra, fmt.Errorf("failed to start container: %w", err))
 	}
 	logger.Info("worker container started", slog.String("event_type", "container:started"), logging.LogDuration(containerStart))
+	r.Hooks.containerStarted()
 
 	// Ensure container is stopped at the end
 	defer func() {
@@ -207,12 +259,13 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 		nextActionReqYAML := string(summaryBytes)
 
 		logger.Info("calling Meta.NextAction", slog.String("event_type", "meta:thinking"), slog.String("detail", "Analyzing..."), slog.Int("worker_runs_count", len(taskCtx.WorkerRuns)))
+		r.Hooks.metaThinking("Analyzing...")
 		actionStart := time.Now()
 		action, err := r.Meta.NextAction(ctx, summary)
 		if err != nil {
 			logger.Error("NextAction failed", slog.Any("error", err), logging.LogDuration(actionStart))
 			taskCtx.State = StateFailed
-			return taskCtx, fmt.Errorf("next_action failed: %w", err)
+			return taskCtx, newRunError(ErrorKindOf(err), fmt.Errorf("next_action failed: %w", err))
 		}
 		logger.Info("NextAction completed",
 			slog.String("action", action.Decision.Action),
@@ -265,7 +318,7 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 			assessment, err := r.Meta.CompletionAssessment(ctx, validationSummary)
 			if err != nil {
 				taskCtx.State = StateFailed
-				return taskCtx, fmt.Errorf("completion assessment failed: %w", err)
+				return taskCtx, newRunError(ErrorKindOf(err), fmt.Errorf("completion assessment failed: %w", err))
 			}
 
 			// Record CompletionAssessment response
@@ -297,6 +350,7 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 		} else if action.Decision.Action == "run_worker" {
 			// Execute Worker
 			logger.Info("executing worker", slog.String("event_type", "worker:running"), slog.String("command", action.WorkerCall.Prompt), slog.Int("prompt_length", len(action.WorkerCall.Prompt)))
+			r.Hooks.workerRunning(action.WorkerCall.Prompt)
 			logger.Debug("worker prompt", slog.String("prompt", action.WorkerCall.Prompt))
 			baseCall := action.WorkerCall
 			attempts := 0
@@ -308,9 +362,11 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 					forceMode = true
 					baseCall = applyWorkerCandidate(baseCall, forced)
 					logger.Info("worker tooling forced",
+						slog.String("event_type", "worker:tooling_selected"),
 						slog.String("tool", forced.Tool),
 						slog.String("model", forced.Model),
 					)
+					r.Hooks.toolingSelected(forced)
 				} else if cfg, ok := toolSelector.Category(tooling.CategoryWorker); ok && len(cfg.Candidates) > 0 {
 					maxAttempts = len(cfg.Candidates)
 				}
@@ -327,9 +383,11 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 						workerCall = applyWorkerCandidate(baseCall, candidate)
 						usedTooling = true
 						logger.Info("worker tooling selected",
+							slog.String("event_type", "worker:tooling_selected"),
 							slog.String("tool", candidate.Tool),
 							slog.String("model", candidate.Model),
 						)
+						r.Hooks.toolingSelected(candidate)
 					}
 				}
 
@@ -351,9 +409,13 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 						slog.Int("exit_code", res.ExitCode),
 						slog.Int("output_length", len(res.RawOutput)),
 						slog.Any("artifacts", res.Artifacts),
+						slog.Int("input_tokens", res.InputTokens),
+						slog.Int("output_tokens", res.OutputTokens),
+						slog.Float64("cost_usd", res.CostUSD),
 						logging.LogDuration(workerStart),
 					)
 					logger.Debug("worker output", slog.String("output", res.RawOutput))
+					r.Hooks.workerCompleted(*res)
 				}
 				taskCtx.WorkerRuns = append(taskCtx.WorkerRuns, *res)
 
@@ -377,7 +439,7 @@ func (r *Runner) Run(ctx context.Context) (*TaskContext, error) {
 		} else {
 			// Unknown action or abort
 			taskCtx.State = StateFailed
-			return taskCtx, fmt.Errorf("unknown or abort action: %s", action.Decision.Action)
+			return taskCtx, newRunError(ErrorKindAgentGaveUp, fmt.Errorf("unknown or abort action: %s", action.Decision.Action))
 		}
 	}
 
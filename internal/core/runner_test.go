@@ -808,3 +808,105 @@ func containsAt(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRunner_Hooks(t *testing.T) {
+	cfg := &config.TaskConfig{
+		Task: config.TaskDetails{ID: "hooks-task", Title: "Hooks", Repo: ".", PRD: config.PRDDetails{Text: "prd"}},
+	}
+	mockMeta := &mock.MetaClient{
+		PlanTaskFunc: func(ctx context.Context, prd string) (*meta.PlanTaskResponse, error) {
+			return &meta.PlanTaskResponse{TaskID: "hooks-task"}, nil
+		},
+		NextActionFunc: func(ctx context.Context, summary *meta.TaskSummary) (*meta.NextActionResponse, error) {
+			if summary.WorkerRunsCount == 0 {
+				return &meta.NextActionResponse{
+					Decision:   meta.Decision{Action: "run_worker"},
+					WorkerCall: meta.WorkerCall{WorkerType: "codex-cli", Prompt: "Do work"},
+				}, nil
+			}
+			return &meta.NextActionResponse{Decision: meta.Decision{Action: "mark_complete"}}, nil
+		},
+		CompletionAssessmentFunc: func(ctx context.Context, summary *meta.TaskSummary) (*meta.CompletionAssessmentResponse, error) {
+			return &meta.CompletionAssessmentResponse{AllCriteriaSatisfied: true}, nil
+		},
+	}
+	mockWorker := &mock.WorkerExecutor{
+		StartFunc: func(ctx context.Context) error { return nil },
+		StopFunc:  func(ctx context.Context) error { return nil },
+		RunWorkerFunc: func(ctx context.Context, call meta.WorkerCall, env map[string]string) (*core.WorkerRunResult, error) {
+			return &core.WorkerRunResult{Artifacts: []string{"main.go"}, InputTokens: 10}, nil
+		},
+	}
+	mockNote := &mock.NoteWriter{WriteFunc: func(taskCtx *core.TaskContext) error { return nil }}
+
+	var events []string
+	runner := core.NewRunner(cfg, mockMeta, mockWorker, mockNote)
+	runner.Hooks = core.RunnerHooks{
+		OnMetaThinking:      func(detail string) { events = append(events, "thinking:"+detail) },
+		OnContainerStarting: func() { events = append(events, "container:starting") },
+		OnContainerStarted:  func() { events = append(events, "container:started") },
+		OnWorkerRunning:     func(prompt string) { events = append(events, "worker:running:"+prompt) },
+		OnWorkerCompleted: func(res core.WorkerRunResult) {
+			events = append(events, fmt.Sprintf("worker:completed:%v:%d", res.Artifacts, res.InputTokens))
+		},
+	}
+
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if err := core.OutcomeError(result); err != nil {
+		t.Fatalf("expected no outcome error, got %v", err)
+	}
+	want := []string{
+		"thinking:Planning task...",
+		"container:starting",
+		"container:started",
+		"thinking:Analyzing...",
+		"worker:running:Do work",
+		"worker:completed:[main.go]:10",
+		"thinking:Analyzing...",
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Fatalf("unexpected hook events:\n got: %v\nwant: %v", events, want)
+	}
+}
+
+func TestOutcomeError(t *testing.T) {
+	if err := core.OutcomeError(&core.TaskContext{State: core.StateComplete}); err != nil {
+		t.Fatalf("expected nil for COMPLETE, got %v", err)
+	}
+	if kind := core.ErrorKindOf(core.OutcomeError(&core.TaskContext{State: core.StateFailed})); kind != core.ErrorKindValidationFailed {
+		t.Fatalf("expected validation_failed, got %s", kind)
+	}
+	if kind := core.ErrorKindOf(core.OutcomeError(&core.TaskContext{State: core.StateRunning})); kind != core.ErrorKindAgentGaveUp {
+		t.Fatalf("expected agent_gave_up, got %s", kind)
+	}
+}
+
+func TestClassifyErrorMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want core.ErrorKind
+	}{
+		{"401 Unauthorized", core.ErrorKindAuth},
+		{"meta call failed: status 401", core.ErrorKindAuth},
+		{"unexpected status code: 401", core.ErrorKindAuth},
+		{"HTTP/1.1 401", core.ErrorKindAuth},
+		{`{"type":"error","error":{"type":"authentication_error"}}`, core.ErrorKindAuth},
+		{"Codex CLI session not found", core.ErrorKindAuth},
+		{"Error: Codex authentication failed", core.ErrorKindAuth},
+		{"429 Too Many Requests", core.ErrorKindRateLimit},
+		// 401 や authentication を含むだけの通常の失敗は認証の失敗ではない
+		{"main.go:401: undefined: token", core.ErrorKindTransient},
+		{"--- FAIL: TestAuthenticationMiddleware (1.401s)", core.ErrorKindTransient},
+		{"request 401 failed: connection reset by peer", core.ErrorKindTransient},
+		{"authentication handler returned nil", core.ErrorKindTransient},
+		{"--- FAIL: TestUnauthorizedRequest (0.02s)", core.ErrorKindTransient},
+	}
+	for _, tt := range tests {
+		if got := core.ClassifyErrorMessage(tt.msg); got != tt.want {
+			t.Errorf("ClassifyErrorMessage(%q) = %s, want %s", tt.msg, got, tt.want)
+		}
+	}
+}
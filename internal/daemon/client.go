@@ -0,0 +1,326 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/chat"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/eventbus"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// Client はデーモン API のクライアント（CLI / IDE から利用する）
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// APIError は API がエラーステータスを返した場合のエラー
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error (%d): %s", e.StatusCode, e.Message)
+}
+
+// NewClient は endpoint（"unix:///path/to.sock" または "http://127.0.0.1:port"）に接続するクライアントを生成する
+func NewClient(endpoint, token string) (*Client, error) {
+	if strings.HasPrefix(endpoint, unixScheme) {
+		path := strings.TrimPrefix(endpoint, unixScheme)
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+		return &Client{baseURL: "http://unix", token: token, http: &http.Client{Transport: transport}}, nil
+	}
+	if _, err := url.Parse(endpoint); err != nil || !strings.HasPrefix(endpoint, "http") {
+		return nil, fmt.Errorf("invalid endpoint: %s", endpoint)
+	}
+	return &Client{baseURL: strings.TrimSuffix(endpoint, "/"), token: token, http: &http.Client{}}, nil
+}
+
+// DiscoverClient はワークスペースの api.json から接続先とトークンを読み込んでクライアントを生成する
+func DiscoverClient(workspaceDir string) (*Client, error) {
+	info, err := ReadAPIInfo(workspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("orchestrator daemon api not found for workspace: %w", err)
+	}
+	return NewClient(info.Endpoint, info.Token)
+}
+
+// --- Health ---
+
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var out HealthResponse
+	return &out, c.do(ctx, http.MethodGet, "/v1/health", nil, &out)
+}
+
+// --- Tasks ---
+
+// ListTasks はタスク一覧を返す（status が空でなければそのステータスのみ）
+func (c *Client) ListTasks(ctx context.Context, status string) ([]orchestrator.Task, error) {
+	path := "/v1/tasks"
+	if status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+	var out []orchestrator.Task
+	return out, c.do(ctx, http.MethodGet, path, nil, &out)
+}
+
+func (c *Client) GetTask(ctx context.Context, taskID string) (*orchestrator.Task, error) {
+	var out orchestrator.Task
+	return &out, c.do(ctx, http.MethodGet, "/v1/tasks/"+url.PathEscape(taskID), nil, &out)
+}
+
+func (c *Client) CreateTask(ctx context.Context, title, poolID string) (*orchestrator.Task, error) {
+	var out orchestrator.Task
+	return &out, c.do(ctx, http.MethodPost, "/v1/tasks", CreateTaskRequest{Title: title, PoolID: poolID}, &out)
+}
+
+func (c *Client) RunTask(ctx context.Context, taskID string) error {
+	return c.do(ctx, http.MethodPost, "/v1/tasks/"+url.PathEscape(taskID)+"/run", nil, nil)
+}
+
+// CancelTask はタスクを取り消す（実行中であればデーモンが agent-runner を停止する）
+func (c *Client) CancelTask(ctx context.Context, taskID string) error {
+	return c.do(ctx, http.MethodPost, "/v1/tasks/"+url.PathEscape(taskID)+"/cancel", nil, nil)
+}
+
+func (c *Client) ListAttempts(ctx context.Context, taskID string) ([]orchestrator.Attempt, error) {
+	var out []orchestrator.Attempt
+	return out, c.do(ctx, http.MethodGet, "/v1/tasks/"+url.PathEscape(taskID)+"/attempts", nil, &out)
+}
+
+// GetAttempt は実行試行を返す
+func (c *Client) GetAttempt(ctx context.Context, attemptID string) (*orchestrator.Attempt, error) {
+	var out orchestrator.Attempt
+	return &out, c.do(ctx, http.MethodGet, "/v1/attempts/"+url.PathEscape(attemptID), nil, &out)
+}
+
+// AttemptLog は試行のログを from 行目から最大 limit 行返す
+// wait > 0 なら、新しい行が無く試行が実行中の間はサーバー側で最大 wait 待つ。
+func (c *Client) AttemptLog(ctx context.Context, attemptID string, from, limit int, wait time.Duration) (*persistence.AttemptLogPage, error) {
+	q := url.Values{}
+	q.Set("from", strconv.Itoa(from))
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if wait > 0 {
+		q.Set("wait", wait.String())
+	}
+	var out persistence.AttemptLogPage
+	return &out, c.do(ctx, http.MethodGet, "/v1/attempts/"+url.PathEscape(attemptID)+"/log?"+q.Encode(), nil, &out)
+}
+
+// --- Backlog ---
+
+// ListBacklog はバックログ一覧を返す（all=false なら未解決のみ）
+func (c *Client) ListBacklog(ctx context.Context, all bool) ([]orchestrator.BacklogItem, error) {
+	path := "/v1/backlog"
+	if all {
+		path += "?all=true"
+	}
+	var out []orchestrator.BacklogItem
+	return out, c.do(ctx, http.MethodGet, path, nil, &out)
+}
+
+// ResolveBacklog はバックログアイテムを resolution の操作で解決する
+func (c *Client) ResolveBacklog(ctx context.Context, id string, resolution orchestrator.BacklogResolution) (*orchestrator.BacklogResolutionResult, error) {
+	var out orchestrator.BacklogResolutionResult
+	return &out, c.do(ctx, http.MethodPost, "/v1/backlog/"+url.PathEscape(id)+"/resolve", ResolveBacklogRequest{BacklogResolution: resolution}, &out)
+}
+
+func (c *Client) DeleteBacklog(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/backlog/"+url.PathEscape(id), nil, nil)
+}
+
+// --- Chat ---
+
+func (c *Client) ListSessions(ctx context.Context) ([]chat.ChatSession, error) {
+	var out []chat.ChatSession
+	return out, c.do(ctx, http.MethodGet, "/v1/chat/sessions", nil, &out)
+}
+
+func (c *Client) CreateSession(ctx context.Context) (*chat.ChatSession, error) {
+	var out chat.ChatSession
+	return &out, c.do(ctx, http.MethodPost, "/v1/chat/sessions", nil, &out)
+}
+
+func (c *Client) GetHistory(ctx context.Context, sessionID string) ([]chat.ChatMessage, error) {
+	var out []chat.ChatMessage
+	return out, c.do(ctx, http.MethodGet, "/v1/chat/sessions/"+url.PathEscape(sessionID)+"/messages", nil, &out)
+}
+
+func (c *Client) SendMessage(ctx context.Context, sessionID, message string) (*chat.ChatResponse, error) {
+	var out chat.ChatResponse
+	return &out, c.do(ctx, http.MethodPost, "/v1/chat/sessions/"+url.PathEscape(sessionID)+"/messages", SendMessageRequest{Message: message}, &out)
+}
+
+// --- Execution ---
+
+func (c *Client) GetExecution(ctx context.Context) (*ExecutionStatus, error) {
+	var out ExecutionStatus
+	return &out, c.do(ctx, http.MethodGet, "/v1/execution", nil, &out)
+}
+
+// Execution は実行ループを操作する（action: start / pause / resume / stop）
+func (c *Client) Execution(ctx context.Context, action string) (*ExecutionStatus, error) {
+	var out ExecutionStatus
+	return &out, c.do(ctx, http.MethodPost, "/v1/execution/"+url.PathEscape(action), nil, &out)
+}
+
+// --- History ---
+
+// ListActions は history のアクションを返す（ゼロ値の since / until は無指定、kind は前方一致）
+func (c *Client) ListActions(ctx context.Context, since, until time.Time, kind string) ([]persistence.Action, error) {
+	q := url.Values{}
+	if !since.IsZero() {
+		q.Set("since", since.Format(time.RFC3339Nano))
+	}
+	if !until.IsZero() {
+		q.Set("until", until.Format(time.RFC3339Nano))
+	}
+	if kind != "" {
+		q.Set("kind", kind)
+	}
+	path := "/v1/history/actions"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var out []persistence.Action
+	return out, c.do(ctx, http.MethodGet, path, nil, &out)
+}
+
+// StateAt は at 時点の state を history から再構築して返す（ゼロ値なら現在）
+func (c *Client) StateAt(ctx context.Context, at time.Time) (*persistence.ReplayedState, error) {
+	path := "/v1/history/state"
+	if !at.IsZero() {
+		path += "?at=" + url.QueryEscape(at.Format(time.RFC3339Nano))
+	}
+	var out persistence.ReplayedState
+	return &out, c.do(ctx, http.MethodGet, path, nil, &out)
+}
+
+// --- Events ---
+
+// StreamEvents は SSE を購読し、受信したイベントごとに fn を呼ぶ
+// Envelope.Data は JSON のまま（json.RawMessage）渡す。ctx の終了または fn のエラーで戻る。
+func (c *Client) StreamEvents(ctx context.Context, fn func(eventbus.Envelope) error) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect event stream: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return readAPIError(resp)
+	}
+
+	var data bytes.Buffer
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				var raw struct {
+					eventbus.Envelope
+					Data json.RawMessage `json:"data"`
+				}
+				if err := json.Unmarshal(data.Bytes(), &raw); err != nil {
+					return fmt.Errorf("failed to decode event: %w", err)
+				}
+				env := raw.Envelope
+				env.Data = raw.Data
+				if err := fn(env); err != nil {
+					return err
+				}
+			}
+			data.Reset()
+		case strings.HasPrefix(line, "data: "):
+			data.WriteString(strings.TrimPrefix(line, "data: "))
+		default:
+			// コメント（keep-alive）と id / event 行（Envelope に含まれる）は読み飛ばす
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+// --- Helpers ---
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call orchestrator api: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		return readAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusAccepted {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func readAPIError(resp *http.Response) error {
+	var body ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		body.Error = http.StatusText(resp.StatusCode)
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: body.Error}
+}
@@ -0,0 +1,144 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// APIInfoFileName はクライアントが接続先とトークンを発見するためのファイル（ワークスペース直下）
+	APIInfoFileName = "api.json"
+	// SocketFileName はデフォルトの Unix ソケット名（ワークスペース直下）
+	SocketFileName = "orchestrator.sock"
+	// TokenEnv が設定されている場合、そのトークンを使用する（未設定時は起動ごとに生成）
+	TokenEnv = "MULTIVERSE_API_TOKEN"
+
+	unixScheme = "unix://"
+)
+
+// APIInfo はデーモンの接続情報
+type APIInfo struct {
+	Endpoint  string    `json:"endpoint"`
+	Token     string    `json:"token"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// DefaultListenAddress はワークスペースごとのデフォルトの待ち受けアドレスを返す
+// Unix 系ではワークスペース直下のソケット、Windows では localhost の空きポート。
+func DefaultListenAddress(workspaceDir string) string {
+	if runtime.GOOS == "windows" {
+		return "127.0.0.1:0"
+	}
+	return unixScheme + filepath.Join(workspaceDir, SocketFileName)
+}
+
+// Listen は addr（"unix:///path/to.sock" または "127.0.0.1:port"）で待ち受ける
+// TCP はループバックアドレスのみ許可する。戻り値の endpoint はクライアントが接続に使う表記。
+func Listen(addr string) (net.Listener, string, error) {
+	if strings.HasPrefix(addr, unixScheme) {
+		path := strings.TrimPrefix(addr, unixScheme)
+		if err := removeStaleSocket(path); err != nil {
+			return nil, "", err
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to listen on unix socket: %w", err)
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			_ = ln.Close()
+			return nil, "", fmt.Errorf("failed to restrict socket permissions: %w", err)
+		}
+		return ln, unixScheme + path, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if !isLoopbackHost(host) {
+		return nil, "", fmt.Errorf("refusing to listen on non-loopback address: %s", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen: %w", err)
+	}
+	return ln, "http://" + ln.Addr().String(), nil
+}
+
+// removeStaleSocket は接続できない（前回のプロセスが残した）ソケットファイルを削除する
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", path, 500*time.Millisecond)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("socket already in use: %s", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// GenerateToken はランダムな API トークンを生成する
+func GenerateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// WriteAPIInfo は接続情報をワークスペースに書き込む（トークンを含むため 0600）
+func WriteAPIInfo(workspaceDir string, info *APIInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal api info: %w", err)
+	}
+	path := filepath.Join(workspaceDir, APIInfoFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write api info: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ReadAPIInfo はワークスペースの接続情報を読み込む
+func ReadAPIInfo(workspaceDir string) (*APIInfo, error) {
+	data, err := os.ReadFile(filepath.Join(workspaceDir, APIInfoFileName))
+	if err != nil {
+		return nil, err
+	}
+	var info APIInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse api info: %w", err)
+	}
+	return &info, nil
+}
+
+// RemoveAPIInfo は自プロセスが書き込んだ接続情報を削除する
+func RemoveAPIInfo(workspaceDir string) {
+	info, err := ReadAPIInfo(workspaceDir)
+	if err != nil || info.PID != os.Getpid() {
+		return
+	}
+	_ = os.Remove(filepath.Join(workspaceDir, APIInfoFileName))
+}
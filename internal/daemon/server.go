@@ -0,0 +1,731 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/chat"
+	"github.com/biwakonbu/agent-runner/internal/logging"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/eventbus"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// sseKeepAlive は SSE 接続を維持するためのコメント送信間隔
+const sseKeepAlive = 15 * time.Second
+
+// Config はデーモン API サーバーが操作する対象
+// Chat / Sessions が nil の場合、対応するエンドポイントは 503 を返す。
+type Config struct {
+	WorkspaceID  string
+	Repo         persistence.WorkspaceRepository
+	Scheduler    *orchestrator.Scheduler
+	Orchestrator *orchestrator.ExecutionOrchestrator
+	BacklogStore *orchestrator.BacklogStore
+	Chat         *chat.Handler
+	Sessions     *chat.ChatSessionStore
+	Events       *eventbus.Bus
+	// Token は Authorization: Bearer で要求するトークン（空の場合は認証しない）
+	Token string
+}
+
+// Server はオーケストレータデーモンのローカル HTTP/JSON API
+type Server struct {
+	cfg     Config
+	mux     *http.ServeMux
+	baseCtx context.Context
+	logger  *slog.Logger
+}
+
+// NewServer は API サーバーを生成する
+func NewServer(cfg Config) *Server {
+	s := &Server{
+		cfg:     cfg,
+		mux:     http.NewServeMux(),
+		baseCtx: context.Background(),
+		logger:  logging.WithComponent(slog.Default(), "daemon-api"),
+	}
+	s.routes()
+	return s
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /v1/health", s.handleHealth)
+
+	s.mux.HandleFunc("GET /v1/tasks", s.handleListTasks)
+	s.mux.HandleFunc("POST /v1/tasks", s.handleCreateTask)
+	s.mux.HandleFunc("GET /v1/tasks/{id}", s.handleGetTask)
+	s.mux.HandleFunc("POST /v1/tasks/{id}/run", s.handleRunTask)
+	s.mux.HandleFunc("POST /v1/tasks/{id}/cancel", s.handleCancelTask)
+	s.mux.HandleFunc("GET /v1/tasks/{id}/attempts", s.handleListAttempts)
+	s.mux.HandleFunc("GET /v1/attempts/{id}", s.handleGetAttempt)
+	s.mux.HandleFunc("GET /v1/attempts/{id}/log", s.handleAttemptLog)
+
+	s.mux.HandleFunc("GET /v1/backlog", s.handleListBacklog)
+	s.mux.HandleFunc("POST /v1/backlog/{id}/resolve", s.handleResolveBacklog)
+	s.mux.HandleFunc("DELETE /v1/backlog/{id}", s.handleDeleteBacklog)
+
+	s.mux.HandleFunc("GET /v1/chat/sessions", s.handleListSessions)
+	s.mux.HandleFunc("POST /v1/chat/sessions", s.handleCreateSession)
+	s.mux.HandleFunc("GET /v1/chat/sessions/{id}/messages", s.handleGetHistory)
+	s.mux.HandleFunc("POST /v1/chat/sessions/{id}/messages", s.handleSendMessage)
+
+	s.mux.HandleFunc("GET /v1/execution", s.handleGetExecution)
+	s.mux.HandleFunc("POST /v1/execution/{action}", s.handleExecutionAction)
+
+	s.mux.HandleFunc("GET /v1/history/actions", s.handleListActions)
+	s.mux.HandleFunc("GET /v1/history/state", s.handleStateAt)
+
+	s.mux.HandleFunc("GET /v1/events", s.handleEvents)
+}
+
+// Handler は認証付きの http.Handler を返す
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/health" && !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+// authorized は Bearer トークン（SSE 用に ?token= も可）を検証する
+func (s *Server) authorized(r *http.Request) bool {
+	if s.cfg.Token == "" {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" && r.URL.Path == "/v1/events" {
+		token = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) == 1
+}
+
+// Serve は ctx が終了するまで ln で API を提供する
+// 実行開始（/v1/execution/start）は ctx を親コンテキストとして使う。
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	s.baseCtx = ctx
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shutdown api server: %w", err)
+		}
+		return nil
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+// ServeWorkspace は listenAddr（空ならデフォルト）で API を待ち受け、接続情報を workspaceDir/api.json に公開する
+// cfg.Token が空の場合はトークンを生成する。ctx の終了でサーバーを停止し、api.json を削除してから done を閉じる。
+func ServeWorkspace(ctx context.Context, workspaceDir, listenAddr string, cfg Config) (endpoint string, done <-chan struct{}, err error) {
+	if listenAddr == "" {
+		listenAddr = DefaultListenAddress(workspaceDir)
+	}
+	if cfg.Token == "" {
+		if cfg.Token, err = GenerateToken(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	ln, endpoint, err := Listen(listenAddr)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := WriteAPIInfo(workspaceDir, &APIInfo{
+		Endpoint:  endpoint,
+		Token:     cfg.Token,
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+	}); err != nil {
+		_ = ln.Close()
+		return "", nil, err
+	}
+	if cfg.Orchestrator != nil && cfg.Orchestrator.Leader != nil {
+		leader := cfg.Orchestrator.Leader
+		leader.SetEndpoint(endpoint)
+		// 次のハートビートを待たずに leader.json へ反映する
+		if leader.Held() {
+			_ = leader.Heartbeat("")
+		}
+	}
+
+	server := NewServer(cfg)
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		defer RemoveAPIInfo(workspaceDir)
+		if err := server.Serve(ctx, ln); err != nil {
+			server.logger.Error("api server stopped", slog.Any("error", err))
+		}
+	}()
+	server.logger.Info("api listening", slog.String("endpoint", endpoint))
+	return endpoint, doneCh, nil
+}
+
+// --- Health ---
+
+// HealthResponse は /v1/health の応答
+type HealthResponse struct {
+	Status      string `json:"status"`
+	WorkspaceID string `json:"workspaceId"`
+	PID         int    `json:"pid"`
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok", WorkspaceID: s.cfg.WorkspaceID, PID: os.Getpid()})
+}
+
+// --- Tasks ---
+
+// CreateTaskRequest は POST /v1/tasks の本文
+type CreateTaskRequest struct {
+	Title  string `json:"title"`
+	PoolID string `json:"poolId"`
+}
+
+func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := orchestrator.ListTaskViews(s.cfg.Repo)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filtered := make([]orchestrator.Task, 0, len(tasks))
+		for _, t := range tasks {
+			if strings.EqualFold(string(t.Status), status) {
+				filtered = append(filtered, t)
+			}
+		}
+		tasks = filtered
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
+	var req CreateTaskRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("title is required"))
+		return
+	}
+	if req.PoolID == "" {
+		req.PoolID = orchestrator.DefaultPoolID
+	}
+	task, err := orchestrator.CreateManualTask(s.cfg.Repo, req.Title, req.PoolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if s.cfg.Events != nil {
+		s.cfg.Events.Emit(orchestrator.EventTaskCreated, orchestrator.TaskCreatedEvent{Task: *task})
+	}
+	writeJSON(w, http.StatusCreated, task)
+}
+
+func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
+	task, err := orchestrator.FindTaskView(s.cfg.Repo, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+func (s *Server) handleRunTask(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Scheduler == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("scheduler not initialized"))
+		return
+	}
+	if err := s.cfg.Scheduler.ScheduleTask(r.PathValue("id")); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleCancelTask(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	var err error
+	if s.cfg.Orchestrator != nil {
+		err = s.cfg.Orchestrator.CancelTask(taskID)
+	} else {
+		var oldStatus orchestrator.TaskStatus
+		if oldStatus, err = orchestrator.CancelTaskState(s.cfg.Repo, taskID); err == nil && s.cfg.Events != nil {
+			s.cfg.Events.Emit(orchestrator.EventTaskStateChange, orchestrator.TaskStateChangeEvent{
+				TaskID:    taskID,
+				OldStatus: oldStatus,
+				NewStatus: orchestrator.TaskStatusCanceled,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	if err != nil {
+		status := statusFor(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusConflict
+		}
+		writeError(w, status, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleListAttempts(w http.ResponseWriter, r *http.Request) {
+	attempts, err := orchestrator.ListTaskAttempts(s.cfg.Repo, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, attempts)
+}
+
+func (s *Server) handleGetAttempt(w http.ResponseWriter, r *http.Request) {
+	attempt, err := orchestrator.GetAttempt(s.cfg.Repo, r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, attempt)
+}
+
+// maxAttemptLogWait は GET /v1/attempts/{id}/log の ?wait= の上限
+const maxAttemptLogWait = 30 * time.Second
+
+// handleAttemptLog は試行のログを返す（?from= / ?limit= でページング）
+// ?wait=（例: 10s）を指定すると、新しい行が無く試行が実行中の間は最大 wait 待ってから返す（tail -f 用）。
+func (s *Server) handleAttemptLog(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, err := parseIntParam(q.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, err := parseIntParam(q.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var wait time.Duration
+	if raw := q.Get("wait"); raw != "" {
+		wait, err = time.ParseDuration(raw)
+		if err != nil || wait < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid wait %q", raw))
+			return
+		}
+		wait = min(wait, maxAttemptLogWait)
+	}
+	page, err := orchestrator.WaitAttemptLog(r.Context(), s.cfg.Repo, r.PathValue("id"), from, limit, wait)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// --- Backlog ---
+
+// ResolveBacklogRequest は POST /v1/backlog/{id}/resolve の本文
+// action を省略した場合は従来どおり resolution（または note）を記録するだけの解決になる
+// （QUESTION では回答として待っているタスクへ渡す）。
+type ResolveBacklogRequest struct {
+	orchestrator.BacklogResolution
+	// Resolution は note の旧名（note が空の場合に使う）
+	Resolution string `json:"resolution,omitempty"`
+}
+
+func (s *Server) handleListBacklog(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.BacklogStore == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("backlog store not initialized"))
+		return
+	}
+	var (
+		items []orchestrator.BacklogItem
+		err   error
+	)
+	if r.URL.Query().Get("all") == "true" {
+		items, err = s.cfg.BacklogStore.List()
+	} else {
+		items, err = s.cfg.BacklogStore.ListUnresolved()
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if items == nil {
+		items = []orchestrator.BacklogItem{}
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) handleResolveBacklog(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.BacklogStore == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("backlog store not initialized"))
+		return
+	}
+	var req ResolveBacklogRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	resolution := req.BacklogResolution
+	if resolution.Note == "" {
+		resolution.Note = req.Resolution
+	}
+	result, err := s.backlogResolver().Resolve(r.Context(), r.PathValue("id"), resolution)
+	if err != nil {
+		status := statusFor(err)
+		switch {
+		case errors.Is(err, orchestrator.ErrInvalidBacklogResolution):
+			status = http.StatusBadRequest
+		case errors.Is(err, orchestrator.ErrTaskRunning):
+			status = http.StatusConflict
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// backlogResolver はバックログの解決をタスクに適用する BacklogResolver を返す
+// チャットハンドラがあれば split に Meta-agent を使う。
+func (s *Server) backlogResolver() *orchestrator.BacklogResolver {
+	var events orchestrator.EventEmitter
+	if s.cfg.Events != nil {
+		events = s.cfg.Events
+	}
+	resolver := orchestrator.NewBacklogResolver(s.cfg.Repo, s.cfg.BacklogStore, events)
+	if s.cfg.Chat != nil {
+		resolver.SetTaskSplitter(s.cfg.Chat)
+	}
+	return resolver
+}
+
+func (s *Server) handleDeleteBacklog(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.BacklogStore == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("backlog store not initialized"))
+		return
+	}
+	if err := s.cfg.BacklogStore.Delete(r.PathValue("id")); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Chat ---
+
+// SendMessageRequest は POST /v1/chat/sessions/{id}/messages の本文
+type SendMessageRequest struct {
+	Message string `json:"message"`
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, _ *http.Request) {
+	if s.cfg.Sessions == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("chat is not available"))
+		return
+	}
+	sessions, err := s.cfg.Sessions.ListSessions()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []chat.ChatSession{}
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Chat == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("chat is not available"))
+		return
+	}
+	session, err := s.cfg.Chat.CreateSession(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, session)
+}
+
+func (s *Server) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Chat == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("chat is not available"))
+		return
+	}
+	messages, err := s.cfg.Chat.GetHistory(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if messages == nil {
+		messages = []chat.ChatMessage{}
+	}
+	writeJSON(w, http.StatusOK, messages)
+}
+
+func (s *Server) handleSendMessage(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Chat == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("chat is not available"))
+		return
+	}
+	var req SendMessageRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("message is required"))
+		return
+	}
+	resp, err := s.cfg.Chat.HandleMessage(r.Context(), r.PathValue("id"), req.Message)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// IDE の Chat Autopilot と同様、生成されたタスクを即時スケジューリングする
+	if len(resp.GeneratedTasks) > 0 && s.cfg.Scheduler != nil {
+		if _, err := s.cfg.Scheduler.ScheduleReadyTasks(); err != nil {
+			s.logger.Warn("failed to schedule ready tasks after chat", slog.Any("error", err))
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// --- Execution ---
+
+// ExecutionStatus は GET /v1/execution の応答
+type ExecutionStatus struct {
+	State  string                  `json:"state"`
+	Leader *persistence.LeaderInfo `json:"leader,omitempty"`
+}
+
+func (s *Server) executionStatus() ExecutionStatus {
+	status := ExecutionStatus{State: string(orchestrator.ExecutionStateIdle)}
+	if s.cfg.Orchestrator != nil {
+		status.State = string(s.cfg.Orchestrator.State())
+	}
+	if s.cfg.Repo != nil {
+		if info, ok := persistence.ActiveLeader(s.cfg.Repo.BaseDir(), persistence.DefaultLeaderStaleAfter); ok {
+			status.Leader = info
+		}
+	}
+	return status
+}
+
+func (s *Server) handleGetExecution(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.executionStatus())
+}
+
+func (s *Server) handleExecutionAction(w http.ResponseWriter, r *http.Request) {
+	orch := s.cfg.Orchestrator
+	if orch == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("execution orchestrator not initialized"))
+		return
+	}
+
+	var err error
+	switch action := r.PathValue("action"); action {
+	case "start":
+		// リクエストのコンテキストではなくサーバーのコンテキストで実行ループを起動する
+		err = orch.Start(s.baseCtx)
+	case "pause":
+		err = orch.Pause()
+	case "resume":
+		err = orch.Resume()
+	case "stop":
+		err = orch.Stop()
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown execution action: %s", action))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.executionStatus())
+}
+
+// --- History ---
+
+// handleListActions は history のアクションを返す（?since= / ?until= は RFC3339、?kind= は前方一致）
+func (s *Server) handleListActions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, err := parseTimeParam(q.Get("since"), time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	until, err := parseTimeParam(q.Get("until"), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	actions, err := s.cfg.Repo.History().ListActions(since, until)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	kind := q.Get("kind")
+	out := make([]persistence.Action, 0, len(actions))
+	for _, a := range actions {
+		if strings.HasPrefix(a.Kind, kind) {
+			out = append(out, a)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleStateAt は ?at=（RFC3339、省略時は現在）時点の state を history から再構築して返す
+func (s *Server) handleStateAt(w http.ResponseWriter, r *http.Request) {
+	at, err := parseTimeParam(r.URL.Query().Get("at"), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	state, err := persistence.StateAt(s.cfg.Repo.History(), at)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+func parseIntParam(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", raw)
+	}
+	return n, nil
+}
+
+func parseTimeParam(raw string, def time.Time) (time.Time, error) {
+	if raw == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (want RFC3339)", raw)
+	}
+	return t, nil
+}
+
+// --- Events (SSE) ---
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Events == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("event stream is not available"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+
+	events, unsubscribe := s.cfg.Events.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSE は 1 イベントを SSE 形式で書き出す（id は連番、data は Envelope 全体の JSON）
+func writeSSE(w http.ResponseWriter, env eventbus.Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", env.Seq, env.Name, data)
+	return err
+}
+
+// --- Helpers ---
+
+// ErrorResponse はエラー応答の本文
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+// statusFor はストア層のエラーを HTTP ステータスに変換する
+func statusFor(err error) int {
+	if errors.Is(err, os.ErrNotExist) || strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,327 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/eventbus"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+func newTestServer(t *testing.T, token string) (*httptest.Server, Config) {
+	t.Helper()
+	dir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+
+	cfg := Config{
+		WorkspaceID:  "ws-test",
+		Repo:         repo,
+		BacklogStore: orchestrator.NewBacklogStore(dir),
+		Events:       eventbus.New(),
+		Token:        token,
+	}
+	ts := httptest.NewServer(NewServer(cfg).Handler())
+	t.Cleanup(ts.Close)
+	return ts, cfg
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	ts, _ := newTestServer(t, "secret")
+	ctx := context.Background()
+
+	// health は認証不要
+	anon, err := NewClient(ts.URL, "")
+	require.NoError(t, err)
+	health, err := anon.Health(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", health.Status)
+
+	_, err = anon.ListTasks(ctx, "")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+
+	wrong, err := NewClient(ts.URL, "wrong")
+	require.NoError(t, err)
+	_, err = wrong.ListTasks(ctx, "")
+	require.ErrorAs(t, err, &apiErr)
+
+	authed, err := NewClient(ts.URL, "secret")
+	require.NoError(t, err)
+	_, err = authed.ListTasks(ctx, "")
+	assert.NoError(t, err)
+}
+
+func TestServer_Tasks(t *testing.T) {
+	ts, _ := newTestServer(t, "secret")
+	ctx := context.Background()
+	client, err := NewClient(ts.URL, "secret")
+	require.NoError(t, err)
+
+	created, err := client.CreateTask(ctx, "Write docs", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Write docs", created.Title)
+	assert.Equal(t, orchestrator.DefaultPoolID, created.PoolID)
+
+	tasks, err := client.ListTasks(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+
+	got, err := client.GetTask(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+
+	filtered, err := client.ListTasks(ctx, "SUCCEEDED")
+	require.NoError(t, err)
+	assert.Empty(t, filtered)
+
+	_, err = client.GetTask(ctx, "missing")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+
+	attempts, err := client.ListAttempts(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+
+	require.NoError(t, client.CancelTask(ctx, created.ID))
+	got, err = client.GetTask(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.TaskStatusCanceled, got.Status)
+
+	err = client.CancelTask(ctx, created.ID)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+}
+
+func TestServer_Backlog(t *testing.T) {
+	ts, cfg := newTestServer(t, "")
+	ctx := context.Background()
+	client, err := NewClient(ts.URL, "")
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.BacklogStore.Add(&orchestrator.BacklogItem{
+		ID:     "bl-1",
+		TaskID: "task-1",
+		Type:   orchestrator.BacklogTypeFailure,
+		Title:  "failed",
+	}))
+
+	items, err := client.ListBacklog(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	result, err := client.ResolveBacklog(ctx, "bl-1", orchestrator.BacklogResolution{Note: "fixed manually"})
+	require.NoError(t, err)
+	assert.Equal(t, "fixed manually", result.Item.Resolution)
+	items, err = client.ListBacklog(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	items, err = client.ListBacklog(ctx, true)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	// 解決操作はタスクの状態に反映される
+	task, err := client.CreateTask(ctx, "flaky", "")
+	require.NoError(t, err)
+	require.NoError(t, cfg.BacklogStore.Add(&orchestrator.BacklogItem{
+		ID:     "bl-2",
+		TaskID: task.ID,
+		Type:   orchestrator.BacklogTypeFailure,
+		Title:  "failed",
+	}))
+	var apiErr *APIError
+	_, err = client.ResolveBacklog(ctx, "bl-2", orchestrator.BacklogResolution{Action: "reboot"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	result, err = client.ResolveBacklog(ctx, "bl-2", orchestrator.BacklogResolution{Action: orchestrator.BacklogActionAbandon})
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.TaskStatusCanceled, result.TaskStatus)
+	assert.Equal(t, orchestrator.BacklogActionAbandon, result.Item.ResolutionAction)
+	got, err := client.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, orchestrator.TaskStatusCanceled, got.Status)
+}
+
+func TestServer_AttemptLog(t *testing.T) {
+	ts, cfg := newTestServer(t, "")
+	ctx := context.Background()
+	client, err := NewClient(ts.URL, "")
+	require.NoError(t, err)
+
+	attempts := cfg.Repo.Attempts()
+	require.NoError(t, attempts.SaveAttempt(&persistence.AttemptRecord{
+		AttemptID: "att-1", TaskID: "task-1", Status: "RUNNING", StartedAt: time.Now(),
+	}))
+	logw, err := attempts.OpenLog("att-1")
+	require.NoError(t, err)
+	for _, line := range []string{"one", "two", "three"} {
+		require.NoError(t, logw.WriteLine("stdout", line))
+	}
+
+	got, err := client.GetAttempt(ctx, "att-1")
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", got.TaskID)
+
+	page, err := client.AttemptLog(ctx, "att-1", 1, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 1)
+	assert.Equal(t, "two", page.Lines[0].Line)
+	assert.Equal(t, 2, page.Next)
+	assert.False(t, page.Complete)
+
+	// 実行中の試行は新しい行が追記されるまで待つ
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = logw.WriteLine("stderr", "four")
+	}()
+	page, err = client.AttemptLog(ctx, "att-1", 3, 0, 5*time.Second)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 1)
+	assert.Equal(t, "four", page.Lines[0].Line)
+	assert.Equal(t, "stderr", page.Lines[0].Stream)
+
+	require.NoError(t, logw.Close())
+	page, err = client.AttemptLog(ctx, "att-1", 4, 0, 5*time.Second)
+	require.NoError(t, err)
+	assert.Empty(t, page.Lines)
+	assert.True(t, page.Complete)
+
+	_, err = client.AttemptLog(ctx, "missing", 0, 0, 0)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestServer_HistoryTimeTravel(t *testing.T) {
+	ts, cfg := newTestServer(t, "")
+	ctx := context.Background()
+	client, err := NewClient(ts.URL, "")
+	require.NoError(t, err)
+
+	task, err := client.CreateTask(ctx, "Write docs", "")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	checkpoint := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	_, err = orchestrator.CancelTaskState(cfg.Repo, task.ID)
+	require.NoError(t, err)
+
+	actions, err := client.ListActions(ctx, time.Time{}, time.Time{}, persistence.ActionTaskStatusChanged)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "CANCELED", actions[0].Payload["to_status"])
+
+	past, err := client.StateAt(ctx, checkpoint)
+	require.NoError(t, err)
+	require.Len(t, past.Tasks.Tasks, 1)
+	assert.Equal(t, "PENDING", past.Tasks.Tasks[0].Status)
+
+	now, err := client.StateAt(ctx, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "CANCELED", now.Tasks.Tasks[0].Status)
+
+	resp, err := http.Get(ts.URL + "/v1/history/state?at=yesterday")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_ExecutionUnavailableWithoutOrchestrator(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+	client, err := NewClient(ts.URL, "")
+	require.NoError(t, err)
+
+	status, err := client.GetExecution(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, string(orchestrator.ExecutionStateIdle), status.State)
+
+	_, err = client.Execution(context.Background(), "start")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+}
+
+func TestServer_EventStream(t *testing.T) {
+	ts, cfg := newTestServer(t, "secret")
+	client, err := NewClient(ts.URL, "secret")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan eventbus.Envelope, 1)
+	go func() {
+		_ = client.StreamEvents(ctx, func(ev eventbus.Envelope) error {
+			received <- ev
+			cancel()
+			return nil
+		})
+	}()
+
+	// 購読が確立するまで待ってから発行する
+	require.Eventually(t, func() bool { return cfg.Events.SubscriberCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+	cfg.Events.Emit(orchestrator.EventTaskStateChange, orchestrator.TaskStateChangeEvent{TaskID: "t1"})
+
+	select {
+	case ev := <-received:
+		assert.Equal(t, orchestrator.EventTaskStateChange, ev.Name)
+		assert.Equal(t, eventbus.SchemaVersion, ev.SchemaVersion)
+		assert.Equal(t, int64(1), ev.Seq)
+		assert.Contains(t, string(ev.Data.(json.RawMessage)), `"t1"`)
+	case <-time.After(3 * time.Second):
+		t.Fatal("event not received")
+	}
+}
+
+func TestListen_RejectsNonLoopback(t *testing.T) {
+	_, _, err := Listen("0.0.0.0:0")
+	assert.Error(t, err)
+
+	ln, endpoint, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	assert.Contains(t, endpoint, "http://127.0.0.1:")
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not used on windows")
+	}
+	// ソケットパス長の制限を避けるため短いディレクトリを使う
+	dir, err := os.MkdirTemp("", "mv")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	addr := DefaultListenAddress(dir)
+
+	ln, endpoint, err := Listen(addr)
+	require.NoError(t, err)
+	assert.Equal(t, addr, endpoint)
+
+	srv := &http.Server{Handler: NewServer(Config{WorkspaceID: "ws"}).Handler()}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+
+	client, err := NewClient(endpoint, "")
+	require.NoError(t, err)
+	health, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ws", health.WorkspaceID)
+
+	// 稼働中のソケットは奪わない
+	_, _, err = Listen(addr)
+	assert.Error(t, err)
+}
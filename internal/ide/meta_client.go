@@ -0,0 +1,68 @@
+package ide
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+
+	"github.com/biwakonbu/agent-runner/internal/agenttools"
+	"github.com/biwakonbu/agent-runner/internal/chat"
+	"github.com/biwakonbu/agent-runner/internal/meta"
+)
+
+// NewMetaClient は LLMConfigStore / ToolingConfigStore の設定に基づいて Meta クライアントを生成する
+// IDE とオーケストレータデーモンで共有する。
+// 優先度:
+// 1. LLMConfigStore の設定（codex-cli, mock 等）
+// 2. 環境変数でのオーバーライド（後方互換性のため）
+func NewMetaClient(llmStore *LLMConfigStore, toolingStore *ToolingConfigStore, logger *slog.Logger) chat.MetaClient {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	config, err := llmStore.GetEffectiveConfig()
+	if err != nil {
+		logger.Error("failed to load LLM config, falling back to default", slog.Any("error", err))
+		config = DefaultLLMConfig()
+	}
+
+	kind := config.Kind
+	if kind == "" {
+		kind = "openai-chat"
+	}
+
+	// agenttools.IsValidToolKind で有効性を検証
+	if !agenttools.IsValidToolKind(kind) {
+		logger.Error("unknown LLM kind, falling back to openai-chat", slog.String("kind", kind))
+		kind = "openai-chat"
+	}
+
+	apiKey, apiKeyErr := llmStore.GetAPIKey()
+	if apiKeyErr != nil {
+		logger.Warn("failed to read API key", slog.Any("error", apiKeyErr))
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	// openai-chat は API キー必須。未設定時は codex-cli に自動フォールバックする。
+	if kind == "openai-chat" && apiKey == "" {
+		if _, err := exec.LookPath("codex"); err == nil {
+			logger.Info("OPENAI_API_KEY is empty; switching Meta provider from openai-chat to codex-cli")
+			kind = "codex-cli"
+		} else {
+			logger.Warn("OPENAI_API_KEY is empty and codex CLI not found; Meta requests will fail")
+		}
+	}
+
+	baseClient := meta.NewClient(kind, apiKey, config.Model, config.SystemPrompt)
+
+	toolingCfg, err := toolingStore.Load()
+	if err != nil {
+		logger.Error("failed to load tooling config", slog.Any("error", err))
+		toolingCfg = DefaultToolingConfig()
+	}
+	if toolingCfg != nil && len(toolingCfg.Profiles) > 0 {
+		return meta.NewToolingClient(toolingCfg, apiKey, baseClient, config.SystemPrompt)
+	}
+
+	return baseClient
+}
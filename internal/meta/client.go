@@ -130,7 +130,8 @@ Output MUST be a JSON block with the following structure:
             ],
             "dependencies": [],
             "wbs_level": 1,
-            "estimated_effort": "small"
+            "estimated_effort": "small",
+            "story_points": 1
           }
         ]
       },
@@ -148,7 +149,8 @@ Output MUST be a JSON block with the following structure:
             ],
             "dependencies": ["temp-001"],
             "wbs_level": 2,
-            "estimated_effort": "medium"
+            "estimated_effort": "medium",
+            "story_points": 3
           }
         ]
       },
@@ -167,6 +169,7 @@ Output MUST be a JSON block with the following structure:
             "dependencies": ["temp-002"],
             "wbs_level": 3,
             "estimated_effort": "large",
+            "story_points": 5,
             "suggested_impl": {
               "language": "go",
               "file_paths": ["internal/feature/new.go"],
@@ -189,6 +192,8 @@ Output MUST be a JSON block with the following structure:
 Guidelines:
 - WBS levels: 1=概念設計, 2=実装設計, 3=実装
 - Estimated effort: small (< 1 hour), medium (1-4 hours), large (> 4 hours)
+- Story points: relative size of the task (1, 2, 3, 5, 8)
+- If "Estimate Calibration" is given, adjust estimated_effort / story_points to match how long similar past tasks actually took
 - Task IDs must start with "temp-" (they will be replaced with permanent IDs)
 - Dependencies can reference other temp IDs from the same batch
 - Be specific about acceptance criteria - they should be verifiable
@@ -224,6 +229,8 @@ Output MUST be a JSON block with the following structure:
         "wbs_level": 1,
         "phase_name": "...",
         "parent_id": "...",
+        "estimated_effort": "medium",
+        "story_points": 3,
         "suggested_impl": { ... }
       },
       {
@@ -252,4 +259,8 @@ Output MUST be a JSON block with the following structure:
     ]
   }
 }
+
+Guidelines:
+- estimated_effort: small (< 1 hour), medium (1-4 hours), large (> 4 hours); story_points: relative size (1, 2, 3, 5, 8)
+- If "Estimate Calibration" is given, adjust estimated_effort / story_points of created or updated tasks to match how long similar past tasks actually took
 `
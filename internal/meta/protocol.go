@@ -91,9 +91,10 @@ type DecomposeRequest struct {
 
 // DecomposeContext はタスク分解時のコンテキスト情報
 type DecomposeContext struct {
-	WorkspacePath       string                `yaml:"workspace_path" json:"workspace_path"`             // プロジェクトパス
-	ExistingTasks       []ExistingTaskSummary `yaml:"existing_tasks" json:"existing_tasks"`             // 既存タスク一覧
-	ConversationHistory []ConversationMessage `yaml:"conversation_history" json:"conversation_history"` // 会話履歴
+	WorkspacePath       string                `yaml:"workspace_path" json:"workspace_path"`                                 // プロジェクトパス
+	ExistingTasks       []ExistingTaskSummary `yaml:"existing_tasks" json:"existing_tasks"`                                 // 既存タスク一覧
+	ConversationHistory []ConversationMessage `yaml:"conversation_history" json:"conversation_history"`                     // 会話履歴
+	Calibration         []EstimateCalibration `yaml:"estimate_calibration,omitempty" json:"estimate_calibration,omitempty"` // 過去の見積もりと実績
 }
 
 // ExistingTaskSummary は既存タスクの要約（分解時の参照用）
@@ -137,6 +138,7 @@ type DecomposedTask struct {
 	Dependencies       []string       `yaml:"dependencies" json:"dependencies"`                         // 依存タスクID（同バッチ内の一時ID参照可）
 	WBSLevel           int            `yaml:"wbs_level" json:"wbs_level"`                               // WBS階層レベル
 	EstimatedEffort    string         `yaml:"estimated_effort" json:"estimated_effort"`                 // 推定工数（small/medium/large）
+	StoryPoints        int            `yaml:"story_points,omitempty" json:"story_points,omitempty"`     // ストーリーポイント
 	SuggestedImpl      *SuggestedImpl `yaml:"suggested_impl,omitempty" json:"suggested_impl,omitempty"` // 実装のヒント
 }
 
@@ -170,6 +172,21 @@ type PlanPatchContext struct {
 	ExistingTasks       []ExistingTaskSummary `yaml:"existing_tasks" json:"existing_tasks"`
 	ExistingWBS         *WBSOverview          `yaml:"existing_wbs,omitempty" json:"existing_wbs,omitempty"`
 	ConversationHistory []ConversationMessage `yaml:"conversation_history" json:"conversation_history"`
+	Calibration         []EstimateCalibration `yaml:"estimate_calibration,omitempty" json:"estimate_calibration,omitempty"`
+}
+
+// EstimateCalibration は完了したノードの見積もりと実績の比較（種別・難易度ごと）
+// planner が過去の実績に合わせて見積もりを補正するために渡す。
+type EstimateCalibration struct {
+	Dimension       string  `yaml:"dimension" json:"dimension"` // kind | difficulty
+	Key             string  `yaml:"key" json:"key"`
+	Nodes           int     `yaml:"nodes" json:"nodes"`
+	AvgStoryPoints  float64 `yaml:"avg_story_points" json:"avg_story_points"`
+	AvgWallClockMin float64 `yaml:"avg_wall_clock_min" json:"avg_wall_clock_min"`
+	AvgAttempts     float64 `yaml:"avg_attempts" json:"avg_attempts"`
+	AvgWorkerRuns   float64 `yaml:"avg_worker_runs" json:"avg_worker_runs"`
+	AvgTokens       float64 `yaml:"avg_tokens" json:"avg_tokens"`
+	AvgCostUSD      float64 `yaml:"avg_cost_usd" json:"avg_cost_usd"`
 }
 
 // WBSOverview は Meta に渡すWBSの最小表現
@@ -216,6 +233,8 @@ type PlanOperation struct {
 	WBSLevel           *int           `yaml:"wbs_level,omitempty" json:"wbs_level,omitempty"`
 	PhaseName          *string        `yaml:"phase_name,omitempty" json:"phase_name,omitempty"`
 	Milestone          *string        `yaml:"milestone,omitempty" json:"milestone,omitempty"`
+	EstimatedEffort    *string        `yaml:"estimated_effort,omitempty" json:"estimated_effort,omitempty"`
+	StoryPoints        *int           `yaml:"story_points,omitempty" json:"story_points,omitempty"`
 	SuggestedImpl      *SuggestedImpl `yaml:"suggested_impl,omitempty" json:"suggested_impl,omitempty"`
 
 	ParentID *string      `yaml:"parent_id,omitempty" json:"parent_id,omitempty"`
@@ -136,6 +136,8 @@ func buildDecomposeUserPrompt(req *DecomposeRequest) string {
 		}
 	}
 
+	writeEstimateCalibration(b, req.Context.Calibration)
+
 	if len(req.Context.ConversationHistory) > 0 {
 		fmt.Fprintf(b, "\nConversation History:\n")
 		for _, msg := range req.Context.ConversationHistory {
@@ -151,6 +153,23 @@ func buildDecomposeUserPrompt(req *DecomposeRequest) string {
 	return b.String()
 }
 
+// writeEstimateCalibration writes historical estimate-versus-actual rows so the planner can calibrate
+// estimated_effort / story_points against what similar nodes actually took.
+func writeEstimateCalibration(b *strings.Builder, rows []EstimateCalibration) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\nEstimate Calibration (actuals of completed nodes, averages per node):\n")
+	for _, r := range rows {
+		key := r.Key
+		if key == "" {
+			key = "unset"
+		}
+		fmt.Fprintf(b, "- %s=%s: nodes=%d, story_points=%.1f, wall_clock=%.1fmin, attempts=%.1f, worker_runs=%.1f, tokens=%.0f, cost=$%.2f\n",
+			r.Dimension, key, r.Nodes, r.AvgStoryPoints, r.AvgWallClockMin, r.AvgAttempts, r.AvgWorkerRuns, r.AvgTokens, r.AvgCostUSD)
+	}
+}
+
 // statusPriority returns priority for deterministic sorting (lower = higher priority)
 // PRD 13.3 #2: RUNNING > BLOCKED > PENDING/READY > others
 func statusPriority(status string) int {
@@ -232,6 +251,8 @@ func buildPlanPatchUserPrompt(req *PlanPatchRequest) string {
 		}
 	}
 
+	writeEstimateCalibration(b, req.Context.Calibration)
+
 	// Conversation history (max 10 messages, each truncated to 300 chars)
 	if len(req.Context.ConversationHistory) > 0 {
 		fmt.Fprintf(b, "\nConversation History:\n")
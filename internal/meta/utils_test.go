@@ -136,3 +136,27 @@ func TestBuildPlanPatchUserPrompt_FullContent(t *testing.T) {
 	assert.True(t, strings.Contains(prompt, "WBS Structure"), "should contain WBS section")
 	assert.True(t, strings.Contains(prompt, "Conversation History"), "should contain history section")
 }
+
+func TestBuildUserPrompts_EstimateCalibration(t *testing.T) {
+	calibration := []EstimateCalibration{
+		{Dimension: "kind", Key: "feature", Nodes: 4, AvgStoryPoints: 3, AvgWallClockMin: 12.5, AvgAttempts: 1.5, AvgWorkerRuns: 2, AvgTokens: 12000, AvgCostUSD: 0.42},
+		{Dimension: "difficulty", Key: "", Nodes: 1, AvgStoryPoints: 1},
+	}
+
+	patchPrompt := buildPlanPatchUserPrompt(&PlanPatchRequest{
+		UserInput: "add a feature",
+		Context:   PlanPatchContext{Calibration: calibration},
+	})
+	assert.Contains(t, patchPrompt, "Estimate Calibration")
+	assert.Contains(t, patchPrompt, "- kind=feature: nodes=4, story_points=3.0, wall_clock=12.5min, attempts=1.5, worker_runs=2.0, tokens=12000, cost=$0.42")
+	assert.Contains(t, patchPrompt, "- difficulty=unset: nodes=1")
+
+	decomposePrompt := buildDecomposeUserPrompt(&DecomposeRequest{
+		UserInput: "add a feature",
+		Context:   DecomposeContext{Calibration: calibration},
+	})
+	assert.Contains(t, decomposePrompt, "Estimate Calibration")
+
+	// 実績が無ければセクションを出さない
+	assert.NotContains(t, buildPlanPatchUserPrompt(&PlanPatchRequest{UserInput: "x"}), "Estimate Calibration")
+}
@@ -0,0 +1,263 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// エージェントの割り当て: タスクは kind・言語（SuggestedImpl.Language）・明示的なタグ（Inputs["capabilities"]）から
+// 必要な能力を導き、SchedulerV2 はそれを全て持つ正常なエージェントのうち最も負荷の低いものに割り当てる。
+// 能力は "kind:<kind>" / "lang:<language>" / タグそのもので表し、エージェントの Kind は "kind:<Kind>" として扱う。
+// Kind も Capabilities も宣言していないエージェントは汎用とみなし、どのタスクも実行できる。
+
+const (
+	capabilityKindPrefix = "kind:"
+	capabilityLangPrefix = "lang:"
+)
+
+// ErrAgentBusy は実行中のタスクがあるエージェントを登録解除しようとしたことを表す
+var ErrAgentBusy = errors.New("agent has running tasks")
+
+// AgentHealthCheck はエージェントが利用可能かを確認する（nil なら正常）
+type AgentHealthCheck func(ctx context.Context, agent persistence.AgentState) error
+
+// agentHealthChecker はエージェントのヘルスチェックを提供する ExecutorV2
+// NewSchedulerV2 は executor がこれを実装していれば、スケジュールの前のヘルスチェックに使う。
+type agentHealthChecker interface {
+	CheckAgent(ctx context.Context, agent persistence.AgentState) error
+}
+
+// AgentRunnerHealthCheck は agent-runner を起動できるかを確認するヘルスチェックを返す
+// エージェントのタスクは agent-runner で実行するため、見つからなければどのエージェントも unhealthy になる。
+func AgentRunnerHealthCheck(agentRunnerPath string) AgentHealthCheck {
+	return func(ctx context.Context, agent persistence.AgentState) error {
+		if _, err := exec.LookPath(agentRunnerPath); err != nil {
+			return fmt.Errorf("agent-runner not available: %w", err)
+		}
+		return nil
+	}
+}
+
+// ListAgents は agents.json のエージェントを ID 順に返す
+func ListAgents(repo persistence.WorkspaceRepository) ([]persistence.AgentState, error) {
+	state, err := repo.State().LoadAgents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load agents: %w", err)
+	}
+	agents := append([]persistence.AgentState{}, state.Agents...)
+	sort.Slice(agents, func(i, j int) bool { return agents[i].AgentID < agents[j].AgentID })
+	return agents, nil
+}
+
+// normalizeCapability は能力の表記を揃える（小文字・前後の空白を除去）
+func normalizeCapability(c string) string {
+	return strings.ToLower(strings.TrimSpace(c))
+}
+
+// TaskCapabilities はタスクの実行に必要な能力を返す（重複なし・昇順）
+// node はタスクのノード設計で、nil なら言語を要求しない。
+func TaskCapabilities(task *persistence.TaskState, node *persistence.NodeDesign) []string {
+	set := make(map[string]struct{})
+	add := func(c string) {
+		if c = normalizeCapability(c); c != "" {
+			set[c] = struct{}{}
+		}
+	}
+	if task.Kind != "" {
+		add(capabilityKindPrefix + task.Kind)
+	}
+	if node != nil && strings.TrimSpace(node.SuggestedImpl.Language) != "" {
+		add(capabilityLangPrefix + node.SuggestedImpl.Language)
+	}
+	for _, tag := range inputStrings(task.Inputs, InputKeyCapabilities) {
+		add(tag)
+	}
+	return sortedKeys(set)
+}
+
+// agentCapabilities はエージェントが提供する能力を返す（汎用のエージェントは nil）
+func agentCapabilities(agent *persistence.AgentState) map[string]struct{} {
+	if agent.Kind == "" && len(agent.Capabilities) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(agent.Capabilities)+1)
+	if agent.Kind != "" {
+		set[normalizeCapability(capabilityKindPrefix+agent.Kind)] = struct{}{}
+	}
+	for _, c := range agent.Capabilities {
+		set[normalizeCapability(c)] = struct{}{}
+	}
+	return set
+}
+
+// missingCapabilities はエージェントに足りない能力を返す
+func missingCapabilities(agent *persistence.AgentState, required []string) []string {
+	provided := agentCapabilities(agent)
+	if provided == nil {
+		return nil
+	}
+	var missing []string
+	for _, c := range required {
+		if _, ok := provided[c]; !ok {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// agentAvailable はエージェントが新しいタスクを受け付けられるかを返す
+func agentAvailable(agent *persistence.AgentState) bool {
+	return persistence.AgentHealth(agent.Health) != persistence.AgentHealthUnhealthy &&
+		len(agent.RunningTasks) < agent.MaxParallel
+}
+
+// agentLoad はエージェントの負荷（実行中のタスク数 / 並列数）を返す
+func agentLoad(agent *persistence.AgentState) float64 {
+	if agent.MaxParallel <= 0 {
+		return 1
+	}
+	return float64(len(agent.RunningTasks)) / float64(agent.MaxParallel)
+}
+
+// AgentAssignment はタスクの割り当て先と理由
+type AgentAssignment struct {
+	AgentID              string
+	RequiredCapabilities []string
+	Reason               string
+	Candidates           int
+}
+
+// SelectAgent は必要な能力を全て持つ利用可能なエージェントのうち、最も負荷の低いものを選ぶ
+// 負荷が同じなら実行中のタスクが少ない方、さらに同じならエージェント ID の昇順で選ぶ。
+// 割り当てられない場合は理由を error で返す。
+func SelectAgent(agents []persistence.AgentState, required []string) (*AgentAssignment, error) {
+	var candidates []*persistence.AgentState
+	capable := 0
+	for i := range agents {
+		a := &agents[i]
+		if len(missingCapabilities(a, required)) > 0 {
+			continue
+		}
+		capable++
+		if agentAvailable(a) {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		if capable == 0 {
+			return nil, fmt.Errorf("no agent has capabilities [%s]", strings.Join(required, " "))
+		}
+		return nil, fmt.Errorf("all %d agents with capabilities [%s] are busy or unhealthy", capable, strings.Join(required, " "))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		li, lj := agentLoad(candidates[i]), agentLoad(candidates[j])
+		if li != lj {
+			return li < lj
+		}
+		if len(candidates[i].RunningTasks) != len(candidates[j].RunningTasks) {
+			return len(candidates[i].RunningTasks) < len(candidates[j].RunningTasks)
+		}
+		return candidates[i].AgentID < candidates[j].AgentID
+	})
+
+	chosen := candidates[0]
+	matched := "any capabilities"
+	if len(required) > 0 {
+		matched = fmt.Sprintf("capabilities [%s]", strings.Join(required, " "))
+	}
+	return &AgentAssignment{
+		AgentID:              chosen.AgentID,
+		RequiredCapabilities: required,
+		Reason: fmt.Sprintf("matched %s; load %d/%d, least loaded of %d candidates",
+			matched, len(chosen.RunningTasks), chosen.MaxParallel, len(candidates)),
+		Candidates: len(candidates),
+	}, nil
+}
+
+// RegisterAgent はエージェントを agents.json に登録する（同じ ID があれば設定を更新する）
+// 実行中のタスクとヘルスチェックの結果は引き継ぐ。MaxParallel が 0 以下なら 1 にする。
+func RegisterAgent(repo persistence.WorkspaceRepository, agent persistence.AgentState) error {
+	if strings.TrimSpace(agent.AgentID) == "" {
+		return fmt.Errorf("agent id is required")
+	}
+	if agent.MaxParallel <= 0 {
+		agent.MaxParallel = 1
+	}
+	return repo.State().UpdateAgents(func(state *persistence.AgentsState) error {
+		for i := range state.Agents {
+			a := &state.Agents[i]
+			if a.AgentID != agent.AgentID {
+				continue
+			}
+			a.Kind = agent.Kind
+			a.MaxParallel = agent.MaxParallel
+			a.Capabilities = agent.Capabilities
+			return nil
+		}
+		agent.RunningTasks = []string{}
+		agent.Health, agent.HealthError, agent.HealthCheckedAt = "", "", nil
+		state.Agents = append(state.Agents, agent)
+		return nil
+	})
+}
+
+// UnregisterAgent はエージェントを agents.json から削除する
+// 実行中のタスクがあれば ErrAgentBusy を返す。
+func UnregisterAgent(repo persistence.WorkspaceRepository, agentID string) error {
+	return repo.State().UpdateAgents(func(state *persistence.AgentsState) error {
+		for i := range state.Agents {
+			if state.Agents[i].AgentID != agentID {
+				continue
+			}
+			if len(state.Agents[i].RunningTasks) > 0 {
+				return fmt.Errorf("%w: %s", ErrAgentBusy, agentID)
+			}
+			state.Agents = append(state.Agents[:i], state.Agents[i+1:]...)
+			return nil
+		}
+		return fmt.Errorf("agent not found: %s", agentID)
+	})
+}
+
+// CheckAgentsHealth は全エージェントのヘルスチェックを実行し、結果を agents.json に保存する
+// unhealthy のエージェントには新しいタスクを割り当てない（実行中のタスクはそのまま）。
+func CheckAgentsHealth(ctx context.Context, repo persistence.WorkspaceRepository, check AgentHealthCheck) error {
+	agentsState, err := repo.State().LoadAgents()
+	if err != nil {
+		return fmt.Errorf("failed to load agents: %w", err)
+	}
+	// ヘルスチェックは時間がかかり得るため、ロックの外で実行してから結果だけを反映する
+	type result struct {
+		health persistence.AgentHealth
+		err    string
+		at     time.Time
+	}
+	results := make(map[string]result, len(agentsState.Agents))
+	for _, a := range agentsState.Agents {
+		r := result{health: persistence.AgentHealthHealthy}
+		if err := check(ctx, a); err != nil {
+			r.health, r.err = persistence.AgentHealthUnhealthy, err.Error()
+		}
+		r.at = time.Now()
+		results[a.AgentID] = r
+	}
+	return repo.State().UpdateAgents(func(state *persistence.AgentsState) error {
+		for i := range state.Agents {
+			a := &state.Agents[i]
+			r, ok := results[a.AgentID]
+			if !ok {
+				continue
+			}
+			at := r.at
+			a.Health, a.HealthError, a.HealthCheckedAt = string(r.health), r.err, &at
+		}
+		return nil
+	})
+}
@@ -0,0 +1,239 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskCapabilities(t *testing.T) {
+	task := &persistence.TaskState{Kind: "implementation", Inputs: map[string]interface{}{
+		InputKeyCapabilities: []interface{}{"GPU", " refactor ", "gpu"},
+	}}
+	node := &persistence.NodeDesign{SuggestedImpl: persistence.SuggestedImpl{Language: "Go"}}
+	assert.Equal(t, []string{"gpu", "kind:implementation", "lang:go", "refactor"}, TaskCapabilities(task, node))
+	assert.Empty(t, TaskCapabilities(&persistence.TaskState{}, nil))
+}
+
+func TestSelectAgent(t *testing.T) {
+	agents := []persistence.AgentState{
+		{AgentID: "codex", Kind: "implementation", MaxParallel: 2, RunningTasks: []string{"t1"}, Capabilities: []string{"lang:go"}},
+		{AgentID: "claude", Kind: "implementation", MaxParallel: 4, RunningTasks: []string{"t2"}, Capabilities: []string{"lang:go", "lang:typescript"}},
+		{AgentID: "tester", Kind: "test", MaxParallel: 1, Capabilities: []string{"lang:go"}},
+		{AgentID: "down", Kind: "implementation", MaxParallel: 4, Capabilities: []string{"lang:go"}, Health: string(persistence.AgentHealthUnhealthy)},
+	}
+
+	got, err := SelectAgent(agents, []string{"kind:implementation", "lang:go"})
+	require.NoError(t, err)
+	assert.Equal(t, "claude", got.AgentID, "the least loaded agent is chosen")
+	assert.Equal(t, 2, got.Candidates)
+	assert.Equal(t, "matched capabilities [kind:implementation lang:go]; load 1/4, least loaded of 2 candidates", got.Reason)
+
+	got, err = SelectAgent(agents, []string{"kind:test", "lang:go"})
+	require.NoError(t, err)
+	assert.Equal(t, "tester", got.AgentID)
+
+	_, err = SelectAgent(agents, []string{"kind:implementation", "lang:rust"})
+	assert.EqualError(t, err, "no agent has capabilities [kind:implementation lang:rust]")
+
+	agents[2].RunningTasks = []string{"t3"}
+	_, err = SelectAgent(agents, []string{"kind:test"})
+	assert.EqualError(t, err, "all 1 agents with capabilities [kind:test] are busy or unhealthy")
+
+	// 能力を宣言していないエージェントは汎用
+	got, err = SelectAgent([]persistence.AgentState{{AgentID: "any", MaxParallel: 1}}, []string{"kind:test", "lang:rust"})
+	require.NoError(t, err)
+	assert.Equal(t, "any", got.AgentID)
+}
+
+func TestRegisterAgent(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	require.NoError(t, RegisterAgent(repo, persistence.AgentState{AgentID: "codex", Kind: "implementation"}))
+	require.NoError(t, repo.State().UpdateAgents(func(s *persistence.AgentsState) error {
+		s.Agents[0].RunningTasks = []string{"task-1"}
+		return nil
+	}))
+	require.NoError(t, RegisterAgent(repo, persistence.AgentState{AgentID: "codex", Kind: "implementation", MaxParallel: 3, Capabilities: []string{"lang:go"}}))
+
+	agentsState, err := repo.State().LoadAgents()
+	require.NoError(t, err)
+	require.Len(t, agentsState.Agents, 1)
+	assert.Equal(t, 3, agentsState.Agents[0].MaxParallel)
+	assert.Equal(t, []string{"lang:go"}, agentsState.Agents[0].Capabilities)
+	assert.Equal(t, []string{"task-1"}, agentsState.Agents[0].RunningTasks, "running tasks survive re-registration")
+
+	assert.ErrorIs(t, UnregisterAgent(repo, "codex"), ErrAgentBusy)
+	assert.Error(t, RegisterAgent(repo, persistence.AgentState{}))
+}
+
+func TestCheckAgentsHealth(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	require.NoError(t, RegisterAgent(repo, persistence.AgentState{AgentID: "ok"}))
+	require.NoError(t, RegisterAgent(repo, persistence.AgentState{AgentID: "down"}))
+
+	err := CheckAgentsHealth(context.Background(), repo, func(_ context.Context, a persistence.AgentState) error {
+		if a.AgentID == "down" {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	agentsState, err := repo.State().LoadAgents()
+	require.NoError(t, err)
+	require.Len(t, agentsState.Agents, 2)
+	assert.Equal(t, string(persistence.AgentHealthHealthy), agentsState.Agents[0].Health)
+	assert.Equal(t, string(persistence.AgentHealthUnhealthy), agentsState.Agents[1].Health)
+	assert.Equal(t, "connection refused", agentsState.Agents[1].HealthError)
+	assert.NotNil(t, agentsState.Agents[1].HealthCheckedAt)
+}
+
+type recordingExecutorV2 struct {
+	mu    sync.Mutex
+	tasks []string
+	wg    sync.WaitGroup
+}
+
+func (r *recordingExecutorV2) Execute(_ context.Context, task persistence.TaskState) error {
+	r.mu.Lock()
+	r.tasks = append(r.tasks, task.TaskID)
+	r.mu.Unlock()
+	r.wg.Done()
+	return nil
+}
+
+func TestSchedulerV2_MatchesCapabilities(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	require.NoError(t, repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "node-go", SuggestedImpl: persistence.SuggestedImpl{Language: "go"}}))
+	require.NoError(t, repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "node-rust", SuggestedImpl: persistence.SuggestedImpl{Language: "rust"}}))
+	require.NoError(t, repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "task-go", NodeID: "node-go", Kind: "implementation", Status: "pending"},
+		{TaskID: "task-rust", NodeID: "node-rust", Kind: "implementation", Status: "pending"},
+	}}))
+	require.NoError(t, RegisterAgent(repo, persistence.AgentState{AgentID: "gopher", Kind: "implementation", MaxParallel: 1, Capabilities: []string{"lang:go"}}))
+	require.NoError(t, RegisterAgent(repo, persistence.AgentState{AgentID: "offline", Kind: "implementation", MaxParallel: 1, Capabilities: []string{"lang:rust"}}))
+
+	exec := &recordingExecutorV2{}
+	exec.wg.Add(1)
+	scheduler := NewSchedulerV2(repo, exec, slog.Default())
+	scheduler.SetHealthCheck(func(_ context.Context, a persistence.AgentState) error {
+		if a.AgentID == "offline" {
+			return errors.New("not responding")
+		}
+		return nil
+	})
+	require.NoError(t, scheduler.CheckAndSchedule(context.Background()))
+	exec.wg.Wait()
+
+	assert.Equal(t, []string{"task-go"}, exec.tasks)
+	assert.Equal(t, "gopher", loadTaskState(t, repo, "task-go").AssignedAgent)
+	assert.Equal(t, "pending", loadTaskState(t, repo, "task-rust").Status, "unhealthy agents get no tasks")
+
+	actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	var started *persistence.TaskStartedPayload
+	for _, a := range actions {
+		if a.Kind == persistence.ActionTaskStarted {
+			started = &persistence.TaskStartedPayload{}
+			require.NoError(t, a.DecodePayload(started))
+			assert.Equal(t, workspaceIDOf(repo), a.WorkspaceID)
+		}
+	}
+	require.NotNil(t, started)
+	assert.Equal(t, "gopher", started.AgentID)
+	assert.Equal(t, []string{"kind:implementation", "lang:go"}, started.RequiredCapabilities)
+	assert.Contains(t, started.Reason, "least loaded of 1 candidates")
+
+	assert.Eventually(t, func() bool {
+		agentsState, err := repo.State().LoadAgents()
+		return err == nil && len(agentsState.Agents[0].RunningTasks) == 0
+	}, time.Second, 10*time.Millisecond, "the agent is released after execution")
+}
+
+// racingAgentsRepo は最初の UpdateAgents の直前に before を実行する（読み込み後に他のスケジューラが枠を使った状況）
+type racingAgentsRepo struct {
+	persistence.WorkspaceRepository
+	state *racingAgentsState
+}
+
+func (r *racingAgentsRepo) State() persistence.StateRepository { return r.state }
+
+type racingAgentsState struct {
+	persistence.StateRepository
+	once   sync.Once
+	before func()
+}
+
+func (s *racingAgentsState) UpdateAgents(fn func(state *persistence.AgentsState) error) error {
+	s.once.Do(s.before)
+	return s.StateRepository.UpdateAgents(fn)
+}
+
+func TestSchedulerV2_RechecksCapacityBeforeReserving(t *testing.T) {
+	base, _ := setupTestRepo(t)
+	require.NoError(t, base.Design().SaveNode(&persistence.NodeDesign{NodeID: "node-1"}))
+	require.NoError(t, base.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Status: "pending"},
+	}}))
+	require.NoError(t, RegisterAgent(base, persistence.AgentState{AgentID: "agent-1", MaxParallel: 1}))
+	repo := &racingAgentsRepo{WorkspaceRepository: base, state: &racingAgentsState{StateRepository: base.State(), before: func() {
+		require.NoError(t, base.State().UpdateAgents(func(s *persistence.AgentsState) error {
+			s.Agents[0].RunningTasks = append(s.Agents[0].RunningTasks, "other-task")
+			return nil
+		}))
+	}}}
+
+	exec := &recordingExecutorV2{}
+	require.NoError(t, NewSchedulerV2(repo, exec, slog.Default()).CheckAndSchedule(context.Background()))
+
+	assert.Empty(t, exec.tasks)
+	assert.Equal(t, "pending", loadTaskState(t, base, "task-1").Status, "the task waits while the agent is full")
+	agentsState, err := base.State().LoadAgents()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other-task"}, agentsState.Agents[0].RunningTasks, "MaxParallel is not exceeded")
+}
+
+func TestSchedulerV2_ReleasesReservationWhenTaskIsClaimed(t *testing.T) {
+	base, _ := setupTestRepo(t)
+	require.NoError(t, base.Design().SaveNode(&persistence.NodeDesign{NodeID: "node-1"}))
+	require.NoError(t, base.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Status: "pending"},
+	}}))
+	require.NoError(t, RegisterAgent(base, persistence.AgentState{AgentID: "agent-1", MaxParallel: 1}))
+	// 枠を確保する間に他の書き込み手がタスクを始めた
+	repo := &racingAgentsRepo{WorkspaceRepository: base, state: &racingAgentsState{StateRepository: base.State(), before: func() {
+		require.NoError(t, base.State().UpdateTasks(func(s *persistence.TasksState) error {
+			findTaskState(s, "task-1").Status = "running"
+			return nil
+		}))
+	}}}
+
+	exec := &recordingExecutorV2{}
+	require.NoError(t, NewSchedulerV2(repo, exec, slog.Default()).CheckAndSchedule(context.Background()))
+
+	assert.Empty(t, exec.tasks)
+	agentsState, err := base.State().LoadAgents()
+	require.NoError(t, err)
+	assert.Empty(t, agentsState.Agents[0].RunningTasks, "the reserved slot is released")
+}
+
+func TestNewSchedulerV2_ChecksAgentRunnerHealth(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	require.NoError(t, RegisterAgent(repo, persistence.AgentState{AgentID: "agent-1"}))
+
+	scheduler := NewSchedulerV2(repo, NewExecutorV2("/nonexistent/agent-runner", t.TempDir(), repo, slog.Default()), slog.Default())
+	require.NoError(t, scheduler.CheckAndSchedule(context.Background()))
+
+	agents, err := ListAgents(repo)
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+	assert.Equal(t, string(persistence.AgentHealthUnhealthy), agents[0].Health)
+	assert.Contains(t, agents[0].HealthError, "agent-runner not available")
+}
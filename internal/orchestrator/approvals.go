@@ -0,0 +1,548 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/google/uuid"
+)
+
+// 承認ゲート: 人の承認が必要な地点でタスクを止め、バックログの APPROVAL アイテムとして承認を依頼する。
+//   - start: タスクを開始する前（依存が満たされた時点で依頼し、承認まで BLOCKED で待つ）
+//   - diff: 実装タスクが成功した後、ノードを implemented にする前（差分を承認すると実行ループがタスクを完了させる）
+//   - milestone: マイルストーンのノードが全て完了した後、別のマイルストーンからそのノードに依存するノードを開始する前
+//
+// start / diff は NodeDesign.Approvals か approvals.json のルール、milestone は approvals.json の milestones で指定する。
+// 却下（reject）は start ではタスクを取り消し、diff では却下の理由を回答として渡して再実装する。
+
+// ApprovalsFileName はワークスペース直下の承認ゲート設定ファイル名
+const ApprovalsFileName = "approvals.json"
+
+// ApprovalGate は承認ゲートの種類
+type ApprovalGate string
+
+const (
+	ApprovalGateStart     ApprovalGate = "start"     // タスクの開始前
+	ApprovalGateDiff      ApprovalGate = "diff"      // ノードを implemented にする前
+	ApprovalGateMilestone ApprovalGate = "milestone" // 次のマイルストーンのノードを開始する前
+)
+
+// ErrApprovalRequired はタスクが承認を待っていることを表す
+var ErrApprovalRequired = errors.New("approval required")
+
+// ApprovalRule は承認ゲートを要求するタスクの条件
+// 指定された条件はすべて AND で評価し、各条件内の値は OR で評価する。
+// 条件が 1 つも指定されていないルールは何にもマッチしない。
+type ApprovalRule struct {
+	Paths []string       `json:"paths,omitempty"` // 書き込み先（ディレクトリは配下を含む）
+	Kinds []string       `json:"kinds,omitempty"` // TaskState.Kind
+	Gates []ApprovalGate `json:"gates"`           // start / diff
+}
+
+// ApprovalConfig は approvals.json の内容を表す
+type ApprovalConfig struct {
+	Milestones []string       `json:"milestones,omitempty"` // 完了後に承認が必要なマイルストーン（NodeDesign.Milestone）
+	Rules      []ApprovalRule `json:"rules,omitempty"`
+}
+
+// LoadApprovalConfig はワークスペースの approvals.json を読み込む
+// ファイルが存在しない場合は空の設定（NodeDesign.Approvals だけが有効）を返す。
+func LoadApprovalConfig(workspaceDir string) (*ApprovalConfig, error) {
+	path := filepath.Join(workspaceDir, ApprovalsFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ApprovalConfig{}, nil
+		}
+		return &ApprovalConfig{}, fmt.Errorf("failed to read %s: %w", ApprovalsFileName, err)
+	}
+
+	var cfg ApprovalConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return &ApprovalConfig{}, fmt.Errorf("failed to parse %s: %w", ApprovalsFileName, err)
+	}
+	for i, rule := range cfg.Rules {
+		for _, gate := range rule.Gates {
+			if gate != ApprovalGateStart && gate != ApprovalGateDiff {
+				return &ApprovalConfig{}, fmt.Errorf("invalid %s: rules[%d]: unknown gate %q (want start or diff)", ApprovalsFileName, i, gate)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// Requires はタスクに承認ゲート gate が必要かを返す
+// node はタスクのノード設計（nil 可）、paths はタスクの書き込み先。c が nil なら NodeDesign.Approvals だけを見る。
+// 検証タスクはコードを変更しないため承認を求めない。
+func (c *ApprovalConfig) Requires(gate ApprovalGate, task *persistence.TaskState, node *persistence.NodeDesign, paths []string) bool {
+	if isVerificationTask(task) {
+		return false
+	}
+	if node != nil && containsString(node.Approvals, string(gate)) {
+		return true
+	}
+	if c == nil {
+		return false
+	}
+	for _, rule := range c.Rules {
+		if slices.Contains(rule.Gates, gate) && rule.matches(task, paths) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ApprovalRule) matches(task *persistence.TaskState, paths []string) bool {
+	if len(r.Paths) == 0 && len(r.Kinds) == 0 {
+		return false
+	}
+	if len(r.Kinds) > 0 && !containsString(r.Kinds, task.Kind) {
+		return false
+	}
+	if len(r.Paths) > 0 {
+		for _, want := range normalizeWritePaths(r.Paths) {
+			for _, p := range paths {
+				if writePathsOverlap(want, p) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// hasRules は gate を要求するルールがあるかを返す
+func (c *ApprovalConfig) hasRules(gate ApprovalGate) bool {
+	if c == nil {
+		return false
+	}
+	for _, rule := range c.Rules {
+		if slices.Contains(rule.Gates, gate) {
+			return true
+		}
+	}
+	return false
+}
+
+// MilestoneGated はマイルストーンの完了に承認が必要かを返す
+func (c *ApprovalConfig) MilestoneGated(milestone string) bool {
+	return c != nil && milestone != "" && containsString(c.Milestones, milestone)
+}
+
+// CreateApprovalItem は承認ゲートのバックログアイテムを作成する
+// マイルストーンの承認は taskID を空にし、subject にマイルストーン名を渡す（それ以外はタスクの表示名）。
+func CreateApprovalItem(gate ApprovalGate, taskID, subject string, node *persistence.NodeDesign, detail string) *BacklogItem {
+	labels := map[ApprovalGate]string{
+		ApprovalGateStart:     "開始",
+		ApprovalGateDiff:      "差分",
+		ApprovalGateMilestone: "マイルストーン",
+	}
+	metadata := map[string]any{"gate": string(gate)}
+	if node != nil {
+		metadata["nodeId"] = node.NodeID
+		if node.Milestone != "" {
+			metadata["milestone"] = node.Milestone
+		}
+	}
+	if gate == ApprovalGateMilestone {
+		metadata["milestone"] = subject
+	}
+	return &BacklogItem{
+		TaskID:      taskID,
+		Type:        BacklogTypeApproval,
+		Title:       fmt.Sprintf("承認待ち（%s）: %s", labels[gate], subject),
+		Description: detail,
+		Priority:    4,
+		Metadata:    metadata,
+	}
+}
+
+// approvalGateOf は APPROVAL アイテムの承認ゲートを返す
+func approvalGateOf(item *BacklogItem) ApprovalGate {
+	gate, _ := item.Metadata["gate"].(string)
+	return ApprovalGate(gate)
+}
+
+// findMilestoneApproval はマイルストーンの承認アイテムのうち最も新しいものを返す（無ければ nil）
+func findMilestoneApproval(items []BacklogItem, milestone string) *BacklogItem {
+	var found *BacklogItem
+	for i := range items {
+		item := &items[i]
+		if item.Type != BacklogTypeApproval || approvalGateOf(item) != ApprovalGateMilestone {
+			continue
+		}
+		if m, _ := item.Metadata["milestone"].(string); m != milestone {
+			continue
+		}
+		if found == nil || item.CreatedAt.After(found.CreatedAt) {
+			found = item
+		}
+	}
+	return found
+}
+
+// ListPendingApprovals は未解決の APPROVAL アイテムを返す
+func (s *BacklogStore) ListPendingApprovals() ([]BacklogItem, error) {
+	unresolved, err := s.ListUnresolved()
+	if err != nil {
+		return nil, err
+	}
+	pending := []BacklogItem{}
+	for _, item := range unresolved {
+		if item.Type == BacklogTypeApproval {
+			pending = append(pending, item)
+		}
+	}
+	return pending, nil
+}
+
+// waitingForApproval はタスクが承認（または承認後の完了処理）を待っているかを返す
+func waitingForApproval(task *persistence.TaskState) bool {
+	return inputString(task.Inputs, InputKeyAwaitingApproval) != "" || inputString(task.Inputs, InputKeyApprovalGate) != ""
+}
+
+// requestStartApproval は開始の承認が必要なタスクの承認をバックログに依頼し、承認まで BLOCKED で待たせる
+// 承認を待っている（待たせた）場合はそのアイテム ID を返す。依存が満たされていないタスクは依頼しない。
+func (s *Scheduler) requestStartApproval(taskID string) (string, error) {
+	if s.Backlog == nil {
+		return "", nil
+	}
+	tasksState, err := s.Repo.State().LoadTasks()
+	if err != nil {
+		return "", fmt.Errorf("failed to load tasks state: %w", err)
+	}
+	t := findTaskState(tasksState, taskID)
+	if t == nil {
+		return "", nil
+	}
+	if id := inputString(t.Inputs, InputKeyAwaitingApproval); id != "" {
+		return id, nil
+	}
+	if inputString(t.Inputs, InputKeyApprovalGate) == string(ApprovalGateDiff) {
+		return "", fmt.Errorf("%w: approved diff of task %s is waiting to be completed", ErrApprovalRequired, taskID)
+	}
+	if containsString(inputStrings(t.Inputs, InputKeyApprovedGates), string(ApprovalGateStart)) {
+		return "", nil
+	}
+	var node *persistence.NodeDesign
+	if n, err := s.Repo.Design().GetNode(t.NodeID); err == nil {
+		node = n
+	}
+	// 書き込み先の組み立ては試行の記録を読むため、開始の承認を要求し得るタスクに限る
+	if (node == nil || !containsString(node.Approvals, string(ApprovalGateStart))) && !s.Approvals.hasRules(ApprovalGateStart) {
+		return "", nil
+	}
+	paths := LoadTaskWriteSet(s.Repo, t).Paths()
+	if !s.Approvals.Requires(ApprovalGateStart, t, node, paths) || !s.allDependenciesSatisfied(t) {
+		return "", nil
+	}
+
+	title := inputString(t.Inputs, InputKeyTitle)
+	if node != nil && node.Name != "" {
+		title = node.Name
+	}
+	if title == "" {
+		title = t.TaskID
+	}
+	detail := fmt.Sprintf("タスク '%s' の開始には承認が必要です。", title)
+	if len(paths) > 0 {
+		detail += "\n書き込み先: " + strings.Join(paths, ", ")
+	}
+	item := CreateApprovalItem(ApprovalGateStart, t.TaskID, title, node, detail)
+	if err := s.Backlog.Add(item); err != nil {
+		return "", err
+	}
+
+	var change *taskStatusChange
+	err = s.Repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+		change = nil
+		t := findTaskState(state, taskID)
+		if t == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+		if t.Inputs == nil {
+			t.Inputs = make(map[string]interface{})
+		}
+		t.Inputs[InputKeyAwaitingApproval] = item.ID
+		t.Inputs[InputKeyApprovalGate] = string(ApprovalGateStart)
+		if TaskStatus(t.Status) != TaskStatusBlocked {
+			change = &taskStatusChange{TaskID: t.TaskID, Old: TaskStatus(t.Status), New: TaskStatusBlocked}
+			t.Status = string(TaskStatusBlocked)
+		}
+		t.UpdatedAt = s.clock.Now()
+		return nil
+	})
+	if err != nil {
+		_ = s.Backlog.Delete(item.ID)
+		return "", fmt.Errorf("failed to save task waiting for approval: %w", err)
+	}
+
+	s.logger.Info("task waiting for start approval", slog.String("task_id", taskID), slog.String("backlog_id", item.ID))
+	if s.events != nil {
+		s.events.Emit(EventBacklogAdded, item)
+	}
+	if change != nil {
+		s.emitStateChange(change.TaskID, change.Old, change.New)
+	}
+	return item.ID, nil
+}
+
+// milestoneGatesPassed は別のマイルストーンに属する依存先のうち、承認が必要なマイルストーンが全て承認済みかを返す
+func (s *Scheduler) milestoneGatesPassed(node *persistence.NodeDesign) bool {
+	if s.Backlog == nil || s.Approvals == nil || len(s.Approvals.Milestones) == 0 {
+		return true
+	}
+	var items []BacklogItem
+	loaded := false
+	for _, depID := range node.Dependencies {
+		dep, err := s.Repo.Design().GetNode(depID)
+		if err != nil || dep.Milestone == node.Milestone || !s.Approvals.MilestoneGated(dep.Milestone) {
+			continue
+		}
+		if !loaded {
+			if items, err = s.Backlog.List(); err != nil {
+				s.logger.Warn("failed to load backlog for milestone approval", slog.Any("error", err))
+				return false
+			}
+			loaded = true
+		}
+		item := findMilestoneApproval(items, dep.Milestone)
+		if item == nil || item.ResolutionAction != BacklogActionApprove {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestMilestoneApprovals は承認が必要なマイルストーンのうち、全てのノードが完了したものの承認をバックログに依頼する
+// 承認の依頼はマイルストーンごとに 1 度だけ行う。作成したアイテムの ID を返す。
+func (s *Scheduler) RequestMilestoneApprovals() ([]string, error) {
+	if s.Backlog == nil || s.Approvals == nil || len(s.Approvals.Milestones) == 0 {
+		return nil, nil
+	}
+	items, err := s.Backlog.List()
+	if err != nil {
+		return nil, err
+	}
+	var waiting []string
+	for _, m := range s.Approvals.Milestones {
+		if findMilestoneApproval(items, m) == nil {
+			waiting = append(waiting, m)
+		}
+	}
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+
+	tasksState, err := s.Repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks state: %w", err)
+	}
+	nodesRuntime, err := s.Repo.State().LoadNodesRuntime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nodes runtime: %w", err)
+	}
+	completed := make(map[string]bool, len(nodesRuntime.Nodes))
+	for _, nr := range nodesRuntime.Nodes {
+		completed[nr.NodeID] = persistence.NodeRuntimeStatus(nr.Status).IsCompleted()
+	}
+	members := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, t := range tasksState.Tasks {
+		if t.NodeID == "" || seen[t.NodeID] {
+			continue
+		}
+		seen[t.NodeID] = true
+		if node, err := s.Repo.Design().GetNode(t.NodeID); err == nil && node.Milestone != "" {
+			members[node.Milestone] = append(members[node.Milestone], node.NodeID)
+		}
+	}
+
+	var created []string
+	for _, m := range waiting {
+		nodes := members[m]
+		if len(nodes) == 0 || slices.ContainsFunc(nodes, func(id string) bool { return !completed[id] }) {
+			continue
+		}
+		detail := fmt.Sprintf("マイルストーン '%s' の %d ノードが完了しました。承認すると、このマイルストーンに依存する次のノードを開始します。\nノード: %s",
+			m, len(nodes), strings.Join(nodes, ", "))
+		item := CreateApprovalItem(ApprovalGateMilestone, "", m, nil, detail)
+		item.Metadata["nodeIds"] = nodes
+		if err := s.Backlog.Add(item); err != nil {
+			return created, err
+		}
+		created = append(created, item.ID)
+		s.logger.Info("milestone waiting for approval", slog.String("milestone", m), slog.String("backlog_id", item.ID))
+		if s.events != nil {
+			s.events.Emit(EventBacklogAdded, item)
+		}
+	}
+	return created, nil
+}
+
+// requestDiffApproval は差分の承認が必要な実装タスクの承認をバックログに依頼し、作成したアイテムを返す
+// 承認が不要なら nil を返す。files は試行で変更したファイル。
+// 必須の承認ゲートは開いたまま失敗させない: 追加に失敗してもアイテムを返してタスクをその承認で待たせ、
+// 追加は retryDiffApprovalRequests が次の周期でやり直す。
+func (e *ExecutionOrchestrator) requestDiffApproval(task *persistence.TaskState, files []string) *BacklogItem {
+	if e.BacklogStore == nil || e.Repo == nil {
+		return nil
+	}
+	var cfg *ApprovalConfig
+	if e.Scheduler != nil {
+		cfg = e.Scheduler.Approvals
+	}
+	var node *persistence.NodeDesign
+	if n, err := e.Repo.Design().GetNode(task.NodeID); err == nil {
+		node = n
+	}
+	paths := normalizeWritePaths(append(LoadTaskWriteSet(e.Repo, task).Paths(), files...))
+	if !cfg.Requires(ApprovalGateDiff, task, node, paths) {
+		return nil
+	}
+
+	item := diffApprovalItem(task, node, files)
+	// 追加に失敗してもタスクが同じアイテムを待てるよう、ID は先に決める
+	item.ID = uuid.New().String()
+	e.addDiffApproval(item)
+	return item
+}
+
+// diffApprovalItem は差分の承認を依頼する APPROVAL アイテムを作成する
+func diffApprovalItem(task *persistence.TaskState, node *persistence.NodeDesign, files []string) *BacklogItem {
+	title := task.TaskID
+	if node != nil && node.Name != "" {
+		title = node.Name
+	} else if t := inputString(task.Inputs, InputKeyTitle); t != "" {
+		title = t
+	}
+	detail := fmt.Sprintf("タスク '%s' の差分を承認するとノードを implemented にします。却下すると理由を添えて再実装します。", title)
+	if len(files) > 0 {
+		detail += "\n変更したファイル: " + strings.Join(normalizeWritePaths(files), ", ")
+	}
+	item := CreateApprovalItem(ApprovalGateDiff, task.TaskID, title, node, detail)
+	item.Metadata["files"] = normalizeWritePaths(files)
+	return item
+}
+
+// addDiffApproval は差分の承認依頼をバックログに追加する（失敗した場合は次の周期でやり直すためログに残すだけ）
+func (e *ExecutionOrchestrator) addDiffApproval(item *BacklogItem) {
+	if err := e.BacklogStore.Add(item); err != nil {
+		e.logger.Error("failed to request diff approval, keeping the task blocked and retrying on the next loop",
+			slog.String("task_id", item.TaskID),
+			slog.String("item_id", item.ID),
+			slog.Any("error", err),
+		)
+		return
+	}
+	if e.EventEmitter != nil {
+		e.EventEmitter.Emit(EventBacklogAdded, item)
+	}
+}
+
+// retryDiffApprovalRequests は差分の承認を待っているのに承認依頼がバックログに無いタスクの依頼をやり直す
+func (e *ExecutionOrchestrator) retryDiffApprovalRequests() {
+	if e.Repo == nil || e.BacklogStore == nil {
+		return
+	}
+	tasksState, err := e.Repo.State().LoadTasks()
+	if err != nil {
+		e.logger.Error("failed to load tasks for diff approval requests", slog.Any("error", err))
+		return
+	}
+	for i := range tasksState.Tasks {
+		t := &tasksState.Tasks[i]
+		itemID := inputString(t.Inputs, InputKeyAwaitingApproval)
+		if TaskStatus(t.Status) != TaskStatusBlocked || itemID == "" ||
+			inputString(t.Inputs, InputKeyApprovalGate) != string(ApprovalGateDiff) {
+			continue
+		}
+		if _, err := os.Stat(e.BacklogStore.itemPath(itemID)); !os.IsNotExist(err) {
+			continue
+		}
+		var node *persistence.NodeDesign
+		if n, err := e.Repo.Design().GetNode(t.NodeID); err == nil {
+			node = n
+		}
+		item := diffApprovalItem(t, node, t.Outputs.Files)
+		item.ID = itemID
+		e.addDiffApproval(item)
+	}
+}
+
+// finalizeApprovedDiffs は差分が承認されたタスクのノードを implemented にし、タスクを SUCCEEDED で完了させる
+func (e *ExecutionOrchestrator) finalizeApprovedDiffs() {
+	if e.Repo == nil {
+		return
+	}
+	tasksState, err := e.Repo.State().LoadTasks()
+	if err != nil {
+		e.logger.Error("failed to load tasks for approved diffs", slog.Any("error", err))
+		return
+	}
+	for i := range tasksState.Tasks {
+		t := &tasksState.Tasks[i]
+		if TaskStatus(t.Status) != TaskStatusBlocked ||
+			inputString(t.Inputs, InputKeyApprovalGate) != string(ApprovalGateDiff) ||
+			inputString(t.Inputs, InputKeyAwaitingApproval) != "" {
+			continue
+		}
+		reimplemented, err := e.markNodeImplemented(t.NodeID, t.Outputs.Files)
+		if err != nil {
+			e.logger.Error("failed to mark approved node implemented", slog.String("task_id", t.TaskID), slog.Any("error", err))
+			continue
+		}
+
+		now := e.now()
+		var task persistence.TaskState
+		finalized := false
+		err = e.Repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+			ts := findTaskState(state, t.TaskID)
+			if ts == nil || inputString(ts.Inputs, InputKeyApprovalGate) != string(ApprovalGateDiff) {
+				return persistence.ErrNoChange
+			}
+			delete(ts.Inputs, InputKeyApprovalGate)
+			ts.Status = string(TaskStatusSucceeded)
+			ts.Outputs.Status = string(TaskStatusSucceeded)
+			ts.DoneAt = &now
+			ts.UpdatedAt = now
+			task = *ts
+			finalized = true
+			return nil
+		})
+		if err != nil {
+			e.logger.Error("failed to complete approved task", slog.String("task_id", t.TaskID), slog.Any("error", err))
+			continue
+		}
+		if !finalized {
+			continue
+		}
+		e.logger.Info("approved diff completed", slog.String("task_id", task.TaskID), slog.String("node_id", task.NodeID))
+		e.emitTaskStateChange(task.TaskID, TaskStatusBlocked, TaskStatusSucceeded)
+		if reimplemented {
+			e.propagateReimplementation(task.NodeID)
+		}
+		e.scheduleVerification(&task)
+		e.triggerDependencyResolution()
+	}
+}
+
+// processApprovals は承認ゲートの状態を進める（追加できなかった差分の承認依頼のやり直し・承認済みの差分の完了・完了したマイルストーンの承認依頼）
+func (e *ExecutionOrchestrator) processApprovals() {
+	e.retryDiffApprovalRequests()
+	e.finalizeApprovedDiffs()
+	if e.Scheduler == nil {
+		return
+	}
+	if _, err := e.Scheduler.RequestMilestoneApprovals(); err != nil {
+		e.logger.Error("failed to request milestone approvals", slog.Any("error", err))
+	}
+}
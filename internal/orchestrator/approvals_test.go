@@ -0,0 +1,276 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func writeApprovalConfig(t *testing.T, dir, content string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ApprovalsFileName), []byte(content), 0644))
+}
+
+func pendingApproval(t *testing.T, store *BacklogStore) BacklogItem {
+	items, err := store.ListPendingApprovals()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	return items[0]
+}
+
+func TestLoadApprovalConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadApprovalConfig(dir)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Rules)
+
+	writeApprovalConfig(t, dir, `{"milestones":["M1"],"rules":[{"paths":["db/migrations"],"gates":["start","diff"]}]}`)
+	cfg, err = LoadApprovalConfig(dir)
+	require.NoError(t, err)
+	assert.True(t, cfg.MilestoneGated("M1"))
+	assert.False(t, cfg.MilestoneGated("M2"))
+
+	task := &persistence.TaskState{Kind: "implementation"}
+	assert.True(t, cfg.Requires(ApprovalGateStart, task, nil, []string{"db/migrations/001_users.sql"}))
+	assert.False(t, cfg.Requires(ApprovalGateStart, task, nil, []string{"internal/api/users.go"}))
+	assert.True(t, cfg.Requires(ApprovalGateDiff, task, &persistence.NodeDesign{Approvals: []string{"diff"}}, nil))
+	verification := &persistence.TaskState{Kind: TaskKindTest, Inputs: map[string]interface{}{InputKeyVerifiesTaskID: "task-1"}}
+	assert.False(t, cfg.Requires(ApprovalGateStart, verification, nil, []string{"db/migrations/001_users.sql"}), "verification tasks need no approval")
+
+	writeApprovalConfig(t, dir, `{"rules":[{"kinds":["implementation"],"gates":["milestone"]}]}`)
+	_, err = LoadApprovalConfig(dir)
+	assert.Error(t, err)
+}
+
+func TestScheduler_StartApproval(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	writeApprovalConfig(t, repo.BaseDir(), `{"rules":[{"paths":["db/migrations"],"gates":["start"]}]}`)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-1", Name: "users テーブル", SuggestedImpl: persistence.SuggestedImpl{FilePaths: []string{"db/migrations/001_users.sql"}}},
+		{NodeID: "node-2", Name: "API", SuggestedImpl: persistence.SuggestedImpl{FilePaths: []string{"internal/api/users.go"}}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Kind: "implementation", Status: string(TaskStatusPending)},
+		{TaskID: "task-2", NodeID: "node-2", Kind: "implementation", Status: string(TaskStatusPending)},
+	}, nil)
+	scheduler := NewScheduler(repo, queue, nil)
+
+	scheduled, err := scheduler.ScheduleReadyTasks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-2"}, scheduled)
+	assert.ErrorIs(t, scheduler.ScheduleTask("task-1"), ErrApprovalRequired)
+
+	item := pendingApproval(t, scheduler.Backlog)
+	assert.Equal(t, "task-1", item.TaskID)
+	assert.Equal(t, ApprovalGateStart, approvalGateOf(&item))
+	assert.Contains(t, item.Description, "db/migrations/001_users.sql")
+	task := loadTaskState(t, repo, "task-1")
+	assert.Equal(t, string(TaskStatusBlocked), task.Status)
+	assert.Equal(t, item.ID, inputString(task.Inputs, InputKeyAwaitingApproval))
+
+	unblocked, err := scheduler.UpdateBlockedTasks()
+	require.NoError(t, err)
+	assert.Empty(t, unblocked, "tasks awaiting approval stay blocked")
+
+	resolver := NewBacklogResolver(repo, scheduler.Backlog, nil)
+	_, err = resolver.Resolve(context.Background(), item.ID, BacklogResolution{})
+	assert.ErrorIs(t, err, ErrInvalidBacklogResolution, "approvals need an explicit approve or reject")
+
+	result, err := resolver.Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionApprove, Note: "LGTM"})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusPending, result.TaskStatus)
+	task = loadTaskState(t, repo, "task-1")
+	assert.Equal(t, []string{"start"}, inputStrings(task.Inputs, InputKeyApprovedGates))
+	assert.False(t, waitingForApproval(&task))
+
+	require.NoError(t, scheduler.ScheduleTask("task-1"))
+	assert.Equal(t, string(TaskStatusReady), loadTaskState(t, repo, "task-1").Status)
+}
+
+func TestScheduler_StartApprovalRejected(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	saveDesign(t, repo, []persistence.NodeDesign{{NodeID: "node-1", Approvals: []string{"start"}}})
+	saveState(t, repo, []persistence.TaskState{{TaskID: "task-1", NodeID: "node-1", Status: string(TaskStatusPending)}}, nil)
+	scheduler := NewScheduler(repo, queue, nil)
+
+	assert.ErrorIs(t, scheduler.ScheduleTask("task-1"), ErrApprovalRequired)
+	item := pendingApproval(t, scheduler.Backlog)
+	result, err := NewBacklogResolver(repo, scheduler.Backlog, nil).Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionReject})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusCanceled, result.TaskStatus)
+}
+
+func TestExecutionOrchestrator_DiffApproval(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-1", Name: "users テーブル", Approvals: []string{"diff"}},
+		{NodeID: "node-2", Name: "API", Dependencies: []string{"node-1"}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Kind: "implementation", Status: string(TaskStatusPending)},
+		{TaskID: "task-2", NodeID: "node-2", Kind: "implementation", Status: string(TaskStatusPending)},
+	}, nil)
+
+	finished := time.Now()
+	mockExecutor := new(MockExecutor)
+	mockExecutor.On("ExecuteTask", mock.Anything, mock.Anything).Return(&Attempt{
+		Status:     AttemptStatusSucceeded,
+		FinishedAt: &finished,
+		Artifacts:  &Artifacts{Files: []string{"db/migrations/001_users.sql"}},
+	}, nil)
+	store := NewBacklogStore(repo.BaseDir())
+	orch := NewExecutionOrchestrator(NewScheduler(repo, queue, nil), mockExecutor, repo, queue, nil, store, []string{"default"})
+
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-1", TaskID: "task-1", PoolID: "default"})
+	task := loadTaskState(t, repo, "task-1")
+	assert.Equal(t, string(TaskStatusBlocked), task.Status)
+	assert.Equal(t, []string{"db/migrations/001_users.sql"}, task.Outputs.Files)
+	nr := loadNodeRuntime(t, repo, "node-1")
+	assert.Equal(t, string(persistence.NodeRuntimeStatusPlanned), nr.Status, "the node is not implemented before approval")
+	require.NotNil(t, nr.Actuals)
+	assert.Equal(t, 1, nr.Actuals.Attempts)
+	assert.Error(t, orch.Scheduler.ScheduleTask("task-2"), "dependents wait until the diff is approved")
+
+	// 却下すると理由を回答として渡して再実装する
+	item := pendingApproval(t, store)
+	assert.Equal(t, ApprovalGateDiff, approvalGateOf(&item))
+	resolver := NewBacklogResolver(repo, store, nil)
+	_, err := resolver.Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionReject})
+	assert.ErrorIs(t, err, ErrInvalidBacklogResolution, "rejecting a diff needs a reason")
+	result, err := resolver.Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionReject, Note: "インデックスを追加してください"})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusPending, result.TaskStatus)
+	answers := taskAnswersFromInputs(loadTaskState(t, repo, "task-1").Inputs)
+	require.Len(t, answers, 1)
+	assert.Equal(t, "インデックスを追加してください", answers[0].Answer)
+
+	// 再実装した差分を承認すると、実行ループがノードを implemented にしてタスクを完了させる
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-2", TaskID: "task-1", PoolID: "default"})
+	item = pendingApproval(t, store)
+	result, err = resolver.Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionApprove})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusBlocked, result.TaskStatus)
+	assert.ErrorIs(t, orch.Scheduler.ScheduleTask("task-1"), ErrApprovalRequired, "approved diffs are not executed again")
+
+	orch.finalizeApprovedDiffs()
+	task = loadTaskState(t, repo, "task-1")
+	assert.Equal(t, string(TaskStatusSucceeded), task.Status)
+	assert.NotNil(t, task.DoneAt)
+	assert.False(t, waitingForApproval(&task))
+	nr = loadNodeRuntime(t, repo, "node-1")
+	assert.Equal(t, string(persistence.NodeRuntimeStatusImplemented), nr.Status)
+	assert.Equal(t, []string{"db/migrations/001_users.sql"}, nr.Implementation.Files)
+	assert.Equal(t, string(TaskStatusReady), loadTaskState(t, repo, "task-2").Status, "dependents are scheduled once the diff is approved")
+}
+
+func TestExecutionOrchestrator_DiffApprovalFailsClosed(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	saveDesign(t, repo, []persistence.NodeDesign{{NodeID: "node-1", Approvals: []string{"diff"}}})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Kind: "implementation", Status: string(TaskStatusPending)},
+	}, nil)
+
+	finished := time.Now()
+	mockExecutor := new(MockExecutor)
+	mockExecutor.On("ExecuteTask", mock.Anything, mock.Anything).Return(&Attempt{
+		Status:     AttemptStatusSucceeded,
+		FinishedAt: &finished,
+		Artifacts:  &Artifacts{Files: []string{"main.go"}},
+	}, nil)
+	store := NewBacklogStore(repo.BaseDir())
+	orch := NewExecutionOrchestrator(NewScheduler(repo, queue, nil), mockExecutor, repo, queue, nil, store, []string{"default"})
+
+	// バックログのディレクトリを作れず、承認依頼を追加できない
+	blocker := filepath.Join(repo.BaseDir(), "backlog")
+	require.NoError(t, os.WriteFile(blocker, nil, 0644))
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-1", TaskID: "task-1", PoolID: "default"})
+
+	task := loadTaskState(t, repo, "task-1")
+	assert.Equal(t, string(TaskStatusBlocked), task.Status)
+	assert.Equal(t, string(ApprovalGateDiff), inputString(task.Inputs, InputKeyApprovalGate))
+	itemID := inputString(task.Inputs, InputKeyAwaitingApproval)
+	require.NotEmpty(t, itemID)
+	orch.processApprovals()
+	assert.Equal(t, string(TaskStatusBlocked), loadTaskState(t, repo, "task-1").Status, "the diff is not completed without approval")
+	assert.Equal(t, string(persistence.NodeRuntimeStatusPlanned), loadNodeRuntime(t, repo, "node-1").Status)
+
+	// バックログが使えるようになれば次の周期で同じアイテムを依頼し直す
+	require.NoError(t, os.Remove(blocker))
+	orch.processApprovals()
+	item := pendingApproval(t, store)
+	assert.Equal(t, itemID, item.ID)
+	assert.Equal(t, []any{"main.go"}, item.Metadata["files"])
+
+	_, err := NewBacklogResolver(repo, store, nil).Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionApprove})
+	require.NoError(t, err)
+	orch.processApprovals()
+	assert.Equal(t, string(TaskStatusSucceeded), loadTaskState(t, repo, "task-1").Status)
+}
+
+func TestScheduler_MilestoneApproval(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	writeApprovalConfig(t, repo.BaseDir(), `{"milestones":["M1"]}`)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-1", Milestone: "M1"},
+		{NodeID: "node-2", Milestone: "M1"},
+		{NodeID: "node-3", Milestone: "M1", Dependencies: []string{"node-1"}},
+		{NodeID: "node-4", Milestone: "M2", Dependencies: []string{"node-1"}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Status: string(TaskStatusSucceeded)},
+		{TaskID: "task-2", NodeID: "node-2", Status: string(TaskStatusRunning)},
+		{TaskID: "task-3", NodeID: "node-3", Status: string(TaskStatusPending)},
+		{TaskID: "task-4", NodeID: "node-4", Status: string(TaskStatusPending)},
+	}, []persistence.NodeRuntime{
+		{NodeID: "node-1", Status: string(persistence.NodeRuntimeStatusImplemented)},
+	})
+	scheduler := NewScheduler(repo, queue, nil)
+
+	// 同じマイルストーン内の依存は承認を待たない
+	require.NoError(t, scheduler.ScheduleTask("task-3"))
+	assert.Error(t, scheduler.ScheduleTask("task-4"))
+
+	created, err := scheduler.RequestMilestoneApprovals()
+	require.NoError(t, err)
+	assert.Empty(t, created, "the milestone is not complete yet")
+
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Status: string(TaskStatusSucceeded)},
+		{TaskID: "task-2", NodeID: "node-2", Status: string(TaskStatusSucceeded)},
+		{TaskID: "task-3", NodeID: "node-3", Status: string(TaskStatusSucceeded)},
+		{TaskID: "task-4", NodeID: "node-4", Status: string(TaskStatusBlocked)},
+	}, []persistence.NodeRuntime{
+		{NodeID: "node-1", Status: string(persistence.NodeRuntimeStatusImplemented)},
+		{NodeID: "node-2", Status: string(persistence.NodeRuntimeStatusImplemented)},
+		{NodeID: "node-3", Status: string(persistence.NodeRuntimeStatusVerified)},
+	})
+	created, err = scheduler.RequestMilestoneApprovals()
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	created, err = scheduler.RequestMilestoneApprovals()
+	require.NoError(t, err)
+	assert.Empty(t, created, "a milestone is requested once")
+
+	unblocked, err := scheduler.UpdateBlockedTasks()
+	require.NoError(t, err)
+	assert.Empty(t, unblocked, "the next milestone waits for approval")
+
+	item := pendingApproval(t, scheduler.Backlog)
+	assert.Equal(t, "承認待ち（マイルストーン）: M1", item.Title)
+	resolver := NewBacklogResolver(repo, scheduler.Backlog, nil)
+	_, err = resolver.Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionReject, Note: "no"})
+	assert.ErrorIs(t, err, ErrInvalidBacklogResolution)
+	_, err = resolver.Resolve(context.Background(), item.ID, BacklogResolution{Action: BacklogActionApprove})
+	require.NoError(t, err)
+
+	unblocked, err = scheduler.UpdateBlockedTasks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-4"}, unblocked)
+}
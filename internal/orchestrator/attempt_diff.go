@@ -0,0 +1,121 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// attemptDiffBudget は 1 回の試行で記録する差分の上限（バイト）
+// 超えた分は切り詰め、以降のファイルは Truncated だけを記録する。
+const attemptDiffBudget = 64 * 1024
+
+// attemptDiffTimeout は差分の取得に使う git コマンドのタイムアウト
+const attemptDiffTimeout = 10 * time.Second
+
+// recordAttemptChanges は試行で変更したファイルとその差分を attempt に記録する
+// 失敗した試行の変更も再計画の入力にするため、成否に関わらず記録する。
+func (e *Executor) recordAttemptChanges(attempt *Attempt, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	attempt.Artifacts = &Artifacts{Files: files, Diffs: e.captureDiffs(files)}
+}
+
+// captureDiffs は ProjectRoot の HEAD に対する files の差分をファイルごとに返す
+// 未追跡のファイルは全体を追加した差分にする。git が使えない場合は nil を返す（記録は必須ではない）。
+func (e *Executor) captureDiffs(files []string) []FileDiff {
+	ctx, cancel := context.WithTimeout(context.Background(), attemptDiffTimeout)
+	defer cancel()
+
+	out, err := e.git(ctx, append([]string{"diff", "--no-color", "--no-ext-diff", "HEAD", "--"}, files...)...)
+	if err != nil {
+		e.logger.Debug("failed to capture attempt diff", slog.Any("error", err))
+		return nil
+	}
+	patches := make(map[string]string)
+	for _, patch := range splitGitDiff(out) {
+		patches[patch.Path] = patch.Patch
+	}
+	diffs := make([]FileDiff, 0, len(files))
+	for _, file := range files {
+		patch, ok := patches[file]
+		if !ok {
+			patch = e.untrackedDiff(ctx, file)
+		}
+		if patch != "" {
+			diffs = append(diffs, FileDiff{Path: file, Patch: patch})
+		}
+	}
+	return truncateDiffs(diffs, attemptDiffBudget)
+}
+
+// untrackedDiff は未追跡のファイルを新規作成した差分を返す（未追跡でなければ空）
+func (e *Executor) untrackedDiff(ctx context.Context, file string) string {
+	if info, err := os.Stat(filepath.Join(e.ProjectRoot, file)); err != nil || info.IsDir() {
+		return ""
+	}
+	if _, err := e.git(ctx, "ls-files", "--error-unmatch", "--", file); err == nil {
+		return "" // 追跡済みで HEAD との差分が無い
+	}
+	// --no-index は差分があると終了コード 1 を返す
+	out, err := e.git(ctx, "diff", "--no-color", "--no-ext-diff", "--no-index", "--", os.DevNull, file)
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return ""
+	}
+	return out
+}
+
+func (e *Executor) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = e.ProjectRoot
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// splitGitDiff は git diff の出力をファイルごとに分ける（Path は変更後のパス）
+func splitGitDiff(out string) []FileDiff {
+	var diffs []FileDiff
+	for out != "" {
+		next := strings.Index(out, "\ndiff --git ")
+		chunk := out
+		if next >= 0 {
+			chunk, out = out[:next+1], out[next+1:]
+		} else {
+			out = ""
+		}
+		header, _, _ := strings.Cut(chunk, "\n")
+		if _, path, ok := strings.Cut(strings.TrimPrefix(header, "diff --git "), " b/"); ok {
+			diffs = append(diffs, FileDiff{Path: path, Patch: chunk})
+		}
+	}
+	return diffs
+}
+
+// truncateDiffs は差分の合計が budget バイトに収まるように切り詰める
+// 収まらない差分は行の途中で切らずに Truncated を付け、以降のファイルは Patch を省く。
+func truncateDiffs(diffs []FileDiff, budget int) []FileDiff {
+	remaining := budget
+	for i := range diffs {
+		if len(diffs[i].Patch) <= remaining {
+			remaining -= len(diffs[i].Patch)
+			continue
+		}
+		patch := diffs[i].Patch[:remaining]
+		if idx := strings.LastIndexByte(patch, '\n'); idx >= 0 {
+			patch = patch[:idx+1]
+		} else {
+			patch = ""
+		}
+		diffs[i].Patch = patch
+		diffs[i].Truncated = true
+		remaining = 0
+	}
+	return diffs
+}
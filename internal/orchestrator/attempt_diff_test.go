@@ -0,0 +1,73 @@
+package orchestrator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_RecordAttemptChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "other.go"), []byte("package main\n"), 0644))
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "other.go"), []byte("package other\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new.go"), []byte("package main\n\nvar x = 1\n"), 0644))
+
+	executor := NewExecutor("agent-runner", root)
+	attempt := &Attempt{Status: AttemptStatusFailed}
+	executor.recordAttemptChanges(attempt, []string{"main.go", "new.go"})
+
+	require.NotNil(t, attempt.Artifacts)
+	assert.Equal(t, []string{"main.go", "new.go"}, attempt.Artifacts.Files)
+	require.Len(t, attempt.Artifacts.Diffs, 2, "only the attempt's files are recorded")
+	assert.Equal(t, "main.go", attempt.Artifacts.Diffs[0].Path)
+	assert.Contains(t, attempt.Artifacts.Diffs[0].Patch, "+func main() {}")
+	assert.Equal(t, "new.go", attempt.Artifacts.Diffs[1].Path)
+	assert.Contains(t, attempt.Artifacts.Diffs[1].Patch, "+var x = 1")
+
+	// 記録した差分は試行の記録を経由しても残る
+	repo, _ := setupTestRepo(t)
+	attempt.ID = "attempt-1"
+	attempt.TaskID = "task-1"
+	attempt.StartedAt = time.Now()
+	require.NoError(t, repo.Attempts().SaveAttempt(attemptRecord(attempt)))
+	loaded, err := GetAttempt(repo, "attempt-1")
+	require.NoError(t, err)
+	assert.Equal(t, attempt.Artifacts.Diffs, loaded.Artifacts.Diffs)
+}
+
+func TestTruncateDiffs(t *testing.T) {
+	diffs := truncateDiffs([]FileDiff{
+		{Path: "a.go", Patch: "+1\n+2\n"},
+		{Path: "b.go", Patch: "+3\n+4\n"},
+		{Path: "c.go", Patch: "+5\n"},
+	}, 9)
+
+	assert.Equal(t, []FileDiff{
+		{Path: "a.go", Patch: "+1\n+2\n"},
+		{Path: "b.go", Patch: "+3\n", Truncated: true},
+		{Path: "c.go", Truncated: true},
+	}, diffs)
+	assert.LessOrEqual(t, len(diffs[0].Patch)+len(diffs[1].Patch), 9)
+	assert.False(t, strings.Contains(diffs[1].Patch, "+4"))
+}
@@ -0,0 +1,273 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// 実行試行は runs/<attempt-id>/ の記録（persistence.AttemptRecord）と stdout / stderr のログとして保存する。
+// 監査のため、開始・終了は history の task.attempt_started / task.succeeded / task.failed アクション
+// （persistence.AttemptPayload）としても記録する。
+
+// attemptLogPollInterval は FollowAttemptLog が新しい行を待つ間隔
+const attemptLogPollInterval = 500 * time.Millisecond
+
+// AttemptRun は processJob が ExecuteTask に渡す試行の情報
+// Executor は試行 ID をこの値に揃え、stdout / stderr を Log に記録する。
+type AttemptRun struct {
+	ID        string
+	StartedAt time.Time
+	Log       *persistence.AttemptLogWriter // 記録に失敗した場合は nil
+
+	taskID string
+	poolID string
+}
+
+type attemptRunKey struct{}
+
+// WithAttemptRun は試行の情報を ctx に設定する
+func WithAttemptRun(ctx context.Context, run *AttemptRun) context.Context {
+	return context.WithValue(ctx, attemptRunKey{}, run)
+}
+
+// AttemptRunFromContext は ctx の試行の情報を返す（無ければ nil）
+func AttemptRunFromContext(ctx context.Context) *AttemptRun {
+	run, _ := ctx.Value(attemptRunKey{}).(*AttemptRun)
+	return run
+}
+
+// attemptFromRecord は試行の記録を表示用の Attempt に変換する
+func attemptFromRecord(rec persistence.AttemptRecord) Attempt {
+	attempt := Attempt{
+		ID:           rec.AttemptID,
+		TaskID:       rec.TaskID,
+		PoolID:       rec.PoolID,
+		Status:       AttemptStatus(rec.Status),
+		StartedAt:    rec.StartedAt,
+		FinishedAt:   rec.FinishedAt,
+		ExitCode:     rec.ExitCode,
+		ErrorSummary: rec.ErrorSummary,
+		FailureKind:  FailureKind(rec.FailureKind),
+		LogLines:     rec.LogLines,
+		WorkerRuns:   rec.WorkerRuns,
+		InputTokens:  rec.InputTokens,
+		OutputTokens: rec.OutputTokens,
+		CostUSD:      rec.CostUSD,
+	}
+	if rec.Tooling != nil {
+		attempt.Tooling = &AttemptTooling{Tool: rec.Tooling.Tool, Model: rec.Tooling.Model}
+	}
+	if len(rec.Artifacts.Files) > 0 || len(rec.Artifacts.Logs) > 0 || len(rec.Artifacts.Diffs) > 0 {
+		attempt.Artifacts = &Artifacts{Files: rec.Artifacts.Files, Logs: rec.Artifacts.Logs}
+		for _, d := range rec.Artifacts.Diffs {
+			attempt.Artifacts.Diffs = append(attempt.Artifacts.Diffs, FileDiff{Path: d.Path, Patch: d.Patch, Truncated: d.Truncated})
+		}
+	}
+	return attempt
+}
+
+// attemptRecord は Attempt を試行の記録に変換する
+func attemptRecord(attempt *Attempt) *persistence.AttemptRecord {
+	rec := &persistence.AttemptRecord{
+		AttemptID:    attempt.ID,
+		TaskID:       attempt.TaskID,
+		PoolID:       attempt.PoolID,
+		Status:       string(attempt.Status),
+		StartedAt:    attempt.StartedAt,
+		FinishedAt:   attempt.FinishedAt,
+		ExitCode:     attempt.ExitCode,
+		ErrorSummary: attempt.ErrorSummary,
+		FailureKind:  string(attempt.FailureKind),
+		LogLines:     attempt.LogLines,
+		WorkerRuns:   attempt.WorkerRuns,
+		InputTokens:  attempt.InputTokens,
+		OutputTokens: attempt.OutputTokens,
+		CostUSD:      attempt.CostUSD,
+	}
+	if attempt.Tooling != nil {
+		rec.Tooling = &persistence.AttemptTooling{Tool: attempt.Tooling.Tool, Model: attempt.Tooling.Model}
+	}
+	if attempt.Artifacts != nil {
+		rec.Artifacts = persistence.AttemptArtifacts{Files: attempt.Artifacts.Files, Logs: attempt.Artifacts.Logs}
+		for _, d := range attempt.Artifacts.Diffs {
+			rec.Artifacts.Diffs = append(rec.Artifacts.Diffs, persistence.AttemptFileDiff{Path: d.Path, Patch: d.Patch, Truncated: d.Truncated})
+		}
+	}
+	return rec
+}
+
+// ListTaskAttempts は taskID の実行試行を開始順に返す
+func ListTaskAttempts(repo persistence.WorkspaceRepository, taskID string) ([]Attempt, error) {
+	records, err := repo.Attempts().ListAttempts(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attempts: %w", err)
+	}
+	attempts := make([]Attempt, 0, len(records))
+	for _, rec := range records {
+		attempts = append(attempts, attemptFromRecord(rec))
+	}
+	return attempts, nil
+}
+
+// GetAttempt は試行 ID の実行試行を返す
+func GetAttempt(repo persistence.WorkspaceRepository, attemptID string) (*Attempt, error) {
+	rec, err := repo.Attempts().GetAttempt(attemptID)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("attempt not found: %s: %w", attemptID, os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to load attempt: %w", err)
+	}
+	attempt := attemptFromRecord(*rec)
+	return &attempt, nil
+}
+
+// LatestAttempt はタスクの最新の実行試行を返す（試行が無ければ nil）
+func LatestAttempt(repo persistence.WorkspaceRepository, taskID string) (*Attempt, error) {
+	attempts, err := ListTaskAttempts(repo, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+	return &attempts[len(attempts)-1], nil
+}
+
+// ReadAttemptLog は試行のログを from 行目から最大 limit 行返す
+func ReadAttemptLog(repo persistence.WorkspaceRepository, attemptID string, from, limit int) (*persistence.AttemptLogPage, error) {
+	page, err := repo.Attempts().ReadLog(attemptID, from, limit)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("attempt not found: %s: %w", attemptID, os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to read attempt log: %w", err)
+	}
+	return page, nil
+}
+
+// WaitAttemptLog は ReadAttemptLog と同じだが、新しい行が無く試行が実行中の場合は
+// 行が追記されるか試行が終わるまで最大 wait 待つ（ロングポーリング用）
+func WaitAttemptLog(ctx context.Context, repo persistence.WorkspaceRepository, attemptID string, from, limit int, wait time.Duration) (*persistence.AttemptLogPage, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		page, err := ReadAttemptLog(repo, attemptID, from, limit)
+		if err != nil || len(page.Lines) > 0 || page.Complete || !time.Now().Before(deadline) {
+			return page, err
+		}
+		select {
+		case <-ctx.Done():
+			return page, nil
+		case <-time.After(attemptLogPollInterval):
+		}
+	}
+}
+
+// FollowAttemptLog は試行のログを from 行目から順に fn に渡し、試行が終わるまで新しい行を待つ（tail -f）
+// ctx の終了または fn のエラーで戻る。
+func FollowAttemptLog(ctx context.Context, repo persistence.WorkspaceRepository, attemptID string, from int, fn func(persistence.AttemptLogLine) error) error {
+	for {
+		page, err := WaitAttemptLog(ctx, repo, attemptID, from, persistence.DefaultAttemptLogPageSize, time.Minute)
+		if err != nil {
+			return err
+		}
+		for _, line := range page.Lines {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		from = page.Next
+		if page.Complete && len(page.Lines) == 0 {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// isAttemptAction は実行試行の記録アクションかどうかを返す
+func isAttemptAction(kind string) bool {
+	switch kind {
+	case persistence.ActionTaskAttemptStarted, persistence.ActionTaskSucceeded, persistence.ActionTaskFailed:
+		return true
+	}
+	return false
+}
+
+// attemptsFromActions は試行アクションを試行ごとにまとめ、開始順に返す（taskID が空なら全タスク）
+// 試行の記録（runs/）の導入前に history だけに記録された試行の移行に使う。
+func attemptsFromActions(actions []persistence.Action, taskID string) []Attempt {
+	byID := make(map[string]*Attempt)
+	var order []string
+	for _, a := range actions {
+		if !isAttemptAction(a.Kind) {
+			continue
+		}
+		var p persistence.AttemptPayload
+		if err := a.DecodePayload(&p); err != nil || p.AttemptID == "" {
+			continue
+		}
+		if taskID != "" && p.TaskID != taskID {
+			continue
+		}
+		attempt, ok := byID[p.AttemptID]
+		if !ok {
+			attempt = &Attempt{ID: p.AttemptID, TaskID: p.TaskID, Status: AttemptStatusRunning, StartedAt: a.At}
+			byID[p.AttemptID] = attempt
+			order = append(order, p.AttemptID)
+		}
+		if a.Kind == persistence.ActionTaskAttemptStarted {
+			attempt.StartedAt = a.At
+			continue
+		}
+
+		finishedAt := a.At
+		attempt.FinishedAt = &finishedAt
+		attempt.ErrorSummary = p.Error
+		switch {
+		case p.Status != "":
+			attempt.Status = AttemptStatus(p.Status)
+		case a.Kind == persistence.ActionTaskSucceeded:
+			attempt.Status = AttemptStatusSucceeded
+		default:
+			attempt.Status = AttemptStatusFailed
+		}
+	}
+
+	attempts := make([]Attempt, 0, len(order))
+	for _, id := range order {
+		attempts = append(attempts, *byID[id])
+	}
+	sort.SliceStable(attempts, func(i, j int) bool { return attempts[i].StartedAt.Before(attempts[j].StartedAt) })
+	return attempts
+}
+
+// appendAttemptAction は実行試行の開始・終了を history に追記する
+func appendAttemptAction(repo persistence.WorkspaceRepository, kind string, at time.Time, payload persistence.AttemptPayload) error {
+	action, err := persistence.NewAction(kind, workspaceIDOf(repo), at, payload)
+	if err != nil {
+		return err
+	}
+	return repo.History().AppendAction(action)
+}
+
+// attemptResultKind は試行ステータスに対応する終了アクションの種別を返す
+func attemptResultKind(status AttemptStatus) string {
+	if status == AttemptStatusSucceeded {
+		return persistence.ActionTaskSucceeded
+	}
+	return persistence.ActionTaskFailed
+}
+
+// workspaceIDOf はリポジトリのディレクトリ名（ワークスペース ID）を返す
+func workspaceIDOf(repo persistence.WorkspaceRepository) string {
+	return filepath.Base(repo.BaseDir())
+}
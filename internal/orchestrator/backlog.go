@@ -20,20 +20,23 @@ const (
 	BacklogTypeFailure  BacklogType = "FAILURE"  // タスク失敗
 	BacklogTypeQuestion BacklogType = "QUESTION" // Meta-agent からの質問
 	BacklogTypeBlocker  BacklogType = "BLOCKER"  // 外部ブロッカー
+	BacklogTypeApproval BacklogType = "APPROVAL" // 承認ゲート（approvals.go）
 )
 
 // BacklogItem はバックログアイテムを表す
 type BacklogItem struct {
-	ID          string         `json:"id"`
-	TaskID      string         `json:"taskId"`
-	Type        BacklogType    `json:"type"`
-	Title       string         `json:"title"`
-	Description string         `json:"description"`
-	Priority    int            `json:"priority"` // 1-5（5が最高）
-	CreatedAt   time.Time      `json:"createdAt"`
-	ResolvedAt  *time.Time     `json:"resolvedAt,omitempty"`
-	Resolution  string         `json:"resolution,omitempty"`
-	Metadata    map[string]any `json:"metadata,omitempty"` // エラー詳細等
+	ID          string      `json:"id"`
+	TaskID      string      `json:"taskId"`
+	Type        BacklogType `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Priority    int         `json:"priority"` // 1-5（5が最高）
+	CreatedAt   time.Time   `json:"createdAt"`
+	ResolvedAt  *time.Time  `json:"resolvedAt,omitempty"`
+	Resolution  string      `json:"resolution,omitempty"`
+	// ResolutionAction は解決時にタスクへ適用した操作（記録のみの解決では空）
+	ResolutionAction BacklogAction  `json:"resolutionAction,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"` // エラー詳細等
 }
 
 // BacklogStore はバックログアイテムを永続化する
@@ -174,15 +177,22 @@ func (s *BacklogStore) ListUnresolved() ([]BacklogItem, error) {
 }
 
 // Resolve はバックログアイテムを解決済みにする
+// 計画は変更しない（タスクへの操作を伴う解決は BacklogResolver.Resolve を使う）。
 func (s *BacklogStore) Resolve(id string, resolution string) error {
 	item, err := s.Get(id)
 	if err != nil {
 		return err
 	}
+	return s.markResolved(item, "", resolution)
+}
 
+// markResolved は item を解決済みとして保存する
+func (s *BacklogStore) markResolved(item *BacklogItem, action BacklogAction, resolution string) error {
+	id := item.ID
 	now := time.Now()
 	item.ResolvedAt = &now
 	item.Resolution = resolution
+	item.ResolutionAction = action
 
 	data, err := json.MarshalIndent(item, "", "  ")
 	if err != nil {
@@ -196,6 +206,7 @@ func (s *BacklogStore) Resolve(id string, resolution string) error {
 
 	s.logger.Info("backlog item resolved",
 		slog.String("id", id),
+		slog.String("action", string(action)),
 		slog.String("resolution", resolution),
 	)
 
@@ -230,3 +241,17 @@ func CreateFailureItem(taskID string, taskTitle string, err error, attemptCount
 		},
 	}
 }
+
+// CreateQuestionItem はタスクからの質問のバックログアイテムを作成する
+func CreateQuestionItem(taskID string, taskTitle string, question string) *BacklogItem {
+	return &BacklogItem{
+		TaskID:      taskID,
+		Type:        BacklogTypeQuestion,
+		Title:       fmt.Sprintf("質問: %s", taskTitle),
+		Description: question,
+		Priority:    3,
+		Metadata: map[string]any{
+			"question": question,
+		},
+	}
+}
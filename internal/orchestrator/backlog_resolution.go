@@ -0,0 +1,751 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/logging"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// BacklogAction はバックログアイテムの解決時にタスクへ適用する操作
+type BacklogAction string
+
+const (
+	BacklogActionNote         BacklogAction = "note"          // 記録のみ（計画は変更しない）
+	BacklogActionRetry        BacklogAction = "retry"         // そのまま再実行
+	BacklogActionRetryEdited  BacklogAction = "retry_edited"  // 説明・受け入れ条件を編集して再実行
+	BacklogActionRetryTooling BacklogAction = "retry_tooling" // tooling プロファイルを変えて再実行
+	BacklogActionSkip         BacklogAction = "skip"          // スキップして後続タスクの依存を満たす
+	BacklogActionSplit        BacklogAction = "split"         // Meta-agent で小さなタスクに分割する
+	BacklogActionAbandon      BacklogAction = "abandon"       // 放棄する（CANCELED）
+	BacklogActionAnswer       BacklogAction = "answer"        // QUESTION に回答し、待っているタスクを再開する
+	BacklogActionApprove      BacklogAction = "approve"       // APPROVAL を承認し、承認ゲートを通す
+	BacklogActionReject       BacklogAction = "reject"        // APPROVAL を却下する（開始は取り消し、差分は理由を添えて再実装）
+)
+
+// BacklogActions は既知の解決操作
+var BacklogActions = []BacklogAction{
+	BacklogActionNote,
+	BacklogActionRetry,
+	BacklogActionRetryEdited,
+	BacklogActionRetryTooling,
+	BacklogActionSkip,
+	BacklogActionSplit,
+	BacklogActionAbandon,
+	BacklogActionAnswer,
+	BacklogActionApprove,
+	BacklogActionReject,
+}
+
+// IsValid は既知の操作かどうかを返す
+func (a BacklogAction) IsValid() bool {
+	return slices.Contains(BacklogActions, a)
+}
+
+// ErrInvalidBacklogResolution はアイテムに適用できない解決の指定
+var ErrInvalidBacklogResolution = errors.New("invalid backlog resolution")
+
+// BacklogResolution はバックログアイテムの解決方法
+// Action を省略すると QUESTION は answer、それ以外は note として扱う（APPROVAL は approve / reject の指定が必要）。
+type BacklogResolution struct {
+	Action BacklogAction `json:"action,omitempty"`
+	// Note は解決メモ（answer では回答、split では分割の指示、reject では却下の理由）
+	Note string `json:"note,omitempty"`
+	// Description / AcceptanceCriteria は retry_edited で置き換える説明と受け入れ条件（nil・空は変更しない）
+	Description        *string  `json:"description,omitempty"`
+	AcceptanceCriteria []string `json:"acceptanceCriteria,omitempty"`
+	// ToolingProfile は retry_tooling で使う tooling プロファイル
+	ToolingProfile string `json:"toolingProfile,omitempty"`
+}
+
+// BacklogResolutionResult はバックログアイテムを解決した結果
+type BacklogResolutionResult struct {
+	Item           *BacklogItem `json:"item"`
+	TaskStatus     TaskStatus   `json:"taskStatus,omitempty"`     // 解決後のタスクの状態
+	CreatedTaskIDs []string     `json:"createdTaskIds,omitempty"` // split で作成したタスク
+}
+
+// TaskSplitter はタスクを Meta-agent で小さなタスクに分割し、作成したタスク ID を返す
+// 元のタスクと後続タスクの扱いは BacklogResolver が行う。
+type TaskSplitter interface {
+	SplitTask(ctx context.Context, taskID, instructions string) ([]string, error)
+}
+
+// BacklogResolver はバックログアイテムの解決をタスクと設計に適用する
+// 解決は history に backlog.resolved を追記してから state / design を更新し、
+// 更新に失敗した場合は適用した変更を元に戻して state_save_failed を記録する（plan_patch と同じ順序）。
+type BacklogResolver struct {
+	repo     persistence.WorkspaceRepository
+	store    *BacklogStore
+	events   EventEmitter
+	splitter TaskSplitter
+	logger   *slog.Logger
+}
+
+// NewBacklogResolver は BacklogResolver を作成する
+func NewBacklogResolver(repo persistence.WorkspaceRepository, store *BacklogStore, events EventEmitter) *BacklogResolver {
+	return &BacklogResolver{
+		repo:   repo,
+		store:  store,
+		events: events,
+		logger: logging.WithComponent(slog.Default(), "backlog-resolver"),
+	}
+}
+
+// SetTaskSplitter は split に使う Meta-agent を設定する（未設定なら split は使えない）
+func (r *BacklogResolver) SetTaskSplitter(splitter TaskSplitter) {
+	r.splitter = splitter
+}
+
+// Resolve はバックログアイテムを resolution の操作で解決する
+func (r *BacklogResolver) Resolve(ctx context.Context, id string, resolution BacklogResolution) (*BacklogResolutionResult, error) {
+	item, err := r.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item.ResolvedAt != nil {
+		return nil, fmt.Errorf("%w: backlog item already resolved: %s", ErrInvalidBacklogResolution, id)
+	}
+	action, err := r.validate(item, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BacklogResolutionResult{Item: item}
+	var task *persistence.TaskState
+	// マイルストーンの承認はタスクを持たない（依存するタスクはスケジューラが承認を確認して再開する）
+	if action != BacklogActionNote && item.TaskID != "" {
+		if task, err = r.loadTask(item.TaskID); err != nil {
+			return nil, err
+		}
+		if TaskStatus(task.Status) == TaskStatusRunning && action != BacklogActionAnswer {
+			return nil, fmt.Errorf("cannot %s task %s: %w", action, task.TaskID, ErrTaskRunning)
+		}
+	}
+
+	// 分割は Meta-agent の呼び出しに時間がかかり、作成は plan_patch として記録されるため先に行う
+	if action == BacklogActionSplit {
+		instructions := strings.TrimSpace(item.Description + "\n\n" + resolution.Note)
+		created, err := r.splitter.SplitTask(ctx, task.TaskID, instructions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split task: %w", err)
+		}
+		if len(created) == 0 {
+			return nil, fmt.Errorf("failed to split task: meta-agent created no tasks")
+		}
+		result.CreatedTaskIDs = created
+	}
+
+	now := time.Now()
+	historyAction, err := persistence.NewAction(persistence.ActionBacklogResolved, workspaceIDOf(r.repo), now, persistence.BacklogResolvedPayload{
+		ItemID:             item.ID,
+		TaskID:             item.TaskID,
+		ItemType:           string(item.Type),
+		Action:             string(action),
+		Note:               resolution.Note,
+		Description:        resolution.Description,
+		AcceptanceCriteria: resolution.AcceptanceCriteria,
+		ToolingProfile:     resolution.ToolingProfile,
+		CreatedTaskIDs:     result.CreatedTaskIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.repo.History().AppendAction(historyAction); err != nil {
+		return nil, fmt.Errorf("failed to append history: %w", err)
+	}
+
+	var oldStatus TaskStatus
+	var undo *resolutionUndo
+	if task != nil {
+		undo, err = r.apply(item, action, resolution, task, result, now)
+		if err != nil {
+			recordStateSaveFailed(r.repo, r.logger, historyAction.ID, stageOf(err), err)
+			return nil, err
+		}
+		oldStatus = TaskStatus(task.Status)
+	}
+	if err := r.store.markResolved(item, action, resolution.Note); err != nil {
+		// アイテムが未解決のまま残るので、適用した変更も元に戻す
+		r.rollback(undo, now)
+		recordStateSaveFailed(r.repo, r.logger, historyAction.ID, "save_backlog_item", err)
+		return nil, err
+	}
+
+	r.logger.Info("backlog item resolved with action",
+		slog.String("id", item.ID),
+		slog.String("task_id", item.TaskID),
+		slog.String("action", string(action)),
+		slog.String("task_status", string(result.TaskStatus)),
+	)
+	if r.events != nil {
+		if task != nil && result.TaskStatus != oldStatus {
+			r.events.Emit(EventTaskStateChange, TaskStateChangeEvent{
+				TaskID:    task.TaskID,
+				OldStatus: oldStatus,
+				NewStatus: result.TaskStatus,
+				Timestamp: now,
+			})
+		}
+		r.events.Emit(EventBacklogResolved, item)
+	}
+	return result, nil
+}
+
+// AskQuestion はタスクからの質問をバックログに追加し、回答されるまでタスクを BLOCKED で待たせる
+func (r *BacklogResolver) AskQuestion(taskID, question string) (*BacklogItem, error) {
+	if strings.TrimSpace(question) == "" {
+		return nil, fmt.Errorf("question is empty")
+	}
+	view, err := FindTaskView(r.repo, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := CreateQuestionItem(taskID, view.Title, question)
+	if err := r.store.Add(item); err != nil {
+		return nil, err
+	}
+
+	var oldStatus TaskStatus
+	err = r.repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+		t := findTaskState(state, taskID)
+		if t == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+		if t.Inputs == nil {
+			t.Inputs = make(map[string]interface{})
+		}
+		oldStatus = TaskStatus(t.Status)
+		t.Inputs[InputKeyAwaitingAnswer] = item.ID
+		// 実行中のタスクは実行を終えてから回答を待つ（状態は実行ループが決める）
+		if oldStatus != TaskStatusRunning {
+			t.Status = string(TaskStatusBlocked)
+		}
+		t.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		_ = r.store.Delete(item.ID)
+		return nil, fmt.Errorf("failed to save task waiting for answer: %w", err)
+	}
+
+	if r.events != nil {
+		r.events.Emit(EventBacklogAdded, item)
+		if oldStatus != TaskStatusRunning && oldStatus != TaskStatusBlocked {
+			r.events.Emit(EventTaskStateChange, TaskStateChangeEvent{
+				TaskID:    taskID,
+				OldStatus: oldStatus,
+				NewStatus: TaskStatusBlocked,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	return item, nil
+}
+
+// validate は resolution を item に適用できるか検証し、適用する操作を返す
+func (r *BacklogResolver) validate(item *BacklogItem, resolution BacklogResolution) (BacklogAction, error) {
+	action := resolution.Action
+	if action == "" {
+		action = BacklogActionNote
+		if item.Type == BacklogTypeQuestion {
+			action = BacklogActionAnswer
+		}
+	}
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidBacklogResolution, fmt.Sprintf(format, args...))
+	}
+	if !action.IsValid() {
+		return "", invalid("unknown action %q", action)
+	}
+	isApproval := action == BacklogActionApprove || action == BacklogActionReject
+	if item.Type == BacklogTypeApproval && !isApproval {
+		return "", invalid("%s items are resolved with %s or %s", BacklogTypeApproval, BacklogActionApprove, BacklogActionReject)
+	}
+	if item.Type != BacklogTypeApproval && isApproval {
+		return "", invalid("%s applies only to %s items", action, BacklogTypeApproval)
+	}
+	milestone := approvalGateOf(item) == ApprovalGateMilestone
+	if action != BacklogActionNote && item.TaskID == "" && !(milestone && action == BacklogActionApprove) {
+		return "", invalid("backlog item %s has no task to %s", item.ID, action)
+	}
+	switch action {
+	case BacklogActionRetryEdited:
+		if resolution.Description == nil && len(resolution.AcceptanceCriteria) == 0 {
+			return "", invalid("%s requires a description or acceptance criteria", action)
+		}
+	case BacklogActionRetryTooling:
+		if strings.TrimSpace(resolution.ToolingProfile) == "" {
+			return "", invalid("%s requires a tooling profile", action)
+		}
+	case BacklogActionSplit:
+		if r.splitter == nil {
+			return "", invalid("task splitting is not available (meta-agent not configured)")
+		}
+	case BacklogActionAnswer:
+		if item.Type != BacklogTypeQuestion {
+			return "", invalid("%s applies only to %s items", action, BacklogTypeQuestion)
+		}
+		if strings.TrimSpace(resolution.Note) == "" {
+			return "", invalid("%s requires an answer", action)
+		}
+	case BacklogActionReject:
+		if milestone {
+			return "", invalid("milestone approvals cannot be rejected; fix the milestone's tasks and approve it when ready")
+		}
+		if approvalGateOf(item) == ApprovalGateDiff && strings.TrimSpace(resolution.Note) == "" {
+			return "", invalid("rejecting a diff requires a reason for the re-implementation")
+		}
+	}
+	return action, nil
+}
+
+func (r *BacklogResolver) loadTask(taskID string) (*persistence.TaskState, error) {
+	state, err := r.repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	t := findTaskState(state, taskID)
+	if t == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	return t, nil
+}
+
+// apply は操作を design / nodes-runtime / tasks に適用し、適用した変更を元に戻すための undo を返す
+// 設計と実行時ステータスを先に書き換え、最後にタスクが実行中でないことを確かめてタスクを書き換える。
+// いずれかの段階で失敗した場合は書き換えた変更（split で作成したタスクを含む）を元に戻し、
+// 失敗した段階を持つ resolutionError を返す。
+// task.Status には適用直前の状態を入れて返す（状態変更イベント用）。
+func (r *BacklogResolver) apply(item *BacklogItem, action BacklogAction, resolution BacklogResolution, task *persistence.TaskState, result *BacklogResolutionResult, now time.Time) (*resolutionUndo, error) {
+	nodeID := task.NodeID
+	if nodeID == "" {
+		nodeID = task.TaskID
+	}
+	undo := &resolutionUndo{createdTaskIDs: result.CreatedTaskIDs}
+	fail := func(stage string, err error) (*resolutionUndo, error) {
+		r.rollback(undo, now)
+		return nil, &resolutionError{stage: stage, err: err}
+	}
+
+	switch action {
+	case BacklogActionRetryEdited:
+		edit := func(node *persistence.NodeDesign) error {
+			if resolution.Description != nil {
+				node.Summary = *resolution.Description
+			}
+			if len(resolution.AcceptanceCriteria) > 0 {
+				node.AcceptanceCriteria = append([]string{}, resolution.AcceptanceCriteria...)
+			}
+			node.UpdatedAt = now
+			return nil
+		}
+		err := undo.updateNode(r.repo, nodeID, edit)
+		if errors.Is(err, os.ErrNotExist) {
+			// 手動作成のタスクには設計ノードが無いので、説明の置き場として作成する
+			node := &persistence.NodeDesign{NodeID: nodeID, Name: inputString(task.Inputs, InputKeyTitle), CreatedAt: now, CreatedBy: "backlog"}
+			_ = edit(node)
+			err = undo.createNode(r.repo, node)
+		}
+		if err != nil {
+			return fail("save_node_design", fmt.Errorf("failed to update node design: %w", err))
+		}
+	case BacklogActionSplit:
+		if err := replaceNodeDependency(r.repo, nodeID, result.CreatedTaskIDs, now, undo); err != nil {
+			return fail("save_node_design", err)
+		}
+	}
+
+	switch action {
+	case BacklogActionSkip:
+		if err := setNodeRuntimeStatus(r.repo, nodeID, persistence.NodeRuntimeStatusSkipped, "backlog", "skipped from backlog", now, undo); err != nil {
+			return fail("save_nodes_runtime", err)
+		}
+	case BacklogActionSplit:
+		if err := setNodeRuntimeStatus(r.repo, nodeID, persistence.NodeRuntimeStatusObsolete, "backlog", "split into "+strings.Join(result.CreatedTaskIDs, ", "), now, undo); err != nil {
+			return fail("save_nodes_runtime", err)
+		}
+	}
+
+	var before persistence.TaskState
+	err := r.repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+		t := findTaskState(state, task.TaskID)
+		if t == nil {
+			return fmt.Errorf("task not found: %s", task.TaskID)
+		}
+		running := TaskStatus(t.Status) == TaskStatusRunning
+		if running && action != BacklogActionAnswer {
+			return fmt.Errorf("cannot %s task %s: %w", action, t.TaskID, ErrTaskRunning)
+		}
+		before = cloneTaskState(t)
+		if t.Inputs == nil {
+			t.Inputs = make(map[string]interface{})
+		}
+		task.Status = t.Status
+		t.UpdatedAt = now
+
+		switch action {
+		case BacklogActionRetry, BacklogActionRetryEdited, BacklogActionRetryTooling:
+			if action == BacklogActionRetryTooling {
+				t.Inputs[InputKeyToolingProfile] = strings.TrimSpace(resolution.ToolingProfile)
+			}
+			// 再実行はリトライポリシーの試行回数を数え直す
+			delete(t.Inputs, InputKeyAttemptCount)
+			delete(t.Inputs, InputKeyNextRetryAt)
+			t.Status = string(TaskStatusPending)
+			t.DoneAt = nil
+		case BacklogActionSkip:
+			t.Status = string(TaskStatusSkipped)
+			t.DoneAt = &now
+		case BacklogActionSplit, BacklogActionAbandon:
+			t.Status = string(TaskStatusCanceled)
+			t.DoneAt = &now
+		case BacklogActionAnswer:
+			appendTaskAnswer(t.Inputs, TaskAnswer{Question: questionOf(item), Answer: resolution.Note, AnsweredAt: now})
+			// 回答を待っているタスクだけを再開する（それ以外は次の試行で回答を渡す）
+			if inputString(t.Inputs, InputKeyAwaitingAnswer) == item.ID {
+				delete(t.Inputs, InputKeyAwaitingAnswer)
+				if !running {
+					t.Status = string(TaskStatusPending)
+					t.DoneAt = nil
+				}
+			}
+		case BacklogActionApprove, BacklogActionReject:
+			// 承認を待っているタスクにだけ適用する（承認待ちでなくなったタスクは変更しない）
+			if inputString(t.Inputs, InputKeyAwaitingApproval) != item.ID {
+				break
+			}
+			delete(t.Inputs, InputKeyAwaitingApproval)
+			gate := approvalGateOf(item)
+			if action == BacklogActionApprove {
+				if gate == ApprovalGateDiff {
+					// ノードを implemented にしてタスクを完了させるのは実行ループ（approval_gate を残して待つ）
+					break
+				}
+				delete(t.Inputs, InputKeyApprovalGate)
+				if approved := inputStrings(t.Inputs, InputKeyApprovedGates); !containsString(approved, string(gate)) {
+					t.Inputs[InputKeyApprovedGates] = append(approved, string(gate))
+				}
+				t.Status = string(TaskStatusPending)
+				t.DoneAt = nil
+				break
+			}
+			delete(t.Inputs, InputKeyApprovalGate)
+			if gate == ApprovalGateDiff {
+				// 却下の理由を回答として渡して再実装する
+				appendTaskAnswer(t.Inputs, TaskAnswer{Question: "差分が却下されました。指摘に従って実装し直してください。", Answer: resolution.Note, AnsweredAt: now})
+				delete(t.Inputs, InputKeyAttemptCount)
+				delete(t.Inputs, InputKeyNextRetryAt)
+				t.Status = string(TaskStatusPending)
+				t.DoneAt = nil
+				break
+			}
+			t.Status = string(TaskStatusCanceled)
+			t.DoneAt = &now
+		}
+		result.TaskStatus = TaskStatus(t.Status)
+		return nil
+	})
+	if err != nil {
+		return fail("save_tasks_state", fmt.Errorf("failed to save tasks state: %w", err))
+	}
+	undo.task, undo.taskStatus = &before, string(result.TaskStatus)
+	return undo, nil
+}
+
+// rollback は undo の変更を元に戻す（元に戻せなかった場合はログに残す）
+func (r *BacklogResolver) rollback(undo *resolutionUndo, now time.Time) {
+	if undo == nil {
+		return
+	}
+	if err := undo.rollback(r.repo, now); err != nil {
+		r.logger.Error("failed to roll back backlog resolution", slog.Any("error", err))
+	}
+}
+
+// resolutionError は解決の適用に失敗した段階（state_save_failed の stage）を持つエラー
+type resolutionError struct {
+	stage string
+	err   error
+}
+
+func (e *resolutionError) Error() string { return e.err.Error() }
+func (e *resolutionError) Unwrap() error { return e.err }
+
+// stageOf は apply のエラーから失敗した段階を返す
+func stageOf(err error) string {
+	var re *resolutionError
+	if errors.As(err, &re) {
+		return re.stage
+	}
+	return "apply"
+}
+
+// resolutionUndo は解決で書き換えた設計・実行時ステータス・タスクの元の値を記録する
+// 各ドキュメントはそれぞれのロック内で書き換える直前の値を記録する。tasks のロックを保持したまま
+// 設計を書くとスナップショットのロック順（パスの昇順）と逆になるため、まとめてロックはしない。
+type resolutionUndo struct {
+	nodeIDs        []string                           // 書き換えたノード（書き換えた順）
+	nodes          map[string]*persistence.NodeDesign // 書き換える前の設計（nil は解決で作成したノード）
+	runtimeID      string
+	runtime        *persistence.NodeRuntime // 書き換える前の実行時ステータス（nil は解決で追加したエントリ）
+	runtimeSet     bool
+	task           *persistence.TaskState // 書き換える前のタスク
+	taskStatus     string                 // 書き換えた後のタスクの状態
+	createdTaskIDs []string               // split で Meta-agent が作成したタスク
+}
+
+// recordNode は nodeID を最初に書き換える前の設計を記録する
+func (u *resolutionUndo) recordNode(nodeID string, before *persistence.NodeDesign) {
+	if u == nil {
+		return
+	}
+	if u.nodes == nil {
+		u.nodes = make(map[string]*persistence.NodeDesign)
+	}
+	if _, ok := u.nodes[nodeID]; ok {
+		return
+	}
+	u.nodes[nodeID] = before
+	u.nodeIDs = append(u.nodeIDs, nodeID)
+}
+
+// updateNode は UpdateNode で設計を書き換え、書き換える前の値を記録する（u が nil なら記録しない）
+func (u *resolutionUndo) updateNode(repo persistence.WorkspaceRepository, nodeID string, fn func(node *persistence.NodeDesign) error) error {
+	var before persistence.NodeDesign
+	changed := false
+	err := repo.Design().UpdateNode(nodeID, func(node *persistence.NodeDesign) error {
+		before = *node
+		if err := fn(node); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	})
+	if err == nil && changed {
+		u.recordNode(nodeID, &before)
+	}
+	return err
+}
+
+// createNode は設計を新規に保存し、解決で作成したノードとして記録する
+func (u *resolutionUndo) createNode(repo persistence.WorkspaceRepository, node *persistence.NodeDesign) error {
+	if err := repo.Design().SaveNode(node); err != nil {
+		return err
+	}
+	u.recordNode(node.NodeID, nil)
+	return nil
+}
+
+// rollback は記録した変更を新しいものから順に元に戻す
+// 元に戻すまでに他の書き込み手が状態を変えたタスクはそのままにし、split で作成したタスクは取り消す。
+func (u *resolutionUndo) rollback(repo persistence.WorkspaceRepository, now time.Time) error {
+	var errs []error
+	if u.task != nil || len(u.createdTaskIDs) > 0 {
+		err := repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+			changed := false
+			if u.task != nil {
+				if t := findTaskState(state, u.task.TaskID); t != nil && t.Status == u.taskStatus {
+					*t = cloneTaskState(u.task)
+					changed = true
+				}
+			}
+			for _, id := range u.createdTaskIDs {
+				t := findTaskState(state, id)
+				if t == nil || TaskStatus(t.Status) == TaskStatusRunning || isTerminalTaskStatus(t.Status) {
+					continue
+				}
+				t.Status = string(TaskStatusCanceled)
+				t.DoneAt = &now
+				t.UpdatedAt = now
+				changed = true
+			}
+			if !changed {
+				return persistence.ErrNoChange
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore tasks state: %w", err))
+		}
+	}
+	if u.runtimeSet {
+		err := repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+			for i := range nodesRuntime.Nodes {
+				if nodesRuntime.Nodes[i].NodeID != u.runtimeID {
+					continue
+				}
+				if u.runtime == nil {
+					nodesRuntime.Nodes = slices.Delete(nodesRuntime.Nodes, i, i+1)
+				} else {
+					nodesRuntime.Nodes[i] = *u.runtime
+				}
+				return nil
+			}
+			return persistence.ErrNoChange
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore nodes runtime: %w", err))
+		}
+	}
+	for i := len(u.nodeIDs) - 1; i >= 0; i-- {
+		id := u.nodeIDs[i]
+		before := u.nodes[id]
+		var err error
+		if before == nil {
+			err = repo.Design().DeleteNode(id)
+		} else {
+			err = repo.Design().UpdateNode(id, func(node *persistence.NodeDesign) error {
+				version := node.Version
+				*node = *before
+				node.Version = version
+				return nil
+			})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore node design %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cloneTaskState は Inputs を別の map にしたタスクのコピーを返す
+func cloneTaskState(t *persistence.TaskState) persistence.TaskState {
+	c := *t
+	c.Inputs = maps.Clone(t.Inputs)
+	return c
+}
+
+// replaceNodeDependency は nodeID に依存するノードの依存を分割後のノードへ付け替え、
+// 分割後のノードに元のノードの依存を引き継ぐ（undo が nil でなければ書き換える前の設計を記録する）
+func replaceNodeDependency(repo persistence.WorkspaceRepository, nodeID string, createdIDs []string, now time.Time, undo *resolutionUndo) error {
+	original, err := repo.Design().GetNode(nodeID)
+	if err != nil {
+		return fmt.Errorf("failed to load node design: %w", err)
+	}
+	for _, id := range createdIDs {
+		err := undo.updateNode(repo, id, func(node *persistence.NodeDesign) error {
+			changed := false
+			for _, dep := range original.Dependencies {
+				if !slices.Contains(node.Dependencies, dep) {
+					node.Dependencies = append(node.Dependencies, dep)
+					changed = true
+				}
+			}
+			if !changed {
+				return persistence.ErrNoChange
+			}
+			node.UpdatedAt = now
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update split node %s: %w", id, err)
+		}
+	}
+
+	wbs, err := repo.Design().LoadWBS()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load wbs: %w", err)
+	}
+	for _, n := range wbs.NodeIndex {
+		if n.NodeID == nodeID || slices.Contains(createdIDs, n.NodeID) {
+			continue
+		}
+		node, err := repo.Design().GetNode(n.NodeID)
+		if err != nil || !slices.Contains(node.Dependencies, nodeID) {
+			continue
+		}
+		err = undo.updateNode(repo, n.NodeID, func(node *persistence.NodeDesign) error {
+			deps := make([]string, 0, len(node.Dependencies)+len(createdIDs))
+			add := func(dep string) {
+				if !slices.Contains(deps, dep) {
+					deps = append(deps, dep)
+				}
+			}
+			for _, dep := range node.Dependencies {
+				if dep != nodeID {
+					add(dep)
+					continue
+				}
+				for _, id := range createdIDs {
+					add(id)
+				}
+			}
+			node.Dependencies = deps
+			node.UpdatedAt = now
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update dependent node %s: %w", n.NodeID, err)
+		}
+	}
+	return nil
+}
+
+// setNodeRuntimeStatus はノードの実行時ステータスを変更し、by / note をノートに残す（無ければ作成する）
+// undo が nil でなければ書き換える前のエントリを記録する。
+func setNodeRuntimeStatus(repo persistence.WorkspaceRepository, nodeID string, status persistence.NodeRuntimeStatus, by, note string, now time.Time, undo *resolutionUndo) error {
+	var before *persistence.NodeRuntime
+	err := repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		before = nil
+		nodeNote := persistence.NodeNote{At: now, By: by, Text: note}
+		for i := range nodesRuntime.Nodes {
+			if nodesRuntime.Nodes[i].NodeID == nodeID {
+				prev := nodesRuntime.Nodes[i]
+				before = &prev
+				nodesRuntime.Nodes[i].Status = string(status)
+				nodesRuntime.Nodes[i].Notes = append(nodesRuntime.Nodes[i].Notes, nodeNote)
+				return nil
+			}
+		}
+		nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
+			NodeID:       nodeID,
+			Status:       string(status),
+			Verification: persistence.NodeVerification{Status: "not_tested"},
+			Notes:        []persistence.NodeNote{nodeNote},
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save nodes runtime: %w", err)
+	}
+	if undo != nil && !undo.runtimeSet {
+		undo.runtimeID, undo.runtime, undo.runtimeSet = nodeID, before, true
+	}
+	return nil
+}
+
+// recordStateSaveFailed は history に追記したアクションの適用に失敗したことを記録する
+func recordStateSaveFailed(repo persistence.WorkspaceRepository, logger *slog.Logger, actionID, stage string, cause error) {
+	action, err := persistence.NewAction(persistence.ActionStateSaveFailed, workspaceIDOf(repo), time.Now(), persistence.StateSaveFailedPayload{
+		OriginalActionIDs: []string{actionID},
+		Stage:             stage,
+		Error:             cause.Error(),
+	})
+	if err == nil {
+		err = repo.History().AppendAction(action)
+	}
+	if err != nil {
+		logger.Warn("failed to record state save failure", slog.String("action_id", actionID), slog.Any("error", err))
+	}
+}
+
+// questionOf は QUESTION アイテムの質問文を返す
+func questionOf(item *BacklogItem) string {
+	if q, ok := item.Metadata["question"].(string); ok && q != "" {
+		return q
+	}
+	return item.Description
+}
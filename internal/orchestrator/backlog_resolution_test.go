@@ -0,0 +1,398 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTaskSplitter struct {
+	repo    persistence.WorkspaceRepository
+	created []string
+	calls   []string
+	// onSplit は分割の後に呼ばれる（Meta-agent の呼び出し中に起きた変更を再現する）
+	onSplit func()
+}
+
+func (f *fakeTaskSplitter) SplitTask(_ context.Context, taskID, instructions string) ([]string, error) {
+	f.calls = append(f.calls, taskID+": "+instructions)
+	for _, id := range f.created {
+		if err := f.repo.Design().SaveNode(&persistence.NodeDesign{NodeID: id, Name: id}); err != nil {
+			return nil, err
+		}
+	}
+	if f.onSplit != nil {
+		f.onSplit()
+	}
+	return f.created, nil
+}
+
+func setupBacklogResolver(t *testing.T) (*BacklogResolver, persistence.WorkspaceRepository, *BacklogStore) {
+	repo, _ := setupTestRepo(t)
+	store := NewBacklogStore(repo.BaseDir())
+	return NewBacklogResolver(repo, store, nil), repo, store
+}
+
+func addFailureItem(t *testing.T, store *BacklogStore, id, taskID string) {
+	require.NoError(t, store.Add(&BacklogItem{ID: id, TaskID: taskID, Type: BacklogTypeFailure, Title: "failed"}))
+}
+
+func loadTaskState(t *testing.T, repo persistence.WorkspaceRepository, taskID string) persistence.TaskState {
+	state, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	ts := findTaskState(state, taskID)
+	require.NotNil(t, ts, "task %s not found", taskID)
+	return *ts
+}
+
+func TestBacklogResolver_Retry(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveState(t, repo, []persistence.TaskState{{
+		TaskID: "task-1", NodeID: "task-1", Status: string(TaskStatusFailed),
+		Inputs: map[string]interface{}{InputKeyAttemptCount: 3, InputKeyNextRetryAt: time.Now().Format(time.RFC3339)},
+	}}, nil)
+	addFailureItem(t, store, "bl-1", "task-1")
+
+	result, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionRetry, Note: "flaky"})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusPending, result.TaskStatus)
+
+	ts := loadTaskState(t, repo, "task-1")
+	assert.Equal(t, string(TaskStatusPending), ts.Status)
+	assert.NotContains(t, ts.Inputs, InputKeyAttemptCount)
+	assert.NotContains(t, ts.Inputs, InputKeyNextRetryAt)
+
+	item, err := store.Get("bl-1")
+	require.NoError(t, err)
+	assert.NotNil(t, item.ResolvedAt)
+	assert.Equal(t, BacklogActionRetry, item.ResolutionAction)
+	assert.Equal(t, "flaky", item.Resolution)
+
+	actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	var payload persistence.BacklogResolvedPayload
+	for _, a := range actions {
+		if a.Kind == persistence.ActionBacklogResolved {
+			require.NoError(t, a.DecodePayload(&payload))
+		}
+	}
+	assert.Equal(t, "bl-1", payload.ItemID)
+	assert.Equal(t, "retry", payload.Action)
+
+	// 解決済みのアイテムは再度解決できない
+	_, err = resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionRetry})
+	assert.ErrorIs(t, err, ErrInvalidBacklogResolution)
+}
+
+func TestBacklogResolver_RetryEditedAndTooling(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveDesign(t, repo, []persistence.NodeDesign{{NodeID: "task-1", Summary: "old", AcceptanceCriteria: []string{"old"}}})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "task-1", Status: string(TaskStatusFailed)},
+		{TaskID: "task-2", Status: string(TaskStatusFailed), Inputs: map[string]interface{}{InputKeyTitle: "manual"}},
+	}, nil)
+	addFailureItem(t, store, "bl-1", "task-1")
+	addFailureItem(t, store, "bl-2", "task-2")
+	addFailureItem(t, store, "bl-3", "task-2")
+
+	description := "new description"
+	_, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{
+		Action:             BacklogActionRetryEdited,
+		Description:        &description,
+		AcceptanceCriteria: []string{"tests pass"},
+	})
+	require.NoError(t, err)
+	node, err := repo.Design().GetNode("task-1")
+	require.NoError(t, err)
+	assert.Equal(t, "new description", node.Summary)
+	assert.Equal(t, []string{"tests pass"}, node.AcceptanceCriteria)
+
+	// 設計ノードが無いタスクはノードを作成して説明を置く
+	_, err = resolver.Resolve(context.Background(), "bl-2", BacklogResolution{Action: BacklogActionRetryEdited, Description: &description})
+	require.NoError(t, err)
+	node, err = repo.Design().GetNode("task-2")
+	require.NoError(t, err)
+	assert.Equal(t, "manual", node.Name)
+	assert.Equal(t, "new description", node.Summary)
+
+	_, err = resolver.Resolve(context.Background(), "bl-3", BacklogResolution{Action: BacklogActionRetryTooling, ToolingProfile: "fast"})
+	require.NoError(t, err)
+	ts := loadTaskState(t, repo, "task-2")
+	assert.Equal(t, string(TaskStatusPending), ts.Status)
+	assert.Equal(t, "fast", ts.Inputs[InputKeyToolingProfile])
+}
+
+func TestBacklogResolver_SkipUnblocksDependents(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "task-1"},
+		{NodeID: "task-2", Dependencies: []string{"task-1"}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "task-1", Status: string(TaskStatusFailed)},
+		{TaskID: "task-2", NodeID: "task-2", Status: string(TaskStatusBlocked)},
+	}, []persistence.NodeRuntime{{NodeID: "task-1", Status: string(persistence.NodeRuntimeStatusInProgress)}})
+	addFailureItem(t, store, "bl-1", "task-1")
+
+	result, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionSkip})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusSkipped, result.TaskStatus)
+
+	ts := loadTaskState(t, repo, "task-1")
+	assert.NotNil(t, ts.DoneAt)
+
+	scheduler := NewScheduler(repo, nil, nil)
+	unblocked, err := scheduler.UpdateBlockedTasks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-2"}, unblocked)
+	assert.Equal(t, string(TaskStatusPending), loadTaskState(t, repo, "task-2").Status)
+}
+
+func TestBacklogResolver_Abandon(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", Status: string(TaskStatusFailed)},
+		{TaskID: "task-2", Status: string(TaskStatusRunning)},
+	}, nil)
+	addFailureItem(t, store, "bl-1", "task-1")
+	addFailureItem(t, store, "bl-2", "task-2")
+
+	result, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionAbandon})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusCanceled, result.TaskStatus)
+
+	// 実行中のタスクは解決で変更しない
+	_, err = resolver.Resolve(context.Background(), "bl-2", BacklogResolution{Action: BacklogActionAbandon})
+	assert.ErrorIs(t, err, ErrTaskRunning)
+	item, err := store.Get("bl-2")
+	require.NoError(t, err)
+	assert.Nil(t, item.ResolvedAt)
+}
+
+func TestBacklogResolver_Split(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "base"},
+		{NodeID: "task-1", Dependencies: []string{"base"}},
+		{NodeID: "task-2", Dependencies: []string{"task-1"}},
+	})
+	require.NoError(t, repo.Design().SaveWBS(&persistence.WBS{NodeIndex: []persistence.NodeIndex{
+		{NodeID: "base"}, {NodeID: "task-1"}, {NodeID: "task-2"},
+	}}))
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "task-1", Status: string(TaskStatusFailed)},
+		{TaskID: "task-2", NodeID: "task-2", Status: string(TaskStatusBlocked)},
+	}, nil)
+	addFailureItem(t, store, "bl-1", "task-1")
+
+	// Meta-agent が無ければ分割できない
+	_, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionSplit})
+	assert.ErrorIs(t, err, ErrInvalidBacklogResolution)
+
+	splitter := &fakeTaskSplitter{repo: repo, created: []string{"task-1a", "task-1b"}}
+	resolver.SetTaskSplitter(splitter)
+	result, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionSplit, Note: "API と UI に分ける"})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusCanceled, result.TaskStatus)
+	assert.Equal(t, []string{"task-1a", "task-1b"}, result.CreatedTaskIDs)
+	require.Len(t, splitter.calls, 1)
+	assert.Contains(t, splitter.calls[0], "API と UI に分ける")
+
+	node, err := repo.Design().GetNode("task-1a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base"}, node.Dependencies)
+	node, err = repo.Design().GetNode("task-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-1a", "task-1b"}, node.Dependencies)
+
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	require.NoError(t, err)
+	require.Len(t, nodesRuntime.Nodes, 1)
+	assert.Equal(t, string(persistence.NodeRuntimeStatusObsolete), nodesRuntime.Nodes[0].Status)
+}
+
+func TestBacklogResolver_SplitRollsBackWhenTaskStartsRunning(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "base"},
+		{NodeID: "task-1", Dependencies: []string{"base"}},
+		{NodeID: "task-2", Dependencies: []string{"task-1"}},
+	})
+	require.NoError(t, repo.Design().SaveWBS(&persistence.WBS{NodeIndex: []persistence.NodeIndex{
+		{NodeID: "base"}, {NodeID: "task-1"}, {NodeID: "task-2"},
+	}}))
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "task-1", Status: string(TaskStatusFailed)},
+		{TaskID: "task-2", NodeID: "task-2", Status: string(TaskStatusBlocked)},
+	}, []persistence.NodeRuntime{{NodeID: "task-1", Status: string(persistence.NodeRuntimeStatusInProgress)}})
+	addFailureItem(t, store, "bl-1", "task-1")
+
+	splitter := &fakeTaskSplitter{repo: repo, created: []string{"task-1a", "task-1b"}}
+	// Meta-agent が分割している間に、別の経路で元のタスクの実行が始まった
+	splitter.onSplit = func() {
+		require.NoError(t, repo.State().UpdateTasks(func(s *persistence.TasksState) error {
+			findTaskState(s, "task-1").Status = string(TaskStatusRunning)
+			for _, id := range splitter.created {
+				s.Tasks = append(s.Tasks, persistence.TaskState{TaskID: id, NodeID: id, Status: string(TaskStatusPending)})
+			}
+			return nil
+		}))
+	}
+	resolver.SetTaskSplitter(splitter)
+
+	_, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionSplit})
+	require.ErrorIs(t, err, ErrTaskRunning)
+
+	item, err := store.Get("bl-1")
+	require.NoError(t, err)
+	assert.Nil(t, item.ResolvedAt)
+	assert.Equal(t, string(TaskStatusRunning), loadTaskState(t, repo, "task-1").Status)
+
+	// 依存の付け替えと obsolete は元に戻し、分割で作成したタスクは取り消す
+	node, err := repo.Design().GetNode("task-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-1"}, node.Dependencies)
+	node, err = repo.Design().GetNode("task-1a")
+	require.NoError(t, err)
+	assert.Empty(t, node.Dependencies)
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	require.NoError(t, err)
+	require.Len(t, nodesRuntime.Nodes, 1)
+	assert.Equal(t, string(persistence.NodeRuntimeStatusInProgress), nodesRuntime.Nodes[0].Status)
+	assert.Empty(t, nodesRuntime.Nodes[0].Notes)
+	for _, id := range splitter.created {
+		assert.Equal(t, string(TaskStatusCanceled), loadTaskState(t, repo, id).Status)
+	}
+}
+
+// startingDesignRepo は設計の保存の直後にタスクの実行を始めさせる
+type startingDesignRepo struct {
+	persistence.DesignRepository
+	start func()
+}
+
+func (d *startingDesignRepo) SaveNode(node *persistence.NodeDesign) error {
+	if err := d.DesignRepository.SaveNode(node); err != nil {
+		return err
+	}
+	d.start()
+	return nil
+}
+
+type startingRepo struct {
+	persistence.WorkspaceRepository
+	design *startingDesignRepo
+}
+
+func (r *startingRepo) Design() persistence.DesignRepository { return r.design }
+
+func TestBacklogResolver_RetryEditedRollsBackCreatedNode(t *testing.T) {
+	base, _ := setupTestRepo(t)
+	saveState(t, base, []persistence.TaskState{
+		{TaskID: "task-1", Status: string(TaskStatusFailed), Inputs: map[string]interface{}{InputKeyTitle: "manual"}},
+	}, nil)
+	repo := &startingRepo{WorkspaceRepository: base, design: &startingDesignRepo{DesignRepository: base.Design(), start: func() {
+		require.NoError(t, base.State().UpdateTasks(func(s *persistence.TasksState) error {
+			findTaskState(s, "task-1").Status = string(TaskStatusRunning)
+			return nil
+		}))
+	}}}
+	store := NewBacklogStore(base.BaseDir())
+	resolver := NewBacklogResolver(repo, store, nil)
+	addFailureItem(t, store, "bl-1", "task-1")
+
+	description := "new description"
+	_, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Action: BacklogActionRetryEdited, Description: &description})
+	require.ErrorIs(t, err, ErrTaskRunning)
+
+	_, err = base.Design().GetNode("task-1")
+	assert.ErrorIs(t, err, os.ErrNotExist, "the node created for the edit is removed")
+	item, err := store.Get("bl-1")
+	require.NoError(t, err)
+	assert.Nil(t, item.ResolvedAt)
+
+	actions, err := base.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	var stage string
+	for _, a := range actions {
+		if a.Kind == persistence.ActionStateSaveFailed {
+			var p persistence.StateSaveFailedPayload
+			require.NoError(t, a.DecodePayload(&p))
+			stage = p.Stage
+		}
+	}
+	assert.Equal(t, "save_tasks_state", stage)
+}
+
+func TestBacklogResolver_AnswerQuestion(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveState(t, repo, []persistence.TaskState{{TaskID: "task-1", Status: string(TaskStatusPending)}}, nil)
+
+	item, err := resolver.AskQuestion("task-1", "どの DB を使いますか？")
+	require.NoError(t, err)
+	assert.Equal(t, BacklogTypeQuestion, item.Type)
+	ts := loadTaskState(t, repo, "task-1")
+	assert.Equal(t, string(TaskStatusBlocked), ts.Status)
+	assert.Equal(t, item.ID, ts.Inputs[InputKeyAwaitingAnswer])
+
+	// 回答を待つタスクは依存の解消で自動的に再開しない
+	unblocked, err := NewScheduler(repo, nil, nil).UpdateBlockedTasks()
+	require.NoError(t, err)
+	assert.Empty(t, unblocked)
+
+	// 回答が無ければ解決できない
+	_, err = resolver.Resolve(context.Background(), item.ID, BacklogResolution{})
+	assert.ErrorIs(t, err, ErrInvalidBacklogResolution)
+
+	result, err := resolver.Resolve(context.Background(), item.ID, BacklogResolution{Note: "PostgreSQL"})
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusPending, result.TaskStatus)
+	assert.Equal(t, BacklogActionAnswer, result.Item.ResolutionAction)
+
+	ts = loadTaskState(t, repo, "task-1")
+	assert.NotContains(t, ts.Inputs, InputKeyAwaitingAnswer)
+	answers := taskAnswersFromInputs(ts.Inputs)
+	require.Len(t, answers, 1)
+	assert.Equal(t, "どの DB を使いますか？", answers[0].Question)
+	assert.Equal(t, "PostgreSQL", answers[0].Answer)
+
+	_, err = store.Get(item.ID)
+	require.NoError(t, err)
+}
+
+func TestBacklogResolver_Validate(t *testing.T) {
+	resolver, repo, store := setupBacklogResolver(t)
+	saveState(t, repo, []persistence.TaskState{{TaskID: "task-1", Status: string(TaskStatusFailed)}}, nil)
+	addFailureItem(t, store, "bl-1", "task-1")
+	require.NoError(t, store.Add(&BacklogItem{ID: "bl-2", Type: BacklogTypeBlocker, Title: "no task"}))
+
+	tests := []struct {
+		name       string
+		id         string
+		resolution BacklogResolution
+	}{
+		{"unknown action", "bl-1", BacklogResolution{Action: "bogus"}},
+		{"retry_edited without changes", "bl-1", BacklogResolution{Action: BacklogActionRetryEdited}},
+		{"retry_tooling without profile", "bl-1", BacklogResolution{Action: BacklogActionRetryTooling}},
+		{"answer on failure", "bl-1", BacklogResolution{Action: BacklogActionAnswer, Note: "x"}},
+		{"retry without task", "bl-2", BacklogResolution{Action: BacklogActionRetry}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolver.Resolve(context.Background(), tt.id, tt.resolution)
+			assert.True(t, errors.Is(err, ErrInvalidBacklogResolution), "got %v", err)
+		})
+	}
+
+	// 操作を指定しなければメモとして記録し、タスクは変えない
+	result, err := resolver.Resolve(context.Background(), "bl-1", BacklogResolution{Note: "investigating"})
+	require.NoError(t, err)
+	assert.Empty(t, result.TaskStatus)
+	assert.Equal(t, string(TaskStatusFailed), loadTaskState(t, repo, "task-1").Status)
+}
@@ -0,0 +1,43 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		require.NoError(t, err)
+		return v
+	}
+	cases := []struct {
+		expr  string
+		after string
+		want  string
+	}{
+		{"@daily", "2025-12-11 07:30", "2025-12-12 00:00"},
+		{"*/15 * * * *", "2025-12-11 07:30", "2025-12-11 07:45"},
+		{"0 3 * * mon-fri", "2025-12-12 04:00", "2025-12-15 03:00"}, // 金曜の 3 時を過ぎたら次は月曜
+		{"0 9 1 * 0", "2025-12-02 10:00", "2025-12-07 09:00"},       // 日と曜日は OR
+		{"30 2 * * 7", "2025-12-11 00:00", "2025-12-14 02:30"},      // 7 は日曜日
+		{"0 0 29 feb *", "2025-03-01 00:00", "2028-02-29 00:00"},
+	}
+	for _, tc := range cases {
+		cron, err := ParseCron(tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, at(tc.want), cron.Next(at(tc.after)), tc.expr)
+	}
+
+	cron, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, cron.Next(at("2025-01-01 00:00")).IsZero(), "impossible dates never match")
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "0 0 * * fun", "5-1 * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
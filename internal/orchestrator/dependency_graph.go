@@ -0,0 +1,350 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// ErrDependencyCycle は依存グラフに循環があることを表す
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// DependencyGraph はノード設計の依存関係（NodeDesign.Dependencies）のグラフ
+// 辺は「ノード → 依存先」の向きで持ち、グラフに無いノードへの依存は未知の参照として扱う。
+type DependencyGraph struct {
+	deps    map[string][]string
+	weights map[string]int
+}
+
+// NewDependencyGraph は空の依存グラフを生成する
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		deps:    make(map[string][]string),
+		weights: make(map[string]int),
+	}
+}
+
+// SetNode はノードとその依存先を設定する（既にあれば置き換える）
+// weight はクリティカルパスの重みで、1 未満は 1 として扱う。
+func (g *DependencyGraph) SetNode(nodeID string, deps []string, weight int) {
+	unique := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if dep != "" && !slices.Contains(unique, dep) {
+			unique = append(unique, dep)
+		}
+	}
+	g.deps[nodeID] = unique
+	g.weights[nodeID] = max(weight, 1)
+}
+
+// Has はノードがグラフにあるかを返す
+func (g *DependencyGraph) Has(nodeID string) bool {
+	_, ok := g.deps[nodeID]
+	return ok
+}
+
+// NodeIDs はノード ID を昇順で返す
+func (g *DependencyGraph) NodeIDs() []string {
+	return sortedKeys(g.deps)
+}
+
+// DependencyRef はノードから依存先への参照
+type DependencyRef struct {
+	NodeID    string `json:"nodeId"`
+	DependsOn string `json:"dependsOn"`
+}
+
+// UnknownReferences はグラフに無いノードへの依存を返す
+func (g *DependencyGraph) UnknownReferences() []DependencyRef {
+	var refs []DependencyRef
+	for _, id := range g.NodeIDs() {
+		for _, dep := range g.deps[id] {
+			if !g.Has(dep) {
+				refs = append(refs, DependencyRef{NodeID: id, DependsOn: dep})
+			}
+		}
+	}
+	return refs
+}
+
+// Cycles は循環（強連結成分のうち 2 ノード以上のもの・自己依存）をノード ID の昇順で返す
+func (g *DependencyGraph) Cycles() [][]string {
+	// Tarjan の強連結成分分解
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	next := 0
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = next
+		lowlink[id] = next
+		next++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, dep := range g.deps[id] {
+			if !g.Has(dep) {
+				continue
+			}
+			if _, seen := index[dep]; !seen {
+				visit(dep)
+				lowlink[id] = min(lowlink[id], lowlink[dep])
+			} else if onStack[dep] {
+				lowlink[id] = min(lowlink[id], index[dep])
+			}
+		}
+
+		if lowlink[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		if len(component) > 1 || slices.Contains(g.deps[id], id) {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, id := range g.NodeIDs() {
+		if _, seen := index[id]; !seen {
+			visit(id)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// TopologicalOrder は依存先が先に来る順でノード ID を返す（同順位はノード ID の昇順）
+// 循環がある場合は循環とその後続を除いた順序と ErrDependencyCycle を返す。
+func (g *DependencyGraph) TopologicalOrder() ([]string, error) {
+	remaining := make(map[string]int, len(g.deps))
+	dependents := g.dependents()
+	var ready []string
+	for _, id := range g.NodeIDs() {
+		n := 0
+		for _, dep := range g.deps[id] {
+			if g.Has(dep) {
+				n++
+			}
+		}
+		remaining[id] = n
+		if n == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := make([]string, 0, len(g.deps))
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, dependent := range dependents[id] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				i, _ := slices.BinarySearch(ready, dependent)
+				ready = slices.Insert(ready, i, dependent)
+			}
+		}
+	}
+	if len(order) < len(g.deps) {
+		return order, ErrDependencyCycle
+	}
+	return order, nil
+}
+
+// Dependents は nodeID に直接・間接に依存するノードを昇順で返す
+func (g *DependencyGraph) Dependents(nodeID string) []string {
+	dependents := g.dependents()
+	seen := map[string]bool{nodeID: true}
+	queue := []string{nodeID}
+	var out []string
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[id] {
+			if seen[dependent] {
+				continue
+			}
+			seen[dependent] = true
+			out = append(out, dependent)
+			queue = append(queue, dependent)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// CriticalPath は完了していないノードのうち、重みの合計が最大になる依存の連鎖を依存先から順に返す
+// done が true を返すノードは完了済みとして除く。循環に含まれるノードとその後続は対象外。
+func (g *DependencyGraph) CriticalPath(done func(nodeID string) bool) ([]string, int) {
+	order, _ := g.TopologicalOrder()
+	length := make(map[string]int, len(order))
+	prev := make(map[string]string, len(order))
+	var last string
+	for _, id := range order {
+		if done != nil && done(id) {
+			continue
+		}
+		best, from := 0, ""
+		for _, dep := range g.deps[id] {
+			if l, ok := length[dep]; ok && (l > best || (l == best && from != "" && dep < from)) {
+				best, from = l, dep
+			}
+		}
+		length[id] = best + g.weights[id]
+		if from != "" {
+			prev[id] = from
+		}
+		if last == "" || length[id] > length[last] {
+			last = id
+		}
+	}
+	if last == "" {
+		return nil, 0
+	}
+	var path []string
+	for id := last; id != ""; id = prev[id] {
+		path = append(path, id)
+	}
+	slices.Reverse(path)
+	return path, length[last]
+}
+
+// dependents は依存先 → 依存元（昇順）の逆引きを返す
+func (g *DependencyGraph) dependents() map[string][]string {
+	out := make(map[string][]string, len(g.deps))
+	for _, id := range g.NodeIDs() {
+		for _, dep := range g.deps[id] {
+			if g.Has(dep) && !slices.Contains(out[dep], id) {
+				out[dep] = append(out[dep], id)
+			}
+		}
+	}
+	return out
+}
+
+// LoadDependencyGraph は state/tasks.json のタスクのノード設計と、その依存先を辿ったノード設計から依存グラフを組み立てる
+// 読み込めないノード設計への依存は未知の参照になる。重みはストーリーポイント。
+func LoadDependencyGraph(repo persistence.WorkspaceRepository) (*DependencyGraph, error) {
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	g := NewDependencyGraph()
+	var queue []string
+	for _, t := range tasksState.Tasks {
+		if t.NodeID != "" {
+			queue = append(queue, t.NodeID)
+		}
+	}
+	missing := make(map[string]bool)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if g.Has(id) || missing[id] {
+			continue
+		}
+		node, err := repo.Design().GetNode(id)
+		if err != nil {
+			missing[id] = true
+			continue
+		}
+		g.SetNode(id, node.Dependencies, node.Estimate.StoryPoints)
+		queue = append(queue, node.Dependencies...)
+	}
+	return g, nil
+}
+
+// DependencyAnalysis は依存グラフの分析結果（IDE のボトルネック表示用）
+// ID はノード ID（plan_patch で作成したタスクではタスク ID と同じ）。
+type DependencyAnalysis struct {
+	// Order は依存先が先に来る実行順（循環に含まれるノードとその後続は除く）
+	Order       []string        `json:"order"`
+	Cycles      [][]string      `json:"cycles"`
+	UnknownRefs []DependencyRef `json:"unknownRefs"`
+	// CriticalPath は未完了のノードのうちストーリーポイントの合計が最大の依存の連鎖
+	CriticalPath       []string `json:"criticalPath"`
+	CriticalPathWeight int      `json:"criticalPathWeight"`
+	// BlockedByFailure は失敗したノード → それが完了しないと実行できない未完了のノード
+	BlockedByFailure map[string][]string `json:"blockedByFailure"`
+}
+
+// AnalyzeDependencies はワークスペースの依存グラフを分析する
+// 完了・失敗の判定はタスクのステータスとノードの実行時ステータスから行う。
+func AnalyzeDependencies(repo persistence.WorkspaceRepository) (*DependencyAnalysis, error) {
+	g, err := LoadDependencyGraph(repo)
+	if err != nil {
+		return nil, err
+	}
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nodes runtime: %w", err)
+	}
+
+	completed := make(map[string]bool)
+	for _, nr := range nodesRuntime.Nodes {
+		if persistence.NodeRuntimeStatus(nr.Status).IsCompleted() {
+			completed[nr.NodeID] = true
+		}
+	}
+	var failed []string
+	for _, t := range tasksState.Tasks {
+		switch TaskStatus(t.Status) {
+		case TaskStatusSucceeded, TaskStatusCompleted, TaskStatusSkipped:
+			completed[t.NodeID] = true
+		case TaskStatusFailed:
+			failed = append(failed, t.NodeID)
+		}
+	}
+	isDone := func(id string) bool { return completed[id] }
+
+	analysis := &DependencyAnalysis{
+		Cycles:           g.Cycles(),
+		UnknownRefs:      g.UnknownReferences(),
+		BlockedByFailure: make(map[string][]string),
+	}
+	analysis.Order, _ = g.TopologicalOrder()
+	analysis.CriticalPath, analysis.CriticalPathWeight = g.CriticalPath(isDone)
+	for _, id := range failed {
+		var blocked []string
+		for _, dependent := range g.Dependents(id) {
+			if !completed[dependent] {
+				blocked = append(blocked, dependent)
+			}
+		}
+		if len(blocked) > 0 {
+			analysis.BlockedByFailure[id] = blocked
+		}
+	}
+	if analysis.Order == nil {
+		analysis.Order = []string{}
+	}
+	if analysis.Cycles == nil {
+		analysis.Cycles = [][]string{}
+	}
+	if analysis.UnknownRefs == nil {
+		analysis.UnknownRefs = []DependencyRef{}
+	}
+	if analysis.CriticalPath == nil {
+		analysis.CriticalPath = []string{}
+	}
+	return analysis, nil
+}
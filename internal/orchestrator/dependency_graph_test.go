@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyGraph_OrderAndCycles(t *testing.T) {
+	g := NewDependencyGraph()
+	g.SetNode("a", nil, 1)
+	g.SetNode("b", []string{"a"}, 1)
+	g.SetNode("c", []string{"a", "b", "b"}, 1)
+	g.SetNode("d", []string{"missing"}, 1)
+
+	order, err := g.TopologicalOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, order)
+	assert.Empty(t, g.Cycles())
+	assert.Equal(t, []DependencyRef{{NodeID: "d", DependsOn: "missing"}}, g.UnknownReferences())
+	assert.Equal(t, []string{"b", "c"}, g.Dependents("a"))
+
+	// x -> y -> z -> x の循環と自己依存
+	g.SetNode("x", []string{"z"}, 1)
+	g.SetNode("y", []string{"x"}, 1)
+	g.SetNode("z", []string{"y"}, 1)
+	g.SetNode("self", []string{"self"}, 1)
+	g.SetNode("after", []string{"x"}, 1)
+	assert.Equal(t, [][]string{{"self"}, {"x", "y", "z"}}, g.Cycles())
+	order, err = g.TopologicalOrder()
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, order, "cycles and their dependents are excluded")
+}
+
+func TestDependencyGraph_CriticalPath(t *testing.T) {
+	g := NewDependencyGraph()
+	g.SetNode("design", nil, 2)
+	g.SetNode("api", []string{"design"}, 5)
+	g.SetNode("ui", []string{"design"}, 3)
+	g.SetNode("release", []string{"api", "ui"}, 1)
+
+	path, weight := g.CriticalPath(nil)
+	assert.Equal(t, []string{"design", "api", "release"}, path)
+	assert.Equal(t, 8, weight)
+
+	// 完了したノードは除く
+	done := map[string]bool{"design": true, "api": true}
+	path, weight = g.CriticalPath(func(id string) bool { return done[id] })
+	assert.Equal(t, []string{"ui", "release"}, path)
+	assert.Equal(t, 4, weight)
+}
+
+func TestAnalyzeDependencies(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "n1", Estimate: persistence.Estimate{StoryPoints: 3}},
+		{NodeID: "n2", Dependencies: []string{"n1"}},
+		{NodeID: "n3", Dependencies: []string{"n2", "gone"}},
+		{NodeID: "n4", Dependencies: []string{"n5"}},
+		{NodeID: "n5", Dependencies: []string{"n4"}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "n1", NodeID: "n1", Status: string(TaskStatusSucceeded)},
+		{TaskID: "n2", NodeID: "n2", Status: string(TaskStatusFailed)},
+		{TaskID: "n3", NodeID: "n3", Status: string(TaskStatusBlocked)},
+		{TaskID: "n4", NodeID: "n4", Status: string(TaskStatusBlocked)},
+		{TaskID: "n5", NodeID: "n5", Status: string(TaskStatusBlocked)},
+	}, nil)
+
+	analysis, err := AnalyzeDependencies(repo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"n1", "n2", "n3"}, analysis.Order)
+	assert.Equal(t, [][]string{{"n4", "n5"}}, analysis.Cycles)
+	assert.Equal(t, []DependencyRef{{NodeID: "n3", DependsOn: "gone"}}, analysis.UnknownRefs)
+	assert.Equal(t, []string{"n2", "n3"}, analysis.CriticalPath)
+	assert.Equal(t, map[string][]string{"n2": {"n3"}}, analysis.BlockedByFailure)
+}
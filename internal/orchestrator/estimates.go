@@ -0,0 +1,179 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// 見積もりと実績の比較で使う集計の軸
+const (
+	CalibrationDimensionKind       = "kind"
+	CalibrationDimensionDifficulty = "difficulty"
+)
+
+// recordNodeActuals は終了した試行の実績をノードの実行状態（NodeRuntime.Actuals）に加算する
+// 設計の無いノード（手動作成のタスクなど）は見積もりと比べられないため記録しない。
+func (e *ExecutionOrchestrator) recordNodeActuals(nodeID string, attempt *Attempt) {
+	if nodeID == "" || attempt == nil || e.Repo == nil {
+		return
+	}
+	if _, err := e.Repo.Design().GetNode(nodeID); err != nil {
+		return
+	}
+	finishedAt := e.now()
+	if attempt.FinishedAt != nil {
+		finishedAt = *attempt.FinishedAt
+	}
+	err := e.Repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		idx := -1
+		for i := range nodesRuntime.Nodes {
+			if nodesRuntime.Nodes[i].NodeID == nodeID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
+				NodeID: nodeID,
+				Status: string(persistence.NodeRuntimeStatusPlanned),
+				Verification: persistence.NodeVerification{
+					Status: string(persistence.NodeVerificationNotTested),
+				},
+			})
+			idx = len(nodesRuntime.Nodes) - 1
+		}
+		node := &nodesRuntime.Nodes[idx]
+		if node.Actuals == nil {
+			node.Actuals = &persistence.NodeActuals{FirstStartedAt: attempt.StartedAt}
+		}
+		actuals := node.Actuals
+		actuals.Attempts++
+		actuals.WorkerRuns += attempt.WorkerRuns
+		if !attempt.StartedAt.IsZero() && finishedAt.After(attempt.StartedAt) {
+			actuals.WallClockSec += finishedAt.Sub(attempt.StartedAt).Seconds()
+		}
+		actuals.InputTokens += attempt.InputTokens
+		actuals.OutputTokens += attempt.OutputTokens
+		actuals.CostUSD += attempt.CostUSD
+		if actuals.FirstStartedAt.IsZero() || (!attempt.StartedAt.IsZero() && attempt.StartedAt.Before(actuals.FirstStartedAt)) {
+			actuals.FirstStartedAt = attempt.StartedAt
+		}
+		actuals.LastAttemptAt = finishedAt
+		return nil
+	})
+	if err != nil {
+		e.logger.Warn("failed to record node actuals",
+			slog.String("node_id", nodeID),
+			slog.String("attempt_id", attempt.ID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// CalibrationGroup はノードの種別または難易度ごとの見積もりと実績の集計
+// 合計に加えて 1 ノードあたりの平均と 1 ポイントあたりの実績を持つ。
+// ポイントは進捗レポートと同じく Estimate.StoryPoints（1 未満は 1）。
+type CalibrationGroup struct {
+	Dimension    string  `json:"dimension"` // kind | difficulty
+	Key          string  `json:"key"`       // 空は未設定
+	Nodes        int     `json:"nodes"`
+	StoryPoints  int     `json:"storyPoints"`
+	Attempts     int     `json:"attempts"`
+	WorkerRuns   int     `json:"workerRuns"`
+	WallClockSec float64 `json:"wallClockSec"`
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	CostUSD      float64 `json:"costUsd"`
+
+	AvgStoryPoints       float64 `json:"avgStoryPoints"`
+	AvgWallClockSec      float64 `json:"avgWallClockSec"`
+	AvgAttempts          float64 `json:"avgAttempts"`
+	AvgWorkerRuns        float64 `json:"avgWorkerRuns"`
+	AvgTokens            float64 `json:"avgTokens"` // 入力と出力の合計
+	AvgCostUSD           float64 `json:"avgCostUsd"`
+	WallClockSecPerPoint float64 `json:"wallClockSecPerPoint"`
+	CostUSDPerPoint      float64 `json:"costUsdPerPoint"`
+}
+
+// CalibrationReport は完了したノードの見積もりと実績を種別・難易度ごとに比較したレポート
+// 実装済み・検証済みで実績が記録されたノードだけを対象にする（途中のノードは実績が揃っていないため）。
+type CalibrationReport struct {
+	GeneratedAt  time.Time          `json:"generatedAt"`
+	Nodes        int                `json:"nodes"`
+	ByKind       []CalibrationGroup `json:"byKind"`
+	ByDifficulty []CalibrationGroup `json:"byDifficulty"`
+}
+
+// BuildCalibrationReport はノード設計と実行状態から見積もりと実績の比較レポートを組み立てる
+func BuildCalibrationReport(repo persistence.WorkspaceRepository, now time.Time) (*CalibrationReport, error) {
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nodes runtime: %w", err)
+	}
+
+	nodes := newProgressNodes(repo)
+	report := &CalibrationReport{GeneratedAt: now}
+	byKind := make(map[string]*CalibrationGroup)
+	byDifficulty := make(map[string]*CalibrationGroup)
+	for _, rt := range nodesRuntime.Nodes {
+		if rt.Actuals == nil {
+			continue
+		}
+		switch persistence.NodeRuntimeStatus(rt.Status) {
+		case persistence.NodeRuntimeStatusImplemented, persistence.NodeRuntimeStatusVerified:
+		default:
+			continue
+		}
+		node := nodes.get(rt.NodeID)
+		points := nodes.points(rt.NodeID)
+		addCalibration(calibrationGroupFor(byKind, CalibrationDimensionKind, node.Kind), points, rt.Actuals)
+		addCalibration(calibrationGroupFor(byDifficulty, CalibrationDimensionDifficulty, node.Estimate.Difficulty), points, rt.Actuals)
+		report.Nodes++
+	}
+	report.ByKind = sortedCalibrationGroups(byKind)
+	report.ByDifficulty = sortedCalibrationGroups(byDifficulty)
+	return report, nil
+}
+
+func calibrationGroupFor(groups map[string]*CalibrationGroup, dimension, key string) *CalibrationGroup {
+	g, ok := groups[key]
+	if !ok {
+		g = &CalibrationGroup{Dimension: dimension, Key: key}
+		groups[key] = g
+	}
+	return g
+}
+
+// addCalibration はノード 1 件の見積もりと実績を集計に加える
+func addCalibration(g *CalibrationGroup, points int, actuals *persistence.NodeActuals) {
+	g.Nodes++
+	g.StoryPoints += points
+	g.Attempts += actuals.Attempts
+	g.WorkerRuns += actuals.WorkerRuns
+	g.WallClockSec += actuals.WallClockSec
+	g.InputTokens += actuals.InputTokens
+	g.OutputTokens += actuals.OutputTokens
+	g.CostUSD += actuals.CostUSD
+}
+
+// sortedCalibrationGroups はキー順に並べ、平均と 1 ポイントあたりの実績を埋める
+func sortedCalibrationGroups(groups map[string]*CalibrationGroup) []CalibrationGroup {
+	out := make([]CalibrationGroup, 0, len(groups))
+	for _, key := range sortedKeys(groups) {
+		g := *groups[key]
+		n := float64(g.Nodes)
+		g.AvgStoryPoints = float64(g.StoryPoints) / n
+		g.AvgWallClockSec = g.WallClockSec / n
+		g.AvgAttempts = float64(g.Attempts) / n
+		g.AvgWorkerRuns = float64(g.WorkerRuns) / n
+		g.AvgTokens = float64(g.InputTokens+g.OutputTokens) / n
+		g.AvgCostUSD = g.CostUSD / n
+		g.WallClockSecPerPoint = g.WallClockSec / float64(g.StoryPoints)
+		g.CostUSDPerPoint = g.CostUSD / float64(g.StoryPoints)
+		out = append(out, g)
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordNodeActuals(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	orch := NewExecutionOrchestrator(nil, nil, repo, queue, nil, nil, []string{"default"})
+	saveDesign(t, repo, []persistence.NodeDesign{{NodeID: "n1", Kind: "feature"}})
+	saveState(t, repo, nil, []persistence.NodeRuntime{{NodeID: "n1", Status: string(persistence.NodeRuntimeStatusPlanned)}})
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	first := start.Add(2 * time.Minute)
+	second := start.Add(10 * time.Minute)
+	orch.recordNodeActuals("n1", &Attempt{ID: "a1", StartedAt: start, FinishedAt: &first, WorkerRuns: 1, InputTokens: 100, OutputTokens: 20, CostUSD: 0.5})
+	orch.recordNodeActuals("n1", &Attempt{ID: "a2", StartedAt: start.Add(5 * time.Minute), FinishedAt: &second, WorkerRuns: 2, InputTokens: 50, OutputTokens: 10, CostUSD: 0.25})
+	// 設計の無いノードは記録しない
+	orch.recordNodeActuals("manual-t1", &Attempt{ID: "a3", StartedAt: start, FinishedAt: &first})
+
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	require.NoError(t, err)
+	require.Len(t, nodesRuntime.Nodes, 1)
+	actuals := nodesRuntime.Nodes[0].Actuals
+	require.NotNil(t, actuals)
+	assert.Equal(t, 2, actuals.Attempts)
+	assert.Equal(t, 3, actuals.WorkerRuns)
+	assert.InDelta(t, 420.0, actuals.WallClockSec, 1e-9)
+	assert.Equal(t, 150, actuals.InputTokens)
+	assert.Equal(t, 30, actuals.OutputTokens)
+	assert.InDelta(t, 0.75, actuals.CostUSD, 1e-9)
+	assert.True(t, start.Equal(actuals.FirstStartedAt))
+	assert.True(t, second.Equal(actuals.LastAttemptAt))
+	assert.Equal(t, string(persistence.NodeRuntimeStatusPlanned), nodesRuntime.Nodes[0].Status)
+}
+
+func TestBuildCalibrationReport(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "n1", Kind: "feature", Estimate: persistence.Estimate{StoryPoints: 2, Difficulty: "low"}},
+		{NodeID: "n2", Kind: "feature", Estimate: persistence.Estimate{StoryPoints: 4, Difficulty: "high"}},
+		{NodeID: "n3", Kind: "bugfix", Estimate: persistence.Estimate{Difficulty: "low"}},
+		{NodeID: "n4", Kind: "feature", Estimate: persistence.Estimate{StoryPoints: 8, Difficulty: "high"}},
+	})
+	saveState(t, repo, nil, []persistence.NodeRuntime{
+		{NodeID: "n1", Status: string(persistence.NodeRuntimeStatusImplemented), Actuals: &persistence.NodeActuals{Attempts: 1, WorkerRuns: 1, WallClockSec: 60, InputTokens: 100, OutputTokens: 100, CostUSD: 1}},
+		{NodeID: "n2", Status: string(persistence.NodeRuntimeStatusVerified), Actuals: &persistence.NodeActuals{Attempts: 3, WorkerRuns: 5, WallClockSec: 600, InputTokens: 900, OutputTokens: 100, CostUSD: 5}},
+		{NodeID: "n3", Status: string(persistence.NodeRuntimeStatusImplemented), Actuals: &persistence.NodeActuals{Attempts: 1, WorkerRuns: 1, WallClockSec: 30, CostUSD: 0.5}},
+		// 途中のノードと実績の無いノードは対象外
+		{NodeID: "n4", Status: string(persistence.NodeRuntimeStatusInProgress), Actuals: &persistence.NodeActuals{Attempts: 2}},
+		{NodeID: "n5", Status: string(persistence.NodeRuntimeStatusImplemented)},
+	})
+
+	report, err := BuildCalibrationReport(repo, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Nodes)
+
+	require.Len(t, report.ByKind, 2)
+	assert.Equal(t, "bugfix", report.ByKind[0].Key)
+	feature := report.ByKind[1]
+	assert.Equal(t, CalibrationDimensionKind, feature.Dimension)
+	assert.Equal(t, 2, feature.Nodes)
+	assert.Equal(t, 6, feature.StoryPoints)
+	assert.InDelta(t, 3.0, feature.AvgStoryPoints, 1e-9)
+	assert.InDelta(t, 330.0, feature.AvgWallClockSec, 1e-9)
+	assert.InDelta(t, 2.0, feature.AvgAttempts, 1e-9)
+	assert.InDelta(t, 3.0, feature.AvgWorkerRuns, 1e-9)
+	assert.InDelta(t, 600.0, feature.AvgTokens, 1e-9)
+	assert.InDelta(t, 110.0, feature.WallClockSecPerPoint, 1e-9)
+	assert.InDelta(t, 1.0, feature.CostUSDPerPoint, 1e-9)
+
+	require.Len(t, report.ByDifficulty, 2)
+	assert.Equal(t, "high", report.ByDifficulty[0].Key)
+	low := report.ByDifficulty[1]
+	assert.Equal(t, CalibrationDimensionDifficulty, low.Dimension)
+	assert.Equal(t, 2, low.Nodes)
+	// ポイント未設定のノードは 1pt として数える
+	assert.Equal(t, 3, low.StoryPoints)
+	assert.InDelta(t, 0.5, low.CostUSDPerPoint, 1e-9)
+}
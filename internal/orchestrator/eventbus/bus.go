@@ -0,0 +1,259 @@
+// Package eventbus はオーケストレータのイベントを複数の出力先（Sink）へ配信する。
+//
+// Bus は orchestrator.EventEmitter を満たし、発行されたイベントにスキーマバージョンと
+// 連番を付与した Envelope として、登録された Sink とプロセス内の購読者へ配信する。
+package eventbus
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/logging"
+)
+
+// SchemaVersion は Envelope の形式バージョン（互換性のない変更時にのみ上げる）
+const SchemaVersion = 1
+
+const (
+	// sinkBuffer は Sink ごとの未送信イベントの上限（溢れた分は破棄する）
+	sinkBuffer = 1024
+	// subscriberBuffer は購読者ごとのイベントバッファ数（溢れた分は破棄する）
+	subscriberBuffer = 256
+)
+
+// Envelope は配信されるイベント
+type Envelope struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Seq           int64     `json:"seq"` // 発行したプロセス内での連番（同じワークスペースの別プロセスとは重複し得る）
+	Name          string    `json:"name"`
+	Timestamp     time.Time `json:"timestamp"`
+	Data          any       `json:"data"`
+}
+
+// Sink はイベントの出力先
+// Write は Sink ごとの専用ゴルーチンから順番に呼ばれるため、スレッドセーフである必要はない。
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, env Envelope) error
+	Close() error
+}
+
+// Option は Bus の設定
+type Option func(*Bus)
+
+// WithStartSeq は連番の開始値を設定する（再起動後も連番を継続する場合に使う）
+// 連番はプロセスごとに採番するため、開始値を揃えても他のプロセスと同時に発行すると重複する。
+func WithStartSeq(seq int64) Option {
+	return func(b *Bus) { b.seq = seq }
+}
+
+// WithLogger はカスタムロガーを設定する
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *Bus) { b.logger = logging.WithComponent(logger, "event-bus") }
+}
+
+// Bus は orchestrator.EventEmitter を実装するイベントの多重配信器
+// Emit は呼び出し元（オーケストレータ）をブロックしない。遅い Sink や購読者へのイベントはバッファが溢れると破棄する。
+type Bus struct {
+	mu     sync.Mutex
+	seq    int64
+	sinks  []*sinkWorker
+	subs   map[chan Envelope]struct{}
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger *slog.Logger
+}
+
+// New は Bus を生成する
+func New(opts ...Option) *Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bus{
+		subs:   make(map[chan Envelope]struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logging.WithComponent(slog.Default(), "event-bus"),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// AddSink は Sink を登録し、配信ゴルーチンを開始する
+func (b *Bus) AddSink(sink Sink) {
+	w := &sinkWorker{sink: sink, ch: make(chan Envelope, sinkBuffer), done: make(chan struct{})}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		_ = sink.Close()
+		return
+	}
+	b.sinks = append(b.sinks, w)
+	go w.run(b.ctx, b.logger)
+}
+
+// Emit はイベントに連番を付けて全 Sink と購読者へ配信する
+func (b *Bus) Emit(eventName string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.seq++
+	env := Envelope{
+		SchemaVersion: SchemaVersion,
+		Seq:           b.seq,
+		Name:          eventName,
+		Timestamp:     time.Now(),
+		Data:          data,
+	}
+	for _, w := range b.sinks {
+		select {
+		case w.ch <- env:
+		default:
+			w.dropped++
+			if w.dropped == 1 || w.dropped%100 == 0 {
+				b.logger.Warn("event sink is falling behind, dropping events",
+					slog.String("sink", w.sink.Name()),
+					slog.Int64("dropped", w.dropped),
+				)
+			}
+		}
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- env:
+		default:
+		}
+	}
+}
+
+// Seq は最後に発行したイベントの連番を返す
+func (b *Bus) Seq() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq
+}
+
+// Subscribe はプロセス内の購読を開始し、イベントチャネルと購読解除関数を返す
+// SSE や CLI の tail 表示に使う。Close 時にもチャネルは閉じられる。
+func (b *Bus) Subscribe() (<-chan Envelope, func()) {
+	ch := make(chan Envelope, subscriberBuffer)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// SubscriberCount は現在の購読者数を返す
+func (b *Bus) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close は未送信のイベントを送り切ってから Sink を閉じる
+// ctx が終了した場合は残りを破棄して閉じる（Webhook の再試行待ちも中断する）。
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	sinks := b.sinks
+	for _, w := range sinks {
+		close(w.ch)
+	}
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+	b.mu.Unlock()
+
+	for _, w := range sinks {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			b.cancel()
+			<-w.done
+		}
+	}
+	b.cancel()
+
+	var firstErr error
+	for _, w := range sinks {
+		if err := w.sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sinkWorker は 1 つの Sink へ順番にイベントを書き込む
+type sinkWorker struct {
+	sink    Sink
+	ch      chan Envelope
+	done    chan struct{}
+	dropped int64 // Bus.mu で保護
+}
+
+func (w *sinkWorker) run(ctx context.Context, logger *slog.Logger) {
+	defer close(w.done)
+	for env := range w.ch {
+		if ctx.Err() != nil {
+			continue // Close のタイムアウト後は残りを破棄する
+		}
+		if err := w.sink.Write(ctx, env); err != nil {
+			logger.Warn("failed to write event to sink",
+				slog.String("sink", w.sink.Name()),
+				slog.String("event", env.Name),
+				slog.Int64("seq", env.Seq),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// Emitter は orchestrator.EventEmitter と同じ形のイベント発行インターフェース
+type Emitter interface {
+	Emit(eventName string, data any)
+}
+
+// EmitterSink は既存の Emitter（Wails など）を Sink として扱うアダプタ
+// Envelope ではなく元のイベント名とペイロードをそのまま渡す。
+type EmitterSink struct {
+	name    string
+	emitter Emitter
+}
+
+// NewEmitterSink は EmitterSink を生成する
+func NewEmitterSink(name string, emitter Emitter) *EmitterSink {
+	return &EmitterSink{name: name, emitter: emitter}
+}
+
+func (s *EmitterSink) Name() string { return s.name }
+
+func (s *EmitterSink) Write(_ context.Context, env Envelope) error {
+	s.emitter.Emit(env.Name, env.Data)
+	return nil
+}
+
+func (s *EmitterSink) Close() error { return nil }
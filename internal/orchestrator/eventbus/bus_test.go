@@ -0,0 +1,153 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink は受け取った Envelope を記録するテスト用 Sink
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Envelope
+	closed bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Write(_ context.Context, env Envelope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, env)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+type recordingEmitter struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (e *recordingEmitter) Emit(eventName string, _ any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.names = append(e.names, eventName)
+}
+
+func TestBus_FanOutWithSequence(t *testing.T) {
+	bus := New(WithStartSeq(41))
+	sink := &recordingSink{}
+	emitter := &recordingEmitter{}
+	bus.AddSink(sink)
+	bus.AddSink(NewEmitterSink("wails", emitter))
+
+	sub, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	assert.Equal(t, 1, bus.SubscriberCount())
+
+	bus.Emit("task:stateChange", map[string]string{"taskId": "t1"})
+	bus.Emit("task:log", map[string]string{"line": "hello"})
+	assert.Equal(t, int64(43), bus.Seq())
+
+	first := <-sub
+	assert.Equal(t, SchemaVersion, first.SchemaVersion)
+	assert.Equal(t, int64(42), first.Seq)
+	assert.Equal(t, "task:stateChange", first.Name)
+	assert.Equal(t, int64(43), (<-sub).Seq)
+
+	require.NoError(t, bus.Close(context.Background()))
+	assert.True(t, sink.closed)
+	require.Len(t, sink.events, 2)
+	assert.Equal(t, []int64{42, 43}, []int64{sink.events[0].Seq, sink.events[1].Seq})
+	assert.Equal(t, []string{"task:stateChange", "task:log"}, emitter.names)
+
+	// Close 後の Emit と購読は無視される
+	bus.Emit("ignored", nil)
+	_, ok := <-sub
+	assert.False(t, ok)
+	closedSub, _ := bus.Subscribe()
+	_, ok = <-closedSub
+	assert.False(t, ok)
+}
+
+func TestFileSink_RotationAndLastSeq(t *testing.T) {
+	dir := t.TempDir()
+	path := EventLogPath(dir)
+	assert.Equal(t, int64(0), LastSeq(path))
+
+	// 1 行ごとにローテーションされる小さな上限
+	sink, err := NewFileSink(path, 64, 2)
+	require.NoError(t, err)
+	for seq := int64(1); seq <= 5; seq++ {
+		require.NoError(t, sink.Write(context.Background(), Envelope{
+			SchemaVersion: SchemaVersion,
+			Seq:           seq,
+			Name:          "task:log",
+			Timestamp:     time.Now(),
+			Data:          map[string]string{"line": "some output line"},
+		}))
+	}
+	require.NoError(t, sink.Close())
+
+	assert.Len(t, rotatedFiles(path), 2, "old backups should be pruned")
+	assert.Equal(t, int64(5), LastSeq(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var env Envelope
+	require.NoError(t, json.Unmarshal(data, &env))
+	assert.Equal(t, int64(5), env.Seq)
+
+	// 現在のファイルが空でもローテーション済みファイルから連番を引き継ぐ
+	require.NoError(t, os.Truncate(path, 0))
+	assert.Equal(t, int64(4), LastSeq(path))
+}
+
+func TestNewWorkspaceBus_ContinuesSequence(t *testing.T) {
+	dir := t.TempDir()
+
+	bus := NewWorkspaceBus(dir, nil, nil)
+	bus.Emit("a", nil)
+	bus.Emit("b", nil)
+	require.NoError(t, bus.Close(context.Background()))
+
+	bus = NewWorkspaceBus(dir, nil, nil)
+	assert.Equal(t, int64(2), bus.Seq())
+	bus.Emit("c", nil)
+	require.NoError(t, bus.Close(context.Background()))
+	assert.Equal(t, int64(3), LastSeq(EventLogPath(dir)))
+}
+
+func TestLoadSinksConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadSinksConfig(dir)
+	require.NoError(t, err)
+	assert.False(t, cfg.EventLog.Disabled)
+	assert.Empty(t, cfg.Webhooks)
+
+	raw := `{"eventLog":{"disabled":true},"webhooks":[{"url":"http://127.0.0.1:1/hook","events":["task:stateChange"]}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SinksFileName), []byte(raw), 0644))
+	cfg, err = LoadSinksConfig(dir)
+	require.NoError(t, err)
+	assert.True(t, cfg.EventLog.Disabled)
+	require.Len(t, cfg.Webhooks, 1)
+	assert.Equal(t, []string{"task:stateChange"}, cfg.Webhooks[0].Events)
+
+	bus := NewWorkspaceBus(dir, cfg, nil)
+	require.NoError(t, bus.Close(context.Background()))
+	_, err = os.Stat(EventLogPath(dir))
+	assert.True(t, os.IsNotExist(err), "event log should be disabled")
+}
@@ -0,0 +1,100 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SinksFileName はワークスペースのイベント出力設定ファイル
+const SinksFileName = "event-sinks.json"
+
+// WebhookSecretEnv が設定されている場合、secret 未指定の Webhook の署名鍵として使う
+const WebhookSecretEnv = "MULTIVERSE_WEBHOOK_SECRET"
+
+// SinksConfig はワークスペースごとのイベント出力設定（event-sinks.json）
+type SinksConfig struct {
+	EventLog EventLogConfig      `json:"eventLog"`
+	Webhooks []WebhookFileConfig `json:"webhooks,omitempty"`
+}
+
+// EventLogConfig は JSONL イベントログの設定（デフォルトで有効）
+type EventLogConfig struct {
+	Disabled   bool  `json:"disabled,omitempty"`
+	MaxBytes   int64 `json:"maxBytes,omitempty"`
+	MaxBackups int   `json:"maxBackups,omitempty"`
+}
+
+// WebhookFileConfig は event-sinks.json 上の Webhook 設定
+type WebhookFileConfig struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	Events     []string `json:"events,omitempty"`
+	MaxRetries int      `json:"maxRetries,omitempty"`
+	TimeoutSec int      `json:"timeoutSec,omitempty"`
+}
+
+// LoadSinksConfig はワークスペースの event-sinks.json を読み込む
+// ファイルが存在しない場合はデフォルト（イベントログのみ）を返す。
+func LoadSinksConfig(workspaceDir string) (*SinksConfig, error) {
+	path := filepath.Join(workspaceDir, SinksFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &SinksConfig{}, nil
+		}
+		return &SinksConfig{}, fmt.Errorf("failed to read %s: %w", SinksFileName, err)
+	}
+	var cfg SinksConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return &SinksConfig{}, fmt.Errorf("failed to parse %s: %w", SinksFileName, err)
+	}
+	return &cfg, nil
+}
+
+// NewWorkspaceBus はワークスペースの設定に従って Sink を登録した Bus を生成する
+// イベントログの連番を引き継ぐため、既存ログの最後の連番から再開する。
+// extra は追加の Sink（IDE の Wails 転送など）。設定不備の Sink はスキップしてログに残す。
+func NewWorkspaceBus(workspaceDir string, cfg *SinksConfig, logger *slog.Logger, extra ...Sink) *Bus {
+	if cfg == nil {
+		cfg = &SinksConfig{}
+	}
+	logPath := EventLogPath(workspaceDir)
+	bus := New(WithStartSeq(LastSeq(logPath)), WithLogger(logger))
+
+	for _, sink := range extra {
+		bus.AddSink(sink)
+	}
+
+	if !cfg.EventLog.Disabled {
+		fileSink, err := NewFileSink(logPath, cfg.EventLog.MaxBytes, cfg.EventLog.MaxBackups)
+		if err != nil {
+			bus.logger.Warn("event log disabled", slog.Any("error", err))
+		} else {
+			bus.AddSink(fileSink)
+		}
+	}
+
+	for _, wh := range cfg.Webhooks {
+		secret := wh.Secret
+		if secret == "" {
+			secret = os.Getenv(WebhookSecretEnv)
+		}
+		sink, err := NewWebhookSink(WebhookConfig{
+			URL:        wh.URL,
+			Secret:     secret,
+			Events:     wh.Events,
+			MaxRetries: wh.MaxRetries,
+			Timeout:    time.Duration(wh.TimeoutSec) * time.Second,
+		})
+		if err != nil {
+			bus.logger.Warn("invalid webhook sink skipped", slog.Any("error", err))
+			continue
+		}
+		bus.AddSink(sink)
+	}
+	return bus
+}
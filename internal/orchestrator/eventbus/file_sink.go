@@ -0,0 +1,201 @@
+package eventbus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// EventLogFileName はワークスペース内のイベントログ（logs/ 配下）
+	EventLogFileName = "events.jsonl"
+	// DefaultMaxFileBytes はローテーションするファイルサイズ
+	DefaultMaxFileBytes = 10 * 1024 * 1024
+	// DefaultMaxBackups は保持するローテーション済みファイル数
+	DefaultMaxBackups = 5
+)
+
+// EventLogPath はワークスペースのイベントログのパスを返す
+func EventLogPath(workspaceDir string) string {
+	return filepath.Join(workspaceDir, "logs", EventLogFileName)
+}
+
+// FileSink は Envelope を 1 行 1 JSON で追記し、サイズ上限でローテーションする
+// ローテーション済みファイルは events-<timestamp>.jsonl として同じディレクトリに残し、古いものから削除する。
+type FileSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewFileSink は path へ追記する FileSink を生成する（maxBytes / maxBackups が 0 以下ならデフォルト）
+func NewFileSink(path string, maxBytes int64, maxBackups int) (*FileSink, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxFileBytes
+	}
+	if maxBackups <= 0 {
+		maxBackups = DefaultMaxBackups
+	}
+	s := &FileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Name() string { return "file:" + s.path }
+
+func (s *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create event log directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat event log: %w", err)
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+// Write は 1 イベントを追記する
+func (s *FileSink) Write(_ context.Context, env Envelope) error {
+	line, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	line = append(line, '\n')
+
+	if s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write event log: %w", err)
+	}
+	return nil
+}
+
+// rotate は現在のファイルを退避して新しいファイルを開く
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close event log: %w", err)
+	}
+	ext := filepath.Ext(s.path)
+	base := strings.TrimSuffix(s.path, ext)
+	rotated := fmt.Sprintf("%s-%s%s", base, time.Now().UTC().Format("20060102T150405.000000000"), ext)
+	if err := os.Rename(s.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate event log: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.pruneBackups()
+	return nil
+}
+
+// pruneBackups は保持数を超えたローテーション済みファイルを古い順に削除する
+func (s *FileSink) pruneBackups() {
+	backups := rotatedFiles(s.path)
+	for len(backups) > s.maxBackups {
+		_ = os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// rotatedFiles は path のローテーション済みファイルを古い順に返す
+func rotatedFiles(path string) []string {
+	ext := filepath.Ext(path)
+	pattern := strings.TrimSuffix(path, ext) + "-*" + ext
+	matches, _ := filepath.Glob(pattern)
+	sort.Strings(matches) // タイムスタンプ名なので辞書順 = 時系列
+	return matches
+}
+
+// Close はファイルを閉じる
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// LastSeq はイベントログ（無ければ最新のローテーション済みファイル）の最後の連番を返す
+// 再起動後に WithStartSeq へ渡して連番を継続するために使う。
+// 起動時点の値であり、その後に他のプロセスが同じログへ追記した連番とは調整しない。
+func LastSeq(path string) int64 {
+	candidates := append([]string{path}, reverse(rotatedFiles(path))...)
+	for _, p := range candidates {
+		if seq, ok := lastSeqInFile(p); ok {
+			return seq
+		}
+	}
+	return 0
+}
+
+func lastSeqInFile(path string) (int64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer func() { _ = f.Close() }()
+
+	// 末尾だけを読む（最大 64KB）
+	const tail = 64 * 1024
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return 0, false
+	}
+	offset := info.Size() - tail
+	if offset < 0 {
+		offset = 0
+	}
+	buf := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return 0, false
+	}
+
+	var last int64
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(make([]byte, 0, 64*1024), tail)
+	for scanner.Scan() {
+		var env struct {
+			Seq int64 `json:"seq"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &env); err == nil && env.Seq > 0 {
+			last = env.Seq
+			found = true
+		}
+	}
+	return last, found
+}
+
+func reverse(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook リクエストのヘッダ
+const (
+	HeaderEvent     = "X-Multiverse-Event"
+	HeaderSeq       = "X-Multiverse-Seq"
+	HeaderSignature = "X-Multiverse-Signature" // "sha256=<hex(HMAC-SHA256(secret, body))>"
+	HeaderTimestamp = "X-Multiverse-Timestamp"
+)
+
+// WebhookConfig は Webhook Sink の設定
+type WebhookConfig struct {
+	URL string
+	// Secret が設定されている場合、本文の HMAC-SHA256 署名を HeaderSignature に付与する
+	Secret string
+	// Events が空でなければ、指定したイベント名のみ送信する
+	Events []string
+	// MaxRetries は 1 イベントあたりの再試行回数（デフォルト 3）
+	MaxRetries int
+	// Backoff は初回の再試行待ち時間（以降は倍々、デフォルト 500ms）
+	Backoff time.Duration
+	// Timeout は 1 リクエストのタイムアウト（デフォルト 5s）
+	Timeout time.Duration
+}
+
+// WebhookSink は Envelope を JSON で HTTP POST する
+// ネットワークエラー・5xx・429 は指数バックオフで再試行し、それ以外の 4xx は再試行しない。
+type WebhookSink struct {
+	cfg    WebhookConfig
+	filter map[string]struct{}
+	client *http.Client
+}
+
+// NewWebhookSink は WebhookSink を生成する
+func NewWebhookSink(cfg WebhookConfig) (*WebhookSink, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook url is required")
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 500 * time.Millisecond
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	s := &WebhookSink{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+	if len(cfg.Events) > 0 {
+		s.filter = make(map[string]struct{}, len(cfg.Events))
+		for _, name := range cfg.Events {
+			s.filter[name] = struct{}{}
+		}
+	}
+	return s, nil
+}
+
+func (s *WebhookSink) Name() string { return "webhook:" + s.cfg.URL }
+
+// Write は 1 イベントを送信する（再試行を含む）
+func (s *WebhookSink) Write(ctx context.Context, env Envelope) error {
+	if s.filter != nil {
+		if _, ok := s.filter[env.Name]; !ok {
+			return nil
+		}
+	}
+	body, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	backoff := s.cfg.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= s.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("webhook delivery canceled: %w", lastErr)
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		retry, err := s.post(ctx, env, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return fmt.Errorf("failed to deliver webhook: %w", lastErr)
+}
+
+// post は 1 回送信し、失敗時は再試行すべきかを返す
+func (s *WebhookSink) post(ctx context.Context, env Envelope, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, env.Name)
+	req.Header.Set(HeaderSeq, strconv.FormatInt(env.Seq, 10))
+	req.Header.Set(HeaderTimestamp, env.Timestamp.UTC().Format(time.RFC3339Nano))
+	if s.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(s.cfg.Secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+func (s *WebhookSink) Close() error { return nil }
+
+// Sign は本文の署名（HeaderSignature の値）を返す
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature は受信側で署名を検証する
+func VerifySignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
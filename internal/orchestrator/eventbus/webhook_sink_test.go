@@ -0,0 +1,85 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEnvelope(name string) Envelope {
+	return Envelope{SchemaVersion: SchemaVersion, Seq: 7, Name: name, Timestamp: time.Now(), Data: map[string]string{"taskId": "t1"}}
+}
+
+func TestWebhookSink_RetriesAndSigns(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, Secret: "s3cret", Backoff: time.Millisecond})
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), testEnvelope("task:stateChange")))
+	assert.Equal(t, int32(3), calls.Load())
+
+	r := <-received
+	assert.Equal(t, "task:stateChange", r.Header.Get(HeaderEvent))
+	assert.Equal(t, "7", r.Header.Get(HeaderSeq))
+	assert.True(t, VerifySignature("s3cret", body, r.Header.Get(HeaderSignature)))
+	assert.False(t, VerifySignature("other", body, r.Header.Get(HeaderSignature)))
+
+	var env Envelope
+	require.NoError(t, json.Unmarshal(body, &env))
+	assert.Equal(t, SchemaVersion, env.SchemaVersion)
+	assert.Equal(t, int64(7), env.Seq)
+}
+
+func TestWebhookSink_NoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, Backoff: time.Millisecond})
+	require.NoError(t, err)
+	err = sink.Write(context.Background(), testEnvelope("task:log"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWebhookSink_EventFilter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Empty(t, r.Header.Get(HeaderSignature), "unsigned without secret")
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, Events: []string{"task:stateChange"}})
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), testEnvelope("task:log")))
+	require.NoError(t, sink.Write(context.Background(), testEnvelope("task:stateChange")))
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err = NewWebhookSink(WebhookConfig{})
+	assert.Error(t, err)
+}
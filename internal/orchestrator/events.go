@@ -36,6 +36,7 @@ const (
 	EventTaskCreated            = "task:created"
 	EventChatProgress           = "chat:progress"
 	EventBacklogAdded           = "backlog:added"
+	EventBacklogResolved        = "backlog:resolved"
 	EventTaskLog                = "task:log"
 	EventProcessMetaUpdate      = "process:metaUpdate"
 	EventProcessWorkerUpdate    = "process:workerUpdate"
@@ -73,6 +74,7 @@ type ChatProgressEvent struct {
 // TaskLogEvent represents a log line from task execution
 type TaskLogEvent struct {
 	TaskID    string    `json:"taskId"`
+	AttemptID string    `json:"attemptId,omitempty"`
 	Stream    string    `json:"stream"` // "stdout" or "stderr"
 	Line      string    `json:"line"`   // Log line content
 	Timestamp time.Time `json:"timestamp"`
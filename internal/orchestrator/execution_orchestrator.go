@@ -10,6 +10,7 @@ import (
 	"github.com/biwakonbu/agent-runner/internal/logging"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/google/uuid"
 )
 
 // ExecutionState represents the state of the execution loop
@@ -32,13 +33,38 @@ type ExecutionOrchestrator struct {
 	RetryPolicy  *RetryPolicy
 	PoolIDs      []string
 
+	// retryPolicies は失敗の分類・タスク種別ごとのリトライポリシー（RetryPolicy を基準に上書きする）
+	retryPolicies *RetryPoliciesConfig
+	// replanner はリトライを使い切ったタスクの自動再計画に使う（retryPolicies.AutoReplan が有効な場合）
+	replanner TaskReplanner
+	// verification は実装タスクの成功後に作成する検証タスクの設定（nil で無効）
+	verification *VerificationConfig
+	// schedules は実行ループでタスクを作成する定期タスクの定義（Repo のワークスペース）
+	schedules *ScheduleStore
+	// clock は状態・試行・リトライ予定に記録する時刻の取得元（既定は Repo の Clock）
+	clock persistence.Clock
+
+	// Leader はワークスペース単位の単一インスタンス保証（Start で取得し Stop で解放する）
+	Leader *persistence.LeaderLock
+
+	// startupFsck が設定されている場合、Start でリーダーロック取得後に整合性チェックを行う
+	startupFsck *FsckOptions
+
 	state   ExecutionState
 	stateMu sync.RWMutex
 
-	// Force Stop support
-	runningCancel context.CancelFunc
+	// Force Stop support（job ID -> cancel）
+	runningCancels map[string]context.CancelFunc
+	// 実行中タスク（task ID -> job ID）と、CancelTask で取り消し要求されたタスク
+	runningJobs   map[string]string
+	canceledTasks map[string]struct{}
 	cancelMu      sync.Mutex
 
+	// Pool ごとの同時実行数と実行中ジョブ数
+	poolConcurrency map[string]int
+	inFlight        map[string]int
+	inFlightMu      sync.Mutex
+
 	stopCh   chan struct{}
 	resumeCh chan struct{}
 
@@ -60,6 +86,15 @@ func NewExecutionOrchestrator(
 	if len(poolIDs) == 0 {
 		poolIDs = []string{"default"}
 	}
+	var leader *persistence.LeaderLock
+	var schedules *ScheduleStore
+	clock := persistence.SystemClock
+	if repo != nil {
+		leader = persistence.NewLeaderLock(repo.BaseDir(), persistence.LeaderRoleDaemon)
+		clock = repo.Clock()
+		schedules = NewScheduleStore(repo.BaseDir())
+		schedules.SetClock(clock)
+	}
 	return &ExecutionOrchestrator{
 		Scheduler:    scheduler,
 		Executor:     executor,
@@ -69,13 +104,146 @@ func NewExecutionOrchestrator(
 		BacklogStore: backlogStore,
 		RetryPolicy:  DefaultRetryPolicy(),
 		PoolIDs:      poolIDs,
+		Leader:       leader,
+		schedules:    schedules,
+		clock:        clock,
 		state:        ExecutionStateIdle,
 		stopCh:       nil,
 		resumeCh:     make(chan struct{}),
-		logger:       logging.WithComponent(slog.Default(), "execution-orchestrator"),
+
+		runningCancels:  make(map[string]context.CancelFunc),
+		runningJobs:     make(map[string]string),
+		canceledTasks:   make(map[string]struct{}),
+		poolConcurrency: make(map[string]int),
+		inFlight:        make(map[string]int),
+
+		logger: logging.WithComponent(slog.Default(), "execution-orchestrator"),
+	}
+}
+
+// SetWorkerPools は Pool ごとの同時実行数を設定する
+func (e *ExecutionOrchestrator) SetWorkerPools(cfg *WorkerPoolsConfig) {
+	if cfg == nil {
+		return
+	}
+	e.inFlightMu.Lock()
+	defer e.inFlightMu.Unlock()
+	for _, p := range cfg.Pools {
+		e.poolConcurrency[p.ID] = p.MaxConcurrency()
+	}
+}
+
+// SetRetryPolicies は失敗の分類・タスク種別ごとのリトライポリシーを設定する（nil で既定のポリシーのみ）
+func (e *ExecutionOrchestrator) SetRetryPolicies(cfg *RetryPoliciesConfig) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.retryPolicies = cfg
+}
+
+// retryPolicyFor はタスク種別と失敗の分類に適用するリトライポリシーを返す
+func (e *ExecutionOrchestrator) retryPolicyFor(taskKind string, kind FailureKind) *RetryPolicy {
+	e.stateMu.RLock()
+	cfg := e.retryPolicies
+	e.stateMu.RUnlock()
+	return cfg.PolicyFor(e.RetryPolicy, taskKind, kind)
+}
+
+// SetClock は時刻の取得元を差し替える（nil で実時間）
+// Scheduler と定期タスクの定義にも同じ Clock を使わせる。
+func (e *ExecutionOrchestrator) SetClock(clock persistence.Clock) {
+	if clock == nil {
+		clock = persistence.SystemClock
+	}
+	e.stateMu.Lock()
+	e.clock = clock
+	e.stateMu.Unlock()
+	if e.Scheduler != nil {
+		e.Scheduler.SetClock(clock)
+	}
+	if e.schedules != nil {
+		e.schedules.SetClock(clock)
 	}
 }
 
+// now は Clock の現在時刻を返す
+func (e *ExecutionOrchestrator) now() time.Time {
+	e.stateMu.RLock()
+	defer e.stateMu.RUnlock()
+	return e.clock.Now()
+}
+
+// SetLeaderLock はリーダーロックを差し替える（nil で単一インスタンス保証を無効化）
+func (e *ExecutionOrchestrator) SetLeaderLock(lock *persistence.LeaderLock) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.Leader = lock
+}
+
+// tryAcquireSlot は Pool に空きがあれば実行枠を確保する
+func (e *ExecutionOrchestrator) tryAcquireSlot(poolID string) bool {
+	e.inFlightMu.Lock()
+	defer e.inFlightMu.Unlock()
+	limit := e.poolConcurrency[poolID]
+	if limit <= 0 {
+		limit = 1
+	}
+	if e.inFlight[poolID] >= limit {
+		return false
+	}
+	e.inFlight[poolID]++
+	return true
+}
+
+// releaseSlot は確保した実行枠を解放する
+func (e *ExecutionOrchestrator) releaseSlot(poolID string) {
+	e.inFlightMu.Lock()
+	defer e.inFlightMu.Unlock()
+	if e.inFlight[poolID] > 0 {
+		e.inFlight[poolID]--
+	}
+}
+
+// SetStartupFsck は Start 時の整合性チェックを設定する（nil で無効化）
+// リーダーロックの取得後に実行するため、opts.Repair による修復は他のオーケストレータと競合しない。
+func (e *ExecutionOrchestrator) SetStartupFsck(opts *FsckOptions) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.startupFsck = opts
+}
+
+// runStartupFsck はワークスペースの整合性チェックを行い、結果をログに出す（失敗しても起動は続ける）
+func (e *ExecutionOrchestrator) runStartupFsck(opts FsckOptions) {
+	if e.Repo == nil {
+		return
+	}
+	fsck := NewFsck(e.Repo, e.Queue, e.BacklogStore)
+	report, err := fsck.Run(opts)
+	if err != nil {
+		e.logger.Warn("workspace integrity check failed", slog.Any("error", err))
+		if report == nil {
+			return
+		}
+	}
+	for _, issue := range report.Issues {
+		level := slog.LevelWarn
+		if issue.Severity == FsckSeverityInfo || issue.Repaired {
+			level = slog.LevelInfo
+		}
+		e.logger.Log(context.Background(), level, "workspace integrity issue",
+			slog.String("code", issue.Code),
+			slog.String("severity", string(issue.Severity)),
+			slog.String("subject", issue.Subject),
+			slog.String("message", issue.Message),
+			slog.Bool("repaired", issue.Repaired),
+		)
+	}
+	e.logger.Info("workspace integrity check completed",
+		slog.Int("issues", len(report.Issues)),
+		slog.Int("repaired", report.Repaired()),
+		slog.Int("errors", report.Count(FsckSeverityError)),
+	)
+}
+
 // Start starts the execution loop
 func (e *ExecutionOrchestrator) Start(ctx context.Context) error {
 	e.stateMu.Lock()
@@ -83,6 +251,17 @@ func (e *ExecutionOrchestrator) Start(ctx context.Context) error {
 		e.stateMu.Unlock()
 		return nil // 冪等性: 既に実行中なら成功扱い
 	}
+	// 同じワークスペースのキューを複数のオーケストレータが消費しないようリーダーロックを取得する
+	if e.Leader != nil {
+		if err := e.Leader.Acquire(); err != nil {
+			e.stateMu.Unlock()
+			return fmt.Errorf("failed to acquire leader lock: %w", err)
+		}
+		e.Leader.RunHeartbeat(func() string { return string(e.State()) }, e.onLeaderLost)
+	}
+	if e.startupFsck != nil {
+		e.runStartupFsck(*e.startupFsck)
+	}
 	oldState := e.state
 	// 再スタートに備え stopCh を作り直す
 	e.stopCh = make(chan struct{})
@@ -149,20 +328,32 @@ func (e *ExecutionOrchestrator) Stop() error {
 		close(stopCh) // runLoop を確実に終了させる
 	}
 
-	// Cancel currently running task if any
+	// Cancel currently running tasks if any
 	e.cancelMu.Lock()
-	if e.runningCancel != nil {
-		e.logger.Info("canceling running task due to stop signal")
-		e.runningCancel()
-		e.runningCancel = nil
+	for jobID, cancel := range e.runningCancels {
+		e.logger.Info("canceling running task due to stop signal", slog.String("job_id", jobID))
+		cancel()
+		delete(e.runningCancels, jobID)
 	}
 	e.cancelMu.Unlock()
 
 	e.emitStateChange(oldState, ExecutionStateIdle)
+	if e.Leader != nil {
+		if err := e.Leader.Release(); err != nil {
+			e.logger.Warn("failed to release leader lock", slog.Any("error", err))
+		}
+	}
 	e.logger.Info("execution orchestrator stopped")
 	return nil
 }
 
+// onLeaderLost はハートビートが途絶えている間に他のプロセスへリーダーが移った場合に呼ばれる
+// 二重実行を避けるため、自分の実行ループを停止する。
+func (e *ExecutionOrchestrator) onLeaderLost(err error) {
+	e.logger.Error("leader lock lost, stopping execution loop", slog.Any("error", err))
+	_ = e.Stop()
+}
+
 // State returns the current state
 func (e *ExecutionOrchestrator) State() ExecutionState {
 	e.stateMu.RLock()
@@ -176,18 +367,27 @@ func (e *ExecutionOrchestrator) Wait() {
 }
 
 func (e *ExecutionOrchestrator) emitStateChange(oldState, newState ExecutionState) {
+	// クライアント（IDE 等）が参照できるようリーダー情報にも状態を書き込む
+	if e.Leader != nil && e.Leader.Held() {
+		if err := e.Leader.Heartbeat(string(newState)); err != nil {
+			e.logger.Warn("failed to update leader state", slog.Any("error", err))
+		}
+	}
 	if e.EventEmitter != nil {
 		e.EventEmitter.Emit(EventExecutionStateChange, ExecutionStateChangeEvent{
 			OldState:  oldState,
 			NewState:  newState,
-			Timestamp: time.Now(),
+			Timestamp: e.now(),
 		})
 	}
 }
 
+// executionPollInterval は実行ループが状態を確認する間隔
+const executionPollInterval = 2 * time.Second
+
 func (e *ExecutionOrchestrator) runLoop(ctx context.Context, stopCh <-chan struct{}) {
 	defer e.wg.Done()
-	ticker := time.NewTicker(2 * time.Second) // Poll every 2s
+	ticker := time.NewTicker(executionPollInterval)
 	defer ticker.Stop()
 
 	for {
@@ -205,141 +405,172 @@ func (e *ExecutionOrchestrator) runLoop(ctx context.Context, stopCh <-chan struc
 				continue // Skip if paused or idle
 			}
 
-			if e.State() == ExecutionStateIdle {
-				return
-			}
+			e.runCycle(ctx)
+		}
+	}
+}
 
-			// 0-a. Reset Retry Tasks (RETRY_WAIT -> PENDING when backoff expired)
-			if e.Scheduler != nil {
-				if reset, err := e.Scheduler.ResetRetryTasks(); err != nil {
-					e.logger.Error("failed to reset retry tasks", slog.Any("error", err))
-				} else {
-					for _, id := range reset {
-						e.emitTaskStateChange(id, TaskStatusRetryWait, TaskStatusPending)
-					}
-				}
+// runCycle は実行ループの 1 周分（定期タスクの作成・リトライ待ちと依存の解決・承認・スケジュール・ジョブの取り出し）を行う
+// 取り出したジョブは goroutine で実行し、終了を待たずに返る（シミュレーションでは Wait で待つ）。
+func (e *ExecutionOrchestrator) runCycle(ctx context.Context) {
+	// 0. Materialize Scheduled Tasks (予定時刻を過ぎた定期タスクの定義から PENDING のタスクを作成)
+	e.materializeSchedules()
+
+	// 0-a. Reset Retry Tasks (RETRY_WAIT -> PENDING when backoff expired)
+	if e.Scheduler != nil {
+		if reset, err := e.Scheduler.ResetRetryTasks(); err != nil {
+			e.logger.Error("failed to reset retry tasks", slog.Any("error", err))
+		} else {
+			for _, id := range reset {
+				e.emitTaskStateChange(id, TaskStatusRetryWait, TaskStatusPending)
 			}
+		}
+	}
 
-			// 0-b. Update Blocked Tasks (BLOCKED -> PENDING when dependencies satisfied)
-			if e.Scheduler != nil {
-				if unblocked, err := e.Scheduler.UpdateBlockedTasks(); err != nil {
-					e.logger.Error("failed to update blocked tasks", slog.Any("error", err))
-				} else {
-					for _, id := range unblocked {
-						e.emitTaskStateChange(id, TaskStatusBlocked, TaskStatusPending)
-					}
-				}
+	// 0-b. Update Blocked Tasks (BLOCKED -> PENDING when dependencies satisfied)
+	if e.Scheduler != nil {
+		if unblocked, err := e.Scheduler.UpdateBlockedTasks(); err != nil {
+			e.logger.Error("failed to update blocked tasks", slog.Any("error", err))
+		} else {
+			for _, id := range unblocked {
+				e.emitTaskStateChange(id, TaskStatusBlocked, TaskStatusPending)
 			}
+		}
+	}
 
-			// 0-c. Set BLOCKED status for pending tasks with unsatisfied dependencies
-			if e.Scheduler != nil {
-				if newlyBlocked, err := e.Scheduler.SetBlockedStatusForPendingWithUnsatisfiedDeps(); err != nil {
-					e.logger.Error("failed to set blocked status for pending tasks", slog.Any("error", err))
-				} else {
-					for _, id := range newlyBlocked {
-						e.emitTaskStateChange(id, TaskStatusPending, TaskStatusBlocked)
-					}
-				}
+	// 0-c. Set BLOCKED status for pending tasks with unsatisfied dependencies
+	if e.Scheduler != nil {
+		if newlyBlocked, err := e.Scheduler.SetBlockedStatusForPendingWithUnsatisfiedDeps(); err != nil {
+			e.logger.Error("failed to set blocked status for pending tasks", slog.Any("error", err))
+		} else {
+			for _, id := range newlyBlocked {
+				e.emitTaskStateChange(id, TaskStatusPending, TaskStatusBlocked)
 			}
+		}
+	}
 
-			// 1. Schedule Ready Tasks
-			// This moves tasks from PENDING/BLOCKED -> READY -> QUEUE
-			if e.Scheduler != nil {
-				if _, err := e.Scheduler.ScheduleReadyTasks(); err != nil {
-					e.logger.Error("failed to schedule ready tasks", slog.Any("error", err))
-				}
-			}
+	// 0-d. Approval Gates (承認済みの差分を完了させ、完了したマイルストーンの承認を依頼する)
+	e.processApprovals()
 
-			// 2. Consume from Queue (Simulating Worker Pool resource availability)
-			// Process job for each configured pool
-			for _, poolID := range e.PoolIDs {
-				job, err := e.Queue.Dequeue(poolID)
-				if err != nil {
-					e.logger.Error("failed to dequeue job", slog.String("pool_id", poolID), slog.Any("error", err))
-					continue
-				}
+	// 1. Schedule Ready Tasks
+	// This moves tasks from PENDING/BLOCKED -> READY -> QUEUE
+	if e.Scheduler != nil {
+		if _, err := e.Scheduler.ScheduleReadyTasks(); err != nil {
+			e.logger.Error("failed to schedule ready tasks", slog.Any("error", err))
+		}
+	}
 
-				if job != nil {
-					e.processJob(ctx, job)
-				}
-			}
+	// 2. Consume from Queue
+	// Pool ごとに同時実行数の空きがある限りジョブを取り出して並行実行する
+	for _, poolID := range e.PoolIDs {
+		e.dispatchPool(ctx, poolID)
+	}
+}
+
+// dispatchPool は Pool の空き枠分だけジョブを取り出して実行を開始する
+func (e *ExecutionOrchestrator) dispatchPool(ctx context.Context, poolID string) {
+	for e.tryAcquireSlot(poolID) {
+		job, err := e.Queue.Dequeue(poolID)
+		if err != nil {
+			e.releaseSlot(poolID)
+			e.logger.Error("failed to dequeue job", slog.String("pool_id", poolID), slog.Any("error", err))
+			return
 		}
+		if job == nil {
+			e.releaseSlot(poolID)
+			return
+		}
+
+		e.wg.Add(1)
+		go func(job *ipc.Job) {
+			defer e.wg.Done()
+			defer e.releaseSlot(poolID)
+			e.processJob(ctx, job)
+		}(job)
 	}
 }
 
 func (e *ExecutionOrchestrator) processJob(ctx context.Context, job *ipc.Job) {
 	e.logger.Info("processing job", slog.String("job_id", job.ID), slog.String("task_id", job.TaskID))
 
-	// Load Task
-	// We use Repo.State()
-	tasksState, err := e.Repo.State().LoadTasks()
+	// Pre-exec update: increment attempt count and set RUNNING.
+	now := e.now()
+	var (
+		task          persistence.TaskState
+		found         bool
+		attemptCount  int
+		preExecStatus TaskStatus
+		canceled      bool
+	)
+	err := e.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		t := findTaskState(tasksState, job.TaskID)
+		found = t != nil
+		if t == nil {
+			return persistence.ErrNoChange
+		}
+		if TaskStatus(t.Status) == TaskStatusCanceled {
+			canceled = true
+			return persistence.ErrNoChange
+		}
+		if t.Inputs == nil {
+			t.Inputs = make(map[string]interface{})
+		}
+		attemptCount = 0
+		switch v := t.Inputs[InputKeyAttemptCount].(type) {
+		case float64:
+			attemptCount = int(v)
+		case int:
+			attemptCount = v
+		}
+		attemptCount++
+		t.Inputs[InputKeyAttemptCount] = attemptCount
+
+		preExecStatus = TaskStatus(t.Status)
+		t.Status = string(TaskStatusRunning)
+		t.UpdatedAt = now
+		if t.StartedAt == nil {
+			t.StartedAt = &now
+		}
+		t.DoneAt = nil
+		task = *t
+		return nil
+	})
 	if err != nil {
-		e.logger.Error("failed to load tasks state", slog.Any("error", err))
+		e.logger.Error("failed to persist pre-exec task update",
+			slog.String("task_id", job.TaskID),
+			slog.Any("error", err),
+		)
 		_ = e.Queue.Complete(job.ID, job.PoolID)
 		return
 	}
-
-	var task *persistence.TaskState
-	for i := range tasksState.Tasks {
-		if tasksState.Tasks[i].TaskID == job.TaskID {
-			task = &tasksState.Tasks[i]
-			break
-		}
-	}
-	if task == nil {
+	if !found {
 		e.logger.Error("task not found in state", slog.String("task_id", job.TaskID))
 		_ = e.Queue.Complete(job.ID, job.PoolID)
 		return
 	}
-
-	// Pre-exec update: increment attempt count and set RUNNING.
-	now := time.Now()
-	if task.Inputs == nil {
-		task.Inputs = make(map[string]interface{})
-	}
-	attemptCount := 0
-	switch v := task.Inputs[InputKeyAttemptCount].(type) {
-	case float64:
-		attemptCount = int(v)
-	case int:
-		attemptCount = v
-	}
-	attemptCount++
-	task.Inputs[InputKeyAttemptCount] = attemptCount
-
-	preExecStatus := TaskStatus(task.Status)
-	task.Status = string(TaskStatusRunning)
-	task.UpdatedAt = now
+	if canceled {
+		e.logger.Info("skipping canceled task", slog.String("task_id", job.TaskID))
+		_ = e.Queue.Complete(job.ID, job.PoolID)
+		return
+	}
 	if preExecStatus != TaskStatusRunning {
 		e.emitTaskStateChange(task.TaskID, preExecStatus, TaskStatusRunning)
 	}
-	if err := e.Repo.State().SaveTasks(tasksState); err != nil {
-		e.logger.Error("failed to persist pre-exec task update",
-			slog.String("task_id", task.TaskID),
-			slog.Any("error", err),
-		)
-	}
-	e.updateLegacyTask(task.TaskID, func(t *Task) {
-		if t.StartedAt == nil {
-			t.StartedAt = &now
-		}
-		t.Status = TaskStatusRunning
-		t.AttemptCount = attemptCount
-	})
 
 	// Create cancellable context for this job
 	jobCtx, cancel := context.WithCancel(ctx)
 	e.cancelMu.Lock()
-	e.runningCancel = cancel
+	e.runningCancels[job.ID] = cancel
+	e.runningJobs[task.TaskID] = job.ID
 	e.cancelMu.Unlock()
 
 	defer func() {
 		e.cancelMu.Lock()
-		if e.runningCancel != nil {
-			// Ensure cancel is called if not already
-			cancel()
-			e.runningCancel = nil
-		}
+		// Ensure cancel is called if not already
+		cancel()
+		delete(e.runningCancels, job.ID)
+		delete(e.runningJobs, task.TaskID)
+		delete(e.canceledTasks, task.TaskID)
 		e.cancelMu.Unlock()
 	}()
 
@@ -354,9 +585,12 @@ func (e *ExecutionOrchestrator) processJob(ctx context.Context, job *ipc.Job) {
 		ID:     task.TaskID,
 		Title:  task.Kind + ":" + task.NodeID, // Title fallback
 		Status: TaskStatus(task.Status),       // constant cast
+		PoolID: job.PoolID,
 		// Other fields...
 	}
 	taskDTO.Runner = runnerSpecFromInputs(task.Inputs)
+	taskDTO.ToolingProfile = inputString(task.Inputs, InputKeyToolingProfile)
+	taskDTO.Answers = taskAnswersFromInputs(task.Inputs)
 	// Try to get Title from Design?
 	if node, err := e.Repo.Design().GetNode(task.NodeID); err == nil {
 		taskDTO.Title = node.Name
@@ -369,102 +603,134 @@ func (e *ExecutionOrchestrator) processJob(ctx context.Context, job *ipc.Job) {
 		}
 		taskDTO.AcceptanceCriteria = node.AcceptanceCriteria
 	}
+	if isVerificationTask(&task) {
+		applyVerificationPrompt(taskDTO)
+	}
 
 	oldStatus := TaskStatus(task.Status)
-	attempt, execErr := e.Executor.ExecuteTask(jobCtx, taskDTO)
-
-	// Fetch latest state (reload in case changed? or just use tasksState?)
-	// Reloading is safer for concurrency.
-	tasksState, _ = e.Repo.State().LoadTasks() // Ignore error for reload?
-	// finding task again
-	for i := range tasksState.Tasks {
-		if tasksState.Tasks[i].TaskID == job.TaskID {
-			task = &tasksState.Tasks[i]
-			break
-		}
-	}
-
-	if task != nil {
-		// Update Status from Executor result?
-		// Executor returns Attempt. Status is in Attempt.
-		// If attempt succeeded -> Succeeded.
-		if attempt != nil {
-			finishedAt := attempt.FinishedAt
-			if finishedAt == nil {
-				finished := time.Now()
-				finishedAt = &finished
+	run := e.startAttempt(task.TaskID, job.PoolID)
+	attempt, execErr := e.Executor.ExecuteTask(WithAttemptRun(jobCtx, run), taskDTO)
+	attempt = e.closeAttempt(run, attempt, execErr)
+
+	// CancelTask による取り消しは失敗扱い（リトライ・バックログ）にせず CANCELED で終える
+	if e.takeCanceled(task.TaskID) {
+		attempt.Status = AttemptStatusCanceled
+		attempt.FailureKind = ""
+		e.recordAttempt(attempt)
+		e.recordNodeActuals(task.NodeID, attempt)
+		e.finishCanceled(task.TaskID, oldStatus)
+		if err := e.Queue.Complete(job.ID, job.PoolID); err != nil {
+			e.logger.Error("failed to complete job", slog.String("job_id", job.ID), slog.Any("error", err))
+		}
+		return
+	}
+
+	if attempt != nil {
+		e.recordAttempt(attempt)
+		e.recordNodeActuals(task.NodeID, attempt)
+		e.recordFileConflicts(&task, attempt)
+		finishedAt := attempt.FinishedAt
+		if finishedAt == nil {
+			finished := e.now()
+			finishedAt = &finished
+		}
+
+		// 検証タスクのテストの成否はノードの検証結果として扱い、通常のリトライ・バックログの流れには乗せない
+		passed := execErr == nil && attempt.Status == AttemptStatusSucceeded
+		if isVerificationTask(&task) && (passed || execErr == nil || verificationTestFailure(ClassifyFailure(execErr).Kind)) {
+			if err := e.finishVerification(&task, passed, *finishedAt); err != nil {
+				e.logger.Error("failed to save verification result", slog.String("task_id", task.TaskID), slog.Any("error", err))
 			}
-			if attempt.Status == AttemptStatusSucceeded {
-				// 依存解決前に現在の状態を保存
-				if err := e.Repo.State().SaveTasks(tasksState); err != nil {
-					e.logger.Error("failed to save tasks before dependency resolution",
-						slog.String("task_id", task.TaskID),
-						slog.Any("error", err),
-					)
-				}
+			if err := e.Queue.Complete(job.ID, job.PoolID); err != nil {
+				e.logger.Error("failed to complete job", slog.String("job_id", job.ID), slog.Any("error", err))
+			}
+			return
+		}
+
+		newStatus := oldStatus
+		reimplemented := false
+		var approval *BacklogItem
+		switch attempt.Status {
+		case AttemptStatusSucceeded:
+			newStatus = TaskStatusSucceeded
+			var files []string
+			if attempt.Artifacts != nil {
+				files = attempt.Artifacts.Files
+			}
+			// 差分の承認が必要なタスクは、承認されるまでノードを implemented にせず BLOCKED で待たせる
+			if approval = e.requestDiffApproval(&task, files); approval != nil {
+				newStatus = TaskStatusBlocked
+				break
+			}
+			// ノードの実行時ステータスを更新（依存解決に必要）
+			var err error
+			reimplemented, err = e.markNodeImplemented(task.NodeID, files)
+			if err != nil {
+				// ノード更新に失敗した場合、後続タスクが永遠にブロックされる
+				// 重大なエラーとして記録し、タスクをFAILEDにする
+				e.logger.Error("critical: failed to update node runtime on success, marking task as failed",
+					slog.String("node_id", task.NodeID),
+					slog.String("task_id", task.TaskID),
+					slog.Any("error", err),
+				)
+				newStatus = TaskStatusFailed
+			}
+		case AttemptStatusFailed:
+			newStatus = TaskStatusFailed
+		}
 
-				// ノードの実行時ステータスを更新（依存解決に必要）
-				if err := e.markNodeImplemented(task.NodeID); err != nil {
-					// ノード更新に失敗した場合、後続タスクが永遠にブロックされる
-					// 重大なエラーとして記録し、タスクをFAILEDにする
-					e.logger.Error("critical: failed to update node runtime on success, marking task as failed",
-						slog.String("node_id", task.NodeID),
-						slog.String("task_id", task.TaskID),
-						slog.Any("error", err),
-					)
-					task.Status = string(TaskStatusFailed)
-					e.updateLegacyTask(task.TaskID, func(t *Task) {
-						t.Status = TaskStatusFailed
-						t.DoneAt = finishedAt
-						t.AttemptCount = attemptCount
-					})
-				} else {
-					task.Status = string(TaskStatusSucceeded)
-					task.Outputs.Status = string(TaskStatusSucceeded) // 表記統一: "SUCCEEDED" に統一
-					// Artifacts を persistence.TaskState にも同期
-					if taskDTO.Artifacts != nil {
-						task.Outputs.Files = taskDTO.Artifacts.Files
-						task.Outputs.Logs = taskDTO.Artifacts.Logs
-					}
-					e.updateLegacyTask(task.TaskID, func(t *Task) {
-						t.Status = TaskStatusSucceeded
-						t.DoneAt = finishedAt
-						t.AttemptCount = attemptCount
-						if taskDTO.Artifacts != nil {
-							t.Artifacts = taskDTO.Artifacts
-						}
-					})
-
-					// task の変更内容を persistence に保存（dependencyResolution前）
-					// ここで保存することで、task.Status = SUCCEEDED が永続化される
-					if err := e.Repo.State().SaveTasks(tasksState); err != nil {
-						e.logger.Error("failed to save tasks before dependency resolution",
-							slog.String("task_id", task.TaskID),
-							slog.Any("error", err),
-						)
-					}
-
-					// 成功時：依存解決を即時実行して後続タスクを迅速に開始
-					e.triggerDependencyResolution()
+		// 実行中に他の書き込み手が tasks.json を更新している可能性があるため、最新の状態に対して適用する
+		err := e.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+			t := findTaskState(tasksState, job.TaskID)
+			if t == nil {
+				return persistence.ErrNoChange
+			}
+			t.Status = string(newStatus)
+			if isTerminalTaskStatus(string(newStatus)) {
+				t.DoneAt = finishedAt
+			}
+			if approval != nil {
+				if t.Inputs == nil {
+					t.Inputs = make(map[string]interface{})
+				}
+				t.Inputs[InputKeyAwaitingApproval] = approval.ID
+				t.Inputs[InputKeyApprovalGate] = string(ApprovalGateDiff)
+				// 承認後の完了処理で使うため、成果物は先に同期しておく
+				if attempt.Artifacts != nil {
+					t.Outputs.Files = attempt.Artifacts.Files
+					t.Outputs.Logs = attempt.Artifacts.Logs
 				}
-			} else if attempt.Status == AttemptStatusFailed {
-				task.Status = string(TaskStatusFailed)
-				e.updateLegacyTask(task.TaskID, func(t *Task) {
-					t.Status = TaskStatusFailed
-					t.DoneAt = finishedAt
-					t.AttemptCount = attemptCount
-				})
 			}
+			if newStatus == TaskStatusSucceeded {
+				t.Outputs.Status = string(TaskStatusSucceeded) // 表記統一: "SUCCEEDED" に統一
+				// Artifacts を persistence.TaskState にも同期
+				if taskDTO.Artifacts != nil {
+					t.Outputs.Files = taskDTO.Artifacts.Files
+					t.Outputs.Logs = taskDTO.Artifacts.Logs
+				}
+			}
+			task = *t
+			return nil
+		})
+		if err != nil {
+			e.logger.Error("failed to save task result",
+				slog.String("task_id", task.TaskID),
+				slog.Any("error", err),
+			)
 		}
 
-		if task != nil && oldStatus != TaskStatus(task.Status) {
-			e.emitTaskStateChange(task.TaskID, oldStatus, TaskStatus(task.Status))
+		if newStatus != oldStatus {
+			e.emitTaskStateChange(task.TaskID, oldStatus, newStatus)
 		}
 
-		// 状態を保存
-		// 注: reload後の場合は taskポインターが古いメモリを指すため、
-		// task変更内容を反映させるため、ここで保存する
-		_ = e.Repo.State().SaveTasks(tasksState)
+		if err == nil && newStatus == TaskStatusSucceeded {
+			if reimplemented {
+				e.propagateReimplementation(task.NodeID)
+			}
+			e.scheduleVerification(&task)
+			// 成功時：依存解決を即時実行して後続タスクを迅速に開始
+			e.triggerDependencyResolution()
+		}
 	}
 
 	if execErr != nil {
@@ -489,7 +755,7 @@ func (e *ExecutionOrchestrator) processJob(ctx context.Context, job *ipc.Job) {
 		// Logic earlier was missing.
 		// I will rely on HandleFailure to use the count passed.
 
-		if handleErr := e.HandleFailure(task, execErr, attemptCount); handleErr != nil {
+		if handleErr := e.HandleFailure(&task, execErr, attemptCount); handleErr != nil {
 			e.logger.Error("failed to handle task failure", slog.String("task_id", task.TaskID), slog.Any("error", handleErr))
 		}
 	} else {
@@ -502,6 +768,60 @@ func (e *ExecutionOrchestrator) processJob(ctx context.Context, job *ipc.Job) {
 	}
 }
 
+// CancelTask はタスクを取り消す
+// 実行中であれば agent-runner を停止して CANCELED にし、未実行であれば状態を CANCELED に変更する
+// （キュー投入済みのジョブは取り出し時に破棄される）。
+func (e *ExecutionOrchestrator) CancelTask(taskID string) error {
+	e.cancelMu.Lock()
+	if jobID, ok := e.runningJobs[taskID]; ok {
+		e.canceledTasks[taskID] = struct{}{}
+		if cancel, ok := e.runningCancels[jobID]; ok {
+			cancel()
+		}
+		e.cancelMu.Unlock()
+		e.logger.Info("canceling running task", slog.String("task_id", taskID), slog.String("job_id", jobID))
+		return nil
+	}
+	e.cancelMu.Unlock()
+
+	oldStatus, err := CancelTaskState(e.Repo, taskID)
+	if err != nil {
+		return err
+	}
+	e.emitTaskStateChange(taskID, oldStatus, TaskStatusCanceled)
+	return nil
+}
+
+// takeCanceled は taskID に取り消し要求があったかを返し、要求を消費する
+func (e *ExecutionOrchestrator) takeCanceled(taskID string) bool {
+	e.cancelMu.Lock()
+	defer e.cancelMu.Unlock()
+	_, ok := e.canceledTasks[taskID]
+	delete(e.canceledTasks, taskID)
+	return ok
+}
+
+// finishCanceled は取り消された実行中タスクを CANCELED として保存する
+func (e *ExecutionOrchestrator) finishCanceled(taskID string, oldStatus TaskStatus) {
+	err := e.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		t := findTaskState(tasksState, taskID)
+		if t == nil {
+			return persistence.ErrNoChange
+		}
+		now := e.now()
+		t.Status = string(TaskStatusCanceled)
+		t.UpdatedAt = now
+		t.DoneAt = &now
+		return nil
+	})
+	if err != nil {
+		e.logger.Error("failed to save canceled task", slog.String("task_id", taskID), slog.Any("error", err))
+		return
+	}
+	e.emitTaskStateChange(taskID, oldStatus, TaskStatusCanceled)
+	e.logger.Info("task canceled", slog.String("task_id", taskID))
+}
+
 // emitTaskStateChange はタスク状態変更イベントを発行する
 func (e *ExecutionOrchestrator) emitTaskStateChange(taskID string, oldStatus, newStatus TaskStatus) {
 	if e.EventEmitter != nil {
@@ -509,51 +829,57 @@ func (e *ExecutionOrchestrator) emitTaskStateChange(taskID string, oldStatus, ne
 			TaskID:    taskID,
 			OldStatus: oldStatus,
 			NewStatus: newStatus,
-			Timestamp: time.Now(),
+			Timestamp: e.now(),
 		})
 	}
 }
 
 // markNodeImplemented updates NodesRuntime so dependency resolution can proceed.
-func (e *ExecutionOrchestrator) markNodeImplemented(nodeID string) error {
+// files は変更したファイルで、NodeImplementation.Files に加える（変更の影響の分析に使う）。
+// 既に implemented / verified だったノード（再実装）であれば true を返す。
+func (e *ExecutionOrchestrator) markNodeImplemented(nodeID string, files []string) (bool, error) {
 	if nodeID == "" || e.Repo == nil {
-		return nil
-	}
-	now := time.Now()
-	nodesRuntime, err := e.Repo.State().LoadNodesRuntime()
-	if err != nil {
-		return err
+		return false, nil
 	}
-
-	for i := range nodesRuntime.Nodes {
-		if nodesRuntime.Nodes[i].NodeID == nodeID {
-			nodesRuntime.Nodes[i].Status = string(persistence.NodeRuntimeStatusImplemented)
-			nodesRuntime.Nodes[i].Implementation.LastModifiedAt = now
-			nodesRuntime.Nodes[i].Implementation.LastModifiedBy = "agent-runner"
-			if nodesRuntime.Nodes[i].Implementation.Files == nil {
-				nodesRuntime.Nodes[i].Implementation.Files = []string{}
+	now := e.now()
+	reimplemented := false
+	err := e.Repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		reimplemented = false
+		for i := range nodesRuntime.Nodes {
+			if nodesRuntime.Nodes[i].NodeID == nodeID {
+				switch persistence.NodeRuntimeStatus(nodesRuntime.Nodes[i].Status) {
+				case persistence.NodeRuntimeStatusImplemented, persistence.NodeRuntimeStatusVerified:
+					reimplemented = true
+				}
+				nodesRuntime.Nodes[i].Status = string(persistence.NodeRuntimeStatusImplemented)
+				// 実装し直したので以前の検証結果は使えない
+				nodesRuntime.Nodes[i].Verification.Status = string(persistence.NodeVerificationNotTested)
+				nodesRuntime.Nodes[i].Verification.StaleReason = ""
+				nodesRuntime.Nodes[i].Implementation.LastModifiedAt = now
+				nodesRuntime.Nodes[i].Implementation.LastModifiedBy = "agent-runner"
+				nodesRuntime.Nodes[i].Implementation.Files = normalizeWritePaths(append(nodesRuntime.Nodes[i].Implementation.Files, files...))
+				return nil
 			}
-			return e.Repo.State().SaveNodesRuntime(nodesRuntime)
-		}
-	}
-
-	nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
-		NodeID: nodeID,
-		Status: string(persistence.NodeRuntimeStatusImplemented),
-		Implementation: persistence.NodeImplementation{
-			Files:          []string{},
-			LastModifiedAt: now,
-			LastModifiedBy: "agent-runner",
-		},
-		Verification: persistence.NodeVerification{
-			Status: "not_tested",
-		},
-		Notes: []persistence.NodeNote{
-			{At: now, By: "execution-orchestrator", Text: "auto-marked implemented on task success"},
-		},
-	})
+		}
 
-	return e.Repo.State().SaveNodesRuntime(nodesRuntime)
+		nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
+			NodeID: nodeID,
+			Status: string(persistence.NodeRuntimeStatusImplemented),
+			Implementation: persistence.NodeImplementation{
+				Files:          normalizeWritePaths(files),
+				LastModifiedAt: now,
+				LastModifiedBy: "agent-runner",
+			},
+			Verification: persistence.NodeVerification{
+				Status: string(persistence.NodeVerificationNotTested),
+			},
+			Notes: []persistence.NodeNote{
+				{At: now, By: "execution-orchestrator", Text: "auto-marked implemented on task success"},
+			},
+		})
+		return nil
+	})
+	return reimplemented, err
 }
 
 func runnerSpecFromInputs(inputs map[string]interface{}) *RunnerSpec {
@@ -589,21 +915,94 @@ func runnerSpecFromInputs(inputs map[string]interface{}) *RunnerSpec {
 	}
 }
 
-func (e *ExecutionOrchestrator) updateLegacyTask(taskID string, update func(t *Task)) {
-	if e.Repo == nil || taskID == "" {
-		return
+// maxAttemptErrorLen は記録する試行のエラー要約の最大長（agent-runner の出力全体を含むことがあるため）
+// 出力全体は試行ログに残る。
+const maxAttemptErrorLen = 4096
+
+// startAttempt は実行試行の記録（RUNNING）とログを作成し、history に開始を記録する
+// 記録に失敗しても実行は続け、試行 ID だけを返す。
+func (e *ExecutionOrchestrator) startAttempt(taskID, poolID string) *AttemptRun {
+	run := &AttemptRun{ID: uuid.New().String(), StartedAt: e.now(), taskID: taskID, poolID: poolID}
+	if e.Repo == nil {
+		return run
 	}
-	store := NewTaskStore(e.Repo.BaseDir())
-	legacy, err := store.LoadTask(taskID)
-	if err != nil {
+	logger := e.logger.With(slog.String("task_id", taskID), slog.String("attempt_id", run.ID))
+	record := &persistence.AttemptRecord{
+		AttemptID: run.ID,
+		TaskID:    taskID,
+		PoolID:    poolID,
+		Status:    string(AttemptStatusRunning),
+		StartedAt: run.StartedAt,
+	}
+	if err := e.Repo.Attempts().SaveAttempt(record); err != nil {
+		logger.Warn("failed to save attempt record", slog.Any("error", err))
+		return run
+	}
+	if log, err := e.Repo.Attempts().OpenLog(run.ID); err != nil {
+		logger.Warn("failed to open attempt log", slog.Any("error", err))
+	} else {
+		run.Log = log
+	}
+	payload := persistence.AttemptPayload{TaskID: taskID, AttemptID: run.ID}
+	if err := appendAttemptAction(e.Repo, persistence.ActionTaskAttemptStarted, run.StartedAt, payload); err != nil {
+		logger.Warn("failed to record attempt start", slog.Any("error", err))
+	}
+	return run
+}
+
+// closeAttempt は試行ログを閉じ（圧縮し）、Executor の結果を試行 ID に揃えて返す
+// Executor が試行を返さなかった場合は execErr から失敗の試行を作る。
+func (e *ExecutionOrchestrator) closeAttempt(run *AttemptRun, attempt *Attempt, execErr error) *Attempt {
+	if run.Log != nil {
+		if err := run.Log.Close(); err != nil {
+			e.logger.Warn("failed to close attempt log", slog.String("attempt_id", run.ID), slog.Any("error", err))
+		}
+	}
+	if attempt == nil {
+		finishedAt := e.now()
+		attempt = &Attempt{Status: AttemptStatusFailed, StartedAt: run.StartedAt, FinishedAt: &finishedAt}
+		if execErr != nil {
+			attempt.ErrorSummary = execErr.Error()
+		}
+	}
+	if attempt.Status == AttemptStatusFailed && attempt.FailureKind == "" && execErr != nil {
+		attempt.FailureKind = ClassifyFailure(execErr).Kind
+	}
+	attempt.ID = run.ID
+	attempt.TaskID = run.taskID
+	if attempt.PoolID == "" {
+		attempt.PoolID = run.poolID
+	}
+	if run.Log != nil && run.Log.Lines() > attempt.LogLines {
+		attempt.LogLines = run.Log.Lines()
+	}
+	return attempt
+}
+
+// recordAttempt は終了した実行試行の記録を確定し、history に終了を記録する
+func (e *ExecutionOrchestrator) recordAttempt(attempt *Attempt) {
+	if attempt == nil || e.Repo == nil {
 		return
 	}
-	update(legacy)
-	if err := store.SaveTask(legacy); err != nil {
-		e.logger.Debug("failed to save legacy task",
-			slog.String("task_id", taskID),
-			slog.Any("error", err),
-		)
+	if attempt.FinishedAt == nil {
+		finishedAt := e.now()
+		attempt.FinishedAt = &finishedAt
+	}
+	if len(attempt.ErrorSummary) > maxAttemptErrorLen {
+		attempt.ErrorSummary = attempt.ErrorSummary[:maxAttemptErrorLen] + "..."
+	}
+	logger := e.logger.With(slog.String("task_id", attempt.TaskID), slog.String("attempt_id", attempt.ID))
+	if err := e.Repo.Attempts().SaveAttempt(attemptRecord(attempt)); err != nil {
+		logger.Warn("failed to save attempt record", slog.Any("error", err))
+	}
+	payload := persistence.AttemptPayload{
+		TaskID:    attempt.TaskID,
+		AttemptID: attempt.ID,
+		Status:    string(attempt.Status),
+		Error:     attempt.ErrorSummary,
+	}
+	if err := appendAttemptAction(e.Repo, attemptResultKind(attempt.Status), *attempt.FinishedAt, payload); err != nil {
+		logger.Warn("failed to record attempt result", slog.Any("error", err))
 	}
 }
 
@@ -640,58 +1039,59 @@ func (e *ExecutionOrchestrator) HandleFailure(task *persistence.TaskState, execE
 		return nil
 	}
 
-	nextAction := e.RetryPolicy.DetermineNextAction(attemptNum)
+	// 失敗の分類（レート制限・認証など）とタスク種別でポリシーを選ぶ
+	failure := ClassifyFailure(execErr)
+	policy := e.retryPolicyFor(task.Kind, failure.Kind)
+	nextAction := policy.DetermineNextAction(attemptNum)
 
 	switch nextAction {
 	case NextActionRetry:
 		// リトライをスケジュール (DB更新)
-		backoff := e.RetryPolicy.CalculateBackoff(attemptNum)
-		nextRetryAt := time.Now().Add(backoff)
+		// プロバイダが待ち時間（レート制限のクールダウン）を指定した場合はそれより早く再実行しない
+		backoff := max(policy.CalculateBackoff(attemptNum), failure.RetryAfter)
+		nextRetryAt := e.now().Add(backoff)
 
 		e.logger.Info("scheduling retry (persisted)",
 			slog.String("task_id", task.TaskID),
+			slog.String("failure_kind", string(failure.Kind)),
 			slog.Int("attempt", attemptNum),
 			slog.Duration("backoff", backoff),
 			slog.Time("next_retry_at", nextRetryAt),
 		)
 
-		// Load state to update
-		tasksState, err := e.Repo.State().LoadTasks()
-		if err != nil {
-			return fmt.Errorf("failed to load tasks for retry: %w", err)
-		}
-
-		var taskState *persistence.TaskState
-		for i := range tasksState.Tasks {
-			if tasksState.Tasks[i].TaskID == task.TaskID {
-				taskState = &tasksState.Tasks[i]
-				break
+		err := e.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+			taskState := findTaskState(tasksState, task.TaskID)
+			if taskState == nil {
+				return fmt.Errorf("task not found for retry: %s", task.TaskID)
 			}
-		}
-		if taskState == nil {
-			return fmt.Errorf("task not found for retry: %s", task.TaskID)
-		}
-
-		taskState.Status = string(TaskStatusRetryWait)
-		// Store next_retry_at in inputs map for now
-		if taskState.Inputs == nil {
-			taskState.Inputs = make(map[string]interface{})
-		}
-		taskState.Inputs[InputKeyNextRetryAt] = nextRetryAt.Format(time.RFC3339)
 
-		if err := e.Repo.State().SaveTasks(tasksState); err != nil {
+			taskState.Status = string(TaskStatusRetryWait)
+			// Store next_retry_at in inputs map for now
+			if taskState.Inputs == nil {
+				taskState.Inputs = make(map[string]interface{})
+			}
+			taskState.Inputs[InputKeyNextRetryAt] = nextRetryAt.Format(time.RFC3339)
+			return nil
+		})
+		if err != nil {
 			return fmt.Errorf("failed to save retry state: %w", err)
 		}
 
 		e.emitTaskStateChange(task.TaskID, TaskStatusFailed, TaskStatusRetryWait)
-		e.updateLegacyTask(task.TaskID, func(t *Task) {
-			t.Status = TaskStatusRetryWait
-			nr := nextRetryAt
-			t.NextRetryAt = &nr
-		})
 		return nil
 
 	case NextActionBacklog:
+		// 自動再計画が有効なら、人間に渡す前に計画を変えて続行する
+		outcome, replanErr := e.tryAutoReplan(task, failure, execErr)
+		if replanErr != nil {
+			e.logger.Warn("auto replan failed, falling back to backlog",
+				slog.String("task_id", task.TaskID),
+				slog.Any("error", replanErr),
+			)
+		} else if outcome != "" {
+			return nil
+		}
+
 		// バックログに追加
 		if e.BacklogStore == nil {
 			e.logger.Warn("no backlog store configured, cannot add to backlog")
@@ -699,12 +1099,17 @@ func (e *ExecutionOrchestrator) HandleFailure(task *persistence.TaskState, execE
 		}
 		e.logger.Info("adding task to backlog for human review",
 			slog.String("task_id", task.TaskID),
+			slog.String("failure_kind", string(failure.Kind)),
 			slog.Int("attempts", attemptNum),
 		)
 		// Title needed. task is TaskState.
 		// Need better title fallback. "Task {Kind}:{NodeID}"?
 		title := fmt.Sprintf("%s: %s", task.Kind, task.NodeID)
 		item := CreateFailureItem(task.TaskID, title, execErr, attemptNum)
+		item.Metadata["failureKind"] = string(failure.Kind)
+		if replanErr != nil {
+			item.Metadata["replanError"] = replanErr.Error()
+		}
 		if err := e.BacklogStore.Add(item); err != nil {
 			return fmt.Errorf("failed to add to backlog: %w", err)
 		}
@@ -716,7 +1121,7 @@ func (e *ExecutionOrchestrator) HandleFailure(task *persistence.TaskState, execE
 
 	case NextActionFail:
 		// 失敗としてマーク（既に Executor で実施済み）
-		e.logger.Warn("task permanently failed", slog.String("task_id", task.TaskID))
+		e.logger.Warn("task permanently failed", slog.String("task_id", task.TaskID), slog.String("failure_kind", string(failure.Kind)))
 		return nil
 
 	default:
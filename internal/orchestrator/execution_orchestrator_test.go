@@ -2,6 +2,8 @@ package orchestrator
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockEventEmitter is a mock implementation of EventEmitter
@@ -78,8 +81,57 @@ func TestExecutionOrchestrator_DependencyOrderExecution(t *testing.T) {
 }
 
 func TestExecutionOrchestrator_ConcurrentExecution(t *testing.T) {
-	t.Run("respects maxConcurrent limit", func(t *testing.T) {
-		t.Skip("Not implemented - current design processes one task at a time")
+	t.Run("respects pool concurrency limit", func(t *testing.T) {
+		repo, queue := setupTestRepo(t)
+		now := time.Now()
+
+		var tasks []persistence.TaskState
+		for _, id := range []string{"task-1", "task-2", "task-3"} {
+			tasks = append(tasks, persistence.TaskState{
+				TaskID: id, NodeID: "node-" + id, Kind: "test",
+				Status: string(TaskStatusReady), CreatedAt: now,
+			})
+			_ = queue.Enqueue(&ipc.Job{ID: "job-" + id, TaskID: id, PoolID: "default"})
+		}
+		saveState(t, repo, tasks, nil)
+
+		started := make(chan string, 3)
+		release := make(chan struct{})
+		mockExecutor := new(MockExecutor)
+		mockExecutor.On("ExecuteTask", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			started <- args.Get(1).(*Task).ID
+			<-release
+		}).Return(&Attempt{Status: AttemptStatusSucceeded}, nil)
+
+		orch := NewExecutionOrchestrator(nil, mockExecutor, repo, queue, nil, nil, []string{"default"})
+		orch.SetWorkerPools(&WorkerPoolsConfig{Pools: []Pool{{ID: "default", Concurrency: 2}}})
+
+		require.NoError(t, orch.Start(context.Background()))
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected two tasks to start concurrently")
+			}
+		}
+
+		// 3 つ目は枠が空くまで開始されない
+		select {
+		case id := <-started:
+			t.Fatalf("task %s started beyond concurrency limit", id)
+		case <-time.After(2500 * time.Millisecond):
+		}
+
+		close(release)
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("third task did not start after slots were released")
+		}
+
+		_ = orch.Stop()
+		orch.Wait()
 	})
 }
 
@@ -388,3 +440,154 @@ func TestExecutionOrchestrator_processJob_MarksNodeImplementedOnSuccess(t *testi
 
 	mockExecutor.AssertExpectations(t)
 }
+
+func TestExecutionOrchestrator_processJob_RecordsAttemptAndLog(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	now := time.Now()
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", Kind: "implementation", Status: string(TaskStatusPending), CreatedAt: now, UpdatedAt: now},
+	}, nil)
+
+	exitCode := 1
+	mockExecutor := new(MockExecutor)
+	mockExecutor.On("ExecuteTask", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		run := AttemptRunFromContext(args.Get(0).(context.Context))
+		require.NotNil(t, run)
+		require.NotNil(t, run.Log)
+		_ = run.Log.WriteLine("stdout", "building")
+		_ = run.Log.WriteLine("stderr", "build failed")
+	}).Return(&Attempt{Status: AttemptStatusFailed, ExitCode: &exitCode, ErrorSummary: "exit status 1"}, nil)
+
+	orch := NewExecutionOrchestrator(nil, mockExecutor, repo, queue, nil, nil, []string{"default"})
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-1", TaskID: "task-1", PoolID: "default"})
+
+	attempts, err := ListTaskAttempts(repo, "task-1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	attempt := attempts[0]
+	assert.NotEmpty(t, attempt.ID)
+	assert.Equal(t, "default", attempt.PoolID)
+	assert.Equal(t, AttemptStatusFailed, attempt.Status)
+	require.NotNil(t, attempt.FinishedAt)
+	require.NotNil(t, attempt.ExitCode)
+	assert.Equal(t, 1, *attempt.ExitCode)
+	assert.Equal(t, "exit status 1", attempt.ErrorSummary)
+	assert.Equal(t, 2, attempt.LogLines)
+
+	// ログは終了時に圧縮される
+	runDir := filepath.Join(repo.BaseDir(), persistence.AttemptsDirName, attempt.ID)
+	_, err = os.Stat(filepath.Join(runDir, "output.jsonl.gz"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(runDir, "output.jsonl"))
+	assert.True(t, os.IsNotExist(err))
+
+	page, err := ReadAttemptLog(repo, attempt.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 2)
+	assert.Equal(t, "stderr", page.Lines[1].Stream)
+	assert.Equal(t, "build failed", page.Lines[1].Line)
+	assert.True(t, page.Complete)
+
+	// 開始・終了は history にも記録される
+	actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	var kinds []string
+	for _, a := range actions {
+		if isAttemptAction(a.Kind) {
+			kinds = append(kinds, a.Kind)
+		}
+	}
+	assert.Equal(t, []string{persistence.ActionTaskAttemptStarted, persistence.ActionTaskFailed}, kinds)
+}
+
+func TestExecutionOrchestrator_SingleInstancePerWorkspace(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	first := NewExecutionOrchestrator(nil, &MockExecutor{}, repo, queue, nil, nil, nil)
+	second := NewExecutionOrchestrator(nil, &MockExecutor{}, repo, queue, nil, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, first.Start(ctx))
+	err := second.Start(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, persistence.ErrLeaderLockHeld)
+	assert.Equal(t, ExecutionStateIdle, second.State())
+
+	info, ok := persistence.ActiveLeader(repo.BaseDir(), persistence.DefaultLeaderStaleAfter)
+	require.True(t, ok)
+	assert.Equal(t, string(ExecutionStateRunning), info.State)
+
+	// 停止するとロックが解放され、別インスタンスが起動できる
+	require.NoError(t, first.Stop())
+	first.Wait()
+	require.NoError(t, second.Start(ctx))
+	require.NoError(t, second.Stop())
+	second.Wait()
+}
+
+func TestExecutionOrchestrator_CancelTask(t *testing.T) {
+	emitter := new(MockEventEmitter)
+	emitter.On("Emit", mock.Anything, mock.Anything).Return()
+
+	repo, queue := setupTestRepo(t)
+	now := time.Now()
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-run", NodeID: "node-1", Kind: "test", Status: string(TaskStatusPending), CreatedAt: now},
+		{TaskID: "task-wait", NodeID: "node-2", Kind: "test", Status: string(TaskStatusPending), CreatedAt: now},
+		{TaskID: "task-done", NodeID: "node-3", Kind: "test", Status: string(TaskStatusSucceeded), CreatedAt: now},
+	}, nil)
+
+	mockExecutor := new(MockExecutor)
+	executeCalled := make(chan struct{})
+	mockExecutor.On("ExecuteTask", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		close(executeCalled)
+		<-args.Get(0).(context.Context).Done()
+	}).Return(&Attempt{Status: AttemptStatusFailed}, context.Canceled).Once()
+
+	orch := NewExecutionOrchestrator(nil, mockExecutor, repo, queue, emitter, NewBacklogStore(repo.BaseDir()), []string{"default"})
+
+	statusOf := func(taskID string) string {
+		tasksState, err := repo.State().LoadTasks()
+		require.NoError(t, err)
+		for _, ts := range tasksState.Tasks {
+			if ts.TaskID == taskID {
+				return ts.Status
+			}
+		}
+		return ""
+	}
+
+	// 未実行タスクは状態のみ CANCELED になり、キューのジョブは実行されずに破棄される
+	require.NoError(t, orch.CancelTask("task-wait"))
+	assert.Equal(t, string(TaskStatusCanceled), statusOf("task-wait"))
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-wait", TaskID: "task-wait", PoolID: "default"})
+	assert.Equal(t, string(TaskStatusCanceled), statusOf("task-wait"))
+
+	// 完了済みタスクは取り消せない
+	assert.Error(t, orch.CancelTask("task-done"))
+
+	// 実行中タスクは実行を中断し、リトライ・バックログに回さず CANCELED になる
+	done := make(chan struct{})
+	go func() {
+		orch.processJob(context.Background(), &ipc.Job{ID: "job-run", TaskID: "task-run", PoolID: "default"})
+		close(done)
+	}()
+	select {
+	case <-executeCalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ExecuteTask was not called within timeout")
+	}
+	require.NoError(t, orch.CancelTask("task-run"))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processJob did not return after cancel")
+	}
+	assert.Equal(t, string(TaskStatusCanceled), statusOf("task-run"))
+
+	items, err := orch.BacklogStore.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	mockExecutor.AssertExpectations(t)
+}
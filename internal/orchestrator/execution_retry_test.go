@@ -133,3 +133,44 @@ func TestRetryPersistence_Integration(t *testing.T) {
 		assert.True(t, ok, "next_retry_at should stay")
 	})
 }
+
+func TestHandleFailure_PolicyByFailureKind(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	backlogStore := NewBacklogStore(repo.BaseDir())
+	orch := NewExecutionOrchestrator(nil, nil, repo, queue, nil, backlogStore, []string{"default"})
+	now := time.Now()
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-auth", Kind: "implementation", Status: string(TaskStatusFailed), CreatedAt: now},
+		{TaskID: "task-rate", Kind: "implementation", Status: string(TaskStatusFailed), CreatedAt: now},
+	}, nil)
+
+	// 認証の失敗は 1 回目でバックログへ
+	authErr := &ExecutionError{Kind: FailureAuth, Err: fmt.Errorf("Codex CLI session not found")}
+	require.NoError(t, orch.HandleFailure(&persistence.TaskState{TaskID: "task-auth", Kind: "implementation"}, authErr, 1))
+	items, err := backlogStore.ListUnresolved()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "task-auth", items[0].TaskID)
+	assert.Equal(t, string(FailureAuth), items[0].Metadata["failureKind"])
+
+	// レート制限はプロバイダのクールダウンより早く再実行しない
+	rateErr := &ExecutionError{Kind: FailureRateLimit, RetryAfter: time.Hour, Err: fmt.Errorf("429 Too Many Requests")}
+	require.NoError(t, orch.HandleFailure(&persistence.TaskState{TaskID: "task-rate", Kind: "implementation"}, rateErr, 1))
+	state, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	rate := findTaskState(state, "task-rate")
+	require.NotNil(t, rate)
+	assert.Equal(t, string(TaskStatusRetryWait), rate.Status)
+	retryAt, err := time.Parse(time.RFC3339, rate.Inputs[InputKeyNextRetryAt].(string))
+	require.NoError(t, err)
+	assert.True(t, retryAt.After(now.Add(59*time.Minute)), "retry at %s", retryAt)
+
+	// タスク種別ごとのルールで上書きできる
+	orch.SetRetryPolicies(&RetryPoliciesConfig{Rules: []RetryRule{
+		{TaskKinds: []string{"implementation"}, FailureKinds: []FailureKind{FailureAuth}, Policy: RetryPolicySpec{MaxAttempts: 3}},
+	}})
+	require.NoError(t, orch.HandleFailure(&persistence.TaskState{TaskID: "task-auth", Kind: "implementation"}, authErr, 1))
+	state, err = repo.State().LoadTasks()
+	require.NoError(t, err)
+	assert.Equal(t, string(TaskStatusRetryWait), findTaskState(state, "task-auth").Status)
+}
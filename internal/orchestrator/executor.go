@@ -12,14 +12,20 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/biwakonbu/agent-runner/internal/logging"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/biwakonbu/agent-runner/internal/tooling"
 	"github.com/biwakonbu/agent-runner/pkg/config"
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
 
+// maxLogLineSize は agent-runner の出力 1 行の最大長（worker の出力全体を含む構造化ログがあるため大きめ）
+const maxLogLineSize = 16 * 1024 * 1024
+
 // TaskExecutor defines the interface for executing tasks
 type TaskExecutor interface {
 	ExecuteTask(ctx context.Context, task *Task) (*Attempt, error)
@@ -30,6 +36,7 @@ type Executor struct {
 	AgentRunnerPath string // Path to agent-runner binary
 	ProjectRoot     string // Root directory of the project
 	ToolingConfig   *config.ToolingConfig
+	WorkerPools     *WorkerPoolsConfig // Pool ごとの tooling プロファイル / Worker イメージ
 	logger          *slog.Logger
 	events          EventEmitter // Event emitter for streaming logs
 }
@@ -59,18 +66,52 @@ func (e *Executor) SetToolingConfig(cfg *config.ToolingConfig) {
 	e.ToolingConfig = cfg
 }
 
+// SetWorkerPools は Pool ごとの実行設定（tooling プロファイル、Worker イメージ）を反映する。
+func (e *Executor) SetWorkerPools(cfg *WorkerPoolsConfig) {
+	e.WorkerPools = cfg
+}
+
+// toolingConfigFor はタスクに適用する tooling 設定を返す（未設定なら nil）
+func (e *Executor) toolingConfigFor(task *Task) *config.ToolingConfig {
+	if e.ToolingConfig == nil {
+		return nil
+	}
+	profile := task.ToolingProfile
+	if pool, ok := e.poolFor(task); ok && profile == "" {
+		profile = pool.ToolingProfile
+	}
+	if profile != "" {
+		// タスク・Pool 指定のプロファイルで ActiveProfile を上書き（元の設定は変更しない）
+		overridden := *e.ToolingConfig
+		overridden.ActiveProfile = profile
+		return &overridden
+	}
+	return e.ToolingConfig
+}
+
+// rateLimitCooldown はタスクの tooling 設定の worker カテゴリのクールダウンを返す（未設定なら 0）
+func (e *Executor) rateLimitCooldown(task *Task) time.Duration {
+	cfg := e.toolingConfigFor(task)
+	if cfg == nil {
+		return 0
+	}
+	return time.Duration(tooling.NewSelector(cfg).CooldownSec(tooling.CategoryWorker)) * time.Second
+}
+
+// poolFor はタスクの Pool 設定を返す
+func (e *Executor) poolFor(task *Task) (Pool, bool) {
+	if e.WorkerPools == nil || task.PoolID == "" {
+		return Pool{}, false
+	}
+	return e.WorkerPools.FindPool(task.PoolID)
+}
+
 // ExecuteTask runs the agent-runner for a given task.
 func (e *Executor) ExecuteTask(ctx context.Context, task *Task) (*Attempt, error) {
 	logger := logging.WithTraceID(e.logger, ctx)
 	start := time.Now()
 
-	// Create new attempt
-	attempt := &Attempt{
-		ID:        uuid.New().String(),
-		TaskID:    task.ID,
-		Status:    AttemptStatusRunning,
-		StartedAt: time.Now(),
-	}
+	attempt, attemptLog := newExecutionAttempt(ctx, task)
 
 	logger.Info("starting task execution",
 		slog.String("task_id", task.ID),
@@ -91,7 +132,7 @@ func (e *Executor) ExecuteTask(ctx context.Context, task *Task) (*Attempt, error
 	// QH-007: Pre-flight check for worker session
 	if err := e.verifyPreFlight(ctx, task); err != nil {
 		logger.Error("pre-flight check failed", slog.Any("error", err))
-		return e.handleExecutionError(attempt, task, err)
+		return e.handleExecutionError(attempt, task, &ExecutionError{Kind: FailureAuth, Err: err})
 	}
 
 	// Execute agent-runner
@@ -117,66 +158,93 @@ func (e *Executor) ExecuteTask(ctx context.Context, task *Task) (*Attempt, error
 		return e.handleExecutionError(attempt, task, err)
 	}
 
-	// Setup stdout/stderr streaming if event emitter is available
-	var stdoutPipe, stderrPipe io.ReadCloser
-	var outputBuf bytes.Buffer
-	// Capture artifacts from log stream
-	var capturedArtifacts []string
+	// stdout/stderr を行単位で読み、試行ログへの記録・イベント配信・構造化ログの解釈を行う
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		logger.Error("failed to create stdout pipe", slog.Any("error", err))
+		return e.handleExecutionError(attempt, task, err)
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		logger.Error("failed to create stderr pipe", slog.Any("error", err))
+		return e.handleExecutionError(attempt, task, err)
+	}
 
-	if e.events != nil {
-		stdoutPipe, err = cmd.StdoutPipe()
-		if err != nil {
-			logger.Error("failed to create stdout pipe", slog.Any("error", err))
-			return e.handleExecutionError(attempt, task, err)
-		}
-		stderrPipe, err = cmd.StderrPipe()
-		if err != nil {
-			logger.Error("failed to create stderr pipe", slog.Any("error", err))
-			return e.handleExecutionError(attempt, task, err)
+	var (
+		outputMu          sync.Mutex
+		outputBuf         bytes.Buffer
+		capturedArtifacts []string // Capture artifacts from log stream
+		reportedFailure   *runnerFailure
+		lastLine          string
+		streams           sync.WaitGroup
+	)
+	hooks := structuredLogHooks{
+		onArtifacts: func(artifacts []string) {
+			outputMu.Lock()
+			capturedArtifacts = artifacts
+			outputMu.Unlock()
+		},
+		onTooling: func(tooling AttemptTooling) {
+			outputMu.Lock()
+			attempt.Tooling = &tooling
+			outputMu.Unlock()
+		},
+		onFailure: func(failure runnerFailure) {
+			outputMu.Lock()
+			reportedFailure = &failure
+			outputMu.Unlock()
+		},
+		onWorkerRun: func(usage workerRunUsage) {
+			outputMu.Lock()
+			attempt.WorkerRuns++
+			attempt.InputTokens += usage.inputTokens
+			attempt.OutputTokens += usage.outputTokens
+			attempt.CostUSD += usage.costUSD
+			outputMu.Unlock()
+		},
+	}
+	handleLine := func(stream, line string) {
+		outputMu.Lock()
+		outputBuf.WriteString(line + "\n")
+		if strings.TrimSpace(line) != "" {
+			lastLine = line
 		}
+		outputMu.Unlock()
 
-		// Stream stdout
-		go func() {
-			scanner := bufio.NewScanner(stdoutPipe)
-			for scanner.Scan() {
-				line := scanner.Text()
-				outputBuf.WriteString(line + "\n")
-
-				// Try parsing as structured log/event
-				var entry map[string]interface{}
-				if err := json.Unmarshal([]byte(line), &entry); err == nil {
-					e.handleStructuredLog(task.ID, task.Title, entry, func(artifacts []string) {
-						capturedArtifacts = artifacts
-					})
-				}
+		if attemptLog != nil {
+			if err := attemptLog.WriteLine(stream, line); err != nil {
+				logger.Warn("failed to write attempt log", slog.Any("error", err))
+			}
+		}
 
-				e.events.Emit(EventTaskLog, TaskLogEvent{
-					TaskID:    task.ID,
-					Stream:    "stdout",
-					Line:      line,
-					Timestamp: time.Now(),
-				})
+		if stream == "stdout" {
+			// Try parsing as structured log/event
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err == nil {
+				e.handleStructuredLog(task.ID, task.Title, entry, hooks)
 			}
-		}()
+		}
 
-		// Stream stderr
-		go func() {
-			scanner := bufio.NewScanner(stderrPipe)
+		if e.events != nil {
+			e.events.Emit(EventTaskLog, TaskLogEvent{
+				TaskID:    task.ID,
+				AttemptID: attempt.ID,
+				Stream:    stream,
+				Line:      line,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	for stream, pipe := range map[string]io.ReadCloser{"stdout": stdoutPipe, "stderr": stderrPipe} {
+		streams.Add(1)
+		go func(stream string, pipe io.ReadCloser) {
+			defer streams.Done()
+			scanner := bufio.NewScanner(pipe)
+			scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
 			for scanner.Scan() {
-				line := scanner.Text()
-				outputBuf.WriteString(line + "\n")
-				e.events.Emit(EventTaskLog, TaskLogEvent{
-					TaskID:    task.ID,
-					Stream:    "stderr",
-					Line:      line,
-					Timestamp: time.Now(),
-				})
+				handleLine(stream, scanner.Text())
 			}
-		}()
-	} else {
-		// Fallback: use CombinedOutput if no event emitter
-		cmd.Stdout = &outputBuf
-		cmd.Stderr = &outputBuf
+		}(stream, pipe)
 	}
 
 	go func() {
@@ -193,21 +261,37 @@ func (e *Executor) ExecuteTask(ctx context.Context, task *Task) (*Attempt, error
 	err = cmd.Start()
 	if err != nil {
 		logger.Error("failed to start agent-runner", slog.Any("error", err))
-		return e.handleExecutionError(attempt, task, err)
+		return e.handleExecutionError(attempt, task, &ExecutionError{Kind: FailureSandboxInfra, Err: err})
 	}
 
+	// パイプを読み切ってから Wait する（Wait はパイプを閉じるため）
+	streams.Wait()
 	err = cmd.Wait()
 	finishedAt := time.Now()
 	attempt.FinishedAt = &finishedAt
+	if cmd.ProcessState != nil {
+		exitCode := cmd.ProcessState.ExitCode()
+		attempt.ExitCode = &exitCode
+	}
+	if attemptLog != nil {
+		attempt.LogLines = attemptLog.Lines()
+	}
 	output := outputBuf.String()
+	e.recordAttemptChanges(attempt, capturedArtifacts)
 
 	if err != nil {
+		if ctx.Err() == nil {
+			execErr := e.classifyRunFailure(task, err, reportedFailure, lastLine)
+			attempt.FailureKind = execErr.Kind
+			err = execErr
+		}
 		attempt.Status = AttemptStatusFailed
 		attempt.ErrorSummary = fmt.Sprintf("Execution failed: %s\nOutput: %s", err.Error(), string(output))
 		task.Status = TaskStatusFailed
 		task.DoneAt = &finishedAt
 		logger.Error("agent-runner execution failed",
 			slog.Any("error", err),
+			slog.String("failure_kind", string(attempt.FailureKind)),
 			slog.Int("output_length", len(output)),
 			logging.LogDuration(start),
 		)
@@ -258,11 +342,29 @@ func (e *Executor) ExecuteTask(ctx context.Context, task *Task) (*Attempt, error
 	return attempt, err
 }
 
+// newExecutionAttempt は実行する試行を作る（processJob が試行 ID とログを渡した場合はそれを使う）
+func newExecutionAttempt(ctx context.Context, task *Task) (*Attempt, *persistence.AttemptLogWriter) {
+	attempt := &Attempt{
+		ID:        uuid.New().String(),
+		TaskID:    task.ID,
+		PoolID:    task.PoolID,
+		Status:    AttemptStatusRunning,
+		StartedAt: time.Now(),
+	}
+	var attemptLog *persistence.AttemptLogWriter
+	if run := AttemptRunFromContext(ctx); run != nil {
+		attempt.ID = run.ID
+		attemptLog = run.Log
+	}
+	return attempt, attemptLog
+}
+
 func (e *Executor) handleExecutionError(attempt *Attempt, task *Task, err error) (*Attempt, error) {
 	now := time.Now()
 	attempt.FinishedAt = &now
 	attempt.Status = AttemptStatusFailed
 	attempt.ErrorSummary = err.Error()
+	attempt.FailureKind = ClassifyFailure(err).Kind
 
 	task.Status = TaskStatusFailed
 	task.DoneAt = &now
@@ -283,7 +385,8 @@ func (e *Executor) handleExecutionError(attempt *Attempt, task *Task, err error)
 	return attempt, err
 }
 
-func (e *Executor) generateTaskYAML(task *Task) string {
+// taskPromptText は agent-runner に渡す PRD の本文（説明・受け入れ条件・回答・実装の提案）を組み立てる
+func taskPromptText(task *Task) string {
 	// Construct the prompt text with Description, AcceptanceCriteria, and SuggestedImpl
 	promptText := fmt.Sprintf("Execute task: %s", task.Title)
 	if task.Description != "" {
@@ -295,6 +398,12 @@ func (e *Executor) generateTaskYAML(task *Task) string {
 			promptText += fmt.Sprintf("\n- %s", ac)
 		}
 	}
+	if len(task.Answers) > 0 {
+		promptText += "\n\nAnswers to Your Questions:"
+		for _, a := range task.Answers {
+			promptText += fmt.Sprintf("\n- Q: %s\n  A: %s", a.Question, a.Answer)
+		}
+	}
 	if task.SuggestedImpl != nil {
 		promptText += "\n\nSuggested Implementation:"
 		if task.SuggestedImpl.Language != "" {
@@ -313,6 +422,65 @@ func (e *Executor) generateTaskYAML(task *Task) string {
 			}
 		}
 	}
+	return promptText
+}
+
+// runnerSettings はタスクの agent-runner のループ上限と Worker 種別を返す（未指定は既定値）
+func runnerSettings(task *Task) (maxLoops int, workerKind string) {
+	maxLoops = DefaultRunnerMaxLoops
+	workerKind = DefaultWorkerKind
+	if task.Runner != nil {
+		if task.Runner.MaxLoops > 0 {
+			maxLoops = task.Runner.MaxLoops
+		}
+		if task.Runner.WorkerKind != "" {
+			workerKind = task.Runner.WorkerKind
+		}
+	}
+	return maxLoops, workerKind
+}
+
+// buildTaskConfig はタスクの agent-runner 設定を組み立てる（generateTaskYAML と同じ内容）
+// repo は ProjectRoot の絶対パスにする（サブプロセスは ProjectRoot で起動するため "." で足りる）。
+func (e *Executor) buildTaskConfig(task *Task) *config.TaskConfig {
+	repo := e.ProjectRoot
+	if abs, err := filepath.Abs(repo); err == nil {
+		repo = abs
+	}
+	maxLoops, workerKind := runnerSettings(task)
+	cfg := &config.TaskConfig{
+		Version: 1,
+		Task: config.TaskDetails{
+			ID:           task.ID,
+			Title:        task.Title,
+			Repo:         repo,
+			Description:  task.Description,
+			WBSLevel:     task.WBSLevel,
+			PhaseName:    task.PhaseName,
+			Dependencies: append([]string{}, task.Dependencies...),
+			PRD:          config.PRDDetails{Text: taskPromptText(task) + "\n"},
+		},
+		Runner: config.RunnerConfig{
+			MaxLoops: maxLoops,
+			Tooling:  e.toolingConfigFor(task),
+			Worker:   config.WorkerConfig{Kind: workerKind},
+		},
+	}
+	if task.SuggestedImpl != nil {
+		cfg.Task.SuggestedImpl = &config.SuggestedImpl{
+			Language:    task.SuggestedImpl.Language,
+			FilePaths:   append([]string{}, task.SuggestedImpl.FilePaths...),
+			Constraints: append([]string{}, task.SuggestedImpl.Constraints...),
+		}
+	}
+	if pool, ok := e.poolFor(task); ok && pool.WorkerImage != "" {
+		cfg.Runner.Worker.DockerImage = pool.WorkerImage
+	}
+	return cfg
+}
+
+func (e *Executor) generateTaskYAML(task *Task) string {
+	promptText := taskPromptText(task)
 
 	// Simple task YAML for agent-runner
 	// Using literal style Block Scalar (|) for prd.text to handle multi-line strings safely.
@@ -340,27 +508,25 @@ func (e *Executor) generateTaskYAML(task *Task) string {
 	// Dependencies
 	dependenciesYAML := fmt.Sprintf("dependencies: [%s]", quoteList(task.Dependencies))
 
-	runnerMaxLoops := DefaultRunnerMaxLoops
-	workerKind := DefaultWorkerKind
-	if task.Runner != nil {
-		if task.Runner.MaxLoops > 0 {
-			runnerMaxLoops = task.Runner.MaxLoops
-		}
-		if task.Runner.WorkerKind != "" {
-			workerKind = task.Runner.WorkerKind
-		}
-	}
+	runnerMaxLoops, workerKind := runnerSettings(task)
+
+	pool, hasPool := e.poolFor(task)
 
 	toolingYAML := ""
-	if e.ToolingConfig != nil {
+	if toolingCfg := e.toolingConfigFor(task); toolingCfg != nil {
 		toolingBytes, err := yaml.Marshal(map[string]interface{}{
-			"tooling": e.ToolingConfig,
+			"tooling": toolingCfg,
 		})
 		if err == nil {
 			toolingYAML = indentYAML(string(toolingBytes), 2)
 		}
 	}
 
+	workerImageYAML := ""
+	if hasPool && pool.WorkerImage != "" {
+		workerImageYAML = fmt.Sprintf("    docker_image: %q\n", pool.WorkerImage)
+	}
+
 	return fmt.Sprintf(`version: "1"
 task:
   id: %s
@@ -377,7 +543,7 @@ task:
   max_loops: %d
 %s  worker:
     kind: %q
-`, task.ID, task.Title, task.Description, task.WBSLevel, task.PhaseName, dependenciesYAML, suggestedImplYAML, promptTextIndented, runnerMaxLoops, toolingYAML, workerKind)
+%s`, task.ID, task.Title, task.Description, task.WBSLevel, task.PhaseName, dependenciesYAML, suggestedImplYAML, promptTextIndented, runnerMaxLoops, toolingYAML, workerKind, workerImageYAML)
 }
 
 func quoteList(items []string) string {
@@ -402,11 +568,74 @@ func indentYAML(input string, spaces int) string {
 	return strings.Join(lines, "\n") + "\n"
 }
 
-func (e *Executor) handleStructuredLog(taskID, taskTitle string, entry map[string]interface{}, onArtifacts func([]string)) {
+// runnerFailure は agent-runner が runner:failed イベントで報告した失敗
+type runnerFailure struct {
+	kind    string
+	message string
+}
+
+// structuredLogHooks は構造化ログから試行の情報を受け取るコールバック（nil は無視）
+type structuredLogHooks struct {
+	onArtifacts func([]string)
+	onTooling   func(AttemptTooling)
+	onFailure   func(runnerFailure)
+	onWorkerRun func(workerRunUsage)
+}
+
+// workerRunUsage は worker:completed で報告された 1 回の Worker 実行の使用量
+type workerRunUsage struct {
+	inputTokens  int
+	outputTokens int
+	costUSD      float64
+}
+
+// classifyRunFailure は agent-runner の異常終了を分類する
+// runner:failed イベントの error_kind を優先し、報告が無ければ最後の出力行から推定する。
+// レート制限で待ち時間の指定が無い場合は tooling 設定のクールダウンを使う。
+func (e *Executor) classifyRunFailure(task *Task, err error, reported *runnerFailure, lastLine string) *ExecutionError {
+	var failure Failure
+	if reported != nil && FailureKind(reported.kind).IsValid() {
+		failure = Failure{Kind: FailureKind(reported.kind), RetryAfter: parseRetryAfter(reported.message)}
+	} else {
+		failure = classifyFailureMessage(lastLine)
+	}
+	if failure.Kind == FailureRateLimit && failure.RetryAfter == 0 {
+		failure.RetryAfter = e.rateLimitCooldown(task)
+	}
+	return &ExecutionError{Kind: failure.Kind, RetryAfter: failure.RetryAfter, Err: err}
+}
+
+func (e *Executor) handleStructuredLog(taskID, taskTitle string, entry map[string]interface{}, hooks structuredLogHooks) {
 	eventType, ok := entry["event_type"].(string)
 	if !ok {
 		return
 	}
+	switch eventType {
+	case "worker:tooling_selected":
+		tool, _ := entry["tool"].(string)
+		model, _ := entry["model"].(string)
+		if hooks.onTooling != nil {
+			hooks.onTooling(AttemptTooling{Tool: tool, Model: model})
+		}
+		return
+	case "runner:failed":
+		kind, _ := entry["error_kind"].(string)
+		message, _ := entry["err"].(string)
+		if hooks.onFailure != nil {
+			hooks.onFailure(runnerFailure{kind: kind, message: message})
+		}
+		return
+	case "worker:completed":
+		if hooks.onWorkerRun != nil {
+			inputTokens, _ := entry["input_tokens"].(float64)
+			outputTokens, _ := entry["output_tokens"].(float64)
+			cost, _ := entry["cost_usd"].(float64)
+			hooks.onWorkerRun(workerRunUsage{inputTokens: int(inputTokens), outputTokens: int(outputTokens), costUSD: cost})
+		}
+	}
+	if e.events == nil {
+		return
+	}
 
 	timestamp := time.Now()
 	if tsStr, ok := entry["time"].(string); ok {
@@ -418,38 +647,16 @@ func (e *Executor) handleStructuredLog(taskID, taskTitle string, entry map[strin
 	switch eventType {
 	case "meta:thinking":
 		detail, _ := entry["detail"].(string)
-		e.events.Emit(EventProcessMetaUpdate, ProcessMetaUpdateEvent{
-			TaskID:    taskID,
-			TaskTitle: taskTitle,
-			State:     "THINKING",
-			Detail:    detail,
-			Timestamp: timestamp,
-		})
+		e.emitMetaThinking(taskID, taskTitle, detail, timestamp)
 	case "meta:state_change":
 		// Only distinct states, maybe map "state transition" later if needed
 	case "container:starting":
-		e.events.Emit(EventProcessContainerUpdate, ProcessContainerUpdateEvent{
-			TaskID:    taskID,
-			Status:    "STARTING",
-			Image:     "unknown", // Could add to log if needed
-			Timestamp: timestamp,
-		})
+		e.emitContainerStarting(taskID, timestamp)
 	case "container:started":
-		e.events.Emit(EventProcessContainerUpdate, ProcessContainerUpdateEvent{
-			TaskID:      taskID,
-			ContainerID: "running", // Don't have ID in log yet, but status is key
-			Status:      "RUNNING",
-			Timestamp:   timestamp,
-		})
+		e.emitContainerStarted(taskID, timestamp)
 	case "worker:running":
 		cmd, _ := entry["command"].(string)
-		e.events.Emit(EventProcessWorkerUpdate, ProcessWorkerUpdateEvent{
-			TaskID:    taskID,
-			WorkerID:  "worker-1",
-			Status:    "RUNNING",
-			Command:   cmd,
-			Timestamp: timestamp,
-		})
+		e.emitWorkerRunning(taskID, cmd, timestamp)
 	case "worker:completed":
 		exitCode, _ := entry["exit_code"].(float64)
 		var artifacts []string
@@ -461,19 +668,78 @@ func (e *Executor) handleStructuredLog(taskID, taskTitle string, entry map[strin
 			}
 		}
 
-		if len(artifacts) > 0 && onArtifacts != nil {
-			onArtifacts(artifacts)
+		if len(artifacts) > 0 && hooks.onArtifacts != nil {
+			hooks.onArtifacts(artifacts)
 		}
 
-		e.events.Emit(EventProcessWorkerUpdate, ProcessWorkerUpdateEvent{
-			TaskID:    taskID,
-			WorkerID:  "worker-1",
-			Status:    "IDLE", // Or FINISHED
-			ExitCode:  int(exitCode),
-			Artifacts: artifacts,
-			Timestamp: timestamp,
-		})
+		e.emitWorkerCompleted(taskID, int(exitCode), artifacts, timestamp)
+	}
+}
+
+// 以下は Runner の進行をプロセスのイベントとして配信する（サブプロセスは構造化ログ、プロセス内は RunnerHooks から呼ぶ）
+
+func (e *Executor) emitMetaThinking(taskID, taskTitle, detail string, timestamp time.Time) {
+	if e.events == nil {
+		return
+	}
+	e.events.Emit(EventProcessMetaUpdate, ProcessMetaUpdateEvent{
+		TaskID:    taskID,
+		TaskTitle: taskTitle,
+		State:     "THINKING",
+		Detail:    detail,
+		Timestamp: timestamp,
+	})
+}
+
+func (e *Executor) emitContainerStarting(taskID string, timestamp time.Time) {
+	if e.events == nil {
+		return
+	}
+	e.events.Emit(EventProcessContainerUpdate, ProcessContainerUpdateEvent{
+		TaskID:    taskID,
+		Status:    "STARTING",
+		Image:     "unknown", // Could add to log if needed
+		Timestamp: timestamp,
+	})
+}
+
+func (e *Executor) emitContainerStarted(taskID string, timestamp time.Time) {
+	if e.events == nil {
+		return
+	}
+	e.events.Emit(EventProcessContainerUpdate, ProcessContainerUpdateEvent{
+		TaskID:      taskID,
+		ContainerID: "running", // Don't have ID in log yet, but status is key
+		Status:      "RUNNING",
+		Timestamp:   timestamp,
+	})
+}
+
+func (e *Executor) emitWorkerRunning(taskID, command string, timestamp time.Time) {
+	if e.events == nil {
+		return
+	}
+	e.events.Emit(EventProcessWorkerUpdate, ProcessWorkerUpdateEvent{
+		TaskID:    taskID,
+		WorkerID:  "worker-1",
+		Status:    "RUNNING",
+		Command:   command,
+		Timestamp: timestamp,
+	})
+}
+
+func (e *Executor) emitWorkerCompleted(taskID string, exitCode int, artifacts []string, timestamp time.Time) {
+	if e.events == nil {
+		return
 	}
+	e.events.Emit(EventProcessWorkerUpdate, ProcessWorkerUpdateEvent{
+		TaskID:    taskID,
+		WorkerID:  "worker-1",
+		Status:    "IDLE", // Or FINISHED
+		ExitCode:  exitCode,
+		Artifacts: artifacts,
+		Timestamp: timestamp,
+	})
 }
 
 // verifyPreFlight performs checks before starting the agent-runner.
@@ -0,0 +1,266 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/cli"
+	"github.com/biwakonbu/agent-runner/internal/core"
+	"github.com/biwakonbu/agent-runner/internal/logging"
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	"github.com/biwakonbu/agent-runner/internal/note"
+	"github.com/biwakonbu/agent-runner/internal/worker"
+	"github.com/biwakonbu/agent-runner/pkg/config"
+)
+
+// ExecutorMode は TaskExecutor の実行方式
+type ExecutorMode string
+
+const (
+	// ExecutorModeSubprocess はタスクごとに agent-runner をサブプロセスで起動する（既定。プロセスを分離できる）
+	ExecutorModeSubprocess ExecutorMode = "subprocess"
+	// ExecutorModeInProcess は core.Runner を同じプロセスで呼ぶ（YAML の生成・出力の解釈をしない）
+	ExecutorModeInProcess ExecutorMode = "in-process"
+)
+
+// ParseExecutorMode は実行方式の指定を解釈する（空は subprocess）
+func ParseExecutorMode(s string) (ExecutorMode, error) {
+	switch ExecutorMode(s) {
+	case "", ExecutorModeSubprocess:
+		return ExecutorModeSubprocess, nil
+	case ExecutorModeInProcess:
+		return ExecutorModeInProcess, nil
+	}
+	return "", fmt.Errorf("unknown executor mode %q (want %s or %s)", s, ExecutorModeSubprocess, ExecutorModeInProcess)
+}
+
+// NewTaskExecutor は mode に応じた TaskExecutor を返す
+// in-process の場合も tooling・Pool・イベントの設定は executor と共有する。
+func NewTaskExecutor(executor *Executor, mode ExecutorMode) TaskExecutor {
+	if mode == ExecutorModeInProcess {
+		return NewInProcessExecutor(executor)
+	}
+	return executor
+}
+
+// RunnerFactory はタスクの設定から core.Runner を作る
+type RunnerFactory func(cfg *config.TaskConfig) (*core.Runner, error)
+
+// NewDefaultRunner は agent-runner コマンドと同じ構成（Meta クライアント・Worker・ノート）の Runner を作る
+func NewDefaultRunner(cfg *config.TaskConfig) (*core.Runner, error) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	metaModel := cli.ResolveMetaModel("", cfg.Runner.Meta.Model)
+	baseMetaClient := meta.NewClient(cfg.Runner.Meta.Kind, apiKey, metaModel, cfg.Runner.Meta.SystemPrompt)
+	var metaClient core.MetaClient = baseMetaClient
+	if cfg.Runner.Tooling != nil {
+		metaClient = meta.NewToolingClient(cfg.Runner.Tooling, apiKey, baseMetaClient, cfg.Runner.Meta.SystemPrompt)
+	}
+	workerExecutor, err := worker.NewExecutor(cfg.Runner.Worker, cfg.Task.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return core.NewRunner(cfg, metaClient, workerExecutor, note.NewWriter()), nil
+}
+
+// InProcessExecutor は agent-runner を起動せず、core.Runner をプロセス内で呼ぶ TaskExecutor
+// タスクの設定は config.TaskConfig として直接渡し、進行は RunnerHooks、結果は TaskContext で受け取る。
+// Runner の構造化ログは試行ログとタスクログのイベントに流す（サブプロセスの stdout と同じ内容）。
+type InProcessExecutor struct {
+	*Executor
+	NewRunner RunnerFactory // nil なら NewDefaultRunner
+}
+
+// NewInProcessExecutor は executor の設定を使う InProcessExecutor を作る
+func NewInProcessExecutor(executor *Executor) *InProcessExecutor {
+	return &InProcessExecutor{Executor: executor}
+}
+
+// ExecuteTask runs the task with core.Runner in this process.
+func (x *InProcessExecutor) ExecuteTask(ctx context.Context, task *Task) (*Attempt, error) {
+	e := x.Executor
+	logger := logging.WithTraceID(e.logger, ctx)
+	start := time.Now()
+	attempt, attemptLog := newExecutionAttempt(ctx, task)
+
+	logger.Info("starting in-process task execution",
+		slog.String("task_id", task.ID),
+		slog.String("task_title", task.Title),
+		slog.String("attempt_id", attempt.ID),
+	)
+
+	task.Status = TaskStatusRunning
+	now := time.Now()
+	task.StartedAt = &now
+
+	if err := e.verifyPreFlight(ctx, task); err != nil {
+		logger.Error("pre-flight check failed", slog.Any("error", err))
+		return e.handleExecutionError(attempt, task, &ExecutionError{Kind: FailureAuth, Err: err})
+	}
+
+	if e.events != nil {
+		e.events.Emit(EventProcessMetaUpdate, ProcessMetaUpdateEvent{
+			TaskID:    task.ID,
+			TaskTitle: task.Title,
+			State:     "RUNNING",
+			Detail:    "Initializing runner...",
+			Timestamp: time.Now(),
+		})
+	}
+
+	newRunner := x.NewRunner
+	if newRunner == nil {
+		newRunner = NewDefaultRunner
+	}
+	runner, err := newRunner(e.buildTaskConfig(task))
+	if err != nil {
+		logger.Error("failed to create runner", slog.Any("error", err))
+		return e.handleExecutionError(attempt, task, &ExecutionError{Kind: FailureSandboxInfra, Err: err})
+	}
+
+	lines := &lineWriter{onLine: func(line string) {
+		if attemptLog != nil {
+			if err := attemptLog.WriteLine("stdout", line); err != nil {
+				logger.Warn("failed to write attempt log", slog.Any("error", err))
+			}
+		}
+		if e.events != nil {
+			e.events.Emit(EventTaskLog, TaskLogEvent{
+				TaskID:    task.ID,
+				AttemptID: attempt.ID,
+				Stream:    "stdout",
+				Line:      line,
+				Timestamp: time.Now(),
+			})
+		}
+	}}
+	runner.Logger = slog.New(slog.NewJSONHandler(lines, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	runner.Hooks = core.RunnerHooks{
+		OnMetaThinking:      func(detail string) { e.emitMetaThinking(task.ID, task.Title, detail, time.Now()) },
+		OnContainerStarting: func() { e.emitContainerStarting(task.ID, time.Now()) },
+		OnContainerStarted:  func() { e.emitContainerStarted(task.ID, time.Now()) },
+		OnWorkerRunning:     func(prompt string) { e.emitWorkerRunning(task.ID, prompt, time.Now()) },
+		// フックは Run と同じ goroutine で呼ばれる
+		OnToolingSelected: func(candidate config.ToolCandidate) {
+			attempt.Tooling = &AttemptTooling{Tool: candidate.Tool, Model: candidate.Model}
+		},
+		OnWorkerCompleted: func(res core.WorkerRunResult) {
+			e.emitWorkerCompleted(task.ID, res.ExitCode, res.Artifacts, time.Now())
+		},
+	}
+
+	result, err := runner.Run(ctx)
+	if err == nil {
+		err = core.OutcomeError(result)
+	}
+	lines.flush()
+	finishedAt := time.Now()
+	attempt.FinishedAt = &finishedAt
+	if attemptLog != nil {
+		attempt.LogLines = attemptLog.Lines()
+	}
+	artifacts := applyRunnerResult(attempt, result)
+	e.recordAttemptChanges(attempt, artifacts)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			reported := &runnerFailure{kind: string(core.ErrorKindOf(err)), message: err.Error()}
+			execErr := e.classifyRunFailure(task, err, reported, "")
+			attempt.FailureKind = execErr.Kind
+			err = execErr
+		}
+		attempt.Status = AttemptStatusFailed
+		attempt.ErrorSummary = fmt.Sprintf("Execution failed: %s", err.Error())
+		task.Status = TaskStatusFailed
+		task.DoneAt = &finishedAt
+		logger.Error("in-process execution failed",
+			slog.Any("error", err),
+			slog.String("failure_kind", string(attempt.FailureKind)),
+			logging.LogDuration(start),
+		)
+		return attempt, err
+	}
+
+	attempt.Status = AttemptStatusSucceeded
+	task.Status = TaskStatusSucceeded
+	task.DoneAt = &finishedAt
+	if len(artifacts) > 0 {
+		if task.Artifacts == nil {
+			task.Artifacts = &Artifacts{}
+		}
+		task.Artifacts.Files = artifacts
+	}
+	if e.events != nil {
+		e.events.Emit(EventProcessMetaUpdate, ProcessMetaUpdateEvent{
+			TaskID:    task.ID,
+			TaskTitle: task.Title,
+			State:     "DONE",
+			Detail:    "Task completed successfully",
+			Timestamp: time.Now(),
+		})
+	}
+	logger.Info("in-process execution succeeded",
+		slog.Int("worker_runs", attempt.WorkerRuns),
+		slog.Int("artifacts", len(artifacts)),
+		logging.LogDuration(start),
+	)
+	return attempt, nil
+}
+
+// applyRunnerResult は Runner の結果から Worker 実行の回数・使用量を試行に加え、成果物を返す
+// サブプロセスの worker:completed と同じく、正常に終わった Worker 実行だけを数え、成果物は最後に報告されたものを使う。
+func applyRunnerResult(attempt *Attempt, result *core.TaskContext) []string {
+	if result == nil {
+		return nil
+	}
+	var artifacts []string
+	for _, run := range result.WorkerRuns {
+		if run.Error != nil {
+			continue
+		}
+		attempt.WorkerRuns++
+		attempt.InputTokens += run.InputTokens
+		attempt.OutputTokens += run.OutputTokens
+		attempt.CostUSD += run.CostUSD
+		if len(run.Artifacts) > 0 {
+			artifacts = run.Artifacts
+		}
+	}
+	return artifacts
+}
+
+// lineWriter は書き込まれた内容を行ごとに onLine へ渡す io.Writer
+type lineWriter struct {
+	mu     sync.Mutex
+	buf    []byte
+	onLine func(line string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush は改行で終わっていない残りを 1 行として渡す
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.onLine(string(w.buf))
+		w.buf = nil
+	}
+}
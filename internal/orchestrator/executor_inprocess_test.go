@@ -0,0 +1,203 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/biwakonbu/agent-runner/internal/core"
+	"github.com/biwakonbu/agent-runner/internal/meta"
+	internalmock "github.com/biwakonbu/agent-runner/internal/mock"
+	"github.com/biwakonbu/agent-runner/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuildTaskConfig_MatchesGeneratedYAML(t *testing.T) {
+	projectRoot := t.TempDir()
+	executor := NewExecutor("agent-runner", projectRoot)
+	executor.SetToolingConfig(&config.ToolingConfig{
+		ActiveProfile: "default",
+		Profiles: []config.ToolProfile{
+			{ID: "fast", Name: "Fast", Categories: map[string]config.ToolCategoryConfig{
+				"worker": {Strategy: "weighted", Candidates: []config.ToolCandidate{{Tool: "codex-cli", Model: "m1", Weight: 1}}},
+			}},
+		},
+	})
+	executor.SetWorkerPools(&WorkerPoolsConfig{Pools: []Pool{{ID: "gpu", ToolingProfile: "fast", WorkerImage: "worker:gpu"}}})
+
+	task := &Task{
+		ID:                 "task-1",
+		Title:              "Implement API",
+		Description:        "Add the endpoint",
+		PoolID:             "gpu",
+		WBSLevel:           2,
+		PhaseName:          "実装",
+		Dependencies:       []string{"task-0"},
+		AcceptanceCriteria: []string{"returns 200"},
+		Answers:            []TaskAnswer{{Question: "Which port?", Answer: "8080"}},
+		SuggestedImpl:      &SuggestedImpl{Language: "go", FilePaths: []string{"api.go"}, Constraints: []string{"no globals"}},
+		Runner:             &RunnerSpec{MaxLoops: 7, WorkerKind: "claude-code"},
+	}
+
+	// 生成される YAML は version を文字列で書くため、比較用に数値へ直して読む
+	generated := strings.Replace(executor.generateTaskYAML(task), `version: "1"`, "version: 1", 1)
+	var fromYAML config.TaskConfig
+	require.NoError(t, yaml.Unmarshal([]byte(generated), &fromYAML))
+	// サブプロセスは ProjectRoot で起動するため repo は "."、プロセス内は絶対パスになる
+	assert.Equal(t, ".", fromYAML.Task.Repo)
+	fromYAML.Task.Repo = projectRoot
+
+	cfg := executor.buildTaskConfig(task)
+	assert.Equal(t, &fromYAML, cfg)
+	assert.Equal(t, "fast", cfg.Runner.Tooling.ActiveProfile)
+	assert.Equal(t, "worker:gpu", cfg.Runner.Worker.DockerImage)
+}
+
+// newMockRunnerFactory は Meta・Worker・ノートをモックにした Runner を作る
+// worker は 1 回だけ実行され、assessment で完了評価の結果を決める。
+func newMockRunnerFactory(captured **config.TaskConfig, assessment bool, planErr error) RunnerFactory {
+	return func(cfg *config.TaskConfig) (*core.Runner, error) {
+		*captured = cfg
+		metaClient := &internalmock.MetaClient{
+			PlanTaskFunc: func(ctx context.Context, prd string) (*meta.PlanTaskResponse, error) {
+				if planErr != nil {
+					return nil, planErr
+				}
+				return &meta.PlanTaskResponse{TaskID: cfg.Task.ID}, nil
+			},
+			NextActionFunc: func(ctx context.Context, summary *meta.TaskSummary) (*meta.NextActionResponse, error) {
+				if summary.WorkerRunsCount == 0 {
+					return &meta.NextActionResponse{
+						Decision:   meta.Decision{Action: "run_worker"},
+						WorkerCall: meta.WorkerCall{WorkerType: "codex-cli", Prompt: "implement"},
+					}, nil
+				}
+				return &meta.NextActionResponse{Decision: meta.Decision{Action: "mark_complete"}}, nil
+			},
+			CompletionAssessmentFunc: func(ctx context.Context, summary *meta.TaskSummary) (*meta.CompletionAssessmentResponse, error) {
+				return &meta.CompletionAssessmentResponse{AllCriteriaSatisfied: assessment}, nil
+			},
+		}
+		workerExecutor := &internalmock.WorkerExecutor{
+			StartFunc: func(ctx context.Context) error { return nil },
+			StopFunc:  func(ctx context.Context) error { return nil },
+			RunWorkerFunc: func(ctx context.Context, call meta.WorkerCall, env map[string]string) (*core.WorkerRunResult, error) {
+				return &core.WorkerRunResult{Artifacts: []string{"api.go"}, InputTokens: 120, OutputTokens: 30, CostUSD: 0.02}, nil
+			},
+		}
+		noteWriter := &internalmock.NoteWriter{WriteFunc: func(taskCtx *core.TaskContext) error { return nil }}
+		return core.NewRunner(cfg, metaClient, workerExecutor, noteWriter), nil
+	}
+}
+
+func TestInProcessExecutor_ExecuteTask_Success(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	projectRoot := t.TempDir()
+	emitter := new(MockEventEmitter)
+	emitter.On("Emit", mock.Anything, mock.Anything).Return()
+	base := NewExecutor("agent-runner", projectRoot)
+	base.SetEventEmitter(emitter)
+
+	var cfg *config.TaskConfig
+	executor := NewInProcessExecutor(base)
+	executor.NewRunner = newMockRunnerFactory(&cfg, true, nil)
+
+	run := &AttemptRun{ID: "attempt-1"}
+	log, err := repo.Attempts().OpenLog(run.ID)
+	require.NoError(t, err)
+	run.Log = log
+
+	task := &Task{ID: "task-1", Title: "Implement API", Runner: &RunnerSpec{WorkerKind: "mock"}}
+	attempt, err := executor.ExecuteTask(WithAttemptRun(context.Background(), run), task)
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	require.NotNil(t, cfg)
+	abs, _ := filepath.Abs(projectRoot)
+	assert.Equal(t, abs, cfg.Task.Repo)
+	assert.Contains(t, cfg.Task.PRD.Text, "Execute task: Implement API")
+	assert.Equal(t, "mock", cfg.Runner.Worker.Kind)
+
+	assert.Equal(t, "attempt-1", attempt.ID)
+	assert.Equal(t, AttemptStatusSucceeded, attempt.Status)
+	require.NotNil(t, attempt.Artifacts)
+	assert.Equal(t, []string{"api.go"}, attempt.Artifacts.Files)
+	assert.Equal(t, 1, attempt.WorkerRuns)
+	assert.Equal(t, 120, attempt.InputTokens)
+	assert.Equal(t, 30, attempt.OutputTokens)
+	assert.InDelta(t, 0.02, attempt.CostUSD, 1e-9)
+	assert.Nil(t, attempt.ExitCode)
+	assert.Equal(t, TaskStatusSucceeded, task.Status)
+	// Runner の構造化ログは試行ログに残る
+	assert.Positive(t, attempt.LogLines)
+
+	var thinking, workerCompleted, logLines int
+	for _, call := range emitter.Calls {
+		switch ev := call.Arguments.Get(1).(type) {
+		case ProcessMetaUpdateEvent:
+			if ev.State == "THINKING" {
+				thinking++
+			}
+		case ProcessWorkerUpdateEvent:
+			if ev.Status == "IDLE" {
+				workerCompleted++
+				assert.Equal(t, []string{"api.go"}, ev.Artifacts)
+			}
+		case TaskLogEvent:
+			logLines++
+			assert.Equal(t, "attempt-1", ev.AttemptID)
+		}
+	}
+	assert.Positive(t, thinking)
+	assert.Equal(t, 1, workerCompleted)
+	assert.Equal(t, attempt.LogLines, logLines)
+}
+
+func TestInProcessExecutor_ExecuteTask_ClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		assessment bool
+		planErr    error
+		want       FailureKind
+	}{
+		{name: "acceptance criteria not met", assessment: false, want: FailureValidationFailed},
+		{name: "meta rate limited", planErr: errors.New("429 Too Many Requests: rate limit exceeded"), want: FailureRateLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg *config.TaskConfig
+			executor := NewInProcessExecutor(NewExecutor("agent-runner", t.TempDir()))
+			executor.NewRunner = newMockRunnerFactory(&cfg, tt.assessment, tt.planErr)
+
+			task := &Task{ID: "task-1", Title: "Implement API", Runner: &RunnerSpec{WorkerKind: "mock"}}
+			attempt, err := executor.ExecuteTask(context.Background(), task)
+			require.Error(t, err)
+			assert.Equal(t, tt.want, ClassifyFailure(err).Kind)
+			assert.Equal(t, AttemptStatusFailed, attempt.Status)
+			assert.Equal(t, tt.want, attempt.FailureKind)
+			assert.Equal(t, TaskStatusFailed, task.Status)
+		})
+	}
+}
+
+func TestParseExecutorMode(t *testing.T) {
+	mode, err := ParseExecutorMode("")
+	require.NoError(t, err)
+	assert.Equal(t, ExecutorModeSubprocess, mode)
+
+	mode, err = ParseExecutorMode("in-process")
+	require.NoError(t, err)
+	assert.Equal(t, ExecutorModeInProcess, mode)
+
+	_, err = ParseExecutorMode("thread")
+	assert.Error(t, err)
+
+	base := NewExecutor("agent-runner", ".")
+	assert.Same(t, base, NewTaskExecutor(base, ExecutorModeSubprocess))
+	assert.IsType(t, &InProcessExecutor{}, NewTaskExecutor(base, ExecutorModeInProcess))
+}
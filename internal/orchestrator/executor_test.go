@@ -2,12 +2,15 @@ package orchestrator
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/biwakonbu/agent-runner/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestExecutor_ExecuteTask_Cancellation verifies that canceling the context kills the process.
@@ -72,6 +75,80 @@ exec sleep 10
 	// In real usage, the Orchestrator calling this would handle saving Failed status.
 }
 
+// writeMockRunner は指定した stdout を出力して exitCode で終了する agent-runner を作る
+func writeMockRunner(t *testing.T, stdout string, exitCode int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mock_runner.sh")
+	script := fmt.Sprintf("#!/bin/sh\ncat > /dev/null\ncat <<'EOF'\n%s\nEOF\nexit %d\n", stdout, exitCode)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestExecutor_ExecuteTask_ClassifiesFailures(t *testing.T) {
+	t.Setenv("CODEX_API_KEY", "test")
+	task := func() *Task { return &Task{ID: "task-1", Title: "Build", PoolID: "default"} }
+
+	t.Run("runner reported kind with retry-after", func(t *testing.T) {
+		runner := writeMockRunner(t, `{"event_type":"runner:failed","error_kind":"rate_limit","err":"429 Too Many Requests (retry after 42s)"}`, 1)
+		attempt, err := NewExecutor(runner, t.TempDir()).ExecuteTask(context.Background(), task())
+		var execErr *ExecutionError
+		require.ErrorAs(t, err, &execErr)
+		assert.Equal(t, FailureRateLimit, execErr.Kind)
+		assert.Equal(t, 42*time.Second, execErr.RetryAfter)
+		assert.Equal(t, FailureRateLimit, attempt.FailureKind)
+	})
+
+	t.Run("rate limit falls back to tooling cooldown", func(t *testing.T) {
+		runner := writeMockRunner(t, `{"event_type":"runner:failed","error_kind":"rate_limit","err":"rate limit exceeded"}`, 1)
+		executor := NewExecutor(runner, t.TempDir())
+		executor.SetToolingConfig(&config.ToolingConfig{Profiles: []config.ToolProfile{{
+			ID:         "default",
+			Categories: map[string]config.ToolCategoryConfig{"worker": {CooldownSec: 90}},
+		}}})
+		_, err := executor.ExecuteTask(context.Background(), task())
+		var execErr *ExecutionError
+		require.ErrorAs(t, err, &execErr)
+		assert.Equal(t, 90*time.Second, execErr.RetryAfter)
+	})
+
+	t.Run("validation failure", func(t *testing.T) {
+		runner := writeMockRunner(t, `{"event_type":"runner:failed","error_kind":"validation_failed","err":"task did not satisfy its acceptance criteria"}`, 1)
+		_, err := NewExecutor(runner, t.TempDir()).ExecuteTask(context.Background(), task())
+		assert.Equal(t, FailureValidationFailed, ClassifyFailure(err).Kind)
+	})
+
+	t.Run("unreported failure is classified from the last output line", func(t *testing.T) {
+		runner := writeMockRunner(t, "starting\nCannot connect to the Docker daemon at unix:///var/run/docker.sock", 1)
+		_, err := NewExecutor(runner, t.TempDir()).ExecuteTask(context.Background(), task())
+		assert.Equal(t, FailureSandboxInfra, ClassifyFailure(err).Kind)
+	})
+
+	t.Run("missing CLI session is an auth failure", func(t *testing.T) {
+		t.Setenv("CODEX_API_KEY", "")
+		t.Setenv("HOME", t.TempDir())
+		attempt, err := NewExecutor(writeMockRunner(t, "", 0), t.TempDir()).ExecuteTask(context.Background(), task())
+		assert.Equal(t, FailureAuth, ClassifyFailure(err).Kind)
+		assert.Equal(t, FailureAuth, attempt.FailureKind)
+	})
+
+	t.Run("missing agent-runner binary is a sandbox failure", func(t *testing.T) {
+		_, err := NewExecutor(filepath.Join(t.TempDir(), "missing"), t.TempDir()).ExecuteTask(context.Background(), task())
+		assert.Equal(t, FailureSandboxInfra, ClassifyFailure(err).Kind)
+	})
+}
+
+func TestExecutor_ExecuteTask_RecordsWorkerUsage(t *testing.T) {
+	t.Setenv("CODEX_API_KEY", "test")
+	output := `{"event_type":"worker:completed","exit_code":0,"input_tokens":1000,"output_tokens":200,"cost_usd":0.5}
+{"event_type":"worker:completed","exit_code":0,"input_tokens":500,"output_tokens":100}`
+	attempt, err := NewExecutor(writeMockRunner(t, output, 0), t.TempDir()).ExecuteTask(context.Background(), &Task{ID: "task-1", Title: "Build"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempt.WorkerRuns)
+	assert.Equal(t, 1500, attempt.InputTokens)
+	assert.Equal(t, 300, attempt.OutputTokens)
+	assert.InDelta(t, 0.5, attempt.CostUSD, 1e-9)
+}
+
 // TestGenerateTaskYAML verifies that V2 fields are correctly correctly populated in the YAML
 func TestGenerateTaskYAML(t *testing.T) {
 	// 1. Setup Executor (mocking dependencies not needed for this method)
@@ -118,6 +195,14 @@ func TestGenerateTaskYAML(t *testing.T) {
 	assert.Contains(t, yamlStr, "      - AC1: works")
 	assert.Contains(t, yamlStr, "      Suggested Implementation:")
 	assert.Contains(t, yamlStr, "      Language: go")
+	assert.NotContains(t, yamlStr, "Answers to Your Questions:")
+
+	// バックログで回答された質問はプロンプトに含める
+	task.Answers = []TaskAnswer{{Question: "Which DB?", Answer: "PostgreSQL"}}
+	yamlStr = executor.generateTaskYAML(task)
+	assert.Contains(t, yamlStr, "      Answers to Your Questions:")
+	assert.Contains(t, yamlStr, "      - Q: Which DB?")
+	assert.Contains(t, yamlStr, "        A: PostgreSQL")
 }
 
 func TestExecutor_verifyPreFlight_ClaudeCodeAlias_SucceedsWhenAuthDirExists(t *testing.T) {
@@ -34,6 +34,11 @@ func NewExecutorV2(agentRunnerPath, projectRoot string, repo persistence.Workspa
 	}
 }
 
+// CheckAgent は agent-runner を起動できるかを確認する（SchedulerV2 のヘルスチェック）
+func (e *executorV2Impl) CheckAgent(ctx context.Context, agent persistence.AgentState) error {
+	return AgentRunnerHealthCheck(e.AgentRunnerPath)(ctx, agent)
+}
+
 func (e *executorV2Impl) Execute(ctx context.Context, task persistence.TaskState) error {
 	e.Logger.Info("ExecutorV2: starting task execution", "task_id", task.TaskID)
 
@@ -44,8 +49,8 @@ func (e *executorV2Impl) Execute(ctx context.Context, task persistence.TaskState
 	actionStart := &persistence.Action{
 		ID:          uuid.New().String(),
 		At:          time.Now(),
-		Kind:        "task.attempt_started",
-		WorkspaceID: "TODO-WS-ID", // propagate this
+		Kind:        persistence.ActionTaskAttemptStarted,
+		WorkspaceID: workspaceIDOf(e.Repo),
 		Payload: map[string]interface{}{
 			"task_id":    task.TaskID,
 			"attempt_id": attemptID,
@@ -66,16 +71,16 @@ func (e *executorV2Impl) Execute(ctx context.Context, task persistence.TaskState
 	success := err == nil
 
 	// Create Result Action
-	kind := "task.succeeded"
+	kind := persistence.ActionTaskSucceeded
 	if !success {
-		kind = "task.failed"
+		kind = persistence.ActionTaskFailed
 	}
 
 	actionResult := &persistence.Action{
 		ID:          uuid.New().String(),
 		At:          finishedAt,
 		Kind:        kind,
-		WorkspaceID: "TODO-WS-ID",
+		WorkspaceID: workspaceIDOf(e.Repo),
 		Payload: map[string]interface{}{
 			"task_id":    task.TaskID,
 			"attempt_id": attemptID,
@@ -94,23 +99,22 @@ func (e *executorV2Impl) Execute(ctx context.Context, task persistence.TaskState
 	// "Executor ... results based on state/tasks.json ... auto update".
 
 	// Let's update the task state directly here for MVP simplicity to "succeeded" or "failed"
-	// Race condition warning: Scheduler might be updating "running" status logic.
-	// But Scheduler is single-threaded logic mostly.
-
-	currentTasks, repoErr := e.Repo.State().LoadTasks()
-	if repoErr == nil {
-		for i, t := range currentTasks.Tasks {
-			if t.TaskID == task.TaskID {
-				if success {
-					currentTasks.Tasks[i].Status = "succeeded"
-				} else {
-					currentTasks.Tasks[i].Status = "failed"
-				}
-				currentTasks.Tasks[i].UpdatedAt = finishedAt
-				_ = e.Repo.State().SaveTasks(currentTasks)
-				break
-			}
+	// UpdateTasks により Scheduler の "running" 更新と競合しても取りこぼさない
+
+	status := "succeeded"
+	if !success {
+		status = "failed"
+	}
+	if repoErr := e.Repo.State().UpdateTasks(func(currentTasks *persistence.TasksState) error {
+		t := findTaskState(currentTasks, task.TaskID)
+		if t == nil {
+			return persistence.ErrNoChange
 		}
+		t.Status = status
+		t.UpdatedAt = finishedAt
+		return nil
+	}); repoErr != nil {
+		e.Logger.Error("failed to update task state", "task_id", task.TaskID, "err", repoErr)
 	}
 
 	return err
@@ -78,9 +78,24 @@ exit 0
 	}
 
 	// 5. Verify History
-	actions, _ := repo.History().ListActions(time.Time{}, time.Now())
+	all, _ := repo.History().ListActions(time.Time{}, time.Now())
+	var actions, transitions []persistence.Action
+	for _, a := range all {
+		if a.IsStateTransition() {
+			transitions = append(transitions, a)
+		} else {
+			actions = append(actions, a)
+		}
+	}
 	assert.Equal(t, 2, len(actions)) // started + succeeded
 	assert.Equal(t, "task.attempt_started", actions[0].Kind)
 	assert.Equal(t, "task.succeeded", actions[1].Kind)
 	assert.Equal(t, "Mock Output", strings.TrimSpace(actions[1].Payload["output"].(string)))
+
+	// 状態遷移も記録されている（seed の作成 + succeeded への遷移）
+	if assert.NotEmpty(t, transitions) {
+		last := transitions[len(transitions)-1]
+		assert.Equal(t, persistence.ActionTaskStatusChanged, last.Kind)
+		assert.Equal(t, "succeeded", last.Payload["to_status"])
+	}
 }
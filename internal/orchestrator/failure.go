@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/core"
+)
+
+// FailureKind はタスク失敗の分類（リトライポリシーの選択に使う）
+// 値は agent-runner が runner:failed イベントで出力する error_kind と同じ。
+type FailureKind string
+
+const (
+	FailureTransient        FailureKind = FailureKind(core.ErrorKindTransient)
+	FailureRateLimit        FailureKind = FailureKind(core.ErrorKindRateLimit)
+	FailureAuth             FailureKind = FailureKind(core.ErrorKindAuth)
+	FailureSandboxInfra     FailureKind = FailureKind(core.ErrorKindSandboxInfra)
+	FailureAgentGaveUp      FailureKind = FailureKind(core.ErrorKindAgentGaveUp)
+	FailureValidationFailed FailureKind = FailureKind(core.ErrorKindValidationFailed)
+)
+
+// FailureKinds は既知の失敗の分類
+var FailureKinds = []FailureKind{
+	FailureTransient,
+	FailureRateLimit,
+	FailureAuth,
+	FailureSandboxInfra,
+	FailureAgentGaveUp,
+	FailureValidationFailed,
+}
+
+// IsValid は既知の分類かどうかを返す
+func (k FailureKind) IsValid() bool {
+	for _, known := range FailureKinds {
+		if k == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecutionError は Executor が返す分類付きの実行失敗
+// RetryAfter はプロバイダが指定した待ち時間（レート制限のクールダウンなど、無ければ 0）。
+type ExecutionError struct {
+	Kind       FailureKind
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ExecutionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// Failure は分類済みの失敗
+type Failure struct {
+	Kind       FailureKind
+	RetryAfter time.Duration
+}
+
+// ClassifyFailure は実行の失敗を分類する
+// ExecutionError であればその分類を使い、そうでなければメッセージから推定する。
+func ClassifyFailure(err error) Failure {
+	if err == nil {
+		return Failure{Kind: FailureTransient}
+	}
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) && execErr.Kind.IsValid() {
+		return Failure{Kind: execErr.Kind, RetryAfter: execErr.RetryAfter}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Failure{Kind: FailureTransient}
+	}
+	return classifyFailureMessage(err.Error())
+}
+
+// classifyFailureMessage は agent-runner の出力などのメッセージから失敗を推定する
+func classifyFailureMessage(msg string) Failure {
+	failure := Failure{Kind: FailureKind(core.ClassifyErrorMessage(msg))}
+	if failure.Kind == FailureTransient {
+		lower := strings.ToLower(msg)
+		for _, marker := range sandboxErrorMarkers {
+			if strings.Contains(lower, marker) {
+				failure.Kind = FailureSandboxInfra
+				break
+			}
+		}
+	}
+	if failure.Kind == FailureRateLimit {
+		failure.RetryAfter = parseRetryAfter(msg)
+	}
+	return failure
+}
+
+// sandboxErrorMarkers はサンドボックス基盤の失敗を示すメッセージの断片（小文字）
+var sandboxErrorMarkers = []string{
+	"failed to start container",
+	"cannot connect to the docker daemon",
+	"docker: error",
+	"no such image",
+	"executable file not found",
+}
+
+var retryAfterPattern = regexp.MustCompile(`(?i)retry[- _]after["':= ]*(\d+)\s*(ms|s|sec|seconds)?`)
+
+// parseRetryAfter はメッセージ中の "retry after N" / "Retry-After: N" を待ち時間として返す（単位省略時は秒）
+func parseRetryAfter(msg string) time.Duration {
+	m := retryAfterPattern.FindStringSubmatch(msg)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	if strings.EqualFold(m[2], "ms") {
+		return time.Duration(n) * time.Millisecond
+	}
+	return time.Duration(n) * time.Second
+}
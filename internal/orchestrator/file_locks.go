@@ -0,0 +1,213 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// ファイル単位の排他: READY / RUNNING のタスクが書き込むファイル・モジュールをロックとみなし、
+// 書き込み先が重なるタスクは PENDING のまま待たせる（理由は inputs.wait_reason に残す）。
+// 書き込み先はノード設計の宣言（SuggestedImpl の file_paths / module_paths）と、
+// 過去の試行が実際に変更したファイル（Attempt.Artifacts）を合わせたもの。
+
+// ErrFileConflict はタスクの書き込み先が実行待ち・実行中のタスクと重なるため実行を待たせたことを表す
+var ErrFileConflict = errors.New("write set conflicts with a scheduled task")
+
+// newFileSuffix は plan_patch が新規ファイルの file_paths に付ける注記
+const newFileSuffix = " (New File)"
+
+// TaskWriteSet はタスクが書き込むファイル・モジュール
+type TaskWriteSet struct {
+	Declared []string `json:"declared"` // ノード設計の SuggestedImpl（file_paths / module_paths）
+	Observed []string `json:"observed"` // 過去の試行が変更したファイル
+}
+
+// Paths は宣言と観測を合わせた書き込み先を返す
+func (w TaskWriteSet) Paths() []string {
+	return normalizeWritePaths(append(slices.Clone(w.Declared), w.Observed...))
+}
+
+// LoadTaskWriteSet はタスクのノード設計と過去の試行から書き込み先を組み立てる（読めない情報は無視する）
+func LoadTaskWriteSet(repo persistence.WorkspaceRepository, task *persistence.TaskState) TaskWriteSet {
+	var ws TaskWriteSet
+	if task.NodeID != "" {
+		if node, err := repo.Design().GetNode(task.NodeID); err == nil {
+			ws.Declared = normalizeWritePaths(append(slices.Clone(node.SuggestedImpl.FilePaths), node.SuggestedImpl.ModulePaths...))
+		}
+	}
+	observed := slices.Clone(task.Outputs.Files)
+	if attempts, err := ListTaskAttempts(repo, task.TaskID); err == nil {
+		for _, attempt := range attempts {
+			if attempt.Artifacts != nil {
+				observed = append(observed, attempt.Artifacts.Files...)
+			}
+		}
+	}
+	ws.Observed = normalizeWritePaths(observed)
+	return ws
+}
+
+// FileConflict は書き込み先の重なり
+type FileConflict struct {
+	Path     string `json:"path"`     // 待たされるタスクの書き込み先
+	TaskID   string `json:"taskId"`   // ロックを持つタスク
+	HeldPath string `json:"heldPath"` // ロックを持つタスクの書き込み先
+}
+
+// FileLockTable はタスクごとの書き込み先のロック表
+type FileLockTable struct {
+	locks map[string][]string
+}
+
+// NewFileLockTable は空のロック表を生成する
+func NewFileLockTable() *FileLockTable {
+	return &FileLockTable{locks: make(map[string][]string)}
+}
+
+// Lock は taskID の書き込み先をロックする（既にあれば置き換える）
+func (t *FileLockTable) Lock(taskID string, paths []string) {
+	if len(paths) == 0 {
+		delete(t.locks, taskID)
+		return
+	}
+	t.locks[taskID] = normalizeWritePaths(paths)
+}
+
+// Unlock は taskID のロックを解放する
+func (t *FileLockTable) Unlock(taskID string) {
+	delete(t.locks, taskID)
+}
+
+// Conflicts は taskID が paths に書き込む場合に、他のタスクのロックと重なるものを返す
+// 同じファイル、またはモジュール（ディレクトリ）とその配下のファイルを重なりとみなす。
+func (t *FileLockTable) Conflicts(taskID string, paths []string) []FileConflict {
+	var conflicts []FileConflict
+	for _, p := range normalizeWritePaths(paths) {
+		for _, holder := range sortedKeys(t.locks) {
+			if holder == taskID {
+				continue
+			}
+			for _, held := range t.locks[holder] {
+				if writePathsOverlap(p, held) {
+					conflicts = append(conflicts, FileConflict{Path: p, TaskID: holder, HeldPath: held})
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// FormatWaitReason はロック待ちの理由を表示用の文字列にする
+func FormatWaitReason(conflicts []FileConflict) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		parts = append(parts, fmt.Sprintf("%s (held by %s)", c.Path, c.TaskID))
+	}
+	return "waiting for file lock: " + strings.Join(parts, ", ")
+}
+
+// writePathsOverlap は 2 つの書き込み先が同じファイル、またはディレクトリとその配下かを返す
+func writePathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// normalizeWritePaths は書き込み先を比較できる形（スラッシュ区切り・重複なし・昇順）に揃える
+func normalizeWritePaths(paths []string) []string {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		p = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(p), newFileSuffix))
+		if p == "" {
+			continue
+		}
+		p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+		if p == "." || p == "/" {
+			continue
+		}
+		if !slices.Contains(out, p) {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// fileLockTable は READY / RUNNING のタスク（exceptTaskID を除く）の書き込み先からロック表を組み立てる
+func fileLockTable(repo persistence.WorkspaceRepository, tasksState *persistence.TasksState, exceptTaskID string) *FileLockTable {
+	table := NewFileLockTable()
+	for i := range tasksState.Tasks {
+		t := &tasksState.Tasks[i]
+		if t.TaskID == exceptTaskID {
+			continue
+		}
+		switch TaskStatus(t.Status) {
+		case TaskStatusReady, TaskStatusRunning:
+			table.Lock(t.TaskID, LoadTaskWriteSet(repo, t).Paths())
+		}
+	}
+	return table
+}
+
+// recordFileConflicts は試行が宣言外のファイルを変更した場合や、同時に実行中のタスクの書き込み先を変更した場合に
+// task.file_conflict を history に記録する（実行前のロックでは防げなかった衝突の事後記録）
+func (e *ExecutionOrchestrator) recordFileConflicts(task *persistence.TaskState, attempt *Attempt) {
+	if attempt == nil || attempt.Artifacts == nil {
+		return
+	}
+	touched := normalizeWritePaths(attempt.Artifacts.Files)
+	if len(touched) == 0 {
+		return
+	}
+
+	// 宣言が無いタスクは比較の基準が無いので、宣言外の変更としては扱わない
+	declared := LoadTaskWriteSet(e.Repo, task).Declared
+	var undeclared []string
+	if len(declared) > 0 {
+		for _, p := range touched {
+			if !slices.ContainsFunc(declared, func(d string) bool { return writePathsOverlap(p, d) }) {
+				undeclared = append(undeclared, p)
+			}
+		}
+	}
+
+	var conflicting []string
+	if state, err := e.Repo.State().LoadTasks(); err == nil {
+		table := fileLockTable(e.Repo, state, task.TaskID)
+		for _, c := range table.Conflicts(task.TaskID, touched) {
+			if ts := findTaskState(state, c.TaskID); ts != nil && TaskStatus(ts.Status) == TaskStatusRunning && !slices.Contains(conflicting, c.TaskID) {
+				conflicting = append(conflicting, c.TaskID)
+			}
+		}
+	}
+	if len(undeclared) == 0 && len(conflicting) == 0 {
+		return
+	}
+
+	e.logger.Warn("task touched files outside its write set",
+		slog.String("task_id", task.TaskID),
+		slog.Any("undeclared_files", undeclared),
+		slog.Any("conflicting_tasks", conflicting),
+	)
+	action, err := persistence.NewAction(persistence.ActionTaskFileConflict, workspaceIDOf(e.Repo), e.now(), persistence.TaskFileConflictPayload{
+		TaskID:             task.TaskID,
+		AttemptID:          attempt.ID,
+		DeclaredFiles:      declared,
+		UndeclaredFiles:    undeclared,
+		ConflictingTaskIDs: conflicting,
+	})
+	if err == nil {
+		err = e.Repo.History().AppendAction(action)
+	}
+	if err != nil {
+		e.logger.Warn("failed to record file conflict", slog.String("task_id", task.TaskID), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,131 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLockTable_Conflicts(t *testing.T) {
+	table := NewFileLockTable()
+	table.Lock("task-a", []string{"internal/foo/bar.go", "./docs/README.md (New File)"})
+	table.Lock("task-b", []string{"internal/baz"})
+
+	conflicts := table.Conflicts("task-c", []string{"internal/foo/bar.go", "internal/baz/qux.go", "cmd/main.go"})
+	assert.Equal(t, []FileConflict{
+		{Path: "internal/baz/qux.go", TaskID: "task-b", HeldPath: "internal/baz"},
+		{Path: "internal/foo/bar.go", TaskID: "task-a", HeldPath: "internal/foo/bar.go"},
+	}, conflicts)
+	assert.Equal(t, "waiting for file lock: internal/baz/qux.go (held by task-b), internal/foo/bar.go (held by task-a)", FormatWaitReason(conflicts))
+
+	assert.Empty(t, table.Conflicts("task-a", []string{"internal/foo/bar.go"}), "own locks never conflict")
+	assert.Len(t, table.Conflicts("task-c", []string{"docs/README.md"}), 1, "new file annotation is ignored")
+	assert.Empty(t, table.Conflicts("task-c", []string{"internal/bazaar.go"}), "prefix without separator is not a module")
+
+	table.Unlock("task-b")
+	assert.Empty(t, table.Conflicts("task-c", []string{"internal/baz/qux.go"}))
+}
+
+func TestScheduler_ScheduleTask_WaitsForFileLock(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	scheduler := NewScheduler(repo, queue, nil)
+	now := time.Now()
+
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-a", SuggestedImpl: persistence.SuggestedImpl{FilePaths: []string{"internal/foo.go"}}},
+		{NodeID: "node-b", SuggestedImpl: persistence.SuggestedImpl{FilePaths: []string{"internal/foo.go (New File)"}}},
+		{NodeID: "node-c", SuggestedImpl: persistence.SuggestedImpl{ModulePaths: []string{"cmd"}}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-a", NodeID: "node-a", Status: string(TaskStatusRunning), CreatedAt: now},
+		{TaskID: "task-b", NodeID: "node-b", Status: string(TaskStatusPending), CreatedAt: now},
+		{TaskID: "task-c", NodeID: "node-c", Status: string(TaskStatusPending), CreatedAt: now},
+	}, nil)
+
+	err := scheduler.ScheduleTask("task-b")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrFileConflict))
+	task := loadTaskState(t, repo, "task-b")
+	assert.Equal(t, string(TaskStatusPending), task.Status)
+	assert.Equal(t, "waiting for file lock: internal/foo.go (held by task-a)", inputString(task.Inputs, InputKeyWaitReason))
+
+	views, err := ListTaskViews(repo)
+	require.NoError(t, err)
+	for _, v := range views {
+		if v.ID == "task-b" {
+			assert.Equal(t, "waiting for file lock: internal/foo.go (held by task-a)", v.WaitReason)
+		}
+	}
+
+	// 書き込み先が重ならないタスクは並行して実行できる
+	require.NoError(t, scheduler.ScheduleTask("task-c"))
+	assert.Equal(t, string(TaskStatusReady), loadTaskState(t, repo, "task-c").Status)
+
+	// ロックが解放されれば実行でき、待ちの理由は消える
+	require.NoError(t, repo.State().UpdateTasks(func(s *persistence.TasksState) error {
+		findTaskState(s, "task-a").Status = string(TaskStatusSucceeded)
+		return nil
+	}))
+	require.NoError(t, scheduler.ScheduleTask("task-b"))
+	task = loadTaskState(t, repo, "task-b")
+	assert.Equal(t, string(TaskStatusReady), task.Status)
+	assert.NotContains(t, task.Inputs, InputKeyWaitReason)
+}
+
+func TestScheduler_ScheduleTask_ObservedWritesAreLocked(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	scheduler := NewScheduler(repo, queue, nil)
+	now := time.Now()
+
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-a"},
+		{NodeID: "node-b", SuggestedImpl: persistence.SuggestedImpl{FilePaths: []string{"internal/foo.go"}}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-a", NodeID: "node-a", Status: string(TaskStatusRunning), CreatedAt: now,
+			Outputs: persistence.TaskOutputs{Files: []string{"internal/foo.go"}}},
+		{TaskID: "task-b", NodeID: "node-b", Status: string(TaskStatusPending), CreatedAt: now},
+	}, nil)
+
+	err := scheduler.ScheduleTask("task-b")
+	assert.True(t, errors.Is(err, ErrFileConflict), "files written by earlier attempts are locked too")
+}
+
+func TestRecordFileConflicts(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	orch := NewExecutionOrchestrator(nil, nil, repo, queue, nil, nil, []string{"default"})
+	now := time.Now()
+
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-a", SuggestedImpl: persistence.SuggestedImpl{FilePaths: []string{"internal/foo.go"}}},
+		{NodeID: "node-b", SuggestedImpl: persistence.SuggestedImpl{ModulePaths: []string{"internal/bar"}}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-a", NodeID: "node-a", Status: string(TaskStatusRunning), CreatedAt: now},
+		{TaskID: "task-b", NodeID: "node-b", Status: string(TaskStatusRunning), CreatedAt: now},
+	}, nil)
+
+	task := loadTaskState(t, repo, "task-a")
+	orch.recordFileConflicts(&task, &Attempt{ID: "attempt-1", TaskID: "task-a",
+		Artifacts: &Artifacts{Files: []string{"internal/foo.go"}}})
+	orch.recordFileConflicts(&task, &Attempt{ID: "attempt-2", TaskID: "task-a",
+		Artifacts: &Artifacts{Files: []string{"internal/foo.go", "internal/bar/baz.go"}}})
+
+	actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	var recorded []persistence.Action
+	for _, a := range actions {
+		if a.Kind == persistence.ActionTaskFileConflict {
+			recorded = append(recorded, a)
+		}
+	}
+	require.Len(t, recorded, 1, "writes within the declared set are not recorded")
+	payload := recorded[0].Payload
+	assert.Equal(t, "attempt-2", payload["attempt_id"])
+	assert.Equal(t, []interface{}{"internal/bar/baz.go"}, payload["undeclared_files"])
+	assert.Equal(t, []interface{}{"task-b"}, payload["conflicting_task_ids"])
+}
@@ -0,0 +1,743 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// FsckSeverity は整合性チェックで見つかった問題の重大度
+type FsckSeverity string
+
+const (
+	// FsckSeverityError は実行を誤らせる・止める問題（完了済みタスクの再実行、解決できない依存など）
+	FsckSeverityError FsckSeverity = "error"
+	// FsckSeverityWarning は表示や補助データの不整合（実行には影響しない）
+	FsckSeverityWarning FsckSeverity = "warning"
+	// FsckSeverityInfo は参考情報
+	FsckSeverityInfo FsckSeverity = "info"
+)
+
+// 問題の種類（FsckIssue.Code）
+const (
+	FsckCorruptWBS         = "design.corrupt_wbs"
+	FsckCorruptNode        = "design.corrupt_node"
+	FsckNodeIDMismatch     = "design.node_id_mismatch"
+	FsckWBSMissingNode     = "wbs.missing_node"
+	FsckWBSUnindexedNode   = "wbs.unindexed_node"
+	FsckDanglingDependency = "node.dangling_dependency"
+	FsckDependencyCycle    = "node.dependency_cycle"
+	FsckCorruptState       = "state.corrupt"
+	FsckDuplicateTask      = "task.duplicate"
+	FsckTaskMissingNode    = "task.missing_node"
+	FsckOrphanNodeRuntime  = "runtime.orphan_node"
+	FsckSchemaOutdated     = "schema.outdated"
+	FsckSchemaTooNew       = "schema.too_new"
+	FsckLegacyUnmigrated   = "legacy.unmigrated"
+	FsckCorruptJob         = "queue.corrupt_job"
+	FsckOrphanJob          = "queue.orphan_job"
+	FsckFinishedTaskJob    = "queue.finished_task_job"
+	FsckDuplicateJob       = "queue.duplicate_job"
+	FsckStaleProcessingJob = "queue.stale_processing_job"
+	FsckAttemptInterrupted = "attempt.interrupted"
+	FsckCorruptBacklogItem = "backlog.corrupt_item"
+	FsckOrphanBacklogItem  = "backlog.orphan_item"
+)
+
+const (
+	// manualNodePrefix は手動タスク（ノード設計を持たない）のダミー NodeID の接頭辞
+	manualNodePrefix = "manual-"
+	// fsckCorruptJobSuffix は壊れたジョブファイルの退避先の接尾辞（Dequeue は *.json のみ読む）
+	fsckCorruptJobSuffix = ".corrupt"
+)
+
+// FsckIssue は整合性チェックで見つかった 1 件の問題
+type FsckIssue struct {
+	Code     string       `json:"code"`
+	Severity FsckSeverity `json:"severity"`
+	// Subject は問題のあるタスク・ノード・ジョブ・バックログアイテムの ID
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	// Repairable は自動修復できるかどうか（Repair 指定時に修復を試みる）
+	Repairable  bool   `json:"repairable"`
+	Repaired    bool   `json:"repaired"`
+	RepairError string `json:"repairError,omitempty"`
+
+	repair func() error
+}
+
+// FsckReport は整合性チェックの結果
+type FsckReport struct {
+	CheckedAt time.Time   `json:"checkedAt"`
+	Issues    []FsckIssue `json:"issues"`
+	// SnapshotID は修復前に取得した自動スナップショットの ID（修復しなかった場合は空）
+	SnapshotID string `json:"snapshotId,omitempty"`
+}
+
+// Count は未修復の問題のうち指定した重大度のものの数を返す
+func (r *FsckReport) Count(severity FsckSeverity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity && !issue.Repaired {
+			n++
+		}
+	}
+	return n
+}
+
+// Repaired は修復した問題の数を返す
+func (r *FsckReport) Repaired() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Repaired {
+			n++
+		}
+	}
+	return n
+}
+
+// FsckOptions は整合性チェックのオプション
+type FsckOptions struct {
+	// Repair は安全に自動修復できる問題を修復する（修復前に自動スナップショットを取得する）
+	// 実行ループが動いている間はキューやタスクと競合するため、リーダーロックを持つプロセスだけが指定すること。
+	Repair bool
+}
+
+// Fsck はワークスペースのスキーマ版 / design / state / キュー / バックログの整合性を検査する
+type Fsck struct {
+	Repo    persistence.WorkspaceRepository
+	Queue   *ipc.FilesystemQueue
+	Backlog *BacklogStore
+
+	issues []FsckIssue
+}
+
+// NewFsck は Fsck を生成する（Queue / Backlog が nil の場合はその検査を省略する）
+func NewFsck(repo persistence.WorkspaceRepository, queue *ipc.FilesystemQueue, backlog *BacklogStore) *Fsck {
+	return &Fsck{Repo: repo, Queue: queue, Backlog: backlog}
+}
+
+// Run は整合性チェックを行い、opts.Repair が true なら修復可能な問題を修復する
+func (f *Fsck) Run(opts FsckOptions) (*FsckReport, error) {
+	if f.Repo == nil {
+		return nil, fmt.Errorf("workspace repository is required")
+	}
+	f.issues = nil
+
+	f.checkSchema()
+	nodes := f.checkDesign()
+	tasks := f.checkState(nodes)
+	f.checkQueue(tasks)
+	f.checkAttempts(tasks)
+	f.checkBacklog(tasks)
+
+	report := &FsckReport{CheckedAt: time.Now(), Issues: f.issues}
+	if report.Issues == nil {
+		report.Issues = []FsckIssue{}
+	}
+	if !opts.Repair {
+		return report, nil
+	}
+
+	var repairable []string
+	for _, issue := range report.Issues {
+		if issue.Repairable {
+			repairable = append(repairable, issue.Code)
+		}
+	}
+	if len(repairable) == 0 {
+		return report, nil
+	}
+	if snap := f.Repo.Snapshot(); snap != nil {
+		s, err := snap.CreateAutoSnapshot(fmt.Sprintf("before fsck repair (%d issues)", len(repairable)))
+		if err != nil {
+			return report, fmt.Errorf("failed to snapshot before repair: %w", err)
+		}
+		report.SnapshotID = s.ID
+	}
+	for i := range report.Issues {
+		issue := &report.Issues[i]
+		if !issue.Repairable || issue.repair == nil {
+			continue
+		}
+		if err := issue.repair(); err != nil {
+			issue.RepairError = err.Error()
+			continue
+		}
+		issue.Repaired = true
+	}
+	return report, nil
+}
+
+func (f *Fsck) report(issue FsckIssue) {
+	issue.Repairable = issue.repair != nil
+	f.issues = append(f.issues, issue)
+}
+
+// --- design ---
+
+// checkDesign は WBS とノード設計を検査し、読み込めたノード設計を返す
+func (f *Fsck) checkDesign() map[string]*persistence.NodeDesign {
+	design := f.Repo.Design()
+	nodesDir := filepath.Join(f.Repo.BaseDir(), "design", "nodes")
+	nodes := make(map[string]*persistence.NodeDesign)
+
+	files, _ := filepath.Glob(filepath.Join(nodesDir, "*.json"))
+	sort.Strings(files)
+	for _, path := range files {
+		id := strings.TrimSuffix(filepath.Base(path), ".json")
+		node, err := design.GetNode(id)
+		if err != nil {
+			f.report(FsckIssue{
+				Code:     FsckCorruptNode,
+				Severity: FsckSeverityError,
+				Subject:  id,
+				Path:     path,
+				Message:  fmt.Sprintf("node design cannot be read: %v", err),
+			})
+			continue
+		}
+		if node.NodeID != id {
+			f.report(FsckIssue{
+				Code:     FsckNodeIDMismatch,
+				Severity: FsckSeverityWarning,
+				Subject:  id,
+				Path:     path,
+				Message:  fmt.Sprintf("file name does not match node_id %q", node.NodeID),
+			})
+		}
+		nodes[id] = node
+	}
+
+	// ノード設計の依存先が削除されていないか
+	for _, id := range sortedKeys(nodes) {
+		for _, dep := range nodes[id].Dependencies {
+			if _, ok := nodes[dep]; ok {
+				continue
+			}
+			nodeID, dep := id, dep
+			f.report(FsckIssue{
+				Code:     FsckDanglingDependency,
+				Severity: FsckSeverityError,
+				Subject:  nodeID,
+				Message:  fmt.Sprintf("depends on deleted node %s (repair drops the dependency)", dep),
+				repair: func() error {
+					return design.UpdateNode(nodeID, func(node *persistence.NodeDesign) error {
+						kept := node.Dependencies[:0]
+						for _, d := range node.Dependencies {
+							if d != dep {
+								kept = append(kept, d)
+							}
+						}
+						if len(kept) == len(node.Dependencies) {
+							return persistence.ErrNoChange
+						}
+						node.Dependencies = kept
+						return nil
+					})
+				},
+			})
+		}
+	}
+
+	// 循環した依存はどのノードも実行できないまま残る（どの依存を外すかは人が決める）
+	graph := NewDependencyGraph()
+	for id, node := range nodes {
+		graph.SetNode(id, node.Dependencies, node.Estimate.StoryPoints)
+	}
+	for _, cycle := range graph.Cycles() {
+		f.report(FsckIssue{
+			Code:     FsckDependencyCycle,
+			Severity: FsckSeverityError,
+			Subject:  cycle[0],
+			Message:  fmt.Sprintf("dependency cycle among %s", strings.Join(cycle, ", ")),
+		})
+	}
+
+	wbs, err := design.LoadWBS()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			f.report(FsckIssue{
+				Code:     FsckCorruptWBS,
+				Severity: FsckSeverityError,
+				Subject:  "wbs",
+				Path:     filepath.Join(f.Repo.BaseDir(), "design", "wbs.json"),
+				Message:  fmt.Sprintf("wbs.json cannot be read: %v", err),
+			})
+		}
+		return nodes
+	}
+	f.checkWBS(wbs, nodes)
+	return nodes
+}
+
+// checkWBS は NodeIndex がノード設計と対応しているかを検査する
+// ルートノード（RootNodeID）はノード設計を持たない。
+func (f *Fsck) checkWBS(wbs *persistence.WBS, nodes map[string]*persistence.NodeDesign) {
+	design := f.Repo.Design()
+	indexed := make(map[string]persistence.NodeIndex, len(wbs.NodeIndex))
+	for _, entry := range wbs.NodeIndex {
+		indexed[entry.NodeID] = entry
+	}
+
+	// NodeIndex（エントリと children）が指すノード設計が存在するか
+	missing := make(map[string]bool)
+	for _, entry := range wbs.NodeIndex {
+		for _, id := range append([]string{entry.NodeID}, entry.Children...) {
+			if id == wbs.RootNodeID || nodes[id] != nil {
+				continue
+			}
+			missing[id] = true
+		}
+	}
+	for _, id := range sortedKeys(missing) {
+		nodeID := id
+		issue := FsckIssue{
+			Code:     FsckWBSMissingNode,
+			Severity: FsckSeverityError,
+			Subject:  nodeID,
+			Message:  "WBS references a node without design (nodes/" + nodeID + ".json)",
+		}
+		// 子を持つエントリは子の付け替え先を決められないため手動で直す
+		if len(indexed[nodeID].Children) == 0 {
+			issue.repair = func() error {
+				return design.UpdateWBS(func(wbs *persistence.WBS) error {
+					return removeFromWBS(wbs, nodeID)
+				})
+			}
+		} else {
+			issue.Message += "; it still has children and must be fixed by hand"
+		}
+		f.report(issue)
+	}
+
+	// ノード設計が WBS に載っているか（載っていないノードはツリーに表示されない）
+	referenced := make(map[string]bool)
+	for _, entry := range wbs.NodeIndex {
+		referenced[entry.NodeID] = true
+		for _, c := range entry.Children {
+			referenced[c] = true
+		}
+	}
+	for _, id := range sortedKeys(nodes) {
+		if referenced[id] {
+			continue
+		}
+		nodeID := id
+		issue := FsckIssue{
+			Code:     FsckWBSUnindexedNode,
+			Severity: FsckSeverityWarning,
+			Subject:  nodeID,
+			Message:  "node design is not part of the WBS",
+		}
+		if wbs.RootNodeID != "" {
+			issue.Message += " (repair attaches it under the root)"
+			issue.repair = func() error {
+				return design.UpdateWBS(func(wbs *persistence.WBS) error {
+					return attachToWBSRoot(wbs, nodeID)
+				})
+			}
+		}
+		f.report(issue)
+	}
+}
+
+// removeFromWBS は子を持たない nodeID を NodeIndex と親の children から取り除く
+func removeFromWBS(wbs *persistence.WBS, nodeID string) error {
+	changed := false
+	kept := wbs.NodeIndex[:0]
+	for _, entry := range wbs.NodeIndex {
+		if entry.NodeID == nodeID {
+			if len(entry.Children) > 0 {
+				return fmt.Errorf("node %s has children", nodeID)
+			}
+			changed = true
+			continue
+		}
+		children := entry.Children[:0]
+		for _, c := range entry.Children {
+			if c == nodeID {
+				changed = true
+				continue
+			}
+			children = append(children, c)
+		}
+		entry.Children = children
+		kept = append(kept, entry)
+	}
+	if !changed {
+		return persistence.ErrNoChange
+	}
+	wbs.NodeIndex = kept
+	wbs.UpdatedAt = time.Now()
+	return nil
+}
+
+// attachToWBSRoot は nodeID をルートノードの子として NodeIndex に追加する
+func attachToWBSRoot(wbs *persistence.WBS, nodeID string) error {
+	rootID := wbs.RootNodeID
+	rootPos := -1
+	for i, entry := range wbs.NodeIndex {
+		if entry.NodeID == nodeID {
+			return persistence.ErrNoChange
+		}
+		if entry.NodeID == rootID {
+			rootPos = i
+		}
+	}
+	if rootPos < 0 {
+		wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{NodeID: rootID, Children: []string{}})
+		rootPos = len(wbs.NodeIndex) - 1
+	}
+	wbs.NodeIndex[rootPos].Children = append(wbs.NodeIndex[rootPos].Children, nodeID)
+	wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{NodeID: nodeID, ParentID: &rootID, Children: []string{}})
+	wbs.UpdatedAt = time.Now()
+	return nil
+}
+
+// --- state ---
+
+// checkState は state/tasks.json と nodes-runtime.json を検査し、タスクを ID ごとに返す
+// tasks.json が読めない場合は nil を返し、タスクとの照合を行う検査は省略する。
+func (f *Fsck) checkState(nodes map[string]*persistence.NodeDesign) map[string]persistence.TaskState {
+	stateDir := filepath.Join(f.Repo.BaseDir(), "state")
+	tasksState, err := f.Repo.State().LoadTasks()
+	if err != nil {
+		f.report(FsckIssue{
+			Code:     FsckCorruptState,
+			Severity: FsckSeverityError,
+			Subject:  "tasks.json",
+			Path:     filepath.Join(stateDir, "tasks.json"),
+			Message:  fmt.Sprintf("tasks state cannot be read (run 'multiverse history rebuild'): %v", err),
+		})
+		return nil
+	}
+
+	tasks := make(map[string]persistence.TaskState, len(tasksState.Tasks))
+	for _, t := range tasksState.Tasks {
+		if _, dup := tasks[t.TaskID]; dup {
+			f.report(FsckIssue{
+				Code:     FsckDuplicateTask,
+				Severity: FsckSeverityError,
+				Subject:  t.TaskID,
+				Message:  "task appears more than once in tasks.json",
+			})
+			continue
+		}
+		tasks[t.TaskID] = t
+		// 手動タスク（CreateManualTask）はノード設計を持たない
+		if strings.HasPrefix(t.NodeID, manualNodePrefix) {
+			continue
+		}
+		if nodes[t.NodeID] == nil {
+			f.report(FsckIssue{
+				Code:     FsckTaskMissingNode,
+				Severity: FsckSeverityError,
+				Subject:  t.TaskID,
+				Message:  fmt.Sprintf("task refers to node %q which has no design (the scheduler keeps it blocked)", t.NodeID),
+			})
+		}
+	}
+
+	nodesRuntime, err := f.Repo.State().LoadNodesRuntime()
+	if err != nil {
+		f.report(FsckIssue{
+			Code:     FsckCorruptState,
+			Severity: FsckSeverityError,
+			Subject:  "nodes-runtime.json",
+			Path:     filepath.Join(stateDir, "nodes-runtime.json"),
+			Message:  fmt.Sprintf("nodes runtime cannot be read (run 'multiverse history rebuild'): %v", err),
+		})
+		return tasks
+	}
+	for _, n := range nodesRuntime.Nodes {
+		if nodes[n.NodeID] == nil && n.Status != string(persistence.NodeRuntimeStatusObsolete) {
+			f.report(FsckIssue{
+				Code:     FsckOrphanNodeRuntime,
+				Severity: FsckSeverityInfo,
+				Subject:  n.NodeID,
+				Message:  fmt.Sprintf("runtime state (%s) exists for a node without design", n.Status),
+			})
+		}
+	}
+	return tasks
+}
+
+// --- schema ---
+
+// checkSchema はスキーマ版が最新か、移行されていない旧 TaskStore のファイルが残っていないかを検査する
+func (f *Fsck) checkSchema() {
+	dir := f.Repo.BaseDir()
+	path := filepath.Join(dir, persistence.SchemaFileName)
+	info, err := persistence.LoadSchemaInfo(dir)
+	if err != nil {
+		f.report(FsckIssue{
+			Code:     FsckSchemaOutdated,
+			Severity: FsckSeverityError,
+			Subject:  "schema",
+			Path:     path,
+			Message:  fmt.Sprintf("schema version cannot be read: %v", err),
+		})
+		return
+	}
+	switch {
+	case info.Version > WorkspaceSchemaVersion:
+		f.report(FsckIssue{
+			Code:     FsckSchemaTooNew,
+			Severity: FsckSeverityError,
+			Subject:  "schema",
+			Path:     path,
+			Message:  fmt.Sprintf("workspace schema v%d is newer than this build (v%d)", info.Version, WorkspaceSchemaVersion),
+		})
+	case info.Version < WorkspaceSchemaVersion:
+		f.report(FsckIssue{
+			Code:     FsckSchemaOutdated,
+			Severity: FsckSeverityError,
+			Subject:  "schema",
+			Path:     path,
+			Message:  fmt.Sprintf("workspace schema v%d is older than v%d (repair runs the migrations)", info.Version, WorkspaceSchemaVersion),
+			repair: func() error {
+				_, err := MigrateWorkspace(f.Repo)
+				return err
+			},
+		})
+	case HasLegacyTaskStore(dir):
+		// 移行後に古いバイナリが書き込んだ、または移行前のスナップショットをリストアした
+		f.report(FsckIssue{
+			Code:     FsckLegacyUnmigrated,
+			Severity: FsckSeverityWarning,
+			Subject:  "tasks",
+			Path:     filepath.Join(dir, "tasks"),
+			Message:  "legacy task store files are present but not part of state (repair imports them)",
+			repair: func() error {
+				return ImportLegacyTaskStore(f.Repo)
+			},
+		})
+	}
+}
+
+// --- queue ---
+
+// checkQueue はキュー（ipc/queue, ipc/processing）のジョブファイルを検査する
+func (f *Fsck) checkQueue(tasks map[string]persistence.TaskState) {
+	if f.Queue == nil {
+		return
+	}
+	// 処理待ちのジョブ（task ID → 最初のジョブ）
+	queued := make(map[string]string)
+	for _, poolID := range queuePools(f.Queue.GetQueueDir("")) {
+		files, _ := filepath.Glob(filepath.Join(f.Queue.GetQueueDir(poolID), "*.json"))
+		sort.Strings(files) // ジョブ ID は投入時刻を含むので古い順
+		for _, path := range files {
+			job, ok := f.readJob(path, tasks)
+			if !ok {
+				continue
+			}
+			state := tasks[job.TaskID]
+			switch {
+			case isTerminalTaskStatus(state.Status) && !strings.EqualFold(state.Status, string(TaskStatusCanceled)):
+				// CANCELED のジョブは processJob が破棄するので問題ない
+				f.report(FsckIssue{
+					Code:     FsckFinishedTaskJob,
+					Severity: FsckSeverityError,
+					Subject:  job.ID,
+					Path:     path,
+					Message:  fmt.Sprintf("queued job would re-run finished task %s (%s)", job.TaskID, state.Status),
+					repair:   removeFile(path),
+				})
+			case queued[job.TaskID] != "":
+				f.report(FsckIssue{
+					Code:     FsckDuplicateJob,
+					Severity: FsckSeverityError,
+					Subject:  job.ID,
+					Path:     path,
+					Message:  fmt.Sprintf("task %s is already queued as %s (it would run twice)", job.TaskID, queued[job.TaskID]),
+					repair:   removeFile(path),
+				})
+			default:
+				queued[job.TaskID] = job.ID
+			}
+		}
+	}
+
+	for _, poolID := range queuePools(f.Queue.GetProcessingDir("")) {
+		files, _ := filepath.Glob(filepath.Join(f.Queue.GetProcessingDir(poolID), "*.json"))
+		sort.Strings(files)
+		for _, path := range files {
+			job, ok := f.readJob(path, tasks)
+			if !ok {
+				continue
+			}
+			state := tasks[job.TaskID]
+			if strings.EqualFold(state.Status, string(TaskStatusRunning)) {
+				continue
+			}
+			f.report(FsckIssue{
+				Code:     FsckStaleProcessingJob,
+				Severity: FsckSeverityWarning,
+				Subject:  job.ID,
+				Path:     path,
+				Message:  fmt.Sprintf("claimed job left behind for task %s (%s)", job.TaskID, state.Status),
+				repair:   removeFile(path),
+			})
+		}
+	}
+}
+
+// readJob はジョブファイルを読み込み、壊れたファイルと存在しないタスクのジョブを報告する
+// tasks が nil（tasks.json が読めない）の場合はタスクとの照合を行わない。
+func (f *Fsck) readJob(path string, tasks map[string]persistence.TaskState) (*ipc.Job, bool) {
+	var job ipc.Job
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &job)
+	}
+	if err != nil {
+		f.report(FsckIssue{
+			Code:     FsckCorruptJob,
+			Severity: FsckSeverityError,
+			Subject:  strings.TrimSuffix(filepath.Base(path), ".json"),
+			Path:     path,
+			Message:  fmt.Sprintf("job file cannot be read (repair moves it aside): %v", err),
+			repair: func() error {
+				return os.Rename(path, path+fsckCorruptJobSuffix)
+			},
+		})
+		return nil, false
+	}
+	if tasks == nil {
+		return nil, false
+	}
+	if _, ok := tasks[job.TaskID]; !ok {
+		f.report(FsckIssue{
+			Code:     FsckOrphanJob,
+			Severity: FsckSeverityWarning,
+			Subject:  job.ID,
+			Path:     path,
+			Message:  fmt.Sprintf("job refers to unknown task %s", job.TaskID),
+			repair:   removeFile(path),
+		})
+		return nil, false
+	}
+	return &job, true
+}
+
+// queuePools は dir 配下の Pool ディレクトリ名を返す
+func queuePools(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var pools []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			pools = append(pools, entry.Name())
+		}
+	}
+	return pools
+}
+
+func removeFile(path string) func() error {
+	return func() error {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+}
+
+// --- attempts ---
+
+// checkAttempts は実行中のまま残った試行（プロセスの異常終了など）を検査する
+// タスクが RUNNING の間の試行は実行中の可能性があるため対象にしない。
+func (f *Fsck) checkAttempts(tasks map[string]persistence.TaskState) {
+	if tasks == nil {
+		return
+	}
+	records, err := f.Repo.Attempts().ListAttempts("")
+	if err != nil {
+		return
+	}
+	for _, rec := range records {
+		if rec.FinishedAt != nil {
+			continue
+		}
+		if t, ok := tasks[rec.TaskID]; ok && TaskStatus(strings.ToUpper(t.Status)) == TaskStatusRunning {
+			continue
+		}
+		rec := rec
+		f.report(FsckIssue{
+			Code:       FsckAttemptInterrupted,
+			Severity:   FsckSeverityWarning,
+			Subject:    rec.AttemptID,
+			Path:       filepath.Join(f.Repo.BaseDir(), persistence.AttemptsDirName, rec.AttemptID),
+			Message:    fmt.Sprintf("attempt of task %s is still %s but the task is not running", rec.TaskID, rec.Status),
+			Repairable: true,
+			repair: func() error {
+				if err := persistence.CompressAttemptLog(f.Repo, rec.AttemptID); err != nil {
+					return err
+				}
+				now := time.Now()
+				rec.Status = string(AttemptStatusFailed)
+				rec.FinishedAt = &now
+				rec.ErrorSummary = "interrupted: the orchestrator stopped before the attempt finished"
+				return f.Repo.Attempts().SaveAttempt(&rec)
+			},
+		})
+	}
+}
+
+// --- backlog ---
+
+// checkBacklog はバックログアイテムが読み込めるか、存在するタスクを指しているかを検査する
+func (f *Fsck) checkBacklog(tasks map[string]persistence.TaskState) {
+	if f.Backlog == nil {
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(f.Backlog.backlogDir(), "*.json"))
+	sort.Strings(files)
+	for _, path := range files {
+		id := strings.TrimSuffix(filepath.Base(path), ".json")
+		item, err := f.Backlog.Get(id)
+		if err != nil {
+			f.report(FsckIssue{
+				Code:     FsckCorruptBacklogItem,
+				Severity: FsckSeverityWarning,
+				Subject:  id,
+				Path:     path,
+				Message:  fmt.Sprintf("backlog item cannot be read (it is hidden from the backlog): %v", err),
+			})
+			continue
+		}
+		if tasks == nil || item.TaskID == "" || item.ResolvedAt != nil {
+			continue
+		}
+		if _, ok := tasks[item.TaskID]; ok {
+			continue
+		}
+		f.report(FsckIssue{
+			Code:     FsckOrphanBacklogItem,
+			Severity: FsckSeverityWarning,
+			Subject:  id,
+			Path:     path,
+			Message:  fmt.Sprintf("unresolved backlog item %q refers to unknown task %s", item.Title, item.TaskID),
+		})
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueCodes は Subject ごとの問題の種類を返す
+func issueCodes(report *FsckReport) map[string][]string {
+	codes := make(map[string][]string)
+	for _, issue := range report.Issues {
+		codes[issue.Subject] = append(codes[issue.Subject], issue.Code)
+	}
+	return codes
+}
+
+func newFsckWorkspace(t *testing.T) (string, persistence.WorkspaceRepository, *Fsck) {
+	t.Helper()
+	dir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+	_, err := MigrateWorkspace(repo)
+	require.NoError(t, err)
+	fsck := NewFsck(repo, ipc.NewFilesystemQueue(dir), NewBacklogStore(dir))
+	return dir, repo, fsck
+}
+
+func TestFsck_CleanWorkspace(t *testing.T) {
+	_, repo, fsck := newFsckWorkspace(t)
+	root := "node-root"
+	require.NoError(t, repo.Design().SaveWBS(&persistence.WBS{
+		RootNodeID: root,
+		NodeIndex: []persistence.NodeIndex{
+			{NodeID: root, Children: []string{"n1"}},
+			{NodeID: "n1", ParentID: &root, Children: []string{}},
+		},
+	}))
+	require.NoError(t, repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "n1", Name: "one"}))
+	require.NoError(t, repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "n1", NodeID: "n1", Status: string(TaskStatusPending)},
+		{TaskID: "m1", NodeID: manualNodePrefix + "m1", Status: string(TaskStatusPending)},
+	}}))
+
+	report, err := fsck.Run(FsckOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+}
+
+func TestFsck_ReportsAndRepairs(t *testing.T) {
+	dir, repo, fsck := newFsckWorkspace(t)
+	root := "node-root"
+	require.NoError(t, repo.Design().SaveWBS(&persistence.WBS{
+		RootNodeID: root,
+		NodeIndex: []persistence.NodeIndex{
+			{NodeID: root, Children: []string{"n1", "gone"}},
+			{NodeID: "n1", ParentID: &root, Children: []string{}},
+			{NodeID: "gone", ParentID: &root, Children: []string{}},
+		},
+	}))
+	require.NoError(t, repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "n1", Name: "one", Dependencies: []string{"gone"}}))
+	require.NoError(t, repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "n2", Name: "unindexed"}))
+	require.NoError(t, repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "n1", NodeID: "n1", Status: string(TaskStatusSucceeded)},
+		{TaskID: "n2", NodeID: "n2", Status: string(TaskStatusPending)},
+		{TaskID: "t3", NodeID: "missing", Status: string(TaskStatusPending)},
+	}}))
+
+	// 移行後に書き込まれた旧 TaskStore: 状態のずれと tasks.json に無いタスク
+	store := NewTaskStore(dir)
+	require.NoError(t, store.SaveTask(&Task{ID: "n1", Title: "one", Status: TaskStatusRunning}))
+	require.NoError(t, store.SaveTask(&Task{ID: "legacy-only", Title: "old", Status: TaskStatusPending}))
+
+	// キュー: 完了済みタスクのジョブ、重複ジョブ、存在しないタスクのジョブ、壊れたジョブ、取り残された処理中ジョブ
+	queue := ipc.NewFilesystemQueue(dir)
+	require.NoError(t, queue.Enqueue(&ipc.Job{ID: "job-n1", TaskID: "n1", PoolID: "default"}))
+	require.NoError(t, queue.Enqueue(&ipc.Job{ID: "job-n2-1", TaskID: "n2", PoolID: "default"}))
+	require.NoError(t, queue.Enqueue(&ipc.Job{ID: "job-n2-2", TaskID: "n2", PoolID: "default"}))
+	require.NoError(t, queue.Enqueue(&ipc.Job{ID: "job-ghost", TaskID: "ghost", PoolID: "codegen"}))
+	require.NoError(t, os.WriteFile(filepath.Join(queue.GetQueueDir("default"), "job-bad.json"), []byte("{"), 0644))
+	require.NoError(t, os.MkdirAll(queue.GetProcessingDir("default"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(queue.GetProcessingDir("default"), "job-stale.json"),
+		[]byte(`{"id":"job-stale","taskId":"n2","poolId":"default"}`), 0644))
+
+	// バックログ: 存在しないタスクの未解決アイテム
+	require.NoError(t, NewBacklogStore(dir).Add(&BacklogItem{ID: "b1", TaskID: "ghost", Title: "failed"}))
+
+	report, err := fsck.Run(FsckOptions{})
+	require.NoError(t, err)
+	codes := issueCodes(report)
+	assert.Equal(t, []string{FsckWBSMissingNode}, codes["gone"])
+	assert.Equal(t, []string{FsckDanglingDependency}, codes["n1"][:1])
+	assert.Equal(t, []string{FsckWBSUnindexedNode}, codes["n2"])
+	assert.Equal(t, []string{FsckTaskMissingNode}, codes["t3"])
+	assert.Equal(t, []string{FsckLegacyUnmigrated}, codes["tasks"])
+	assert.Equal(t, []string{FsckFinishedTaskJob}, codes["job-n1"])
+	assert.Equal(t, []string{FsckDuplicateJob}, codes["job-n2-2"])
+	assert.Empty(t, codes["job-n2-1"])
+	assert.Equal(t, []string{FsckOrphanJob}, codes["job-ghost"])
+	assert.Equal(t, []string{FsckCorruptJob}, codes["job-bad"])
+	assert.Equal(t, []string{FsckStaleProcessingJob}, codes["job-stale"])
+	assert.Equal(t, []string{FsckOrphanBacklogItem}, codes["b1"])
+	assert.Zero(t, report.Repaired())
+	assert.Empty(t, report.SnapshotID)
+
+	report, err = fsck.Run(FsckOptions{Repair: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, report.SnapshotID)
+	for _, issue := range report.Issues {
+		assert.Equal(t, issue.Repairable, issue.Repaired, "%s %s: %s", issue.Code, issue.Subject, issue.RepairError)
+	}
+
+	wbs, err := repo.Design().LoadWBS()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"n1", "legacy-only", "n2"}, wbs.NodeIndex[0].Children)
+	node, err := repo.Design().GetNode("n1")
+	require.NoError(t, err)
+	assert.Empty(t, node.Dependencies)
+	// 取り込みでは state の状態を正とし、旧 TaskStore にしか無いタスクだけを追加する
+	n1, err := FindTaskView(repo, "n1")
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusSucceeded, n1.Status)
+	imported, err := FindTaskView(repo, "legacy-only")
+	require.NoError(t, err)
+	assert.Equal(t, "old", imported.Title)
+	assert.False(t, HasLegacyTaskStore(dir))
+	jobs, err := queue.ListJobs("default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"job-n2-1"}, jobs)
+	_, err = os.Stat(filepath.Join(queue.GetQueueDir("default"), "job-bad.json"+fsckCorruptJobSuffix))
+	assert.NoError(t, err)
+
+	// 修復後は手動対応が必要な問題だけが残る
+	report, err = fsck.Run(FsckOptions{})
+	require.NoError(t, err)
+	codes = issueCodes(report)
+	assert.Len(t, report.Issues, 2)
+	assert.Equal(t, []string{FsckTaskMissingNode}, codes["t3"])
+	assert.Equal(t, []string{FsckOrphanBacklogItem}, codes["b1"])
+}
+
+func TestFsck_RepairsInterruptedAttempt(t *testing.T) {
+	_, repo, fsck := newFsckWorkspace(t)
+	require.NoError(t, repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{
+		{TaskID: "m1", NodeID: manualNodePrefix + "m1", Status: string(TaskStatusFailed)},
+		{TaskID: "m2", NodeID: manualNodePrefix + "m2", Status: string(TaskStatusRunning)},
+	}}))
+
+	// 異常終了で閉じられなかった試行と、実行中の試行
+	attempts := repo.Attempts()
+	require.NoError(t, attempts.SaveAttempt(&persistence.AttemptRecord{
+		AttemptID: "att-crashed", TaskID: "m1", Status: string(AttemptStatusRunning), StartedAt: time.Now(),
+	}))
+	logw, err := attempts.OpenLog("att-crashed")
+	require.NoError(t, err)
+	require.NoError(t, logw.WriteLine("stdout", "partial output"))
+	require.NoError(t, attempts.SaveAttempt(&persistence.AttemptRecord{
+		AttemptID: "att-running", TaskID: "m2", Status: string(AttemptStatusRunning), StartedAt: time.Now(),
+	}))
+
+	report, err := fsck.Run(FsckOptions{})
+	require.NoError(t, err)
+	codes := issueCodes(report)
+	assert.Equal(t, []string{FsckAttemptInterrupted}, codes["att-crashed"])
+	assert.Empty(t, codes["att-running"])
+
+	_, err = fsck.Run(FsckOptions{Repair: true})
+	require.NoError(t, err)
+	attempt, err := GetAttempt(repo, "att-crashed")
+	require.NoError(t, err)
+	assert.Equal(t, AttemptStatusFailed, attempt.Status)
+	assert.NotNil(t, attempt.FinishedAt)
+	assert.Contains(t, attempt.ErrorSummary, "interrupted")
+
+	// 残っていたログは圧縮され、最後まで読める
+	page, err := ReadAttemptLog(repo, "att-crashed", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 1)
+	assert.Equal(t, "partial output", page.Lines[0].Line)
+	assert.True(t, page.Complete)
+	_, err = os.Stat(filepath.Join(repo.BaseDir(), persistence.AttemptsDirName, "att-crashed", "output.jsonl"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFsck_ReportsDependencyCycle(t *testing.T) {
+	_, repo, fsck := newFsckWorkspace(t)
+	require.NoError(t, repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "n1", Dependencies: []string{"n2"}}))
+	require.NoError(t, repo.Design().SaveNode(&persistence.NodeDesign{NodeID: "n2", Dependencies: []string{"n1"}}))
+
+	report, err := fsck.Run(FsckOptions{Repair: true})
+	require.NoError(t, err)
+	assert.Contains(t, issueCodes(report)["n1"], FsckDependencyCycle)
+	for _, issue := range report.Issues {
+		if issue.Code == FsckDependencyCycle {
+			assert.False(t, issue.Repaired, "cycles are left for a human to break")
+			assert.Contains(t, issue.Message, "n1, n2")
+		}
+	}
+}
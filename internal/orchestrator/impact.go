@@ -0,0 +1,237 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// 変更の影響: 完了したノードの設計（受け入れ条件・説明）が変わった、またはノードが再実装された場合に、
+// 依存グラフを辿った後続ノードと、実装ファイル（NodeImplementation.Files）を共有するノードのうち
+// implemented / verified のものに再検証が必要な印（verification.stale_reason）を付ける。
+// 再検証は verification.json の onImpact に従い、検証タスクを自動で作成するか IDE での確認を待つ。
+
+// ImpactPolicy は影響を受けたノードの再検証の扱い
+type ImpactPolicy string
+
+const (
+	ImpactPolicyConfirm  ImpactPolicy = "confirm"  // 印を付けるだけで、再検証は IDE から確認して始める
+	ImpactPolicySchedule ImpactPolicy = "schedule" // 検証タスクを自動で作成する（検証が有効な場合）
+)
+
+// IsValid は既知の扱いかどうかを返す（空は ImpactPolicyConfirm）
+func (p ImpactPolicy) IsValid() bool {
+	return p == "" || p == ImpactPolicyConfirm || p == ImpactPolicySchedule
+}
+
+// ImpactCause は影響の原因
+type ImpactCause string
+
+const (
+	ImpactCauseDesignChanged ImpactCause = "design_changed"
+	ImpactCauseReimplemented ImpactCause = "reimplemented"
+)
+
+// NodeImpact は再検証が必要なノードとその理由
+type NodeImpact struct {
+	NodeID string `json:"nodeId"`
+	Reason string `json:"reason"`
+}
+
+// ImpactReport は影響の伝播の結果
+type ImpactReport struct {
+	NodeID              string       `json:"nodeId"`
+	Cause               ImpactCause  `json:"cause"`
+	Impacted            []NodeImpact `json:"impacted"`
+	VerificationTaskIDs []string     `json:"verificationTaskIds,omitempty"`
+}
+
+// AnalyzeImpact は nodeID の変更で再検証が必要になる implemented / verified のノードを返す（ノード ID の昇順）
+// includeSelf が true なら nodeID 自身も対象にする（設計の変更では自身の検証結果も使えない）。
+func AnalyzeImpact(repo persistence.WorkspaceRepository, nodeID string, cause ImpactCause, includeSelf bool) ([]NodeImpact, error) {
+	g, err := LoadDependencyGraph(repo)
+	if err != nil {
+		return nil, err
+	}
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nodes runtime: %w", err)
+	}
+	runtimes := make(map[string]*persistence.NodeRuntime, len(nodesRuntime.Nodes))
+	for i := range nodesRuntime.Nodes {
+		runtimes[nodesRuntime.Nodes[i].NodeID] = &nodesRuntime.Nodes[i]
+	}
+	done := func(id string) bool {
+		nr := runtimes[id]
+		if nr == nil {
+			return false
+		}
+		status := persistence.NodeRuntimeStatus(nr.Status)
+		return status == persistence.NodeRuntimeStatusImplemented || status == persistence.NodeRuntimeStatusVerified
+	}
+
+	what := "design changed"
+	if cause == ImpactCauseReimplemented {
+		what = "reimplemented"
+	}
+	reasons := make(map[string]string)
+	if includeSelf && done(nodeID) {
+		reasons[nodeID] = fmt.Sprintf("%s %s", nodeID, what)
+	}
+	for _, id := range g.Dependents(nodeID) {
+		if done(id) {
+			reasons[id] = fmt.Sprintf("depends on %s (%s)", nodeID, what)
+		}
+	}
+	if changed := runtimes[nodeID]; changed != nil {
+		files := normalizeWritePaths(changed.Implementation.Files)
+		for _, id := range sortedKeys(runtimes) {
+			if id == nodeID || !done(id) || reasons[id] != "" {
+				continue
+			}
+			var shared []string
+			for _, f := range normalizeWritePaths(runtimes[id].Implementation.Files) {
+				if slices.ContainsFunc(files, func(p string) bool { return writePathsOverlap(p, f) }) {
+					shared = append(shared, f)
+				}
+			}
+			if len(shared) > 0 {
+				reasons[id] = fmt.Sprintf("shares files with %s (%s): %s", nodeID, what, strings.Join(shared, ", "))
+			}
+		}
+	}
+
+	impacts := make([]NodeImpact, 0, len(reasons))
+	for _, id := range sortedKeys(reasons) {
+		impacts = append(impacts, NodeImpact{NodeID: id, Reason: reasons[id]})
+	}
+	return impacts, nil
+}
+
+// PropagateImpact は nodeID の変更の影響を受けるノードに再検証が必要な印を付け、ポリシーに従って検証タスクを作成する
+// verified のノードは implemented に戻す（RequiresVerified の後続は再検証まで実行されない）。detail は理由に添える説明。
+func PropagateImpact(repo persistence.WorkspaceRepository, nodeID string, cause ImpactCause, detail string, cfg *VerificationConfig, logger *slog.Logger) (*ImpactReport, error) {
+	impacts, err := AnalyzeImpact(repo, nodeID, cause, cause == ImpactCauseDesignChanged)
+	if err != nil {
+		return nil, err
+	}
+	report := &ImpactReport{NodeID: nodeID, Cause: cause, Impacted: impacts}
+	if len(impacts) == 0 {
+		return report, nil
+	}
+	if detail != "" {
+		for i := range impacts {
+			impacts[i].Reason += ": " + detail
+		}
+	}
+
+	now := repo.Clock().Now()
+	ids := make([]string, 0, len(impacts))
+	for _, impact := range impacts {
+		ids = append(ids, impact.NodeID)
+	}
+	action, err := persistence.NewAction(persistence.ActionNodeImpacted, workspaceIDOf(repo), now, persistence.NodeImpactedPayload{
+		NodeID:          nodeID,
+		Cause:           string(cause),
+		Detail:          detail,
+		ImpactedNodeIDs: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.History().AppendAction(action); err != nil {
+		return nil, fmt.Errorf("failed to append history: %w", err)
+	}
+
+	err = repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		for _, impact := range impacts {
+			for i := range nodesRuntime.Nodes {
+				nr := &nodesRuntime.Nodes[i]
+				if nr.NodeID != impact.NodeID {
+					continue
+				}
+				if persistence.NodeRuntimeStatus(nr.Status) == persistence.NodeRuntimeStatusVerified {
+					nr.Status = string(persistence.NodeRuntimeStatusImplemented)
+				}
+				nr.Verification.Status = string(persistence.NodeVerificationNotTested)
+				nr.Verification.StaleReason = impact.Reason
+				nr.Notes = append(nr.Notes, persistence.NodeNote{At: now, By: "impact", Text: "needs re-verification: " + impact.Reason})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		recordStateSaveFailed(repo, logger, action.ID, "save_nodes_runtime", err)
+		return nil, fmt.Errorf("failed to save nodes runtime: %w", err)
+	}
+	logger.Info("nodes need re-verification",
+		slog.String("node_id", nodeID),
+		slog.String("cause", string(cause)),
+		slog.Any("impacted_node_ids", ids),
+	)
+
+	if cfg == nil || !cfg.Enabled || cfg.OnImpact != ImpactPolicySchedule {
+		return report, nil
+	}
+	for _, id := range ids {
+		taskID, err := ScheduleReverification(repo, id)
+		if err != nil {
+			logger.Warn("failed to schedule re-verification", slog.String("node_id", id), slog.Any("error", err))
+			continue
+		}
+		if taskID != "" {
+			report.VerificationTaskIDs = append(report.VerificationTaskIDs, taskID)
+		}
+	}
+	return report, nil
+}
+
+// ScheduleReverification はノードの最新の実装タスクを検証する検証タスクを作成し、その ID を返す
+// 未完了の検証タスクが既にあれば作成せずに空を返す。
+func ScheduleReverification(repo persistence.WorkspaceRepository, nodeID string) (string, error) {
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return "", fmt.Errorf("failed to load tasks: %w", err)
+	}
+	var latest *persistence.TaskState
+	for i := range tasksState.Tasks {
+		t := &tasksState.Tasks[i]
+		if t.NodeID != nodeID || isVerificationTask(t) {
+			continue
+		}
+		if latest == nil || t.CreatedAt.After(latest.CreatedAt) {
+			latest = t
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("node has no implementation task: %s", nodeID)
+	}
+	return CreateVerificationTask(repo, nodeID, latest.TaskID, inputString(latest.Inputs, InputKeyPoolID))
+}
+
+// ListStaleNodes は再検証が必要な印の付いたノードを返す（ノード ID の昇順）
+func ListStaleNodes(repo persistence.WorkspaceRepository) ([]NodeImpact, error) {
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nodes runtime: %w", err)
+	}
+	stale := []NodeImpact{}
+	for _, nr := range nodesRuntime.Nodes {
+		if nr.Verification.StaleReason != "" {
+			stale = append(stale, NodeImpact{NodeID: nr.NodeID, Reason: nr.Verification.StaleReason})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].NodeID < stale[j].NodeID })
+	return stale, nil
+}
+
+// propagateReimplementation は再実装したノードの影響を伝播する（失敗しても実行は続ける）
+func (e *ExecutionOrchestrator) propagateReimplementation(nodeID string) {
+	if _, err := PropagateImpact(e.Repo, nodeID, ImpactCauseReimplemented, "", e.verificationConfig(), e.logger); err != nil {
+		e.logger.Warn("failed to propagate impact of reimplementation", slog.String("node_id", nodeID), slog.Any("error", err))
+	}
+}
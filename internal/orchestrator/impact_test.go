@@ -0,0 +1,130 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupImpactRepo は api ← ui ← e2e の依存と、api とファイルを共有する cli を持つワークスペースを作る
+func setupImpactRepo(t *testing.T) (persistence.WorkspaceRepository, *ipc.FilesystemQueue) {
+	repo, queue := setupTestRepo(t)
+	now := time.Now()
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "api"},
+		{NodeID: "ui", Dependencies: []string{"api"}},
+		{NodeID: "e2e", Dependencies: []string{"ui"}},
+		{NodeID: "cli"},
+		{NodeID: "docs", Dependencies: []string{"api"}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "api", NodeID: "api", Kind: "implementation", Status: string(TaskStatusSucceeded), CreatedAt: now},
+		{TaskID: "ui", NodeID: "ui", Kind: "implementation", Status: string(TaskStatusSucceeded), CreatedAt: now},
+		{TaskID: "e2e", NodeID: "e2e", Kind: "implementation", Status: string(TaskStatusSucceeded), CreatedAt: now},
+		{TaskID: "cli", NodeID: "cli", Kind: "implementation", Status: string(TaskStatusSucceeded), CreatedAt: now},
+		{TaskID: "docs", NodeID: "docs", Kind: "implementation", Status: string(TaskStatusPending), CreatedAt: now},
+	}, []persistence.NodeRuntime{
+		{NodeID: "api", Status: string(persistence.NodeRuntimeStatusVerified),
+			Implementation: persistence.NodeImplementation{Files: []string{"internal/api/handler.go"}}},
+		{NodeID: "ui", Status: string(persistence.NodeRuntimeStatusVerified),
+			Verification: persistence.NodeVerification{Status: string(persistence.NodeVerificationPassed)}},
+		{NodeID: "e2e", Status: string(persistence.NodeRuntimeStatusImplemented)},
+		{NodeID: "cli", Status: string(persistence.NodeRuntimeStatusImplemented),
+			Implementation: persistence.NodeImplementation{Files: []string{"internal/api"}}},
+		{NodeID: "docs", Status: string(persistence.NodeRuntimeStatusPlanned)},
+	})
+	return repo, queue
+}
+
+func TestAnalyzeImpact(t *testing.T) {
+	repo, _ := setupImpactRepo(t)
+
+	impacts, err := AnalyzeImpact(repo, "api", ImpactCauseDesignChanged, true)
+	require.NoError(t, err)
+	assert.Equal(t, []NodeImpact{
+		{NodeID: "api", Reason: "api design changed"},
+		{NodeID: "cli", Reason: "shares files with api (design changed): internal/api"},
+		{NodeID: "e2e", Reason: "depends on api (design changed)"},
+		{NodeID: "ui", Reason: "depends on api (design changed)"},
+	}, impacts, "nodes that are not completed yet are not impacted")
+
+	impacts, err = AnalyzeImpact(repo, "ui", ImpactCauseReimplemented, false)
+	require.NoError(t, err)
+	assert.Equal(t, []NodeImpact{{NodeID: "e2e", Reason: "depends on ui (reimplemented)"}}, impacts)
+}
+
+func TestPropagateImpact(t *testing.T) {
+	t.Run("confirm only flags nodes", func(t *testing.T) {
+		repo, _ := setupImpactRepo(t)
+		report, err := PropagateImpact(repo, "ui", ImpactCauseDesignChanged, "acceptance criteria updated", nil, slog.Default())
+		require.NoError(t, err)
+		assert.Len(t, report.Impacted, 2)
+		assert.Empty(t, report.VerificationTaskIDs)
+
+		ui := loadNodeRuntime(t, repo, "ui")
+		assert.Equal(t, string(persistence.NodeRuntimeStatusImplemented), ui.Status, "verified nodes go back to implemented")
+		assert.Equal(t, string(persistence.NodeVerificationNotTested), ui.Verification.Status)
+		assert.Equal(t, "ui design changed: acceptance criteria updated", ui.Verification.StaleReason)
+
+		stale, err := ListStaleNodes(repo)
+		require.NoError(t, err)
+		assert.Equal(t, []NodeImpact{
+			{NodeID: "e2e", Reason: "depends on ui (design changed): acceptance criteria updated"},
+			{NodeID: "ui", Reason: "ui design changed: acceptance criteria updated"},
+		}, stale)
+
+		taskID, err := ScheduleReverification(repo, "e2e")
+		require.NoError(t, err)
+		task := loadTaskState(t, repo, taskID)
+		assert.Equal(t, TaskKindTest, task.Kind)
+		assert.Equal(t, "e2e", inputString(task.Inputs, InputKeyVerifiesTaskID))
+
+		actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		var impacted []persistence.Action
+		for _, a := range actions {
+			if a.Kind == persistence.ActionNodeImpacted {
+				impacted = append(impacted, a)
+			}
+		}
+		require.Len(t, impacted, 1)
+		assert.Equal(t, "design_changed", impacted[0].Payload["cause"])
+	})
+
+	t.Run("schedule creates verification tasks", func(t *testing.T) {
+		repo, _ := setupImpactRepo(t)
+		cfg := &VerificationConfig{Enabled: true, OnImpact: ImpactPolicySchedule}
+		report, err := PropagateImpact(repo, "ui", ImpactCauseReimplemented, "", cfg, slog.Default())
+		require.NoError(t, err)
+		assert.Equal(t, []NodeImpact{{NodeID: "e2e", Reason: "depends on ui (reimplemented)"}}, report.Impacted)
+		require.Len(t, report.VerificationTaskIDs, 1)
+		assert.Equal(t, "e2e", loadTaskState(t, repo, report.VerificationTaskIDs[0]).NodeID)
+	})
+}
+
+func TestExecutionOrchestrator_ReimplementationPropagatesImpact(t *testing.T) {
+	repo, queue := setupImpactRepo(t)
+	require.NoError(t, repo.State().UpdateTasks(func(s *persistence.TasksState) error {
+		findTaskState(s, "ui").Status = string(TaskStatusPending)
+		return nil
+	}))
+
+	finished := time.Now()
+	mockExecutor := new(MockExecutor)
+	mockExecutor.On("ExecuteTask", mock.Anything, mock.Anything).
+		Return(&Attempt{Status: AttemptStatusSucceeded, FinishedAt: &finished, Artifacts: &Artifacts{Files: []string{"web/app.ts"}}}, nil)
+	orch := NewExecutionOrchestrator(nil, mockExecutor, repo, queue, nil, nil, []string{"default"})
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-1", TaskID: "ui", PoolID: "default"})
+
+	ui := loadNodeRuntime(t, repo, "ui")
+	assert.Equal(t, string(persistence.NodeRuntimeStatusImplemented), ui.Status)
+	assert.Equal(t, []string{"web/app.ts"}, ui.Implementation.Files)
+	assert.Equal(t, "depends on ui (reimplemented)", loadNodeRuntime(t, repo, "e2e").Verification.StaleReason)
+}
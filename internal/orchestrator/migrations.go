@@ -0,0 +1,492 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/google/uuid"
+)
+
+// LegacyArchiveDir は移行済みの旧 TaskStore（tasks/ / attempts/）を退避するディレクトリ
+const LegacyArchiveDir = "legacy"
+
+// legacyStoreDirs は旧 TaskStore のディレクトリ
+var legacyStoreDirs = []string{"tasks", "attempts"}
+
+// WorkspaceMigrations はワークスペーススキーマのマイグレーション（版の昇順）
+//
+//	v1: 旧 TaskStore（tasks/*.jsonl・attempts/*.json）を state / design / history へ取り込み、legacy/ へ退避する
+//	v2: history にだけ記録された実行試行から試行の記録（runs/<attempt-id>/attempt.json）を作る
+var WorkspaceMigrations = []persistence.Migration{
+	{
+		Version:     1,
+		Description: "import legacy task store into state, design and history",
+		Apply:       ImportLegacyTaskStore,
+	},
+	{
+		Version:     2,
+		Description: "create attempt records from history",
+		Apply:       recordAttemptsFromHistory,
+	},
+}
+
+// WorkspaceSchemaVersion はこのビルドのワークスペーススキーマの版
+var WorkspaceSchemaVersion = persistence.LatestSchemaVersion(WorkspaceMigrations)
+
+// MigrateWorkspace はワークスペースを現在のスキーマ版へ移行する
+// ワークスペースを開く側（IDE・CLI・デーモン）が repo.Init() の後に呼ぶ。
+func MigrateWorkspace(repo persistence.WorkspaceRepository) (*persistence.MigrationResult, error) {
+	return persistence.Migrate(repo, WorkspaceMigrations)
+}
+
+// HasLegacyTaskStore は移行されていない旧 TaskStore のファイルが残っているかを返す
+// 移行後に古いバイナリが書き込んだ場合や、移行前のスナップショットをリストアした場合に残る。
+func HasLegacyTaskStore(workspaceDir string) bool {
+	for _, pattern := range []string{"tasks/*.jsonl", "attempts/*.json"} {
+		if files, _ := filepath.Glob(filepath.Join(workspaceDir, pattern)); len(files) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportLegacyTaskStore は旧 TaskStore のタスクと実行試行を v2 のモデルへ取り込む
+//   - state/tasks.json に無いタスクは TaskState・ノード設計・WBS・ノード実行状態を作成する
+//   - 既にあるタスクは state を正とし、旧形式にしか無い情報（Pool・開始/完了時刻など）だけを補う
+//   - 実行試行は history の試行アクションとして追記する（取り込み済みの試行は飛ばす）
+//
+// 取り込んだファイルは legacy/ へ移すため、再実行しても二重に取り込まれない。
+func ImportLegacyTaskStore(repo persistence.WorkspaceRepository) error {
+	dir := repo.BaseDir()
+	// NewTaskStore はディレクトリを作成するため、読み込み専用に直接組み立てる
+	store := &TaskStore{WorkspaceDir: dir}
+
+	legacyTasks, err := store.ListAllTasks()
+	if err != nil {
+		return err
+	}
+	attempts, err := listLegacyAttempts(store)
+	if err != nil {
+		return err
+	}
+	if len(legacyTasks) > 0 {
+		if err := importLegacyTasks(repo, legacyTasks); err != nil {
+			return err
+		}
+	}
+	if len(attempts) > 0 {
+		if err := importLegacyAttempts(repo, attempts); err != nil {
+			return err
+		}
+	}
+	return archiveLegacyTaskStore(dir)
+}
+
+// listLegacyAttempts は attempts/ の全ての試行を返す（読めないファイルは飛ばす）
+func listLegacyAttempts(store *TaskStore) ([]Attempt, error) {
+	entries, err := os.ReadDir(store.GetAttemptDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attempts directory: %w", err)
+	}
+	var attempts []Attempt
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		attempt, err := store.LoadAttempt(entry.Name()[:len(entry.Name())-5])
+		if err != nil {
+			continue
+		}
+		attempts = append(attempts, *attempt)
+	}
+	return attempts, nil
+}
+
+func importLegacyTasks(repo persistence.WorkspaceRepository, legacyTasks []Task) error {
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+	existing := make(map[string]bool, len(tasksState.Tasks))
+	for _, ts := range tasksState.Tasks {
+		existing[ts.TaskID] = true
+	}
+	var newTasks []Task
+	for _, t := range legacyTasks {
+		if !existing[t.ID] {
+			newTasks = append(newTasks, t)
+		}
+	}
+
+	now := time.Now()
+	if len(newTasks) > 0 {
+		wbsID, err := indexLegacyTasks(repo, newTasks, now)
+		if err != nil {
+			return err
+		}
+		for _, t := range newTasks {
+			if _, err := repo.Design().GetNode(t.ID); err == nil {
+				continue
+			} else if !os.IsNotExist(err) {
+				return fmt.Errorf("failed to load node %s: %w", t.ID, err)
+			}
+			if err := repo.Design().SaveNode(legacyNodeDesign(t, wbsID, now)); err != nil {
+				return fmt.Errorf("failed to save node %s: %w", t.ID, err)
+			}
+		}
+		if err := addLegacyNodesRuntime(repo, newTasks, now); err != nil {
+			return err
+		}
+	}
+
+	legacyByID := make(map[string]Task, len(legacyTasks))
+	for _, t := range legacyTasks {
+		legacyByID[t.ID] = t
+	}
+	err = repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+		// 版衝突時に fn は再実行されるため、取り込み済みの印は呼び出しごとの複製に付ける
+		pending := make(map[string]Task, len(legacyByID))
+		for id, t := range legacyByID {
+			pending[id] = t
+		}
+		changed := false
+		for i := range state.Tasks {
+			if t, ok := pending[state.Tasks[i].TaskID]; ok {
+				// ノード設計が無いタスク（手動タスクなど）は表示名を inputs に持たせる
+				_, nodeErr := repo.Design().GetNode(state.Tasks[i].NodeID)
+				changed = mergeLegacyTask(&state.Tasks[i], t, nodeErr != nil) || changed
+				delete(pending, t.ID)
+			}
+		}
+		// 旧 TaskStore の並び（ListAllTasks のファイル名順）で追加する
+		for _, t := range legacyTasks {
+			if _, ok := pending[t.ID]; ok {
+				state.Tasks = append(state.Tasks, legacyTaskState(t))
+				changed = true
+			}
+		}
+		if !changed {
+			return persistence.ErrNoChange
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save tasks: %w", err)
+	}
+	return nil
+}
+
+// legacyTaskState は旧 TaskStore のタスクを TaskState に変換する（ノード ID はタスク ID と同じ）
+func legacyTaskState(t Task) persistence.TaskState {
+	status := t.Status
+	if status == "" {
+		status = TaskStatusPending
+	}
+	ts := persistence.TaskState{
+		TaskID:      t.ID,
+		NodeID:      t.ID,
+		Kind:        "implementation",
+		Status:      string(status),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+		ScheduledBy: "migration",
+		Inputs: map[string]interface{}{
+			InputKeyAttemptCount: t.AttemptCount,
+		},
+		StartedAt: t.StartedAt,
+		DoneAt:    t.DoneAt,
+	}
+	mergeLegacyTask(&ts, t, false)
+	if t.Runner != nil {
+		ts.Inputs[InputKeyRunnerMaxLoops] = t.Runner.MaxLoops
+		ts.Inputs[InputKeyRunnerWorkerKind] = t.Runner.WorkerKind
+	}
+	if t.NextRetryAt != nil {
+		ts.Inputs[InputKeyNextRetryAt] = t.NextRetryAt.Format(time.RFC3339)
+	}
+	if t.Artifacts != nil {
+		ts.Outputs.Files = t.Artifacts.Files
+		ts.Outputs.Logs = t.Artifacts.Logs
+	}
+	if status == TaskStatusSucceeded || status == TaskStatusCompleted {
+		ts.Outputs.Status = string(TaskStatusSucceeded)
+	}
+	return ts
+}
+
+// mergeLegacyTask は TaskState に無い情報を旧 TaskStore のタスクから補い、変更したかを返す
+// 状態などの両方にある値は state を正とする（二重書き込みの間も state が主だったため）。
+// withTitle が false の場合、表示名はノード設計から取るため inputs には入れない。
+func mergeLegacyTask(ts *persistence.TaskState, t Task, withTitle bool) bool {
+	if ts.Inputs == nil {
+		ts.Inputs = make(map[string]interface{})
+	}
+	changed := false
+	setInput := func(key, value string) {
+		if value == "" || inputString(ts.Inputs, key) != "" {
+			return
+		}
+		ts.Inputs[key] = value
+		changed = true
+	}
+	if withTitle {
+		setInput(InputKeyTitle, t.Title)
+	}
+	setInput(InputKeyPoolID, t.PoolID)
+	if t.SourceChatID != nil {
+		setInput(InputKeySourceChatID, *t.SourceChatID)
+	}
+	if ts.StartedAt == nil && t.StartedAt != nil {
+		ts.StartedAt = t.StartedAt
+		changed = true
+	}
+	if ts.DoneAt == nil && t.DoneAt != nil {
+		ts.DoneAt = t.DoneAt
+		changed = true
+	}
+	return changed
+}
+
+// legacyNodeDesign は旧 TaskStore のタスクからノード設計を作る
+func legacyNodeDesign(t Task, wbsID string, now time.Time) *persistence.NodeDesign {
+	suggested := persistence.SuggestedImpl{}
+	if t.SuggestedImpl != nil {
+		suggested = persistence.SuggestedImpl{
+			Language:    t.SuggestedImpl.Language,
+			FilePaths:   t.SuggestedImpl.FilePaths,
+			Constraints: t.SuggestedImpl.Constraints,
+		}
+	}
+	createdAt := t.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	return &persistence.NodeDesign{
+		NodeID:             t.ID,
+		WBSID:              wbsID,
+		Name:               t.Title,
+		Summary:            t.Description,
+		PhaseName:          t.PhaseName,
+		Milestone:          t.Milestone,
+		WBSLevel:           t.WBSLevel,
+		Kind:               "feature",
+		Priority:           "medium",
+		Dependencies:       t.Dependencies,
+		AcceptanceCriteria: t.AcceptanceCriteria,
+		DesignNotes:        []string{},
+		SuggestedImpl:      suggested,
+		CreatedAt:          createdAt,
+		UpdatedAt:          now,
+		CreatedBy:          "migration",
+	}
+}
+
+// indexLegacyTasks は取り込むタスクを WBS に登録し、WBS ID を返す
+// 親タスクが WBS にある（または同時に取り込む）場合はその子に、それ以外はルートの子にする。
+func indexLegacyTasks(repo persistence.WorkspaceRepository, tasks []Task, now time.Time) (string, error) {
+	var wbsID string
+	err := repo.Design().UpdateWBS(func(wbs *persistence.WBS) error {
+		if wbs.WBSID == "" {
+			wbs.WBSID = uuid.New().String()
+			wbs.CreatedAt = now
+		}
+		if wbs.RootNodeID == "" {
+			wbs.RootNodeID = "node-root"
+		}
+		wbsID = wbs.WBSID
+
+		pos := make(map[string]int, len(wbs.NodeIndex))
+		for i := range wbs.NodeIndex {
+			pos[wbs.NodeIndex[i].NodeID] = i
+		}
+		if _, ok := pos[wbs.RootNodeID]; !ok {
+			wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{NodeID: wbs.RootNodeID, Children: []string{}})
+			pos[wbs.RootNodeID] = len(wbs.NodeIndex) - 1
+		}
+		importing := make(map[string]bool, len(tasks))
+		for _, t := range tasks {
+			importing[t.ID] = true
+		}
+
+		changed := false
+		for _, t := range tasks {
+			if _, ok := pos[t.ID]; ok {
+				continue
+			}
+			parentID := wbs.RootNodeID
+			if t.ParentID != nil {
+				if _, indexed := pos[*t.ParentID]; indexed || importing[*t.ParentID] {
+					parentID = *t.ParentID
+				}
+			}
+			wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{NodeID: t.ID, ParentID: &parentID, Children: []string{}})
+			pos[t.ID] = len(wbs.NodeIndex) - 1
+			changed = true
+		}
+		// 親の Children は全ノードを登録してから張る（親が後から登録される場合があるため）
+		for _, t := range tasks {
+			node := wbs.NodeIndex[pos[t.ID]]
+			if node.ParentID == nil {
+				continue
+			}
+			parent := &wbs.NodeIndex[pos[*node.ParentID]]
+			if !containsString(parent.Children, t.ID) {
+				parent.Children = append(parent.Children, t.ID)
+				changed = true
+			}
+		}
+		if !changed {
+			return persistence.ErrNoChange
+		}
+		wbs.UpdatedAt = now
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save wbs: %w", err)
+	}
+	return wbsID, nil
+}
+
+// addLegacyNodesRuntime は取り込むタスクのノード実行状態を作成する（成功済みは implemented）
+func addLegacyNodesRuntime(repo persistence.WorkspaceRepository, tasks []Task, now time.Time) error {
+	err := repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		exists := make(map[string]bool, len(nodesRuntime.Nodes))
+		for _, n := range nodesRuntime.Nodes {
+			exists[n.NodeID] = true
+		}
+		changed := false
+		for _, t := range tasks {
+			if exists[t.ID] {
+				continue
+			}
+			status := "planned"
+			if t.Status == TaskStatusSucceeded || t.Status == TaskStatusCompleted {
+				status = string(persistence.NodeRuntimeStatusImplemented)
+			}
+			nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
+				NodeID: t.ID,
+				Status: status,
+				Implementation: persistence.NodeImplementation{
+					Files:          []string{},
+					LastModifiedAt: now,
+					LastModifiedBy: "migration",
+				},
+				Verification: persistence.NodeVerification{Status: "not_tested"},
+				Notes: []persistence.NodeNote{
+					{At: now, By: "migration", Text: "imported from legacy task store"},
+				},
+			})
+			exists[t.ID] = true
+			changed = true
+		}
+		if !changed {
+			return persistence.ErrNoChange
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save nodes runtime: %w", err)
+	}
+	return nil
+}
+
+// importLegacyAttempts は旧 TaskStore の試行を history の試行アクションと試行の記録として追加する
+func importLegacyAttempts(repo persistence.WorkspaceRepository, attempts []Attempt) error {
+	actions, err := repo.History().ListActions(time.Time{}, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list history: %w", err)
+	}
+	recorded := make(map[string]bool)
+	for _, a := range attemptsFromActions(actions, "") {
+		recorded[a.ID] = true
+	}
+
+	for _, a := range attempts {
+		if err := saveMissingAttemptRecord(repo, a); err != nil {
+			return err
+		}
+		if recorded[a.ID] {
+			continue
+		}
+		payload := persistence.AttemptPayload{TaskID: a.TaskID, AttemptID: a.ID}
+		if err := appendAttemptAction(repo, persistence.ActionTaskAttemptStarted, a.StartedAt, payload); err != nil {
+			return fmt.Errorf("failed to record attempt %s: %w", a.ID, err)
+		}
+		if a.Status == AttemptStatusStarting || a.Status == AttemptStatusRunning {
+			continue
+		}
+		finishedAt := a.StartedAt
+		if a.FinishedAt != nil {
+			finishedAt = *a.FinishedAt
+		}
+		payload.Status = string(a.Status)
+		payload.Error = a.ErrorSummary
+		if err := appendAttemptAction(repo, attemptResultKind(a.Status), finishedAt, payload); err != nil {
+			return fmt.Errorf("failed to record attempt %s: %w", a.ID, err)
+		}
+	}
+	return nil
+}
+
+// recordAttemptsFromHistory は history の試行アクションから、記録の無い試行の記録を作る
+func recordAttemptsFromHistory(repo persistence.WorkspaceRepository) error {
+	actions, err := repo.History().ListActions(time.Time{}, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list history: %w", err)
+	}
+	for _, a := range attemptsFromActions(actions, "") {
+		if err := saveMissingAttemptRecord(repo, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveMissingAttemptRecord は試行の記録が無ければ保存する
+func saveMissingAttemptRecord(repo persistence.WorkspaceRepository, a Attempt) error {
+	if _, err := repo.Attempts().GetAttempt(a.ID); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to load attempt %s: %w", a.ID, err)
+	}
+	if err := repo.Attempts().SaveAttempt(attemptRecord(&a)); err != nil {
+		return fmt.Errorf("failed to save attempt %s: %w", a.ID, err)
+	}
+	return nil
+}
+
+// archiveLegacyTaskStore は旧 TaskStore のファイルを legacy/ へ移し、空になったディレクトリを削除する
+func archiveLegacyTaskStore(workspaceDir string) error {
+	for _, name := range legacyStoreDirs {
+		src := filepath.Join(workspaceDir, name)
+		entries, err := os.ReadDir(src)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		dst := filepath.Join(workspaceDir, LegacyArchiveDir, name)
+		if len(entries) > 0 {
+			if err := os.MkdirAll(dst, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dst, err)
+			}
+		}
+		for _, entry := range entries {
+			if err := os.Rename(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return fmt.Errorf("failed to archive %s/%s: %w", name, entry.Name(), err)
+			}
+		}
+		if err := os.Remove(src); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	return nil
+}
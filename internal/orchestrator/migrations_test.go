@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateWorkspace_ImportsLegacyTaskStore(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Now().Add(-time.Hour).Truncate(time.Second)
+	finished := started.Add(time.Minute)
+	chatID := "chat-1"
+
+	// 旧形式のワークスペース（schema.json 無し、tasks/ と attempts/ のみ）
+	store := NewTaskStore(dir)
+	require.NoError(t, store.SaveTask(&Task{
+		ID: "t1", Title: "設計", Status: TaskStatusSucceeded, PoolID: "default",
+		CreatedAt: started, UpdatedAt: finished, StartedAt: &started, DoneAt: &finished,
+		AttemptCount: 1, SourceChatID: &chatID,
+	}))
+	require.NoError(t, store.SaveTask(&Task{
+		ID: "t2", Title: "実装", Status: TaskStatusPending, PoolID: "codegen",
+		Dependencies: []string{"t1"}, CreatedAt: started, UpdatedAt: started,
+	}))
+	require.NoError(t, store.SaveAttempt(&Attempt{
+		ID: "a1", TaskID: "t1", Status: AttemptStatusSucceeded, StartedAt: started, FinishedAt: &finished,
+	}))
+
+	repo := persistence.NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+	result, err := MigrateWorkspace(repo)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.FromVersion)
+	assert.Equal(t, WorkspaceSchemaVersion, result.ToVersion)
+	assert.NotEmpty(t, result.SnapshotID)
+
+	tasks, err := ListTaskViews(repo)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "t1", tasks[0].ID)
+	assert.Equal(t, "設計", tasks[0].Title)
+	assert.Equal(t, TaskStatusSucceeded, tasks[0].Status)
+	assert.Equal(t, 1, tasks[0].AttemptCount)
+	require.NotNil(t, tasks[0].SourceChatID)
+	assert.Equal(t, chatID, *tasks[0].SourceChatID)
+	require.NotNil(t, tasks[0].DoneAt)
+	assert.True(t, finished.Equal(*tasks[0].DoneAt))
+	assert.Equal(t, []string{"t1"}, tasks[1].Dependencies)
+	assert.Equal(t, "codegen", tasks[1].PoolID)
+
+	attempts, err := ListTaskAttempts(repo, "t1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "a1", attempts[0].ID)
+	assert.Equal(t, AttemptStatusSucceeded, attempts[0].Status)
+
+	report, err := BuildProgressReport(repo, time.Now(), 0)
+	require.NoError(t, err)
+	summaries := report.Pools
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "codegen", summaries[0].PoolID)
+	assert.Equal(t, 1, summaries[0].Queued)
+	assert.Equal(t, "default", summaries[1].PoolID)
+	assert.Equal(t, 1, summaries[1].Counts[string(TaskStatusSucceeded)])
+
+	// 旧ファイルは legacy/ へ退避される
+	assert.False(t, HasLegacyTaskStore(dir))
+	_, err = os.Stat(filepath.Join(dir, LegacyArchiveDir, "tasks", "t1.jsonl"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, LegacyArchiveDir, "attempts", "a1.json"))
+	assert.NoError(t, err)
+
+	// 再実行しても二重に取り込まれない
+	result, err = MigrateWorkspace(repo)
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	require.NoError(t, ImportLegacyTaskStore(repo))
+	attempts, err = ListTaskAttempts(repo, "t1")
+	require.NoError(t, err)
+	assert.Len(t, attempts, 1)
+}
+
+func TestMigrateWorkspace_EmptyWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	repo := persistence.NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+
+	_, err := MigrateWorkspace(repo)
+	require.NoError(t, err)
+
+	info, err := persistence.LoadSchemaInfo(dir)
+	require.NoError(t, err)
+	assert.Equal(t, WorkspaceSchemaVersion, info.Version)
+	tasks, err := ListTaskViews(repo)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
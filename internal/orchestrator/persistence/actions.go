@@ -0,0 +1,337 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Action.Kind の一覧
+// "state." で始まるアクションは state/*.json の状態遷移で、リプレイ時に適用される。
+// それ以外は監査用の記録で、リプレイでは状態を変えない。
+const (
+	// ActionStateBaseline は履歴の記録開始時点の state 全体（既存ワークスペースの起点）
+	ActionStateBaseline = "state.baseline"
+
+	ActionTaskCreated       = "state.task_created"
+	ActionTaskUpdated       = "state.task_updated"
+	ActionTaskStatusChanged = "state.task_status_changed"
+	ActionTaskRemoved       = "state.task_removed"
+	ActionQueueMetaUpdated  = "state.queue_meta_updated"
+
+	ActionNodeCreated       = "state.node_created"
+	ActionNodeUpdated       = "state.node_updated"
+	ActionNodeStatusChanged = "state.node_status_changed"
+	ActionNodeRemoved       = "state.node_removed"
+
+	// ActionPlanPatch はチャットによる計画変更（設計と状態の変更は個別の state.* アクションとして続く）
+	ActionPlanPatch = "plan_patch"
+	// ActionHistoryFailed は履歴の追記に失敗したことの記録
+	ActionHistoryFailed = "history_failed"
+	// ActionStateSaveFailed は履歴の追記後に state の保存が失敗したことの記録
+	// リプレイでは original_action_id / original_action_ids のアクションを適用しない。
+	ActionStateSaveFailed = "state_save_failed"
+
+	// task.attempt_started と task.succeeded / task.failed（AttemptPayload）がタスクの実行試行の記録になる
+	ActionTaskStarted        = "task.started"
+	ActionTaskAttemptStarted = "task.attempt_started"
+	ActionTaskSucceeded      = "task.succeeded"
+	ActionTaskFailed         = "task.failed"
+
+	// ActionSchemaMigrated はワークスペースのスキーマ版を進めたことの記録
+	ActionSchemaMigrated = "schema.migrated"
+
+	// ActionBacklogResolved はバックログアイテムの解決（タスクと設計の変更は個別の state.* アクションとして続く）
+	ActionBacklogResolved = "backlog.resolved"
+
+	// ActionTaskReplanned はリトライを使い切ったタスクの自動再計画（計画の変更は直前の plan_patch として記録される）
+	ActionTaskReplanned = "task.replanned"
+
+	// ActionTaskFileConflict は試行が宣言外のファイル・同時に実行中のタスクの書き込み先を変更したことの事後記録
+	ActionTaskFileConflict = "task.file_conflict"
+
+	// ActionNodeVerified はノードの検証結果の確定（ノードとタスクの変更は個別の state.* アクションとして続く）
+	ActionNodeVerified = "node.verified"
+
+	// ActionNodeImpacted は完了したノードの設計変更・再実装が影響するノードの記録（影響先の変更は個別の state.* アクションとして続く）
+	ActionNodeImpacted = "node.impacted"
+
+	// ActionScheduleFired は定期タスクの予定の処理（作成したタスクは続く state.task_created として記録される）
+	ActionScheduleFired = "schedule.fired"
+)
+
+// BaselinePayload は ActionStateBaseline のペイロード
+type BaselinePayload struct {
+	Tasks        *TasksState   `json:"tasks,omitempty"`
+	NodesRuntime *NodesRuntime `json:"nodes_runtime,omitempty"`
+}
+
+// TaskActionPayload はタスクの作成・更新・状態遷移のペイロード（遷移後のタスク全体を持つ）
+type TaskActionPayload struct {
+	Version    int64     `json:"version"` // 保存後の tasks.json の版
+	TaskID     string    `json:"task_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status,omitempty"`
+	Task       TaskState `json:"task"`
+}
+
+// TaskRemovedPayload はタスク削除のペイロード
+type TaskRemovedPayload struct {
+	Version int64  `json:"version"`
+	TaskID  string `json:"task_id"`
+}
+
+// QueueMetaPayload はキューのメタ情報更新のペイロード
+type QueueMetaPayload struct {
+	Version   int64     `json:"version"`
+	QueueMeta QueueMeta `json:"queue_meta"`
+}
+
+// NodeActionPayload はノード実行状態の作成・更新・状態遷移のペイロード
+type NodeActionPayload struct {
+	Version    int64       `json:"version"` // 保存後の nodes-runtime.json の版
+	NodeID     string      `json:"node_id"`
+	FromStatus string      `json:"from_status,omitempty"`
+	ToStatus   string      `json:"to_status,omitempty"`
+	Node       NodeRuntime `json:"node"`
+}
+
+// NodeRemovedPayload はノード実行状態削除のペイロード
+type NodeRemovedPayload struct {
+	Version int64  `json:"version"`
+	NodeID  string `json:"node_id"`
+}
+
+// AttemptPayload はタスクの実行試行（task.attempt_started / task.succeeded / task.failed）のペイロード
+// Status は終了時の試行ステータス（SUCCEEDED / FAILED / CANCELED など）。
+type AttemptPayload struct {
+	TaskID    string `json:"task_id"`
+	AttemptID string `json:"attempt_id"`
+	Status    string `json:"status,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BacklogResolvedPayload は ActionBacklogResolved のペイロード
+type BacklogResolvedPayload struct {
+	ItemID             string   `json:"item_id"`
+	TaskID             string   `json:"task_id,omitempty"`
+	ItemType           string   `json:"item_type"`
+	Action             string   `json:"action"`
+	Note               string   `json:"note,omitempty"`
+	Description        *string  `json:"description,omitempty"`
+	AcceptanceCriteria []string `json:"acceptance_criteria,omitempty"`
+	ToolingProfile     string   `json:"tooling_profile,omitempty"`
+	CreatedTaskIDs     []string `json:"created_task_ids,omitempty"`
+}
+
+// TaskReplannedPayload は ActionTaskReplanned のペイロード
+// Outcome は retry（元のタスクを再実行）か split（元のタスクを作成したタスクで置き換え）。
+type TaskReplannedPayload struct {
+	TaskID         string   `json:"task_id"`
+	FailureKind    string   `json:"failure_kind,omitempty"`
+	Replan         int      `json:"replan"`
+	Outcome        string   `json:"outcome"`
+	Understanding  string   `json:"understanding,omitempty"`
+	CreatedTaskIDs []string `json:"created_task_ids,omitempty"`
+}
+
+// TaskStartedPayload は ActionTaskStarted のペイロード
+// Reason は割り当ての理由（一致した能力と負荷）、Candidates は割り当て可能だったエージェントの数。
+type TaskStartedPayload struct {
+	TaskID               string   `json:"task_id"`
+	AgentID              string   `json:"agent_id"`
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"`
+	Reason               string   `json:"reason,omitempty"`
+	Candidates           int      `json:"candidates,omitempty"`
+}
+
+// TaskFileConflictPayload は ActionTaskFileConflict のペイロード
+type TaskFileConflictPayload struct {
+	TaskID             string   `json:"task_id"`
+	AttemptID          string   `json:"attempt_id,omitempty"`
+	DeclaredFiles      []string `json:"declared_files,omitempty"`
+	UndeclaredFiles    []string `json:"undeclared_files,omitempty"`
+	ConflictingTaskIDs []string `json:"conflicting_task_ids,omitempty"`
+}
+
+// NodeVerifiedPayload は ActionNodeVerified のペイロード
+// Status は passed / failed / flaky、Runs は結果を確定するまでの検証タスクの実行回数。
+type NodeVerifiedPayload struct {
+	NodeID string `json:"node_id"`
+	TaskID string `json:"task_id"`
+	Status string `json:"status"`
+	Runs   int    `json:"runs"`
+}
+
+// NodeImpactedPayload は ActionNodeImpacted のペイロード
+// Cause は design_changed（設計の変更）か reimplemented（再実装）。
+type NodeImpactedPayload struct {
+	NodeID            string   `json:"node_id"`
+	Cause             string   `json:"cause"`
+	Detail            string   `json:"detail,omitempty"`
+	ImpactedNodeIDs   []string `json:"impacted_node_ids"`
+	VerificationTasks []string `json:"verification_task_ids,omitempty"`
+}
+
+// ScheduleFiredPayload は ActionScheduleFired のペイロード
+// Missed は予定時刻から猶予を過ぎていたこと、Skipped は逃した予定をタスクを作らずに飛ばしたこと（TaskID は空）。
+type ScheduleFiredPayload struct {
+	ScheduleID string    `json:"schedule_id"`
+	TaskID     string    `json:"task_id,omitempty"`
+	DueAt      time.Time `json:"due_at"`
+	Missed     bool      `json:"missed,omitempty"`
+	Skipped    bool      `json:"skipped,omitempty"`
+}
+
+// StateSaveFailedPayload は state 保存失敗のペイロード
+type StateSaveFailedPayload struct {
+	OriginalActionIDs []string `json:"original_action_ids"`
+	Stage             string   `json:"stage"`
+	Error             string   `json:"error"`
+}
+
+// NewAction は型付きのペイロードから Action を生成する
+func NewAction(kind, workspaceID string, at time.Time, payload any) (*Action, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", kind, err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to convert %s payload: %w", kind, err)
+	}
+	return &Action{
+		ID:          uuid.New().String(),
+		At:          at,
+		Kind:        kind,
+		WorkspaceID: workspaceID,
+		Payload:     m,
+	}, nil
+}
+
+// DecodePayload はペイロードを型付きの構造体へ変換する
+func (a Action) DecodePayload(v any) error {
+	data, err := json.Marshal(a.Payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", a.Kind, err)
+	}
+	return nil
+}
+
+// IsStateTransition は state/*.json の状態遷移を表すアクションかどうかを返す
+func (a Action) IsStateTransition() bool {
+	return strings.HasPrefix(a.Kind, "state.")
+}
+
+// --- State diff ---
+
+// diffTasks は保存前後の TasksState を比較し、状態遷移アクションを返す
+func diffTasks(workspaceID string, at time.Time, before, after *TasksState, version int64) ([]*Action, error) {
+	var actions []*Action
+	add := func(kind string, payload any) error {
+		a, err := NewAction(kind, workspaceID, at, payload)
+		if err != nil {
+			return err
+		}
+		actions = append(actions, a)
+		return nil
+	}
+
+	old := make(map[string]TaskState, len(before.Tasks))
+	for _, t := range before.Tasks {
+		old[t.TaskID] = t
+	}
+	seen := make(map[string]bool, len(after.Tasks))
+	for _, t := range after.Tasks {
+		seen[t.TaskID] = true
+		prev, existed := old[t.TaskID]
+		payload := TaskActionPayload{Version: version, TaskID: t.TaskID, Task: t}
+		var err error
+		switch {
+		case !existed:
+			payload.ToStatus = t.Status
+			err = add(ActionTaskCreated, payload)
+		case prev.Status != t.Status:
+			payload.FromStatus, payload.ToStatus = prev.Status, t.Status
+			err = add(ActionTaskStatusChanged, payload)
+		case !sameJSON(prev, t):
+			err = add(ActionTaskUpdated, payload)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range before.Tasks {
+		if !seen[t.TaskID] {
+			if err := add(ActionTaskRemoved, TaskRemovedPayload{Version: version, TaskID: t.TaskID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !sameJSON(before.QueueMeta, after.QueueMeta) {
+		if err := add(ActionQueueMetaUpdated, QueueMetaPayload{Version: version, QueueMeta: after.QueueMeta}); err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+// diffNodesRuntime は保存前後の NodesRuntime を比較し、状態遷移アクションを返す
+func diffNodesRuntime(workspaceID string, at time.Time, before, after *NodesRuntime, version int64) ([]*Action, error) {
+	var actions []*Action
+	add := func(kind string, payload any) error {
+		a, err := NewAction(kind, workspaceID, at, payload)
+		if err != nil {
+			return err
+		}
+		actions = append(actions, a)
+		return nil
+	}
+
+	old := make(map[string]NodeRuntime, len(before.Nodes))
+	for _, n := range before.Nodes {
+		old[n.NodeID] = n
+	}
+	seen := make(map[string]bool, len(after.Nodes))
+	for _, n := range after.Nodes {
+		seen[n.NodeID] = true
+		prev, existed := old[n.NodeID]
+		payload := NodeActionPayload{Version: version, NodeID: n.NodeID, Node: n}
+		var err error
+		switch {
+		case !existed:
+			payload.ToStatus = n.Status
+			err = add(ActionNodeCreated, payload)
+		case prev.Status != n.Status:
+			payload.FromStatus, payload.ToStatus = prev.Status, n.Status
+			err = add(ActionNodeStatusChanged, payload)
+		case !sameJSON(prev, n):
+			err = add(ActionNodeUpdated, payload)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range before.Nodes {
+		if !seen[n.NodeID] {
+			if err := add(ActionNodeRemoved, NodeRemovedPayload{Version: version, NodeID: n.NodeID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return actions, nil
+}
+
+// sameJSON は 2 つの値の JSON 表現が等しいかを返す（time.Time の monotonic 部分などを無視するため）
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
@@ -0,0 +1,382 @@
+package persistence
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 実行試行は runs/<attempt-id>/ に記録する
+//
+//	attempt.json      試行の記録（AttemptRecord）
+//	output.jsonl      実行中の stdout / stderr（1 行 1 AttemptLogLine）
+//	output.jsonl.gz   試行の終了時に output.jsonl を圧縮したもの
+//
+// 旧 TaskStore の attempts/ と区別するため、ディレクトリ名は runs とする。
+const (
+	AttemptsDirName          = "runs"
+	attemptRecordFileName    = "attempt.json"
+	attemptLogFileName       = "output.jsonl"
+	attemptLogCompressedName = "output.jsonl.gz"
+)
+
+const (
+	// DefaultAttemptLogPageSize は ReadLog で limit を省略した場合の行数
+	DefaultAttemptLogPageSize = 1000
+	// MaxAttemptLogPageSize は ReadLog で一度に返す最大行数
+	MaxAttemptLogPageSize = 10000
+)
+
+// AttemptRecord はタスクの実行試行の記録
+type AttemptRecord struct {
+	AttemptID    string           `json:"attempt_id"`
+	TaskID       string           `json:"task_id"`
+	PoolID       string           `json:"pool_id,omitempty"`
+	Status       string           `json:"status"`
+	StartedAt    time.Time        `json:"started_at"`
+	FinishedAt   *time.Time       `json:"finished_at,omitempty"`
+	ExitCode     *int             `json:"exit_code,omitempty"`
+	ErrorSummary string           `json:"error_summary,omitempty"`
+	FailureKind  string           `json:"failure_kind,omitempty"`
+	Tooling      *AttemptTooling  `json:"tooling,omitempty"`
+	Artifacts    AttemptArtifacts `json:"artifacts"`
+	LogLines     int              `json:"log_lines"`
+	WorkerRuns   int              `json:"worker_runs,omitempty"`
+	InputTokens  int              `json:"input_tokens,omitempty"`
+	OutputTokens int              `json:"output_tokens,omitempty"`
+	CostUSD      float64          `json:"cost_usd,omitempty"`
+}
+
+// AttemptTooling は試行で使われた tooling の候補（agent-runner が選択したもの）
+type AttemptTooling struct {
+	Tool  string `json:"tool,omitempty"`
+	Model string `json:"model,omitempty"`
+}
+
+// AttemptArtifacts は試行の成果物
+type AttemptArtifacts struct {
+	Files []string          `json:"files,omitempty"`
+	Logs  []string          `json:"logs,omitempty"`
+	Diffs []AttemptFileDiff `json:"diffs,omitempty"`
+}
+
+// AttemptFileDiff は試行で変更した 1 ファイルの差分
+type AttemptFileDiff struct {
+	Path      string `json:"path"`
+	Patch     string `json:"patch,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// AttemptLogLine は試行ログの 1 行（Seq は 0 始まりの行番号）
+type AttemptLogLine struct {
+	Seq    int       `json:"seq"`
+	At     time.Time `json:"at"`
+	Stream string    `json:"stream"`
+	Line   string    `json:"line"`
+}
+
+// AttemptLogPage は ReadLog の結果
+// Next は次に読む行番号。Complete が true なら試行のログはこれ以上増えない。
+type AttemptLogPage struct {
+	AttemptID string           `json:"attempt_id"`
+	Lines     []AttemptLogLine `json:"lines"`
+	Next      int              `json:"next"`
+	Complete  bool             `json:"complete"`
+}
+
+// AttemptRepository は runs/ の実行試行の記録とログを扱う
+type AttemptRepository interface {
+	SaveAttempt(record *AttemptRecord) error
+	GetAttempt(attemptID string) (*AttemptRecord, error)
+	// ListAttempts は taskID の試行を開始順に返す（taskID が空なら全タスク）
+	ListAttempts(taskID string) ([]AttemptRecord, error)
+	// OpenLog は試行のログを書き込み用に開く（既存のログには追記する）
+	OpenLog(attemptID string) (*AttemptLogWriter, error)
+	// ReadLog は from 行目から最大 limit 行を返す（limit <= 0 なら DefaultAttemptLogPageSize）
+	ReadLog(attemptID string, from, limit int) (*AttemptLogPage, error)
+}
+
+type attemptRepoImpl struct {
+	baseDir string
+	clock   Clock
+}
+
+func (r *attemptRepoImpl) dir(attemptID string) (string, error) {
+	if attemptID == "" || attemptID != filepath.Base(attemptID) || attemptID == "." || attemptID == ".." {
+		return "", fmt.Errorf("invalid attempt id: %q", attemptID)
+	}
+	return filepath.Join(r.baseDir, attemptID), nil
+}
+
+func (r *attemptRepoImpl) SaveAttempt(record *AttemptRecord) error {
+	dir, err := r.dir(record.AttemptID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(dir, attemptRecordFileName), record)
+}
+
+func (r *attemptRepoImpl) GetAttempt(attemptID string) (*AttemptRecord, error) {
+	dir, err := r.dir(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	var record AttemptRecord
+	if err := readJSON(filepath.Join(dir, attemptRecordFileName), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *attemptRepoImpl) ListAttempts(taskID string) ([]AttemptRecord, error) {
+	entries, err := os.ReadDir(r.baseDir)
+	if os.IsNotExist(err) {
+		return []AttemptRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := []AttemptRecord{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		var record AttemptRecord
+		if err := readJSON(filepath.Join(r.baseDir, entry.Name(), attemptRecordFileName), &record); err != nil {
+			continue // 書き込み途中・壊れた記録は飛ばす
+		}
+		if taskID != "" && record.TaskID != taskID {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].StartedAt.Before(records[j].StartedAt) })
+	return records, nil
+}
+
+func (r *attemptRepoImpl) OpenLog(attemptID string) (*AttemptLogWriter, error) {
+	dir, err := r.dir(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, attemptLogFileName)
+	lines, err := countLogLines(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &AttemptLogWriter{path: path, file: f, enc: json.NewEncoder(f), seq: lines, clock: r.clock}, nil
+}
+
+func (r *attemptRepoImpl) ReadLog(attemptID string, from, limit int) (*AttemptLogPage, error) {
+	dir, err := r.dir(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	var record AttemptRecord
+	if err := readJSON(filepath.Join(dir, attemptRecordFileName), &record); err != nil {
+		return nil, err
+	}
+	if from < 0 {
+		from = 0
+	}
+	if limit <= 0 {
+		limit = DefaultAttemptLogPageSize
+	}
+	if limit > MaxAttemptLogPageSize {
+		limit = MaxAttemptLogPageSize
+	}
+	page := &AttemptLogPage{AttemptID: attemptID, Lines: []AttemptLogLine{}, Next: from}
+
+	// 実行中は output.jsonl、終了後は圧縮済みのファイルを読む
+	// 圧縮は .gz を書き終えてから output.jsonl を消すため、どちらかは必ず完全な内容を持つ。
+	rc, live, err := openAttemptLog(dir)
+	if err != nil {
+		return nil, err
+	}
+	if rc == nil {
+		page.Complete = true
+		return page, nil
+	}
+	defer func() { _ = rc.Close() }()
+
+	dec := json.NewDecoder(bufio.NewReader(rc))
+	for len(page.Lines) < limit {
+		var line AttemptLogLine
+		if err := dec.Decode(&line); err != nil {
+			// 実行中のファイルは末尾の行が書き込み途中の場合がある（次回に読む）
+			break
+		}
+		if line.Seq < from {
+			continue
+		}
+		page.Lines = append(page.Lines, line)
+		page.Next = line.Seq + 1
+	}
+	// 終了した試行のログが圧縮されずに残っている場合（プロセスの異常終了など）も、読み切れば完了とする
+	if len(page.Lines) < limit && (!live || record.FinishedAt != nil) {
+		page.Complete = true
+	}
+	return page, nil
+}
+
+// openAttemptLog は試行ログを読み込み用に開き、実行中のファイルかどうかを返す（ログが無ければ nil）
+func openAttemptLog(dir string) (io.ReadCloser, bool, error) {
+	if f, err := os.Open(filepath.Join(dir, attemptLogFileName)); err == nil {
+		return f, true, nil
+	} else if !os.IsNotExist(err) {
+		return nil, false, err
+	}
+	f, err := os.Open(filepath.Join(dir, attemptLogCompressedName))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, false, fmt.Errorf("failed to open compressed log: %w", err)
+	}
+	return &gzipFile{Reader: zr, file: f}, false, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	_ = g.Reader.Close()
+	return g.file.Close()
+}
+
+// countLogLines は既存の output.jsonl の行数を返す（追記時の行番号の起点）
+func countLogLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines, scanner.Err()
+}
+
+// AttemptLogWriter は試行の stdout / stderr を行単位で記録する（複数の goroutine から呼べる）
+type AttemptLogWriter struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	enc    *json.Encoder
+	seq    int
+	closed bool
+	clock  Clock
+}
+
+// WriteLine は 1 行を追記する
+func (w *AttemptLogWriter) WriteLine(stream, line string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if err := w.enc.Encode(AttemptLogLine{Seq: w.seq, At: w.clock.Now(), Stream: stream, Line: line}); err != nil {
+		return err
+	}
+	w.seq++
+	return nil
+}
+
+// Lines はこれまでに記録した行数を返す
+func (w *AttemptLogWriter) Lines() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.seq
+}
+
+// Close はログを閉じて gzip で圧縮し、圧縮前のファイルを削除する
+func (w *AttemptLogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	return compressAttemptLog(w.path)
+}
+
+// compressAttemptLog は output.jsonl を output.jsonl.gz に圧縮して元のファイルを削除する
+func compressAttemptLog(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	gzPath := filepath.Join(filepath.Dir(path), attemptLogCompressedName)
+	tmpPath := gzPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to compress log: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to compress log: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, gzPath); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
+
+// CompressAttemptLog は閉じられずに残った試行ログ（プロセスの異常終了など）を圧縮する
+func CompressAttemptLog(repo WorkspaceRepository, attemptID string) error {
+	path := filepath.Join(repo.BaseDir(), AttemptsDirName, attemptID, attemptLogFileName)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return compressAttemptLog(path)
+}
@@ -0,0 +1,138 @@
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAttemptRepo(t *testing.T) (string, AttemptRepository) {
+	t.Helper()
+	dir := t.TempDir()
+	repo := NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+	return dir, repo.Attempts()
+}
+
+func TestAttemptRepository_SaveListGet(t *testing.T) {
+	_, repo := newAttemptRepo(t)
+	base := time.Now().Truncate(time.Second)
+	exitCode := 2
+
+	require.NoError(t, repo.SaveAttempt(&AttemptRecord{AttemptID: "a2", TaskID: "t1", Status: "FAILED", StartedAt: base.Add(time.Minute), ExitCode: &exitCode}))
+	require.NoError(t, repo.SaveAttempt(&AttemptRecord{AttemptID: "a1", TaskID: "t1", Status: "SUCCEEDED", StartedAt: base,
+		Tooling: &AttemptTooling{Tool: "codex-cli", Model: "gpt-5.1"}, Artifacts: AttemptArtifacts{Files: []string{"main.go"}}}))
+	require.NoError(t, repo.SaveAttempt(&AttemptRecord{AttemptID: "b1", TaskID: "t2", Status: "RUNNING", StartedAt: base}))
+
+	records, err := repo.ListAttempts("t1")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "a1", records[0].AttemptID)
+	assert.Equal(t, "a2", records[1].AttemptID)
+
+	all, err := repo.ListAttempts("")
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	got, err := repo.GetAttempt("a1")
+	require.NoError(t, err)
+	require.NotNil(t, got.Tooling)
+	assert.Equal(t, "codex-cli", got.Tooling.Tool)
+	assert.Equal(t, []string{"main.go"}, got.Artifacts.Files)
+
+	got, err = repo.GetAttempt("a2")
+	require.NoError(t, err)
+	require.NotNil(t, got.ExitCode)
+	assert.Equal(t, 2, *got.ExitCode)
+
+	_, err = repo.GetAttempt("missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// 試行 ID で runs/ の外を指せない
+	assert.Error(t, repo.SaveAttempt(&AttemptRecord{AttemptID: "../escape", TaskID: "t1"}))
+	_, err = repo.GetAttempt("..")
+	assert.Error(t, err)
+}
+
+func TestAttemptRepository_LogPagingAndCompression(t *testing.T) {
+	dir, repo := newAttemptRepo(t)
+	require.NoError(t, repo.SaveAttempt(&AttemptRecord{AttemptID: "a1", TaskID: "t1", Status: "RUNNING", StartedAt: time.Now()}))
+
+	// 試行が始まる前（ログ無し）は空で完了扱い
+	page, err := repo.ReadLog("a1", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, page.Lines)
+	assert.True(t, page.Complete)
+
+	w, err := repo.OpenLog("a1")
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.WriteLine("stdout", fmt.Sprintf("line %d", i)))
+	}
+	assert.Equal(t, 5, w.Lines())
+
+	page, err = repo.ReadLog("a1", 1, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 2)
+	assert.Equal(t, "line 1", page.Lines[0].Line)
+	assert.Equal(t, 2, page.Lines[1].Seq)
+	assert.Equal(t, 3, page.Next)
+	assert.False(t, page.Complete)
+
+	// 実行中は読み切っても完了ではない
+	page, err = repo.ReadLog("a1", 3, 0)
+	require.NoError(t, err)
+	assert.Len(t, page.Lines, 2)
+	assert.Equal(t, 5, page.Next)
+	assert.False(t, page.Complete)
+
+	require.NoError(t, w.Close())
+	assert.ErrorIs(t, w.WriteLine("stdout", "late"), os.ErrClosed)
+	runDir := filepath.Join(dir, AttemptsDirName, "a1")
+	_, err = os.Stat(filepath.Join(runDir, attemptLogCompressedName))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(runDir, attemptLogFileName))
+	assert.True(t, os.IsNotExist(err))
+
+	// 圧縮後も同じ行番号で読める
+	page, err = repo.ReadLog("a1", 3, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 2)
+	assert.Equal(t, "line 4", page.Lines[1].Line)
+	assert.True(t, page.Complete)
+
+	_, err = repo.ReadLog("missing", 0, 0)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAttemptRepository_LeftoverLog(t *testing.T) {
+	dir, repo := newAttemptRepo(t)
+	finished := time.Now()
+	require.NoError(t, repo.SaveAttempt(&AttemptRecord{AttemptID: "a1", TaskID: "t1", Status: "FAILED", StartedAt: finished, FinishedAt: &finished}))
+	w, err := repo.OpenLog("a1")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteLine("stderr", "boom"))
+
+	// 終了済みの試行に圧縮前のログが残っていても、読み切れば完了
+	page, err := repo.ReadLog("a1", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 1)
+	assert.True(t, page.Complete)
+
+	// 追記で開き直すと行番号が続く
+	w2, err := repo.OpenLog("a1")
+	require.NoError(t, err)
+	require.NoError(t, w2.WriteLine("stderr", "again"))
+	require.NoError(t, w2.Close())
+
+	require.NoError(t, CompressAttemptLog(NewWorkspaceRepository(dir), "a1"))
+	page, err = repo.ReadLog("a1", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Lines, 2)
+	assert.Equal(t, 1, page.Lines[1].Seq)
+}
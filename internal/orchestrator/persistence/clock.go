@@ -0,0 +1,54 @@
+package persistence
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock は現在時刻の取得元
+// 通常は SystemClock を使い、シミュレーションやテストでは VirtualClock に差し替えて時刻を決定的に進める。
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock は実時間の Clock
+var SystemClock Clock = systemClock{}
+
+// VirtualClock は Advance / Set を呼んだときだけ進む仮想時刻
+type VirtualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewVirtualClock は start から始まる仮想時刻を作成する
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now は現在の仮想時刻を返す
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance は仮想時刻を d だけ進め、進めた後の時刻を返す
+func (c *VirtualClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Set は仮想時刻を t にする（過去に戻すことはできない）
+func (c *VirtualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
@@ -0,0 +1,54 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVirtualClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(start)
+	if !clock.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, clock.Now())
+	}
+	if got := clock.Advance(5 * time.Second); !got.Equal(start.Add(5 * time.Second)) {
+		t.Fatalf("unexpected time after Advance: %v", got)
+	}
+	// 過去には戻らない
+	clock.Set(start)
+	if !clock.Now().Equal(start.Add(5 * time.Second)) {
+		t.Fatalf("Set moved the clock backwards: %v", clock.Now())
+	}
+	clock.Set(start.Add(time.Minute))
+	if !clock.Now().Equal(start.Add(time.Minute)) {
+		t.Fatalf("unexpected time after Set: %v", clock.Now())
+	}
+}
+
+func TestWorkspaceRepositoryWithClock_RecordsVirtualTime(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewWorkspaceRepositoryWithClock(t.TempDir(), NewVirtualClock(start))
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	err := repo.State().UpdateTasks(func(s *TasksState) error {
+		s.Tasks = append(s.Tasks, TaskState{TaskID: "t1", Status: "PENDING"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateTasks failed: %v", err)
+	}
+	actions, err := repo.History().ListActions(start, start)
+	if err != nil {
+		t.Fatalf("ListActions failed: %v", err)
+	}
+	found := false
+	for _, a := range actions {
+		if a.Kind == ActionTaskCreated {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected task_created action at virtual time, got %+v", actions)
+	}
+}
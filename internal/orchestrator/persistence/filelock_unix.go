@@ -0,0 +1,32 @@
+//go:build !windows
+
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile は path に対応する .lock ファイルで排他 advisory lock（flock）を取得する
+// 同一プロセス内でも open ごとに別ロックとして扱われるため、goroutine 間の排他にもなる。
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(lockPath(path), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to acquire file lock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
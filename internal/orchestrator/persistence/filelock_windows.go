@@ -0,0 +1,38 @@
+//go:build windows
+
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleLockTimeout を超えて残っている .lock ファイルはクラッシュしたプロセスのものとみなす
+const staleLockTimeout = 30 * time.Second
+
+// lockFile は .lock ファイルの排他作成（O_EXCL）でロックを取得する
+// Windows では flock が使えないため、作成できるまでポーリングする。
+func lockFile(path string) (func(), error) {
+	lp := lockPath(path)
+	deadline := time.Now().Add(2 * staleLockTimeout)
+	for {
+		f, err := os.OpenFile(lp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+			return func() { _ = os.Remove(lp) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+		if info, statErr := os.Stat(lp); statErr == nil && time.Since(info.ModTime()) > staleLockTimeout {
+			_ = os.Remove(lp)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for file lock: %s", lp)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,284 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LeaderFileName はワークスペース直下に置くリーダーロックファイル名
+const LeaderFileName = "leader.json"
+
+// DefaultLeaderStaleAfter を超えてハートビートが更新されていないロックは奪取できる
+const DefaultLeaderStaleAfter = 30 * time.Second
+
+// リーダーの種別
+const (
+	LeaderRoleDaemon = "daemon"
+	LeaderRoleIDE    = "ide"
+)
+
+// ErrLeaderLockHeld は他のプロセスが有効なリーダーロックを保持している場合に返る
+var ErrLeaderLockHeld = errors.New("workspace is already owned by another orchestrator")
+
+// ErrLeaderLockLost はハートビート時に自分がリーダーでなくなっていた場合に返る
+var ErrLeaderLockLost = errors.New("leader lock lost")
+
+// LeaderInfo はワークスペースのキュー消費権を持つオーケストレータの情報
+type LeaderInfo struct {
+	InstanceID  string    `json:"instance_id"`
+	Role        string    `json:"role"`
+	PID         int       `json:"pid"`
+	Hostname    string    `json:"hostname"`
+	State       string    `json:"state,omitempty"`    // ExecutionState（クライアント表示用）
+	Endpoint    string    `json:"endpoint,omitempty"` // 外部から操作するためのエンドポイント（あれば）
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// IsStale はハートビートが staleAfter 以上更新されていないかを返す
+func (i *LeaderInfo) IsStale(now time.Time, staleAfter time.Duration) bool {
+	return now.Sub(i.HeartbeatAt) > staleAfter
+}
+
+// String はログ・エラーメッセージ用の表記を返す
+func (i *LeaderInfo) String() string {
+	return fmt.Sprintf("%s pid=%d host=%s", i.Role, i.PID, i.Hostname)
+}
+
+// LeaderLockHeldError は保持者の情報付きで ErrLeaderLockHeld を表す
+type LeaderLockHeldError struct {
+	Holder LeaderInfo
+}
+
+func (e *LeaderLockHeldError) Error() string {
+	return fmt.Sprintf("%s (%s, last heartbeat %s)", ErrLeaderLockHeld.Error(), e.Holder.String(), e.Holder.HeartbeatAt.Format(time.RFC3339))
+}
+
+func (e *LeaderLockHeldError) Unwrap() error { return ErrLeaderLockHeld }
+
+// ReadLeader は現在のリーダー情報を読み込む（ロックファイルが無い場合は nil）
+func ReadLeader(workspaceDir string) (*LeaderInfo, error) {
+	var info LeaderInfo
+	if err := readJSON(filepath.Join(workspaceDir, LeaderFileName), &info); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ActiveLeader はハートビートが有効なリーダーがいればその情報を返す
+func ActiveLeader(workspaceDir string, staleAfter time.Duration) (*LeaderInfo, bool) {
+	info, err := ReadLeader(workspaceDir)
+	if err != nil || info == nil {
+		return nil, false
+	}
+	if info.IsStale(time.Now(), staleAfter) {
+		return nil, false
+	}
+	return info, true
+}
+
+// LeaderLock はワークスペース単位の単一インスタンス保証（リーダーロック）
+// leader.json に PID・ホスト名・ハートビートを記録し、読み書きは advisory lock で直列化する。
+// ハートビートが StaleAfter を超えて途絶えたロックは別プロセスが奪取できる。
+type LeaderLock struct {
+	path       string
+	StaleAfter time.Duration
+	Interval   time.Duration
+
+	mu     sync.Mutex
+	info   LeaderInfo
+	held   bool
+	stopCh chan struct{}
+}
+
+// NewLeaderLock は workspaceDir のリーダーロックを生成する（まだ取得はしない）
+func NewLeaderLock(workspaceDir, role string) *LeaderLock {
+	hostname, _ := os.Hostname()
+	return &LeaderLock{
+		path:       filepath.Join(workspaceDir, LeaderFileName),
+		StaleAfter: DefaultLeaderStaleAfter,
+		Interval:   DefaultLeaderStaleAfter / 3,
+		info: LeaderInfo{
+			InstanceID: uuid.New().String(),
+			Role:       role,
+			PID:        os.Getpid(),
+			Hostname:   hostname,
+		},
+	}
+}
+
+// Info は自分のリーダー情報を返す
+func (l *LeaderLock) Info() LeaderInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.info
+}
+
+// Held はロックを保持しているかを返す
+func (l *LeaderLock) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.held
+}
+
+// Acquire はリーダーロックを取得する
+// 有効な他のリーダーがいる場合は *LeaderLockHeldError を返す。取得済みなら何もしない。
+func (l *LeaderLock) Acquire() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held {
+		return nil
+	}
+
+	err := withFileLock(l.path, func() error {
+		current, err := l.readLocked()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if current != nil && current.InstanceID != l.info.InstanceID && !current.IsStale(now, l.StaleAfter) {
+			return &LeaderLockHeldError{Holder: *current}
+		}
+		l.info.StartedAt = now
+		l.info.HeartbeatAt = now
+		return writeJSON(l.path, &l.info)
+	})
+	if err != nil {
+		return err
+	}
+
+	l.held = true
+	l.stopCh = make(chan struct{})
+	return nil
+}
+
+// Heartbeat はハートビート時刻（と state）を更新する
+// 他のプロセスにロックを奪取されていた場合は ErrLeaderLockLost を返す。
+func (l *LeaderLock) Heartbeat(state string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.held {
+		return ErrLeaderLockLost
+	}
+
+	err := withFileLock(l.path, func() error {
+		current, err := l.readLocked()
+		if err != nil {
+			return err
+		}
+		if current == nil || current.InstanceID != l.info.InstanceID {
+			return ErrLeaderLockLost
+		}
+		l.info.HeartbeatAt = time.Now()
+		if state != "" {
+			l.info.State = state
+		}
+		return writeJSON(l.path, &l.info)
+	})
+	if errors.Is(err, ErrLeaderLockLost) {
+		l.held = false
+	}
+	return err
+}
+
+// SetEndpoint はクライアントが接続するためのエンドポイントを記録する
+func (l *LeaderLock) SetEndpoint(endpoint string) {
+	l.mu.Lock()
+	l.info.Endpoint = endpoint
+	l.mu.Unlock()
+}
+
+// RunHeartbeat は Interval ごとにハートビートを書き込む（Release まで）
+// state は書き込み時点の状態を返す関数（nil 可）。ロックを失った場合は onLost を呼んで終了する。
+func (l *LeaderLock) RunHeartbeat(state func() string, onLost func(error)) {
+	l.mu.Lock()
+	stopCh := l.stopCh
+	interval := l.Interval
+	l.mu.Unlock()
+	if stopCh == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultLeaderStaleAfter / 3
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				s := ""
+				if state != nil {
+					s = state()
+				}
+				err := l.Heartbeat(s)
+				if errors.Is(err, ErrLeaderLockLost) {
+					if onLost != nil {
+						onLost(err)
+					}
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Release はリーダーロックを解放する（自分が保持している場合のみファイルを削除する）
+func (l *LeaderLock) Release() error {
+	l.mu.Lock()
+	stopCh := l.stopCh
+	l.stopCh = nil
+	held := l.held
+	l.held = false
+	l.mu.Unlock()
+
+	if stopCh != nil {
+		close(stopCh)
+	}
+
+	if !held {
+		return nil
+	}
+	return withFileLock(l.path, func() error {
+		current, err := l.readLocked()
+		if err != nil {
+			return err
+		}
+		if current == nil || current.InstanceID != l.info.InstanceID {
+			return nil
+		}
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove leader lock: %w", err)
+		}
+		return nil
+	})
+}
+
+// readLocked は leader.json を読み込む（呼び出し側で advisory lock を保持していること）
+// 壊れたファイルは保持者なしとして扱う。
+func (l *LeaderLock) readLocked() (*LeaderInfo, error) {
+	var info LeaderInfo
+	if err := readJSON(l.path, &info); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return &info, nil
+}
@@ -0,0 +1,75 @@
+package persistence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderLock_AcquireAndRelease(t *testing.T) {
+	dir := t.TempDir()
+	first := NewLeaderLock(dir, LeaderRoleDaemon)
+	second := NewLeaderLock(dir, LeaderRoleIDE)
+
+	require.NoError(t, first.Acquire())
+	assert.True(t, first.Held())
+	// 取得済みの再取得は冪等
+	require.NoError(t, first.Acquire())
+
+	err := second.Acquire()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrLeaderLockHeld))
+	var held *LeaderLockHeldError
+	require.True(t, errors.As(err, &held))
+	assert.Equal(t, LeaderRoleDaemon, held.Holder.Role)
+	assert.Equal(t, first.Info().PID, held.Holder.PID)
+
+	info, ok := ActiveLeader(dir, DefaultLeaderStaleAfter)
+	require.True(t, ok)
+	assert.Equal(t, first.Info().InstanceID, info.InstanceID)
+
+	require.NoError(t, first.Release())
+	_, ok = ActiveLeader(dir, DefaultLeaderStaleAfter)
+	assert.False(t, ok)
+
+	require.NoError(t, second.Acquire())
+	require.NoError(t, second.Release())
+}
+
+func TestLeaderLock_StaleTakeover(t *testing.T) {
+	dir := t.TempDir()
+	stale := NewLeaderLock(dir, LeaderRoleDaemon)
+	require.NoError(t, stale.Acquire())
+
+	next := NewLeaderLock(dir, LeaderRoleDaemon)
+	next.StaleAfter = 10 * time.Millisecond
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, next.Acquire())
+
+	// 奪取された側はハートビートで喪失を検知し、解放しても相手のロックを消さない
+	err := stale.Heartbeat("RUNNING")
+	assert.ErrorIs(t, err, ErrLeaderLockLost)
+	assert.False(t, stale.Held())
+	require.NoError(t, stale.Release())
+
+	info, err := ReadLeader(dir)
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, next.Info().InstanceID, info.InstanceID)
+}
+
+func TestLeaderLock_HeartbeatRecordsState(t *testing.T) {
+	dir := t.TempDir()
+	lock := NewLeaderLock(dir, LeaderRoleDaemon)
+	require.NoError(t, lock.Acquire())
+	defer func() { _ = lock.Release() }()
+
+	require.NoError(t, lock.Heartbeat("PAUSED"))
+	info, err := ReadLeader(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "PAUSED", info.State)
+	assert.False(t, info.IsStale(time.Now(), DefaultLeaderStaleAfter))
+}
@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SchemaFileName はワークスペースのスキーマ版を記録するファイル（ワークスペース直下）
+const SchemaFileName = "schema.json"
+
+// ErrSchemaTooNew はワークスペースのスキーマ版がこのバイナリの知っている版より新しい場合に返る
+// 新しいバイナリで書き込まれたワークスペースを古いバイナリで壊さないため、マイグレーションを行わずに失敗させる。
+var ErrSchemaTooNew = errors.New("workspace schema is newer than supported")
+
+// SchemaInfo はワークスペースのスキーマ版と適用済みマイグレーションの記録
+// schema.json が存在しないワークスペースは版 0（スキーマ版の導入前）として扱う。
+type SchemaInfo struct {
+	Version   int                `json:"version"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Applied   []AppliedMigration `json:"applied,omitempty"`
+}
+
+// AppliedMigration は適用済みのマイグレーション
+type AppliedMigration struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied_at"`
+}
+
+// Migration はスキーマ版を Version へ進める変換
+// Apply が途中で失敗しても版は進まず、次回に再実行されるため、Apply は冪等に実装すること。
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(repo WorkspaceRepository) error
+}
+
+// MigrationResult は Migrate の結果
+type MigrationResult struct {
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	Applied     []AppliedMigration `json:"applied"`
+	SnapshotID  string             `json:"snapshot_id,omitempty"` // マイグレーション前に取得したスナップショット
+}
+
+// SchemaMigratedPayload は ActionSchemaMigrated のペイロード
+type SchemaMigratedPayload struct {
+	FromVersion int    `json:"from_version"`
+	ToVersion   int    `json:"to_version"`
+	Description string `json:"description"`
+}
+
+// LoadSchemaInfo はワークスペースのスキーマ情報を読み込む（schema.json が無い場合は版 0）
+func LoadSchemaInfo(workspaceDir string) (*SchemaInfo, error) {
+	var info SchemaInfo
+	if err := readJSON(filepath.Join(workspaceDir, SchemaFileName), &info); err != nil {
+		if os.IsNotExist(err) {
+			return &SchemaInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", SchemaFileName, err)
+	}
+	return &info, nil
+}
+
+// LatestSchemaVersion は migrations を全て適用した後の版を返す
+func LatestSchemaVersion(migrations []Migration) int {
+	latest := 0
+	for _, m := range migrations {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+// Migrate は未適用のマイグレーションを版の順に適用する
+// schema.json のロックを保持して実行するため、複数プロセスが同時に開いても 1 度だけ適用される。
+// 適用前に自動スナップショットを取得し、各マイグレーションの成功ごとに schema.json と履歴を更新する。
+func Migrate(repo WorkspaceRepository, migrations []Migration) (*MigrationResult, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	latest := LatestSchemaVersion(sorted)
+
+	dir := repo.BaseDir()
+	path := filepath.Join(dir, SchemaFileName)
+	result := &MigrationResult{Applied: []AppliedMigration{}}
+
+	err := withFileLock(path, func() error {
+		info, err := LoadSchemaInfo(dir)
+		if err != nil {
+			return err
+		}
+		result.FromVersion, result.ToVersion = info.Version, info.Version
+		if info.Version > latest {
+			return fmt.Errorf("%w: workspace is v%d, this build supports up to v%d", ErrSchemaTooNew, info.Version, latest)
+		}
+
+		var pending []Migration
+		for _, m := range sorted {
+			if m.Version > info.Version {
+				pending = append(pending, m)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if snapshots := repo.Snapshot(); snapshots != nil {
+			snap, err := snapshots.CreateAutoSnapshot(fmt.Sprintf("before schema migration v%d -> v%d", info.Version, latest))
+			if err != nil {
+				return fmt.Errorf("failed to create snapshot before migration: %w", err)
+			}
+			result.SnapshotID = snap.ID
+		}
+
+		for _, m := range pending {
+			if err := m.Apply(repo); err != nil {
+				return fmt.Errorf("migration v%d (%s) failed: %w", m.Version, m.Description, err)
+			}
+			now := time.Now()
+			applied := AppliedMigration{Version: m.Version, Description: m.Description, AppliedAt: now}
+			from := info.Version
+			info.Version = m.Version
+			info.UpdatedAt = now
+			info.Applied = append(info.Applied, applied)
+			if err := writeJSON(path, info); err != nil {
+				return fmt.Errorf("failed to write %s: %w", SchemaFileName, err)
+			}
+			result.ToVersion = m.Version
+			result.Applied = append(result.Applied, applied)
+
+			// 履歴への記録は監査用（リプレイには影響しない）ため best-effort
+			if action, err := NewAction(ActionSchemaMigrated, filepath.Base(dir), now, SchemaMigratedPayload{
+				FromVersion: from,
+				ToVersion:   m.Version,
+				Description: m.Description,
+			}); err == nil {
+				_ = repo.History().AppendAction(action)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate_AppliesPendingInOrder(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+
+	var applied []int
+	migrations := []Migration{
+		{Version: 2, Description: "second", Apply: func(WorkspaceRepository) error { applied = append(applied, 2); return nil }},
+		{Version: 1, Description: "first", Apply: func(WorkspaceRepository) error { applied = append(applied, 1); return nil }},
+	}
+
+	result, err := Migrate(repo, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, applied)
+	assert.Equal(t, 0, result.FromVersion)
+	assert.Equal(t, 2, result.ToVersion)
+	assert.Len(t, result.Applied, 2)
+	assert.NotEmpty(t, result.SnapshotID)
+
+	info, err := LoadSchemaInfo(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Version)
+	require.Len(t, info.Applied, 2)
+	assert.Equal(t, "first", info.Applied[0].Description)
+
+	actions, err := repo.History().ListActions(time.Time{}, time.Now())
+	require.NoError(t, err)
+	var migrated int
+	for _, a := range actions {
+		if a.Kind == ActionSchemaMigrated {
+			migrated++
+		}
+	}
+	assert.Equal(t, 2, migrated)
+
+	// 適用済みの版は再実行されない
+	result, err = Migrate(repo, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, applied)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, result.SnapshotID)
+}
+
+func TestMigrate_FailureKeepsVersion(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+
+	boom := errors.New("boom")
+	result, err := Migrate(repo, []Migration{
+		{Version: 1, Description: "ok", Apply: func(WorkspaceRepository) error { return nil }},
+		{Version: 2, Description: "broken", Apply: func(WorkspaceRepository) error { return boom }},
+	})
+	require.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, result.ToVersion)
+
+	info, err := LoadSchemaInfo(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, info.Version)
+}
+
+func TestMigrate_SchemaTooNew(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SchemaFileName), []byte(`{"version":5}`), 0644))
+
+	_, err := Migrate(repo, []Migration{
+		{Version: 1, Description: "first", Apply: func(WorkspaceRepository) error {
+			t.Fatal("migration must not run on a newer workspace")
+			return nil
+		}},
+	})
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
+
+func TestLoadSchemaInfo_MissingFileIsVersionZero(t *testing.T) {
+	info, err := LoadSchemaInfo(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, 0, info.Version)
+}
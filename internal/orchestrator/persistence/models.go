@@ -7,6 +7,7 @@ import (
 // --- Design Models ---
 
 type WBS struct {
+	Version     int64       `json:"version"` // 楽観的排他制御用（保存ごとに +1）
 	WBSID       string      `json:"wbs_id"`
 	ProjectRoot string      `json:"project_root"`
 	CreatedAt   time.Time   `json:"created_at"`
@@ -22,6 +23,7 @@ type NodeIndex struct {
 }
 
 type NodeDesign struct {
+	Version            int64         `json:"version"` // 楽観的排他制御用（保存ごとに +1）
 	NodeID             string        `json:"node_id"`
 	WBSID              string        `json:"wbs_id"`
 	Name               string        `json:"name"`
@@ -33,6 +35,8 @@ type NodeDesign struct {
 	Priority           string        `json:"priority"`
 	Estimate           Estimate      `json:"estimate"`
 	Dependencies       []string      `json:"dependencies"`
+	RequiresVerified   bool          `json:"requires_verified,omitempty"` // 依存先が verified になるまで実行しない（implemented では不十分）
+	Approvals          []string      `json:"approvals,omitempty"`         // 人の承認が必要なゲート（"start": 開始前 / "diff": implemented にする前）
 	AcceptanceCriteria []string      `json:"acceptance_criteria"`
 	DesignNotes        []string      `json:"design_notes"`
 	SuggestedImpl      SuggestedImpl `json:"suggested_impl"`
@@ -66,23 +70,38 @@ const (
 	NodeRuntimeStatusVerified    NodeRuntimeStatus = "verified"
 	NodeRuntimeStatusBlocked     NodeRuntimeStatus = "blocked"
 	NodeRuntimeStatusObsolete    NodeRuntimeStatus = "obsolete"
+	NodeRuntimeStatusSkipped     NodeRuntimeStatus = "skipped" // バックログでスキップされた（後続の依存は満たされる）
 )
 
 // IsCompleted は依存解決に使える完了状態かどうかを返す
 func (s NodeRuntimeStatus) IsCompleted() bool {
-	return s == NodeRuntimeStatusImplemented || s == NodeRuntimeStatusVerified
+	return s == NodeRuntimeStatusImplemented || s == NodeRuntimeStatusVerified || s == NodeRuntimeStatusSkipped
 }
 
 type NodesRuntime struct {
-	Nodes []NodeRuntime `json:"nodes"`
+	Version int64         `json:"version"` // 楽観的排他制御用（保存ごとに +1）
+	Nodes   []NodeRuntime `json:"nodes"`
 }
 
 type NodeRuntime struct {
 	NodeID         string             `json:"node_id"`
-	Status         string             `json:"status"` // planned, in_progress, implemented, verified, blocked, obsolete
+	Status         string             `json:"status"` // planned, in_progress, implemented, verified, blocked, obsolete, skipped
 	Implementation NodeImplementation `json:"implementation"`
 	Verification   NodeVerification   `json:"verification"`
 	Notes          []NodeNote         `json:"notes"`
+	Actuals        *NodeActuals       `json:"actuals,omitempty"` // 試行の実績（見積もりとの比較用、試行が無ければ nil）
+}
+
+// NodeActuals はノードのタスクの全試行を合計した実績
+type NodeActuals struct {
+	Attempts       int       `json:"attempts"`
+	WorkerRuns     int       `json:"worker_runs"`
+	WallClockSec   float64   `json:"wall_clock_sec"` // 試行の開始から終了までの合計
+	InputTokens    int       `json:"input_tokens"`
+	OutputTokens   int       `json:"output_tokens"`
+	CostUSD        float64   `json:"cost_usd"`
+	FirstStartedAt time.Time `json:"first_started_at"`
+	LastAttemptAt  time.Time `json:"last_attempt_at"`
 }
 
 type NodeImplementation struct {
@@ -91,10 +110,21 @@ type NodeImplementation struct {
 	LastModifiedBy string    `json:"last_modified_by"`
 }
 
+// NodeVerificationStatus はノードの検証結果（NodeVerification.Status）
+type NodeVerificationStatus string
+
+const (
+	NodeVerificationNotTested NodeVerificationStatus = "not_tested"
+	NodeVerificationPassed    NodeVerificationStatus = "passed"
+	NodeVerificationFailed    NodeVerificationStatus = "failed"
+	NodeVerificationFlaky     NodeVerificationStatus = "flaky" // 再実行で結果が変わった
+)
+
 type NodeVerification struct {
 	Status         string    `json:"status"` // not_tested, passed, failed, flaky
 	LastTestTaskID string    `json:"last_test_task_id"`
 	LastTestAt     time.Time `json:"last_test_at"`
+	StaleReason    string    `json:"stale_reason,omitempty"` // 依存先・共有ファイルの変更で再検証が必要な理由（空なら不要）
 }
 
 type NodeNote struct {
@@ -104,6 +134,7 @@ type NodeNote struct {
 }
 
 type TasksState struct {
+	Version   int64       `json:"version"` // 楽観的排他制御用（保存ごとに +1）
 	Tasks     []TaskState `json:"tasks"`
 	QueueMeta QueueMeta   `json:"queue_meta"`
 }
@@ -120,6 +151,8 @@ type TaskState struct {
 	Priority      int                    `json:"priority"`
 	Inputs        map[string]interface{} `json:"inputs"` // Flexible inputs (goal, constraints, etc.)
 	Outputs       TaskOutputs            `json:"outputs"`
+	StartedAt     *time.Time             `json:"started_at,omitempty"` // 最初の試行の開始時刻
+	DoneAt        *time.Time             `json:"done_at,omitempty"`    // 完了（成功・失敗・取り消し）時刻
 }
 
 type TaskOutputs struct {
@@ -135,7 +168,8 @@ type QueueMeta struct {
 }
 
 type AgentsState struct {
-	Agents []AgentState `json:"agents"`
+	Version int64        `json:"version"` // 楽観的排他制御用（保存ごとに +1）
+	Agents  []AgentState `json:"agents"`
 }
 
 type AgentState struct {
@@ -144,8 +178,21 @@ type AgentState struct {
 	MaxParallel  int      `json:"max_parallel"`
 	RunningTasks []string `json:"running_tasks"`
 	Capabilities []string `json:"capabilities"`
+
+	// ヘルスチェックの結果（未実施は空で、割り当ての対象になる）
+	Health          string     `json:"health,omitempty"` // healthy, unhealthy
+	HealthError     string     `json:"health_error,omitempty"`
+	HealthCheckedAt *time.Time `json:"health_checked_at,omitempty"`
 }
 
+// AgentHealth はエージェントのヘルスチェックの結果
+type AgentHealth string
+
+const (
+	AgentHealthHealthy   AgentHealth = "healthy"
+	AgentHealthUnhealthy AgentHealth = "unhealthy"
+)
+
 // --- History Models ---
 
 type Action struct {
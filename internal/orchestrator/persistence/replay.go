@@ -0,0 +1,237 @@
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReplayedState は history の状態遷移アクションから再構築した state
+type ReplayedState struct {
+	// At は再構築した時点（この時刻以前のアクションを適用）
+	At           time.Time     `json:"at"`
+	Tasks        *TasksState   `json:"tasks"`
+	NodesRuntime *NodesRuntime `json:"nodes_runtime"`
+	// Applied は適用した状態遷移アクションの数
+	Applied int `json:"applied"`
+	// Skipped は保存失敗（state_save_failed）で無効になったアクションの数
+	Skipped      int       `json:"skipped"`
+	LastActionID string    `json:"last_action_id,omitempty"`
+	LastActionAt time.Time `json:"last_action_at,omitempty"`
+}
+
+// Replay は時刻順のアクション列を適用して state を再構築する
+// state.* 以外のアクションは無視し、state_save_failed が指すアクションは適用しない。
+func Replay(actions []Action) (*ReplayedState, error) {
+	failed := make(map[string]bool)
+	for _, a := range actions {
+		if a.Kind != ActionStateSaveFailed {
+			continue
+		}
+		var p struct {
+			OriginalActionID  string   `json:"original_action_id"`
+			OriginalActionIDs []string `json:"original_action_ids"`
+		}
+		if err := a.DecodePayload(&p); err != nil {
+			return nil, err
+		}
+		if p.OriginalActionID != "" {
+			failed[p.OriginalActionID] = true
+		}
+		for _, id := range p.OriginalActionIDs {
+			failed[id] = true
+		}
+	}
+
+	rs := &ReplayedState{
+		Tasks:        &TasksState{Tasks: []TaskState{}},
+		NodesRuntime: &NodesRuntime{Nodes: []NodeRuntime{}},
+	}
+	for _, a := range actions {
+		if !a.IsStateTransition() {
+			continue
+		}
+		if failed[a.ID] {
+			rs.Skipped++
+			continue
+		}
+		if err := rs.apply(a); err != nil {
+			return nil, fmt.Errorf("failed to replay action %s: %w", a.ID, err)
+		}
+		rs.Applied++
+		rs.LastActionID = a.ID
+		rs.LastActionAt = a.At
+	}
+	return rs, nil
+}
+
+func (rs *ReplayedState) apply(a Action) error {
+	switch a.Kind {
+	case ActionStateBaseline:
+		var p BaselinePayload
+		if err := a.DecodePayload(&p); err != nil {
+			return err
+		}
+		if p.Tasks != nil {
+			rs.Tasks = p.Tasks
+		}
+		if p.NodesRuntime != nil {
+			rs.NodesRuntime = p.NodesRuntime
+		}
+	case ActionTaskCreated, ActionTaskUpdated, ActionTaskStatusChanged:
+		var p TaskActionPayload
+		if err := a.DecodePayload(&p); err != nil {
+			return err
+		}
+		rs.upsertTask(p.Task)
+		rs.Tasks.Version = p.Version
+	case ActionTaskRemoved:
+		var p TaskRemovedPayload
+		if err := a.DecodePayload(&p); err != nil {
+			return err
+		}
+		for i, t := range rs.Tasks.Tasks {
+			if t.TaskID == p.TaskID {
+				rs.Tasks.Tasks = append(rs.Tasks.Tasks[:i], rs.Tasks.Tasks[i+1:]...)
+				break
+			}
+		}
+		rs.Tasks.Version = p.Version
+	case ActionQueueMetaUpdated:
+		var p QueueMetaPayload
+		if err := a.DecodePayload(&p); err != nil {
+			return err
+		}
+		rs.Tasks.QueueMeta = p.QueueMeta
+		rs.Tasks.Version = p.Version
+	case ActionNodeCreated, ActionNodeUpdated, ActionNodeStatusChanged:
+		var p NodeActionPayload
+		if err := a.DecodePayload(&p); err != nil {
+			return err
+		}
+		rs.upsertNode(p.Node)
+		rs.NodesRuntime.Version = p.Version
+	case ActionNodeRemoved:
+		var p NodeRemovedPayload
+		if err := a.DecodePayload(&p); err != nil {
+			return err
+		}
+		for i, n := range rs.NodesRuntime.Nodes {
+			if n.NodeID == p.NodeID {
+				rs.NodesRuntime.Nodes = append(rs.NodesRuntime.Nodes[:i], rs.NodesRuntime.Nodes[i+1:]...)
+				break
+			}
+		}
+		rs.NodesRuntime.Version = p.Version
+	default:
+		// 未知の state.* アクション（新しいバージョンで追加されたもの）は無視する
+	}
+	return nil
+}
+
+func (rs *ReplayedState) upsertTask(task TaskState) {
+	for i := range rs.Tasks.Tasks {
+		if rs.Tasks.Tasks[i].TaskID == task.TaskID {
+			rs.Tasks.Tasks[i] = task
+			return
+		}
+	}
+	rs.Tasks.Tasks = append(rs.Tasks.Tasks, task)
+}
+
+func (rs *ReplayedState) upsertNode(node NodeRuntime) {
+	for i := range rs.NodesRuntime.Nodes {
+		if rs.NodesRuntime.Nodes[i].NodeID == node.NodeID {
+			rs.NodesRuntime.Nodes[i] = node
+			return
+		}
+	}
+	rs.NodesRuntime.Nodes = append(rs.NodesRuntime.Nodes, node)
+}
+
+// StateAt は指定時刻時点の state を history から再構築する（タイムトラベル）
+func StateAt(history HistoryRepository, at time.Time) (*ReplayedState, error) {
+	actions, err := history.ListActions(time.Time{}, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list actions: %w", err)
+	}
+	rs, err := Replay(actions)
+	if err != nil {
+		return nil, err
+	}
+	rs.At = at
+	return rs, nil
+}
+
+// Drift は再構築した state と実際の state の差分を返す（監査用、一致していれば空）
+// 各要素は "<アクション種別> <ID>" の形式で、再構築した state を実際の state にするために必要な遷移を表す。
+func (rs *ReplayedState) Drift(tasks *TasksState, nodes *NodesRuntime) ([]string, error) {
+	var drift []string
+	taskActions, err := diffTasks("", time.Time{}, rs.Tasks, tasks, tasks.Version)
+	if err != nil {
+		return nil, err
+	}
+	nodeActions, err := diffNodesRuntime("", time.Time{}, rs.NodesRuntime, nodes, nodes.Version)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range append(taskActions, nodeActions...) {
+		id, _ := a.Payload["task_id"].(string)
+		if a.Kind == ActionQueueMetaUpdated {
+			id = "queue_meta"
+		} else if nodeID, ok := a.Payload["node_id"].(string); ok {
+			id = nodeID
+		}
+		drift = append(drift, a.Kind+" "+id)
+	}
+	return drift, nil
+}
+
+// RebuildState は history を全て再生し、tasks.json / nodes-runtime.json を再構築した内容で置き換える
+// state ファイルが壊れた・ずれた場合の復旧に使う。置き換えによる差分も状態遷移として記録される。
+func RebuildState(repo WorkspaceRepository) (*ReplayedState, error) {
+	rs, err := StateAt(repo.History(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// 置き換え前の state を残す（best-effort）
+	if snap := repo.Snapshot(); snap != nil {
+		_, _ = snap.CreateAutoSnapshot("before rebuilding state from history")
+	}
+	// 読めない state ファイルは退避してから作り直す
+	stateDir := filepath.Join(repo.BaseDir(), "state")
+	if _, err := repo.State().LoadTasks(); err != nil {
+		if err := quarantine(filepath.Join(stateDir, "tasks.json")); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := repo.State().LoadNodesRuntime(); err != nil {
+		if err := quarantine(filepath.Join(stateDir, "nodes-runtime.json")); err != nil {
+			return nil, err
+		}
+	}
+	if err := repo.State().UpdateTasks(func(state *TasksState) error {
+		state.Tasks = rs.Tasks.Tasks
+		state.QueueMeta = rs.Tasks.QueueMeta
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to rebuild tasks state: %w", err)
+	}
+	if err := repo.State().UpdateNodesRuntime(func(state *NodesRuntime) error {
+		state.Nodes = rs.NodesRuntime.Nodes
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to rebuild nodes runtime: %w", err)
+	}
+	return rs, nil
+}
+
+// quarantine は壊れたファイルを <name>.corrupt-<timestamp> に退避する
+func quarantine(path string) error {
+	dest := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(path, dest); err != nil {
+		return fmt.Errorf("failed to move aside corrupt %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package persistence
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setTaskStatus(t *testing.T, repo WorkspaceRepository, taskID, status string) {
+	t.Helper()
+	require.NoError(t, repo.State().UpdateTasks(func(s *TasksState) error {
+		for i := range s.Tasks {
+			if s.Tasks[i].TaskID == taskID {
+				s.Tasks[i].Status = status
+				return nil
+			}
+		}
+		s.Tasks = append(s.Tasks, TaskState{TaskID: taskID, Status: status})
+		return nil
+	}))
+}
+
+func TestReplay_RebuildsStateFromHistory(t *testing.T) {
+	repo := NewWorkspaceRepository(t.TempDir())
+	require.NoError(t, repo.Init())
+
+	setTaskStatus(t, repo, "t1", "pending")
+	setTaskStatus(t, repo, "t2", "pending")
+	require.NoError(t, repo.State().UpdateNodesRuntime(func(s *NodesRuntime) error {
+		s.Nodes = append(s.Nodes, NodeRuntime{NodeID: "n1", Status: string(NodeRuntimeStatusPlanned)})
+		return nil
+	}))
+	time.Sleep(5 * time.Millisecond)
+	checkpoint := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	setTaskStatus(t, repo, "t1", "running")
+	setTaskStatus(t, repo, "t1", "succeeded")
+	require.NoError(t, repo.State().UpdateTasks(func(s *TasksState) error {
+		s.Tasks = s.Tasks[:1] // t2 を削除
+		s.QueueMeta.NextTaskIDSeq = 3
+		return nil
+	}))
+	require.NoError(t, repo.State().UpdateNodesRuntime(func(s *NodesRuntime) error {
+		s.Nodes[0].Status = string(NodeRuntimeStatusImplemented)
+		return nil
+	}))
+
+	actions, err := repo.History().ListActions(time.Time{}, time.Now())
+	require.NoError(t, err)
+	var statusChanges []string
+	for _, a := range actions {
+		if a.Kind == ActionTaskStatusChanged {
+			var p TaskActionPayload
+			require.NoError(t, a.DecodePayload(&p))
+			statusChanges = append(statusChanges, p.FromStatus+"->"+p.ToStatus)
+		}
+	}
+	assert.Equal(t, []string{"pending->running", "running->succeeded"}, statusChanges)
+
+	// 現在の state と一致する
+	rs, err := StateAt(repo.History(), time.Now())
+	require.NoError(t, err)
+	tasks, _ := repo.State().LoadTasks()
+	nodes, _ := repo.State().LoadNodesRuntime()
+	assert.Equal(t, tasks.Version, rs.Tasks.Version)
+	assert.Equal(t, nodes.Version, rs.NodesRuntime.Version)
+	drift, err := rs.Drift(tasks, nodes)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	// タイムトラベル: チェックポイント時点では両タスクとも pending
+	past, err := StateAt(repo.History(), checkpoint)
+	require.NoError(t, err)
+	require.Len(t, past.Tasks.Tasks, 2)
+	assert.Equal(t, "pending", past.Tasks.Tasks[0].Status)
+	assert.Equal(t, string(NodeRuntimeStatusPlanned), past.NodesRuntime.Nodes[0].Status)
+}
+
+func TestReplay_SkipsFailedSaves(t *testing.T) {
+	task := TaskState{TaskID: "t1", Status: "pending"}
+	created, err := NewAction(ActionTaskCreated, "ws", time.Now(), TaskActionPayload{Version: 1, TaskID: "t1", Task: task})
+	require.NoError(t, err)
+	task.Status = "running"
+	changed, err := NewAction(ActionTaskStatusChanged, "ws", time.Now(), TaskActionPayload{Version: 2, TaskID: "t1", Task: task})
+	require.NoError(t, err)
+	failed, err := NewAction(ActionStateSaveFailed, "ws", time.Now(), StateSaveFailedPayload{
+		OriginalActionIDs: []string{changed.ID},
+		Stage:             "save_tasks_state",
+		Error:             "disk full",
+	})
+	require.NoError(t, err)
+	note, err := NewAction(ActionTaskStarted, "ws", time.Now(), map[string]string{"task_id": "t1"})
+	require.NoError(t, err)
+
+	rs, err := Replay([]Action{*created, *changed, *failed, *note})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rs.Applied)
+	assert.Equal(t, 1, rs.Skipped)
+	require.Len(t, rs.Tasks.Tasks, 1)
+	assert.Equal(t, "pending", rs.Tasks.Tasks[0].Status)
+	assert.Equal(t, int64(1), rs.Tasks.Version)
+}
+
+func TestRebuildState_RecoversCorruptState(t *testing.T) {
+	dir := t.TempDir()
+	// 履歴記録の導入前からある state は baseline として取り込まれる
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "state"), 0755))
+	require.NoError(t, writeJSON(filepath.Join(dir, "state", "tasks.json"), &TasksState{
+		Version: 3,
+		Tasks:   []TaskState{{TaskID: "legacy", Status: "succeeded"}},
+	}))
+	repo := NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+	setTaskStatus(t, repo, "t1", "pending")
+
+	tasksPath := filepath.Join(dir, "state", "tasks.json")
+	require.NoError(t, os.WriteFile(tasksPath, []byte("{broken"), 0644))
+	_, err := repo.State().LoadTasks()
+	require.Error(t, err)
+
+	rs, err := RebuildState(repo)
+	require.NoError(t, err)
+	assert.Len(t, rs.Tasks.Tasks, 2)
+
+	tasks, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	require.Len(t, tasks.Tasks, 2)
+	assert.Equal(t, "legacy", tasks.Tasks[0].TaskID)
+	assert.Equal(t, "t1", tasks.Tasks[1].TaskID)
+
+	corrupt, _ := filepath.Glob(tasksPath + ".corrupt-*")
+	assert.Len(t, corrupt, 1)
+}
+
+func TestStateRepository_HistoryAppendedBeforeSave(t *testing.T) {
+	repo := NewWorkspaceRepository(t.TempDir())
+	require.NoError(t, repo.Init())
+
+	// fn のエラーや変更なしでは何も記録しない
+	require.NoError(t, repo.State().UpdateTasks(func(*TasksState) error { return ErrNoChange }))
+	require.Error(t, repo.State().UpdateTasks(func(*TasksState) error { return errors.New("boom") }))
+	actions, err := repo.History().ListActions(time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+
+	// 保存に失敗した遷移は state_save_failed で無効化される
+	stale := &TasksState{Tasks: []TaskState{{TaskID: "t1", Status: "pending"}}}
+	require.NoError(t, repo.State().SaveTasks(&TasksState{Tasks: []TaskState{{TaskID: "t0"}}}))
+	require.ErrorIs(t, repo.State().SaveTasks(stale), ErrVersionConflict)
+
+	actions, err = repo.History().ListActions(time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.NotEmpty(t, actions)
+	assert.Equal(t, ActionStateSaveFailed, actions[len(actions)-1].Kind)
+
+	rs, err := Replay(actions)
+	require.NoError(t, err)
+	require.Len(t, rs.Tasks.Tasks, 1)
+	assert.Equal(t, "t0", rs.Tasks.Tasks[0].TaskID)
+}
@@ -12,20 +12,33 @@ import (
 
 // --- Interfaces ---
 
+// Save* は compare-and-swap で書き込む: 読み込んだ時点の Version がディスク上の版と
+// 一致しない場合は ErrVersionConflict を返し、成功時は Version を 1 進める。
+// Update* は advisory lock を保持したまま load → fn → save を行い、版衝突時は再試行する。
+// fn から ErrNoChange を返すと保存をスキップする。
+
 type DesignRepository interface {
 	LoadWBS() (*WBS, error)
 	SaveWBS(wbs *WBS) error
+	// UpdateWBS は wbs.json が存在しない場合、空の WBS を fn に渡す
+	UpdateWBS(fn func(wbs *WBS) error) error
 	GetNode(nodeID string) (*NodeDesign, error)
 	SaveNode(node *NodeDesign) error
+	UpdateNode(nodeID string, fn func(node *NodeDesign) error) error
+	// DeleteNode はノードの設計を削除する（存在しなければ何もしない）
+	DeleteNode(nodeID string) error
 }
 
 type StateRepository interface {
 	LoadNodesRuntime() (*NodesRuntime, error)
 	SaveNodesRuntime(state *NodesRuntime) error
+	UpdateNodesRuntime(fn func(state *NodesRuntime) error) error
 	LoadTasks() (*TasksState, error)
 	SaveTasks(state *TasksState) error
+	UpdateTasks(fn func(state *TasksState) error) error
 	LoadAgents() (*AgentsState, error)
 	SaveAgents(state *AgentsState) error
+	UpdateAgents(fn func(state *AgentsState) error) error
 }
 
 type HistoryRepository interface {
@@ -39,7 +52,10 @@ type WorkspaceRepository interface {
 	State() StateRepository
 	History() HistoryRepository
 	Snapshot() SnapshotRepository
+	Attempts() AttemptRepository
 	BaseDir() string
+	// Clock は history・試行ログに記録する時刻の取得元
+	Clock() Clock
 }
 
 // --- Implementations ---
@@ -50,19 +66,38 @@ type workspaceRepoImpl struct {
 	state    *stateRepoImpl
 	history  *historyRepoImpl
 	snapshot *snapshotRepoImpl
+	attempts *attemptRepoImpl
+	clock    Clock
 }
 
 func NewWorkspaceRepository(baseDir string) WorkspaceRepository {
-	return &workspaceRepoImpl{
+	return NewWorkspaceRepositoryWithClock(baseDir, SystemClock)
+}
+
+// NewWorkspaceRepositoryWithClock は history・試行ログの時刻を clock から取るリポジトリを作成する
+// スナップショット ID などの識別子とリーダーロックのハートビートは実時間のまま。
+func NewWorkspaceRepositoryWithClock(baseDir string, clock Clock) WorkspaceRepository {
+	if clock == nil {
+		clock = SystemClock
+	}
+	history := &historyRepoImpl{baseDir: filepath.Join(baseDir, "history")}
+	repo := &workspaceRepoImpl{
 		baseDir: baseDir,
 		design:  &designRepoImpl{baseDir: filepath.Join(baseDir, "design")},
-		state:   &stateRepoImpl{baseDir: filepath.Join(baseDir, "state")},
-		history: &historyRepoImpl{baseDir: filepath.Join(baseDir, "history")},
-		snapshot: &snapshotRepoImpl{
-			baseDir:  filepath.Join(baseDir, "snapshots"),
-			stateDir: filepath.Join(baseDir, "state"),
+		state: &stateRepoImpl{
+			baseDir:     filepath.Join(baseDir, "state"),
+			workspaceID: filepath.Base(baseDir),
+			history:     history,
+			clock:       clock,
 		},
+		history:  history,
+		snapshot: newWorkspaceSnapshotRepository(baseDir),
+		attempts: &attemptRepoImpl{baseDir: filepath.Join(baseDir, AttemptsDirName), clock: clock},
+		clock:    clock,
 	}
+	// リストアは差分ではなく state の置き換えなので、リストア後の state を新しい起点として記録する
+	repo.snapshot.afterRestore = func() error { return repo.appendBaseline(true) }
+	return repo
 }
 
 func (r *workspaceRepoImpl) Init() error {
@@ -72,20 +107,53 @@ func (r *workspaceRepoImpl) Init() error {
 		r.state.baseDir,
 		r.history.baseDir,
 		filepath.Join(r.baseDir, "snapshots"),
+		r.attempts.baseDir,
 	}
 	for _, d := range dirs {
 		if err := os.MkdirAll(d, 0755); err != nil {
 			return err
 		}
 	}
-	return nil
+	return r.recordBaseline()
+}
+
+// recordBaseline は履歴が空で既存の state がある場合（履歴記録の導入前のワークスペース）、
+// 現在の state 全体を起点として記録する。以降の変更は差分として記録されるため、リプレイで再現できる。
+func (r *workspaceRepoImpl) recordBaseline() error {
+	if files, _ := filepath.Glob(filepath.Join(r.history.baseDir, "actions-*.jsonl")); len(files) > 0 {
+		return nil
+	}
+	return r.appendBaseline(false)
+}
+
+// appendBaseline は現在の state 全体を ActionStateBaseline として追記する
+// force が false の場合、state が空なら何もしない。
+func (r *workspaceRepoImpl) appendBaseline(force bool) error {
+	tasks, err := r.state.LoadTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks for baseline: %w", err)
+	}
+	nodes, err := r.state.LoadNodesRuntime()
+	if err != nil {
+		return fmt.Errorf("failed to load nodes runtime for baseline: %w", err)
+	}
+	if !force && len(tasks.Tasks) == 0 && len(nodes.Nodes) == 0 {
+		return nil
+	}
+	action, err := NewAction(ActionStateBaseline, r.state.workspaceID, r.clock.Now(), BaselinePayload{Tasks: tasks, NodesRuntime: nodes})
+	if err != nil {
+		return err
+	}
+	return r.history.AppendAction(action)
 }
 
 func (r *workspaceRepoImpl) Design() DesignRepository     { return r.design }
 func (r *workspaceRepoImpl) State() StateRepository       { return r.state }
 func (r *workspaceRepoImpl) History() HistoryRepository   { return r.history }
 func (r *workspaceRepoImpl) Snapshot() SnapshotRepository { return r.snapshot }
+func (r *workspaceRepoImpl) Attempts() AttemptRepository  { return r.attempts }
 func (r *workspaceRepoImpl) BaseDir() string              { return r.baseDir }
+func (r *workspaceRepoImpl) Clock() Clock                 { return r.clock }
 
 // --- Design Repo ---
 
@@ -104,7 +172,19 @@ func (r *designRepoImpl) LoadWBS() (*WBS, error) {
 
 func (r *designRepoImpl) SaveWBS(wbs *WBS) error {
 	path := filepath.Join(r.baseDir, "wbs.json")
-	return writeJSON(path, wbs)
+	return saveVersioned(path, wbs, &wbs.Version)
+}
+
+func (r *designRepoImpl) UpdateWBS(fn func(wbs *WBS) error) error {
+	path := filepath.Join(r.baseDir, "wbs.json")
+	load := func() (*WBS, error) {
+		wbs, err := r.LoadWBS()
+		if os.IsNotExist(err) {
+			return &WBS{NodeIndex: []NodeIndex{}}, nil
+		}
+		return wbs, err
+	}
+	return updateVersioned(path, load, func(w *WBS) *int64 { return &w.Version }, fn, nil)
 }
 
 func (r *designRepoImpl) GetNode(nodeID string) (*NodeDesign, error) {
@@ -118,13 +198,34 @@ func (r *designRepoImpl) GetNode(nodeID string) (*NodeDesign, error) {
 
 func (r *designRepoImpl) SaveNode(node *NodeDesign) error {
 	path := filepath.Join(r.baseDir, "nodes", node.NodeID+".json")
-	return writeJSON(path, node)
+	return saveVersioned(path, node, &node.Version)
+}
+
+func (r *designRepoImpl) UpdateNode(nodeID string, fn func(node *NodeDesign) error) error {
+	path := filepath.Join(r.baseDir, "nodes", nodeID+".json")
+	load := func() (*NodeDesign, error) { return r.GetNode(nodeID) }
+	return updateVersioned(path, load, func(n *NodeDesign) *int64 { return &n.Version }, fn, nil)
+}
+
+func (r *designRepoImpl) DeleteNode(nodeID string) error {
+	path := filepath.Join(r.baseDir, "nodes", nodeID+".json")
+	return withFileLock(path, func() error {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
 }
 
 // --- State Repo ---
 
+// stateRepoImpl は tasks.json / nodes-runtime.json の変更を状態遷移アクションとして history に記録する
+// （agents.json は実行中の割り当てのみで、リプレイの対象外）
 type stateRepoImpl struct {
-	baseDir string
+	baseDir     string
+	workspaceID string
+	history     *historyRepoImpl
+	clock       Clock
 }
 
 func (r *stateRepoImpl) LoadNodesRuntime() (*NodesRuntime, error) {
@@ -141,7 +242,12 @@ func (r *stateRepoImpl) LoadNodesRuntime() (*NodesRuntime, error) {
 
 func (r *stateRepoImpl) SaveNodesRuntime(state *NodesRuntime) error {
 	path := filepath.Join(r.baseDir, "nodes-runtime.json")
-	return writeJSON(path, state)
+	return saveVersionedRecorded(path, r.LoadNodesRuntime, state, &state.Version, r.recordNodesRuntime)
+}
+
+func (r *stateRepoImpl) UpdateNodesRuntime(fn func(state *NodesRuntime) error) error {
+	path := filepath.Join(r.baseDir, "nodes-runtime.json")
+	return updateVersioned(path, r.LoadNodesRuntime, func(s *NodesRuntime) *int64 { return &s.Version }, fn, r.recordNodesRuntime)
 }
 
 func (r *stateRepoImpl) LoadTasks() (*TasksState, error) {
@@ -158,7 +264,12 @@ func (r *stateRepoImpl) LoadTasks() (*TasksState, error) {
 
 func (r *stateRepoImpl) SaveTasks(state *TasksState) error {
 	path := filepath.Join(r.baseDir, "tasks.json")
-	return writeJSON(path, state)
+	return saveVersionedRecorded(path, r.LoadTasks, state, &state.Version, r.recordTasks)
+}
+
+func (r *stateRepoImpl) UpdateTasks(fn func(state *TasksState) error) error {
+	path := filepath.Join(r.baseDir, "tasks.json")
+	return updateVersioned(path, r.LoadTasks, func(s *TasksState) *int64 { return &s.Version }, fn, r.recordTasks)
 }
 
 func (r *stateRepoImpl) LoadAgents() (*AgentsState, error) {
@@ -175,7 +286,62 @@ func (r *stateRepoImpl) LoadAgents() (*AgentsState, error) {
 
 func (r *stateRepoImpl) SaveAgents(state *AgentsState) error {
 	path := filepath.Join(r.baseDir, "agents.json")
-	return writeJSON(path, state)
+	return saveVersioned(path, state, &state.Version)
+}
+
+func (r *stateRepoImpl) UpdateAgents(fn func(state *AgentsState) error) error {
+	path := filepath.Join(r.baseDir, "agents.json")
+	return updateVersioned(path, r.LoadAgents, func(s *AgentsState) *int64 { return &s.Version }, fn, nil)
+}
+
+func (r *stateRepoImpl) recordTasks(before, after *TasksState) (func(error), error) {
+	actions, err := diffTasks(r.workspaceID, r.clock.Now(), before, after, after.Version+1)
+	if err != nil {
+		return nil, err
+	}
+	return r.appendTransitions(actions, "save_tasks_state")
+}
+
+func (r *stateRepoImpl) recordNodesRuntime(before, after *NodesRuntime) (func(error), error) {
+	actions, err := diffNodesRuntime(r.workspaceID, r.clock.Now(), before, after, after.Version+1)
+	if err != nil {
+		return nil, err
+	}
+	return r.appendTransitions(actions, "save_nodes_runtime")
+}
+
+// appendTransitions は状態遷移アクションを追記し、保存失敗時に state_save_failed を記録する commit を返す
+// 追記に失敗した場合は state を保存させない（履歴を正とするため）。
+func (r *stateRepoImpl) appendTransitions(actions []*Action, stage string) (func(error), error) {
+	if r.history == nil || len(actions) == 0 {
+		return nil, nil
+	}
+	var appended []string
+	markFailed := func(stage string, cause error) {
+		if len(appended) == 0 {
+			return
+		}
+		fail, err := NewAction(ActionStateSaveFailed, r.workspaceID, r.clock.Now(), StateSaveFailedPayload{
+			OriginalActionIDs: appended,
+			Stage:             stage,
+			Error:             cause.Error(),
+		})
+		if err == nil {
+			_ = r.history.AppendAction(fail)
+		}
+	}
+	for _, a := range actions {
+		if err := r.history.AppendAction(a); err != nil {
+			markFailed("append_history", err)
+			return nil, fmt.Errorf("failed to append history: %w", err)
+		}
+		appended = append(appended, a.ID)
+	}
+	return func(saveErr error) {
+		if saveErr != nil {
+			markFailed(stage, saveErr)
+		}
+	}, nil
 }
 
 // --- History Repo ---
@@ -193,19 +359,18 @@ func (r *historyRepoImpl) AppendAction(action *Action) error {
 		return err
 	}
 
+	if err := os.MkdirAll(r.baseDir, 0755); err != nil {
+		return err
+	}
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = f.Close() }()
 
-	if _, err := f.Write(bytes); err != nil {
-		return err
-	}
-	if _, err := f.WriteString("\n"); err != nil {
-		return err
-	}
-	return nil
+	// 1 回の write で追記し、複数プロセスから同時に追記しても行が混ざらないようにする
+	_, err = f.Write(append(bytes, '\n'))
+	return err
 }
 
 func (r *historyRepoImpl) ListActions(from, to time.Time) ([]Action, error) {
@@ -246,8 +411,8 @@ func (r *historyRepoImpl) ListActions(from, to time.Time) ([]Action, error) {
 		_ = f.Close()
 	}
 
-	// Sort by time
-	sort.Slice(actions, func(i, j int) bool {
+	// Sort by time (同時刻のアクションは追記順を保つ)
+	sort.SliceStable(actions, func(i, j int) bool {
 		return actions[i].At.Before(actions[j].At)
 	})
 
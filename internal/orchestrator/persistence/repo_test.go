@@ -104,11 +104,21 @@ func TestWorkspaceRepository(t *testing.T) {
 		if err != nil {
 			t.Fatalf("ListActions failed: %v", err)
 		}
-		if len(actions) != 1 {
-			t.Fatalf("Expected 1 action, got %d", len(actions))
+		// StateRepository の SaveTasks による状態遷移アクションも記録されている
+		var recorded []Action
+		for _, a := range actions {
+			if !a.IsStateTransition() {
+				recorded = append(recorded, a)
+			}
 		}
-		if actions[0].ID != "act-1" {
-			t.Errorf("Expected action ID act-1, got %s", actions[0].ID)
+		if len(recorded) != 1 {
+			t.Fatalf("Expected 1 action, got %d", len(recorded))
+		}
+		if recorded[0].ID != "act-1" {
+			t.Errorf("Expected action ID act-1, got %s", recorded[0].ID)
+		}
+		if actions[0].Kind != ActionTaskCreated {
+			t.Errorf("Expected first action %s, got %s", ActionTaskCreated, actions[0].Kind)
 		}
 	})
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,37 +16,127 @@ type Snapshot struct {
 	ID          string    `json:"id"`
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
+	// Auto は計画変更などの一括操作の前に自動で取得したスナップショット（保持ポリシーの対象）
+	Auto bool `json:"auto,omitempty"`
+	// Sources はスナップショットに含まれるディレクトリ（state, design, tasks, backlog）
+	// 空の場合は state のみ（旧形式）。
+	Sources []string `json:"sources,omitempty"`
 }
 
 type SnapshotRepository interface {
 	CreateSnapshot(description string) (*Snapshot, error)
+	// CreateAutoSnapshot は自動スナップショットを取得し、保持ポリシーに従って古いものを削除する
+	CreateAutoSnapshot(reason string) (*Snapshot, error)
 	RestoreSnapshot(snapshotID string) error
 	ListSnapshots() ([]Snapshot, error)
+	// DiffSnapshots は 2 つのスナップショット間の差分を返す（ID に CurrentSnapshotID を指定すると現在の状態）
+	DiffSnapshots(fromID, toID string) (*SnapshotDiff, error)
+	// PruneSnapshots は保持ポリシーに従ってスナップショットを削除し、削除した ID を返す
+	PruneSnapshots(policy RetentionPolicy) ([]string, error)
+	SetRetentionPolicy(policy RetentionPolicy)
 }
 
+// CurrentSnapshotID は DiffSnapshots で現在のワークスペースの状態を表す ID
+const CurrentSnapshotID = "current"
+
+// backupPrefix はリストア前に自動取得する退避用スナップショットの ID 接頭辞
+const backupPrefix = "backup-pre-restore-"
+
+// RetentionPolicy はスナップショットの保持ポリシー
+// MaxCount / MaxAge が 0 の場合はその条件で削除しない。
+type RetentionPolicy struct {
+	MaxCount int           `json:"max_count"`
+	MaxAge   time.Duration `json:"max_age"`
+	// IncludeManual が false の場合、手動スナップショットは削除対象にしない
+	IncludeManual bool `json:"include_manual"`
+}
+
+// DefaultRetentionPolicy は自動スナップショットとリストア前の退避を 20 件・30 日まで保持する
+var DefaultRetentionPolicy = RetentionPolicy{MaxCount: 20, MaxAge: 30 * 24 * time.Hour}
+
 type snapshotRepoImpl struct {
-	baseDir  string // "snapshots" dir
-	stateDir string // "state" dir to backup/restore
+	baseDir   string            // "snapshots" dir
+	sources   map[string]string // スナップショット対象（名前 → ディレクトリ）
+	retention RetentionPolicy
+	// afterRestore はリストア後に呼ばれる（history への baseline 記録など）
+	afterRestore func() error
 }
 
+// NewSnapshotRepository は state ディレクトリのみを対象とする SnapshotRepository を生成する
 func NewSnapshotRepository(baseDir, stateDir string) SnapshotRepository {
 	return &snapshotRepoImpl{
-		baseDir:  baseDir,
-		stateDir: stateDir,
+		baseDir:   baseDir,
+		sources:   map[string]string{"state": stateDir},
+		retention: DefaultRetentionPolicy,
 	}
 }
 
+// newWorkspaceSnapshotRepository はワークスペースの design / state / tasks（旧 TaskStore）/ backlog を対象とする
+func newWorkspaceSnapshotRepository(workspaceDir string) *snapshotRepoImpl {
+	sources := make(map[string]string)
+	for _, name := range []string{"state", "design", "tasks", "backlog"} {
+		sources[name] = filepath.Join(workspaceDir, name)
+	}
+	return &snapshotRepoImpl{
+		baseDir:   filepath.Join(workspaceDir, "snapshots"),
+		sources:   sources,
+		retention: DefaultRetentionPolicy,
+	}
+}
+
+func (r *snapshotRepoImpl) SetRetentionPolicy(policy RetentionPolicy) {
+	r.retention = policy
+}
+
 func (r *snapshotRepoImpl) CreateSnapshot(description string) (*Snapshot, error) {
 	id := fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), uuid.New().String()[:8])
-	snapDir := filepath.Join(r.baseDir, id)
+	return r.create(id, description, false)
+}
+
+func (r *snapshotRepoImpl) CreateAutoSnapshot(reason string) (*Snapshot, error) {
+	id := fmt.Sprintf("%s-auto-%s", time.Now().Format("20060102-150405"), uuid.New().String()[:8])
+	snap, err := r.create(id, reason, true)
+	if err != nil {
+		return nil, err
+	}
+	// 保持ポリシーの適用は best-effort（失敗してもスナップショット自体は有効）
+	_, _ = r.PruneSnapshots(r.retention)
+	return snap, nil
+}
+
+// create は対象ディレクトリのドキュメントのロックを取得してからコピーする
+func (r *snapshotRepoImpl) create(id, description string, auto bool) (*Snapshot, error) {
+	var dirs []string
+	for _, name := range r.sourceNames() {
+		dirs = append(dirs, r.sources[name])
+	}
+	unlock, err := lockDocuments(documentPaths(dirs...))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return r.createLocked(id, description, auto)
+}
 
+// createLocked は対象ディレクトリをコピーし、メタデータを書き出す（呼び出し側でロックを保持すること）
+func (r *snapshotRepoImpl) createLocked(id, description string, auto bool) (*Snapshot, error) {
+	snapDir := filepath.Join(r.baseDir, id)
 	if err := os.MkdirAll(snapDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create snapshot dir: %w", err)
 	}
 
-	// 1. Copy state directory
-	if err := copyDir(r.stateDir, filepath.Join(snapDir, "state")); err != nil {
-		return nil, fmt.Errorf("failed to copy state: %w", err)
+	// 1. Copy source directories (存在しないものはスキップ)
+	var sources []string
+	for _, name := range r.sourceNames() {
+		src := r.sources[name]
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		if err := copyDir(src, filepath.Join(snapDir, name)); err != nil {
+			_ = os.RemoveAll(snapDir)
+			return nil, fmt.Errorf("failed to copy %s: %w", name, err)
+		}
+		sources = append(sources, name)
 	}
 
 	// 2. Save metadata
@@ -53,6 +144,8 @@ func (r *snapshotRepoImpl) CreateSnapshot(description string) (*Snapshot, error)
 		ID:          id,
 		Description: description,
 		CreatedAt:   time.Now(),
+		Auto:        auto,
+		Sources:     sources,
 	}
 	metaPath := filepath.Join(snapDir, "snapshot.json")
 	if err := writeJSON(metaPath, snap); err != nil {
@@ -62,35 +155,186 @@ func (r *snapshotRepoImpl) CreateSnapshot(description string) (*Snapshot, error)
 	return snap, nil
 }
 
+func (r *snapshotRepoImpl) sourceNames() []string {
+	names := make([]string, 0, len(r.sources))
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RestoreSnapshot はスナップショットに含まれるディレクトリを置き換える
+// 旧形式（state のみ）のスナップショットでは design などは現在のまま残る。
+// リストア前の状態は backup-pre-restore-* として退避し、その ID でリストアし直せる。
+// 置き換える・削除するドキュメントのロックをすべて取得してから退避・リストアするため、CAS の書き込み手と交互に実行されない。
+// ロックファイル（*.lock）は他のプロセスが保持している可能性があるため、削除もコピーもしない。
 func (r *snapshotRepoImpl) RestoreSnapshot(snapshotID string) error {
 	snapDir := filepath.Join(r.baseDir, snapshotID)
 	if _, err := os.Stat(snapDir); os.IsNotExist(err) {
 		return fmt.Errorf("snapshot not found: %s", snapshotID)
 	}
+	sources := r.snapshotSources(snapDir)
 
-	// 1. Safety Backup
-	backupID := fmt.Sprintf("backup-pre-restore-%s", time.Now().Format("20060102-150405"))
-	backupDir := filepath.Join(r.baseDir, backupID)
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create safety backup dir: %w", err)
+	if err := r.restoreLocked(snapshotID, snapDir, sources); err != nil {
+		return err
+	}
+	if r.afterRestore != nil {
+		return r.afterRestore()
 	}
-	if err := copyDir(r.stateDir, filepath.Join(backupDir, "state")); err != nil {
+	return nil
+}
+
+// restoreLocked はロックを取得して退避とリストアを行う
+func (r *snapshotRepoImpl) restoreLocked(snapshotID, snapDir string, sources []string) error {
+	// 退避は全対象ディレクトリ、リストアは sources を書き換えるため、両方のドキュメントをロックする
+	var paths []string
+	for _, name := range r.sourceNames() {
+		paths = append(paths, documentPaths(r.sources[name])...)
+	}
+	for _, name := range sources {
+		dst, ok := r.sources[name]
+		if !ok {
+			continue
+		}
+		for _, rel := range relativeDocuments(filepath.Join(snapDir, name)) {
+			paths = append(paths, filepath.Join(dst, rel))
+		}
+	}
+	unlock, err := lockDocuments(paths)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// 1. Safety Backup
+	backupID := fmt.Sprintf("%s%s-%s", backupPrefix, time.Now().Format("20060102-150405"), uuid.New().String()[:8])
+	if _, err := r.createLocked(backupID, "before restoring "+snapshotID, true); err != nil {
 		return fmt.Errorf("failed to create safety backup: %w", err)
 	}
 
-	// 2. Clear current state
-	if err := os.RemoveAll(r.stateDir); err != nil {
-		return fmt.Errorf("failed to clear current state: %w", err)
+	for _, name := range sources {
+		dst, ok := r.sources[name]
+		if !ok {
+			continue
+		}
+		// 版を巻き戻さない（リストア前に読み込んだ書き込み手の CAS を失敗させる）
+		versions := documentVersions(dst)
+
+		// 2. Replace data files (ロックファイルは残す)
+		if err := replaceDocuments(filepath.Join(snapDir, name), dst); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		if err := advanceVersions(dst, versions); err != nil {
+			return fmt.Errorf("failed to update versions of %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// isDocumentFile はスナップショットの対象となるデータファイルかを返す（ロックと書き込み途中の一時ファイルは除く）
+func isDocumentFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext != ".lock" && ext != ".tmp"
+}
+
+// relativeDocuments は dir 配下のデータファイルの相対パスを返す（dir が無ければ空）
+func relativeDocuments(dir string) []string {
+	var rels []string
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isDocumentFile(d.Name()) {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rels = append(rels, rel)
+		return nil
+	})
+	return rels
+}
+
+// documentPaths は dirs 配下のデータファイルのパスを返す
+func documentPaths(dirs ...string) []string {
+	var paths []string
+	for _, dir := range dirs {
+		for _, rel := range relativeDocuments(dir) {
+			paths = append(paths, filepath.Join(dir, rel))
+		}
 	}
+	return paths
+}
 
-	// 3. Restore from snapshot
-	if err := copyDir(filepath.Join(snapDir, "state"), r.stateDir); err != nil {
-		return fmt.Errorf("failed to restore state: %w", err)
+// lockDocuments は paths のロックをパス順にすべて取得し、逆順に解放する関数を返す
+// 書き込み手は 1 ドキュメントずつロックするため、順序を固定すればデッドロックしない。
+func lockDocuments(paths []string) (func(), error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	var unlocks []func()
+	release := func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
 	}
+	for i, path := range sorted {
+		if i > 0 && sorted[i-1] == path {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			release()
+			return nil, fmt.Errorf("failed to create dir for %s: %w", path, err)
+		}
+		unlock, err := lockFile(path)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		unlocks = append(unlocks, unlock)
+	}
+	return release, nil
+}
 
+// replaceDocuments は dst のデータファイルを src の内容に置き換える
+// src に無いデータファイルは削除し、各ファイルは一時ファイルからの rename で書き込む（ロックを取らない読み手が途中の内容を読まない）。
+func replaceDocuments(src, dst string) error {
+	keep := make(map[string]bool)
+	for _, rel := range relativeDocuments(src) {
+		keep[rel] = true
+		if err := copyFileAtomic(filepath.Join(src, rel), filepath.Join(dst, rel)); err != nil {
+			return err
+		}
+	}
+	for _, rel := range relativeDocuments(dst) {
+		if keep[rel] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dst, rel)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	return nil
 }
 
+// copyFileAtomic は src を dst.tmp にコピーしてから dst へ rename する
+func copyFileAtomic(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmpPath := dst + ".tmp"
+	if err := copyFile(src, tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
+
+// snapshotSources はスナップショットに含まれるディレクトリ名を返す
+func (r *snapshotRepoImpl) snapshotSources(snapDir string) []string {
+	var snap Snapshot
+	if err := readJSON(filepath.Join(snapDir, "snapshot.json"), &snap); err == nil && len(snap.Sources) > 0 {
+		return snap.Sources
+	}
+	return []string{"state"}
+}
+
 func (r *snapshotRepoImpl) ListSnapshots() ([]Snapshot, error) {
 	entries, err := os.ReadDir(r.baseDir)
 	if err != nil {
@@ -103,7 +347,7 @@ func (r *snapshotRepoImpl) ListSnapshots() ([]Snapshot, error) {
 			continue
 		}
 		// Ignore safety backups
-		if len(entry.Name()) > 6 && entry.Name()[:6] == "backup" {
+		if strings.HasPrefix(entry.Name(), "backup") {
 			continue
 		}
 
@@ -124,6 +368,102 @@ func (r *snapshotRepoImpl) ListSnapshots() ([]Snapshot, error) {
 	return snapshots, nil
 }
 
+// PruneSnapshots は自動スナップショットとリストア前の退避（IncludeManual なら手動も）を
+// 新しい順に MaxCount 件まで残し、MaxAge より古いものを削除する。
+func (r *snapshotRepoImpl) PruneSnapshots(policy RetentionPolicy) ([]string, error) {
+	entries, err := os.ReadDir(r.baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	type candidate struct {
+		id        string
+		createdAt time.Time
+	}
+	var candidates []candidate
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		var snap Snapshot
+		metaErr := readJSON(filepath.Join(r.baseDir, entry.Name(), "snapshot.json"), &snap)
+		backup := strings.HasPrefix(entry.Name(), "backup")
+		if !snap.Auto && !backup && !policy.IncludeManual {
+			continue
+		}
+		createdAt := snap.CreatedAt
+		if metaErr != nil {
+			// メタデータの無い旧形式の退避はディレクトリの更新時刻で判断する
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			createdAt = info.ModTime()
+		}
+		candidates = append(candidates, candidate{id: entry.Name(), createdAt: createdAt})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].createdAt.After(candidates[j].createdAt)
+	})
+
+	var removed []string
+	now := time.Now()
+	for i, c := range candidates {
+		expired := policy.MaxAge > 0 && now.Sub(c.createdAt) > policy.MaxAge
+		overflow := policy.MaxCount > 0 && i >= policy.MaxCount
+		if !expired && !overflow {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(r.baseDir, c.id)); err != nil {
+			return removed, fmt.Errorf("failed to remove snapshot %s: %w", c.id, err)
+		}
+		removed = append(removed, c.id)
+	}
+	return removed, nil
+}
+
+// documentVersions は dir 配下の JSON ドキュメントの版を相対パスごとに返す
+func documentVersions(dir string) map[string]int64 {
+	versions := make(map[string]int64)
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		if v, err := readVersion(path); err == nil && v > 0 {
+			rel, _ := filepath.Rel(dir, path)
+			versions[rel] = v
+		}
+		return nil
+	})
+	return versions
+}
+
+// advanceVersions はリストアしたドキュメントの版をリストア前の版より大きくする
+func advanceVersions(dir string, before map[string]int64) error {
+	for rel, prev := range before {
+		path := filepath.Join(dir, rel)
+		current, err := readVersion(path)
+		if err != nil || current > prev {
+			continue
+		}
+		var doc map[string]interface{}
+		if err := readJSON(path, &doc); err != nil {
+			continue
+		}
+		if _, ok := doc["version"]; !ok {
+			continue
+		}
+		doc["version"] = prev + 1
+		if err := writeJSON(path, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // copyDir recursively copies a directory tree, attempting to preserve permissions.
 // Source directory must exist.
 func copyDir(src string, dst string) (err error) {
@@ -150,6 +490,10 @@ func copyDir(src string, dst string) (err error) {
 	}
 
 	for _, entry := range entries {
+		// advisory lock ファイルと書き込み途中の一時ファイルは実行時のみ意味を持つためコピーしない
+		if !entry.IsDir() && !isDocumentFile(entry.Name()) {
+			continue
+		}
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
 
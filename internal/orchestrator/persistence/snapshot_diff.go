@@ -0,0 +1,212 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SnapshotDiff は 2 つのスナップショット（または現在の状態）の差分
+type SnapshotDiff struct {
+	From         string     `json:"from"`
+	To           string     `json:"to"`
+	Tasks        EntityDiff `json:"tasks"`         // state/tasks.json
+	Nodes        EntityDiff `json:"nodes"`         // design/nodes/*.json
+	NodesRuntime EntityDiff `json:"nodes_runtime"` // state/nodes-runtime.json
+	Backlog      EntityDiff `json:"backlog"`       // backlog/*.json
+}
+
+// EntityDiff はエンティティ種別ごとの追加・削除・変更
+type EntityDiff struct {
+	Added   []string       `json:"added"`
+	Removed []string       `json:"removed"`
+	Changed []EntityChange `json:"changed"`
+}
+
+// EntityChange は変更されたエンティティと、値が変わったトップレベルのフィールド
+type EntityChange struct {
+	ID     string   `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+// Empty は差分が無いかどうかを返す
+func (d *SnapshotDiff) Empty() bool {
+	for _, e := range []EntityDiff{d.Tasks, d.Nodes, d.NodesRuntime, d.Backlog} {
+		if len(e.Added)+len(e.Removed)+len(e.Changed) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// diffIgnoredFields は変更として扱わないフィールド（保存のたびに変わるもの）
+var diffIgnoredFields = map[string]bool{"version": true, "updated_at": true, "updatedAt": true}
+
+func (r *snapshotRepoImpl) DiffSnapshots(fromID, toID string) (*SnapshotDiff, error) {
+	from, err := r.loadEntities(fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.loadEntities(toID)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotDiff{
+		From:         fromID,
+		To:           toID,
+		Tasks:        diffEntities(from.tasks, to.tasks),
+		Nodes:        diffEntities(from.nodes, to.nodes),
+		NodesRuntime: diffEntities(from.nodesRuntime, to.nodesRuntime),
+		Backlog:      diffEntities(from.backlog, to.backlog),
+	}, nil
+}
+
+// entitySet は ID ごとのエンティティ（トップレベルのフィールド → JSON）
+type entitySet map[string]map[string]json.RawMessage
+
+type snapshotEntities struct {
+	tasks, nodes, nodesRuntime, backlog entitySet
+}
+
+// rootOf はスナップショット（または現在の状態）の各ディレクトリのパスを返す
+func (r *snapshotRepoImpl) rootOf(id string) (func(name string) string, error) {
+	if id == CurrentSnapshotID || id == "" {
+		return func(name string) string { return r.sources[name] }, nil
+	}
+	snapDir := filepath.Join(r.baseDir, id)
+	if _, err := os.Stat(snapDir); err != nil {
+		return nil, fmt.Errorf("snapshot not found: %s", id)
+	}
+	return func(name string) string { return filepath.Join(snapDir, name) }, nil
+}
+
+func (r *snapshotRepoImpl) loadEntities(id string) (*snapshotEntities, error) {
+	root, err := r.rootOf(id)
+	if err != nil {
+		return nil, err
+	}
+	out := &snapshotEntities{}
+	if out.tasks, err = loadEntityList(filepath.Join(root("state"), "tasks.json"), "tasks", "task_id"); err != nil {
+		return nil, err
+	}
+	if out.nodesRuntime, err = loadEntityList(filepath.Join(root("state"), "nodes-runtime.json"), "nodes", "node_id"); err != nil {
+		return nil, err
+	}
+	if root("design") != "" {
+		if out.nodes, err = loadEntityDir(filepath.Join(root("design"), "nodes"), "node_id"); err != nil {
+			return nil, err
+		}
+	}
+	if root("backlog") != "" {
+		if out.backlog, err = loadEntityDir(root("backlog"), "id"); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// loadEntityList は {"<listKey>": [...]} 形式のドキュメントを読み込む
+func loadEntityList(path, listKey, idKey string) (entitySet, error) {
+	set := make(entitySet)
+	var doc map[string]json.RawMessage
+	if err := readJSON(path, &doc); err != nil {
+		if os.IsNotExist(err) {
+			return set, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var items []map[string]json.RawMessage
+	if raw, ok := doc[listKey]; ok {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	for _, item := range items {
+		set.add(item, idKey)
+	}
+	return set, nil
+}
+
+// loadEntityDir はディレクトリ内の 1 ファイル 1 エンティティの JSON を読み込む
+func loadEntityDir(dir, idKey string) (entitySet, error) {
+	set := make(entitySet)
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range files {
+		var item map[string]json.RawMessage
+		if err := readJSON(path, &item); err != nil {
+			continue // 壊れたファイルは差分の対象外（fsck で検出する）
+		}
+		set.add(item, idKey)
+	}
+	return set, nil
+}
+
+func (s entitySet) add(item map[string]json.RawMessage, idKey string) {
+	var id string
+	if err := json.Unmarshal(item[idKey], &id); err != nil || id == "" {
+		return
+	}
+	s[id] = item
+}
+
+func diffEntities(from, to entitySet) EntityDiff {
+	diff := EntityDiff{Added: []string{}, Removed: []string{}, Changed: []EntityChange{}}
+	for id, after := range to {
+		before, ok := from[id]
+		if !ok {
+			diff.Added = append(diff.Added, id)
+			continue
+		}
+		if fields := changedFields(before, after); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, EntityChange{ID: id, Fields: fields})
+		}
+	}
+	for id := range from {
+		if _, ok := to[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ID < diff.Changed[j].ID })
+	return diff
+}
+
+func changedFields(before, after map[string]json.RawMessage) []string {
+	keys := make(map[string]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	var fields []string
+	for k := range keys {
+		if diffIgnoredFields[k] {
+			continue
+		}
+		if !bytes.Equal(compactJSON(before[k]), compactJSON(after[k])) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func compactJSON(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return []byte(strings.TrimSpace(string(raw)))
+	}
+	return buf.Bytes()
+}
@@ -3,10 +3,12 @@ package persistence
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSnapshotRepository_CreateRestore(t *testing.T) {
@@ -88,3 +90,155 @@ func TestSnapshotRepository_List(t *testing.T) {
 	assert.Equal(t, "snap 2", snaps[1].Description)
 	assert.Equal(t, "snap 1", snaps[2].Description)
 }
+
+func TestWorkspaceSnapshot_RestoresDesignAndAdvancesVersions(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewWorkspaceRepository(dir)
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Design().SaveNode(&NodeDesign{NodeID: "n1", Name: "before"}))
+	setTaskStatus(t, repo, "t1", "pending")
+
+	snap, err := repo.Snapshot().CreateSnapshot("checkpoint")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"design", "state"}, snap.Sources)
+
+	require.NoError(t, repo.Design().UpdateNode("n1", func(n *NodeDesign) error {
+		n.Name = "after"
+		return nil
+	}))
+	setTaskStatus(t, repo, "t1", "running")
+	before, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Snapshot().RestoreSnapshot(snap.ID))
+
+	node, err := repo.Design().GetNode("n1")
+	require.NoError(t, err)
+	assert.Equal(t, "before", node.Name)
+	tasks, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	assert.Equal(t, "pending", tasks.Tasks[0].Status)
+	assert.Greater(t, tasks.Version, before.Version, "restore must not move versions backwards")
+
+	// 古い版で保存しようとした書き込み手は衝突する
+	require.ErrorIs(t, repo.State().SaveTasks(before), ErrVersionConflict)
+
+	// リストア後の state が history の新しい起点になる
+	rs, err := StateAt(repo.History(), time.Now())
+	require.NoError(t, err)
+	drift, err := rs.Drift(tasks, &NodesRuntime{Nodes: []NodeRuntime{}})
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+}
+
+func TestWorkspaceSnapshot_Diff(t *testing.T) {
+	repo := NewWorkspaceRepository(t.TempDir())
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Design().SaveNode(&NodeDesign{NodeID: "n1", Name: "first"}))
+	require.NoError(t, repo.Design().SaveNode(&NodeDesign{NodeID: "n2", Name: "second"}))
+	setTaskStatus(t, repo, "t1", "pending")
+	setTaskStatus(t, repo, "t2", "pending")
+
+	snap, err := repo.Snapshot().CreateSnapshot("base")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Design().UpdateNode("n1", func(n *NodeDesign) error {
+		n.Name = "renamed"
+		return nil
+	}))
+	require.NoError(t, repo.Design().SaveNode(&NodeDesign{NodeID: "n3", Name: "third"}))
+	require.NoError(t, repo.State().UpdateTasks(func(s *TasksState) error {
+		s.Tasks = []TaskState{{TaskID: "t1", Status: "succeeded"}, {TaskID: "t3", Status: "pending"}}
+		return nil
+	}))
+
+	diff, err := repo.Snapshot().DiffSnapshots(snap.ID, CurrentSnapshotID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t3"}, diff.Tasks.Added)
+	assert.Equal(t, []string{"t2"}, diff.Tasks.Removed)
+	require.Len(t, diff.Tasks.Changed, 1)
+	assert.Equal(t, EntityChange{ID: "t1", Fields: []string{"status"}}, diff.Tasks.Changed[0])
+	assert.Equal(t, []string{"n3"}, diff.Nodes.Added)
+	assert.Empty(t, diff.Nodes.Removed)
+	require.Len(t, diff.Nodes.Changed, 1)
+	assert.Equal(t, "n1", diff.Nodes.Changed[0].ID)
+	assert.Equal(t, []string{"name"}, diff.Nodes.Changed[0].Fields)
+	assert.False(t, diff.Empty())
+
+	same, err := repo.Snapshot().DiffSnapshots(snap.ID, snap.ID)
+	require.NoError(t, err)
+	assert.True(t, same.Empty())
+
+	_, err = repo.Snapshot().DiffSnapshots("missing", CurrentSnapshotID)
+	assert.Error(t, err)
+}
+
+func TestSnapshotRepository_Prune(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateDir := filepath.Join(tmpDir, "state")
+	require.NoError(t, os.MkdirAll(stateDir, 0755))
+	repo := NewSnapshotRepository(filepath.Join(tmpDir, "snapshots"), stateDir)
+	repo.SetRetentionPolicy(RetentionPolicy{MaxCount: 2})
+
+	manual, err := repo.CreateSnapshot("manual")
+	require.NoError(t, err)
+	var autos []*Snapshot
+	for i := 0; i < 4; i++ {
+		time.Sleep(5 * time.Millisecond)
+		snap, err := repo.CreateAutoSnapshot("auto")
+		require.NoError(t, err)
+		autos = append(autos, snap)
+	}
+
+	snaps, err := repo.ListSnapshots()
+	require.NoError(t, err)
+	ids := make([]string, 0, len(snaps))
+	for _, s := range snaps {
+		ids = append(ids, s.ID)
+	}
+	// 自動スナップショットは新しい 2 件だけ残り、手動のものは残る
+	assert.Equal(t, []string{autos[3].ID, autos[2].ID, manual.ID}, ids)
+
+	removed, err := repo.PruneSnapshots(RetentionPolicy{MaxAge: time.Nanosecond, IncludeManual: true})
+	require.NoError(t, err)
+	assert.Len(t, removed, 3)
+	snaps, err = repo.ListSnapshots()
+	require.NoError(t, err)
+	assert.Empty(t, snaps)
+}
+
+func TestSnapshotRepository_RestoreWaitsForDocumentLocksAndKeepsLockFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateDir := filepath.Join(tmpDir, "state")
+	require.NoError(t, os.MkdirAll(stateDir, 0755))
+	tasksPath := filepath.Join(stateDir, "tasks.json")
+	require.NoError(t, os.WriteFile(tasksPath, []byte(`{"version": 1}`), 0644))
+
+	repo := NewSnapshotRepository(filepath.Join(tmpDir, "snapshots"), stateDir)
+	snap, err := repo.CreateSnapshot("initial state")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(tasksPath, []byte(`{"version": 2}`), 0644))
+
+	// CAS の書き込み手がロックを保持している間はリストアしない
+	unlock, err := lockFile(tasksPath)
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- repo.RestoreSnapshot(snap.ID) }()
+
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("restore finished while the document was locked: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	require.NoError(t, <-done)
+
+	if runtime.GOOS != "windows" { // Windows のロックファイルは解放時に消える
+		_, err = os.Stat(lockPath(tasksPath))
+		assert.NoError(t, err, "lock files are not removed by restore")
+	}
+	v, err := readVersion(tasksPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), v, "restored document keeps advancing its version")
+}
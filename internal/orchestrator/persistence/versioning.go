@@ -0,0 +1,172 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrVersionConflict は保存しようとしたドキュメントの版がディスク上の版と一致しない場合に返る
+// 読み込み後に他のプロセス/goroutine が書き込んだことを意味する。
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrNoChange を Update* の fn から返すと、保存せずに正常終了する
+var ErrNoChange = errors.New("no change")
+
+// DefaultUpdateRetries は Update* ヘルパーが版衝突時に再試行する回数
+const DefaultUpdateRetries = 5
+
+// lockPath はドキュメントに対応する advisory lock ファイルのパスを返す
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// withFileLock は path のロックを保持したまま fn を実行する
+func withFileLock(path string, fn func() error) error {
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+// readVersion はディスク上のドキュメントの版を返す（存在しない場合は 0）
+// version フィールドを持たない旧形式のファイルも 0 として扱う。
+func readVersion(path string) (int64, error) {
+	var head struct {
+		Version int64 `json:"version"`
+	}
+	if err := readJSON(path, &head); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return head.Version, nil
+}
+
+// writeIfVersion はディスク上の版が *version と一致する場合のみ v を書き込み、版を 1 進める
+// 呼び出し側でロックを保持していること。
+func writeIfVersion(path string, v interface{}, version *int64) error {
+	current, err := readVersion(path)
+	if err != nil {
+		return err
+	}
+	if current != *version {
+		return fmt.Errorf("%w: %s (expected version %d, found %d)", ErrVersionConflict, filepath.Base(path), *version, current)
+	}
+	*version = current + 1
+	if err := writeJSON(path, v); err != nil {
+		*version = current
+		return err
+	}
+	return nil
+}
+
+// recorder はロック内で保存の直前に呼ばれ、保存前後の差分を履歴へ追記する
+// 返した commit は保存結果を受け取り、保存に失敗した場合はその旨を履歴に残す。
+type recorder[T any] func(before, after *T) (commit func(saveErr error), err error)
+
+// saveVersioned はロックを取得して compare-and-swap で書き込む
+func saveVersioned(path string, v interface{}, version *int64) error {
+	return withFileLock(path, func() error {
+		return writeIfVersion(path, v, version)
+	})
+}
+
+// saveVersionedRecorded は saveVersioned と同様に書き込み、ディスク上の版との差分を履歴に記録する
+func saveVersionedRecorded[T any](path string, load func() (*T, error), doc *T, version *int64, record recorder[T]) error {
+	return withFileLock(path, func() error {
+		before, err := load()
+		if err != nil {
+			return err
+		}
+		return writeRecorded(path, before, doc, version, record)
+	})
+}
+
+// writeRecorded は履歴を先に追記してから書き込む（history → state の順。呼び出し側でロックを保持すること）
+func writeRecorded[T any](path string, before, doc *T, version *int64, record recorder[T]) error {
+	commit, err := record(before, doc)
+	if err != nil {
+		return err
+	}
+	err = writeIfVersion(path, doc, version)
+	if commit != nil {
+		commit(err)
+	}
+	return err
+}
+
+// updateVersioned はロックを保持したまま load → fn → compare-and-swap 保存を行う
+// 版衝突（ロックを取らない書き込み手がいた場合）のみ再試行し、fn のエラーはそのまま返す。
+// fn は再試行時に再度呼ばれるため、ドキュメント以外への副作用を持たせないこと。
+// record が nil でなければ、保存前後の差分を履歴に記録する。
+func updateVersioned[T any](path string, load func() (*T, error), version func(*T) *int64, fn func(*T) error, record recorder[T]) error {
+	var lastErr error
+	for attempt := 0; attempt < DefaultUpdateRetries; attempt++ {
+		err := withFileLock(path, func() error {
+			doc, err := load()
+			if err != nil {
+				return err
+			}
+			var before *T
+			if record != nil {
+				// fn がドキュメントを書き換えるため、差分の基準は別に読み込む
+				if before, err = load(); err != nil {
+					return err
+				}
+			}
+			if err := fn(doc); err != nil {
+				if errors.Is(err, ErrNoChange) {
+					return nil
+				}
+				return err
+			}
+			if record != nil {
+				return writeRecorded(path, before, doc, version(doc), record)
+			}
+			return writeIfVersion(path, doc, version(doc))
+		})
+		if !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// UpdateJSONFile は path のロックを保持したまま JSON ドキュメントを読み込み、fn の結果をアトミックに書き込む
+// persistence 外でファイル単位に保存するストア（schedules など）が read-modify-write を直列化するために使う。
+// ファイルが無ければ fn には nil を渡す。fn が nil を返すとファイルを削除し、ErrNoChange を返すと何もしない。
+// fn はロック内で 1 度だけ呼ばれる。
+func UpdateJSONFile[T any](path string, fn func(current *T) (*T, error)) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return withFileLock(path, func() error {
+		var current *T
+		var doc T
+		if err := readJSON(path, &doc); err == nil {
+			current = &doc
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		next, err := fn(current)
+		if err != nil {
+			if errors.Is(err, ErrNoChange) {
+				return nil
+			}
+			return err
+		}
+		if next == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		return writeJSON(path, next)
+	})
+}
@@ -0,0 +1,185 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepo(t *testing.T) WorkspaceRepository {
+	t.Helper()
+	repo := NewWorkspaceRepository(t.TempDir())
+	require.NoError(t, repo.Init())
+	return repo
+}
+
+func TestSaveTasks_CompareAndSwap(t *testing.T) {
+	repo := newTestRepo(t)
+
+	first := &TasksState{Tasks: []TaskState{{TaskID: "t1"}}}
+	require.NoError(t, repo.State().SaveTasks(first))
+	assert.Equal(t, int64(1), first.Version)
+
+	// 2 つの読み手が同じ版を読み込む
+	a, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	b, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+
+	a.Tasks = append(a.Tasks, TaskState{TaskID: "t2"})
+	require.NoError(t, repo.State().SaveTasks(a))
+	assert.Equal(t, int64(2), a.Version)
+
+	// 古い版からの書き込みは拒否される
+	b.Tasks = append(b.Tasks, TaskState{TaskID: "t3"})
+	err = repo.State().SaveTasks(b)
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+	assert.Equal(t, int64(1), b.Version)
+
+	loaded, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	assert.Len(t, loaded.Tasks, 2)
+	assert.Equal(t, int64(2), loaded.Version)
+}
+
+func TestSaveTasks_LegacyFileWithoutVersion(t *testing.T) {
+	repo := newTestRepo(t)
+	path := filepath.Join(repo.BaseDir(), "state", "tasks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"tasks":[{"task_id":"t1"}]}`), 0644))
+
+	state, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), state.Version)
+	require.NoError(t, repo.State().SaveTasks(state))
+	assert.Equal(t, int64(1), state.Version)
+}
+
+func TestUpdateTasks_ConcurrentNoLostUpdates(t *testing.T) {
+	repo := newTestRepo(t)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := repo.State().UpdateTasks(func(s *TasksState) error {
+				s.Tasks = append(s.Tasks, TaskState{TaskID: fmt.Sprintf("t%d", i)})
+				return nil
+			})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	state, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	assert.Len(t, state.Tasks, workers)
+	assert.Equal(t, int64(workers), state.Version)
+}
+
+func TestUpdateTasks_NoChangeAndError(t *testing.T) {
+	repo := newTestRepo(t)
+	require.NoError(t, repo.State().SaveTasks(&TasksState{Tasks: []TaskState{{TaskID: "t1"}}}))
+
+	require.NoError(t, repo.State().UpdateTasks(func(s *TasksState) error {
+		s.Tasks = nil
+		return ErrNoChange
+	}))
+
+	fnErr := errors.New("boom")
+	err := repo.State().UpdateTasks(func(s *TasksState) error {
+		s.Tasks = nil
+		return fnErr
+	})
+	assert.ErrorIs(t, err, fnErr)
+
+	state, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	assert.Len(t, state.Tasks, 1)
+	assert.Equal(t, int64(1), state.Version)
+}
+
+func TestUpdateNode(t *testing.T) {
+	repo := newTestRepo(t)
+
+	err := repo.Design().UpdateNode("missing", func(n *NodeDesign) error { return nil })
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, repo.Design().SaveNode(&NodeDesign{NodeID: "n1", Name: "before"}))
+	require.NoError(t, repo.Design().UpdateNode("n1", func(n *NodeDesign) error {
+		n.Name = "after"
+		return nil
+	}))
+
+	node, err := repo.Design().GetNode("n1")
+	require.NoError(t, err)
+	assert.Equal(t, "after", node.Name)
+	assert.Equal(t, int64(2), node.Version)
+
+	require.NoError(t, repo.Design().DeleteNode("n1"))
+	_, err = repo.Design().GetNode("n1")
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, repo.Design().DeleteNode("n1"), "deleting a missing node is a no-op")
+}
+
+func TestUpdateWBS_CreatesWhenMissing(t *testing.T) {
+	repo := newTestRepo(t)
+
+	require.NoError(t, repo.Design().UpdateWBS(func(w *WBS) error {
+		w.WBSID = "wbs-1"
+		return nil
+	}))
+
+	wbs, err := repo.Design().LoadWBS()
+	require.NoError(t, err)
+	assert.Equal(t, "wbs-1", wbs.WBSID)
+	assert.Equal(t, int64(1), wbs.Version)
+}
+
+func TestUpdateJSONFile_SerializesAndDeletes(t *testing.T) {
+	type counter struct {
+		N int `json:"n"`
+	}
+	path := filepath.Join(t.TempDir(), "docs", "counter.json")
+
+	const workers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, UpdateJSONFile(path, func(c *counter) (*counter, error) {
+				if c == nil {
+					c = &counter{}
+				}
+				c.N++
+				return c, nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	var got counter
+	require.NoError(t, readJSON(path, &got))
+	assert.Equal(t, workers, got.N)
+	_, err := os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err), "temporary file must not remain")
+
+	require.NoError(t, UpdateJSONFile(path, func(c *counter) (*counter, error) {
+		c.N = 0
+		return c, ErrNoChange
+	}))
+	require.NoError(t, readJSON(path, &got))
+	assert.Equal(t, workers, got.N)
+
+	require.NoError(t, UpdateJSONFile(path, func(c *counter) (*counter, error) { return nil, nil }))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
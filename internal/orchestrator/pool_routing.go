@@ -0,0 +1,229 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// DefaultPoolID はルーティングで Pool が決まらなかった場合の既定 Pool
+const DefaultPoolID = "default"
+
+// WorkerPoolsFileName はワークスペース直下の Pool 設定ファイル名
+const WorkerPoolsFileName = "worker-pools.json"
+
+// PoolRoute はタスクを Pool に振り分けるルールを表す
+// 指定された条件はすべて AND で評価し、各条件内の値は OR で評価する。
+// 条件が 1 つも指定されていないルールは何にもマッチしない。
+type PoolRoute struct {
+	PoolID     string   `json:"poolId"`
+	Kinds      []string `json:"kinds,omitempty"`      // TaskState.Kind
+	Phases     []string `json:"phases,omitempty"`     // NodeDesign.PhaseName
+	Milestones []string `json:"milestones,omitempty"` // NodeDesign.Milestone
+	Labels     []string `json:"labels,omitempty"`     // TaskState.Inputs["labels"]
+}
+
+// WorkerPoolsConfig は worker-pools.json の内容を表す
+type WorkerPoolsConfig struct {
+	Pools         []Pool      `json:"pools"`
+	Routes        []PoolRoute `json:"routes,omitempty"`
+	DefaultPoolID string      `json:"defaultPoolId,omitempty"`
+}
+
+// DefaultWorkerPoolsConfig は worker-pools.json が存在しない場合の設定を返す
+func DefaultWorkerPoolsConfig() *WorkerPoolsConfig {
+	pools := make([]Pool, len(DefaultPools))
+	copy(pools, DefaultPools)
+	return &WorkerPoolsConfig{
+		Pools:         pools,
+		DefaultPoolID: DefaultPoolID,
+	}
+}
+
+// LoadWorkerPoolsConfig はワークスペースの worker-pools.json を読み込む
+// ファイルが存在しない、または Pool が空の場合はデフォルト Pool を補完する。
+// routes・defaultPoolId が pools に無い Pool を指す場合はエラーとし、デフォルト設定を返す。
+func LoadWorkerPoolsConfig(workspaceDir string) (*WorkerPoolsConfig, error) {
+	path := filepath.Join(workspaceDir, WorkerPoolsFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultWorkerPoolsConfig(), nil
+		}
+		return DefaultWorkerPoolsConfig(), fmt.Errorf("failed to read %s: %w", WorkerPoolsFileName, err)
+	}
+
+	var cfg WorkerPoolsConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultWorkerPoolsConfig(), fmt.Errorf("failed to parse %s: %w", WorkerPoolsFileName, err)
+	}
+
+	if len(cfg.Pools) == 0 {
+		cfg.Pools = DefaultWorkerPoolsConfig().Pools
+	}
+	if cfg.DefaultPoolID == "" {
+		cfg.DefaultPoolID = DefaultPoolID
+	}
+	if err := cfg.Validate(); err != nil {
+		return DefaultWorkerPoolsConfig(), fmt.Errorf("invalid %s: %w", WorkerPoolsFileName, err)
+	}
+	return &cfg, nil
+}
+
+// Validate は routes と defaultPoolId が設定済みの Pool を指しているかを確認する
+// 存在しない Pool に投入されたジョブはどのオーケストレーターにも取り出されないため、読み込み時に弾く。
+func (c *WorkerPoolsConfig) Validate() error {
+	for i, route := range c.Routes {
+		if _, ok := c.FindPool(route.PoolID); !ok {
+			return fmt.Errorf("routes[%d]: unknown pool %q", i, route.PoolID)
+		}
+	}
+	if _, ok := c.FindPool(c.DefaultPoolID); !ok {
+		return fmt.Errorf("defaultPoolId: unknown pool %q", c.DefaultPoolID)
+	}
+	return nil
+}
+
+// PoolIDs は設定された Pool の ID 一覧を返す
+func (c *WorkerPoolsConfig) PoolIDs() []string {
+	ids := make([]string, 0, len(c.Pools))
+	for _, p := range c.Pools {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// FindPool は ID に一致する Pool を返す
+func (c *WorkerPoolsConfig) FindPool(poolID string) (Pool, bool) {
+	for _, p := range c.Pools {
+		if p.ID == poolID {
+			return p, true
+		}
+	}
+	return Pool{}, false
+}
+
+// PoolRouter はタスクの属性から投入先の Pool を決定する
+type PoolRouter struct {
+	config *WorkerPoolsConfig
+}
+
+// NewPoolRouter は PoolRouter を作成する
+func NewPoolRouter(cfg *WorkerPoolsConfig) *PoolRouter {
+	if cfg == nil {
+		cfg = DefaultWorkerPoolsConfig()
+	}
+	return &PoolRouter{config: cfg}
+}
+
+// Config はルーターが参照している設定を返す
+func (r *PoolRouter) Config() *WorkerPoolsConfig {
+	return r.config
+}
+
+// Route はタスクの投入先 Pool ID を返す
+// 優先度:
+// 1. Inputs["pool_id"] による明示指定（設定に存在する Pool のみ）
+// 2. routes の先頭から最初にマッチしたルール（設定に存在する Pool のみ）
+// 3. defaultPoolId
+// node は NodeDesign が取得できない場合 nil でよい（phase/milestone 条件はマッチしない）。
+func (r *PoolRouter) Route(task *persistence.TaskState, node *persistence.NodeDesign) string {
+	if task != nil && task.Inputs != nil {
+		if poolID, ok := task.Inputs[InputKeyPoolID].(string); ok && poolID != "" {
+			if _, known := r.config.FindPool(poolID); known {
+				return poolID
+			}
+		}
+	}
+
+	for _, route := range r.config.Routes {
+		if _, known := r.config.FindPool(route.PoolID); !known {
+			continue
+		}
+		if route.matches(task, node) {
+			return route.PoolID
+		}
+	}
+
+	if r.config.DefaultPoolID != "" {
+		return r.config.DefaultPoolID
+	}
+	return DefaultPoolID
+}
+
+// matches はルールの全条件がタスクに当てはまるかを判定する
+func (route PoolRoute) matches(task *persistence.TaskState, node *persistence.NodeDesign) bool {
+	if len(route.Kinds) == 0 && len(route.Phases) == 0 && len(route.Milestones) == 0 && len(route.Labels) == 0 {
+		return false
+	}
+
+	if len(route.Kinds) > 0 {
+		if task == nil || !containsString(route.Kinds, task.Kind) {
+			return false
+		}
+	}
+	if len(route.Phases) > 0 {
+		if node == nil || !containsString(route.Phases, node.PhaseName) {
+			return false
+		}
+	}
+	if len(route.Milestones) > 0 {
+		if node == nil || !containsString(route.Milestones, node.Milestone) {
+			return false
+		}
+	}
+	if len(route.Labels) > 0 {
+		matched := false
+		for _, label := range taskLabels(task) {
+			if containsString(route.Labels, label) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// taskLabels は Inputs["labels"] からラベル一覧を取り出す
+func taskLabels(task *persistence.TaskState) []string {
+	if task == nil {
+		return nil
+	}
+	return inputStrings(task.Inputs, InputKeyLabels)
+}
+
+// inputStrings は Inputs[key] から文字列の一覧を取り出す
+// JSON 経由の []interface{} と Go から直接設定された []string の両方を扱う。
+func inputStrings(inputs map[string]interface{}, key string) []string {
+	if inputs == nil {
+		return nil
+	}
+	switch v := inputs[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		labels := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				labels = append(labels, s)
+			}
+		}
+		return labels
+	}
+	return nil
+}
+
+func containsString(xs []string, x string) bool {
+	for _, v := range xs {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/biwakonbu/agent-runner/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeWorkerPools(t *testing.T, dir string, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, WorkerPoolsFileName), []byte(content), 0644))
+}
+
+func TestLoadWorkerPoolsConfig_Default(t *testing.T) {
+	cfg, err := LoadWorkerPoolsConfig(t.TempDir())
+	require.NoError(t, err)
+	assert.Len(t, cfg.Pools, len(DefaultPools))
+	assert.Equal(t, DefaultPoolID, cfg.DefaultPoolID)
+	assert.Equal(t, []string{"default", "codegen", "test"}, cfg.PoolIDs())
+}
+
+func TestLoadWorkerPoolsConfig_InvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkerPools(t, dir, "{invalid")
+
+	cfg, err := LoadWorkerPoolsConfig(dir)
+	assert.Error(t, err)
+	require.NotNil(t, cfg)
+	assert.Len(t, cfg.Pools, len(DefaultPools))
+}
+
+func TestLoadWorkerPoolsConfig_WithRoutes(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkerPools(t, dir, `{
+  "pools": [
+    {"id": "default", "name": "Default"},
+    {"id": "gpu", "name": "GPU", "toolingProfile": "heavy", "workerImage": "example/gpu:1", "concurrency": 2}
+  ],
+  "routes": [{"poolId": "gpu", "kinds": ["test"]}]
+}`)
+
+	cfg, err := LoadWorkerPoolsConfig(dir)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPoolID, cfg.DefaultPoolID)
+	require.Len(t, cfg.Routes, 1)
+
+	pool, ok := cfg.FindPool("gpu")
+	require.True(t, ok)
+	assert.Equal(t, "heavy", pool.ToolingProfile)
+	assert.Equal(t, "example/gpu:1", pool.WorkerImage)
+	assert.Equal(t, 2, pool.MaxConcurrency())
+
+	def, _ := cfg.FindPool("default")
+	assert.Equal(t, 1, def.MaxConcurrency())
+}
+
+func TestLoadWorkerPoolsConfig_UnknownPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "route target",
+			content: `{"pools": [{"id": "default"}, {"id": "gpu"}], "routes": [{"poolId": "gpuu", "kinds": ["test"]}]}`,
+			wantErr: `routes[0]: unknown pool "gpuu"`,
+		},
+		{
+			name:    "default pool",
+			content: `{"pools": [{"id": "default"}, {"id": "gpu"}], "defaultPoolId": "cpu"}`,
+			wantErr: `defaultPoolId: unknown pool "cpu"`,
+		},
+		{
+			name:    "implicit default pool",
+			content: `{"pools": [{"id": "gpu"}]}`,
+			wantErr: `defaultPoolId: unknown pool "default"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeWorkerPools(t, dir, tt.content)
+
+			cfg, err := LoadWorkerPoolsConfig(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			require.NotNil(t, cfg)
+			assert.Equal(t, DefaultWorkerPoolsConfig(), cfg)
+		})
+	}
+}
+
+func TestPoolRouter_Route(t *testing.T) {
+	router := NewPoolRouter(&WorkerPoolsConfig{
+		Pools: []Pool{{ID: "default"}, {ID: "codegen"}, {ID: "test"}, {ID: "release"}, {ID: "docs"}},
+		Routes: []PoolRoute{
+			{PoolID: "test", Kinds: []string{"test"}},
+			{PoolID: "release", Milestones: []string{"M2"}, Phases: []string{"実装"}},
+			{PoolID: "docs", Labels: []string{"docs", "writing"}},
+			{PoolID: "codegen"}, // 条件なしはマッチしない
+		},
+		DefaultPoolID: "default",
+	})
+
+	tests := []struct {
+		name string
+		task persistence.TaskState
+		node *persistence.NodeDesign
+		want string
+	}{
+		{
+			name: "kind match",
+			task: persistence.TaskState{Kind: "test"},
+			want: "test",
+		},
+		{
+			name: "phase and milestone both required",
+			task: persistence.TaskState{Kind: "implementation"},
+			node: &persistence.NodeDesign{PhaseName: "実装", Milestone: "M2"},
+			want: "release",
+		},
+		{
+			name: "phase only does not match",
+			task: persistence.TaskState{Kind: "implementation"},
+			node: &persistence.NodeDesign{PhaseName: "実装", Milestone: "M1"},
+			want: "default",
+		},
+		{
+			name: "label match from JSON inputs",
+			task: persistence.TaskState{Inputs: map[string]interface{}{InputKeyLabels: []interface{}{"backend", "docs"}}},
+			want: "docs",
+		},
+		{
+			name: "explicit pool id wins",
+			task: persistence.TaskState{Kind: "test", Inputs: map[string]interface{}{InputKeyPoolID: "codegen"}},
+			want: "codegen",
+		},
+		{
+			name: "unknown explicit pool id is ignored",
+			task: persistence.TaskState{Kind: "test", Inputs: map[string]interface{}{InputKeyPoolID: "missing"}},
+			want: "test",
+		},
+		{
+			name: "no match falls back to default",
+			task: persistence.TaskState{Kind: "analysis"},
+			want: "default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			assert.Equal(t, tt.want, router.Route(&task, tt.node))
+		})
+	}
+}
+
+func TestScheduler_ScheduleTask_RoutesToPool(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	writeWorkerPools(t, repo.BaseDir(), `{
+  "pools": [{"id": "default"}, {"id": "codegen"}],
+  "routes": [{"poolId": "codegen", "phases": ["実装"]}]
+}`)
+	scheduler := NewScheduler(repo, queue, nil)
+
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-1", PhaseName: "実装"},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Kind: "implementation", Status: string(TaskStatusPending), CreatedAt: time.Now()},
+	}, nil)
+
+	require.NoError(t, scheduler.ScheduleTask("task-1"))
+
+	jobs, err := queue.ListJobs("codegen")
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	defaultJobs, err := queue.ListJobs("default")
+	require.NoError(t, err)
+	assert.Empty(t, defaultJobs)
+}
+
+func TestGenerateTaskYAML_PoolOverrides(t *testing.T) {
+	executor := NewExecutor("agent-runner", t.TempDir())
+	executor.SetToolingConfig(&config.ToolingConfig{ActiveProfile: "balanced"})
+	executor.SetWorkerPools(&WorkerPoolsConfig{
+		Pools: []Pool{{ID: "gpu", ToolingProfile: "heavy", WorkerImage: "example/gpu:1"}},
+	})
+
+	got := executor.generateTaskYAML(&Task{ID: "task-1", Title: "GPU", PoolID: "gpu"})
+	assert.Contains(t, got, "active_profile: heavy")
+	assert.Contains(t, got, `    docker_image: "example/gpu:1"`)
+	// 元の設定は変更されない
+	assert.Equal(t, "balanced", executor.ToolingConfig.ActiveProfile)
+
+	plain := executor.generateTaskYAML(&Task{ID: "task-2", Title: "Plain", PoolID: "default"})
+	assert.Contains(t, plain, "active_profile: balanced")
+	assert.NotContains(t, plain, "docker_image")
+
+	// タスクの指定（バックログの retry_tooling）は Pool の指定より優先する
+	fast := executor.generateTaskYAML(&Task{ID: "task-3", Title: "Fast", PoolID: "gpu", ToolingProfile: "fast"})
+	assert.Contains(t, fast, "active_profile: fast")
+}
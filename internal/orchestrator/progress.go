@@ -0,0 +1,267 @@
+package orchestrator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// DefaultProgressWindowDays はスループットを集計する既定の日数
+const DefaultProgressWindowDays = 14
+
+// ProgressGroup はマイルストーン・フェーズ単位（または全体）の進捗の集計
+// ポイントはノード設計の Estimate.StoryPoints（1 未満は 1、設計の無いタスクは 1）。
+// CANCELED / SKIPPED のタスクはスコープ外としてポイントと TotalTasks に含めず、Counts にだけ数える。
+type ProgressGroup struct {
+	Name            string         `json:"name"` // 空はマイルストーン・フェーズ未設定
+	TotalPoints     int            `json:"totalPoints"`
+	CompletedPoints int            `json:"completedPoints"`
+	TotalTasks      int            `json:"totalTasks"`
+	CompletedTasks  int            `json:"completedTasks"`
+	Counts          map[string]int `json:"counts"` // ステータスごとのタスク数
+}
+
+// RemainingPoints は未完了のポイントを返す
+func (g ProgressGroup) RemainingPoints() int {
+	return g.TotalPoints - g.CompletedPoints
+}
+
+// ThroughputBucket は 1 日に完了したタスク数とポイント
+type ThroughputBucket struct {
+	Date   time.Time `json:"date"` // その日の 0 時
+	Tasks  int       `json:"tasks"`
+	Points int       `json:"points"`
+}
+
+// ProgressReport はマイルストーン・フェーズごとの進捗とバーンダウン用のデータ
+// 検証タスクは実装タスクと同じノードのポイントを二重に数えないよう Pools 以外の集計から除く。
+type ProgressReport struct {
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Total       ProgressGroup      `json:"total"`
+	Milestones  []ProgressGroup    `json:"milestones"`
+	Phases      []ProgressGroup    `json:"phases"`
+	Pools       []PoolSummary      `json:"pools"`
+	Throughput  []ThroughputBucket `json:"throughput"` // 古い日から順に WindowDays 日分
+	WindowDays  int                `json:"windowDays"`
+	// PointsPerDay は Throughput の期間の 1 日あたりの完了ポイント
+	PointsPerDay float64 `json:"pointsPerDay"`
+	// ProjectedCompletion は残りポイントを PointsPerDay で消化し終える見込み日時
+	// 残りが無ければ GeneratedAt、スループットが 0 なら見込みが立たないため nil。
+	ProjectedCompletion *time.Time `json:"projectedCompletion,omitempty"`
+}
+
+// BuildProgressReport は state/tasks.json・ノード設計・history から進捗レポートを組み立てる
+// windowDays はスループットを集計する日数で、0 以下は DefaultProgressWindowDays。
+// 日の区切りは now のタイムゾーンに従う。
+func BuildProgressReport(repo persistence.WorkspaceRepository, now time.Time, windowDays int) (*ProgressReport, error) {
+	if windowDays <= 0 {
+		windowDays = DefaultProgressWindowDays
+	}
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	nodes := newProgressNodes(repo)
+	report := &ProgressReport{
+		GeneratedAt: now,
+		Total:       ProgressGroup{Counts: make(map[string]int)},
+		Pools:       poolSummaries(tasksState.Tasks),
+		WindowDays:  windowDays,
+	}
+	milestones := make(map[string]*ProgressGroup)
+	phases := make(map[string]*ProgressGroup)
+	for i := range tasksState.Tasks {
+		ts := &tasksState.Tasks[i]
+		if isVerificationTask(ts) {
+			continue
+		}
+		node := nodes.get(ts.NodeID)
+		status := TaskStatus(strings.ToUpper(ts.Status))
+		points := nodes.points(ts.NodeID)
+		addProgress(&report.Total, status, points)
+		addProgress(progressGroupFor(milestones, node.Milestone), status, points)
+		addProgress(progressGroupFor(phases, node.PhaseName), status, points)
+	}
+	report.Milestones = sortedProgressGroups(milestones)
+	report.Phases = sortedProgressGroups(phases)
+
+	report.Throughput, err = completionThroughput(repo, nodes, now, windowDays)
+	if err != nil {
+		return nil, err
+	}
+	donePoints := 0
+	for _, b := range report.Throughput {
+		donePoints += b.Points
+	}
+	report.PointsPerDay = float64(donePoints) / float64(windowDays)
+	report.ProjectedCompletion = projectCompletion(report.Total.RemainingPoints(), report.PointsPerDay, now)
+	return report, nil
+}
+
+// addProgress はタスク 1 件を集計に加える
+func addProgress(g *ProgressGroup, status TaskStatus, points int) {
+	g.Counts[string(status)]++
+	switch status {
+	case TaskStatusCanceled, TaskStatusSkipped:
+		return
+	}
+	g.TotalTasks++
+	g.TotalPoints += points
+	if isCompletedTaskStatus(status) {
+		g.CompletedTasks++
+		g.CompletedPoints += points
+	}
+}
+
+// isCompletedTaskStatus は進捗上「完了」として数えるステータスかを返す
+func isCompletedTaskStatus(status TaskStatus) bool {
+	return status == TaskStatusSucceeded || status == TaskStatusCompleted
+}
+
+func progressGroupFor(groups map[string]*ProgressGroup, name string) *ProgressGroup {
+	g, ok := groups[name]
+	if !ok {
+		g = &ProgressGroup{Name: name, Counts: make(map[string]int)}
+		groups[name] = g
+	}
+	return g
+}
+
+// sortedProgressGroups は名前順に並べる（未設定の "" は先頭）
+func sortedProgressGroups(groups map[string]*ProgressGroup) []ProgressGroup {
+	out := make([]ProgressGroup, 0, len(groups))
+	for _, name := range sortedKeys(groups) {
+		out = append(out, *groups[name])
+	}
+	return out
+}
+
+// completionThroughput は history の状態遷移アクションから、期間内に完了したタスクを日ごとに集計する
+// 完了し直したタスク（差分の差し戻し後など）は期間内の最初の完了だけを数える。
+func completionThroughput(repo persistence.WorkspaceRepository, nodes *progressNodes, now time.Time, windowDays int) ([]ThroughputBucket, error) {
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -(windowDays - 1))
+	buckets := make([]ThroughputBucket, windowDays)
+	for i := range buckets {
+		buckets[i].Date = start.AddDate(0, 0, i)
+	}
+
+	actions, err := repo.History().ListActions(start, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+	counted := make(map[string]bool)
+	for _, a := range actions {
+		if a.Kind != persistence.ActionTaskStatusChanged {
+			continue
+		}
+		var p persistence.TaskActionPayload
+		if err := a.DecodePayload(&p); err != nil {
+			continue
+		}
+		if !isCompletedTaskStatus(TaskStatus(strings.ToUpper(p.ToStatus))) || counted[p.TaskID] || isVerificationTask(&p.Task) {
+			continue
+		}
+		at := a.At.In(loc)
+		day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+		idx := int(math.Round(day.Sub(start).Hours() / 24))
+		if idx < 0 || idx >= windowDays {
+			continue
+		}
+		counted[p.TaskID] = true
+		buckets[idx].Tasks++
+		buckets[idx].Points += nodes.points(p.Task.NodeID)
+	}
+	return buckets, nil
+}
+
+// projectCompletion は残りポイントを 1 日あたりのポイントで割って見込み日時を返す
+func projectCompletion(remaining int, pointsPerDay float64, now time.Time) *time.Time {
+	if remaining <= 0 {
+		return &now
+	}
+	if pointsPerDay <= 0 {
+		return nil
+	}
+	days := float64(remaining) / pointsPerDay
+	projected := now.Add(time.Duration(days * float64(24*time.Hour)))
+	return &projected
+}
+
+// progressNodes はレポート作成中に読み込んだノード設計のキャッシュ
+type progressNodes struct {
+	repo  persistence.WorkspaceRepository
+	nodes map[string]*persistence.NodeDesign
+}
+
+func newProgressNodes(repo persistence.WorkspaceRepository) *progressNodes {
+	return &progressNodes{repo: repo, nodes: make(map[string]*persistence.NodeDesign)}
+}
+
+// get はノード設計を返す（読み込めないノードは空の設計として扱う）
+func (n *progressNodes) get(nodeID string) *persistence.NodeDesign {
+	if node, ok := n.nodes[nodeID]; ok {
+		return node
+	}
+	node := &persistence.NodeDesign{NodeID: nodeID}
+	if nodeID != "" {
+		if loaded, err := n.repo.Design().GetNode(nodeID); err == nil {
+			node = loaded
+		}
+	}
+	n.nodes[nodeID] = node
+	return node
+}
+
+// points はノードのストーリーポイントを返す（依存グラフの重みと同じく 1 未満は 1）
+func (n *progressNodes) points(nodeID string) int {
+	return max(n.get(nodeID).Estimate.StoryPoints, 1)
+}
+
+// PoolSummary は Pool ごとのタスク数の集計
+type PoolSummary struct {
+	PoolID  string         `json:"poolId"`
+	Running int            `json:"running"`
+	Queued  int            `json:"queued"` // PENDING と READY
+	Failed  int            `json:"failed"`
+	Total   int            `json:"total"`
+	Counts  map[string]int `json:"counts"` // ステータスごとのタスク数
+}
+
+// poolSummaries はタスクを Pool（inputs.pool_id、未指定は default）ごとに集計する
+func poolSummaries(tasks []persistence.TaskState) []PoolSummary {
+	byPool := make(map[string]*PoolSummary)
+	for _, ts := range tasks {
+		poolID := inputString(ts.Inputs, InputKeyPoolID)
+		if poolID == "" {
+			poolID = DefaultPoolID
+		}
+		summary, ok := byPool[poolID]
+		if !ok {
+			summary = &PoolSummary{PoolID: poolID, Counts: make(map[string]int)}
+			byPool[poolID] = summary
+		}
+		status := TaskStatus(strings.ToUpper(ts.Status))
+		switch status {
+		case TaskStatusRunning:
+			summary.Running++
+		case TaskStatusPending, TaskStatusReady:
+			summary.Queued++
+		case TaskStatusFailed:
+			summary.Failed++
+		}
+		summary.Counts[string(status)]++
+		summary.Total++
+	}
+
+	summaries := make([]PoolSummary, 0, len(byPool))
+	for _, poolID := range sortedKeys(byPool) {
+		summaries = append(summaries, *byPool[poolID])
+	}
+	return summaries
+}
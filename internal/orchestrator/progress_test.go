@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendCompletion(t *testing.T, repo persistence.WorkspaceRepository, at time.Time, task persistence.TaskState) {
+	t.Helper()
+	action, err := persistence.NewAction(persistence.ActionTaskStatusChanged, "ws", at, persistence.TaskActionPayload{
+		TaskID:     task.TaskID,
+		FromStatus: string(TaskStatusRunning),
+		ToStatus:   string(TaskStatusSucceeded),
+		Task:       task,
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.History().AppendAction(action))
+}
+
+func TestBuildProgressReport(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "n1", Milestone: "M1", PhaseName: "設計", Estimate: persistence.Estimate{StoryPoints: 3}},
+		{NodeID: "n2", Milestone: "M1", PhaseName: "実装", Estimate: persistence.Estimate{StoryPoints: 5}},
+		{NodeID: "n3", Milestone: "M2", PhaseName: "実装", Estimate: persistence.Estimate{StoryPoints: 2}},
+		{NodeID: "n4", Milestone: "M2", PhaseName: "実装"},
+	})
+	done1 := persistence.TaskState{TaskID: "t1", NodeID: "n1", Status: string(TaskStatusSucceeded)}
+	done2 := persistence.TaskState{TaskID: "t2", NodeID: "n2", Status: string(TaskStatusCompleted)}
+	saveState(t, repo, []persistence.TaskState{
+		done1,
+		done2,
+		{TaskID: "t3", NodeID: "n3", Status: string(TaskStatusRunning)},
+		{TaskID: "t4", NodeID: "n4", Status: string(TaskStatusCanceled)},
+		{TaskID: "t5", NodeID: "manual-t5", Status: string(TaskStatusPending), Inputs: map[string]interface{}{InputKeyPoolID: "codegen"}},
+		{TaskID: "v1", NodeID: "n1", Kind: TaskKindTest, Status: string(TaskStatusPending), Inputs: map[string]interface{}{InputKeyVerifiesTaskID: "t1"}},
+	}, nil)
+	appendCompletion(t, repo, now.AddDate(0, 0, -3), done1)
+	appendCompletion(t, repo, now.Add(-time.Hour), done2)
+	// 期間外と、完了し直した分は数えない
+	appendCompletion(t, repo, now.AddDate(0, 0, -30), done1)
+	appendCompletion(t, repo, now.Add(-time.Minute), done2)
+
+	report, err := BuildProgressReport(repo, now, 7)
+	require.NoError(t, err)
+
+	// 全体: t1(3)+t2(5) 完了、t3(2)+t5(1) 未完了、t4 はスコープ外、検証タスクは除外
+	assert.Equal(t, 11, report.Total.TotalPoints)
+	assert.Equal(t, 8, report.Total.CompletedPoints)
+	assert.Equal(t, 4, report.Total.TotalTasks)
+	assert.Equal(t, 2, report.Total.CompletedTasks)
+	assert.Equal(t, 1, report.Total.Counts[string(TaskStatusCanceled)])
+
+	require.Len(t, report.Milestones, 3)
+	assert.Equal(t, "", report.Milestones[0].Name)
+	assert.Equal(t, 1, report.Milestones[0].TotalPoints)
+	assert.Equal(t, "M1", report.Milestones[1].Name)
+	assert.Equal(t, 8, report.Milestones[1].CompletedPoints)
+	assert.Equal(t, 8, report.Milestones[1].TotalPoints)
+	assert.Equal(t, "M2", report.Milestones[2].Name)
+	assert.Equal(t, 2, report.Milestones[2].TotalPoints)
+	assert.Equal(t, 1, report.Milestones[2].TotalTasks)
+
+	require.Len(t, report.Phases, 3)
+	assert.Equal(t, "実装", report.Phases[1].Name)
+	assert.Equal(t, 7, report.Phases[1].TotalPoints)
+	assert.Equal(t, 5, report.Phases[1].CompletedPoints)
+
+	// Pool の集計は検証タスクも含む
+	require.Len(t, report.Pools, 2)
+	assert.Equal(t, "codegen", report.Pools[0].PoolID)
+	assert.Equal(t, 5, report.Pools[1].Total)
+
+	require.Len(t, report.Throughput, 7)
+	assert.Equal(t, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), report.Throughput[0].Date)
+	assert.Equal(t, 3, report.Throughput[3].Points)
+	assert.Equal(t, 1, report.Throughput[6].Tasks)
+	assert.Equal(t, 5, report.Throughput[6].Points)
+
+	// 8pt / 7 日 のペースで残り 3pt
+	assert.InDelta(t, 8.0/7.0, report.PointsPerDay, 1e-9)
+	require.NotNil(t, report.ProjectedCompletion)
+	assert.Equal(t, now.Add(time.Duration(3/(8.0/7.0)*float64(24*time.Hour))), *report.ProjectedCompletion)
+}
+
+func TestBuildProgressReport_NoThroughput(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	now := time.Now()
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "t1", NodeID: "n1", Status: string(TaskStatusPending)},
+	}, nil)
+
+	report, err := BuildProgressReport(repo, now, 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProgressWindowDays, report.WindowDays)
+	assert.Len(t, report.Throughput, DefaultProgressWindowDays)
+	assert.Nil(t, report.ProjectedCompletion)
+
+	// 残りが無ければ完了見込みは現在
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "t1", NodeID: "n1", Status: string(TaskStatusSucceeded)},
+	}, nil)
+	report, err = BuildProgressReport(repo, now, 0)
+	require.NoError(t, err)
+	require.NotNil(t, report.ProjectedCompletion)
+	assert.True(t, now.Equal(*report.ProjectedCompletion))
+}
@@ -0,0 +1,248 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// 自動再計画: リトライを使い切ったタスクを、バックログへ送る前に Meta-agent（plan_patch）で計画し直す。
+// 再計画の結果は次のどちらかとして適用する。
+//   - retry: 元のタスクを更新した（受け入れ条件の調整・前提タスクの追加）ので、元のタスクを再実行する
+//   - split: 元のタスクを作成したタスクで置き換える（元のタスクは CANCELED、後続の依存は付け替える）
+
+// DefaultMaxReplansPerNode はノードごとの自動再計画の既定の上限
+const DefaultMaxReplansPerNode = 1
+
+// DefaultReplanFailureKinds は自動再計画の対象とする既定の失敗の分類
+// 認証・レート制限・サンドボックス基盤の失敗は計画を変えても解消しないため対象外。
+var DefaultReplanFailureKinds = []FailureKind{FailureTransient, FailureAgentGaveUp, FailureValidationFailed}
+
+// AutoReplanPolicy は retry-policies.json の autoReplan（自動再計画のポリシー）
+type AutoReplanPolicy struct {
+	Enabled           bool          `json:"enabled"`
+	MaxReplansPerNode int           `json:"maxReplansPerNode,omitempty"` // 0 は DefaultMaxReplansPerNode
+	FailureKinds      []FailureKind `json:"failureKinds,omitempty"`      // 空は DefaultReplanFailureKinds
+}
+
+// MaxReplans はノードごとの再計画の上限を返す
+func (p *AutoReplanPolicy) MaxReplans() int {
+	if p.MaxReplansPerNode > 0 {
+		return p.MaxReplansPerNode
+	}
+	return DefaultMaxReplansPerNode
+}
+
+// Allows は失敗の分類 kind のタスクを、これまでの再計画回数 replans から再計画してよいかを返す
+func (p *AutoReplanPolicy) Allows(kind FailureKind, replans int) bool {
+	if p == nil || !p.Enabled || replans >= p.MaxReplans() {
+		return false
+	}
+	kinds := p.FailureKinds
+	if len(kinds) == 0 {
+		kinds = DefaultReplanFailureKinds
+	}
+	return slices.Contains(kinds, kind)
+}
+
+// TaskReplanner は失敗したタスクを Meta-agent で計画し直す
+// 計画の変更（タスクの作成・元のタスクの更新）は plan_patch として適用済みの状態で返す。
+type TaskReplanner interface {
+	ReplanTask(ctx context.Context, req ReplanRequest) (*ReplanResult, error)
+}
+
+// ReplanRequest は再計画の依頼内容
+type ReplanRequest struct {
+	TaskID      string
+	FailureKind FailureKind
+	Error       string
+	Attempts    []Attempt // 失敗した試行（開始順）
+	Replan      int       // 何回目の再計画か（1 から）
+}
+
+// ReplanResult は再計画の結果
+type ReplanResult struct {
+	Understanding   string
+	CreatedTaskIDs  []string
+	UpdatedOriginal bool // 元のタスクを更新したか（false で作成したタスクがあれば分割とみなす）
+}
+
+// ReplanOutcome は再計画を元のタスクにどう適用したか
+type ReplanOutcome string
+
+const (
+	ReplanOutcomeRetry ReplanOutcome = "retry"
+	ReplanOutcomeSplit ReplanOutcome = "split"
+)
+
+// replanAttemptLimit は再計画の依頼に含める試行の数（新しいものから）
+const replanAttemptLimit = 5
+
+// SetTaskReplanner は自動再計画に使う Meta-agent を設定する
+// retry-policies.json の autoReplan が有効な場合のみ使われる。
+func (e *ExecutionOrchestrator) SetTaskReplanner(replanner TaskReplanner) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.replanner = replanner
+}
+
+// tryAutoReplan はポリシーが許せばタスクを再計画し、適用した結果を返す（再計画しなければ空）
+func (e *ExecutionOrchestrator) tryAutoReplan(task *persistence.TaskState, failure Failure, execErr error) (ReplanOutcome, error) {
+	e.stateMu.RLock()
+	replanner := e.replanner
+	var policy *AutoReplanPolicy
+	if e.retryPolicies != nil {
+		policy = e.retryPolicies.AutoReplan
+	}
+	e.stateMu.RUnlock()
+
+	if replanner == nil || policy == nil || !policy.Enabled {
+		return "", nil
+	}
+	// 呼び出し元のタスクは ID だけのことがあるので、再計画の回数は保存済みの状態から読む
+	inputs := task.Inputs
+	if state, err := e.Repo.State().LoadTasks(); err == nil {
+		if saved := findTaskState(state, task.TaskID); saved != nil {
+			inputs = saved.Inputs
+		}
+	}
+	replans := inputInt(inputs, InputKeyReplanCount)
+	if !policy.Allows(failure.Kind, replans) {
+		if replans >= policy.MaxReplans() {
+			e.logger.Info("auto replan limit reached",
+				slog.String("task_id", task.TaskID),
+				slog.Int("replans", replans),
+			)
+		}
+		return "", nil
+	}
+
+	attempts, err := ListTaskAttempts(e.Repo, task.TaskID)
+	if err != nil {
+		e.logger.Warn("failed to list attempts for replan", slog.String("task_id", task.TaskID), slog.Any("error", err))
+	}
+	if len(attempts) > replanAttemptLimit {
+		attempts = attempts[len(attempts)-replanAttemptLimit:]
+	}
+	req := ReplanRequest{
+		TaskID:      task.TaskID,
+		FailureKind: failure.Kind,
+		Attempts:    attempts,
+		Replan:      replans + 1,
+	}
+	if execErr != nil {
+		req.Error = execErr.Error()
+	}
+
+	e.logger.Info("replanning failed task",
+		slog.String("task_id", task.TaskID),
+		slog.String("failure_kind", string(failure.Kind)),
+		slog.Int("replan", req.Replan),
+	)
+	res, err := replanner.ReplanTask(context.Background(), req)
+	if err != nil {
+		return "", fmt.Errorf("failed to replan task: %w", err)
+	}
+	return e.applyReplan(task, req, res)
+}
+
+// applyReplan は再計画の結果を元のタスクに適用する
+// 計画の変更は plan_patch として記録済みなので、task.replanned を追記してから元のタスクと作成したタスクを更新する。
+func (e *ExecutionOrchestrator) applyReplan(task *persistence.TaskState, req ReplanRequest, res *ReplanResult) (ReplanOutcome, error) {
+	var outcome ReplanOutcome
+	switch {
+	case res.UpdatedOriginal:
+		outcome = ReplanOutcomeRetry
+	case len(res.CreatedTaskIDs) > 0:
+		outcome = ReplanOutcomeSplit
+	default:
+		return "", fmt.Errorf("failed to replan task: meta-agent changed nothing")
+	}
+
+	now := e.now()
+	historyAction, err := persistence.NewAction(persistence.ActionTaskReplanned, workspaceIDOf(e.Repo), now, persistence.TaskReplannedPayload{
+		TaskID:         task.TaskID,
+		FailureKind:    string(req.FailureKind),
+		Replan:         req.Replan,
+		Outcome:        string(outcome),
+		Understanding:  res.Understanding,
+		CreatedTaskIDs: res.CreatedTaskIDs,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := e.Repo.History().AppendAction(historyAction); err != nil {
+		return "", fmt.Errorf("failed to append history: %w", err)
+	}
+
+	nodeID := task.NodeID
+	if nodeID == "" {
+		nodeID = task.TaskID
+	}
+	if outcome == ReplanOutcomeSplit {
+		if err := replaceNodeDependency(e.Repo, nodeID, res.CreatedTaskIDs, now, nil); err != nil {
+			recordStateSaveFailed(e.Repo, e.logger, historyAction.ID, "save_node_design", err)
+			return "", err
+		}
+		note := "replanned into " + strings.Join(res.CreatedTaskIDs, ", ")
+		if err := setNodeRuntimeStatus(e.Repo, nodeID, persistence.NodeRuntimeStatusObsolete, "replan", note, now, nil); err != nil {
+			recordStateSaveFailed(e.Repo, e.logger, historyAction.ID, "save_nodes_runtime", err)
+			return "", err
+		}
+	}
+
+	var oldStatus, newStatus TaskStatus
+	err = e.Repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+		t := findTaskState(state, task.TaskID)
+		if t == nil {
+			return fmt.Errorf("task not found: %s", task.TaskID)
+		}
+		if t.Inputs == nil {
+			t.Inputs = make(map[string]interface{})
+		}
+		oldStatus = TaskStatus(t.Status)
+		t.Inputs[InputKeyReplanCount] = req.Replan
+		t.UpdatedAt = now
+		if outcome == ReplanOutcomeRetry {
+			// 計画を変えたので試行回数を数え直す
+			delete(t.Inputs, InputKeyAttemptCount)
+			delete(t.Inputs, InputKeyNextRetryAt)
+			t.Status = string(TaskStatusPending)
+			t.DoneAt = nil
+		} else {
+			t.Status = string(TaskStatusCanceled)
+			t.DoneAt = &now
+		}
+		newStatus = TaskStatus(t.Status)
+
+		// 作成したタスクは再計画の回数を引き継ぐ（分割の繰り返しを上限で止める）
+		for _, id := range res.CreatedTaskIDs {
+			if created := findTaskState(state, id); created != nil {
+				if created.Inputs == nil {
+					created.Inputs = make(map[string]interface{})
+				}
+				created.Inputs[InputKeyReplanCount] = req.Replan
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		recordStateSaveFailed(e.Repo, e.logger, historyAction.ID, "save_tasks_state", err)
+		return "", fmt.Errorf("failed to save tasks state: %w", err)
+	}
+
+	e.logger.Info("task replanned",
+		slog.String("task_id", task.TaskID),
+		slog.String("outcome", string(outcome)),
+		slog.Int("created_tasks", len(res.CreatedTaskIDs)),
+	)
+	if newStatus != oldStatus {
+		e.emitTaskStateChange(task.TaskID, oldStatus, newStatus)
+	}
+	e.triggerDependencyResolution()
+	return outcome, nil
+}
@@ -0,0 +1,126 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTaskReplanner struct {
+	repo     persistence.WorkspaceRepository
+	result   ReplanResult
+	requests []ReplanRequest
+}
+
+func (f *fakeTaskReplanner) ReplanTask(_ context.Context, req ReplanRequest) (*ReplanResult, error) {
+	f.requests = append(f.requests, req)
+	now := time.Now()
+	for _, id := range f.result.CreatedTaskIDs {
+		if err := f.repo.Design().SaveNode(&persistence.NodeDesign{NodeID: id, Name: id}); err != nil {
+			return nil, err
+		}
+		err := f.repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+			state.Tasks = append(state.Tasks, persistence.TaskState{TaskID: id, NodeID: id, Status: string(TaskStatusPending), CreatedAt: now})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	res := f.result
+	return &res, nil
+}
+
+func TestAutoReplanPolicy_Allows(t *testing.T) {
+	var disabled *AutoReplanPolicy
+	assert.False(t, disabled.Allows(FailureTransient, 0))
+	assert.False(t, (&AutoReplanPolicy{}).Allows(FailureTransient, 0))
+
+	policy := &AutoReplanPolicy{Enabled: true}
+	assert.True(t, policy.Allows(FailureAgentGaveUp, 0))
+	assert.False(t, policy.Allows(FailureAgentGaveUp, DefaultMaxReplansPerNode))
+	assert.False(t, policy.Allows(FailureAuth, 0), "auth failures are not replanned by default")
+
+	policy = &AutoReplanPolicy{Enabled: true, MaxReplansPerNode: 2, FailureKinds: []FailureKind{FailureAuth}}
+	assert.True(t, policy.Allows(FailureAuth, 1))
+	assert.False(t, policy.Allows(FailureTransient, 0))
+}
+
+func TestHandleFailure_AutoReplan(t *testing.T) {
+	newOrchestrator := func(t *testing.T) (*ExecutionOrchestrator, persistence.WorkspaceRepository, *BacklogStore) {
+		repo, queue := setupTestRepo(t)
+		backlogStore := NewBacklogStore(repo.BaseDir())
+		orch := NewExecutionOrchestrator(nil, nil, repo, queue, nil, backlogStore, []string{"default"})
+		orch.SetRetryPolicies(&RetryPoliciesConfig{AutoReplan: &AutoReplanPolicy{Enabled: true}})
+		saveDesign(t, repo, []persistence.NodeDesign{
+			{NodeID: "task-1"},
+			{NodeID: "task-2", Dependencies: []string{"task-1"}},
+		})
+		require.NoError(t, repo.Design().SaveWBS(&persistence.WBS{NodeIndex: []persistence.NodeIndex{
+			{NodeID: "task-1"}, {NodeID: "task-2"},
+		}}))
+		saveState(t, repo, []persistence.TaskState{
+			{TaskID: "task-1", NodeID: "task-1", Kind: "implementation", Status: string(TaskStatusRunning),
+				Inputs: map[string]interface{}{InputKeyAttemptCount: 3}},
+			{TaskID: "task-2", NodeID: "task-2", Kind: "implementation", Status: string(TaskStatusBlocked)},
+		}, nil)
+		return orch, repo, backlogStore
+	}
+	gaveUp := &ExecutionError{Kind: FailureAgentGaveUp, Err: fmt.Errorf("agent gave up")}
+
+	t.Run("retry when the original task is updated", func(t *testing.T) {
+		orch, repo, backlogStore := newOrchestrator(t)
+		replanner := &fakeTaskReplanner{repo: repo, result: ReplanResult{UpdatedOriginal: true}}
+		orch.SetTaskReplanner(replanner)
+
+		require.NoError(t, orch.HandleFailure(&persistence.TaskState{TaskID: "task-1", NodeID: "task-1", Kind: "implementation"}, gaveUp, 3))
+		require.Len(t, replanner.requests, 1)
+		assert.Equal(t, FailureAgentGaveUp, replanner.requests[0].FailureKind)
+		assert.Equal(t, 1, replanner.requests[0].Replan)
+
+		task := loadTaskState(t, repo, "task-1")
+		assert.Equal(t, string(TaskStatusPending), task.Status)
+		assert.Equal(t, 1, inputInt(task.Inputs, InputKeyReplanCount))
+		assert.Equal(t, 0, inputInt(task.Inputs, InputKeyAttemptCount))
+		items, err := backlogStore.ListUnresolved()
+		require.NoError(t, err)
+		assert.Empty(t, items)
+
+		// 上限に達したらバックログへ送る
+		require.NoError(t, orch.HandleFailure(&persistence.TaskState{TaskID: "task-1", NodeID: "task-1", Kind: "implementation"}, gaveUp, 3))
+		assert.Len(t, replanner.requests, 1)
+		items, err = backlogStore.ListUnresolved()
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "task-1", items[0].TaskID)
+	})
+
+	t.Run("split replaces the original task", func(t *testing.T) {
+		orch, repo, _ := newOrchestrator(t)
+		replanner := &fakeTaskReplanner{repo: repo, result: ReplanResult{CreatedTaskIDs: []string{"task-1a", "task-1b"}}}
+		orch.SetTaskReplanner(replanner)
+
+		require.NoError(t, orch.HandleFailure(&persistence.TaskState{TaskID: "task-1", NodeID: "task-1", Kind: "implementation"}, gaveUp, 3))
+
+		assert.Equal(t, string(TaskStatusCanceled), loadTaskState(t, repo, "task-1").Status)
+		assert.Equal(t, 1, inputInt(loadTaskState(t, repo, "task-1a").Inputs, InputKeyReplanCount))
+		node, err := repo.Design().GetNode("task-2")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"task-1a", "task-1b"}, node.Dependencies)
+
+		actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		var replanned *persistence.Action
+		for i := range actions {
+			if actions[i].Kind == persistence.ActionTaskReplanned {
+				replanned = &actions[i]
+			}
+		}
+		require.NotNil(t, replanned)
+	})
+}
@@ -1,7 +1,11 @@
 package orchestrator
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -27,6 +31,7 @@ func DefaultRetryPolicy() *RetryPolicy {
 
 // CalculateBackoff は次のリトライまでの待機時間を計算する
 // attemptNumber は 1 から始まる試行回数
+// 時刻は読まない（再実行時刻は ExecutionOrchestrator が Clock の現在時刻に加えて決める）。
 func (p *RetryPolicy) CalculateBackoff(attemptNumber int) time.Duration {
 	if attemptNumber <= 0 {
 		attemptNumber = 1
@@ -70,3 +75,142 @@ func (p *RetryPolicy) DetermineNextAction(attemptNumber int) NextAction {
 
 	return NextActionFail
 }
+
+// RetryPoliciesFileName はワークスペース直下のリトライポリシー設定ファイル名
+const RetryPoliciesFileName = "retry-policies.json"
+
+// RetryPolicySpec は設定ファイルでのリトライポリシーの指定
+// ゼロ値のフィールドは適用先のポリシーの値を引き継ぐ。MaxAttempts: 1 はリトライしない。
+type RetryPolicySpec struct {
+	MaxAttempts    int     `json:"maxAttempts,omitempty"`
+	BackoffBaseSec int     `json:"backoffBaseSec,omitempty"`
+	BackoffMaxSec  int     `json:"backoffMaxSec,omitempty"`
+	BackoffFactor  float64 `json:"backoffFactor,omitempty"`
+	RequireHuman   *bool   `json:"requireHuman,omitempty"`
+}
+
+// applyTo は spec で指定されたフィールドを p に上書きする
+func (spec RetryPolicySpec) applyTo(p *RetryPolicy) {
+	if spec.MaxAttempts > 0 {
+		p.MaxAttempts = spec.MaxAttempts
+	}
+	if spec.BackoffBaseSec > 0 {
+		p.BackoffBase = time.Duration(spec.BackoffBaseSec) * time.Second
+	}
+	if spec.BackoffMaxSec > 0 {
+		p.BackoffMax = time.Duration(spec.BackoffMaxSec) * time.Second
+	}
+	if spec.BackoffFactor > 0 {
+		p.BackoffFactor = spec.BackoffFactor
+	}
+	if spec.RequireHuman != nil {
+		p.RequireHuman = *spec.RequireHuman
+	}
+}
+
+// RetryRule は失敗の分類・タスク種別ごとのリトライポリシー
+// 指定された条件はすべて AND で評価し、各条件内の値は OR で評価する（PoolRoute と同じ）。
+// 条件が 1 つも指定されていないルールは何にもマッチしない。
+type RetryRule struct {
+	FailureKinds []FailureKind   `json:"failureKinds,omitempty"`
+	TaskKinds    []string        `json:"taskKinds,omitempty"` // TaskState.Kind
+	Policy       RetryPolicySpec `json:"policy"`
+}
+
+func (r RetryRule) matches(taskKind string, kind FailureKind) bool {
+	if len(r.FailureKinds) == 0 && len(r.TaskKinds) == 0 {
+		return false
+	}
+	if len(r.FailureKinds) > 0 {
+		matched := false
+		for _, k := range r.FailureKinds {
+			if k == kind {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.TaskKinds) > 0 && !containsString(r.TaskKinds, taskKind) {
+		return false
+	}
+	return true
+}
+
+// RetryPoliciesConfig は retry-policies.json の内容を表す
+type RetryPoliciesConfig struct {
+	Default *RetryPolicySpec `json:"default,omitempty"` // すべての失敗に適用する基準値
+	Rules   []RetryRule      `json:"rules,omitempty"`   // 先頭から最初にマッチしたルールを適用する
+
+	// AutoReplan はリトライを使い切ったタスクをバックログへ送る前に Meta-agent で再計画するポリシー（nil で無効）
+	AutoReplan *AutoReplanPolicy `json:"autoReplan,omitempty"`
+}
+
+// DefaultFailurePolicies は失敗の分類ごとの既定のリトライポリシー（retry-policies.json で上書きできる）
+// 認証の失敗とエージェントの中断は再実行しても解消しないため、すぐにバックログへ送る。
+var DefaultFailurePolicies = map[FailureKind]RetryPolicySpec{
+	FailureRateLimit:        {MaxAttempts: 5, BackoffBaseSec: 60, BackoffMaxSec: 15 * 60},
+	FailureAuth:             {MaxAttempts: 1},
+	FailureSandboxInfra:     {MaxAttempts: 3, BackoffBaseSec: 30},
+	FailureAgentGaveUp:      {MaxAttempts: 1},
+	FailureValidationFailed: {MaxAttempts: 2},
+}
+
+// LoadRetryPoliciesConfig はワークスペースの retry-policies.json を読み込む
+// ファイルが存在しない場合は空の設定（既定のポリシーのみ）を返す。
+func LoadRetryPoliciesConfig(workspaceDir string) (*RetryPoliciesConfig, error) {
+	path := filepath.Join(workspaceDir, RetryPoliciesFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RetryPoliciesConfig{}, nil
+		}
+		return &RetryPoliciesConfig{}, fmt.Errorf("failed to read %s: %w", RetryPoliciesFileName, err)
+	}
+
+	var cfg RetryPoliciesConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return &RetryPoliciesConfig{}, fmt.Errorf("failed to parse %s: %w", RetryPoliciesFileName, err)
+	}
+	for i, rule := range cfg.Rules {
+		for _, kind := range rule.FailureKinds {
+			if !kind.IsValid() {
+				return &RetryPoliciesConfig{}, fmt.Errorf("invalid %s: rules[%d]: unknown failure kind %q", RetryPoliciesFileName, i, kind)
+			}
+		}
+	}
+	if cfg.AutoReplan != nil {
+		for _, kind := range cfg.AutoReplan.FailureKinds {
+			if !kind.IsValid() {
+				return &RetryPoliciesConfig{}, fmt.Errorf("invalid %s: autoReplan: unknown failure kind %q", RetryPoliciesFileName, kind)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// PolicyFor はタスク種別と失敗の分類に適用するリトライポリシーを返す
+// base（nil なら DefaultRetryPolicy）に default、分類ごとの既定値、最初にマッチしたルールの順で上書きする。
+func (c *RetryPoliciesConfig) PolicyFor(base *RetryPolicy, taskKind string, kind FailureKind) *RetryPolicy {
+	if base == nil {
+		base = DefaultRetryPolicy()
+	}
+	policy := *base
+	if c != nil && c.Default != nil {
+		c.Default.applyTo(&policy)
+	}
+	if spec, ok := DefaultFailurePolicies[kind]; ok {
+		spec.applyTo(&policy)
+	}
+	if c != nil {
+		for _, rule := range c.Rules {
+			if rule.matches(taskKind, kind) {
+				rule.Policy.applyTo(&policy)
+				break
+			}
+		}
+	}
+	return &policy
+}
@@ -1,10 +1,13 @@
 package orchestrator
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaultRetryPolicy(t *testing.T) {
@@ -147,3 +150,63 @@ func TestRetryPolicy_DetermineNextAction(t *testing.T) {
 		assert.Equal(t, NextActionFail, policy.DetermineNextAction(4))
 	})
 }
+
+func TestRetryPoliciesConfig_PolicyFor(t *testing.T) {
+	base := DefaultRetryPolicy()
+
+	t.Run("built-in defaults per failure kind", func(t *testing.T) {
+		var cfg *RetryPoliciesConfig
+		assert.Equal(t, *base, *cfg.PolicyFor(base, "implementation", FailureTransient))
+
+		auth := cfg.PolicyFor(base, "implementation", FailureAuth)
+		assert.Equal(t, NextActionBacklog, auth.DetermineNextAction(1))
+
+		rateLimit := cfg.PolicyFor(base, "implementation", FailureRateLimit)
+		assert.Equal(t, 5, rateLimit.MaxAttempts)
+		assert.Equal(t, time.Minute, rateLimit.BackoffBase)
+	})
+
+	t.Run("rules by failure kind and task kind", func(t *testing.T) {
+		noHuman := false
+		cfg := &RetryPoliciesConfig{
+			Default: &RetryPolicySpec{BackoffBaseSec: 1},
+			Rules: []RetryRule{
+				{TaskKinds: []string{"test"}, FailureKinds: []FailureKind{FailureValidationFailed}, Policy: RetryPolicySpec{MaxAttempts: 4}},
+				{FailureKinds: []FailureKind{FailureAuth}, Policy: RetryPolicySpec{RequireHuman: &noHuman}},
+				{Policy: RetryPolicySpec{MaxAttempts: 99}}, // 条件の無いルールはマッチしない
+			},
+		}
+
+		validation := cfg.PolicyFor(base, "test", FailureValidationFailed)
+		assert.Equal(t, 4, validation.MaxAttempts)
+		assert.Equal(t, time.Second, validation.BackoffBase)
+		assert.Equal(t, 2, cfg.PolicyFor(base, "implementation", FailureValidationFailed).MaxAttempts)
+
+		auth := cfg.PolicyFor(base, "implementation", FailureAuth)
+		assert.Equal(t, NextActionFail, auth.DetermineNextAction(1))
+
+		assert.Equal(t, base.MaxAttempts, cfg.PolicyFor(base, "implementation", FailureTransient).MaxAttempts)
+	})
+}
+
+func TestLoadRetryPoliciesConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadRetryPoliciesConfig(dir)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Rules)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, RetryPoliciesFileName), []byte(`{
+		"default": {"maxAttempts": 4},
+		"rules": [{"failureKinds": ["rate_limit"], "policy": {"maxAttempts": 8, "backoffBaseSec": 120}}]
+	}`), 0644))
+	cfg, err = LoadRetryPoliciesConfig(dir)
+	require.NoError(t, err)
+	policy := cfg.PolicyFor(nil, "", FailureRateLimit)
+	assert.Equal(t, 8, policy.MaxAttempts)
+	assert.Equal(t, 2*time.Minute, policy.BackoffBase)
+	assert.Equal(t, 4, cfg.PolicyFor(nil, "", FailureTransient).MaxAttempts)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, RetryPoliciesFileName), []byte(`{"rules": [{"failureKinds": ["oops"]}]}`), 0644))
+	_, err = LoadRetryPoliciesConfig(dir)
+	assert.ErrorContains(t, err, "unknown failure kind")
+}
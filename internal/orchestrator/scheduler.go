@@ -1,6 +1,7 @@
 package orchestrator
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -14,75 +15,257 @@ import (
 type Scheduler struct {
 	Repo   persistence.WorkspaceRepository
 	Queue  *ipc.FilesystemQueue
-	logger *slog.Logger
-	events EventEmitter
+	Router *PoolRouter
+	// Approvals は承認ゲートの設定、Backlog は承認を依頼するバックログ（nil なら承認ゲートを使わない）
+	Approvals *ApprovalConfig
+	Backlog   *BacklogStore
+	logger    *slog.Logger
+	events    EventEmitter
+	// clock はリトライ待ちの判定と記録する時刻の取得元（既定は Repo の Clock）
+	clock persistence.Clock
 }
 
 // NewScheduler creates a new Scheduler.
+// Pool routing rules are loaded from the workspace's worker-pools.json,
+// and approval gates from approvals.json.
 func NewScheduler(repo persistence.WorkspaceRepository, q *ipc.FilesystemQueue, events EventEmitter) *Scheduler {
+	logger := logging.WithComponent(slog.Default(), "scheduler")
+	router := NewPoolRouter(nil)
+	var (
+		approvals *ApprovalConfig
+		backlog   *BacklogStore
+	)
+	clock := persistence.SystemClock
+	if repo != nil {
+		clock = repo.Clock()
+		cfg, err := LoadWorkerPoolsConfig(repo.BaseDir())
+		if err != nil {
+			logger.Warn("failed to load worker pools config, using defaults", slog.Any("error", err))
+		}
+		router = NewPoolRouter(cfg)
+		if approvals, err = LoadApprovalConfig(repo.BaseDir()); err != nil {
+			logger.Warn("failed to load approvals config, using node approvals only", slog.Any("error", err))
+		}
+		backlog = NewBacklogStore(repo.BaseDir())
+	}
 	return &Scheduler{
-		Repo:   repo,
-		Queue:  q,
-		logger: logging.WithComponent(slog.Default(), "scheduler"),
-		events: events,
+		Repo:      repo,
+		Queue:     q,
+		Router:    router,
+		Approvals: approvals,
+		Backlog:   backlog,
+		logger:    logger,
+		events:    events,
+		clock:     clock,
 	}
 }
 
+// SetClock replaces the clock used for retry timing and recorded timestamps (nil uses real time).
+func (s *Scheduler) SetClock(clock persistence.Clock) {
+	if clock == nil {
+		clock = persistence.SystemClock
+	}
+	s.clock = clock
+}
+
+// SetPoolRouter replaces the pool router used when enqueueing jobs.
+func (s *Scheduler) SetPoolRouter(router *PoolRouter) {
+	s.Router = router
+}
+
+// ErrTaskAlreadyQueued はタスクが既にキューにある（READY）か実行中であることを表す
+var ErrTaskAlreadyQueued = errors.New("task is already queued or running")
+
 // ScheduleTask schedules a task for execution.
 func (s *Scheduler) ScheduleTask(taskID string) error {
-	tasksState, err := s.Repo.State().LoadTasks()
+	// 開始の承認が必要なタスクは承認されるまで BLOCKED で待たせる
+	approvalID, err := s.requestStartApproval(taskID)
 	if err != nil {
-		return fmt.Errorf("failed to load tasks: %w", err)
+		return err
+	}
+	if approvalID != "" {
+		return fmt.Errorf("%w: %s", ErrApprovalRequired, approvalID)
 	}
 
-	var task *persistence.TaskState
-	// taskIndex is not needed if we iterate again or save whole state
-	for i := range tasksState.Tasks {
-		if tasksState.Tasks[i].TaskID == taskID {
-			task = &tasksState.Tasks[i]
-			break
+	var (
+		change     *taskStatusChange
+		blocked    bool
+		waitReason string
+		queued     TaskStatus
+		task       persistence.TaskState
+	)
+	err = s.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		change, blocked, waitReason, queued = nil, false, "", ""
+		t := findTaskState(tasksState, taskID)
+		if t == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+
+		// 並行して解決した依存が同じタスクを二重にキューへ入れないよう、状態は更新の中で確かめる
+		if status := TaskStatus(t.Status); status == TaskStatusReady || status == TaskStatusRunning {
+			queued = status
+			return persistence.ErrNoChange
 		}
-	}
-	if task == nil {
-		return fmt.Errorf("task not found: %s", taskID)
-	}
 
-	// 依存関係をチェック
-	if !s.allDependenciesSatisfied(task) {
-		if TaskStatus(task.Status) != TaskStatusBlocked {
-			oldStatus := TaskStatus(task.Status)
-			task.Status = string(TaskStatusBlocked)
-			if err := s.Repo.State().SaveTasks(tasksState); err != nil {
-				return fmt.Errorf("failed to update task status: %w", err)
+		// 依存関係をチェック
+		if !s.allDependenciesSatisfied(t) {
+			blocked = true
+			if TaskStatus(t.Status) == TaskStatusBlocked {
+				return persistence.ErrNoChange
 			}
-			s.emitStateChange(task.TaskID, oldStatus, TaskStatusBlocked)
+			change = &taskStatusChange{TaskID: t.TaskID, Old: TaskStatus(t.Status), New: TaskStatusBlocked}
+			t.Status = string(TaskStatusBlocked)
+			return nil
 		}
-		return fmt.Errorf("task has unsatisfied dependencies")
-	}
 
-	// Update to READY
-	oldStatus := TaskStatus(task.Status)
-	task.Status = string(TaskStatusReady)
-	if err := s.Repo.State().SaveTasks(tasksState); err != nil {
+		// 書き込み先が READY / RUNNING のタスクと重なる間は状態を変えずに待たせる
+		conflicts := fileLockTable(s.Repo, tasksState, t.TaskID).Conflicts(t.TaskID, LoadTaskWriteSet(s.Repo, t).Paths())
+		if len(conflicts) > 0 {
+			waitReason = FormatWaitReason(conflicts)
+			if inputString(t.Inputs, InputKeyWaitReason) == waitReason {
+				return persistence.ErrNoChange
+			}
+			if t.Inputs == nil {
+				t.Inputs = make(map[string]interface{})
+			}
+			t.Inputs[InputKeyWaitReason] = waitReason
+			return nil
+		}
+		delete(t.Inputs, InputKeyWaitReason)
+
+		// Update to READY
+		change = &taskStatusChange{TaskID: t.TaskID, Old: TaskStatus(t.Status), New: TaskStatusReady}
+		t.Status = string(TaskStatusReady)
+		task = *t
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
-	s.emitStateChange(task.TaskID, oldStatus, TaskStatusReady)
+	if change != nil {
+		s.emitStateChange(change.TaskID, change.Old, change.New)
+	}
+	if queued != "" {
+		return fmt.Errorf("%w: %s", ErrTaskAlreadyQueued, queued)
+	}
+	if blocked {
+		return fmt.Errorf("task has unsatisfied dependencies")
+	}
+	if waitReason != "" {
+		s.logger.Debug("task waiting for file lock",
+			slog.String("task_id", taskID),
+			slog.String("reason", waitReason),
+		)
+		return fmt.Errorf("%w: %s", ErrFileConflict, waitReason)
+	}
 
 	// Create a job for the queue
 	job := &ipc.Job{
+		// ジョブ ID はキューのファイル名を一意にするためのもので、仮想時刻では重複し得るため実時間を使う
 		ID:      fmt.Sprintf("job-%s-%d", task.TaskID, time.Now().UnixNano()),
 		TaskID:  task.TaskID,
-		PoolID:  "default", // taskState.PoolID missing? Assuming default.
+		PoolID:  s.routeTask(&task),
 		Payload: map[string]string{"action": "run_task"},
 	}
 
 	if err := s.Queue.Enqueue(job); err != nil {
+		// キューに入っていない READY のタスクは二度とスケジュールされないため、元の状態に戻す
+		s.revertReady(change)
 		return fmt.Errorf("failed to enqueue job: %w", err)
 	}
-	s.logger.Info("task scheduled", slog.String("task_id", task.TaskID))
+	s.logger.Info("task scheduled",
+		slog.String("task_id", task.TaskID),
+		slog.String("pool_id", job.PoolID),
+	)
+	return nil
+}
+
+// revertReady は READY にしたがキューへ入れられなかったタスクを遷移前の状態に戻す
+// 戻す間に他の書き込み手が状態を変えていれば（READY でなくなっていれば）そのままにする。
+func (s *Scheduler) revertReady(change *taskStatusChange) {
+	reverted := false
+	err := s.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		reverted = false
+		t := findTaskState(tasksState, change.TaskID)
+		if t == nil || TaskStatus(t.Status) != TaskStatusReady {
+			return persistence.ErrNoChange
+		}
+		t.Status = string(change.Old)
+		reverted = true
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to revert task status after enqueue failure",
+			slog.String("task_id", change.TaskID),
+			slog.Any("error", err),
+		)
+		return
+	}
+	if reverted {
+		s.emitStateChange(change.TaskID, TaskStatusReady, change.Old)
+	}
+}
+
+// taskStatusChange は UpdateTasks 内で行った状態遷移（保存成功後にイベント発行する）
+type taskStatusChange struct {
+	TaskID string
+	Old    TaskStatus
+	New    TaskStatus
+}
+
+// findTaskState は tasksState から taskID のタスクを探す
+func findTaskState(tasksState *persistence.TasksState, taskID string) *persistence.TaskState {
+	for i := range tasksState.Tasks {
+		if tasksState.Tasks[i].TaskID == taskID {
+			return &tasksState.Tasks[i]
+		}
+	}
 	return nil
 }
 
+// transitionTasks は UpdateTasks 内で各タスクに next を適用し、遷移したタスクをまとめて保存する
+// next は新しいステータスと遷移するかを返す。保存成功後に遷移イベントを発行する。
+func (s *Scheduler) transitionTasks(next func(task *persistence.TaskState) (TaskStatus, bool)) ([]taskStatusChange, error) {
+	var changes []taskStatusChange
+	err := s.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		changes = nil
+		for i := range tasksState.Tasks {
+			task := &tasksState.Tasks[i]
+			newStatus, ok := next(task)
+			if !ok {
+				continue
+			}
+			changes = append(changes, taskStatusChange{TaskID: task.TaskID, Old: TaskStatus(task.Status), New: newStatus})
+			task.Status = string(newStatus)
+		}
+		if len(changes) == 0 {
+			return persistence.ErrNoChange
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		s.emitStateChange(c.TaskID, c.Old, c.New)
+	}
+	return changes, nil
+}
+
+// routeTask は PoolRouter を使ってタスクの投入先 Pool を決定する
+func (s *Scheduler) routeTask(task *persistence.TaskState) string {
+	if s.Router == nil {
+		return DefaultPoolID
+	}
+	var node *persistence.NodeDesign
+	if task.NodeID != "" {
+		if n, err := s.Repo.Design().GetNode(task.NodeID); err == nil {
+			node = n
+		}
+	}
+	return s.Router.Route(task, node)
+}
+
 // allDependenciesSatisfied checks if all dependencies (Node-level) are satisfied.
 func (s *Scheduler) allDependenciesSatisfied(task *persistence.TaskState) bool {
 	// 1. Get NodeDesign for dependencies
@@ -108,7 +291,12 @@ func (s *Scheduler) allDependenciesSatisfied(task *persistence.TaskState) bool {
 	for _, nr := range nodesRuntime.Nodes {
 		// 定数を使用してステータス比較（スペルミス防止）
 		status := persistence.NodeRuntimeStatus(nr.Status)
-		if status.IsCompleted() {
+		if node.RequiresVerified {
+			// 検証を要求するノードは implemented では実行しない
+			if status == persistence.NodeRuntimeStatusVerified || status == persistence.NodeRuntimeStatusSkipped {
+				completedNodes[nr.NodeID] = true
+			}
+		} else if status.IsCompleted() {
 			completedNodes[nr.NodeID] = true
 		}
 	}
@@ -119,7 +307,8 @@ func (s *Scheduler) allDependenciesSatisfied(task *persistence.TaskState) bool {
 		}
 	}
 
-	return true
+	// 承認が必要なマイルストーンをまたぐ依存は、マイルストーンが承認されるまで満たされない
+	return s.milestoneGatesPassed(node)
 }
 
 // ScheduleReadyTasks schedules all pending tasks that have satisfied dependencies.
@@ -145,122 +334,92 @@ func (s *Scheduler) ScheduleReadyTasks() ([]string, error) {
 }
 
 // UpdateBlockedTasks は BLOCKED 状態のタスクで依存が満たされたものを PENDING に戻す
+// QUESTION への回答・承認ゲートを待っているタスクはバックログで解決されるまで戻さない。
 func (s *Scheduler) UpdateBlockedTasks() ([]string, error) {
-	tasksState, err := s.Repo.State().LoadTasks()
+	changes, err := s.transitionTasks(func(task *persistence.TaskState) (TaskStatus, bool) {
+		if TaskStatus(task.Status) != TaskStatusBlocked || inputString(task.Inputs, InputKeyAwaitingAnswer) != "" || waitingForApproval(task) {
+			return "", false
+		}
+		if !s.allDependenciesSatisfied(task) {
+			return "", false
+		}
+		return TaskStatusPending, true
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to load tasks state: %w", err)
+		return nil, fmt.Errorf("failed to unblock tasks: %w", err)
 	}
 
 	unblocked := []string{}
-	for i := range tasksState.Tasks {
-		task := &tasksState.Tasks[i]
-		if TaskStatus(task.Status) == TaskStatusBlocked {
-			if s.allDependenciesSatisfied(task) {
-				oldStatus := TaskStatus(task.Status)
-				task.Status = string(TaskStatusPending)
-				if err := s.Repo.State().SaveTasks(tasksState); err != nil {
-					s.logger.Warn("failed to unblock task",
-						slog.String("task_id", task.TaskID),
-						slog.Any("error", err),
-					)
-					continue
-				}
-				s.emitStateChange(task.TaskID, oldStatus, TaskStatusPending)
-				unblocked = append(unblocked, task.TaskID)
-				s.logger.Info("task unblocked",
-					slog.String("task_id", task.TaskID),
-				)
-			}
-		}
+	for _, c := range changes {
+		unblocked = append(unblocked, c.TaskID)
+		s.logger.Info("task unblocked",
+			slog.String("task_id", c.TaskID),
+		)
 	}
-
 	return unblocked, nil
 }
 
 // SetBlockedStatusForPendingWithUnsatisfiedDeps は依存が満たされていない PENDING タスクを BLOCKED に設定する
 func (s *Scheduler) SetBlockedStatusForPendingWithUnsatisfiedDeps() ([]string, error) {
-	tasksState, err := s.Repo.State().LoadTasks()
+	changes, err := s.transitionTasks(func(task *persistence.TaskState) (TaskStatus, bool) {
+		if TaskStatus(task.Status) != TaskStatusPending || s.allDependenciesSatisfied(task) {
+			return "", false
+		}
+		return TaskStatusBlocked, true
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to load tasks state: %w", err)
+		return nil, fmt.Errorf("failed to set tasks to blocked: %w", err)
 	}
 
 	blocked := []string{}
-	for i := range tasksState.Tasks {
-		task := &tasksState.Tasks[i]
-		if TaskStatus(task.Status) == TaskStatusPending {
-			if !s.allDependenciesSatisfied(task) {
-				oldStatus := TaskStatus(task.Status)
-				task.Status = string(TaskStatusBlocked)
-				if err := s.Repo.State().SaveTasks(tasksState); err != nil {
-					s.logger.Warn("failed to set task to blocked",
-						slog.String("task_id", task.TaskID),
-						slog.Any("error", err),
-					)
-					continue
-				}
-				s.emitStateChange(task.TaskID, oldStatus, TaskStatusBlocked)
-				blocked = append(blocked, task.TaskID)
-				s.logger.Info("task set to blocked",
-					slog.String("task_id", task.TaskID),
-				)
-			}
-		}
+	for _, c := range changes {
+		blocked = append(blocked, c.TaskID)
+		s.logger.Info("task set to blocked",
+			slog.String("task_id", c.TaskID),
+		)
 	}
-
 	return blocked, nil
 }
 
 // ResetRetryTasks checks for tasks in RETRY_WAIT status that are ready to be retried
 // (NextRetryAt <= now) and resets them to PENDING.
 func (s *Scheduler) ResetRetryTasks() ([]string, error) {
-	tasksState, err := s.Repo.State().LoadTasks()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load tasks state: %w", err)
-	}
-
-	now := time.Now()
-	reset := []string{}
-
-	for i := range tasksState.Tasks {
-		task := &tasksState.Tasks[i]
-		if TaskStatus(task.Status) == TaskStatusRetryWait {
-			// Check retry time from Inputs (Workaround as models.go lacks NextRetryAt)
-			// Or just assume if it's in RETRY_WAIT for a while?
-			// For now, let's look for next retry timestamp in Inputs if exists.
-			var nextRetryAt time.Time
-			if task.Inputs != nil {
-				if val, ok := task.Inputs[InputKeyNextRetryAt].(string); ok {
-					if t, err := time.Parse(time.RFC3339, val); err == nil {
-						nextRetryAt = t
-					}
+	now := s.clock.Now()
+	changes, err := s.transitionTasks(func(task *persistence.TaskState) (TaskStatus, bool) {
+		if TaskStatus(task.Status) != TaskStatusRetryWait {
+			return "", false
+		}
+		// Look for next retry timestamp in Inputs (models.go lacks NextRetryAt)
+		var nextRetryAt time.Time
+		if task.Inputs != nil {
+			if val, ok := task.Inputs[InputKeyNextRetryAt].(string); ok {
+				if t, err := time.Parse(time.RFC3339, val); err == nil {
+					nextRetryAt = t
 				}
 			}
+		}
 
-			// If nextRetryAt is zero (not set) or before now, reset it.
-			if nextRetryAt.IsZero() || !now.Before(nextRetryAt) {
-				oldStatus := TaskStatus(task.Status)
-				task.Status = string(TaskStatusPending)
-				// Clear next_retry_at
-				if task.Inputs != nil {
-					delete(task.Inputs, InputKeyNextRetryAt)
-				}
-
-				if err := s.Repo.State().SaveTasks(tasksState); err != nil {
-					s.logger.Warn("failed to reset retry task",
-						slog.String("task_id", task.TaskID),
-						slog.Any("error", err),
-					)
-					continue
-				}
-				s.emitStateChange(task.TaskID, oldStatus, TaskStatusPending)
-				reset = append(reset, task.TaskID)
-				s.logger.Info("task reset for retry (wait time elapsed)",
-					slog.String("task_id", task.TaskID),
-				)
-			}
+		// If nextRetryAt is zero (not set) or before now, reset it.
+		if !nextRetryAt.IsZero() && now.Before(nextRetryAt) {
+			return "", false
+		}
+		// Clear next_retry_at
+		if task.Inputs != nil {
+			delete(task.Inputs, InputKeyNextRetryAt)
 		}
+		return TaskStatusPending, true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset retry tasks: %w", err)
 	}
 
+	reset := []string{}
+	for _, c := range changes {
+		reset = append(reset, c.TaskID)
+		s.logger.Info("task reset for retry (wait time elapsed)",
+			slog.String("task_id", c.TaskID),
+		)
+	}
 	return reset, nil
 }
 
@@ -271,7 +430,7 @@ func (s *Scheduler) emitStateChange(taskID string, oldStatus, newStatus TaskStat
 			TaskID:    taskID,
 			OldStatus: oldStatus,
 			NewStatus: newStatus,
-			Timestamp: time.Now(),
+			Timestamp: s.clock.Now(),
 		})
 	}
 }
@@ -1,13 +1,17 @@
 package orchestrator
 
 import (
+	"errors"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/mock"
 )
 
 func setupTestRepo(t *testing.T) (persistence.WorkspaceRepository, *ipc.FilesystemQueue) {
@@ -21,21 +25,25 @@ func setupTestRepo(t *testing.T) (persistence.WorkspaceRepository, *ipc.Filesyst
 }
 
 func saveState(t *testing.T, repo persistence.WorkspaceRepository, tasks []persistence.TaskState, nodes []persistence.NodeRuntime) {
-	if tasks != nil {
-		if err := repo.State().SaveTasks(&persistence.TasksState{Tasks: tasks}); err != nil {
-			t.Fatalf("failed to save tasks: %v", err)
-		}
-	} else {
-		// Ensure empty state exists
-		repo.State().SaveTasks(&persistence.TasksState{Tasks: []persistence.TaskState{}})
+	// 既存の state を置き換える（版は UpdateTasks/UpdateNodesRuntime が管理する）
+	if tasks == nil {
+		tasks = []persistence.TaskState{}
+	}
+	if err := repo.State().UpdateTasks(func(s *persistence.TasksState) error {
+		s.Tasks = tasks
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to save tasks: %v", err)
 	}
 
-	if nodes != nil {
-		if err := repo.State().SaveNodesRuntime(&persistence.NodesRuntime{Nodes: nodes}); err != nil {
-			t.Fatalf("failed to save nodes runtime: %v", err)
-		}
-	} else {
-		repo.State().SaveNodesRuntime(&persistence.NodesRuntime{Nodes: []persistence.NodeRuntime{}})
+	if nodes == nil {
+		nodes = []persistence.NodeRuntime{}
+	}
+	if err := repo.State().UpdateNodesRuntime(func(s *persistence.NodesRuntime) error {
+		s.Nodes = nodes
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to save nodes runtime: %v", err)
 	}
 }
 
@@ -139,6 +147,82 @@ func TestScheduler_ScheduleTask_NoDependencies(t *testing.T) {
 	}
 }
 
+func TestScheduler_ScheduleTask_AlreadyQueued(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	scheduler := NewScheduler(repo, queue, nil)
+	scheduler.logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	now := time.Now()
+
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-1", Dependencies: []string{}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Status: string(TaskStatusPending), CreatedAt: now},
+	}, nil)
+
+	if err := scheduler.ScheduleTask("task-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 依存の解決が並行して走ると、同じタスクを古いスナップショットから再度スケジュールしようとする
+	if err := scheduler.ScheduleTask("task-1"); !errors.Is(err, ErrTaskAlreadyQueued) {
+		t.Errorf("expected ErrTaskAlreadyQueued, got %v", err)
+	}
+
+	jobs, err := queue.ListJobs(DefaultPoolID)
+	if err != nil {
+		t.Fatalf("failed to list jobs: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Errorf("expected 1 queued job, got %d", len(jobs))
+	}
+}
+
+func TestScheduler_ScheduleTask_EnqueueFailureRevertsStatus(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	// キューのディレクトリを作れない場所を指すキュー
+	blocker := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("failed to create blocker file: %v", err)
+	}
+	emitter := new(MockEventEmitter)
+	emitter.On("Emit", mock.Anything, mock.Anything).Return()
+	scheduler := NewScheduler(repo, ipc.NewFilesystemQueue(blocker), emitter)
+	scheduler.logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-1", Dependencies: []string{}},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Status: string(TaskStatusPending), CreatedAt: time.Now()},
+	}, nil)
+
+	if err := scheduler.ScheduleTask("task-1"); err == nil {
+		t.Fatal("expected enqueue error")
+	}
+
+	state, _ := repo.State().LoadTasks()
+	if state.Tasks[0].Status != string(TaskStatusPending) {
+		t.Errorf("expected status PENDING after enqueue failure, got %s", state.Tasks[0].Status)
+	}
+	var transitions []string
+	for _, call := range emitter.Calls {
+		if ev, ok := call.Arguments.Get(1).(TaskStateChangeEvent); ok {
+			transitions = append(transitions, string(ev.OldStatus)+"->"+string(ev.NewStatus))
+		}
+	}
+	want := []string{"PENDING->READY", "READY->PENDING"}
+	if strings.Join(transitions, ",") != strings.Join(want, ",") {
+		t.Errorf("expected transitions %v, got %v", want, transitions)
+	}
+
+	// キューが回復すれば同じタスクを再びスケジュールできる
+	scheduler.Queue = ipc.NewFilesystemQueue(repo.BaseDir())
+	if err := scheduler.ScheduleTask("task-1"); err != nil {
+		t.Errorf("unexpected error after queue recovered: %v", err)
+	}
+}
+
 func TestScheduler_ScheduleReadyTasks(t *testing.T) {
 	repo, queue := setupTestRepo(t)
 	scheduler := NewScheduler(repo, queue, nil)
@@ -11,21 +11,40 @@ import (
 )
 
 type SchedulerV2 struct {
-	repo     persistence.WorkspaceRepository
-	executor ExecutorV2
-	logger   *slog.Logger
+	repo        persistence.WorkspaceRepository
+	executor    ExecutorV2
+	logger      *slog.Logger
+	healthCheck AgentHealthCheck
 }
 
+// NewSchedulerV2 は SchedulerV2 を作る
+// executor がエージェントのヘルスチェックを提供していれば、スケジュールの前に実行する（SetHealthCheck で変更できる）。
 func NewSchedulerV2(repo persistence.WorkspaceRepository, executor ExecutorV2, logger *slog.Logger) *SchedulerV2 {
-	return &SchedulerV2{
+	s := &SchedulerV2{
 		repo:     repo,
 		executor: executor,
 		logger:   logger,
 	}
+	if checker, ok := executor.(agentHealthChecker); ok {
+		s.healthCheck = checker.CheckAgent
+	}
+	return s
+}
+
+// SetHealthCheck はスケジュールの前に実行するエージェントのヘルスチェックを設定する（nil なら確認しない）
+func (s *SchedulerV2) SetHealthCheck(check AgentHealthCheck) {
+	s.healthCheck = check
 }
 
 // CheckAndSchedule is the main entry point to be called periodically or on event.
 func (s *SchedulerV2) CheckAndSchedule(ctx context.Context) error {
+	if s.healthCheck != nil {
+		// ヘルスチェックに失敗しても、前回の結果でスケジュールを続ける
+		if err := CheckAgentsHealth(ctx, s.repo, s.healthCheck); err != nil {
+			s.logger.Warn("failed to check agent health", "err", err)
+		}
+	}
+
 	// 1. Load pending tasks
 	tasksState, err := s.repo.State().LoadTasks()
 	if err != nil {
@@ -43,13 +62,23 @@ func (s *SchedulerV2) CheckAndSchedule(ctx context.Context) error {
 	}
 
 	// 2. Filter schedule-able tasks
-	var candidates []persistence.TaskState
+	type candidate struct {
+		task     persistence.TaskState
+		required []string
+	}
+	var candidates []candidate
 	for _, t := range tasksState.Tasks {
-		if t.Status == "pending" {
-			// Check dependencies
-			if s.allDependenciesSatisfied(t.NodeID, nodesRuntime) {
-				candidates = append(candidates, t)
-			}
+		if t.Status != "pending" {
+			continue
+		}
+		node, err := s.repo.Design().GetNode(t.NodeID)
+		if err != nil {
+			s.logger.Error("failed to get node design", "node_id", t.NodeID, "err", err)
+			continue // Fail safe
+		}
+		// Check dependencies
+		if s.allDependenciesSatisfied(node, nodesRuntime) {
+			candidates = append(candidates, candidate{task: t, required: TaskCapabilities(&t, node)})
 		}
 	}
 
@@ -58,90 +87,161 @@ func (s *SchedulerV2) CheckAndSchedule(ctx context.Context) error {
 	}
 
 	// 3. Dispatch to Agents
-	// Simple FIFO dispatch for MVP
-	actionsToAppend := []persistence.Action{}
-	modifiedTasks := false
-	modifiedAgents := false
-
-	for _, task := range candidates {
-		agentID, ok := s.findAvailableAgent(agentsState, task)
-		if !ok {
-			continue // No agent available
-		}
-
-		// Dispatch!
-		now := time.Now()
-
-		// Create Action
-		action := persistence.Action{
-			ID:          newID("act"),
-			At:          now,
-			Kind:        "task.started",
-			WorkspaceID: "TODO-ws-id", // Needs to be plumbed
-			Payload: map[string]interface{}{
-				"task_id":  task.TaskID,
-				"agent_id": agentID,
-			},
-		}
-		actionsToAppend = append(actionsToAppend, action)
-
-		// Update Task State
-		for i, t := range tasksState.Tasks {
-			if t.TaskID == task.TaskID {
-				tasksState.Tasks[i].Status = "running"
-				tasksState.Tasks[i].AssignedAgent = agentID
-				tasksState.Tasks[i].UpdatedAt = now
-				modifiedTasks = true
+	// タスクの順に、必要な能力を持つエージェントのうち最も負荷の低いものへ割り当てる
+	assignments := map[string]*AgentAssignment{} // task_id -> assignment
+	for _, c := range candidates {
+		assignment, err := SelectAgent(agentsState.Agents, c.required)
+		if err != nil {
+			s.logger.Debug("task is waiting for an agent", "task_id", c.task.TaskID, "reason", err.Error())
+			continue
+		}
+		assignments[c.task.TaskID] = assignment
+		// 同一ループ内の負荷の計算に含めるよう、メモリ上の状態にも反映する
+		for i := range agentsState.Agents {
+			if agentsState.Agents[i].AgentID == assignment.AgentID {
+				agentsState.Agents[i].RunningTasks = append(agentsState.Agents[i].RunningTasks, c.task.TaskID)
 				break
 			}
 		}
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
 
-		// Update Agent State
-		for i, a := range agentsState.Agents {
-			if a.AgentID == agentID {
-				agentsState.Agents[i].RunningTasks = append(agentsState.Agents[i].RunningTasks, task.TaskID)
-				modifiedAgents = true
+	// 4. Persist Changes
+	// 読み込み後に他のスケジューラがエージェントの枠を使っている可能性があるため、最新の agents.json で
+	// 空き・状態・能力を確かめ直してから枠を確保する。確保できたタスクだけを running にする。
+	var reserved map[string]string // task_id -> agent_id
+	err = s.repo.State().UpdateAgents(func(state *persistence.AgentsState) error {
+		reserved = make(map[string]string)
+		for _, c := range candidates {
+			assignment, ok := assignments[c.task.TaskID]
+			if !ok {
+				continue
+			}
+			for i := range state.Agents {
+				a := &state.Agents[i]
+				if a.AgentID != assignment.AgentID {
+					continue
+				}
+				if agentAvailable(a) && len(missingCapabilities(a, assignment.RequiredCapabilities)) == 0 {
+					a.RunningTasks = append(a.RunningTasks, c.task.TaskID)
+					reserved[c.task.TaskID] = a.AgentID
+				}
 				break
 			}
 		}
-
-		// Trigger Executor (Async)
-		// Note: Executor needs to be updated to accept TaskStateV2
-		go func(t persistence.TaskState) {
-			s.logger.Info("Executing task", "task_id", t.TaskID)
-			_ = s.executor.Execute(ctx, t)
-		}(task)
+		if len(reserved) == 0 {
+			return persistence.ErrNoChange
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save agents: %w", err)
+	}
+	for taskID, assignment := range assignments {
+		if _, ok := reserved[taskID]; !ok {
+			s.logger.Debug("agent is no longer available, task waits for the next loop", "task_id", taskID, "agent_id", assignment.AgentID)
+		}
+	}
+	if len(reserved) == 0 {
+		return nil
 	}
 
-	// 4. Persist Changes
-	if len(actionsToAppend) > 0 {
-		for _, a := range actionsToAppend {
-			if err := s.repo.History().AppendAction(&a); err != nil {
-				return fmt.Errorf("failed to append action: %w", err)
+	// 読み込み後に他の書き込み手が状態を変えている可能性があるため、まだ pending のタスクだけを確保する
+	now := time.Now()
+	var dispatched []persistence.TaskState
+	err = s.repo.State().UpdateTasks(func(state *persistence.TasksState) error {
+		dispatched = nil
+		for i := range state.Tasks {
+			agentID, ok := reserved[state.Tasks[i].TaskID]
+			if !ok || state.Tasks[i].Status != "pending" {
+				continue
 			}
+			state.Tasks[i].Status = "running"
+			state.Tasks[i].AssignedAgent = agentID
+			state.Tasks[i].UpdatedAt = now
+			dispatched = append(dispatched, state.Tasks[i])
+		}
+		if len(dispatched) == 0 {
+			return persistence.ErrNoChange
 		}
+		return nil
+	})
+	if err != nil {
+		dispatched = nil
+	}
+	// running にできなかったタスクの枠は返す
+	claimed := make(map[string]struct{}, len(dispatched))
+	for _, task := range dispatched {
+		claimed[task.TaskID] = struct{}{}
 	}
-	if modifiedTasks {
-		if err := s.repo.State().SaveTasks(tasksState); err != nil {
-			return fmt.Errorf("failed to save tasks: %w", err)
+	for taskID, agentID := range reserved {
+		if _, ok := claimed[taskID]; !ok {
+			s.releaseAgent(agentID, taskID)
 		}
 	}
-	if modifiedAgents {
-		if err := s.repo.State().SaveAgents(agentsState); err != nil {
-			return fmt.Errorf("failed to save agents: %w", err)
+	if err != nil {
+		return fmt.Errorf("failed to save tasks: %w", err)
+	}
+	if len(dispatched) == 0 {
+		return nil
+	}
+
+	for _, task := range dispatched {
+		assignment := assignments[task.TaskID]
+		action, err := persistence.NewAction(persistence.ActionTaskStarted, workspaceIDOf(s.repo), now, persistence.TaskStartedPayload{
+			TaskID:               task.TaskID,
+			AgentID:              task.AssignedAgent,
+			RequiredCapabilities: assignment.RequiredCapabilities,
+			Reason:               assignment.Reason,
+			Candidates:           assignment.Candidates,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.repo.History().AppendAction(action); err != nil {
+			return fmt.Errorf("failed to append action: %w", err)
 		}
+		s.logger.Info("Assigned task to agent", "task_id", task.TaskID, "agent_id", task.AssignedAgent, "reason", assignment.Reason)
+	}
+
+	// Trigger Executor (Async)
+	// 状態の保存後に起動することで、実行結果の書き込みが running への更新に上書きされない
+	for _, task := range dispatched {
+		go func(t persistence.TaskState) {
+			s.logger.Info("Executing task", "task_id", t.TaskID)
+			_ = s.executor.Execute(ctx, t)
+			s.releaseAgent(t.AssignedAgent, t.TaskID)
+		}(task)
 	}
 
 	return nil
 }
 
-func (s *SchedulerV2) allDependenciesSatisfied(nodeID string, runtime *persistence.NodesRuntime) bool {
-	nodeDesign, err := s.repo.Design().GetNode(nodeID)
+// releaseAgent は実行を終えたタスクをエージェントの実行中の一覧から外す
+func (s *SchedulerV2) releaseAgent(agentID, taskID string) {
+	err := s.repo.State().UpdateAgents(func(state *persistence.AgentsState) error {
+		for i := range state.Agents {
+			a := &state.Agents[i]
+			if a.AgentID != agentID {
+				continue
+			}
+			for j, id := range a.RunningTasks {
+				if id == taskID {
+					a.RunningTasks = append(a.RunningTasks[:j], a.RunningTasks[j+1:]...)
+					return nil
+				}
+			}
+		}
+		return persistence.ErrNoChange
+	})
 	if err != nil {
-		s.logger.Error("failed to get node design", "node_id", nodeID, "err", err)
-		return false // Fail safe
+		s.logger.Error("failed to release agent", "agent_id", agentID, "task_id", taskID, "err", err)
 	}
+}
 
+func (s *SchedulerV2) allDependenciesSatisfied(nodeDesign *persistence.NodeDesign, runtime *persistence.NodesRuntime) bool {
 	for _, depID := range nodeDesign.Dependencies {
 		satisfied := false
 		for _, n := range runtime.Nodes {
@@ -160,19 +260,3 @@ func (s *SchedulerV2) allDependenciesSatisfied(nodeID string, runtime *persisten
 	}
 	return true
 }
-
-func (s *SchedulerV2) findAvailableAgent(agents *persistence.AgentsState, _ persistence.TaskState) (string, bool) {
-	for _, a := range agents.Agents {
-		if len(a.RunningTasks) < a.MaxParallel {
-			// Check capabilities (naive check)
-			// Real logic: check if agent kind matches task requirements
-			return a.AgentID, true
-		}
-	}
-	return "", false
-}
-
-// Helper to generate ID (placeholders)
-func newID(prefix string) string {
-	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
-}
@@ -0,0 +1,458 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/logging"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/google/uuid"
+)
+
+// 定期タスク: ワークスペースの schedules/<id>.json に定義（テンプレート）を置き、
+// 実行ループが予定時刻になった定義からタスクを作成する（Inputs["schedule_id"] で定義を参照する）。
+// 予定は cron 式・間隔（前回の実行から N 時間後）・一度だけの時刻のいずれかで指定する。
+// 同じ定義のタスクは同時に 1 つまでで、未完了のタスクがある間は予定時刻を過ぎても作成を待つ。
+
+const (
+	// ScheduledByTaskSchedule は定期タスクから作成したタスクの ScheduledBy
+	ScheduledByTaskSchedule = "schedule"
+	// DefaultScheduleMissedGrace は予定時刻からこの時間を過ぎて実行する場合を「逃した予定」とみなす
+	DefaultScheduleMissedGrace = 15 * time.Minute
+	// minScheduleInterval は間隔の下限（実行ループの周期より十分長くする）
+	minScheduleInterval = time.Minute
+)
+
+// MissedSchedulePolicy は停止中などで予定時刻を逃した場合の扱い
+// どちらの場合も逃した回数分のタスクはまとめて作らず、次の予定時刻は現在時刻から求める。
+type MissedSchedulePolicy string
+
+const (
+	MissedScheduleRunOnce MissedSchedulePolicy = "run_once" // 1 回だけ実行する（既定）
+	MissedScheduleSkip    MissedSchedulePolicy = "skip"     // 実行せずに次の予定時刻を待つ
+)
+
+// TaskSchedule は定期タスクの定義
+// Cron・Interval・RunAt のいずれか 1 つを指定する。NextRunAt 以降はストアが管理する実行状態。
+type TaskSchedule struct {
+	ID           string               `json:"id"`
+	Title        string               `json:"title"`
+	Kind         string               `json:"kind,omitempty"` // 作成するタスクの種別（既定は manual）
+	PoolID       string               `json:"poolId,omitempty"`
+	Cron         string               `json:"cron,omitempty"`     // 5 フィールドの cron 式（@daily などの省略形も可）
+	Interval     string               `json:"interval,omitempty"` // 前回の実行からの間隔（Go の time.Duration 形式、例: 24h）
+	RunAt        *time.Time           `json:"runAt,omitempty"`    // 一度だけ実行する時刻
+	Timezone     string               `json:"timezone,omitempty"` // cron 式を評価するタイムゾーン（IANA 名、既定はローカル）
+	MissedPolicy MissedSchedulePolicy `json:"missedPolicy,omitempty"`
+	Enabled      bool                 `json:"enabled"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	UpdatedAt    time.Time            `json:"updatedAt"`
+
+	NextRunAt  *time.Time `json:"nextRunAt,omitempty"` // 次の予定時刻（無効・一度だけの実行済みは nil）
+	LastRunAt  *time.Time `json:"lastRunAt,omitempty"` // 最後にタスクを作成した時刻
+	LastTaskID string     `json:"lastTaskId,omitempty"`
+}
+
+// Validate は定義の妥当性を検証する
+func (s *TaskSchedule) Validate() error {
+	if strings.TrimSpace(s.Title) == "" {
+		return fmt.Errorf("schedule title is required")
+	}
+	specs := 0
+	if s.Cron != "" {
+		specs++
+		if _, err := ParseCron(s.Cron); err != nil {
+			return err
+		}
+	}
+	if s.Interval != "" {
+		specs++
+		d, err := time.ParseDuration(s.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid schedule interval %q: %w", s.Interval, err)
+		}
+		if d < minScheduleInterval {
+			return fmt.Errorf("schedule interval must be at least %s: %s", minScheduleInterval, s.Interval)
+		}
+	}
+	if s.RunAt != nil {
+		specs++
+	}
+	if specs != 1 {
+		return fmt.Errorf("schedule needs exactly one of cron, interval or runAt")
+	}
+	if _, err := s.location(); err != nil {
+		return err
+	}
+	switch s.MissedPolicy {
+	case "", MissedScheduleRunOnce, MissedScheduleSkip:
+	default:
+		return fmt.Errorf("unknown missed schedule policy: %s", s.MissedPolicy)
+	}
+	return nil
+}
+
+func (s *TaskSchedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule timezone %q: %w", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// nextAfter は from より後の予定時刻を返す（これ以上予定がなければ nil）
+// 一度だけの定義は、まだ実行していなければ RunAt を返す（過ぎていても返し、逃した予定として扱う）。
+func (s *TaskSchedule) nextAfter(from time.Time) *time.Time {
+	var next time.Time
+	switch {
+	case s.Cron != "":
+		cron, err := ParseCron(s.Cron)
+		if err != nil {
+			return nil
+		}
+		loc, err := s.location()
+		if err != nil {
+			return nil
+		}
+		next = cron.Next(from.In(loc))
+	case s.Interval != "":
+		d, err := time.ParseDuration(s.Interval)
+		if err != nil {
+			return nil
+		}
+		next = from.Add(d)
+	case s.RunAt != nil:
+		if s.LastRunAt != nil {
+			return nil
+		}
+		next = *s.RunAt
+	}
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// sameSpec は予定の指定が同じかどうかを返す（変わった場合は次の予定時刻を求め直す）
+func (s *TaskSchedule) sameSpec(o *TaskSchedule) bool {
+	sameRunAt := (s.RunAt == nil && o.RunAt == nil) || (s.RunAt != nil && o.RunAt != nil && s.RunAt.Equal(*o.RunAt))
+	return s.Cron == o.Cron && s.Interval == o.Interval && s.Timezone == o.Timezone && sameRunAt
+}
+
+// ScheduleStore は定期タスクの定義を永続化する
+// 定義ファイルの読み書きはファイルごとのロック内で行い（persistence.UpdateJSONFile）、
+// CLI・IDE・実行ループが同じ定義を同時に更新しても更新を失わない。
+type ScheduleStore struct {
+	workspaceDir string
+	clock        persistence.Clock
+	logger       *slog.Logger
+}
+
+// NewScheduleStore は ScheduleStore を作成する
+func NewScheduleStore(workspaceDir string) *ScheduleStore {
+	return &ScheduleStore{
+		workspaceDir: workspaceDir,
+		clock:        persistence.SystemClock,
+		logger:       logging.WithComponent(slog.Default(), "schedule-store"),
+	}
+}
+
+// SetClock は作成・更新日時と次の予定時刻の基準にする時刻の取得元を差し替える（nil で実時間）
+func (s *ScheduleStore) SetClock(clock persistence.Clock) {
+	if clock == nil {
+		clock = persistence.SystemClock
+	}
+	s.clock = clock
+}
+
+func (s *ScheduleStore) dir() string {
+	return filepath.Join(s.workspaceDir, "schedules")
+}
+
+func (s *ScheduleStore) path(id string) string {
+	return filepath.Join(s.dir(), id+".json")
+}
+
+// Get は定義を取得する
+func (s *ScheduleStore) Get(id string) (*TaskSchedule, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("schedule not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read schedule: %w", err)
+	}
+	var schedule TaskSchedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// List は全ての定義を作成日時の昇順で返す
+func (s *ScheduleStore) List() ([]TaskSchedule, error) {
+	entries, err := os.ReadDir(s.dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TaskSchedule{}, nil
+		}
+		return nil, fmt.Errorf("failed to read schedules dir: %w", err)
+	}
+	schedules := []TaskSchedule{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		schedule, err := s.Get(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			s.logger.Warn("failed to load schedule", slog.String("file", entry.Name()), slog.Any("error", err))
+			continue
+		}
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+// Save は定義を作成・更新する（ID が空なら新規作成）
+// 実行状態（LastRunAt / LastTaskID）は保存済みの値を引き継ぎ、予定の指定か有効・無効が変わった場合は
+// 次の予定時刻を現在時刻から求め直す。
+func (s *ScheduleStore) Save(schedule *TaskSchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	return s.update(schedule.ID, func(existing *TaskSchedule) (*TaskSchedule, error) {
+		s.apply(schedule, existing)
+		return schedule, nil
+	})
+}
+
+// SetEnabled は定義の有効・無効を切り替える
+func (s *ScheduleStore) SetEnabled(id string, enabled bool) (*TaskSchedule, error) {
+	var schedule *TaskSchedule
+	err := s.update(id, func(existing *TaskSchedule) (*TaskSchedule, error) {
+		if existing == nil {
+			return nil, fmt.Errorf("schedule not found: %s", id)
+		}
+		next := *existing
+		next.Enabled = enabled
+		s.apply(&next, existing)
+		schedule = &next
+		return schedule, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Delete は定義を削除する（作成済みのタスクはそのまま残る）
+func (s *ScheduleStore) Delete(id string) error {
+	return s.update(id, func(existing *TaskSchedule) (*TaskSchedule, error) {
+		if existing == nil {
+			return nil, fmt.Errorf("schedule not found: %s", id)
+		}
+		return nil, nil
+	})
+}
+
+// apply は保存済みの定義 existing（新規なら nil）から引き継ぐ値と次の予定時刻を schedule に設定する
+func (s *ScheduleStore) apply(schedule, existing *TaskSchedule) {
+	now := s.clock.Now()
+	if existing != nil {
+		schedule.CreatedAt = existing.CreatedAt
+		schedule.LastRunAt, schedule.LastTaskID = existing.LastRunAt, existing.LastTaskID
+		schedule.NextRunAt = existing.NextRunAt
+	} else {
+		schedule.CreatedAt = now
+		schedule.LastRunAt, schedule.LastTaskID, schedule.NextRunAt = nil, "", nil
+	}
+	schedule.UpdatedAt = now
+	switch {
+	case !schedule.Enabled:
+		schedule.NextRunAt = nil
+	case existing == nil || !existing.Enabled || !existing.sameSpec(schedule) || schedule.NextRunAt == nil:
+		if existing != nil && !existing.sameSpec(schedule) && schedule.RunAt != nil {
+			schedule.LastRunAt = nil // 時刻を変えた一度だけの定義は再び実行できる
+		}
+		schedule.NextRunAt = schedule.nextAfter(now)
+	}
+}
+
+// update は定義ファイルのロックを保持したまま最新の定義を読み直し、fn の結果を書き込む（nil なら削除する）
+func (s *ScheduleStore) update(id string, fn func(existing *TaskSchedule) (*TaskSchedule, error)) error {
+	var fnErr error
+	err := persistence.UpdateJSONFile(s.path(id), func(existing *TaskSchedule) (*TaskSchedule, error) {
+		next, err := fn(existing)
+		fnErr = err
+		return next, err
+	})
+	if err != nil && fnErr == nil {
+		return fmt.Errorf("failed to write schedule: %w", err)
+	}
+	return err
+}
+
+// MaterializeSchedules は予定時刻を過ぎた定義からタスクを作成し、作成したタスクの ID を返す
+// 同じ定義の未完了のタスクがある定義は、そのタスクが終わるまで作成を待つ。
+// 予定時刻から DefaultScheduleMissedGrace を過ぎた定義は逃した予定として MissedPolicy に従う。
+func MaterializeSchedules(repo persistence.WorkspaceRepository, store *ScheduleStore, now time.Time, logger *slog.Logger) ([]string, error) {
+	schedules, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	var due []TaskSchedule
+	for _, sc := range schedules {
+		if sc.Enabled && sc.NextRunAt != nil && !sc.NextRunAt.After(now) {
+			due = append(due, sc)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	active := make(map[string]string) // schedule_id -> 未完了のタスク ID
+	for _, t := range tasksState.Tasks {
+		if id := inputString(t.Inputs, InputKeyScheduleID); id != "" && !isTerminalTaskStatus(t.Status) {
+			active[id] = t.TaskID
+		}
+	}
+
+	var created []string
+	for i := range due {
+		sc := &due[i]
+		if taskID, ok := active[sc.ID]; ok {
+			logger.Debug("scheduled task is waiting for the previous instance",
+				slog.String("schedule_id", sc.ID), slog.String("task_id", taskID))
+			continue
+		}
+		taskID, err := fireSchedule(repo, store, sc, now, logger)
+		if err != nil {
+			logger.Error("failed to materialize scheduled task", slog.String("schedule_id", sc.ID), slog.Any("error", err))
+			continue
+		}
+		if taskID != "" {
+			created = append(created, taskID)
+		}
+	}
+	return created, nil
+}
+
+// fireSchedule は 1 つの定義の予定を処理する（逃した予定を skip した場合は空の ID を返す）
+// 定義ファイルのロック内で最新の定義を読み直し、一覧の取得後に削除・無効化・変更された定義からは作成しない。
+func fireSchedule(repo persistence.WorkspaceRepository, store *ScheduleStore, sc *TaskSchedule, now time.Time, logger *slog.Logger) (string, error) {
+	var taskID string
+	err := store.update(sc.ID, func(current *TaskSchedule) (*TaskSchedule, error) {
+		if current == nil || !current.Enabled || current.NextRunAt == nil || current.NextRunAt.After(now) {
+			return nil, persistence.ErrNoChange
+		}
+		id, err := fireScheduleLocked(repo, current, now, logger)
+		if err != nil {
+			return nil, err
+		}
+		taskID = id
+		return current, nil
+	})
+	return taskID, err
+}
+
+// fireScheduleLocked は定義 sc からタスクを作成し、sc の実行状態と次の予定時刻を進める（定義ファイルのロック内で呼ぶ）
+func fireScheduleLocked(repo persistence.WorkspaceRepository, sc *TaskSchedule, now time.Time, logger *slog.Logger) (string, error) {
+	dueAt := *sc.NextRunAt
+	missed := now.Sub(dueAt) > DefaultScheduleMissedGrace
+	skip := missed && sc.MissedPolicy == MissedScheduleSkip
+
+	taskID := ""
+	if !skip {
+		taskID = uuid.New().String()
+	}
+	action, err := persistence.NewAction(persistence.ActionScheduleFired, workspaceIDOf(repo), now, persistence.ScheduleFiredPayload{
+		ScheduleID: sc.ID,
+		TaskID:     taskID,
+		DueAt:      dueAt,
+		Missed:     missed,
+		Skipped:    skip,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := repo.History().AppendAction(action); err != nil {
+		return "", fmt.Errorf("failed to append history: %w", err)
+	}
+
+	if !skip {
+		kind := sc.Kind
+		if kind == "" {
+			kind = "manual"
+		}
+		inputs := map[string]interface{}{InputKeyTitle: sc.Title, InputKeyScheduleID: sc.ID}
+		if sc.PoolID != "" {
+			inputs[InputKeyPoolID] = sc.PoolID
+		}
+		err = repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+			tasksState.Tasks = append(tasksState.Tasks, persistence.TaskState{
+				TaskID:      taskID,
+				NodeID:      manualNodePrefix + taskID,
+				Kind:        kind,
+				Status:      string(TaskStatusPending),
+				CreatedAt:   now,
+				UpdatedAt:   now,
+				ScheduledBy: ScheduledByTaskSchedule,
+				Inputs:      inputs,
+			})
+			return nil
+		})
+		if err != nil {
+			recordStateSaveFailed(repo, logger, action.ID, "save_tasks", err)
+			return "", fmt.Errorf("failed to save tasks: %w", err)
+		}
+		ranAt := now
+		sc.LastRunAt, sc.LastTaskID = &ranAt, taskID
+	}
+	if missed {
+		logger.Info("missed schedule", slog.String("schedule_id", sc.ID), slog.Time("due_at", dueAt), slog.Bool("skipped", skip))
+	}
+
+	if sc.RunAt != nil {
+		sc.NextRunAt = nil // 一度だけの定義は実行（または skip）で終わる
+	} else {
+		sc.NextRunAt = sc.nextAfter(now)
+	}
+	return taskID, nil
+}
+
+// materializeSchedules は実行ループから定期タスクを作成する
+func (e *ExecutionOrchestrator) materializeSchedules() {
+	if e.schedules == nil {
+		return
+	}
+	created, err := MaterializeSchedules(e.Repo, e.schedules, e.now(), e.logger)
+	if err != nil {
+		e.logger.Error("failed to materialize scheduled tasks", slog.Any("error", err))
+		return
+	}
+	for _, id := range created {
+		e.emitTaskStateChange(id, "", TaskStatusPending)
+	}
+}
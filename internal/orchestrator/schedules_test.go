@@ -0,0 +1,187 @@
+package orchestrator
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleStore_Save(t *testing.T) {
+	store := NewScheduleStore(t.TempDir())
+
+	assert.Error(t, store.Save(&TaskSchedule{Title: "no spec", Enabled: true}))
+	assert.Error(t, store.Save(&TaskSchedule{Title: "two specs", Cron: "@daily", Interval: "24h"}))
+	assert.Error(t, store.Save(&TaskSchedule{Title: "too often", Interval: "10s"}))
+	assert.Error(t, store.Save(&TaskSchedule{Title: "bad tz", Cron: "@daily", Timezone: "Mars/Base"}))
+
+	sc := &TaskSchedule{Title: "依存関係の更新", Interval: "24h", Enabled: true}
+	before := time.Now()
+	require.NoError(t, store.Save(sc))
+	require.NotEmpty(t, sc.ID)
+	require.NotNil(t, sc.NextRunAt)
+	assert.WithinDuration(t, before.Add(24*time.Hour), *sc.NextRunAt, time.Minute)
+
+	// 予定の指定が変わらない更新では次の予定時刻を引き継ぐ
+	next := *sc.NextRunAt
+	sc.Title = "依存関係の更新（夜間）"
+	require.NoError(t, store.Save(sc))
+	assert.Equal(t, next.Unix(), sc.NextRunAt.Unix())
+
+	disabled, err := store.SetEnabled(sc.ID, false)
+	require.NoError(t, err)
+	assert.Nil(t, disabled.NextRunAt)
+
+	schedules, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	assert.Equal(t, "依存関係の更新（夜間）", schedules[0].Title)
+
+	require.NoError(t, store.Delete(sc.ID))
+	assert.Error(t, store.Delete(sc.ID))
+}
+
+// saveDueSchedule は予定時刻を due にした定義を保存する
+func saveDueSchedule(t *testing.T, store *ScheduleStore, sc TaskSchedule, due time.Time) *TaskSchedule {
+	t.Helper()
+	sc.Enabled = true
+	require.NoError(t, store.Save(&sc))
+	sc.NextRunAt = &due
+	require.NoError(t, store.update(sc.ID, func(*TaskSchedule) (*TaskSchedule, error) { return &sc, nil }))
+	return &sc
+}
+
+func TestMaterializeSchedules(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	store := NewScheduleStore(repo.BaseDir())
+	now := time.Now()
+	nightly := saveDueSchedule(t, store, TaskSchedule{Title: "夜間の依存更新", Interval: "24h", PoolID: "codegen"}, now.Add(-time.Minute))
+	future := saveDueSchedule(t, store, TaskSchedule{Title: "週次のリファクタリング", Cron: "@weekly"}, now.Add(time.Hour))
+
+	created, err := MaterializeSchedules(repo, store, now, slog.Default())
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	task := loadTaskState(t, repo, created[0])
+	assert.Equal(t, string(TaskStatusPending), task.Status)
+	assert.Equal(t, ScheduledByTaskSchedule, task.ScheduledBy)
+	assert.Equal(t, nightly.ID, inputString(task.Inputs, InputKeyScheduleID))
+	assert.Equal(t, "夜間の依存更新", inputString(task.Inputs, InputKeyTitle))
+	assert.Equal(t, "codegen", inputString(task.Inputs, InputKeyPoolID))
+
+	sc, err := store.Get(nightly.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created[0], sc.LastTaskID)
+	assert.Equal(t, now.Add(24*time.Hour).Unix(), sc.NextRunAt.Unix())
+	sc, err = store.Get(future.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sc.LastTaskID)
+
+	// 前のタスクが終わるまでは予定時刻を過ぎても作成しない
+	saveDueSchedule(t, store, *nightly, now.Add(-time.Minute))
+	created, err = MaterializeSchedules(repo, store, now, slog.Default())
+	require.NoError(t, err)
+	assert.Empty(t, created)
+
+	require.NoError(t, repo.State().UpdateTasks(func(s *persistence.TasksState) error {
+		findTaskState(s, task.TaskID).Status = string(TaskStatusSucceeded)
+		return nil
+	}))
+	created, err = MaterializeSchedules(repo, store, now, slog.Default())
+	require.NoError(t, err)
+	assert.Len(t, created, 1)
+}
+
+func TestMaterializeSchedules_Missed(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	store := NewScheduleStore(repo.BaseDir())
+	now := time.Now()
+	// 停止中に何日分も予定を逃した定義
+	runOnce := saveDueSchedule(t, store, TaskSchedule{Title: "run once", Interval: "1h"}, now.Add(-72*time.Hour))
+	skip := saveDueSchedule(t, store, TaskSchedule{Title: "skip", Interval: "1h", MissedPolicy: MissedScheduleSkip}, now.Add(-72*time.Hour))
+	once := saveDueSchedule(t, store, TaskSchedule{Title: "one-shot", RunAt: ptrTime(now.Add(-time.Hour)), MissedPolicy: MissedScheduleSkip}, now.Add(-time.Hour))
+
+	created, err := MaterializeSchedules(repo, store, now, slog.Default())
+	require.NoError(t, err)
+	require.Len(t, created, 1, "missed schedules are caught up at most once")
+	assert.Equal(t, runOnce.ID, inputString(loadTaskState(t, repo, created[0]).Inputs, InputKeyScheduleID))
+
+	sc, err := store.Get(skip.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sc.LastTaskID)
+	assert.Equal(t, now.Add(time.Hour).Unix(), sc.NextRunAt.Unix())
+	sc, err = store.Get(once.ID)
+	require.NoError(t, err)
+	assert.Nil(t, sc.NextRunAt, "one-shot schedules end after being skipped")
+
+	actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	var fired []persistence.ScheduleFiredPayload
+	for _, a := range actions {
+		if a.Kind == persistence.ActionScheduleFired {
+			var p persistence.ScheduleFiredPayload
+			require.NoError(t, a.DecodePayload(&p))
+			fired = append(fired, p)
+		}
+	}
+	require.Len(t, fired, 3)
+	for _, p := range fired {
+		assert.True(t, p.Missed)
+		assert.Equal(t, p.ScheduleID != runOnce.ID, p.Skipped)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+func TestScheduleStore_UsesClock(t *testing.T) {
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := persistence.NewVirtualClock(start)
+	store := NewScheduleStore(t.TempDir())
+	store.SetClock(clock)
+
+	sc := &TaskSchedule{Title: "hourly", Interval: "1h", Enabled: true}
+	require.NoError(t, store.Save(sc))
+	assert.True(t, sc.CreatedAt.Equal(start))
+	assert.True(t, sc.NextRunAt.Equal(start.Add(time.Hour)))
+
+	clock.Advance(30 * time.Minute)
+	updated, err := store.SetEnabled(sc.ID, true)
+	require.NoError(t, err)
+	assert.True(t, updated.UpdatedAt.Equal(start.Add(30*time.Minute)))
+	assert.True(t, updated.NextRunAt.Equal(start.Add(time.Hour)), "re-enabling an enabled schedule keeps its next run")
+}
+
+func TestMaterializeSchedules_RereadsDefinitionUnderLock(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	store := NewScheduleStore(repo.BaseDir())
+	now := time.Now()
+	disabled := saveDueSchedule(t, store, TaskSchedule{Title: "disabled", Interval: "1h"}, now.Add(-time.Minute))
+	renamed := saveDueSchedule(t, store, TaskSchedule{Title: "before", Interval: "1h"}, now.Add(-time.Minute))
+
+	// 一覧を読んだ後に別のプロセスが定義を変更した
+	_, err := store.SetEnabled(disabled.ID, false)
+	require.NoError(t, err)
+	edited := *renamed
+	edited.Title = "after"
+	require.NoError(t, store.Save(&edited))
+
+	taskID, err := fireSchedule(repo, store, disabled, now, slog.Default())
+	require.NoError(t, err)
+	assert.Empty(t, taskID, "disabled schedules do not create tasks")
+
+	taskID, err = fireSchedule(repo, store, renamed, now, slog.Default())
+	require.NoError(t, err)
+	require.NotEmpty(t, taskID)
+	assert.Equal(t, "after", inputString(loadTaskState(t, repo, taskID).Inputs, InputKeyTitle))
+
+	sc, err := store.Get(renamed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "after", sc.Title, "firing must not overwrite the newer definition")
+	assert.Equal(t, taskID, sc.LastTaskID)
+
+	taskID, err = fireSchedule(repo, store, renamed, now, slog.Default())
+	require.NoError(t, err)
+	assert.Empty(t, taskID, "a schedule is fired once per due time")
+}
@@ -0,0 +1,304 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+)
+
+// SimOutcome はシミュレーションでのタスク 1 回分の実行結果
+type SimOutcome struct {
+	Fail  bool     // true なら試行を失敗させる
+	Error string   // 失敗時のエラー（ClassifyFailure で分類される。空なら "simulated failure"）
+	Files []string // 成功時の成果物
+}
+
+// SimulationSpec はシミュレーションの入力
+// WBS のノードごとに同じ ID の実装タスクを 1 つ作り、実行結果は Outcomes の台本に従う。
+type SimulationSpec struct {
+	Start       time.Time                // 仮想時刻の開始（ゼロなら 2026-01-01 00:00 UTC）
+	Nodes       []persistence.NodeDesign // WBS のノード（Dependencies で依存を表す）
+	Outcomes    map[string][]SimOutcome  // タスク ID ごとの実行結果（先頭から順に使い、使い切った後は成功）
+	RetryPolicy *RetryPolicy             // nil なら DefaultRetryPolicy
+	Concurrency int                      // 既定 Pool の同時実行数（0 以下は 1）
+	MaxDuration time.Duration            // 仮想時間の上限（0 なら 24 時間）
+}
+
+// SimEvent はシミュレーションのトレースの 1 行（履歴のタスク関連アクション）
+type SimEvent struct {
+	At     time.Time `json:"at"`
+	Cycle  int       `json:"cycle"` // 実行ループの何周目か（1 始まり）
+	Kind   string    `json:"kind"`  // persistence.ActionTask*
+	TaskID string    `json:"taskId"`
+	From   string    `json:"from,omitempty"` // 状態遷移の前後（state.task_status_changed のみ）
+	To     string    `json:"to,omitempty"`
+}
+
+// SimulationResult はシミュレーションの結果
+type SimulationResult struct {
+	Trace      []SimEvent
+	Tasks      []persistence.TaskState // 終了時点のタスク（ID 順）
+	Executions map[string]int          // タスクごとの Executor の呼び出し回数
+	Overlaps   int                     // 同じタスクが並行して実行された回数（0 であるべき）
+	Cycles     int
+	Elapsed    time.Duration // 仮想時間での経過
+	Quiescent  bool          // 進められるタスクが無くなって終わった（false は MaxDuration に達した）
+}
+
+// simulationTraceKinds はトレースに含める履歴のアクション
+var simulationTraceKinds = map[string]bool{
+	persistence.ActionTaskStatusChanged:  true,
+	persistence.ActionTaskAttemptStarted: true,
+	persistence.ActionTaskSucceeded:      true,
+	persistence.ActionTaskFailed:         true,
+}
+
+// RunSimulation は baseDir に作ったワークスペースで実行ループを仮想時間で回し、トレースを返す
+// 1 周ごとに runCycle を呼んで開始したジョブの終了を待ち、ポーリング間隔だけ時刻を進める。
+// 何も起きなかった周はリトライ待ちの最も早い再実行時刻まで時刻を飛ばし、それも無ければ終了する。
+// 同じ入力からは同じトレースになるよう、同じ周の出来事はタスク ID 順に並べる（タスクごとの順序は保つ）。
+func RunSimulation(ctx context.Context, baseDir string, spec SimulationSpec) (*SimulationResult, error) {
+	start := spec.Start
+	if start.IsZero() {
+		start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	maxDuration := spec.MaxDuration
+	if maxDuration <= 0 {
+		maxDuration = 24 * time.Hour
+	}
+	retryPolicy := spec.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = DefaultRetryPolicy()
+	}
+
+	clock := persistence.NewVirtualClock(start)
+	repo := persistence.NewWorkspaceRepositoryWithClock(baseDir, clock)
+	if err := repo.Init(); err != nil {
+		return nil, fmt.Errorf("failed to init workspace: %w", err)
+	}
+	if err := seedSimulation(repo, spec.Nodes, start); err != nil {
+		return nil, err
+	}
+
+	queue := ipc.NewFilesystemQueue(baseDir)
+	executor := newSimExecutor(clock, spec.Outcomes)
+	orch := NewExecutionOrchestrator(NewScheduler(repo, queue, nil), executor, repo, queue, nil, NewBacklogStore(baseDir), []string{DefaultPoolID})
+	orch.RetryPolicy = retryPolicy
+	orch.SetWorkerPools(&WorkerPoolsConfig{Pools: []Pool{{ID: DefaultPoolID, Concurrency: spec.Concurrency}}})
+
+	res := &SimulationResult{}
+	seen := make(map[string]bool)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res.Cycles++
+		orch.runCycle(ctx)
+		orch.Wait()
+
+		events, err := collectSimEvents(repo, seen, clock.Now(), res.Cycles)
+		if err != nil {
+			return nil, err
+		}
+		res.Trace = append(res.Trace, events...)
+
+		if len(events) > 0 {
+			clock.Advance(executionPollInterval)
+		} else {
+			next, err := earliestRetryAt(repo)
+			if err != nil {
+				return nil, err
+			}
+			if next.IsZero() {
+				res.Quiescent = true
+				break
+			}
+			clock.Set(next)
+		}
+		if clock.Now().Sub(start) > maxDuration {
+			break
+		}
+	}
+
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	res.Tasks = tasksState.Tasks
+	sort.SliceStable(res.Tasks, func(i, j int) bool { return res.Tasks[i].TaskID < res.Tasks[j].TaskID })
+	res.Executions, res.Overlaps = executor.stats()
+	res.Elapsed = clock.Now().Sub(start)
+	return res, nil
+}
+
+// seedSimulation は WBS のノード設計・実行状態と、ノードごとの実装タスクを保存する
+func seedSimulation(repo persistence.WorkspaceRepository, nodes []persistence.NodeDesign, now time.Time) error {
+	wbs := &persistence.WBS{WBSID: "simulation", CreatedAt: now, UpdatedAt: now}
+	for _, n := range nodes {
+		// SaveNode は版を書き換えるため、呼び出し元の spec を変えないよう複製を保存する
+		node := n
+		if err := repo.Design().SaveNode(&node); err != nil {
+			return fmt.Errorf("failed to save node %s: %w", node.NodeID, err)
+		}
+		wbs.NodeIndex = append(wbs.NodeIndex, persistence.NodeIndex{NodeID: node.NodeID})
+	}
+	if err := repo.Design().SaveWBS(wbs); err != nil {
+		return fmt.Errorf("failed to save wbs: %w", err)
+	}
+	err := repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		for _, n := range nodes {
+			nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
+				NodeID: n.NodeID,
+				Status: string(persistence.NodeRuntimeStatusPlanned),
+				Verification: persistence.NodeVerification{
+					Status: string(persistence.NodeVerificationNotTested),
+				},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save nodes runtime: %w", err)
+	}
+	err = repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		for _, n := range nodes {
+			tasksState.Tasks = append(tasksState.Tasks, persistence.TaskState{
+				TaskID:    n.NodeID,
+				NodeID:    n.NodeID,
+				Kind:      "implementation",
+				Status:    string(TaskStatusPending),
+				CreatedAt: now,
+				UpdatedAt: now,
+				Inputs:    map[string]interface{}{},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save tasks: %w", err)
+	}
+	return nil
+}
+
+// collectSimEvents は履歴に新しく追記されたタスク関連のアクションをトレースの行にする
+func collectSimEvents(repo persistence.WorkspaceRepository, seen map[string]bool, now time.Time, cycle int) ([]SimEvent, error) {
+	actions, err := repo.History().ListActions(time.Time{}, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list actions: %w", err)
+	}
+	var events []SimEvent
+	for _, a := range actions {
+		if seen[a.ID] {
+			continue
+		}
+		seen[a.ID] = true
+		if !simulationTraceKinds[a.Kind] {
+			continue
+		}
+		ev := SimEvent{At: a.At, Cycle: cycle, Kind: a.Kind}
+		ev.TaskID, _ = a.Payload["task_id"].(string)
+		if a.Kind == persistence.ActionTaskStatusChanged {
+			ev.From, _ = a.Payload["from_status"].(string)
+			ev.To, _ = a.Payload["to_status"].(string)
+		}
+		events = append(events, ev)
+	}
+	// 並行実行したジョブの出来事は追記順が揺れるため、タスク ID で並べ直す
+	sort.SliceStable(events, func(i, j int) bool { return events[i].TaskID < events[j].TaskID })
+	return events, nil
+}
+
+// earliestRetryAt はリトライ待ちのタスクの最も早い再実行時刻を返す（無ければゼロ値）
+func earliestRetryAt(repo persistence.WorkspaceRepository) (time.Time, error) {
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	var earliest time.Time
+	for _, t := range tasksState.Tasks {
+		if TaskStatus(t.Status) != TaskStatusRetryWait {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, inputString(t.Inputs, InputKeyNextRetryAt))
+		if err != nil {
+			continue
+		}
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	return earliest, nil
+}
+
+// simExecutor は台本どおりの結果を返す TaskExecutor
+type simExecutor struct {
+	clock      persistence.Clock
+	mu         sync.Mutex
+	outcomes   map[string][]SimOutcome
+	executions map[string]int
+	running    map[string]bool
+	overlaps   int
+}
+
+func newSimExecutor(clock persistence.Clock, outcomes map[string][]SimOutcome) *simExecutor {
+	return &simExecutor{
+		clock:      clock,
+		outcomes:   outcomes,
+		executions: make(map[string]int),
+		running:    make(map[string]bool),
+	}
+}
+
+func (x *simExecutor) ExecuteTask(ctx context.Context, task *Task) (*Attempt, error) {
+	x.mu.Lock()
+	n := x.executions[task.ID]
+	x.executions[task.ID]++
+	if x.running[task.ID] {
+		x.overlaps++
+	}
+	x.running[task.ID] = true
+	var outcome SimOutcome
+	if script := x.outcomes[task.ID]; n < len(script) {
+		outcome = script[n]
+	}
+	x.mu.Unlock()
+
+	defer func() {
+		x.mu.Lock()
+		delete(x.running, task.ID)
+		x.mu.Unlock()
+	}()
+
+	// 試行は仮想時刻の同じ瞬間に終わる（時刻は周ごとにだけ進む）
+	now := x.clock.Now()
+	attempt := &Attempt{TaskID: task.ID, StartedAt: now, FinishedAt: &now, WorkerRuns: 1}
+	if outcome.Fail {
+		msg := outcome.Error
+		if msg == "" {
+			msg = "simulated failure"
+		}
+		attempt.Status = AttemptStatusFailed
+		attempt.ErrorSummary = msg
+		return attempt, errors.New(msg)
+	}
+	attempt.Status = AttemptStatusSucceeded
+	attempt.Artifacts = &Artifacts{Files: outcome.Files}
+	return attempt, nil
+}
+
+// stats はタスクごとの実行回数と並行実行の回数を返す
+func (x *simExecutor) stats() (map[string]int, int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	executions := make(map[string]int, len(x.executions))
+	for id, n := range x.executions {
+		executions[id] = n
+	}
+	return executions, x.overlaps
+}
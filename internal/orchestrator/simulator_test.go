@@ -0,0 +1,206 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// firstTraceIndex は条件に合う最初のトレース行の位置を返す（無ければ -1）
+func firstTraceIndex(trace []SimEvent, match func(SimEvent) bool) int {
+	for i, ev := range trace {
+		if match(ev) {
+			return i
+		}
+	}
+	return -1
+}
+
+func attemptStarts(trace []SimEvent, taskID string) []SimEvent {
+	var out []SimEvent
+	for _, ev := range trace {
+		if ev.TaskID == taskID && ev.Kind == persistence.ActionTaskAttemptStarted {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func succeededIndex(trace []SimEvent, taskID string) int {
+	return firstTraceIndex(trace, func(ev SimEvent) bool {
+		return ev.TaskID == taskID && ev.Kind == persistence.ActionTaskStatusChanged && ev.To == string(TaskStatusSucceeded)
+	})
+}
+
+func TestRunSimulation_RetryBackoffAndDependencies(t *testing.T) {
+	spec := SimulationSpec{
+		Nodes: []persistence.NodeDesign{
+			{NodeID: "a", Name: "A"},
+			{NodeID: "b", Name: "B", Dependencies: []string{"a"}},
+			{NodeID: "c", Name: "C", Dependencies: []string{"b"}},
+		},
+		Outcomes: map[string][]SimOutcome{
+			"a": {{Fail: true}, {Fail: true}},
+		},
+	}
+
+	res, err := RunSimulation(context.Background(), t.TempDir(), spec)
+	require.NoError(t, err)
+	assert.True(t, res.Quiescent)
+	assert.Zero(t, res.Overlaps)
+	assert.Equal(t, map[string]int{"a": 3, "b": 1, "c": 1}, res.Executions)
+	require.Len(t, res.Tasks, 3)
+	for _, task := range res.Tasks {
+		assert.Equal(t, string(TaskStatusSucceeded), task.Status, task.TaskID)
+	}
+
+	// リトライは 5 秒・10 秒のバックオフを仮想時間で待ってから再実行される
+	starts := attemptStarts(res.Trace, "a")
+	require.Len(t, starts, 3)
+	assert.GreaterOrEqual(t, starts[1].At.Sub(starts[0].At), 5*time.Second)
+	assert.GreaterOrEqual(t, starts[2].At.Sub(starts[1].At), 10*time.Second)
+	assert.Less(t, starts[2].At.Sub(starts[1].At), 10*time.Second+2*executionPollInterval)
+
+	// 依存先の成功後に実行される
+	assert.Greater(t, firstTraceIndex(res.Trace, func(ev SimEvent) bool {
+		return ev.TaskID == "b" && ev.Kind == persistence.ActionTaskAttemptStarted
+	}), succeededIndex(res.Trace, "a"))
+	assert.Greater(t, firstTraceIndex(res.Trace, func(ev SimEvent) bool {
+		return ev.TaskID == "c" && ev.Kind == persistence.ActionTaskAttemptStarted
+	}), succeededIndex(res.Trace, "b"))
+
+	// 同じ入力からは同じトレースになる
+	again, err := RunSimulation(context.Background(), t.TempDir(), spec)
+	require.NoError(t, err)
+	assert.Equal(t, res.Trace, again.Trace)
+	assert.Equal(t, res.Elapsed, again.Elapsed)
+}
+
+func TestRunSimulation_ExhaustedRetriesLeaveDependentsBlocked(t *testing.T) {
+	spec := SimulationSpec{
+		Nodes: []persistence.NodeDesign{
+			{NodeID: "a"},
+			{NodeID: "b", Dependencies: []string{"a"}},
+		},
+		Outcomes: map[string][]SimOutcome{
+			"a": {{Fail: true}, {Fail: true}, {Fail: true}},
+		},
+		RetryPolicy: &RetryPolicy{MaxAttempts: 2, BackoffBase: time.Minute, BackoffMax: time.Hour, BackoffFactor: 2, RequireHuman: true},
+	}
+
+	res, err := RunSimulation(context.Background(), t.TempDir(), spec)
+	require.NoError(t, err)
+	assert.True(t, res.Quiescent)
+	assert.Equal(t, map[string]int{"a": 2}, res.Executions)
+	require.Len(t, res.Tasks, 2)
+	assert.Equal(t, string(TaskStatusFailed), res.Tasks[0].Status)
+	assert.Equal(t, string(TaskStatusBlocked), res.Tasks[1].Status)
+	assert.GreaterOrEqual(t, res.Elapsed, time.Minute)
+}
+
+// TestRunSimulation_Invariants は無作為な WBS と失敗の台本で実行ループの不変条件を確かめる
+// - タスクが失われない（すべてのタスクが残り、依存が成功したタスクは成功か失敗で終わる）
+// - 二重に実行されない（並行実行なし・成功後の再実行なし・試行回数は上限まで）
+// - 依存先の成功より前に実行されない
+func TestRunSimulation_Invariants(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 20
+	properties := gopter.NewProperties(parameters)
+
+	const maxAttempts = 3
+	policy := &RetryPolicy{MaxAttempts: maxAttempts, BackoffBase: 5 * time.Second, BackoffMax: time.Minute, BackoffFactor: 2, RequireHuman: true}
+
+	properties.Property("no lost tasks and no double execution", prop.ForAll(
+		func(seed int64, size int, concurrency int) bool {
+			rng := rand.New(rand.NewSource(seed))
+			spec := SimulationSpec{RetryPolicy: policy, Concurrency: concurrency, Outcomes: map[string][]SimOutcome{}}
+			fails := make(map[string]int)
+			for i := 0; i < size; i++ {
+				node := persistence.NodeDesign{NodeID: fmt.Sprintf("n%d", i)}
+				for j := 0; j < i; j++ {
+					if rng.Intn(3) == 0 {
+						node.Dependencies = append(node.Dependencies, fmt.Sprintf("n%d", j))
+					}
+				}
+				fails[node.NodeID] = rng.Intn(maxAttempts + 1)
+				for k := 0; k < fails[node.NodeID]; k++ {
+					spec.Outcomes[node.NodeID] = append(spec.Outcomes[node.NodeID], SimOutcome{Fail: true})
+				}
+				spec.Nodes = append(spec.Nodes, node)
+			}
+
+			dir, err := os.MkdirTemp(t.TempDir(), "sim")
+			if err != nil {
+				t.Log(err)
+				return false
+			}
+			res, err := RunSimulation(context.Background(), dir, spec)
+			if err != nil {
+				t.Log(err)
+				return false
+			}
+			if !res.Quiescent || res.Overlaps != 0 || len(res.Tasks) != size {
+				t.Logf("quiescent=%v overlaps=%d tasks=%d", res.Quiescent, res.Overlaps, len(res.Tasks))
+				return false
+			}
+
+			statuses := make(map[string]string)
+			for _, task := range res.Tasks {
+				statuses[task.TaskID] = task.Status
+			}
+			succeeded := make(map[string]bool)
+			for _, node := range spec.Nodes {
+				id := node.NodeID
+				depsOK := true
+				for _, dep := range node.Dependencies {
+					depsOK = depsOK && succeeded[dep]
+				}
+				want, wantRuns := string(TaskStatusBlocked), 0
+				if depsOK {
+					succeeded[id] = fails[id] < maxAttempts
+					want, wantRuns = string(TaskStatusFailed), maxAttempts
+					if succeeded[id] {
+						want, wantRuns = string(TaskStatusSucceeded), fails[id]+1
+					}
+				}
+				if statuses[id] != want || res.Executions[id] != wantRuns || len(attemptStarts(res.Trace, id)) != wantRuns {
+					t.Logf("%s: status=%s executions=%d, want %s/%d", id, statuses[id], res.Executions[id], want, wantRuns)
+					return false
+				}
+
+				done := succeededIndex(res.Trace, id)
+				for i, ev := range res.Trace {
+					if ev.TaskID != id || ev.Kind != persistence.ActionTaskAttemptStarted {
+						continue
+					}
+					if done >= 0 && i > done {
+						t.Logf("%s: executed after success", id)
+						return false
+					}
+					for _, dep := range node.Dependencies {
+						if i < succeededIndex(res.Trace, dep) {
+							t.Logf("%s: executed before dependency %s succeeded", id, dep)
+							return false
+						}
+					}
+				}
+			}
+			return true
+		},
+		gen.Int64(),
+		gen.IntRange(1, 5),
+		gen.IntRange(1, 2),
+	))
+
+	properties.TestingRun(t)
+}
@@ -23,6 +23,7 @@ const (
 	TaskStatusCanceled  TaskStatus = "CANCELED"
 	TaskStatusBlocked   TaskStatus = "BLOCKED"
 	TaskStatusRetryWait TaskStatus = "RETRY_WAIT"
+	TaskStatusSkipped   TaskStatus = "SKIPPED" // バックログでスキップされた（後続タスクは実行できる）
 )
 
 // Default runner settings for AgentRunner tasks.
@@ -33,10 +34,26 @@ const (
 
 // Inputs map keys (state/tasks.json).
 const (
-	InputKeyAttemptCount     = "attempt_count"
-	InputKeyNextRetryAt      = "next_retry_at"
-	InputKeyRunnerMaxLoops   = "runner_max_loops"
-	InputKeyRunnerWorkerKind = "runner_worker_kind"
+	InputKeyAttemptCount         = "attempt_count"
+	InputKeyNextRetryAt          = "next_retry_at"
+	InputKeyRunnerMaxLoops       = "runner_max_loops"
+	InputKeyRunnerWorkerKind     = "runner_worker_kind"
+	InputKeyPoolID               = "pool_id"
+	InputKeyLabels               = "labels"
+	InputKeyTitle                = "title"                 // ノード設計を持たないタスク（手動・旧 TaskStore から移行）の表示名
+	InputKeySourceChatID         = "source_chat_id"        // 生成元チャットセッション ID
+	InputKeyToolingProfile       = "tooling_profile"       // tooling プロファイルの指定（Pool の指定より優先）
+	InputKeyAnswers              = "answers"               // バックログの QUESTION への回答（TaskAnswer の配列）
+	InputKeyAwaitingAnswer       = "awaiting_answer"       // 回答を待っている QUESTION のバックログアイテム ID
+	InputKeyReplanCount          = "replan_count"          // 自動再計画の回数（再計画で作成したタスクは元の回数を引き継ぐ）
+	InputKeyWaitReason           = "wait_reason"           // 依存は満たされているが実行を待っている理由（ファイルのロック待ち）
+	InputKeyVerifiesTaskID       = "verifies_task_id"      // 検証タスクが検証する実装タスクの ID
+	InputKeyVerificationFailures = "verification_failures" // 検証タスクが失敗した回数（flaky の判定に使う）
+	InputKeyCapabilities         = "capabilities"          // 実行するエージェントに求める能力のタグ（kind・言語から導く能力に追加する）
+	InputKeyScheduleID           = "schedule_id"           // 作成元の定期タスクの定義 ID
+	InputKeyAwaitingApproval     = "awaiting_approval"     // 承認を待っている APPROVAL のバックログアイテム ID
+	InputKeyApprovalGate         = "approval_gate"         // 待っている承認ゲート（diff は承認後に実行ループが完了させるまで残る）
+	InputKeyApprovedGates        = "approved_gates"        // 承認済みの承認ゲート（ApprovalGate の配列）
 )
 
 // Task represents a unit of work.
@@ -60,6 +77,8 @@ type Task struct {
 	Milestone          string   `json:"milestone,omitempty"`          // マイルストーン名（Phase単位のまとまり）
 	SourceChatID       *string  `json:"sourceChatId,omitempty"`       // 生成元チャットセッションID
 	AcceptanceCriteria []string `json:"acceptanceCriteria,omitempty"` // 達成条件リスト
+	EstimatedEffort    string   `json:"estimatedEffort,omitempty"`    // 推定工数（small/medium/large、ノード設計の Estimate.Difficulty）
+	StoryPoints        int      `json:"storyPoints,omitempty"`        // ストーリーポイント（ノード設計の Estimate.StoryPoints）
 
 	// リトライ管理用 (v2.0 Extension)
 	AttemptCount int        `json:"attemptCount,omitempty"` // 試行回数
@@ -70,7 +89,17 @@ type Task struct {
 	Artifacts     *Artifacts     `json:"artifacts,omitempty"`     // 生成物（ファイル、ログ等）
 
 	// Runner settings (legacy TaskStore / YAML generation).
-	Runner *RunnerSpec `json:"runner,omitempty"`
+	Runner         *RunnerSpec  `json:"runner,omitempty"`
+	ToolingProfile string       `json:"toolingProfile,omitempty"` // tooling プロファイルの指定（Pool の指定より優先）
+	Answers        []TaskAnswer `json:"answers,omitempty"`        // バックログの QUESTION への回答
+	WaitReason     string       `json:"waitReason,omitempty"`     // PENDING のまま実行を待っている理由（ファイルのロック待ち）
+}
+
+// TaskAnswer is an answer to a question raised by a task.
+type TaskAnswer struct {
+	Question   string    `json:"question"`
+	Answer     string    `json:"answer"`
+	AnsweredAt time.Time `json:"answeredAt"`
 }
 
 // RunnerSpec holds execution hints for AgentRunner.
@@ -88,8 +117,16 @@ type SuggestedImpl struct {
 
 // Artifacts represents the outputs generated by the task execution.
 type Artifacts struct {
-	Files []string `json:"files,omitempty"` // 生成・変更されたファイルのパス
-	Logs  []string `json:"logs,omitempty"`  // 関連するログファイルのパス
+	Files []string   `json:"files,omitempty"` // 生成・変更されたファイルのパス
+	Logs  []string   `json:"logs,omitempty"`  // 関連するログファイルのパス
+	Diffs []FileDiff `json:"diffs,omitempty"` // 試行で変更したファイルの差分（試行の記録のみ）
+}
+
+// FileDiff は試行で変更した 1 ファイルの差分（git diff の出力）
+type FileDiff struct {
+	Path      string `json:"path"`
+	Patch     string `json:"patch,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"` // 記録の上限を超えたため Patch を切り詰めた（または省いた）
 }
 
 // AttemptStatus represents the status of an attempt.
@@ -106,15 +143,35 @@ const (
 
 // Attempt represents a single execution attempt of a task.
 type Attempt struct {
-	ID           string        `json:"id"`
-	TaskID       string        `json:"taskId"`
-	Status       AttemptStatus `json:"status"`
-	StartedAt    time.Time     `json:"startedAt"`
-	FinishedAt   *time.Time    `json:"finishedAt,omitempty"`
-	ErrorSummary string        `json:"errorSummary,omitempty"`
+	ID           string          `json:"id"`
+	TaskID       string          `json:"taskId"`
+	PoolID       string          `json:"poolId,omitempty"`
+	Status       AttemptStatus   `json:"status"`
+	StartedAt    time.Time       `json:"startedAt"`
+	FinishedAt   *time.Time      `json:"finishedAt,omitempty"`
+	ExitCode     *int            `json:"exitCode,omitempty"` // agent-runner の終了コード（起動できなかった場合は nil）
+	ErrorSummary string          `json:"errorSummary,omitempty"`
+	FailureKind  FailureKind     `json:"failureKind,omitempty"` // 失敗の分類（リトライポリシーの選択に使う）
+	Tooling      *AttemptTooling `json:"tooling,omitempty"`     // agent-runner が選択した tooling の候補
+	Artifacts    *Artifacts      `json:"artifacts,omitempty"`
+	LogLines     int             `json:"logLines"` // 記録した stdout / stderr の行数
+
+	// 実績（見積もりとの比較用、Worker CLI が報告しなければ 0）
+	WorkerRuns   int     `json:"workerRuns,omitempty"`   // Worker の実行回数
+	InputTokens  int     `json:"inputTokens,omitempty"`  // Worker の入力トークン数
+	OutputTokens int     `json:"outputTokens,omitempty"` // Worker の出力トークン数
+	CostUSD      float64 `json:"costUsd,omitempty"`      // Worker のコスト（報告が無ければモデルの価格からの見積もり）
+}
+
+// AttemptTooling is the tooling candidate used by an attempt.
+type AttemptTooling struct {
+	Tool  string `json:"tool,omitempty"`
+	Model string `json:"model,omitempty"`
 }
 
-// TaskStore handles task and attempt persistence.
+// TaskStore reads and writes the legacy task store (tasks/*.jsonl, attempts/*.json).
+// Tasks and attempts now live in state/tasks.json and history; the legacy format is
+// only read by the v1 schema migration (ImportLegacyTaskStore).
 type TaskStore struct {
 	WorkspaceDir string
 }
@@ -306,76 +363,28 @@ func (s *TaskStore) ListAttemptsByTaskID(taskID string) ([]Attempt, error) {
 	return attempts, nil
 }
 
-// PoolSummary represents task counts by status for a pool.
-type PoolSummary struct {
-	PoolID  string         `json:"poolId"`
-	Running int            `json:"running"`
-	Queued  int            `json:"queued"`
-	Failed  int            `json:"failed"`
-	Total   int            `json:"total"`
-	Counts  map[string]int `json:"counts"`
-}
-
-// GetPoolSummaries returns task count summaries by pool.
-func (s *TaskStore) GetPoolSummaries() ([]PoolSummary, error) {
-	dir := s.GetTaskDir()
-	entries, err := os.ReadDir(dir)
-	if os.IsNotExist(err) {
-		return []PoolSummary{}, nil
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to read tasks directory: %w", err)
-	}
-
-	// poolID -> status -> count
-	poolCounts := make(map[string]map[TaskStatus]int)
-
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" {
-			continue
-		}
-
-		id := entry.Name()[:len(entry.Name())-6]
-		task, err := s.LoadTask(id)
-		if err != nil {
-			continue
-		}
-
-		if poolCounts[task.PoolID] == nil {
-			poolCounts[task.PoolID] = make(map[TaskStatus]int)
-		}
-		poolCounts[task.PoolID][task.Status]++
-	}
-
-	// Convert to PoolSummary slice
-	var summaries []PoolSummary
-	for poolID, statusCounts := range poolCounts {
-		summary := PoolSummary{
-			PoolID:  poolID,
-			Running: statusCounts[TaskStatusRunning],
-			Queued:  statusCounts[TaskStatusPending] + statusCounts[TaskStatusReady],
-			Failed:  statusCounts[TaskStatusFailed],
-			Counts:  make(map[string]int),
-		}
-		for status, count := range statusCounts {
-			summary.Counts[string(status)] = count
-			summary.Total += count
-		}
-		summaries = append(summaries, summary)
-	}
-
-	return summaries, nil
-}
-
 // Pool represents a worker pool configuration.
 type Pool struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
+
+	// 実行設定（worker-pools.json で Pool ごとに指定）
+	ToolingProfile string `json:"toolingProfile,omitempty"` // 使用する tooling プロファイル ID
+	WorkerImage    string `json:"workerImage,omitempty"`    // Worker の Docker イメージ
+	Concurrency    int    `json:"concurrency,omitempty"`    // 同時実行数（0 以下は 1）
+}
+
+// MaxConcurrency は Pool の同時実行数を返す
+func (p Pool) MaxConcurrency() int {
+	if p.Concurrency <= 0 {
+		return 1
+	}
+	return p.Concurrency
 }
 
 // DefaultPools はデフォルトの Pool 定義を返す
-// 将来的には worker-pools.json から読み込む
+// worker-pools.json が存在しない場合に使用する
 var DefaultPools = []Pool{
 	{ID: "default", Name: "Default", Description: "汎用タスク実行用"},
 	{ID: "codegen", Name: "Codegen", Description: "コード生成タスク用"},
@@ -384,30 +393,11 @@ var DefaultPools = []Pool{
 
 // GetAvailablePools は利用可能な Pool 一覧を返す
 func (s *TaskStore) GetAvailablePools() []Pool {
-	path := filepath.Join(s.WorkspaceDir, "worker-pools.json")
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return DefaultPools
-	}
-
-	data, err := os.ReadFile(path)
+	cfg, err := LoadWorkerPoolsConfig(s.WorkspaceDir)
 	if err != nil {
-		fmt.Printf("failed to read worker-pools.json: %v\n", err)
-		return DefaultPools
+		fmt.Printf("failed to load worker-pools.json: %v\n", err)
 	}
-
-	var config struct {
-		Pools []Pool `json:"pools"`
-	}
-	if err := json.Unmarshal(data, &config); err != nil {
-		fmt.Printf("failed to parse worker-pools.json: %v\n", err)
-		return DefaultPools
-	}
-
-	if len(config.Pools) == 0 {
-		return DefaultPools
-	}
-
-	return config.Pools
+	return cfg.Pools
 }
 
 // ListAllTasks は全タスクの最新状態を返す
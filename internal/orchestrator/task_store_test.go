@@ -210,83 +210,6 @@ func TestListAttemptsByTaskID(t *testing.T) {
 	}
 }
 
-func TestGetPoolSummaries(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "pool_summary_test")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	store := NewTaskStore(tmpDir)
-
-	// 空のディレクトリの場合
-	summaries, err := store.GetPoolSummaries()
-	if err != nil {
-		t.Fatalf("GetPoolSummaries failed on empty dir: %v", err)
-	}
-	if len(summaries) != 0 {
-		t.Errorf("expected 0 summaries, got %d", len(summaries))
-	}
-
-	// 複数のタスクを保存
-	tasks := []*Task{
-		{ID: "task-1", Title: "Task 1", Status: TaskStatusRunning, PoolID: "codegen", CreatedAt: time.Now()},
-		{ID: "task-2", Title: "Task 2", Status: TaskStatusRunning, PoolID: "codegen", CreatedAt: time.Now()},
-		{ID: "task-3", Title: "Task 3", Status: TaskStatusPending, PoolID: "codegen", CreatedAt: time.Now()},
-		{ID: "task-4", Title: "Task 4", Status: TaskStatusFailed, PoolID: "codegen", CreatedAt: time.Now()},
-		{ID: "task-5", Title: "Task 5", Status: TaskStatusRunning, PoolID: "test", CreatedAt: time.Now()},
-		{ID: "task-6", Title: "Task 6", Status: TaskStatusSucceeded, PoolID: "test", CreatedAt: time.Now()},
-	}
-
-	for _, task := range tasks {
-		if err := store.SaveTask(task); err != nil {
-			t.Fatalf("SaveTask failed: %v", err)
-		}
-	}
-
-	summaries, err = store.GetPoolSummaries()
-	if err != nil {
-		t.Fatalf("GetPoolSummaries failed: %v", err)
-	}
-	if len(summaries) != 2 {
-		t.Errorf("expected 2 pools, got %d", len(summaries))
-	}
-
-	// サマリを検証
-	poolMap := make(map[string]PoolSummary)
-	for _, s := range summaries {
-		poolMap[s.PoolID] = s
-	}
-
-	codegen, ok := poolMap["codegen"]
-	if !ok {
-		t.Fatal("codegen pool not found")
-	}
-	if codegen.Running != 2 {
-		t.Errorf("expected codegen running=2, got %d", codegen.Running)
-	}
-	if codegen.Queued != 1 {
-		t.Errorf("expected codegen queued=1, got %d", codegen.Queued)
-	}
-	if codegen.Failed != 1 {
-		t.Errorf("expected codegen failed=1, got %d", codegen.Failed)
-	}
-	if codegen.Total != 4 {
-		t.Errorf("expected codegen total=4, got %d", codegen.Total)
-	}
-
-	testPool, ok := poolMap["test"]
-	if !ok {
-		t.Fatal("test pool not found")
-	}
-	if testPool.Running != 1 {
-		t.Errorf("expected test running=1, got %d", testPool.Running)
-	}
-	if testPool.Total != 2 {
-		t.Errorf("expected test total=2, got %d", testPool.Total)
-	}
-}
-
 func TestGetAvailablePools(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "available_pools_test")
 	if err != nil {
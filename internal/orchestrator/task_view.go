@@ -0,0 +1,311 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/google/uuid"
+)
+
+// ListTaskViews は state/tasks.json と設計情報から表示用の Task 一覧を組み立てる
+// 並び順は WBS の深さ優先順で、WBS に無いタスクは tasks.json の順で末尾に続く。
+// IDE（Wails バインディング）とデーモンの HTTP API で共有する。
+func ListTaskViews(repo persistence.WorkspaceRepository) ([]Task, error) {
+	tasksState, err := repo.State().LoadTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	// Load WBS for ordering / parent info (best-effort).
+	var wbs *persistence.WBS
+	parentByID := map[string]*string{}
+	childrenByID := map[string][]string{}
+	if loaded, err := repo.Design().LoadWBS(); err == nil && loaded != nil {
+		wbs = loaded
+		for i := range wbs.NodeIndex {
+			n := wbs.NodeIndex[i]
+			parentByID[n.NodeID] = n.ParentID
+			childrenByID[n.NodeID] = n.Children
+		}
+	}
+
+	// task_id -> TaskState (preserve original order for fallback).
+	taskStateByID := make(map[string]persistence.TaskState, len(tasksState.Tasks))
+	originalOrder := make([]string, 0, len(tasksState.Tasks))
+	for _, t := range tasksState.Tasks {
+		taskStateByID[t.TaskID] = t
+		originalOrder = append(originalOrder, t.TaskID)
+	}
+
+	// Determine ordered task IDs (WBS DFS order -> fallback).
+	orderedTaskIDs := make([]string, 0, len(tasksState.Tasks))
+	seen := make(map[string]struct{}, len(tasksState.Tasks))
+	if wbs != nil && wbs.RootNodeID != "" {
+		var stack []string
+		stack = append(stack, wbs.RootNodeID)
+		for len(stack) > 0 {
+			// pop
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if _, ok := taskStateByID[n]; ok {
+				if _, dup := seen[n]; !dup {
+					seen[n] = struct{}{}
+					orderedTaskIDs = append(orderedTaskIDs, n)
+				}
+			}
+
+			children := childrenByID[n]
+			// push reversed for stable order
+			for i := len(children) - 1; i >= 0; i-- {
+				stack = append(stack, children[i])
+			}
+		}
+	}
+	for _, id := range originalOrder {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		orderedTaskIDs = append(orderedTaskIDs, id)
+	}
+
+	// NodeDesign cache to minimize disk access.
+	nodeCache := make(map[string]*persistence.NodeDesign)
+	loadNode := func(nodeID string) *persistence.NodeDesign {
+		if nodeID == "" {
+			return nil
+		}
+		if cached, ok := nodeCache[nodeID]; ok {
+			return cached
+		}
+		node, err := repo.Design().GetNode(nodeID)
+		if err != nil {
+			nodeCache[nodeID] = nil
+			return nil
+		}
+		nodeCache[nodeID] = node
+		return node
+	}
+
+	tasks := make([]Task, 0, len(orderedTaskIDs))
+	for _, id := range orderedTaskIDs {
+		ts, ok := taskStateByID[id]
+		if !ok {
+			continue
+		}
+
+		title := ""
+		poolID := DefaultPoolID
+		if s := inputString(ts.Inputs, InputKeyTitle); s != "" {
+			title = s
+		}
+		if s := inputString(ts.Inputs, InputKeyPoolID); s != "" {
+			poolID = s
+		}
+
+		node := loadNode(ts.NodeID)
+		if title == "" {
+			if node != nil && node.Name != "" {
+				title = node.Name
+			} else if ts.NodeID != "" {
+				title = ts.Kind + ": " + ts.NodeID
+			} else {
+				title = ts.Kind + ": " + ts.TaskID
+			}
+		}
+
+		task := Task{
+			ID:             ts.TaskID,
+			Title:          title,
+			Status:         TaskStatus(ts.Status),
+			PoolID:         poolID,
+			CreatedAt:      ts.CreatedAt,
+			UpdatedAt:      ts.UpdatedAt,
+			StartedAt:      ts.StartedAt,
+			DoneAt:         ts.DoneAt,
+			AttemptCount:   inputInt(ts.Inputs, InputKeyAttemptCount),
+			Runner:         runnerSpecFromInputs(ts.Inputs),
+			ToolingProfile: inputString(ts.Inputs, InputKeyToolingProfile),
+			Answers:        taskAnswersFromInputs(ts.Inputs),
+		}
+		if s := inputString(ts.Inputs, InputKeySourceChatID); s != "" {
+			task.SourceChatID = &s
+		}
+		if TaskStatus(ts.Status) == TaskStatusPending {
+			task.WaitReason = inputString(ts.Inputs, InputKeyWaitReason)
+		}
+		if s := inputString(ts.Inputs, InputKeyNextRetryAt); s != "" {
+			if at, err := time.Parse(time.RFC3339, s); err == nil {
+				task.NextRetryAt = &at
+			}
+		}
+		if len(ts.Outputs.Files) > 0 || len(ts.Outputs.Logs) > 0 {
+			task.Artifacts = &Artifacts{Files: ts.Outputs.Files, Logs: ts.Outputs.Logs}
+		}
+
+		// Best-effort enrich from NodeDesign.
+		if node != nil {
+			task.Description = node.Summary
+			task.Dependencies = append([]string{}, node.Dependencies...)
+			task.WBSLevel = node.WBSLevel
+			task.PhaseName = node.PhaseName
+			task.Milestone = node.Milestone
+			task.AcceptanceCriteria = append([]string{}, node.AcceptanceCriteria...)
+			task.EstimatedEffort = node.Estimate.Difficulty
+			task.StoryPoints = node.Estimate.StoryPoints
+			task.SuggestedImpl = &SuggestedImpl{
+				Language:    node.SuggestedImpl.Language,
+				FilePaths:   append([]string{}, node.SuggestedImpl.FilePaths...),
+				Constraints: append([]string{}, node.SuggestedImpl.Constraints...),
+			}
+		}
+
+		// 検証タスクは同じノードの実装タスクの後に実行する
+		if verifies := inputString(ts.Inputs, InputKeyVerifiesTaskID); verifies != "" {
+			task.Dependencies = []string{verifies}
+		}
+
+		if p, ok := parentByID[task.ID]; ok {
+			task.ParentID = p
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// FindTaskView は ListTaskViews の結果から taskID のタスクを返す
+func FindTaskView(repo persistence.WorkspaceRepository, taskID string) (*Task, error) {
+	tasks, err := ListTaskViews(repo)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			return &tasks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("task not found: %s", taskID)
+}
+
+// inputString は inputs の文字列値を返す（無い・文字列でない場合は空）
+func inputString(inputs map[string]interface{}, key string) string {
+	s, _ := inputs[key].(string)
+	return s
+}
+
+// taskAnswersFromInputs は inputs に記録された QUESTION への回答を返す
+func taskAnswersFromInputs(inputs map[string]interface{}) []TaskAnswer {
+	list, _ := inputs[InputKeyAnswers].([]interface{})
+	var answers []TaskAnswer
+	for _, v := range list {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		answer := TaskAnswer{Question: inputString(m, "question"), Answer: inputString(m, "answer")}
+		if at, err := time.Parse(time.RFC3339, inputString(m, "answered_at")); err == nil {
+			answer.AnsweredAt = at
+		}
+		answers = append(answers, answer)
+	}
+	return answers
+}
+
+// appendTaskAnswer は inputs に QUESTION への回答を追記する（JSON から読み込んだ形と揃える）
+func appendTaskAnswer(inputs map[string]interface{}, answer TaskAnswer) {
+	list, _ := inputs[InputKeyAnswers].([]interface{})
+	inputs[InputKeyAnswers] = append(list, map[string]interface{}{
+		"question":    answer.Question,
+		"answer":      answer.Answer,
+		"answered_at": answer.AnsweredAt.Format(time.RFC3339),
+	})
+}
+
+// inputInt は inputs の整数値を返す（JSON から読み込んだ float64 も扱う）
+func inputInt(inputs map[string]interface{}, key string) int {
+	switch v := inputs[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// CreateManualTask はノード設計を持たない手動タスクを tasks.json に追加する
+// NOTE: V2 では本来 Planner 経由で WBS/Node を作成すべきで、直接作成は簡易タスク用。
+// スキーマ上 NodeID が必要なため "manual-<task-id>" をダミーとして設定する。
+func CreateManualTask(repo persistence.WorkspaceRepository, title string, poolID string) (*Task, error) {
+	taskID := uuid.New().String()
+	now := time.Now()
+
+	newState := persistence.TaskState{
+		TaskID:    taskID,
+		NodeID:    manualNodePrefix + taskID, // Dummy
+		Kind:      "manual",
+		Status:    string(TaskStatusPending),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Inputs:    map[string]interface{}{InputKeyTitle: title, InputKeyPoolID: poolID},
+	}
+	err := repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		tasksState.Tasks = append(tasksState.Tasks, newState)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save tasks: %w", err)
+	}
+
+	return &Task{
+		ID:        taskID,
+		Title:     title,
+		Status:    TaskStatusPending,
+		PoolID:    poolID,
+		CreatedAt: now,
+	}, nil
+}
+
+// ErrTaskRunning は実行中のタスクを状態ファイルだけで取り消そうとした場合のエラー
+// 実行中タスクの取り消しは実行ループを所有するプロセス（ExecutionOrchestrator.CancelTask）が行う。
+var ErrTaskRunning = errors.New("task is running")
+
+// isTerminalTaskStatus は再実行されない完了状態かどうかを返す
+func isTerminalTaskStatus(status string) bool {
+	switch TaskStatus(strings.ToUpper(status)) {
+	case TaskStatusSucceeded, TaskStatusCompleted, TaskStatusFailed, TaskStatusCanceled, TaskStatusSkipped:
+		return true
+	}
+	return false
+}
+
+// CancelTaskState は実行中でない未完了タスクを CANCELED にし、変更前の状態を返す
+// キュー投入済みのジョブは processJob が CANCELED を見て破棄する。
+func CancelTaskState(repo persistence.WorkspaceRepository, taskID string) (TaskStatus, error) {
+	var oldStatus TaskStatus
+	err := repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		t := findTaskState(tasksState, taskID)
+		if t == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+		if strings.EqualFold(t.Status, string(TaskStatusRunning)) {
+			return ErrTaskRunning
+		}
+		if isTerminalTaskStatus(t.Status) {
+			return fmt.Errorf("task already finished: %s (%s)", taskID, t.Status)
+		}
+		oldStatus = TaskStatus(t.Status)
+		t.Status = string(TaskStatusCanceled)
+		t.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return oldStatus, nil
+}
@@ -0,0 +1,339 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/google/uuid"
+)
+
+// ノードの検証: 実装タスクが成功してノードが implemented になったら、同じノードの検証タスク（Kind: test）を作成する。
+// 検証タスクはノードのテスト・受け入れ条件を確認し、結果をノードの verification に残す。
+//   - passed: 初回で通った。ノードは verified になる
+//   - flaky: 失敗した後の再実行で通った。ノードは implemented のまま
+//   - failed: 最大実行回数まで失敗した。ノードは implemented のままで、バックログに送る
+//
+// NodeDesign.RequiresVerified のノードは、依存先が verified（またはスキップ）になるまで実行しない。
+
+// VerificationFileName はワークスペース直下の検証設定ファイル名
+const VerificationFileName = "verification.json"
+
+// TaskKindTest は検証タスクの TaskState.Kind
+const TaskKindTest = "test"
+
+// DefaultVerifiedTaskKinds は成功したら検証タスクを作成する既定のタスク種別
+var DefaultVerifiedTaskKinds = []string{"implementation"}
+
+// DefaultVerificationMaxRuns は検証タスクの既定の最大実行回数（1 回の再実行で flaky を検出する）
+const DefaultVerificationMaxRuns = 2
+
+// VerificationConfig は verification.json の内容を表す
+type VerificationConfig struct {
+	Enabled   bool     `json:"enabled"`
+	TaskKinds []string `json:"taskKinds,omitempty"` // 検証するタスク種別（空は DefaultVerifiedTaskKinds）
+	MaxRuns   int      `json:"maxRuns,omitempty"`   // 失敗した検証を再実行する上限の回数（0 は DefaultVerificationMaxRuns、1 は再実行しない）
+	// OnImpact は完了したノードの設計変更・再実装が影響するノードの再検証の扱い（空は ImpactPolicyConfirm）
+	OnImpact ImpactPolicy `json:"onImpact,omitempty"`
+}
+
+// LoadVerificationConfig はワークスペースの verification.json を読み込む
+// ファイルが存在しない場合は無効の設定を返す。
+func LoadVerificationConfig(workspaceDir string) (*VerificationConfig, error) {
+	path := filepath.Join(workspaceDir, VerificationFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &VerificationConfig{}, nil
+		}
+		return &VerificationConfig{}, fmt.Errorf("failed to read %s: %w", VerificationFileName, err)
+	}
+
+	var cfg VerificationConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return &VerificationConfig{}, fmt.Errorf("failed to parse %s: %w", VerificationFileName, err)
+	}
+	if cfg.MaxRuns < 0 {
+		return &VerificationConfig{}, fmt.Errorf("invalid %s: maxRuns must not be negative", VerificationFileName)
+	}
+	if !cfg.OnImpact.IsValid() {
+		return &VerificationConfig{}, fmt.Errorf("invalid %s: unknown onImpact %q", VerificationFileName, cfg.OnImpact)
+	}
+	return &cfg, nil
+}
+
+// Verifies は taskKind のタスクが成功したときに検証タスクを作成するかを返す
+func (c *VerificationConfig) Verifies(taskKind string) bool {
+	if c == nil || !c.Enabled {
+		return false
+	}
+	kinds := c.TaskKinds
+	if len(kinds) == 0 {
+		kinds = DefaultVerifiedTaskKinds
+	}
+	return containsString(kinds, taskKind)
+}
+
+// MaxRunsOrDefault は検証タスクの最大実行回数を返す
+func (c *VerificationConfig) MaxRunsOrDefault() int {
+	if c != nil && c.MaxRuns > 0 {
+		return c.MaxRuns
+	}
+	return DefaultVerificationMaxRuns
+}
+
+// SetVerification は検証タスクの設定を設定する（nil で無効）
+func (e *ExecutionOrchestrator) SetVerification(cfg *VerificationConfig) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.verification = cfg
+}
+
+func (e *ExecutionOrchestrator) verificationConfig() *VerificationConfig {
+	e.stateMu.RLock()
+	defer e.stateMu.RUnlock()
+	return e.verification
+}
+
+// isVerificationTask は検証タスクかどうかを返す
+func isVerificationTask(task *persistence.TaskState) bool {
+	return task.Kind == TaskKindTest && inputString(task.Inputs, InputKeyVerifiesTaskID) != ""
+}
+
+// verificationTestFailure は検証タスクの失敗が「テストが通らなかった」ことを表すかを返す
+// それ以外（レート制限・認証・サンドボックス基盤など）は通常のリトライポリシーで扱う。
+func verificationTestFailure(kind FailureKind) bool {
+	return kind == FailureValidationFailed || kind == FailureAgentGaveUp
+}
+
+// applyVerificationPrompt は検証タスクとして Executor に渡す内容に書き換える
+func applyVerificationPrompt(task *Task) {
+	task.Title = "検証: " + task.Title
+	lines := []string{
+		"このタスクは実装済みのノードの検証です。コードは変更せず、関連するテストと受け入れ条件を確認してください。",
+		"テストが失敗する、または受け入れ条件を満たさない場合はタスクを失敗として終了してください。",
+	}
+	if task.Description != "" {
+		lines = append(lines, "", "ノードの概要: "+task.Description)
+	}
+	task.Description = strings.Join(lines, "\n")
+}
+
+// scheduleVerification は成功した実装タスクのノードの検証タスクを作成する（未完了の検証タスクがあれば作成しない）
+func (e *ExecutionOrchestrator) scheduleVerification(task *persistence.TaskState) {
+	cfg := e.verificationConfig()
+	if !cfg.Verifies(task.Kind) || task.NodeID == "" || strings.HasPrefix(task.NodeID, manualNodePrefix) {
+		return
+	}
+	verificationID, err := CreateVerificationTask(e.Repo, task.NodeID, task.TaskID, inputString(task.Inputs, InputKeyPoolID))
+	if err != nil {
+		e.logger.Error("failed to create verification task", slog.String("task_id", task.TaskID), slog.Any("error", err))
+		return
+	}
+	if verificationID != "" {
+		e.logger.Info("verification task created",
+			slog.String("task_id", verificationID),
+			slog.String("node_id", task.NodeID),
+			slog.String("verifies_task_id", task.TaskID),
+		)
+	}
+}
+
+// CreateVerificationTask はノードの検証タスクを PENDING で作成し、その ID を返す
+// 未完了の検証タスクが既にあれば作成せずに空を返す。verifiesTaskID は検証する実装タスク。
+func CreateVerificationTask(repo persistence.WorkspaceRepository, nodeID, verifiesTaskID, poolID string) (string, error) {
+	title := nodeID
+	if node, err := repo.Design().GetNode(nodeID); err == nil && node.Name != "" {
+		title = node.Name
+	}
+	now := repo.Clock().Now()
+	verificationID := ""
+	err := repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		verificationID = ""
+		for i := range tasksState.Tasks {
+			t := &tasksState.Tasks[i]
+			if t.NodeID == nodeID && isVerificationTask(t) && !isTerminalTaskStatus(t.Status) {
+				return persistence.ErrNoChange
+			}
+		}
+		inputs := map[string]interface{}{
+			InputKeyTitle:          "検証: " + title,
+			InputKeyVerifiesTaskID: verifiesTaskID,
+		}
+		if poolID != "" {
+			inputs[InputKeyPoolID] = poolID
+		}
+		verificationID = uuid.New().String()
+		tasksState.Tasks = append(tasksState.Tasks, persistence.TaskState{
+			TaskID:      verificationID,
+			NodeID:      nodeID,
+			Kind:        TaskKindTest,
+			Status:      string(TaskStatusPending),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			ScheduledBy: "verification",
+			Inputs:      inputs,
+		})
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save tasks state: %w", err)
+	}
+	return verificationID, nil
+}
+
+// finishVerification は検証タスクの試行結果から、再実行するか結果を確定するかを決めて保存する
+// passed はテスト・受け入れ条件を満たしたかどうか。
+func (e *ExecutionOrchestrator) finishVerification(task *persistence.TaskState, passed bool, finishedAt time.Time) error {
+	maxRuns := e.verificationConfig().MaxRunsOrDefault()
+
+	// 失敗回数は保存済みの状態から読む（再実行のたびに数える）
+	failures := 0
+	if state, err := e.Repo.State().LoadTasks(); err == nil {
+		if saved := findTaskState(state, task.TaskID); saved != nil {
+			failures = inputInt(saved.Inputs, InputKeyVerificationFailures)
+		}
+	}
+	if !passed {
+		failures++
+	}
+
+	var verdict persistence.NodeVerificationStatus
+	newStatus := TaskStatusPending
+	switch {
+	case passed && failures == 0:
+		verdict, newStatus = persistence.NodeVerificationPassed, TaskStatusSucceeded
+	case passed:
+		verdict, newStatus = persistence.NodeVerificationFlaky, TaskStatusSucceeded
+	case failures >= maxRuns:
+		verdict, newStatus = persistence.NodeVerificationFailed, TaskStatusFailed
+	}
+	runs := failures
+	if passed {
+		runs++
+	}
+
+	var historyActionID string
+	if verdict != "" {
+		action, err := persistence.NewAction(persistence.ActionNodeVerified, workspaceIDOf(e.Repo), finishedAt, persistence.NodeVerifiedPayload{
+			NodeID: task.NodeID,
+			TaskID: task.TaskID,
+			Status: string(verdict),
+			Runs:   runs,
+		})
+		if err != nil {
+			return err
+		}
+		if err := e.Repo.History().AppendAction(action); err != nil {
+			return fmt.Errorf("failed to append history: %w", err)
+		}
+		historyActionID = action.ID
+		if err := e.saveNodeVerification(task.NodeID, task.TaskID, verdict, runs, finishedAt); err != nil {
+			recordStateSaveFailed(e.Repo, e.logger, historyActionID, "save_nodes_runtime", err)
+			return err
+		}
+	}
+
+	var oldStatus TaskStatus
+	err := e.Repo.State().UpdateTasks(func(tasksState *persistence.TasksState) error {
+		t := findTaskState(tasksState, task.TaskID)
+		if t == nil {
+			return fmt.Errorf("task not found: %s", task.TaskID)
+		}
+		if t.Inputs == nil {
+			t.Inputs = make(map[string]interface{})
+		}
+		oldStatus = TaskStatus(t.Status)
+		t.Inputs[InputKeyVerificationFailures] = failures
+		t.Status = string(newStatus)
+		t.UpdatedAt = finishedAt
+		if isTerminalTaskStatus(string(newStatus)) {
+			t.DoneAt = &finishedAt
+		}
+		*task = *t
+		return nil
+	})
+	if err != nil {
+		if historyActionID != "" {
+			recordStateSaveFailed(e.Repo, e.logger, historyActionID, "save_tasks_state", err)
+		}
+		return fmt.Errorf("failed to save tasks state: %w", err)
+	}
+	if newStatus != oldStatus {
+		e.emitTaskStateChange(task.TaskID, oldStatus, newStatus)
+	}
+
+	switch verdict {
+	case "":
+		e.logger.Info("verification failed, rerunning",
+			slog.String("task_id", task.TaskID),
+			slog.String("node_id", task.NodeID),
+			slog.Int("failures", failures),
+		)
+	case persistence.NodeVerificationFailed:
+		e.logger.Warn("node verification failed",
+			slog.String("task_id", task.TaskID),
+			slog.String("node_id", task.NodeID),
+			slog.Int("runs", runs),
+		)
+		if e.BacklogStore != nil {
+			item := CreateFailureItem(task.TaskID, inputString(task.Inputs, InputKeyTitle),
+				fmt.Errorf("verification failed %d times", runs), runs)
+			item.Metadata["verification"] = string(verdict)
+			if err := e.BacklogStore.Add(item); err != nil {
+				return fmt.Errorf("failed to add to backlog: %w", err)
+			}
+			if e.EventEmitter != nil {
+				e.EventEmitter.Emit(EventBacklogAdded, item)
+			}
+		}
+	default:
+		e.logger.Info("node verification finished",
+			slog.String("task_id", task.TaskID),
+			slog.String("node_id", task.NodeID),
+			slog.String("verification", string(verdict)),
+			slog.Int("runs", runs),
+		)
+	}
+	e.triggerDependencyResolution()
+	return nil
+}
+
+// saveNodeVerification はノードの検証結果を保存する（passed でノードを verified にする）
+func (e *ExecutionOrchestrator) saveNodeVerification(nodeID, taskID string, verdict persistence.NodeVerificationStatus, runs int, now time.Time) error {
+	verification := persistence.NodeVerification{Status: string(verdict), LastTestTaskID: taskID, LastTestAt: now}
+	note := persistence.NodeNote{At: now, By: "verification", Text: fmt.Sprintf("verification %s after %d run(s)", verdict, runs)}
+	err := e.Repo.State().UpdateNodesRuntime(func(nodesRuntime *persistence.NodesRuntime) error {
+		var nr *persistence.NodeRuntime
+		for i := range nodesRuntime.Nodes {
+			if nodesRuntime.Nodes[i].NodeID == nodeID {
+				nr = &nodesRuntime.Nodes[i]
+				break
+			}
+		}
+		if nr == nil {
+			nodesRuntime.Nodes = append(nodesRuntime.Nodes, persistence.NodeRuntime{
+				NodeID: nodeID,
+				Status: string(persistence.NodeRuntimeStatusImplemented),
+			})
+			nr = &nodesRuntime.Nodes[len(nodesRuntime.Nodes)-1]
+		}
+		nr.Verification = verification
+		nr.Notes = append(nr.Notes, note)
+		if verdict == persistence.NodeVerificationPassed {
+			nr.Status = string(persistence.NodeRuntimeStatusVerified)
+		} else if persistence.NodeRuntimeStatus(nr.Status) == persistence.NodeRuntimeStatusVerified {
+			nr.Status = string(persistence.NodeRuntimeStatusImplemented)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save nodes runtime: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadVerificationConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadVerificationConfig(dir)
+	require.NoError(t, err)
+	assert.False(t, cfg.Verifies("implementation"), "verification is disabled without the file")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, VerificationFileName), []byte(`{"enabled": true}`), 0o644))
+	cfg, err = LoadVerificationConfig(dir)
+	require.NoError(t, err)
+	assert.True(t, cfg.Verifies("implementation"))
+	assert.False(t, cfg.Verifies(TaskKindTest))
+	assert.Equal(t, DefaultVerificationMaxRuns, cfg.MaxRunsOrDefault())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, VerificationFileName), []byte(`{"enabled": true, "maxRuns": -1}`), 0o644))
+	_, err = LoadVerificationConfig(dir)
+	assert.Error(t, err)
+}
+
+func loadNodeRuntime(t *testing.T, repo persistence.WorkspaceRepository, nodeID string) persistence.NodeRuntime {
+	t.Helper()
+	nodesRuntime, err := repo.State().LoadNodesRuntime()
+	require.NoError(t, err)
+	for _, nr := range nodesRuntime.Nodes {
+		if nr.NodeID == nodeID {
+			return nr
+		}
+	}
+	t.Fatalf("node runtime not found: %s", nodeID)
+	return persistence.NodeRuntime{}
+}
+
+func findVerificationTask(t *testing.T, repo persistence.WorkspaceRepository, nodeID string) persistence.TaskState {
+	t.Helper()
+	tasksState, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	for _, ts := range tasksState.Tasks {
+		if ts.NodeID == nodeID && isVerificationTask(&ts) {
+			return ts
+		}
+	}
+	t.Fatalf("verification task not found for node %s", nodeID)
+	return persistence.TaskState{}
+}
+
+func TestExecutionOrchestrator_VerificationFlow(t *testing.T) {
+	repo, queue := setupTestRepo(t)
+	now := time.Now()
+	saveDesign(t, repo, []persistence.NodeDesign{
+		{NodeID: "node-1", Name: "API"},
+		{NodeID: "node-2", Name: "UI", Dependencies: []string{"node-1"}, RequiresVerified: true},
+	})
+	saveState(t, repo, []persistence.TaskState{
+		{TaskID: "task-1", NodeID: "node-1", Kind: "implementation", Status: string(TaskStatusPending), CreatedAt: now,
+			Inputs: map[string]interface{}{InputKeyPoolID: "default"}},
+		{TaskID: "task-2", NodeID: "node-2", Kind: "implementation", Status: string(TaskStatusPending), CreatedAt: now},
+	}, nil)
+
+	var titles []string
+	finished := time.Now()
+	mockExecutor := new(MockExecutor)
+	mockExecutor.On("ExecuteTask", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		titles = append(titles, args.Get(1).(*Task).Title)
+	}).Return(&Attempt{Status: AttemptStatusSucceeded, FinishedAt: &finished}, nil)
+
+	orch := NewExecutionOrchestrator(NewScheduler(repo, queue, nil), mockExecutor, repo, queue, nil, nil, []string{"default"})
+	orch.SetVerification(&VerificationConfig{Enabled: true})
+
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-1", TaskID: "task-1", PoolID: "default"})
+	assert.Equal(t, string(persistence.NodeRuntimeStatusImplemented), loadNodeRuntime(t, repo, "node-1").Status)
+	verification := findVerificationTask(t, repo, "node-1")
+	assert.Equal(t, TaskKindTest, verification.Kind)
+	assert.Contains(t, []string{string(TaskStatusPending), string(TaskStatusReady)}, verification.Status)
+	assert.Equal(t, "task-1", inputString(verification.Inputs, InputKeyVerifiesTaskID))
+
+	// 検証を要求するノードは implemented では実行できない
+	assert.Error(t, orch.Scheduler.ScheduleTask("task-2"))
+
+	orch.processJob(context.Background(), &ipc.Job{ID: "job-2", TaskID: verification.TaskID, PoolID: "default"})
+	require.Len(t, titles, 2)
+	assert.Equal(t, "検証: API", titles[1])
+
+	nr := loadNodeRuntime(t, repo, "node-1")
+	assert.Equal(t, string(persistence.NodeRuntimeStatusVerified), nr.Status)
+	assert.Equal(t, string(persistence.NodeVerificationPassed), nr.Verification.Status)
+	assert.Equal(t, verification.TaskID, nr.Verification.LastTestTaskID)
+	assert.Equal(t, string(TaskStatusSucceeded), loadTaskState(t, repo, verification.TaskID).Status)
+	// 検証タスクの成功では検証タスクを作成しない
+	tasksState, err := repo.State().LoadTasks()
+	require.NoError(t, err)
+	assert.Len(t, tasksState.Tasks, 3)
+
+	assert.Equal(t, string(TaskStatusReady), loadTaskState(t, repo, "task-2").Status, "dependents are scheduled once the node is verified")
+}
+
+func TestExecutionOrchestrator_FinishVerification(t *testing.T) {
+	newOrchestrator := func(t *testing.T) (*ExecutionOrchestrator, persistence.WorkspaceRepository, *BacklogStore) {
+		repo, queue := setupTestRepo(t)
+		backlogStore := NewBacklogStore(repo.BaseDir())
+		orch := NewExecutionOrchestrator(nil, nil, repo, queue, nil, backlogStore, []string{"default"})
+		orch.SetVerification(&VerificationConfig{Enabled: true, MaxRuns: 2})
+		saveState(t, repo, []persistence.TaskState{
+			{TaskID: "verify-1", NodeID: "node-1", Kind: TaskKindTest, Status: string(TaskStatusRunning),
+				Inputs: map[string]interface{}{InputKeyVerifiesTaskID: "task-1", InputKeyTitle: "検証: API"}},
+		}, []persistence.NodeRuntime{
+			{NodeID: "node-1", Status: string(persistence.NodeRuntimeStatusImplemented)},
+		})
+		return orch, repo, backlogStore
+	}
+	run := func(t *testing.T, orch *ExecutionOrchestrator, repo persistence.WorkspaceRepository, passed bool) {
+		task := loadTaskState(t, repo, "verify-1")
+		require.NoError(t, orch.finishVerification(&task, passed, time.Now()))
+	}
+
+	t.Run("pass after a failure is flaky", func(t *testing.T) {
+		orch, repo, _ := newOrchestrator(t)
+		run(t, orch, repo, false)
+		assert.Equal(t, string(TaskStatusPending), loadTaskState(t, repo, "verify-1").Status, "failed verification is rerun")
+		assert.Equal(t, "", loadNodeRuntime(t, repo, "node-1").Verification.Status)
+
+		run(t, orch, repo, true)
+		assert.Equal(t, string(TaskStatusSucceeded), loadTaskState(t, repo, "verify-1").Status)
+		nr := loadNodeRuntime(t, repo, "node-1")
+		assert.Equal(t, string(persistence.NodeVerificationFlaky), nr.Verification.Status)
+		assert.Equal(t, string(persistence.NodeRuntimeStatusImplemented), nr.Status)
+	})
+
+	t.Run("failing every run is failed", func(t *testing.T) {
+		orch, repo, backlogStore := newOrchestrator(t)
+		run(t, orch, repo, false)
+		run(t, orch, repo, false)
+		assert.Equal(t, string(TaskStatusFailed), loadTaskState(t, repo, "verify-1").Status)
+		nr := loadNodeRuntime(t, repo, "node-1")
+		assert.Equal(t, string(persistence.NodeVerificationFailed), nr.Verification.Status)
+		assert.Equal(t, string(persistence.NodeRuntimeStatusImplemented), nr.Status)
+
+		items, err := backlogStore.ListUnresolved()
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "verify-1", items[0].TaskID)
+
+		actions, err := repo.History().ListActions(time.Time{}, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		var verified []string
+		for _, a := range actions {
+			if a.Kind == persistence.ActionNodeVerified {
+				verified = append(verified, fmt.Sprint(a.Payload["status"]))
+			}
+		}
+		assert.Equal(t, []string{string(persistence.NodeVerificationFailed)}, verified)
+	})
+}
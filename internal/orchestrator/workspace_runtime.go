@@ -0,0 +1,109 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/ipc"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/biwakonbu/agent-runner/pkg/config"
+)
+
+// WorkspaceOptions は NewFromWorkspace の設定
+// エントリポイント（IDE・デーモン・CLI のフォアグラウンド実行）ごとに異なる部分だけを指定する。
+type WorkspaceOptions struct {
+	ProjectRoot     string                // タスクを実行するディレクトリ
+	AgentRunnerPath string                // 空なら "agent-runner"（カレントディレクトリにあれば絶対パスにする）
+	ExecutorMode    ExecutorMode          // 空なら subprocess
+	PoolIDs         []string              // 消費する Pool（空なら worker-pools.json の全 Pool）
+	Events          EventEmitter          // nil ならイベントを発行しない
+	ToolingConfig   *config.ToolingConfig // nil なら tooling を使わない
+	LeaderRole      string                // リーダーロックの役割（空なら persistence.LeaderRoleDaemon）
+	StartupFsck     *FsckOptions          // nil なら起動時の検査を行わない
+	// Warn は設定ファイルを読めず既定値で続行する場合に呼ばれる（nil なら警告ログを出す）
+	Warn func(err error)
+}
+
+// WorkspaceOrchestrator は NewFromWorkspace が組み立てた実行系のコンポーネント
+type WorkspaceOrchestrator struct {
+	Executor     *Executor // tooling・Pool の設定先（in-process でも設定は共有される）
+	Scheduler    *Scheduler
+	Orchestrator *ExecutionOrchestrator
+	BacklogStore *BacklogStore
+	Queue        *ipc.FilesystemQueue
+	PoolIDs      []string
+}
+
+// NewFromWorkspace はワークスペースの設定ファイル（worker-pools.json・retry-policies.json・verification.json）を
+// 読み込み、Executor・Scheduler・ExecutionOrchestrator を組み立てる
+// 読み込めない設定は Warn に渡して既定値で続行する。repo の初期化とマイグレーションは呼び出し側で済ませること。
+func NewFromWorkspace(repo persistence.WorkspaceRepository, opts WorkspaceOptions) *WorkspaceOrchestrator {
+	dir := repo.BaseDir()
+	warn := opts.Warn
+	if warn == nil {
+		logger := slog.Default()
+		warn = func(err error) { logger.Warn("workspace config", slog.Any("error", err)) }
+	}
+
+	poolsConfig, err := LoadWorkerPoolsConfig(dir)
+	if err != nil {
+		warn(fmt.Errorf("failed to load worker pools config, using defaults: %w", err))
+	}
+	poolIDs := opts.PoolIDs
+	if len(poolIDs) == 0 {
+		poolIDs = poolsConfig.PoolIDs()
+	}
+
+	executor := NewExecutor(resolveAgentRunnerPath(opts.AgentRunnerPath), opts.ProjectRoot)
+	executor.SetWorkerPools(poolsConfig)
+	if opts.Events != nil {
+		executor.SetEventEmitter(opts.Events)
+	}
+	if opts.ToolingConfig != nil {
+		executor.SetToolingConfig(opts.ToolingConfig)
+	}
+
+	queue := ipc.NewFilesystemQueue(dir)
+	scheduler := NewScheduler(repo, queue, opts.Events)
+	backlogStore := NewBacklogStore(dir)
+	orch := NewExecutionOrchestrator(scheduler, NewTaskExecutor(executor, opts.ExecutorMode), repo, queue, opts.Events, backlogStore, poolIDs)
+	orch.SetWorkerPools(poolsConfig)
+	if opts.LeaderRole != "" {
+		orch.SetLeaderLock(persistence.NewLeaderLock(dir, opts.LeaderRole))
+	}
+	orch.SetStartupFsck(opts.StartupFsck)
+	if retryPolicies, err := LoadRetryPoliciesConfig(dir); err != nil {
+		warn(fmt.Errorf("failed to load retry policies, using defaults: %w", err))
+	} else {
+		orch.SetRetryPolicies(retryPolicies)
+	}
+	if verification, err := LoadVerificationConfig(dir); err != nil {
+		warn(fmt.Errorf("failed to load verification config, verification disabled: %w", err))
+	} else {
+		orch.SetVerification(verification)
+	}
+
+	return &WorkspaceOrchestrator{
+		Executor:     executor,
+		Scheduler:    scheduler,
+		Orchestrator: orch,
+		BacklogStore: backlogStore,
+		Queue:        queue,
+		PoolIDs:      poolIDs,
+	}
+}
+
+// resolveAgentRunnerPath は存在する相対パスを絶対パスにする（見つからなければ PATH から探させるためそのまま返す）
+func resolveAgentRunnerPath(path string) string {
+	if path == "" {
+		path = "agent-runner"
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if _, statErr := os.Stat(abs); statErr == nil {
+			return abs
+		}
+	}
+	return path
+}
@@ -0,0 +1,55 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromWorkspace_LoadsWorkspaceConfig(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	writeWorkerPools(t, repo.BaseDir(), `{
+  "pools": [{"id": "default"}, {"id": "gpu", "workerImage": "example/gpu:1", "concurrency": 3}],
+  "routes": [{"poolId": "gpu", "kinds": ["test"]}]
+}`)
+
+	var warnings []error
+	execution := NewFromWorkspace(repo, WorkspaceOptions{
+		ProjectRoot:  t.TempDir(),
+		ExecutorMode: ExecutorModeInProcess,
+		LeaderRole:   persistence.LeaderRoleIDE,
+		Warn:         func(err error) { warnings = append(warnings, err) },
+	})
+
+	assert.Empty(t, warnings)
+	assert.Equal(t, []string{"default", "gpu"}, execution.PoolIDs)
+	assert.Equal(t, execution.PoolIDs, execution.Orchestrator.PoolIDs)
+	assert.Equal(t, 3, execution.Orchestrator.poolConcurrency["gpu"])
+	assert.Equal(t, "gpu", execution.Scheduler.Router.Route(&persistence.TaskState{Kind: "test"}, nil))
+
+	inProcess, ok := execution.Orchestrator.Executor.(*InProcessExecutor)
+	require.True(t, ok)
+	assert.Same(t, execution.Executor, inProcess.Executor)
+	assert.Equal(t, persistence.LeaderRoleIDE, execution.Orchestrator.Leader.Info().Role)
+	assert.Same(t, execution.BacklogStore, execution.Orchestrator.BacklogStore)
+}
+
+func TestNewFromWorkspace_WarnsAndFallsBackToDefaults(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	writeWorkerPools(t, repo.BaseDir(), `{"pools": [{"id": "gpu"}], "defaultPoolId": "cpu"}`)
+
+	var warnings []error
+	execution := NewFromWorkspace(repo, WorkspaceOptions{
+		ProjectRoot: t.TempDir(),
+		PoolIDs:     []string{"codegen"},
+		Warn:        func(err error) { warnings = append(warnings, err) },
+	})
+
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].Error(), "failed to load worker pools config, using defaults")
+	assert.Equal(t, []string{"codegen"}, execution.PoolIDs, "explicit pools override the config")
+	assert.Same(t, execution.Executor, execution.Orchestrator.Executor, "subprocess is the default mode")
+	assert.Equal(t, persistence.LeaderRoleDaemon, execution.Orchestrator.Leader.Info().Role)
+}
@@ -181,6 +181,10 @@ func (e *Executor) RunWorker(ctx context.Context, call meta.WorkerCall, env map[
 		Summary:    "Worker executed",
 		Error:      execErr,
 	}
+	usage := parseUsage(output, call.Model)
+	res.InputTokens = usage.InputTokens
+	res.OutputTokens = usage.OutputTokens
+	res.CostUSD = usage.CostUSD
 
 	// Capture artifacts if execution was successful (or even if failed, we might want to see changes)
 	// QH-008: Track modified files
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/biwakonbu/agent-runner/internal/agenttools"
+)
+
+// workerUsage は Worker CLI が出力したトークン使用量とコスト
+type workerUsage struct {
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+}
+
+// usageLine は JSON 出力の 1 行のうち使用量に関わる部分
+// Codex CLI（--json）は turn.completed の usage、Claude Code（--output-format json）は
+// result の usage と total_cost_usd で報告する。
+type usageLine struct {
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	TotalCostUSD *float64 `json:"total_cost_usd"`
+}
+
+// parseUsage は Worker CLI の出力から使用量を集計する
+// JSON でない行や使用量を含まない行は無視する。CLI がコストを報告しなかった場合は
+// モデルの価格が分かれば見積もる。
+func parseUsage(output, model string) workerUsage {
+	var usage workerUsage
+	costReported := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var parsed usageLine
+		if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+			continue
+		}
+		if parsed.Usage != nil {
+			usage.InputTokens += parsed.Usage.InputTokens
+			usage.OutputTokens += parsed.Usage.OutputTokens
+		}
+		if parsed.TotalCostUSD != nil {
+			usage.CostUSD += *parsed.TotalCostUSD
+			costReported = true
+		}
+	}
+	if !costReported {
+		if cost, ok := agenttools.EstimateCostUSD(model, usage.InputTokens, usage.OutputTokens); ok {
+			usage.CostUSD = cost
+		}
+	}
+	return usage
+}
@@ -0,0 +1,36 @@
+package worker
+
+import "testing"
+
+func TestParseUsage_CodexJSON(t *testing.T) {
+	output := `{"type":"thread.started","thread_id":"t1"}
+not json
+{"type":"turn.completed","usage":{"input_tokens":1200,"cached_input_tokens":800,"output_tokens":150}}
+{"type":"turn.completed","usage":{"input_tokens":300,"output_tokens":50}}`
+
+	usage := parseUsage(output, "unknown-model")
+	if usage.InputTokens != 1500 || usage.OutputTokens != 200 {
+		t.Fatalf("tokens = %d/%d, want 1500/200", usage.InputTokens, usage.OutputTokens)
+	}
+	if usage.CostUSD != 0 {
+		t.Fatalf("CostUSD = %v, want 0 for a model without pricing", usage.CostUSD)
+	}
+}
+
+func TestParseUsage_ClaudeResult(t *testing.T) {
+	output := `{"type":"result","subtype":"success","total_cost_usd":0.0425,"usage":{"input_tokens":20,"output_tokens":700}}`
+
+	usage := parseUsage(output, "")
+	if usage.InputTokens != 20 || usage.OutputTokens != 700 {
+		t.Fatalf("tokens = %d/%d, want 20/700", usage.InputTokens, usage.OutputTokens)
+	}
+	if usage.CostUSD != 0.0425 {
+		t.Fatalf("CostUSD = %v, want 0.0425", usage.CostUSD)
+	}
+}
+
+func TestParseUsage_PlainText(t *testing.T) {
+	if usage := parseUsage("done\n", "gpt-5.2-codex"); usage != (workerUsage{}) {
+		t.Fatalf("usage = %+v, want zero", usage)
+	}
+}
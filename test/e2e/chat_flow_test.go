@@ -10,6 +10,7 @@ import (
 	"github.com/biwakonbu/agent-runner/internal/ide"
 	"github.com/biwakonbu/agent-runner/internal/meta"
 	"github.com/biwakonbu/agent-runner/internal/orchestrator"
+	"github.com/biwakonbu/agent-runner/internal/orchestrator/persistence"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -54,13 +55,13 @@ func TestChatFlow(t *testing.T) {
 	wsDir := wsStore.GetWorkspaceDir(wsID)
 
 	// 2. Initialize Components
-	taskStore := orchestrator.NewTaskStore(wsDir)
+	repo := persistence.NewWorkspaceRepository(wsDir)
 	sessionStore := chat.NewChatSessionStore(wsDir)
 	metaClient := meta.NewMockClient()
 	eventEmitter := &MockEventEmitter{}
 
 	// Initialize ChatHandler
-	handler := chat.NewHandler(metaClient, taskStore, sessionStore, wsID, projectRoot, nil, eventEmitter)
+	handler := chat.NewHandler(metaClient, sessionStore, wsID, projectRoot, repo, eventEmitter)
 
 	// 3. Create Session
 	ctx := context.Background()
@@ -102,12 +103,12 @@ func TestChatFlow(t *testing.T) {
 	require.Len(t, task2.Dependencies, 1)
 	assert.Equal(t, task1.ID, task2.Dependencies[0])
 
-	// 6. Verify Persistence in TaskStore
-	savedTask1, err := taskStore.LoadTask(task1.ID)
+	// 6. Verify Persistence in state/design
+	savedTask1, err := orchestrator.FindTaskView(repo, task1.ID)
 	require.NoError(t, err)
 	assert.Equal(t, task1.Title, savedTask1.Title)
 
-	savedTask2, err := taskStore.LoadTask(task2.ID)
+	savedTask2, err := orchestrator.FindTaskView(repo, task2.ID)
 	require.NoError(t, err)
 	assert.Equal(t, task2.Title, savedTask2.Title)
 
@@ -61,12 +61,11 @@ func TestGoldenPass_Backend(t *testing.T) {
 	require.NoError(t, repo.Init())
 
 	queue := ipc.NewFilesystemQueue(wsDir)
-	taskStore := orchestrator.NewTaskStore(wsDir)
 	sessionStore := chat.NewChatSessionStore(wsDir)
 
 	// 2. Chat -> Decompose -> persist design/state/tasks
 	metaClient := meta.NewMockClient()
-	handler := chat.NewHandler(metaClient, taskStore, sessionStore, wsID, projectRoot, repo, nil)
+	handler := chat.NewHandler(metaClient, sessionStore, wsID, projectRoot, repo, nil)
 
 	ctx := context.Background()
 	session, err := handler.CreateSession(ctx)
@@ -156,18 +155,18 @@ func TestGoldenPass_Backend(t *testing.T) {
 	require.Equal(t, implTask.ID, exec.Calls[1])
 	exec.mu.Unlock()
 
-	// 7. Verify legacy TaskStore was synchronized
-	legacyConcept, err := taskStore.LoadTask(conceptTask.ID)
-	require.NoError(t, err)
-	require.Equal(t, orchestrator.TaskStatusSucceeded, legacyConcept.Status)
-	require.Equal(t, 1, legacyConcept.AttemptCount)
-	require.NotNil(t, legacyConcept.StartedAt)
-	require.NotNil(t, legacyConcept.DoneAt)
-
-	legacyImpl, err := taskStore.LoadTask(implTask.ID)
-	require.NoError(t, err)
-	require.Equal(t, orchestrator.TaskStatusSucceeded, legacyImpl.Status)
-	require.Equal(t, 1, legacyImpl.AttemptCount)
-	require.NotNil(t, legacyImpl.StartedAt)
-	require.NotNil(t, legacyImpl.DoneAt)
+	// 7. Verify task views and attempts recorded in state/history
+	for _, id := range []string{conceptTask.ID, implTask.ID} {
+		view, err := orchestrator.FindTaskView(repo, id)
+		require.NoError(t, err)
+		require.Equal(t, orchestrator.TaskStatusSucceeded, view.Status)
+		require.Equal(t, 1, view.AttemptCount)
+		require.NotNil(t, view.StartedAt)
+		require.NotNil(t, view.DoneAt)
+
+		attempts, err := orchestrator.ListTaskAttempts(repo, id)
+		require.NoError(t, err)
+		require.Len(t, attempts, 1)
+		require.Equal(t, orchestrator.AttemptStatusSucceeded, attempts[0].Status)
+	}
 }
@@ -71,12 +71,11 @@ func TestTaskBuilder_Golden(t *testing.T) {
 	wsDir := wsStore.GetWorkspaceDir(wsID)
 
 	// 2. Setup Components
-	taskStore := orchestrator.NewTaskStore(wsDir)
 	sessionStore := chat.NewChatSessionStore(wsDir)
 	metaClient := &MockMetaClient{}
 
 	// Create a dummy event emitter (can be nil or mock if needed)
-	handler := chat.NewHandler(metaClient, taskStore, sessionStore, wsID, projectRoot, nil, nil)
+	handler := chat.NewHandler(metaClient, sessionStore, wsID, projectRoot, nil, nil)
 
 	// 3. Setup Executor with Capture Script
 	captureScriptPath := filepath.Join(tempDir, "capture_runner.sh")